@@ -1,20 +1,66 @@
 package main
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata"
 
+	"wordpress-go-proxy/internal/access"
+	"wordpress-go-proxy/internal/analytics"
 	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/audit"
+	"wordpress-go-proxy/internal/auth"
+	"wordpress-go-proxy/internal/breaker"
+	"wordpress-go-proxy/internal/cache"
 	"wordpress-go-proxy/internal/config"
+	"wordpress-go-proxy/internal/embeds"
+	"wordpress-go-proxy/internal/export"
+	"wordpress-go-proxy/internal/feedback"
+	"wordpress-go-proxy/internal/filter"
+	"wordpress-go-proxy/internal/flags"
 	"wordpress-go-proxy/internal/handlers"
 	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/internal/ratelimit"
+	"wordpress-go-proxy/internal/redirects"
+	"wordpress-go-proxy/internal/router"
+	"wordpress-go-proxy/internal/sanitize"
+	"wordpress-go-proxy/internal/secrets"
+	"wordpress-go-proxy/internal/server"
+	"wordpress-go-proxy/internal/theme"
+	"wordpress-go-proxy/internal/tracing"
+	"wordpress-go-proxy/internal/transform"
+	"wordpress-go-proxy/internal/warm"
+	"wordpress-go-proxy/pkg/models"
 
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	_ "golang.org/x/crypto/x509roots/fallback"
 )
 
 func main() {
+	exportFlag := flag.String("export", "", "write a static copy of the site to a directory or s3://bucket/prefix URL and exit")
+	auditFlag := flag.String("audit", "", "write a report of broken internal links to a .json or .csv file, or - for standard output, and exit")
+	flag.Parse()
 
 	// Load configuration
 	cfg, err := config.Load()
@@ -22,23 +68,825 @@ func main() {
 		log.Fatal("Error loading config: ", err)
 	}
 
-	// Create WordPress client.  This will fetch menus asynchronously.
-	wordPressClient := api.NewWordPressClient(
-		cfg.WordPressBaseURL,
-		cfg.WordPressUsername,
-		cfg.WordPressPassword,
-		cfg.WordPressMenuIdEn,
-		cfg.WordPressMenuIdFr)
+	// Resolve secrets given as Secrets Manager or SSM Parameter Store ARNs
+	var secretStore *secrets.Store
+	if len(cfg.SecretRefs) > 0 {
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+		if err != nil {
+			log.Fatal("Error loading AWS config: ", err)
+		}
+		secretStore = secrets.NewStore(map[string]secrets.Fetcher{
+			secrets.ServiceSecretsManager: secrets.SecretsManagerFetcher(secretsmanager.NewFromConfig(awsCfg)),
+			secrets.ServiceSSM:            secrets.SSMFetcher(ssm.NewFromConfig(awsCfg)),
+		})
+		if err := cfg.ResolveSecrets(context.Background(), secretStore.Resolve); err != nil {
+			log.Fatal("Error resolving secrets: ", err)
+		}
+	}
+	log.Printf("Config: %s", cfg)
+
+	// Trace requests and WordPress calls if an OTLP endpoint is set, or to
+	// X-Ray in Lambda.  Trace headers are passed on to WordPress either way.
+	otel.SetTextMapPropagator(tracing.Propagator())
+	var exporters []sdktrace.SpanExporter
+	if cfg.TracingEndpoint != "" {
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			log.Fatal("Error creating trace exporter: ", err)
+		}
+		exporters = append(exporters, exporter)
+	}
+	if cfg.XRayEnabled && cfg.RunMode == config.RunModeLambda {
+		exporter, err := tracing.NewXRayExporter(cfg.XRayDaemonAddress)
+		if err != nil {
+			log.Fatal("Error creating X-Ray exporter: ", err)
+		}
+		exporters = append(exporters, exporter)
+	}
+	var tracerProvider *sdktrace.TracerProvider
+	if len(exporters) > 0 {
+		tracerProvider = tracing.Setup(tracing.Options{
+			ServiceName: cfg.TracingServiceName,
+			SampleRatio: cfg.TracingSampleRatio,
+			Exporters:   exporters,
+		})
+	}
+
+	// Serve the configured languages
+	models.Languages = cfg.Languages
+	menuIds := make(map[string]string, len(cfg.Languages))
+	for _, lang := range cfg.Languages {
+		menuIds[lang.Code] = lang.MenuID
+	}
+
+	// Persist cached pages and menus if a backend is configured, so they
+	// survive restarts or are shared between instances
+	var cacheBackend cache.Backend
+	switch cfg.CacheBackend {
+	case config.CacheBackendDisk:
+		fileBackend, err := cache.NewFileBackend(cfg.CacheDir)
+		if err != nil {
+			log.Fatal("Error creating cache directory: ", err)
+		}
+		cacheBackend = fileBackend
+	case config.CacheBackendDynamoDB:
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+		if err != nil {
+			log.Fatal("Error loading AWS config: ", err)
+		}
+		cacheBackend = cache.NewDynamoDBBackend(dynamodb.NewFromConfig(awsCfg), cfg.CacheDynamoDBTable)
+	case config.CacheBackendRedis:
+		opts, err := redis.ParseURL(cfg.CacheRedisURL)
+		if err != nil {
+			log.Fatal("Error parsing CACHE_REDIS_URL: ", err)
+		}
+		cacheBackend = cache.NewRedisBackend(redis.NewClient(opts))
+	}
+
+	// Create WordPress client.  Its menus are loaded from the cache
+	// backend, or fetched once it is set up, and refreshed in the
+	// background once they are older than the interval.
+	wordPressClient := &api.WordPressClient{
+		BaseURL: cfg.WordPressBaseURL,
+		MenuIds: menuIds,
+		Timeouts: api.Timeouts{
+			Page: cfg.WordPressPageTimeout,
+			Menu: cfg.WordPressMenuTimeout,
+		},
+		MenuStore: cacheBackend,
+	}
+	wordPressClient.SetCredentials(cfg.WordPressUsername, cfg.WordPressPassword)
+	if cfg.WordPressHeaders != "" {
+		wordPressClient.SetHeaders(wordPressHeaders(cfg))
+	}
+	wordPressClient.PageCache = cache.NewShared[*models.WordPressPage](cfg.PageCache, cacheBackend, "page/")
+	wordPressClient.ChildCache = cache.NewShared[[]models.WordPressPage](cfg.PageCache, cacheBackend, "children/")
+	wordPressClient.AlertCache = cache.NewShared[[]models.Alert](cfg.AlertCache, cacheBackend, "alerts/")
+	wordPressClient.AlertsRoute = cfg.AlertsRoute
+	wordPressClient.AlertsCategory = cfg.AlertsCategory
+	wordPressClient.MenuRefreshInterval = cfg.MenuRefreshInterval
+	wordPressClient.AuthenticateContent = cfg.WordPressAuthContent
+	wordPressClient.PageSize = cfg.PageSize
+	// Present a client certificate to a WordPress origin requiring mutual
+	// TLS
+	var clientCert *api.ClientCertificate
+	transport := http.DefaultTransport
+	if cfg.WordPressTLSCert != "" || cfg.WordPressTLSCA != "" {
+		clientCert = &api.ClientCertificate{}
+		transport = mutualTLSTransport(cfg, clientCert)
+	}
+	// Count how WordPress requests get their connections, to diagnose slow
+	// requests
+	connStats := &tracing.ConnStats{}
+	transport = tracing.Transport(connStats.Transport(transport))
+	if cfg.ConnStatsInterval > 0 {
+		go connStats.Log(cfg.ConnStatsInterval)
+	}
+	// Stop requesting WordPress while it is failing, if the site can fail
+	// over to its static copy meanwhile
+	var originBreaker *breaker.Breaker
+	if cfg.FailoverEnabled {
+		originBreaker = breaker.New(cfg.BreakerThreshold, cfg.BreakerCooldown)
+		transport = originBreaker.Transport(transport)
+	}
+	wordPressClient.HTTPClient = &http.Client{Transport: transport}
+	if err := wordPressClient.InitMenus(context.Background()); err != nil {
+		log.Fatal("Error fetching menus: ", err)
+	}
+	if secretStore != nil && cfg.SecretRefreshInterval > 0 {
+		go rotateCredentials(cfg, secretStore, wordPressClient, clientCert)
+	}
+
+	// Site names, taglines and the timezone that are not configured are
+	// taken from the WordPress site settings
+	if cfg.NeedsSiteSettings() {
+		if err := cfg.SyncSiteSettings(context.Background(), wordPressClient.FetchSiteSettings); err != nil {
+			log.Fatal("Error syncing site settings: ", err)
+		}
+	}
+	// Home pages given by ID are served by their slug
+	if err := cfg.SyncHomeSlugs(context.Background(), wordPressClient.FetchPageSlug); err != nil {
+		log.Fatal("Error syncing home pages: ", err)
+	}
+	models.Languages = cfg.Languages
+	if cfg.Timezone != nil {
+		models.Timezone = cfg.Timezone
+	}
 
-	siteNames := map[string]string{
-		"en": cfg.SiteNameEn,
-		"fr": cfg.SiteNameFr,
+	siteNames := cfg.Languages.SiteNames()
+
+	// Render pages with the configured template theme
+	handlers.Theme = theme.New("templates", cfg.Theme)
+	handlers.BaseURL = cfg.BaseURL
+	handlers.TrustForwardedHost = cfg.TrustForwardedHost
+	handlers.MinifyHTML = cfg.MinifyHTML
+	handlers.NoIndex = cfg.NoIndex
+	models.NoIndexField = cfg.NoIndexField
+	handlers.Assets = models.Assets{
+		Stylesheets:         cfg.AssetStylesheets,
+		Scripts:             cfg.AssetScripts,
+		AnalyticsID:         cfg.AnalyticsID,
+		DarkStylesheets:     cfg.AssetStylesheetsDark,
+		ContrastStylesheets: cfg.AssetStylesheetsContrast,
+	}
+	handlers.ColorSchemes = cfg.ColorSchemes
+	if cfg.AlertsRoute != "" {
+		handlers.FetchAlerts = wordPressClient.FetchAlerts
+	}
+	if cfg.AnalyticsProvider != "" {
+		handlers.Analytics = models.Analytics{Provider: cfg.AnalyticsProvider, ID: cfg.AnalyticsID}
+	}
+	handlers.Organization = models.Organization{
+		URL:    cfg.OrganizationURL,
+		Logo:   cfg.OrganizationLogo,
+		SameAs: cfg.OrganizationSameAs,
 	}
 
 	// Set up routes
-	http.Handle("/static/", http.StripPrefix("/static/", handlers.NewStaticHandler("static")))
-	http.Handle("/", middleware.SecurityHeaders(handlers.NewPageHandler(siteNames, wordPressClient)))
+	healthHandler := handlers.NewHealthHandler(wordPressClient)
+	http.HandleFunc("/healthz", healthHandler.Liveness)
+	http.HandleFunc("/readyz", healthHandler.Readiness)
+	mediaHandler := middleware.SecurityHeaders(handlers.NewMediaHandler(wordPressClient))
+	http.Handle("/wp-content/uploads/", mediaHandler)
+	http.Handle("/media/", mediaHandler)
+	staticHandler := handlers.NewStaticHandler("static")
+	staticHandler.DirectoryListings = cfg.StaticDirectoryListings
+	http.Handle("/static/", http.StripPrefix("/static/", staticHandler))
+	var securityTxt *handlers.SecurityTxt
+	if len(cfg.SecurityContacts) > 0 {
+		securityTxt = &handlers.SecurityTxt{
+			Contacts:  cfg.SecurityContacts,
+			Expires:   cfg.SecurityExpires,
+			Policy:    cfg.SecurityPolicy,
+			Languages: cfg.Languages.Codes(),
+		}
+		if cfg.BaseURL != "" {
+			securityTxt.Canonical = cfg.BaseURL + handlers.WellKnownPath + "security.txt"
+		}
+	}
+	http.Handle(handlers.WellKnownPath, middleware.SecurityHeaders(handlers.NewWellKnownHandler(securityTxt, cfg.ChangePasswordURL, cfg.WellKnownDir)))
+	if cfg.SiteIcon != "" {
+		file := cfg.SiteIcon
+		if file == config.SiteIconWordPress {
+			file = ""
+		}
+		iconHandler := middleware.SecurityHeaders(handlers.NewIconHandler(wordPressClient, file))
+		for _, path := range handlers.IconPaths() {
+			http.Handle(path, iconHandler)
+		}
+		handlers.SiteIcons = true
+	}
+	if len(cfg.ImageWidths) > 0 {
+		http.Handle("/img", middleware.SecurityHeaders(handlers.NewImageHandler(wordPressClient, cfg.ImageWidths, cfg.ImageCache)))
+	}
+
+	var signer *auth.Signer
+	if cfg.AuthSecret != "" {
+		signer = auth.NewSigner([]byte(cfg.AuthSecret), cfg.AuthTokenTTL, cfg.AuthClockSkew)
+	}
+
+	// HTML responses also get the Content-Security-Policy, which allows the
+	// analytics provider's sources
+	policy := cfg.CSPPolicy
+	if provider, ok := analytics.LookupProvider(cfg.AnalyticsProvider); ok {
+		policy = provider.Policy(policy)
+	}
+	secureHTML := middleware.NewSecurityHeaders(middleware.CSP{
+		Policy:     policy,
+		ReportOnly: cfg.CSPReportOnly,
+		ReportURI:  cfg.CSPReportURI,
+		ReportTo:   cfg.CSPReportTo,
+	})
+
+	// Gate routes behind feature flags, which can be toggled at runtime
+	// through AppConfig
+	var flagLoader flags.Loader
+	if cfg.FlagsURL != "" {
+		flagLoader = flags.AppConfigLoader(&http.Client{Timeout: 5 * time.Second}, cfg.FlagsURL)
+	}
+	flagStore := flags.NewStore(cfg.FeatureFlags(), flagLoader, cfg.FlagsRefreshInterval)
+	if err := flagStore.Refresh(context.Background()); err != nil {
+		log.Printf("Error loading feature flags: %v", err)
+	}
+
+	// Content routes are matched by pattern, with the most specific
+	// pattern winning, so pages are served from every path not claimed
+	// by search, feeds or post types
+	routes := router.New()
+
+	searchHandler := middleware.Feature(flagStore, flags.Search)(secureHTML(handlers.NewSearchHandler(siteNames, wordPressClient)))
+	for _, lang := range cfg.Languages {
+		routes.Handle(lang.SearchPath, searchHandler)
+	}
+
+	feedHandler := handlers.NewFeedHandler(siteNames, wordPressClient, cfg.FeedCache)
+	feedHandler.SiteURL = cfg.BaseURL
+	for _, pattern := range handlers.FeedPatterns {
+		routes.HandleLocalized(pattern, middleware.Feature(flagStore, flags.Feeds)(feedHandler))
+	}
+
+	// Content iframes of embed providers are allowed through sanitization
+	// and replaced according to the provider's mode
+	embedRewriter := embeds.New(cfg.EmbedModes, cfg.EmbedDefaultMode)
+	embedHosts := append(cfg.EmbedHosts, embedRewriter.Hosts()...)
+
+	// Remove the configured WordPress markup from page and post content and
+	// mark links that leave the site
+	cruft, err := transform.NewCruft(cfg.ContentRemove)
+	if err != nil {
+		log.Fatal("Error parsing content selectors: ", err)
+	}
+	contentTransforms := transform.Default(wordPressClient.BaseURL).With(cruft).With(externalLinks(cfg))
+
+	// Forms posted to the proxy must carry the visitor's CSRF token
+	csrf := middleware.CSRF([]byte(cfg.AuthSecret))
+
+	// Serve each custom post type's posts and archive under its own path
+	for _, postType := range cfg.CustomTypes {
+		postTypeHandler := handlers.NewPostTypeHandler(postType, siteNames, wordPressClient)
+		postTypeHandler.SiteURL = cfg.BaseURL
+		postTypeHandler.ImageWidths = cfg.ImageWidths
+		postTypeHandler.MissingTranslation = cfg.MissingTranslation
+		postTypeHandler.Embeds = embedRewriter
+		postTypeHandler.Transforms = contentTransforms
+		postTypeHandler.Comments = cfg.CommentsEnabled
+		postTypeHandler.Feedback = cfg.FeedbackSink != ""
+		if cfg.SanitizeContent {
+			postTypeHandler.Sanitizer = sanitize.New(embedHosts)
+		}
+		// Posts embed the CSRF token in their comment form
+		var posts http.Handler = postTypeHandler
+		if cfg.CommentsEnabled {
+			posts = csrf(posts)
+		}
+		routes.HandleLocalized("/"+postType.Name+"/{path...}", secureHTML(posts))
+	}
+
+	// Comments are forwarded to WordPress, limited per client address
+	if cfg.CommentsEnabled {
+		commentHandler := handlers.NewCommentHandler(wordPressClient, nil)
+		if cfg.CommentRateWindow > 0 {
+			commentHandler.Limiter = ratelimit.New(cfg.CommentRateLimit, cfg.CommentRateWindow)
+		}
+		commentHandler.TrustedProxies = cfg.FilterTrustedProxies
+		http.Handle(handlers.CommentPath, middleware.SecurityHeaders(middleware.LimitBody(handlers.MaxCommentForm)(csrf(commentHandler))))
+	}
+
+	// Answers to whether a page was helpful are recorded to the configured
+	// sink, limited per client address
+	if cfg.FeedbackSink != "" {
+		var feedbackSink feedback.Sink
+		switch cfg.FeedbackSink {
+		case config.FeedbackSinkEMF:
+			feedbackSink = feedback.NewEMFSink(os.Stdout, cfg.FeedbackNamespace)
+		case config.FeedbackSinkDynamoDB:
+			awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+			if err != nil {
+				log.Fatal("Error loading AWS config: ", err)
+			}
+			feedbackSink = feedback.NewDynamoDBSink(dynamodb.NewFromConfig(awsCfg), cfg.FeedbackDynamoDBTable)
+		case config.FeedbackSinkWordPress:
+			feedbackSink = feedback.NewWordPressSink(wordPressClient, cfg.FeedbackRoute)
+		}
+		feedbackHandler := handlers.NewFeedbackHandler(feedbackSink, nil)
+		if cfg.FeedbackRateWindow > 0 {
+			feedbackHandler.Limiter = ratelimit.New(cfg.FeedbackRateLimit, cfg.FeedbackRateWindow)
+		}
+		feedbackHandler.TrustedProxies = cfg.FilterTrustedProxies
+		http.Handle(handlers.FeedbackPath, middleware.SecurityHeaders(feedbackHandler))
+	}
+
+	// Visitors choose the color scheme pages are rendered in, remembered
+	// in a cookie
+	if cfg.ColorSchemes {
+		http.Handle(handlers.ColorSchemePath, middleware.SecurityHeaders(handlers.NewColorSchemeHandler(cfg.ColorSchemeCookie)))
+	}
+
+	// Forms are rendered by the proxy and forwarded to the forms plugin,
+	// with tokens signed by their own signer so they can outlive the
+	// tokens issued to the WordPress plugin
+	if len(cfg.Forms) > 0 {
+		formSigner := auth.NewSigner([]byte(cfg.AuthSecret), cfg.FormTokenTTL, cfg.AuthClockSkew)
+		formHandler := secureHTML(csrf(handlers.NewFormHandler(cfg.Forms, cfg.FormRoute, siteNames, wordPressClient, formSigner)))
+		for _, pattern := range handlers.FormPatterns {
+			routes.HandleLocalized(pattern, formHandler)
+		}
+	}
+
+	pageHandler := handlers.NewPageHandler(siteNames, wordPressClient)
+	pageHandler.SiteURL = cfg.BaseURL
+	pageHandler.ImageWidths = cfg.ImageWidths
+	pageHandler.MissingTranslation = cfg.MissingTranslation
+	pageHandler.Embeds = embedRewriter
+	pageHandler.Transforms = contentTransforms
+	pageHandler.PrefetchTranslations = cfg.PageCache.TTL > 0
+	pageHandler.Feedback = cfg.FeedbackSink != ""
+	// Passwords of protected pages are limited per client address
+	if cfg.PasswordRateWindow > 0 {
+		pageHandler.PasswordLimiter = ratelimit.New(cfg.PasswordRateLimit, cfg.PasswordRateWindow)
+	}
+	pageHandler.TrustedProxies = cfg.FilterTrustedProxies
+	// Apps asking for JSON are sent pages as the page API sends them
+	pageHandler.NegotiateJSON = func() bool { return flagStore.Enabled(flags.PageAPI) }
+	if cfg.SanitizeContent {
+		pageHandler.Sanitizer = sanitize.New(embedHosts)
+	}
+	// Redirect non-canonical page paths, after any redirects managed in
+	// WordPress have been applied
+	var roots []string
+	for _, lang := range cfg.Languages {
+		roots = append(roots, models.Languages.Prefix(lang.Code))
+	}
+	pages := middleware.Canonicalize(middleware.CanonicalPaths{
+		TrailingSlash: cfg.TrailingSlash,
+		Lowercase:     cfg.LowercasePaths,
+		Roots:         roots,
+	})(pageHandler)
+	// Permalinks of the WordPress site, from before it was proxied, are
+	// redirected to the paths their content is now served at
+	if cfg.PermalinkRedirects {
+		pages = middleware.Permalinks(api.NewPermalinks(wordPressClient, cfg.CustomTypes))(pages)
+	}
+	if cfg.RedirectsEnabled {
+		redirectStore := redirects.NewStore(wordPressClient.FetchRedirects, cfg.WordPressBaseURL, cfg.RedirectRefreshInterval)
+		if err := redirectStore.Refresh(context.Background()); err != nil {
+			log.Printf("Error fetching redirects: %v", err)
+		}
+		pages = middleware.Redirects(redirectStore)(pages)
+	}
+	routes.HandleLocalized("/{slug...}", secureHTML(pages))
+
+	// Pages are also served as JSON for apps, built like the HTML pages,
+	// and content can be queried with GraphQL
+	http.Handle(handlers.PageAPIPath, middleware.Feature(flagStore, flags.PageAPI)(middleware.SecurityHeaders(handlers.NewPageAPIHandler(pageHandler))))
+	http.Handle(handlers.GraphQLPath, middleware.Feature(flagStore, flags.GraphQL)(middleware.SecurityHeaders(handlers.NewGraphQLHandler(pageHandler, cfg.CustomTypes))))
+
+	// Visitors are assigned a variant of each experiment, which is not
+	// done for the cache warmer's requests since it uses the routes directly
+	var content http.Handler = routes
+	if len(cfg.Experiments) > 0 {
+		content = middleware.Experiments(cfg.Experiments)(content)
+	}
+	// Analytics only loads for visitors whose cookie grants consent
+	if cfg.AnalyticsProvider != "" {
+		content = middleware.Consent(cfg.AnalyticsConsentCookie)(content)
+	}
+	// Pages are rendered in the color scheme visitors chose or their
+	// browser prefers
+	if cfg.ColorSchemes {
+		content = middleware.ColorScheme(cfg.ColorSchemeCookie)(content)
+	}
+	// Pages are served from the last export while WordPress is down, with
+	// the security headers and a fresh nonce like live pages
+	if originBreaker != nil {
+		content = middleware.Failover(originBreaker, export.NewSnapshot(exportStore(cfg.ExportTarget), secureHTML))(content)
+	}
+	http.Handle("/", content)
+
+	// Write a static copy of the site to fail over to, on request through
+	// /internal/export, or once and exit when run with -export
+	if *exportFlag != "" || cfg.ExportTarget != "" {
+		target := cfg.ExportTarget
+		if *exportFlag != "" {
+			target = *exportFlag
+		}
+		exporter := export.New(routes, wordPressClient, cfg.CustomTypes, cfg.WordPressBaseURL, exportStore(target))
+		exporter.Concurrency = cfg.WarmConcurrency
+		exporter.StaticDir = "static"
+		if *exportFlag != "" {
+			exportSite(wordPressClient, exporter)
+			return
+		}
+		if signer != nil {
+			http.Handle("/internal/export", handlers.NewExportHandler(exporter, signer))
+		}
+	}
 
-	// Start Lambda proxy handler
-	lambda.Start(httpadapter.NewV2(http.DefaultServeMux).ProxyWithContext)
+	// Check the internal links of every page, listed as they are for
+	// exports, on request through /internal/audit, or once and exit when
+	// run with -audit
+	auditor := audit.New(http.DefaultServeMux, export.New(routes, wordPressClient, cfg.CustomTypes, cfg.WordPressBaseURL, nil), warmHosts(cfg))
+	auditor.Concurrency = cfg.WarmConcurrency
+	if *auditFlag != "" {
+		auditSite(wordPressClient, auditor, *auditFlag)
+		return
+	}
+	if signer != nil {
+		http.Handle("/internal/audit", handlers.NewAuditHandler(auditor, signer))
+	}
+
+	// Warm the caches by requesting the pages linked from the menus, so
+	// the first visitors after a deploy are served from the cache
+	warmer := warm.New(routes, wordPressClient, warmHosts(cfg))
+	warmer.Concurrency = cfg.WarmConcurrency
+	warmer.Prefetch = wordPressClient.PrefetchPaths
+	if signer != nil {
+		http.Handle("/internal/warm", handlers.NewWarmHandler(warmer, signer))
+	}
+	if cfg.WarmOnStartup || (cfg.WarmInterval > 0 && cfg.RunMode == config.RunModeServer) {
+		go warmCache(cfg, warmer)
+	}
+
+	// Block filtered requests to everything but the health checks, which
+	// come from load balancers
+	handler := http.Handler(http.DefaultServeMux)
+	if cfg.FilterEnabled() {
+		var loader filter.Loader
+		if bucket, key, ok := filter.ParseS3URL(cfg.FilterRulesURL); ok {
+			awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+			if err != nil {
+				log.Fatal("Error loading AWS config: ", err)
+			}
+			loader = filter.S3Loader(s3.NewFromConfig(awsCfg), bucket, key)
+		} else if cfg.FilterRulesURL != "" {
+			loader = filter.URLLoader(&http.Client{Timeout: 5 * time.Second}, cfg.FilterRulesURL)
+		}
+
+		filterStore, err := filter.NewStore(filter.Rules{
+			AllowCIDRs:     cfg.FilterAllowCIDRs,
+			DenyCIDRs:      cfg.FilterDenyCIDRs,
+			DenyUserAgents: cfg.FilterDenyUserAgents,
+			DenyPaths:      cfg.FilterDenyPaths,
+		}, loader, cfg.FilterRefreshInterval, cfg.FilterTrustedProxies)
+		if err != nil {
+			log.Fatal("Error creating request filter: ", err)
+		}
+		if err := filterStore.Refresh(context.Background()); err != nil {
+			log.Printf("Error loading filter rules: %v", err)
+		}
+
+		root := http.NewServeMux()
+		root.HandleFunc("/healthz", healthHandler.Liveness)
+		root.HandleFunc("/readyz", healthHandler.Readiness)
+		root.Handle("/", middleware.Filter(filterStore)(http.DefaultServeMux))
+		handler = root
+	}
+
+	// Restrict private sections to signed in visitors or allowlisted
+	// networks
+	if len(cfg.AccessRules) > 0 {
+		handler = accessControl(cfg)(handler)
+	}
+
+	// Keep deployments such as staging out of search engines
+	if cfg.NoIndex {
+		handler = middleware.NoIndex(handler)
+	}
+
+	// Lock down non-production deployments, leaving open the health checks
+	// and the endpoints with their own tokens
+	if cfg.SiteAuth != "" {
+		handler = siteAuth(cfg)(handler)
+	}
+
+	// Send visitors on another scheme or host alias to the public URL
+	if cfg.CanonicalRedirect {
+		handler = middleware.CanonicalHost(cfg.BaseURL, cfg.TrustForwardedHost)(handler)
+	}
+
+	// Reject requests no handler takes before any of them runs
+	handler = middleware.Limit(middleware.RequestLimits{
+		MaxHeaderCount: cfg.MaxHeaderCount,
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
+	})(handler)
+
+	handler = tracing.Middleware(handler)
+	// Page responses carry hints to preload the theme's critical resources,
+	// sent ahead of the page as 103 Early Hints when serving directly.  The
+	// hints go outside tracing so spans record the final status.
+	handler = middleware.ResourceHints(resourceHints(cfg), cfg.EarlyHints && cfg.RunMode == config.RunModeServer)(handler)
+
+	if cfg.RunMode == config.RunModeLambda {
+		// Start Lambda proxy handler.  Spans are flushed before each
+		// invocation returns, since the environment may be frozen after.
+		proxy := httpadapter.NewV2(handler).ProxyWithContext
+		lambda.Start(func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			resp, err := proxy(ctx, event)
+			if tracerProvider != nil {
+				if err := tracerProvider.ForceFlush(ctx); err != nil {
+					log.Printf("Error flushing spans: %v", err)
+				}
+			}
+			return resp, err
+		})
+		return
+	}
+
+	// Start standalone HTTP server.  On SIGTERM or SIGINT readiness checks
+	// fail, and requests in flight are completed before exiting.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+	opts := server.Options{
+		ShutdownDelay:   cfg.ShutdownDelay,
+		ShutdownTimeout: cfg.ShutdownTimeout,
+		OnShutdown:      healthHandler.Drain,
+	}
+	if err := server.Serve(ctx, srv, opts); err != nil {
+		log.Fatal("Error running server: ", err)
+	}
+	if tracerProvider != nil {
+		if err := tracerProvider.Shutdown(context.Background()); err != nil {
+			log.Printf("Error flushing spans: %v", err)
+		}
+	}
+}
+
+// rotateCredentials refreshes secrets on an interval and updates the
+// WordPress credentials, headers and client certificate, so that rotated
+// secrets are used without a restart.  Other secrets are only read at
+// startup.
+func rotateCredentials(cfg *config.Config, store *secrets.Store, client *api.WordPressClient, clientCert *api.ClientCertificate) {
+	rotated := *cfg
+	for range time.Tick(cfg.SecretRefreshInterval) {
+		if err := store.Refresh(context.Background()); err != nil {
+			log.Printf("Error refreshing secrets: %v", err)
+		}
+		if err := rotated.ResolveSecrets(context.Background(), store.Resolve); err != nil {
+			log.Printf("Error resolving secrets: %v", err)
+			continue
+		}
+		client.SetCredentials(rotated.WordPressUsername, rotated.WordPressPassword)
+		if rotated.WordPressHeaders != "" {
+			client.SetHeaders(wordPressHeaders(&rotated))
+		}
+		if clientCert != nil && rotated.WordPressTLSCert != "" {
+			pair, err := api.LoadKeyPair(rotated.WordPressTLSCert, rotated.WordPressTLSKey)
+			if err != nil {
+				log.Printf("Error loading WordPress client certificate: %v", err)
+				continue
+			}
+			clientCert.Set(pair)
+		}
+	}
+}
+
+// mutualTLSTransport returns a transport presenting the configured client
+// certificate to WordPress and trusting the configured CAs.
+func mutualTLSTransport(cfg *config.Config, clientCert *api.ClientCertificate) http.RoundTripper {
+	if cfg.WordPressTLSCert != "" {
+		pair, err := api.LoadKeyPair(cfg.WordPressTLSCert, cfg.WordPressTLSKey)
+		if err != nil {
+			log.Fatal("Error loading WordPress client certificate: ", err)
+		}
+		clientCert.Set(pair)
+	}
+	var pool *x509.CertPool
+	if cfg.WordPressTLSCA != "" {
+		var err error
+		if pool, err = api.LoadCertPool(cfg.WordPressTLSCA); err != nil {
+			log.Fatal("Error loading WORDPRESS_TLS_CA: ", err)
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = clientCert.TLSConfig(pool)
+	return transport
+}
+
+// wordPressHeaders returns the headers added to WordPress requests, which
+// were validated when the configuration was loaded.
+func wordPressHeaders(cfg *config.Config) http.Header {
+	header, err := api.ParseHeaders(cfg.WordPressHeaders)
+	if err != nil {
+		log.Fatal("Error parsing WORDPRESS_HEADERS: ", err)
+	}
+	return header
+}
+
+// warmCache warms the caches on start if enabled, then on the warm
+// interval in server mode.  In Lambda, scheduled warms are requested
+// through /internal/warm instead, since the environment is frozen between
+// invocations.
+func warmCache(cfg *config.Config, warmer *warm.Warmer) {
+	if cfg.WarmOnStartup {
+		if _, err := warmer.Warm(context.Background()); err != nil {
+			log.Printf("Error warming cache: %v", err)
+		}
+	}
+	if cfg.WarmInterval <= 0 || cfg.RunMode != config.RunModeServer {
+		return
+	}
+	for range time.Tick(cfg.WarmInterval) {
+		if _, err := warmer.Warm(context.Background()); err != nil {
+			log.Printf("Error warming cache: %v", err)
+		}
+	}
+}
+
+// exportStore returns the store of static exports, an S3 bucket for s3://
+// URLs or else a directory.
+func exportStore(target string) export.Store {
+	bucket, prefix, ok := export.ParseS3URL(target)
+	if !ok {
+		return export.NewDirTarget(target)
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		log.Fatal("Error loading AWS config: ", err)
+	}
+	return export.NewS3Target(s3.NewFromConfig(awsCfg), bucket, prefix)
+}
+
+// exportSite writes a static copy of the site once menus are loaded, so
+// that the exported pages have them, and fails if any path was not
+// written.
+func exportSite(client *api.WordPressClient, exporter *export.Exporter) {
+	if err := client.RefreshMenus(context.Background()); err != nil {
+		log.Fatal("Error fetching menus: ", err)
+	}
+	result, err := exporter.Export(context.Background())
+	if err != nil {
+		log.Fatal("Error exporting site: ", err)
+	}
+	if result.Failed > 0 {
+		log.Fatalf("Error exporting site: %d of %d paths failed", result.Failed, result.Exported+result.Failed)
+	}
+}
+
+// auditSite writes the report of broken internal links once menus are
+// loaded, so that menu links are checked, to a file or to standard output
+// for "-".  Files ending in .csv get CSV and others JSON.
+func auditSite(client *api.WordPressClient, auditor *audit.Auditor, output string) {
+	if err := client.RefreshMenus(context.Background()); err != nil {
+		log.Fatal("Error fetching menus: ", err)
+	}
+	report, err := auditor.Audit(context.Background())
+	if err != nil {
+		log.Fatal("Error auditing links: ", err)
+	}
+
+	out := os.Stdout
+	if output != "-" {
+		out, err = os.Create(output)
+		if err != nil {
+			log.Fatal("Error creating link audit report: ", err)
+		}
+	}
+	if strings.HasSuffix(output, ".csv") {
+		err = report.WriteCSV(out)
+	} else {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	}
+	if err == nil && out != os.Stdout {
+		err = out.Close()
+	}
+	if err != nil {
+		log.Fatal("Error writing link audit report: ", err)
+	}
+}
+
+// externalLinks returns the transformer marking links that leave the site,
+// labelled with the link.external message of the theme in each language.
+func externalLinks(cfg *config.Config) transform.ExternalLinks {
+	messages, err := handlers.Theme.Messages()
+	if err != nil {
+		log.Fatal("Error loading theme messages: ", err)
+	}
+	labels := make(map[string]string)
+	for _, lang := range cfg.Languages {
+		if label, ok := messages.Lookup(lang.Code, "link.external"); ok {
+			labels[lang.Code] = label
+		}
+	}
+	return transform.ExternalLinks{
+		SiteHosts: warmHosts(cfg),
+		Target:    cfg.ExternalLinkTarget,
+		Labels:    labels,
+	}
+}
+
+// siteAuthExempt are the paths left open when the deployment is locked
+// down: the health checks of load balancers, and the endpoints that check
+// their own bearer tokens, which would clash with Basic authentication.
+var siteAuthExempt = []string{"/healthz", "/readyz", "/internal/"}
+
+// siteAuth returns the middleware locking down the deployment as set by
+// SITE_AUTH.
+func siteAuth(cfg *config.Config) func(http.Handler) http.Handler {
+	if cfg.SiteAuth == config.SiteAuthALB {
+		return middleware.ALBAuth(albVerifier(cfg), siteAuthExempt)
+	}
+	return middleware.BasicAuth(htpasswdUsers(cfg), models.Languages.Default().SiteName, siteAuthExempt)
+}
+
+// accessControl returns the middleware restricting the sections set by
+// ACCESS_RULES.  Each rule also covers the page API paths of its section,
+// which serve the same content, and the sections are left out of GraphQL
+// responses.
+func accessControl(cfg *config.Config) func(http.Handler) http.Handler {
+	rules, err := access.ParseRules(cfg.AccessRules)
+	if err != nil {
+		log.Fatal("Error parsing ACCESS_RULES: ", err)
+	}
+	handlers.AccessRules = rules
+	for _, rule := range rules {
+		rule.Prefix = handlers.PageAPIPath + strings.TrimPrefix(rule.Prefix, "/")
+		rules = append(rules, rule)
+	}
+
+	var authenticate middleware.Authenticator
+	switch cfg.AuthMethod() {
+	case config.SiteAuthBasic:
+		authenticate = middleware.BasicAuthenticator(htpasswdUsers(cfg), models.Languages.Default().SiteName)
+	case config.SiteAuthALB:
+		authenticate = middleware.ALBAuthenticator(albVerifier(cfg))
+	}
+	return middleware.Access(rules, authenticate, cfg.FilterTrustedProxies)
+}
+
+// htpasswdUsers returns the users of SITE_AUTH_USERS.
+func htpasswdUsers(cfg *config.Config) auth.Htpasswd {
+	users, err := auth.ParseHtpasswd(cfg.SiteAuthUsers)
+	if err != nil {
+		log.Fatal("Error parsing SITE_AUTH_USERS: ", err)
+	}
+	return users
+}
+
+// albVerifier returns the verifier of the tokens of SITE_AUTH_ALB_ARN.
+func albVerifier(cfg *config.Config) *auth.ALBVerifier {
+	verifier, err := auth.NewALBVerifier(cfg.SiteAuthALBARN)
+	if err != nil {
+		log.Fatal("Error creating ALB verifier: ", err)
+	}
+	return verifier
+}
+
+// resourceHints returns the Link header values of the theme's resource
+// hints, followed by preconnect and dns-prefetch hints for the configured
+// origins, with dns-prefetch for browsers that do not preconnect.
+func resourceHints(cfg *config.Config) []string {
+	hints, err := handlers.Theme.Hints()
+	if err != nil {
+		log.Fatal("Error loading theme hints: ", err)
+	}
+	var links []string
+	for _, hint := range hints {
+		links = append(links, hint.Link())
+	}
+	for _, origin := range cfg.PreconnectOrigins {
+		links = append(links,
+			theme.Hint{URL: origin, Rel: "preconnect"}.Link(),
+			theme.Hint{URL: origin, Rel: "dns-prefetch"}.Link())
+	}
+	return links
+}
+
+// warmHosts returns the hosts of the WordPress site and the proxy, which
+// absolute menu links to pages on the site are made to.
+func warmHosts(cfg *config.Config) []string {
+	var hosts []string
+	for _, rawURL := range []string{cfg.WordPressBaseURL, cfg.BaseURL} {
+		if u, err := url.Parse(rawURL); err == nil && u.Hostname() != "" {
+			hosts = append(hosts, u.Hostname())
+		}
+	}
+	return hosts
 }
@@ -0,0 +1,111 @@
+// Package access restricts sections of the site, by path prefix, to
+// signed in visitors or to visitors from allowlisted networks, so that
+// semi-private content such as an intranet can be proxied alongside public
+// pages.
+package access
+
+import (
+	"fmt"
+	"net/netip"
+	"path"
+	"strings"
+)
+
+// Rule restricts the paths under Prefix to visitors who are signed in, if
+// Auth is set, or whose address is in one of CIDRs.  Meeting any one of
+// the conditions is enough.
+type Rule struct {
+	Prefix string
+	Auth   bool
+	CIDRs  []netip.Prefix
+}
+
+// ParseRule parses a rule written as prefix=condition|condition, where a
+// condition is "auth" or a CIDR, such as /intranet/=auth|10.0.0.0/8.
+// Single addresses are treated as one address CIDRs.
+func ParseRule(s string) (Rule, error) {
+	prefix, conditions, ok := strings.Cut(strings.TrimSpace(s), "=")
+	prefix = strings.TrimSpace(prefix)
+	if !ok || !strings.HasPrefix(prefix, "/") || strings.HasPrefix(prefix, "//") || path.Clean(prefix) == "/" {
+		return Rule{}, fmt.Errorf("invalid access rule %q", s)
+	}
+	rule := Rule{Prefix: strings.ToLower(prefix)}
+	for _, condition := range strings.Split(conditions, "|") {
+		condition = strings.TrimSpace(condition)
+		if condition == "auth" {
+			rule.Auth = true
+			continue
+		}
+		cidr, err := parsePrefix(condition)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid condition in access rule %q: %q", s, condition)
+		}
+		rule.CIDRs = append(rule.CIDRs, cidr)
+	}
+	return rule, nil
+}
+
+// ParseRules parses rules, see ParseRule.
+func ParseRules(values []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(values))
+	for _, value := range values {
+		rule, err := ParseRule(value)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// NeedsAuth reports whether any of the rules lets in signed in visitors.
+func NeedsAuth(rules []Rule) bool {
+	for _, rule := range rules {
+		if rule.Auth {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the rule with the longest prefix matching a path.  Paths
+// are cleaned and compared case insensitively, so that /Intranet/ and
+// /a/../intranet/ do not get around a rule for /intranet/.  A prefix ending
+// in a slash also matches the path without it.
+func Match(rules []Rule, p string) (Rule, bool) {
+	cleaned := strings.ToLower(path.Clean("/" + p))
+	var matched Rule
+	var found bool
+	for _, rule := range rules {
+		dir := strings.TrimSuffix(rule.Prefix, "/")
+		if cleaned != dir && !strings.HasPrefix(cleaned+"/", rule.Prefix) {
+			continue
+		}
+		if !found || len(rule.Prefix) > len(matched.Prefix) {
+			matched, found = rule, true
+		}
+	}
+	return matched, found
+}
+
+// AllowsAddr reports whether a visitor's address is in the rule's CIDRs.
+func (r Rule) AllowsAddr(addr netip.Addr) bool {
+	for _, cidr := range r.CIDRs {
+		if cidr.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefix parses a CIDR or a single address.
+func parsePrefix(value string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
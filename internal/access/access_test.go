@@ -0,0 +1,56 @@
+package access
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule(" /Staff/=auth|10.0.0.0/8|192.168.1.7 ")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rule.Prefix != "/staff/" || !rule.Auth || len(rule.CIDRs) != 2 {
+		t.Errorf("Unexpected rule %+v", rule)
+	}
+	if !rule.AllowsAddr(netip.MustParseAddr("192.168.1.7")) || rule.AllowsAddr(netip.MustParseAddr("192.168.1.8")) {
+		t.Errorf("Expected a single address treated as a one address CIDR, got %v", rule.CIDRs)
+	}
+
+	for _, s := range []string{"/intranet/", "intranet/=auth", "/=auth", "//intranet/=auth", "/intranet/=", "/intranet/=password", "/intranet/=10.0.0.0/33"} {
+		if _, err := ParseRule(s); err == nil {
+			t.Errorf("Expected error for %q", s)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rules, err := ParseRules([]string{"/intranet/=auth", "/intranet/hr/=10.0.0.0/8", "/fr/intranet=auth"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/intranet/", "/intranet/"},
+		{"/intranet", "/intranet/"},
+		{"/intranet/news/", "/intranet/"},
+		{"/Intranet/News/", "/intranet/"},
+		{"/about/../intranet/", "/intranet/"},
+		{"//intranet/", "/intranet/"},
+		{"/intranet/hr/", "/intranet/hr/"},
+		{"/intranet/hr/policies", "/intranet/hr/"},
+		{"/fr/intranet/", "/fr/intranet"},
+		{"/intranets/", ""},
+		{"/about/intranet/", ""},
+		{"/", ""},
+	}
+	for _, tt := range tests {
+		rule, ok := Match(rules, tt.path)
+		if ok != (tt.expected != "") || rule.Prefix != tt.expected {
+			t.Errorf("Expected %s matched by %q, got %q", tt.path, tt.expected, rule.Prefix)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Package analytics describes the web analytics providers pages can load,
+// for visitors who consented to analytics.
+package analytics
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+
+	"wordpress-go-proxy/internal/middleware"
+)
+
+// Provider is a web analytics service whose script pages load.
+type Provider struct {
+	// Name identifies the provider in config and templates, such as
+	// "google".
+	Name string
+
+	// ID matches the IDs of the provider's properties, such as the
+	// measurement ID of a Google Analytics property.
+	ID *regexp.Regexp
+
+	// Sources are the sources the provider's script needs allowed by the
+	// Content-Security-Policy, keyed by directive.
+	Sources map[string][]string
+}
+
+// Providers are the analytics providers the proxy can load.
+var Providers = []Provider{
+	{
+		Name: "google",
+		ID:   regexp.MustCompile(`^G-[A-Z0-9]+$`),
+		Sources: map[string][]string{
+			"script-src":  {"https://www.googletagmanager.com"},
+			"connect-src": {"https://*.google-analytics.com", "https://*.analytics.google.com", "https://*.googletagmanager.com"},
+			"img-src":     {"https://*.google-analytics.com", "https://*.googletagmanager.com"},
+		},
+	},
+	{
+		Name: "adobe",
+		ID:   regexp.MustCompile(`^[a-z0-9]+/[a-z0-9]+/launch-[a-z0-9]+(-development|-staging)?$`),
+		Sources: map[string][]string{
+			"script-src":  {"https://assets.adobedtm.com"},
+			"connect-src": {"https://assets.adobedtm.com", "https://*.omtrdc.net", "https://*.demdex.net"},
+			"img-src":     {"https://*.omtrdc.net", "https://*.demdex.net"},
+		},
+	},
+}
+
+// LookupProvider returns the provider with the given name.
+func LookupProvider(name string) (Provider, bool) {
+	for _, provider := range Providers {
+		if provider.Name == name {
+			return provider, true
+		}
+	}
+	return Provider{}, false
+}
+
+// Policy returns a Content-Security-Policy with the provider's sources
+// added, so that its script can load and send its measurements.
+func (p Provider) Policy(policy string) string {
+	for _, directive := range slices.Sorted(maps.Keys(p.Sources)) {
+		policy = middleware.AddSources(policy, directive, p.Sources[directive]...)
+	}
+	return policy
+}
@@ -0,0 +1,49 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+
+	"wordpress-go-proxy/internal/middleware"
+)
+
+func TestLookupProvider(t *testing.T) {
+	tests := []struct {
+		provider string
+		id       string
+		valid    bool
+	}{
+		{"google", "G-ABC123DEF4", true},
+		{"google", "UA-12345-1", false},
+		{"adobe", "abc123/def456/launch-0a1b2c", true},
+		{"adobe", "abc123/def456/launch-0a1b2c-staging", true},
+		{"adobe", "../launch.js", false},
+	}
+	for _, tt := range tests {
+		provider, ok := LookupProvider(tt.provider)
+		if !ok {
+			t.Fatalf("Expected provider %q", tt.provider)
+		}
+		if provider.ID.MatchString(tt.id) != tt.valid {
+			t.Errorf("Expected %s ID %q valid %v", tt.provider, tt.id, tt.valid)
+		}
+	}
+
+	if _, ok := LookupProvider("matomo"); ok {
+		t.Error("Expected unknown provider not found")
+	}
+}
+
+func TestProviderPolicy(t *testing.T) {
+	provider, _ := LookupProvider("google")
+	got := provider.Policy(middleware.DefaultCSP)
+	for _, expected := range []string{
+		"script-src 'self' 'nonce-{nonce}' 'strict-dynamic' https://cdn.design-system.alpha.canada.ca https://www.googletagmanager.com;",
+		"img-src 'self' data: https://design-system.alpha.canada.ca https://*.google-analytics.com https://*.googletagmanager.com;",
+		"; connect-src 'self' https://*.google-analytics.com https://*.analytics.google.com https://*.googletagmanager.com",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("Expected policy to contain %q, got %q", expected, got)
+		}
+	}
+}
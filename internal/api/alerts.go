@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// maxAlerts is the number of alerts shown on a page.
+const maxAlerts = 5
+
+// alertFields are the fields requested of alerts.
+const alertFields = "id,title,content,acf,meta"
+
+// alertRetryDelay is how long alerts are not requested after a failed
+// request, so that pages are not held up waiting on WordPress for each of
+// them while it is down.
+const alertRetryDelay = 30 * time.Second
+
+// errAlertsUnavailable is returned while alerts are not requested after a
+// failed request.
+var errAlertsUnavailable = errors.New("alerts unavailable after a failed request")
+
+// FetchAlerts retrieves the published alerts in a language, newest first,
+// from the posts of AlertsRoute.
+// Alerts are cached by language when the client has an alert cache, so
+// that every page does not wait on WordPress for them.  Once a request
+// fails, errAlertsUnavailable is returned without requesting them again
+// for alertRetryDelay.
+func (c *WordPressClient) FetchAlerts(ctx context.Context, lang string) ([]models.Alert, error) {
+	if time.Now().UnixNano() < c.alertsFailedUntil.Load() {
+		return nil, errAlertsUnavailable
+	}
+	fetch := func(ctx context.Context) ([]models.Alert, error) {
+		alerts, err := c.fetchAlerts(ctx, lang)
+		if err != nil && ctx.Err() == nil {
+			c.alertsFailedUntil.Store(time.Now().Add(alertRetryDelay).UnixNano())
+		}
+		return alerts, err
+	}
+	if c.AlertCache == nil {
+		return fetch(ctx)
+	}
+	return c.AlertCache.Get(ctx, lang, fetch)
+}
+
+// fetchAlerts retrieves the alerts in a language from the WordPress API.
+func (c *WordPressClient) fetchAlerts(ctx context.Context, lang string) ([]models.Alert, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	params := url.Values{
+		"lang":       {lang},
+		"per_page":   {strconv.Itoa(maxAlerts)},
+		"orderby":    {"date"},
+		"order":      {"desc"},
+		"acf_format": {"standard"},
+		"_fields":    {alertFields},
+	}
+	if c.AlertsCategory > 0 {
+		params.Set("categories", strconv.Itoa(c.AlertsCategory))
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/%s?%s", c.BaseURL, c.AlertsRoute, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetching alerts: %s", req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+
+	var posts []models.WordPressPage
+	if err := json.NewDecoder(resp.Body).Decode(&posts); err != nil {
+		return nil, err
+	}
+	alerts := make([]models.Alert, 0, len(posts))
+	for i := range posts {
+		alerts = append(alerts, models.NewAlert(&posts[i]))
+	}
+	return alerts, nil
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestFetchAlerts(t *testing.T) {
+	var lastPath, lastQuery string
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		lastPath, lastQuery = r.URL.Path, r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"id": 7, "title": {"rendered": "Outage &amp; delays"}, "content": {"rendered": "<p>Online services are down.</p>"}, "acf": {"alert_type": "danger"}},
+			{"id": 6, "title": {"rendered": "Maintenance"}, "content": {"rendered": "<p>Tonight</p>"}, "acf": []}
+		]`))
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{
+		BaseURL:        server.URL,
+		AlertCache:     cache.New[[]models.Alert](cache.Policy{TTL: time.Minute}),
+		AlertsRoute:    "wp/v2/posts",
+		AlertsCategory: 12,
+	}
+
+	for i := 0; i < 2; i++ {
+		alerts, err := client.FetchAlerts(context.Background(), "fr")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := []models.Alert{
+			{ID: 7, Type: "danger", Title: "Outage & delays", Message: "<p>Online services are down.</p>"},
+			{ID: 6, Type: "warning", Title: "Maintenance", Message: "<p>Tonight</p>"},
+		}
+		if len(alerts) != 2 || alerts[0] != expected[0] || alerts[1] != expected[1] {
+			t.Errorf("Expected %+v, got %+v", expected, alerts)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected 1 upstream request, got %d", requests)
+	}
+	expected := "_fields=id%2Ctitle%2Ccontent%2Cacf%2Cmeta&acf_format=standard&categories=12&lang=fr&order=desc&orderby=date&per_page=5"
+	if lastPath != "/wp-json/wp/v2/posts" || lastQuery != expected {
+		t.Errorf("Expected /wp-json/wp/v2/posts?%s, got %s?%s", expected, lastPath, lastQuery)
+	}
+}
+
+func TestFetchAlertsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL, AlertsRoute: "wp/v2/alerts"}
+	_, err := client.FetchAlerts(context.Background(), "en")
+	if err == nil || !strings.Contains(err.Error(), "status: 500") {
+		t.Errorf("Expected status error, got %v", err)
+	}
+}
+
+// TestFetchAlertsRetryDelay tests that alerts are not requested again for a
+// while after a request fails, so that pages do not each wait on WordPress
+func TestFetchAlertsRetryDelay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{
+		BaseURL:     server.URL,
+		AlertCache:  cache.New[[]models.Alert](cache.Policy{TTL: time.Minute}),
+		AlertsRoute: "wp/v2/alerts",
+	}
+	if _, err := client.FetchAlerts(context.Background(), "en"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, lang := range []string{"en", "fr"} {
+		if _, err := client.FetchAlerts(context.Background(), lang); !errors.Is(err, errAlertsUnavailable) {
+			t.Errorf("Expected alerts unavailable in %s, got %v", lang, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 upstream request, got %d", requests)
+	}
+
+	client.alertsFailedUntil.Store(time.Now().Add(-time.Second).UnixNano())
+	client.FetchAlerts(context.Background(), "en")
+	if requests != 2 {
+		t.Errorf("Expected alerts requested again after the delay, got %d requests", requests)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// maxAncestorDepth limits how far up the page hierarchy ancestors are
+// fetched.
+const maxAncestorDepth = 10
+
+// FetchAncestors retrieves the ancestors of a page by following its parent
+// field.  Ancestors are returned ordered from the top of the hierarchy down
+// to the page's direct parent.
+func (c *WordPressClient) FetchAncestors(ctx context.Context, page *models.WordPressPage) ([]models.WordPressPage, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	var ancestors []models.WordPressPage
+	seen := map[int]bool{page.ID: true}
+	for parent := page.Parent; parent != 0 && len(ancestors) < maxAncestorDepth; {
+		if seen[parent] {
+			return nil, fmt.Errorf("page %d has a cyclic ancestry", page.ID)
+		}
+		seen[parent] = true
+
+		ancestor, err := c.fetchPageByID(ctx, parent)
+		if err != nil {
+			return nil, err
+		}
+		ancestors = append(ancestors, *ancestor)
+		parent = ancestor.Parent
+	}
+
+	// Reverse so the top level page is first
+	for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
+		ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+	}
+	return ancestors, nil
+}
+
+// FetchPageSlug returns the slug of the page with the given ID, such as to
+// serve it as a home page.
+func (c *WordPressClient) FetchPageSlug(ctx context.Context, id int) (string, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	page, err := c.fetchByID(ctx, pagesRoute, id)
+	if err != nil {
+		return "", err
+	}
+	if !page.Viewable(false) {
+		return "", fmt.Errorf("page %d is not published: status %q", id, page.Status)
+	}
+	return page.Slug, nil
+}
+
+// fetchPageByID retrieves the fields of a page needed to link to it.
+// Pages are cached by ID when the client has a page cache.
+func (c *WordPressClient) fetchPageByID(ctx context.Context, id int) (*models.WordPressPage, error) {
+	fetch := func(ctx context.Context) (*models.WordPressPage, error) {
+		return c.fetchByID(ctx, pagesRoute, id)
+	}
+
+	if c.PageCache == nil {
+		return fetch(ctx)
+	}
+	return c.PageCache.Get(ctx, "id/"+strconv.Itoa(id), fetch)
+}
+
+// fetchByID retrieves the fields of a page, or a post of a custom post
+// type, needed to link to it from a WordPress REST route by its ID.
+// ErrPageNotFound is returned if there is none.
+func (c *WordPressClient) fetchByID(ctx context.Context, route string, id int) (*models.WordPressPage, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/%s/%d?_fields=id,parent,slug,lang,title,status", c.BaseURL, route, id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetching page: %s", req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var err error = newStatusError(resp)
+		if resp.StatusCode == http.StatusNotFound {
+			err = fmt.Errorf("%w: %w", ErrPageNotFound, err)
+		}
+		return nil, err
+	}
+
+	var page models.WordPressPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// ancestorServer serves pages by ID from the given parent relationships
+func ancestorServer(t *testing.T, parents map[int]int, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		idPath := strings.TrimPrefix(r.URL.Path, "/wp-json/wp/v2/pages/")
+		id, err := strconv.Atoi(idPath)
+		if err != nil {
+			t.Fatalf("Unexpected request path: %s", r.URL.Path)
+		}
+		parent, ok := parents[id]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		page := models.WordPressPage{ID: id, Status: "publish", Parent: parent, Slug: "page-" + idPath}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+}
+
+func TestFetchAncestors(t *testing.T) {
+	requests := 0
+	server := ancestorServer(t, map[int]int{2: 1, 1: 0}, &requests)
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	ancestors, err := client.FetchAncestors(context.Background(), &models.WordPressPage{ID: 3, Status: "publish", Parent: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(ancestors) != 2 {
+		t.Fatalf("Expected 2 ancestors, got %d", len(ancestors))
+	}
+	if ancestors[0].ID != 1 || ancestors[1].ID != 2 {
+		t.Errorf("Expected ancestors ordered from the top, got %d, %d", ancestors[0].ID, ancestors[1].ID)
+	}
+	if ancestors[0].Slug != "page-1" {
+		t.Errorf("Expected slug page-1, got %q", ancestors[0].Slug)
+	}
+}
+
+func TestFetchAncestorsNoParent(t *testing.T) {
+	requests := 0
+	server := ancestorServer(t, nil, &requests)
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	ancestors, err := client.FetchAncestors(context.Background(), &models.WordPressPage{Status: "publish", ID: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(ancestors) != 0 || requests != 0 {
+		t.Errorf("Expected no ancestors or requests, got %d ancestors and %d requests", len(ancestors), requests)
+	}
+}
+
+func TestFetchAncestorsCycle(t *testing.T) {
+	requests := 0
+	server := ancestorServer(t, map[int]int{2: 1, 1: 2}, &requests)
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	_, err := client.FetchAncestors(context.Background(), &models.WordPressPage{ID: 1, Status: "publish", Parent: 2})
+	if err == nil || !strings.Contains(err.Error(), "cyclic") {
+		t.Errorf("Expected cyclic ancestry error, got %v", err)
+	}
+}
+
+func TestFetchAncestorsError(t *testing.T) {
+	requests := 0
+	server := ancestorServer(t, map[int]int{}, &requests)
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	_, err := client.FetchAncestors(context.Background(), &models.WordPressPage{ID: 2, Status: "publish", Parent: 1})
+	if err == nil || !strings.Contains(err.Error(), "status: 404") {
+		t.Errorf("Expected status error, got %v", err)
+	}
+}
+
+func TestFetchAncestorsCached(t *testing.T) {
+	requests := 0
+	server := ancestorServer(t, map[int]int{1: 0}, &requests)
+	defer server.Close()
+
+	client := &WordPressClient{
+		BaseURL:   server.URL,
+		PageCache: cache.New[*models.WordPressPage](cache.Policy{TTL: time.Minute}),
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.FetchAncestors(context.Background(), &models.WordPressPage{ID: 2, Status: "publish", Parent: 1}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 upstream request, got %d", requests)
+	}
+}
+
+func TestFetchPageSlug(t *testing.T) {
+	requests := 0
+	server := ancestorServer(t, map[int]int{42: 0}, &requests)
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	slug, err := client.FetchPageSlug(context.Background(), 42)
+	if err != nil || slug != "page-42" {
+		t.Errorf("Expected slug page-42, got %q and %v", slug, err)
+	}
+	if _, err := client.FetchPageSlug(context.Background(), 7); !errors.Is(err, ErrPageNotFound) {
+		t.Errorf("Expected ErrPageNotFound, got %v", err)
+	}
+}
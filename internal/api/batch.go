@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// maxBatchSlugs is the number of slugs fetched in one request.  Pages in
+// different parts of the hierarchy may share a slug, so it leaves room for
+// several pages per slug within maxBatchPages.
+const maxBatchSlugs = 50
+
+// maxBatchPages is the number of pages returned by a batch request, the
+// most WordPress returns at once.
+const maxBatchPages = 100
+
+// FetchPages retrieves the top level pages with the given slugs in a
+// language, as FetchPage does for paths of a single segment, and returns
+// them keyed by slug.  Pages not in the page cache are fetched in one
+// request per maxBatchSlugs slugs and added to it, so that pages about to
+// be requested, such as the translations of a page, are served from the
+// cache.  Slugs may be percent-encoded, as WordPress stores them, or not.
+// Slugs without a top level page are missing from the result.
+func (c *WordPressClient) FetchPages(ctx context.Context, lang string, slugs []string) (map[string]*models.WordPressPage, error) {
+	found := make(map[string]*models.WordPressPage, len(slugs))
+	var missing []string
+	for _, slug := range slugs {
+		if c.PageCache != nil {
+			if page, ok := c.PageCache.Fresh(pageKey(lang, nil, models.DecodeSlug(slug))); ok {
+				found[slug] = page
+				continue
+			}
+		}
+		if _, ok := found[slug]; !ok && !slices.Contains(missing, slug) {
+			missing = append(missing, slug)
+		}
+	}
+
+	for start := 0; start < len(missing); start += maxBatchSlugs {
+		batch := missing[start:min(start+maxBatchSlugs, len(missing))]
+		pages, err := c.fetchPages(ctx, pagesRoute, batch, lang, "")
+		if err != nil {
+			return found, err
+		}
+
+		bySlug := make(map[string][]models.WordPressPage)
+		for _, page := range pages {
+			slug := models.DecodeSlug(page.Slug)
+			bySlug[slug] = append(bySlug[slug], page)
+		}
+		for _, slug := range batch {
+			candidates := bySlug[models.DecodeSlug(slug)]
+			if len(candidates) == 0 {
+				continue
+			}
+			sort.SliceStable(candidates, func(i, j int) bool {
+				return inLanguage(candidates[i], lang) && !inLanguage(candidates[j], lang)
+			})
+			page := preferredPage(candidates)
+			if page == nil {
+				continue
+			}
+			found[slug] = page
+			if c.PageCache != nil {
+				c.PageCache.Set(pageKey(lang, nil, models.DecodeSlug(slug)), page)
+			}
+		}
+	}
+	return found, nil
+}
+
+// PrefetchPaths adds the pages at paths of a single segment, and the home
+// page of each language, to the page cache in batches by language.  Other
+// paths are left to be fetched when they are requested, since they are
+// resolved against the page hierarchy, as are paths with a percent sign,
+// which FetchPage would not find.  Paths are decoded, as those of requests
+// are.  Errors are logged.
+func (c *WordPressClient) PrefetchPaths(ctx context.Context, paths []string) {
+	if c.PageCache == nil {
+		return
+	}
+
+	slugs := make(map[string][]string)
+	for _, path := range paths {
+		if strings.Contains(path, "%") {
+			continue
+		}
+		slug, parents, lang := pageSlug(path)
+		if len(parents) == 0 {
+			slugs[lang] = append(slugs[lang], slug)
+		}
+	}
+	for _, lang := range models.Languages {
+		if len(slugs[lang.Code]) == 0 {
+			continue
+		}
+		if _, err := c.FetchPages(ctx, lang.Code, slugs[lang.Code]); err != nil {
+			log.Printf("Error prefetching %s pages: %v", lang.Code, err)
+		}
+	}
+}
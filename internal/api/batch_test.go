@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// batchServer serves the pages with the requested slugs and records the
+// slug and lang parameters of each request
+func batchServer(t *testing.T, pages []models.WordPressPage) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		mu.Lock()
+		requests = append(requests, q.Get("lang")+":"+q.Get("slug"))
+		mu.Unlock()
+
+		slugs := strings.Split(q.Get("slug"), ",")
+		if len(slugs) > 1 && q.Get("per_page") != "100" {
+			t.Errorf("Expected per_page=100 for a batch, got %q", q.Get("per_page"))
+		}
+		matches := []models.WordPressPage{}
+		for _, page := range pages {
+			for _, slug := range slugs {
+				if page.Slug == slug {
+					matches = append(matches, page)
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(matches)
+	}))
+	return server, &requests
+}
+
+func TestFetchPages(t *testing.T) {
+	server, requests := batchServer(t, []models.WordPressPage{
+		{ID: 1, Status: "publish", Slug: "about", Lang: "en"},
+		{ID: 2, Status: "publish", Slug: "contact", Lang: "en", Parent: 1},
+		{ID: 3, Status: "publish", Slug: "contact", Lang: "en"},
+		{ID: 4, Status: "publish", Slug: "services", Lang: "en"},
+	})
+	defer server.Close()
+
+	client := &WordPressClient{
+		BaseURL:   server.URL,
+		PageCache: cache.New[*models.WordPressPage](cache.Policy{TTL: time.Minute}),
+	}
+
+	pages, err := client.FetchPages(context.Background(), "en", []string{"about", "contact", "missing", "about"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(pages) != 2 || pages["about"].ID != 1 || pages["contact"].ID != 3 {
+		t.Errorf("Expected about and top level contact pages, got %v", pages)
+	}
+	if expected := []string{"en:about,contact,missing"}; !reflect.DeepEqual(*requests, expected) {
+		t.Errorf("Expected one batch request %v, got %v", expected, *requests)
+	}
+
+	// Fetched pages are served from the cache, and only the pages
+	// missing from it are fetched
+	if page, err := client.FetchPage(context.Background(), "/contact"); err != nil || page.ID != 3 {
+		t.Errorf("Expected cached contact page, got %v, %v", page, err)
+	}
+	if _, err := client.FetchPages(context.Background(), "en", []string{"about", "services"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(*requests) != 2 || (*requests)[1] != "en:services" {
+		t.Errorf("Expected only uncached slugs fetched, got %v", *requests)
+	}
+}
+
+// TestFetchPagesBatches tests that long lists of slugs are split into
+// several requests
+func TestFetchPagesBatches(t *testing.T) {
+	server, requests := batchServer(t, nil)
+	defer server.Close()
+
+	slugs := make([]string, maxBatchSlugs+1)
+	for i := range slugs {
+		slugs[i] = "page-" + strings.Repeat("a", i+1)
+	}
+	client := &WordPressClient{BaseURL: server.URL}
+	if _, err := client.FetchPages(context.Background(), "en", slugs); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(*requests) != 2 {
+		t.Errorf("Expected 2 requests for %d slugs, got %d", len(slugs), len(*requests))
+	}
+}
+
+func TestPrefetchPaths(t *testing.T) {
+	server, requests := batchServer(t, []models.WordPressPage{
+		{ID: 1, Status: "publish", Slug: "home", Lang: "en"},
+		{ID: 2, Status: "publish", Slug: "about", Lang: "en"},
+		{ID: 3, Status: "publish", Slug: "a-propos", Lang: "fr"},
+	})
+	defer server.Close()
+
+	client := &WordPressClient{
+		BaseURL:   server.URL,
+		PageCache: cache.New[*models.WordPressPage](cache.Policy{TTL: time.Minute}),
+	}
+	client.PrefetchPaths(context.Background(), []string{"/", "/about", "/about/team", "/fr/a-propos"})
+
+	expected := []string{"en:home,about", "fr:a-propos"}
+	if !reflect.DeepEqual(*requests, expected) {
+		t.Errorf("Expected requests %v, got %v", expected, *requests)
+	}
+	for _, key := range []string{"en/home", "en/about", "fr/a-propos"} {
+		if _, ok := client.PageCache.Fresh(key); !ok {
+			t.Errorf("Expected %s to be cached", key)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"wordpress-go-proxy/internal/tracing"
+	"wordpress-go-proxy/pkg/models"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxChildPages is the maximum number of child pages fetched for a page.
+const maxChildPages = 100
+
+// FetchChildren retrieves the published child pages of a page in menu
+// order.  Children are cached by parent ID when the client has a child
+// cache.  The lookup has its own span so that its cache hit is recorded
+// apart from the page's.
+func (c *WordPressClient) FetchChildren(ctx context.Context, parentID int, lang string) (_ []models.WordPressPage, err error) {
+	ctx, span := tracing.Start(ctx, "fetch-children", attribute.Int("wordpress.parent", parentID))
+	defer func() { tracing.End(span, err) }()
+
+	if c.ChildCache == nil {
+		return c.fetchChildren(ctx, parentID, lang)
+	}
+	return c.ChildCache.Get(ctx, strconv.Itoa(parentID), func(ctx context.Context) ([]models.WordPressPage, error) {
+		return c.fetchChildren(ctx, parentID, lang)
+	})
+}
+
+// fetchChildren retrieves the child pages of a page from the WordPress API.
+func (c *WordPressClient) fetchChildren(ctx context.Context, parentID int, lang string) ([]models.WordPressPage, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	params := url.Values{
+		"parent":   {strconv.Itoa(parentID)},
+		"lang":     {lang},
+		"per_page": {strconv.Itoa(maxChildPages)},
+		"orderby":  {"menu_order"},
+		"order":    {"asc"},
+		"_fields":  {"id,parent,slug,lang,title"},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/wp/v2/pages?%s", c.BaseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetching child pages: %s", req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+
+	var pages []models.WordPressPage
+	if err := json.NewDecoder(resp.Body).Decode(&pages); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
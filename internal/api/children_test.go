@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestFetchChildren(t *testing.T) {
+	var lastQuery string
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		lastQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPage{
+			{ID: 2, Parent: 1, Slug: "first"},
+			{ID: 3, Parent: 1, Slug: "second"},
+		})
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{
+		BaseURL:    server.URL,
+		ChildCache: cache.New[[]models.WordPressPage](cache.Policy{TTL: time.Minute}),
+	}
+
+	for i := 0; i < 2; i++ {
+		children, err := client.FetchChildren(context.Background(), 1, "en")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(children) != 2 || children[0].Slug != "first" {
+			t.Errorf("Expected 2 children in order, got %v", children)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected 1 upstream request, got %d", requests)
+	}
+	expected := "_fields=id%2Cparent%2Cslug%2Clang%2Ctitle&lang=en&order=asc&orderby=menu_order&parent=1&per_page=100"
+	if lastQuery != expected {
+		t.Errorf("Expected query %q, got %q", expected, lastQuery)
+	}
+}
+
+func TestFetchChildrenError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	_, err := client.FetchChildren(context.Background(), 1, "en")
+	if err == nil || !strings.Contains(err.Error(), "status: 500") {
+		t.Errorf("Expected status error, got %v", err)
+	}
+}
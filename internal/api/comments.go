@@ -0,0 +1,108 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// maxComments is the number of comments shown on a post, the most the
+// WordPress API returns in one request.
+const maxComments = 100
+
+// ErrCommentRejected is returned when WordPress refuses a comment, such as
+// a duplicate, one submitted too quickly after another, or one on a post
+// closed to comments.
+var ErrCommentRejected = errors.New("comment rejected")
+
+// CommentSubmission is a comment submitted by a visitor.  Parent is the ID
+// of the comment it replies to, or 0.
+type CommentSubmission struct {
+	PostID      int    `json:"post"`
+	Parent      int    `json:"parent,omitempty"`
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+	Content     string `json:"content"`
+}
+
+// FetchComments retrieves the approved comments on a post, oldest first.
+func (c *WordPressClient) FetchComments(ctx context.Context, postID int) ([]models.WordPressComment, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	params := url.Values{
+		"post":     {strconv.Itoa(postID)},
+		"per_page": {strconv.Itoa(maxComments)},
+		"order":    {"asc"},
+		"_fields":  {commentFields},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/wp/v2/comments?%s", c.BaseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetching comments: %s", req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+
+	var comments []models.WordPressComment
+	if err := json.NewDecoder(resp.Body).Decode(&comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// SubmitComment forwards a visitor's comment to WordPress, where it is
+// held for moderation according to the site's discussion settings.  The
+// comment is submitted anonymously, so the site must allow anonymous
+// comments through the REST API with the rest_allow_anonymous_comments
+// filter.
+func (c *WordPressClient) SubmitComment(ctx context.Context, comment CommentSubmission) error {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	body, err := json.Marshal(comment)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/wp-json/wp/v2/comments", c.BaseURL), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	log.Printf("Submitting comment on post %d", comment.PostID)
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		var wpErr struct {
+			Code string `json:"code"`
+		}
+		json.NewDecoder(resp.Body).Decode(&wpErr)
+		return fmt.Errorf("%w: status %d, code %q", ErrCommentRejected, resp.StatusCode, wpErr.Code)
+	default:
+		return newStatusError(resp)
+	}
+}
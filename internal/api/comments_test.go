@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchComments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/comments" {
+			t.Errorf("Expected path /wp-json/wp/v2/comments, got %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("post") != "42" || q.Get("per_page") != "100" || q.Get("order") != "asc" || q.Get("_fields") != commentFields {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Expected comments to be fetched anonymously")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":7,"post":42,"parent":0,"author_name":"Ada","date":"2024-05-01T10:00:00","content":{"rendered":"<p>Hello</p>"}}]`))
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL, AuthenticateContent: true}
+
+	comments, err := client.FetchComments(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(comments) != 1 || comments[0].AuthorName != "Ada" || comments[0].Content.Rendered != "<p>Hello</p>" {
+		t.Errorf("Unexpected comments: %+v", comments)
+	}
+}
+
+func TestSubmitComment(t *testing.T) {
+	comment := CommentSubmission{PostID: 42, Parent: 7, AuthorName: "Ada", AuthorEmail: "ada@example.com", Content: "Thanks"}
+
+	testCases := []struct {
+		name     string
+		status   int
+		body     string
+		err      bool
+		rejected bool
+	}{
+		{name: "Created", status: http.StatusCreated, body: `{"id":8}`},
+		{name: "Duplicate", status: http.StatusConflict, body: `{"code":"comment_duplicate"}`, err: true, rejected: true},
+		{name: "Closed", status: http.StatusForbidden, body: `{"code":"rest_comment_closed"}`, err: true, rejected: true},
+		{name: "Server error", status: http.StatusInternalServerError, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/wp-json/wp/v2/comments" {
+					t.Errorf("Expected POST /wp-json/wp/v2/comments, got %s %s", r.Method, r.URL.Path)
+				}
+				var got CommentSubmission
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil || got != comment {
+					t.Errorf("Expected comment %+v, got %+v, %v", comment, got, err)
+				}
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			client := &WordPressClient{BaseURL: server.URL}
+			err := client.SubmitComment(context.Background(), comment)
+			if (err != nil) != tc.err || errors.Is(err, ErrCommentRejected) != tc.rejected {
+				t.Errorf("Expected error %v, rejected %v, got %v", tc.err, tc.rejected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// listPageSize is the number of items requested per page when listing all
+// content, the most WordPress allows.
+const listPageSize = 100
+
+// ListContent retrieves the ID, slug, language and link of every published
+// page or post of a REST route in a language, such as "wp/v2/pages", by
+// requesting each page of results in turn.
+func (c *WordPressClient) ListContent(ctx context.Context, route string, lang string) ([]models.WordPressPost, error) {
+	var content []models.WordPressPost
+	for page := 1; ; page++ {
+		items, totalPages, err := c.listContentPage(ctx, route, lang, page)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, items...)
+		if page >= totalPages {
+			return content, nil
+		}
+	}
+}
+
+// listContentPage retrieves a page of the content of a route, along with
+// the total number of pages.
+func (c *WordPressClient) listContentPage(ctx context.Context, route string, lang string, page int) ([]models.WordPressPost, int, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	params := url.Values{
+		"lang":     {lang},
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(listPageSize)},
+		"orderby":  {"id"},
+		"order":    {"asc"},
+		"_fields":  {linkFields},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/%s?%s", c.BaseURL, route, params.Encode()), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	log.Printf("Listing content: %s", req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, newStatusError(resp)
+	}
+
+	var items []models.WordPressPost
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		return nil, 0, err
+	}
+	totalPages, _ := strconv.Atoi(resp.Header.Get("X-WP-TotalPages"))
+	return items, totalPages, nil
+}
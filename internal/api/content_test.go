@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestListContent(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/pages" {
+			t.Errorf("Expected path /wp-json/wp/v2/pages, got %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("lang") != "fr" || q.Get("per_page") != "100" || q.Get("_fields") != linkFields {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		requested = append(requested, q.Get("page"))
+
+		page, _ := strconv.Atoi(q.Get("page"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-WP-TotalPages", "2")
+		json.NewEncoder(w).Encode([]models.WordPressPost{{ID: page, Slug: "page-" + q.Get("page")}})
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	content, err := client.ListContent(context.Background(), "wp/v2/pages", "fr")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(content) != 2 || content[0].Slug != "page-1" || content[1].Slug != "page-2" {
+		t.Errorf("Expected the content of both pages, got %+v", content)
+	}
+	if len(requested) != 2 {
+		t.Errorf("Expected 2 requests, got %v", requested)
+	}
+}
+
+func TestListContentError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	if _, err := client.ListContent(context.Background(), "wp/v2/pages", "en"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
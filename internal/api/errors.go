@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"wordpress-go-proxy/internal/cache"
+)
+
+// maxErrorBody caps the excerpt of an error response body kept in a
+// StatusError.
+const maxErrorBody = 512
+
+// DefaultBackoff is how long the client stops sending requests after a 429
+// response without a Retry-After header, and MaxBackoff caps the delay
+// asked for by one, so that a misconfigured WAF cannot take the site's
+// content offline for hours.
+const (
+	DefaultBackoff = 10 * time.Second
+	MaxBackoff     = 5 * time.Minute
+)
+
+// The classes of WordPress API errors, for handlers to map to responses and
+// for metrics to count.  Check for them with errors.Is.
+var (
+	// ErrNotFound is a 404 or 410 response.
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthorized is a 401 or 403 response, such as when the
+	// application password was revoked.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrRateLimited is a 429 response, or a request not sent while the
+	// client backs off after one.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrUpstreamTimeout is a request that timed out, or a 408 or 504
+	// response.
+	ErrUpstreamTimeout = errors.New("upstream timeout")
+)
+
+// StatusError is an unexpected response from the WordPress API, with an
+// excerpt of its body.  RetryAfter is the delay of a 429 or 503 response's
+// Retry-After header, or zero if it has none.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+// newStatusError returns the error of an unexpected response, reading an
+// excerpt of its body.
+func newStatusError(resp *http.Response) *StatusError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	e := &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		e.RetryAfter = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return e
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("WordPress API returned status: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("WordPress API returned status: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// Is reports whether the error is in the class of target, one of the
+// ErrNotFound, ErrUnauthorized, ErrRateLimited and ErrUpstreamTimeout
+// errors.  Rate limited responses are also cache.ErrUnavailable, so that
+// cached pages are served while WordPress is rate limiting the proxy.
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited, cache.ErrUnavailable:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUpstreamTimeout:
+		return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusGatewayTimeout
+	}
+	return false
+}
+
+// BackoffError is returned for requests not sent while the client backs off
+// after a 429 response.  RetryAfter is the time left until it sends
+// requests again.
+type BackoffError struct {
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *BackoffError) Error() string {
+	return fmt.Sprintf("WordPress API rate limited, retrying in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Is reports whether target is ErrRateLimited or cache.ErrUnavailable.
+func (e *BackoffError) Is(target error) bool {
+	return target == ErrRateLimited || target == cache.ErrUnavailable
+}
+
+// RetryAfter returns how long to wait before retrying a rate limited
+// request, or zero if err is not rate limited or WordPress did not say.
+func RetryAfter(err error) time.Duration {
+	var statusErr *StatusError
+	var backoffErr *BackoffError
+	switch {
+	case errors.As(err, &backoffErr):
+		return backoffErr.RetryAfter
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests:
+		return statusErr.RetryAfter
+	}
+	return 0
+}
+
+// retryAfter parses a Retry-After header given in seconds or as a date.
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// ErrorClass returns the class of an error for metrics and logs:
+// "not_found", "unauthorized", "rate_limited" or "timeout" for the errors
+// of those classes, "upstream" for other error responses, and "other" for
+// anything else, such as a refused connection or an invalid response.
+func ErrorClass(err error) string {
+	var statusErr *StatusError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrUpstreamTimeout):
+		return "timeout"
+	case errors.As(err, &statusErr):
+		return "upstream"
+	}
+	return "other"
+}
+
+// do sends a request with the client's HTTP client and headers, marking
+// requests that timed out with ErrUpstreamTimeout.  A 429 response makes the client back
+// off for the delay of its Retry-After header: every request fails with a
+// BackoffError until then rather than adding to the load that got the
+// proxy rate limited.
+func (c *WordPressClient) do(req *http.Request) (*http.Response, error) {
+	if wait := c.backoffRemaining(); wait > 0 {
+		return nil, &BackoffError{RetryAfter: wait}
+	}
+	c.addHeaders(req)
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return nil, fmt.Errorf("%w: %w", ErrUpstreamTimeout, err)
+		}
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		c.backOff(retryAfter(resp.Header.Get("Retry-After"), time.Now()))
+	}
+	return resp, nil
+}
+
+// backOff stops requests for the given delay, or DefaultBackoff if it is
+// zero, capped at MaxBackoff.  A backoff already in progress is only ever
+// extended.
+func (c *WordPressClient) backOff(delay time.Duration) {
+	if delay <= 0 {
+		delay = DefaultBackoff
+	}
+	until := time.Now().Add(min(delay, MaxBackoff)).UnixNano()
+	for {
+		current := c.backoffUntil.Load()
+		if current >= until {
+			return
+		}
+		if c.backoffUntil.CompareAndSwap(current, until) {
+			log.Printf("WordPress API rate limited, backing off for %s", min(delay, MaxBackoff))
+			return
+		}
+	}
+}
+
+// backoffRemaining returns the time left until the client sends requests
+// again, or zero if it is not backing off.
+func (c *WordPressClient) backoffRemaining() time.Duration {
+	until := c.backoffUntil.Load()
+	if until == 0 {
+		return 0
+	}
+	return max(time.Until(time.Unix(0, until)), 0)
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/cache"
+)
+
+func TestStatusErrorClasses(t *testing.T) {
+	tests := []struct {
+		status   int
+		class    error
+		expected string
+	}{
+		{http.StatusNotFound, ErrNotFound, "not_found"},
+		{http.StatusGone, ErrNotFound, "not_found"},
+		{http.StatusUnauthorized, ErrUnauthorized, "unauthorized"},
+		{http.StatusForbidden, ErrUnauthorized, "unauthorized"},
+		{http.StatusTooManyRequests, ErrRateLimited, "rate_limited"},
+		{http.StatusGatewayTimeout, ErrUpstreamTimeout, "timeout"},
+		{http.StatusBadGateway, nil, "upstream"},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(strings.Repeat("x", maxErrorBody*2)))
+		}))
+		client := &WordPressClient{BaseURL: server.URL}
+		_, err := client.FetchSiteSettings(context.Background(), "en")
+		server.Close()
+
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status {
+			t.Fatalf("Expected a StatusError with status %d, got %v", tt.status, err)
+		}
+		if len(statusErr.Body) != maxErrorBody {
+			t.Errorf("Expected the body cut to %d bytes, got %d", maxErrorBody, len(statusErr.Body))
+		}
+		if tt.class != nil && !errors.Is(err, tt.class) {
+			t.Errorf("Expected status %d to be %v", tt.status, tt.class)
+		}
+		if got := ErrorClass(err); got != tt.expected {
+			t.Errorf("Expected status %d in class %q, got %q", tt.status, tt.expected, got)
+		}
+	}
+
+	if !errors.Is(ErrPageNotFound, ErrNotFound) {
+		t.Error("Expected ErrPageNotFound to be in the ErrNotFound class")
+	}
+	if got := ErrorClass(errors.New("connection refused")); got != "other" {
+		t.Errorf("Expected other errors in class other, got %q", got)
+	}
+}
+
+func TestStatusErrorRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	_, err := client.FetchSiteSettings(context.Background(), "en")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.RetryAfter != 2*time.Minute {
+		t.Errorf("Expected a retry after 2 minutes, got %v", err)
+	}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if got := retryAfter(now.Add(time.Minute).Format(http.TimeFormat), now); got != time.Minute {
+		t.Errorf("Expected a retry after a minute from a date, got %v", got)
+	}
+	if got := retryAfter("soon", now); got != 0 {
+		t.Errorf("Expected no delay for an invalid header, got %v", got)
+	}
+}
+
+func TestClientBackoff(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	client.FetchSiteSettings(context.Background(), "en")
+
+	// Requests are not sent while backing off, for at most MaxBackoff
+	_, err := client.FetchSiteSettings(context.Background(), "fr")
+	if requests.Load() != 1 {
+		t.Errorf("Expected 1 request while backing off, got %d", requests.Load())
+	}
+	var backoffErr *BackoffError
+	if !errors.As(err, &backoffErr) || !errors.Is(err, ErrRateLimited) || !errors.Is(err, cache.ErrUnavailable) {
+		t.Fatalf("Expected a BackoffError, got %v", err)
+	}
+	if wait := RetryAfter(err); wait <= MaxBackoff-time.Minute || wait > MaxBackoff {
+		t.Errorf("Expected a retry after at most %v, got %v", MaxBackoff, wait)
+	}
+
+	// The backoff is shared by every request and lifts once it is over
+	client.backoffUntil.Store(time.Now().Add(-time.Second).UnixNano())
+	if _, err := client.Ping(context.Background()); !errors.Is(err, ErrRateLimited) || requests.Load() != 2 {
+		t.Errorf("Expected a request once the backoff is over, got %d requests and %v", requests.Load(), err)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.FetchSiteSettings(ctx, "en")
+	if !errors.Is(err, ErrUpstreamTimeout) || ErrorClass(err) != "timeout" {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// SubmitFeedback records a visitor's feedback on a page by posting it as
+// JSON to a REST route of a WordPress plugin, such as
+// page-feedback/v1/feedback.  The request is authenticated with the
+// client's credentials so the route can refuse other callers.
+func (c *WordPressClient) SubmitFeedback(ctx context.Context, route string, feedback models.PageFeedback) error {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	body, err := json.Marshal(feedback)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/wp-json/%s", c.BaseURL, route), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", c.authorization())
+
+	log.Printf("Submitting feedback on page %d", feedback.PageID)
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newStatusError(resp)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestSubmitFeedback(t *testing.T) {
+	answer := models.PageFeedback{PageID: 42, Lang: "en", Helpful: true, Time: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+	for _, status := range []int{http.StatusCreated, http.StatusForbidden} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != "/wp-json/page-feedback/v1/feedback" {
+				t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+			}
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+				t.Errorf("Expected credentials, got %q", r.Header.Get("Authorization"))
+			}
+			var got models.PageFeedback
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil || !got.Time.Equal(answer.Time) || got.PageID != 42 || !got.Helpful {
+				t.Errorf("Unexpected feedback %+v: %v", got, err)
+			}
+			w.WriteHeader(status)
+		}))
+
+		client := &WordPressClient{BaseURL: server.URL, WordPressAuth: basicAuth("user", "pass")}
+		err := client.SubmitFeedback(context.Background(), "page-feedback/v1/feedback", answer)
+		if (err != nil) != (status != http.StatusCreated) {
+			t.Errorf("Status %d: unexpected error %v", status, err)
+		}
+		server.Close()
+	}
+}
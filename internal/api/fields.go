@@ -0,0 +1,43 @@
+package api
+
+import (
+	"strings"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// Fields requested from WordPress with the _fields parameter, so that
+// responses only hold what the proxy decodes rather than the full objects
+// with their links, SEO plugin data and other unused fields.
+const (
+	// menuItemFields are the fields of models.WordPressMenuItem.
+	menuItemFields = "id,title,parent,url,menu_order,attr_title,description,target,classes"
+
+	// postFields are the fields of models.WordPressPost.
+	postFields = "id,slug,lang,link,date_gmt,modified_gmt,title,excerpt"
+
+	// linkFields are the fields of models.WordPressPost that locate it.
+	linkFields = "id,slug,lang,link"
+
+	// searchFields are the fields of models.WordPressSearchResult.
+	searchFields = "id,title,url,type,subtype"
+
+	// commentFields are the fields of models.WordPressComment.
+	commentFields = "id,post,parent,author_name,date,date_gmt,content"
+)
+
+// pageFields returns the fields of models.WordPressPage, with the
+// translation slug of each language served.
+func pageFields() string {
+	fields := []string{
+		"id", "parent", "slug", "lang", "modified", "modified_gmt", "date", "date_gmt",
+		"status", "content", "title", "excerpt", "featured_media", "categories",
+		"meta", "acf", "comment_status", "template", "yoast_head_json", "slug_en", "slug_fr",
+	}
+	for _, lang := range models.Languages {
+		if lang.Code != "en" && lang.Code != "fr" {
+			fields = append(fields, "slug_"+lang.Code)
+		}
+	}
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// FormIDParam is the placeholder for the form ID in the REST route forms
+// are submitted to.
+const FormIDParam = "{id}"
+
+// ErrFormRejected is returned when the forms plugin refuses a submission,
+// such as one it considers invalid or spam.
+var ErrFormRejected = errors.New("form submission rejected")
+
+// rejectedFormStatuses are the statuses Contact Form 7 responds with when
+// a submission is not sent, along with a 200 status code.
+var rejectedFormStatuses = map[string]bool{
+	"validation_failed":  true,
+	"acceptance_missing": true,
+	"spam":               true,
+	"aborted":            true,
+	"mail_failed":        true,
+}
+
+// SubmitForm forwards a form submission to the REST route of a WordPress
+// forms plugin, such as gf/v2/forms/{id}/submissions for Gravity Forms or
+// contact-form-7/v1/contact-forms/{id}/feedback for Contact Form 7, with
+// the form's ID in place of {id}.  The values are posted as form fields,
+// which both plugins accept.
+func (c *WordPressClient) SubmitForm(ctx context.Context, route string, id string, values url.Values) error {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	route = strings.ReplaceAll(route, FormIDParam, url.PathEscape(id))
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/wp-json/%s", c.BaseURL, route), strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	log.Printf("Submitting form %s", id)
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%w: status %d, body: %s", ErrFormRejected, resp.StatusCode, string(body))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newStatusError(resp)
+	}
+
+	// Plugins may report a refused submission in the body of a successful
+	// response, Gravity Forms with is_valid and Contact Form 7 with status
+	var result struct {
+		IsValid *bool  `json:"is_valid"`
+		Status  string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil
+	}
+	if (result.IsValid != nil && !*result.IsValid) || rejectedFormStatuses[result.Status] {
+		return fmt.Errorf("%w: status %q", ErrFormRejected, result.Status)
+	}
+	return nil
+}
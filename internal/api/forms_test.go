@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSubmitForm(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   int
+		body     string
+		err      bool
+		rejected bool
+	}{
+		{name: "Contact Form 7 sent", status: http.StatusOK, body: `{"status":"mail_sent"}`},
+		{name: "Contact Form 7 spam", status: http.StatusOK, body: `{"status":"spam"}`, err: true, rejected: true},
+		{name: "Gravity Forms sent", status: http.StatusOK, body: `{"is_valid":true}`},
+		{name: "Gravity Forms invalid", status: http.StatusBadRequest, body: `{"is_valid":false}`, err: true, rejected: true},
+		{name: "Empty body", status: http.StatusCreated},
+		{name: "Server error", status: http.StatusInternalServerError, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/wp-json/contact-form-7/v1/contact-forms/12/feedback" {
+					t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+				if r.PostFormValue("your-name") != "Ada" {
+					t.Errorf("Expected form values to be forwarded, got %v", r.PostForm)
+				}
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			client := &WordPressClient{BaseURL: server.URL}
+			err := client.SubmitForm(context.Background(), "contact-form-7/v1/contact-forms/{id}/feedback", "12", url.Values{"your-name": {"Ada"}})
+			if (err != nil) != tc.err || errors.Is(err, ErrFormRejected) != tc.rejected {
+				t.Errorf("Expected error %v, rejected %v, got %v", tc.err, tc.rejected, err)
+			}
+		})
+	}
+}
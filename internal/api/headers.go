@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// headerName matches a valid header name.
+var headerName = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// reservedHeaders are the headers the client sets itself, which cannot be
+// added to every request.
+var reservedHeaders = map[string]bool{
+	"Authorization":     true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Host":              true,
+	"Transfer-Encoding": true,
+}
+
+// ParseHeaders parses "Name: value" headers, one per line or separated by
+// commas, such as the shared secret of a CloudFront origin custom header.
+func ParseHeaders(s string) (http.Header, error) {
+	header := make(http.Header)
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == ',' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || !headerName.MatchString(name) || value == "" || strings.ContainsAny(value, "\r\x00") {
+			return nil, fmt.Errorf("invalid header %q", name)
+		}
+		if reservedHeaders[http.CanonicalHeaderKey(name)] {
+			return nil, fmt.Errorf("header %q cannot be set", name)
+		}
+		header.Add(name, value)
+	}
+	if len(header) == 0 {
+		return nil, fmt.Errorf("no headers")
+	}
+	return header, nil
+}
+
+// SetHeaders replaces the headers added to every request to WordPress, such
+// as when the secret of one is rotated.
+func (c *WordPressClient) SetHeaders(header http.Header) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.Headers = header.Clone()
+}
+
+// addHeaders adds the client's headers to a request, replacing those of
+// the same name, such as headers forwarded from a visitor's request.
+func (c *WordPressClient) addHeaders(req *http.Request) {
+	c.authMu.RLock()
+	defer c.authMu.RUnlock()
+	for name, values := range c.Headers {
+		req.Header[name] = append([]string(nil), values...)
+	}
+}
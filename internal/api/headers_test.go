@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseHeaders(t *testing.T) {
+	header, err := ParseHeaders("X-Origin-Verify: s3cr3t\nx-proxy: wordpress-go-proxy, X-Proxy: second")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := http.Header{
+		"X-Origin-Verify": {"s3cr3t"},
+		"X-Proxy":         {"wordpress-go-proxy", "second"},
+	}
+	if !reflect.DeepEqual(header, expected) {
+		t.Errorf("Expected %v, got %v", expected, header)
+	}
+
+	for _, value := range []string{"", "X-Origin-Verify", "X-Origin-Verify:", "Bad Name: value", "Authorization: Bearer token", "host: example.com"} {
+		if _, err := ParseHeaders(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestClientHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	client.SetHeaders(http.Header{"X-Origin-Verify": {"s3cr3t"}})
+
+	resp, err := client.FetchMedia(context.Background(), "2024/01/image.png", http.Header{"X-Origin-Verify": {"forged"}}, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if got := received.Values("X-Origin-Verify"); !reflect.DeepEqual(got, []string{"s3cr3t"}) {
+		t.Errorf("Expected the configured header to replace the forwarded one, got %v", got)
+	}
+
+	// Rotated headers are used by later requests
+	client.SetHeaders(http.Header{"X-Origin-Verify": {"rotated"}})
+	if _, err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := received.Get("X-Origin-Verify"); got != "rotated" {
+		t.Errorf("Expected the rotated header, got %q", got)
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+
+	"wordpress-go-proxy/internal/router"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// Permalinks resolves the permalinks of a directly hosted WordPress site to
+// the paths the proxy serves their content at, so that old links keep
+// working after a migration.  Query permalinks such as /?p=123 and
+// /?page_id=123 are looked up by ID, and date permalinks such as
+// /2024/05/slug by the slug of a post of one of the post types.
+type Permalinks struct {
+	Client    *WordPressClient
+	PostTypes []models.PostType
+}
+
+// NewPermalinks creates a permalink resolver for the content of the given
+// client and custom post types.
+func NewPermalinks(client *WordPressClient, postTypes []models.PostType) *Permalinks {
+	return &Permalinks{
+		Client:    client,
+		PostTypes: postTypes,
+	}
+}
+
+// Permalink patterns of a directly hosted WordPress site.
+var (
+	queryPermalink    = router.MustParse("/")
+	monthPermalink    = router.MustParse("/{year}/{month}/{slug}")
+	dayPermalink      = router.MustParse("/{year}/{month}/{day}/{slug}")
+	permalinkIDParams = []string{"page_id", "p"}
+)
+
+// Resolve returns the proxy path of the page or post at a WordPress
+// permalink.  It reports false if the URL is not a permalink or no content
+// has it.
+func (p *Permalinks) Resolve(ctx context.Context, u *url.URL) (string, bool) {
+	if params, ok := queryPermalink.MatchLocalized(u.Path); ok {
+		query := u.Query()
+		for _, name := range permalinkIDParams {
+			id, err := strconv.Atoi(query.Get(name))
+			if err != nil || id <= 0 {
+				continue
+			}
+			return p.resolveID(ctx, name, id, params[router.LangParam])
+		}
+		return "", false
+	}
+
+	params, ok := dayPermalink.MatchLocalized(u.Path)
+	if !ok || !digits(params["day"], 2) {
+		params, ok = monthPermalink.MatchLocalized(u.Path)
+	}
+	if !ok || !digits(params["year"], 4) || !digits(params["month"], 2) {
+		return "", false
+	}
+	lang := params[router.LangParam]
+	for _, postType := range p.PostTypes {
+		post, err := p.Client.FetchPost(ctx, postType, params["slug"], lang)
+		if err != nil {
+			logPermalinkError(u.Path, err)
+			continue
+		}
+		return postType.PostPath(permalinkLang(post, lang), post.Slug), true
+	}
+	return "", false
+}
+
+// resolveID returns the proxy path of the content with an ID.  Page IDs
+// are only looked up among pages, while post IDs may be of a post of any
+// type, as in WordPress.
+func (p *Permalinks) resolveID(ctx context.Context, param string, id int, lang string) (string, bool) {
+	permalink := "?" + param + "=" + strconv.Itoa(id)
+	if param == "p" {
+		for _, postType := range p.PostTypes {
+			post, err := p.Client.fetchByID(ctx, postType.Route, id)
+			if err != nil {
+				logPermalinkError(permalink, err)
+				continue
+			}
+			return postType.PostPath(permalinkLang(post, lang), post.Slug), true
+		}
+	}
+
+	page, err := p.Client.fetchPageByID(ctx, id)
+	if err != nil {
+		logPermalinkError(permalink, err)
+		return "", false
+	}
+	return models.PagePath(permalinkLang(page, lang), page.Slug), true
+}
+
+// permalinkLang returns the language of content found at a permalink,
+// falling back to the language of the permalink's path.
+func permalinkLang(page *models.WordPressPage, lang string) string {
+	if _, ok := models.Languages.Get(page.Lang); ok {
+		return page.Lang
+	}
+	return lang
+}
+
+// logPermalinkError logs an error resolving a permalink, other than the
+// permalink not being found.
+func logPermalinkError(permalink string, err error) {
+	if !errors.Is(err, ErrPageNotFound) {
+		log.Printf("Error resolving permalink %s: %v", permalink, err)
+	}
+}
+
+// digits reports whether a string is made of n decimal digits.
+func digits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestPermalinksResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/wp-json/wp/v2/pages/10":
+			json.NewEncoder(w).Encode(models.WordPressPage{ID: 10, Status: "publish", Slug: "about", Lang: "en"})
+		case "/wp-json/wp/v2/pages/11":
+			json.NewEncoder(w).Encode(models.WordPressPage{ID: 11, Status: "publish", Slug: "a-propos", Lang: "fr"})
+		case "/wp-json/wp/v2/events/20":
+			json.NewEncoder(w).Encode(models.WordPressPage{ID: 20, Status: "publish", Slug: "launch", Lang: "en"})
+		case "/wp-json/wp/v2/events":
+			if r.URL.Query().Get("slug") == "launch" {
+				json.NewEncoder(w).Encode([]models.WordPressPage{{ID: 20, Status: "publish", Slug: "launch", Lang: r.URL.Query().Get("lang")}})
+				return
+			}
+			json.NewEncoder(w).Encode([]models.WordPressPage{})
+		case "/wp-json/wp/v2/events/500":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	permalinks := NewPermalinks(&WordPressClient{BaseURL: server.URL}, []models.PostType{{Name: "events", Route: "wp/v2/events"}})
+
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{"/?page_id=10", "/about"},
+		{"/?page_id=11", "/fr/a-propos"},
+		{"/?p=20", "/events/launch"},
+		{"/?p=10", "/about"},
+		{"/fr/?p=20", "/events/launch"},
+		{"/2024/05/launch/", "/events/launch"},
+		{"/fr/2024/05/12/launch", "/fr/events/launch"},
+		{"/?page_id=20", ""},
+		{"/?p=404", ""},
+		{"/?p=500", ""},
+		{"/?p=abc", ""},
+		{"/", ""},
+		{"/about?p=10", ""},
+		{"/2024/05/missing", ""},
+		{"/24/05/launch", ""},
+		{"/2024/may/launch", ""},
+		{"/2024/05/1/launch", ""},
+	}
+	for _, tc := range testCases {
+		u, _ := url.Parse(tc.url)
+		path, ok := permalinks.Resolve(context.Background(), u)
+		if ok != (tc.expected != "") || path != tc.expected {
+			t.Errorf("Resolve(%q): expected %q, got %q, %v", tc.url, tc.expected, path, ok)
+		}
+	}
+}
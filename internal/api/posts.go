@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// FetchPosts retrieves the most recent published posts in a language.
+func (c *WordPressClient) FetchPosts(ctx context.Context, lang string, count int) ([]models.WordPressPost, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	params := url.Values{
+		"lang":     {lang},
+		"per_page": {strconv.Itoa(count)},
+		"orderby":  {"date"},
+		"order":    {"desc"},
+		"_fields":  {postFields},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/wp/v2/posts?%s", c.BaseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetching posts: %s", req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse JSON response
+	var posts []models.WordPressPost
+	err = json.Unmarshal(body, &posts)
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestFetchPosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			t.Errorf("Expected path /wp-json/wp/v2/posts, got %s", r.URL.Path)
+		}
+
+		q := r.URL.Query()
+		if q.Get("lang") != "fr" || q.Get("per_page") != "5" || q.Get("orderby") != "date" || q.Get("order") != "desc" || q.Get("_fields") != postFields {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+
+		if q.Get("lang") == "fr" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode([]models.WordPressPost{{ID: 1, Slug: "nouvelles"}})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	posts, err := client.FetchPosts(context.Background(), "fr", 5)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(posts) != 1 || posts[0].Slug != "nouvelles" {
+		t.Errorf("Expected 1 post with slug 'nouvelles', got %+v", posts)
+	}
+}
+
+func TestFetchPostsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	if _, err := client.FetchPosts(context.Background(), "en", 5); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
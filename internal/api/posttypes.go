@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"wordpress-go-proxy/internal/tracing"
+	"wordpress-go-proxy/pkg/models"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FetchPost retrieves a post of a custom post type by its slug and
+// language.  Posts share the page cache, keyed by post type, and are
+// revalidated like pages.
+func (c *WordPressClient) FetchPost(ctx context.Context, postType models.PostType, slug string, lang string) (*models.WordPressPage, error) {
+	tracing.Annotate(ctx, attribute.String("wordpress.slug", slug), attribute.String("wordpress.lang", lang))
+	if c.PageCache == nil {
+		return c.fetchPageShared(ctx, postType.Route, nil, slug, lang)
+	}
+	key := postType.Name + ":" + lang + "/" + slug
+	return c.PageCache.Get(ctx, key, c.revalidated(key, postType.Route, func(ctx context.Context) (*models.WordPressPage, error) {
+		return c.fetchPageShared(ctx, postType.Route, nil, slug, lang)
+	}))
+}
+
+// FetchArchive retrieves a page of the most recent posts of a custom post
+// type in a language.  Pages past the last page of posts return an empty
+// archive.
+func (c *WordPressClient) FetchArchive(ctx context.Context, postType models.PostType, lang string, page int) (*models.Archive, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	params := url.Values{
+		"lang":     {lang},
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(c.pageSize())},
+		"orderby":  {"date"},
+		"order":    {"desc"},
+		"_fields":  {"id,slug,lang,modified,title,excerpt"},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/%s?%s", c.BaseURL, postType.Route, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetching %s archive: %s", postType.Name, req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// WordPress rejects page numbers past the end of the posts
+	if resp.StatusCode == http.StatusBadRequest && page > 1 {
+		return &models.Archive{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+
+	archive := &models.Archive{}
+	if err := json.NewDecoder(resp.Body).Decode(&archive.Posts); err != nil {
+		return nil, err
+	}
+
+	archive.Total, _ = strconv.Atoi(resp.Header.Get("X-WP-Total"))
+	archive.TotalPages, _ = strconv.Atoi(resp.Header.Get("X-WP-TotalPages"))
+
+	return archive, nil
+}
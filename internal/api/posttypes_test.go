@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/pkg/models"
+)
+
+var events = models.PostType{Name: "events", Route: "wp/v2/events"}
+
+func TestFetchPost(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/wp-json/wp/v2/events" {
+			t.Errorf("Expected path /wp-json/wp/v2/events, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("slug") != "launch" || r.URL.Query().Get("lang") != "fr" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":7,"slug":"launch","lang":"fr","status":"publish","meta":{"venue":"Ottawa"}}]`))
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{
+		BaseURL:   server.URL,
+		PageCache: cache.New[*models.WordPressPage](cache.Policy{TTL: time.Minute}),
+	}
+
+	for i := 0; i < 2; i++ {
+		post, err := client.FetchPost(context.Background(), events, "launch", "fr")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if post.ID != 7 || post.Meta["venue"] != "Ottawa" {
+			t.Errorf("Expected post 7 with venue meta, got %+v", post)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected the post to be cached after 1 request, got %d", requests)
+	}
+}
+
+func TestFetchArchive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/events" {
+			t.Errorf("Expected path /wp-json/wp/v2/events, got %s", r.URL.Path)
+		}
+
+		q := r.URL.Query()
+		if q.Get("lang") != "en" || q.Get("per_page") != "10" || q.Get("orderby") != "date" || q.Get("order") != "desc" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+
+		if q.Get("page") == "9" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"rest_post_invalid_page_number"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-WP-Total", "11")
+		w.Header().Set("X-WP-TotalPages", "2")
+		w.Write([]byte(`[{"id":1,"slug":"launch","title":{"rendered":"Launch"}},{"id":2,"slug":"demo","title":{"rendered":"Demo"}}]`))
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	archive, err := client.FetchArchive(context.Background(), events, "en", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(archive.Posts) != 2 || archive.Posts[0].Slug != "launch" {
+		t.Errorf("Expected 2 posts starting with 'launch', got %+v", archive.Posts)
+	}
+	if archive.Total != 11 || archive.TotalPages != 2 {
+		t.Errorf("Expected totals 11/2, got %d/%d", archive.Total, archive.TotalPages)
+	}
+
+	// Pages past the end return no posts rather than an error
+	archive, err = client.FetchArchive(context.Background(), events, "en", 9)
+	if err != nil {
+		t.Fatalf("Expected no error past the last page, got %v", err)
+	}
+	if len(archive.Posts) != 0 {
+		t.Errorf("Expected no posts past the last page, got %+v", archive.Posts)
+	}
+}
+
+func TestFetchArchiveError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	if _, err := client.FetchArchive(context.Background(), events, "en", 1); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
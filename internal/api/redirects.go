@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// redirectsPerPage is the number of redirects requested per page.
+const redirectsPerPage = 200
+
+// maxRedirectPages limits the number of redirect pages fetched.
+const maxRedirectPages = 50
+
+// FetchRedirects retrieves all enabled redirects from the Redirection
+// plugin's REST API.  The endpoint requires authentication.  Each page is
+// fetched with its own timeout, so a large rule set is not cut short by
+// the time the earlier pages took.
+func (c *WordPressClient) FetchRedirects(ctx context.Context) ([]models.WordPressRedirect, error) {
+	var redirects []models.WordPressRedirect
+	for page := 0; ; page++ {
+		result, err := c.fetchRedirectPage(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+
+		redirects = append(redirects, result.Items...)
+		if len(result.Items) < redirectsPerPage || len(redirects) >= result.Total {
+			break
+		}
+		if page+1 >= maxRedirectPages {
+			log.Printf("Loaded only %d of %d redirects: stopped after %d pages", len(redirects), result.Total, maxRedirectPages)
+			break
+		}
+	}
+
+	return redirects, nil
+}
+
+// fetchRedirectPage retrieves one page of enabled redirects.
+func (c *WordPressClient) fetchRedirectPage(ctx context.Context, page int) (models.WordPressRedirects, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Menu, DefaultTimeouts.Menu)
+	defer cancel()
+
+	var result models.WordPressRedirects
+	params := url.Values{
+		"per_page":         {strconv.Itoa(redirectsPerPage)},
+		"page":             {strconv.Itoa(page)},
+		"filterBy[status]": {"enabled"},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/redirection/v1/redirect?%s", c.BaseURL, params.Encode()), nil)
+	if err != nil {
+		return result, err
+	}
+	req.Header.Add("Authorization", c.authorization())
+
+	log.Printf("Fetching redirects: %s", req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return result, newStatusError(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestFetchRedirects(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic dGVzdA==" {
+			t.Errorf("Expected basic auth, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("filterBy[status]") != "enabled" {
+			t.Errorf("Expected enabled filter, got %q", r.URL.RawQuery)
+		}
+
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		result := models.WordPressRedirects{Total: redirectsPerPage + 1}
+		count := redirectsPerPage
+		if page == "1" {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			result.Items = append(result.Items, models.WordPressRedirect{Url: fmt.Sprintf("/%s-%d", page, i)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL, WordPressAuth: "dGVzdA=="}
+	redirects, err := client.FetchRedirects(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(redirects) != redirectsPerPage+1 {
+		t.Errorf("Expected %d redirects, got %d", redirectsPerPage+1, len(redirects))
+	}
+	if strings.Join(pages, ",") != "0,1" {
+		t.Errorf("Expected pages 0 and 1 to be fetched, got %v", pages)
+	}
+}
+
+func TestFetchRedirectsPageTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		result := models.WordPressRedirects{Total: 2*redirectsPerPage + 1}
+		count := redirectsPerPage
+		if r.URL.Query().Get("page") == "2" {
+			count = 1
+		}
+		result.Items = make([]models.WordPressRedirect, count)
+		json.NewEncoder(w).Encode(result)
+	}))
+	defer server.Close()
+
+	// The pages take longer than the timeout together, but not each
+	client := &WordPressClient{BaseURL: server.URL, Timeouts: Timeouts{Menu: 50 * time.Millisecond}}
+	redirects, err := client.FetchRedirects(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(redirects) != 2*redirectsPerPage+1 {
+		t.Errorf("Expected %d redirects, got %d", 2*redirectsPerPage+1, len(redirects))
+	}
+}
+
+func TestFetchRedirectsPageLimit(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		result := models.WordPressRedirects{Total: (maxRedirectPages + 1) * redirectsPerPage}
+		result.Items = make([]models.WordPressRedirect, redirectsPerPage)
+		json.NewEncoder(w).Encode(result)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	redirects, err := client.FetchRedirects(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requests.Load() != maxRedirectPages {
+		t.Errorf("Expected %d requests, got %d", maxRedirectPages, requests.Load())
+	}
+	if len(redirects) != maxRedirectPages*redirectsPerPage {
+		t.Errorf("Expected %d redirects, got %d", maxRedirectPages*redirectsPerPage, len(redirects))
+	}
+}
+
+func TestFetchRedirectsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	_, err := client.FetchRedirects(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "status: 403") {
+		t.Errorf("Expected status error, got %v", err)
+	}
+}
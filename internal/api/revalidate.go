@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// wordPressTime is the layout of the dates in WordPress REST responses.
+const wordPressTime = "2006-01-02T15:04:05"
+
+// revalidated wraps the fetcher of a cached page so that, when the cache
+// holds an earlier copy of the page, WordPress is first asked whether the
+// page was modified since.  Only the page's modified date is requested, and
+// the earlier copy is kept if it is unchanged, which spares WordPress from
+// rendering the full page on every cache refresh.
+func (c *WordPressClient) revalidated(key string, route string, fetch cache.Fetcher[*models.WordPressPage]) cache.Fetcher[*models.WordPressPage] {
+	return func(ctx context.Context) (*models.WordPressPage, error) {
+		cached, ok := c.PageCache.Peek(key)
+		if !ok || cached == nil || cached.ID == 0 || cached.Modified == "" {
+			return fetch(ctx)
+		}
+
+		modified, err := c.pageModified(ctx, route, cached)
+		if err != nil {
+			log.Printf("Error revalidating page %d: %v", cached.ID, err)
+			return fetch(ctx)
+		}
+		if modified {
+			return fetch(ctx)
+		}
+		log.Printf("Page %d not modified since %s", cached.ID, cached.Modified)
+		return cached, nil
+	}
+}
+
+// pageModified reports whether a page was modified since it was fetched.
+// The modified date in UTC is sent as If-Modified-Since, so a cache in
+// front of WordPress can answer 304 Not Modified, and is otherwise
+// compared with the page's current modified date.  Pages that are gone
+// count as modified so that fetching them again reports them missing.
+func (c *WordPressClient) pageModified(ctx context.Context, route string, page *models.WordPressPage) (bool, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/%s/%d?_fields=id,modified,modified_gmt", c.BaseURL, route, page.ID), nil)
+	if err != nil {
+		return false, err
+	}
+	if modified, err := time.Parse(wordPressTime, page.ModifiedGMT); err == nil {
+		req.Header.Set("If-Modified-Since", modified.UTC().Format(http.TimeFormat))
+	}
+	c.authenticateContent(req)
+
+	log.Printf("Revalidating page: %s", req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone, http.StatusUnauthorized, http.StatusForbidden:
+		return true, nil
+	default:
+		return false, newStatusError(resp)
+	}
+
+	var current struct {
+		Modified    string `json:"modified"`
+		ModifiedGMT string `json:"modified_gmt"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&current); err != nil {
+		return false, err
+	}
+	if page.ModifiedGMT != "" && current.ModifiedGMT != "" {
+		return current.ModifiedGMT != page.ModifiedGMT, nil
+	}
+	return current.Modified != page.Modified, nil
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// TestFetchPageRevalidated tests that expired pages are kept when
+// WordPress reports them unmodified and fetched again otherwise
+func TestFetchPageRevalidated(t *testing.T) {
+	modified := "2024-05-01T10:00:00"
+	notModified := false
+	var fetches, revalidations int
+	var ifModifiedSince string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/wp-json/wp/v2/pages":
+			fetches++
+			json.NewEncoder(w).Encode([]models.WordPressPage{
+				{ID: 123, Status: "publish", Slug: "about-us", Lang: "en", Modified: modified, ModifiedGMT: modified},
+			})
+		case "/wp-json/wp/v2/pages/123":
+			revalidations++
+			ifModifiedSince = r.Header.Get("If-Modified-Since")
+			if r.URL.Query().Get("_fields") != "id,modified,modified_gmt" {
+				t.Errorf("Expected only the modified date requested, got %q", r.URL.RawQuery)
+			}
+			if notModified {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"id": 123, "modified": modified, "modified_gmt": modified})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// Every entry expires immediately, so each fetch after the first
+	// revalidates the cached page
+	client := &WordPressClient{
+		BaseURL:   server.URL,
+		PageCache: cache.New[*models.WordPressPage](cache.Policy{TTL: time.Nanosecond}),
+	}
+	fetch := func() *models.WordPressPage {
+		t.Helper()
+		page, err := client.FetchPage(context.Background(), "/about-us")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return page
+	}
+
+	first := fetch()
+	if second := fetch(); second != first {
+		t.Error("Expected unmodified page to be kept")
+	}
+	if fetches != 1 || revalidations != 1 {
+		t.Errorf("Expected 1 fetch and 1 revalidation, got %d and %d", fetches, revalidations)
+	}
+	if ifModifiedSince != "Wed, 01 May 2024 10:00:00 GMT" {
+		t.Errorf("Expected If-Modified-Since from the modified date, got %q", ifModifiedSince)
+	}
+
+	notModified = true
+	if page := fetch(); page != first || fetches != 1 {
+		t.Errorf("Expected page kept on 304, got %d fetches", fetches)
+	}
+
+	notModified = false
+	modified = "2024-06-01T10:00:00"
+	if page := fetch(); page.Modified != modified || fetches != 2 {
+		t.Errorf("Expected modified page fetched again, got %q after %d fetches", page.Modified, fetches)
+	}
+}
+
+// TestFetchPageRevalidationError tests that pages are fetched again when
+// they cannot be revalidated or are gone
+func TestFetchPageRevalidationError(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusNotFound} {
+		fetches := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/wp-json/wp/v2/pages/123" {
+				w.WriteHeader(status)
+				return
+			}
+			fetches++
+			json.NewEncoder(w).Encode([]models.WordPressPage{
+				{ID: 123, Status: "publish", Slug: "about-us", Lang: "en", Modified: "2024-05-01T10:00:00"},
+			})
+		}))
+
+		client := &WordPressClient{
+			BaseURL:   server.URL,
+			PageCache: cache.New[*models.WordPressPage](cache.Policy{TTL: time.Nanosecond}),
+		}
+		for range 2 {
+			if _, err := client.FetchPage(context.Background(), "/about-us"); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		if fetches != 2 {
+			t.Errorf("Status %d: expected page fetched again, got %d fetches", status, fetches)
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// Search queries the WordPress search endpoint for content matching the
+// query in the given language.  Pages past the last page of results
+// return an empty result set.
+func (c *WordPressClient) Search(ctx context.Context, query string, lang string, page int) (*models.SearchResults, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	params := url.Values{
+		"search":   {query},
+		"lang":     {lang},
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(c.pageSize())},
+		"_fields":  {searchFields},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/wp/v2/search?%s", c.BaseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Searching: %s", req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// WordPress rejects page numbers past the end of the results
+	if resp.StatusCode == http.StatusBadRequest && page > 1 {
+		return &models.SearchResults{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse JSON response
+	results := &models.SearchResults{}
+	err = json.Unmarshal(body, &results.Results)
+	if err != nil {
+		return nil, err
+	}
+
+	results.Total, _ = strconv.Atoi(resp.Header.Get("X-WP-Total"))
+	results.TotalPages, _ = strconv.Atoi(resp.Header.Get("X-WP-TotalPages"))
+
+	return results, nil
+}
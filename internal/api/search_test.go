@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestSearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/search" {
+			t.Errorf("Expected path /wp-json/wp/v2/search, got %s", r.URL.Path)
+		}
+
+		q := r.URL.Query()
+		if q.Get("search") != "tax & credits" {
+			t.Errorf("Expected search 'tax & credits', got %q", q.Get("search"))
+		}
+		if q.Get("lang") != "fr" {
+			t.Errorf("Expected lang fr, got %q", q.Get("lang"))
+		}
+		if q.Get("per_page") != "10" {
+			t.Errorf("Expected per_page 10, got %q", q.Get("per_page"))
+		}
+
+		if q.Get("page") == "9" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"rest_post_invalid_page_number"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-WP-Total", "12")
+		w.Header().Set("X-WP-TotalPages", "2")
+		json.NewEncoder(w).Encode([]models.WordPressSearchResult{
+			{ID: 1, Title: "Tax credits", Url: "https://example.com/fr/credits"},
+		})
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	results, err := client.Search(context.Background(), "tax & credits", "fr", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results.Results) != 1 || results.Results[0].Title != "Tax credits" {
+		t.Errorf("Expected 1 result titled 'Tax credits', got %+v", results.Results)
+	}
+	if results.Total != 12 || results.TotalPages != 2 {
+		t.Errorf("Expected totals 12/2, got %d/%d", results.Total, results.TotalPages)
+	}
+
+	// Pages past the end return no results rather than an error
+	results, err = client.Search(context.Background(), "tax & credits", "fr", 9)
+	if err != nil {
+		t.Fatalf("Expected no error for page past the end, got %v", err)
+	}
+	if len(results.Results) != 0 {
+		t.Errorf("Expected no results, got %d", len(results.Results))
+	}
+}
+
+func TestSearchError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	if _, err := client.Search(context.Background(), "tax", "en", 1); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestSearchPageSize(t *testing.T) {
+	var perPage string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		perPage = r.URL.Query().Get("per_page")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL, PageSize: 25}
+	if _, err := client.Search(context.Background(), "tax", "en", 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if perPage != "25" {
+		t.Errorf("Expected per_page 25, got %q", perPage)
+	}
+}
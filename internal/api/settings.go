@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// FetchSiteSettings retrieves the title, tagline and timezone of the site
+// in a language from the index of the REST API, which, unlike the settings
+// endpoint, needs no credentials and has its strings translated.
+func (c *WordPressClient) FetchSiteSettings(ctx context.Context, lang string) (*models.SiteSettings, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	params := url.Values{
+		"lang":    {lang},
+		"_fields": {"name,description,timezone_string,gmt_offset"},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/?%s", c.BaseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetching site settings: %s", req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+
+	settings := &models.SiteSettings{}
+	if err := json.NewDecoder(resp.Body).Decode(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
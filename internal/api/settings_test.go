@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchSiteSettings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/" {
+			t.Errorf("Expected path /wp-json/, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("_fields") != "name,description,timezone_string,gmt_offset" {
+			t.Errorf("Unexpected fields %q", r.URL.Query().Get("_fields"))
+		}
+		if r.URL.Query().Get("lang") == "de" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("lang") == "fr" {
+			w.Write([]byte(`{"name":"Site d'exemple","description":"Un slogan","timezone_string":"America/Toronto","gmt_offset":-5}`))
+			return
+		}
+		w.Write([]byte(`{"name":"Example Site","description":"A tagline","timezone_string":"America/Toronto","gmt_offset":-5}`))
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	settings, err := client.FetchSiteSettings(context.Background(), "fr")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if settings.Name != "Site d'exemple" || settings.Description != "Un slogan" || settings.TimezoneString != "America/Toronto" {
+		t.Errorf("Unexpected settings %+v", settings)
+	}
+
+	if _, err := client.FetchSiteSettings(context.Background(), "de"); err == nil {
+		t.Error("Expected an error for a failed request")
+	}
+}
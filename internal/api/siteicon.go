@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// FetchSiteIconURL returns the URL of the site icon set in the WordPress
+// customizer, from the index of the REST API, or an empty string if no
+// icon is set.
+func (c *WordPressClient) FetchSiteIconURL(ctx context.Context) (string, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/?_fields=site_icon_url", c.BaseURL), nil)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("Fetching site icon: %s", req.URL.String())
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newStatusError(resp)
+	}
+
+	var index struct {
+		SiteIconURL string `json:"site_icon_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+		return "", err
+	}
+	return index.SiteIconURL, nil
+}
+
+// SiteIconPath returns the path of a site icon URL within the uploads
+// directory, for use with FetchMedia.  False is returned for icons that
+// are not uploads of the WordPress site.
+func (c *WordPressClient) SiteIconPath(iconURL string) (string, bool) {
+	u, err := url.Parse(iconURL)
+	base, baseErr := url.Parse(c.BaseURL)
+	if err != nil || baseErr != nil || u.Host != base.Host {
+		return "", false
+	}
+	path, ok := strings.CutPrefix(u.Path, strings.TrimSuffix(base.Path, "/")+"/wp-content/uploads/")
+	if !ok || path == "" {
+		return "", false
+	}
+	return path, true
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchSiteIconURL(t *testing.T) {
+	icon := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/" || r.URL.Query().Get("_fields") != "site_icon_url" {
+			t.Errorf("Unexpected request %s", r.URL.RequestURI())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"site_icon_url":"` + icon + `"}`))
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	iconURL, err := client.FetchSiteIconURL(context.Background())
+	if err != nil || iconURL != "" {
+		t.Errorf("Expected no icon, got %q, %v", iconURL, err)
+	}
+
+	icon = server.URL + "/wp-content/uploads/2025/01/icon.png"
+	iconURL, err = client.FetchSiteIconURL(context.Background())
+	if err != nil || iconURL != icon {
+		t.Errorf("Expected %q, got %q, %v", icon, iconURL, err)
+	}
+}
+
+func TestSiteIconPath(t *testing.T) {
+	client := &WordPressClient{BaseURL: "https://wp.example.com/blog"}
+
+	testCases := []struct {
+		url      string
+		expected string
+		ok       bool
+	}{
+		{url: "https://wp.example.com/blog/wp-content/uploads/2025/01/icon.png", expected: "2025/01/icon.png", ok: true},
+		{url: "https://cdn.example.com/blog/wp-content/uploads/2025/01/icon.png"},
+		{url: "https://wp.example.com/wp-content/uploads/2025/01/icon.png"},
+		{url: "https://wp.example.com/blog/wp-includes/images/w-logo-blue.png"},
+	}
+	for _, tc := range testCases {
+		path, ok := client.SiteIconPath(tc.url)
+		if path != tc.expected || ok != tc.ok {
+			t.Errorf("Expected %q, %v for %s, got %q, %v", tc.expected, tc.ok, tc.url, path, ok)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// pemPrefix starts PEM encoded values given inline rather than as a path.
+const pemPrefix = "-----BEGIN"
+
+// readPEM returns a PEM value given inline, or reads it from the file at
+// the given path.
+func readPEM(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), pemPrefix) {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
+
+// LoadKeyPair loads a client certificate and its private key, each given
+// as PEM or as the path of a PEM file.
+func LoadKeyPair(cert string, key string) (tls.Certificate, error) {
+	certPEM, err := readPEM(cert)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("error reading certificate: %w", err)
+	}
+	keyPEM, err := readPEM(key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("error reading key: %w", err)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("invalid certificate or key: %w", err)
+	}
+	return pair, nil
+}
+
+// LoadCertPool loads the certificates of the CAs trusted to sign the
+// origin's certificate, given as PEM or as the path of a PEM file.
+func LoadCertPool(ca string) (*x509.CertPool, error) {
+	data, err := readPEM(ca)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA certificates: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no CA certificates found")
+	}
+	return pool, nil
+}
+
+// ClientCertificate is the certificate presented to an origin requiring
+// mutual TLS.  It can be replaced while in use, such as when it is rotated,
+// and is used from the next TLS handshake.
+type ClientCertificate struct {
+	cert atomic.Pointer[tls.Certificate]
+}
+
+// Set replaces the certificate.
+func (c *ClientCertificate) Set(cert tls.Certificate) {
+	c.cert.Store(&cert)
+}
+
+// TLSConfig returns a TLS configuration presenting the certificate, and
+// trusting the CAs of pool, or the system CAs if it is nil.
+func (c *ClientCertificate) TLSConfig(pool *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		RootCAs: pool,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := c.cert.Load(); cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCertificate returns a self-signed client certificate and its key as
+// PEM.
+func testCertificate(t *testing.T) (string, string) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "wordpress-go-proxy"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating certificate: %v", err)
+	}
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestLoadKeyPair(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t)
+	if _, err := LoadKeyPair(certPEM, keyPEM); err != nil {
+		t.Errorf("Expected inline PEM to load, got %v", err)
+	}
+
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	os.WriteFile(certPath, []byte(certPEM), 0o600)
+	os.WriteFile(keyPath, []byte(keyPEM), 0o600)
+	if _, err := LoadKeyPair(certPath, keyPath); err != nil {
+		t.Errorf("Expected PEM files to load, got %v", err)
+	}
+
+	if _, err := LoadKeyPair(certPath, filepath.Join(dir, "missing.key")); err == nil {
+		t.Error("Expected an error for a missing key file")
+	}
+	if _, err := LoadKeyPair(keyPEM, certPEM); err == nil {
+		t.Error("Expected an error for a swapped certificate and key")
+	}
+	if _, err := LoadCertPool(keyPath); err == nil {
+		t.Error("Expected an error for a CA file without certificates")
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t)
+	clientCAs, err := LoadCertPool(certPEM)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	serverCAs := x509.NewCertPool()
+	serverCAs.AddCert(server.Certificate())
+	clientCert := &ClientCertificate{}
+	transport := &http.Transport{TLSClientConfig: clientCert.TLSConfig(serverCAs)}
+	client := &WordPressClient{BaseURL: server.URL, HTTPClient: &http.Client{Transport: transport}}
+
+	if _, err := client.Ping(context.Background()); err == nil {
+		t.Error("Expected the origin to refuse a client without a certificate")
+	}
+
+	pair, err := LoadKeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	clientCert.Set(pair)
+	transport.CloseIdleConnections()
+	if status, err := client.Ping(context.Background()); err != nil || status != http.StatusOK {
+		t.Errorf("Expected the origin to accept the certificate, got status %d and %v", status, err)
+	}
+}
@@ -1,104 +1,472 @@
 package api
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/internal/router"
+	"wordpress-go-proxy/internal/tracing"
 	"wordpress-go-proxy/pkg/models"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
 )
 
 // WordPressClient handles communication with the WordPress REST API
 // It manages authentication, caching of menus, and provides methods
-// to fetch content from WordPress.
+// to fetch content from WordPress.  Menus are read with the Menu and Menus
+// accessors since they may be swapped by a background refresh.
 type WordPressClient struct {
 	BaseURL       string
 	WordPressAuth string
-	Menus         map[string]*models.MenuData
-	MenuIdEn      string
-	MenuIdFr      string
+	MenuIds       map[string]string
+	Timeouts      Timeouts
+	HTTPClient    *http.Client
+	PageCache     *cache.Cache[*models.WordPressPage]
+	ChildCache    *cache.Cache[[]models.WordPressPage]
+	AlertCache    *cache.Cache[[]models.Alert]
+
+	// AlertsRoute is the REST route of the posts shown as site-wide
+	// alerts, such as "wp/v2/alerts" for an alerts custom post type, which
+	// are limited to the category AlertsCategory if it is not zero.
+	AlertsRoute    string
+	AlertsCategory int
+
+	// MenuStore persists menus so a new process can start with them
+	// instead of waiting for WordPress.  Menus are not persisted if nil.
+	MenuStore cache.Backend
+
+	// MenuRefreshInterval is how long menus are cached before they are
+	// refreshed in the background.  Zero disables refreshing.
+	MenuRefreshInterval time.Duration
+
+	// PageSize is the number of search results and archive posts requested
+	// per page.  Zero uses DefaultPageSize.
+	PageSize int
+
+	// AuthenticateContent sends the client's credentials with page
+	// requests, and with the media requests of signed in visitors, so that
+	// private pages and protected uploads can be proxied.  Private pages
+	// are cached like public ones, and the page handler only serves them to
+	// signed in visitors.
+	AuthenticateContent bool
+
+	// Headers are added to every request, so that WordPress can turn away
+	// requests that do not come through the proxy, such as with a shared
+	// secret header.  Set them with SetHeaders once the client is in use.
+	Headers http.Header
+
+	// authMu guards WordPressAuth and Headers, which change when
+	// credentials are rotated.
+	authMu sync.RWMutex
+
+	// menus holds the current menu snapshot.  Snapshots are never changed
+	// once stored, so requests read menus without locking while a refresh
+	// builds the next snapshot.  menuMu serialises the writers.
+	menus          atomic.Pointer[menuSnapshot]
+	menuMu         sync.Mutex
+	menuRefreshing atomic.Bool
+
+	// backoffUntil is the time, in Unix nanoseconds, until which requests
+	// are not sent because WordPress is rate limiting the proxy.
+	backoffUntil atomic.Int64
+
+	// alertsFailedUntil is the time, in Unix nanoseconds, until which
+	// alerts are not requested because the last request for them failed.
+	alertsFailedUntil atomic.Int64
+
+	// pageFlights shares one upstream request between concurrent fetches
+	// of the same page.
+	pageFlights singleflight.Group
+}
+
+// Timeouts holds the per-call timeouts applied to WordPress API requests.
+// A zero value falls back to the matching DefaultTimeouts value.
+type Timeouts struct {
+	Page time.Duration
+	Menu time.Duration
+}
+
+// DefaultTimeouts are used when a client has no timeouts configured.
+var DefaultTimeouts = Timeouts{
+	Page: 3 * time.Second,
+	Menu: 3 * time.Second,
+}
+
+// DefaultPageSize is the number of search results and archive posts per
+// page when a client has no page size configured.
+const DefaultPageSize = 10
+
+// pageSize returns the number of results requested per page.
+func (c *WordPressClient) pageSize() int {
+	if c.PageSize > 0 {
+		return c.PageSize
+	}
+	return DefaultPageSize
 }
 
-// MenuResult represents the result of an asynchronous menu fetch operation
-type MenuResult struct {
-	Lang      string
-	MenuItems *[]models.WordPressMenuItem
-	Err       error
+// menuValidator identifies the version of a menu that was last fetched.
+// The ETag and Last-Modified headers are sent back to WordPress so an
+// unchanged menu can be answered with 304 Not Modified, and the hash of
+// the body catches unchanged menus when WordPress sends neither header.
+type menuValidator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Hash         string `json:"hash,omitempty"`
+}
+
+// menuSnapshot is the menus of every language together with the
+// validators of their last fetch and the time they were refreshed.
+type menuSnapshot struct {
+	menus      map[string]*models.MenuData
+	validators map[string]menuValidator
+	refreshed  time.Time
+}
+
+// menuResult is the result of refreshing the menu for one language.
+type menuResult struct {
+	lang      string
+	menu      *models.MenuData
+	validator menuValidator
+	changed   bool
+	err       error
+}
+
+// menuStoreKey is the key menus are persisted under in the menu store.
+const menuStoreKey = "menus"
+
+// menuStoreRetention is how long persisted menus are kept.  Older menus
+// are loaded on start and refreshed in the background.
+const menuStoreRetention = 24 * time.Hour
+
+// storedMenus is the encoding of the menus persisted in the menu store.
+type storedMenus struct {
+	Menus      map[string]*models.MenuData `json:"menus"`
+	Validators map[string]menuValidator    `json:"validators,omitempty"`
+	Refreshed  time.Time                   `json:"refreshed"`
 }
 
 // NewWordPressClient creates and initializes a new WordPress API client.
 // It performs authentication and fetches menus concurrently during initialization.
-func NewWordPressClient(baseURL string, username string, password string, menuIdEn string, menuIdFr string) *WordPressClient {
-	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+// menuIds holds the WordPress menu ID of each language keyed by code.
+func NewWordPressClient(baseURL string, username string, password string, menuIds map[string]string, timeouts Timeouts) *WordPressClient {
+	return NewWordPressClientWithStore(baseURL, username, password, menuIds, timeouts, nil)
+}
+
+// NewWordPressClientWithStore creates a WordPress API client that persists
+// its menus to store.  Menus found in the store are used on start, which
+// avoids fetching them before the first request, and are refreshed in the
+// background once they are older than the refresh interval.
+func NewWordPressClientWithStore(baseURL string, username string, password string, menuIds map[string]string, timeouts Timeouts, store cache.Backend) *WordPressClient {
 	client := &WordPressClient{
 		BaseURL:       baseURL,
-		WordPressAuth: auth,
-		MenuIdEn:      menuIdEn,
-		MenuIdFr:      menuIdFr,
-		Menus:         make(map[string]*models.MenuData),
+		WordPressAuth: basicAuth(username, password),
+		MenuIds:       menuIds,
+		Timeouts:      timeouts,
+		MenuStore:     store,
+	}
+
+	if err := client.InitMenus(context.Background()); err != nil {
+		log.Fatalf("Error fetching menus: %v", err)
+	}
+
+	return client
+}
+
+// InitMenus loads the menus persisted in the menu store, or fetches them if
+// none are stored, for clients that are set up before their first request
+// rather than created with NewWordPressClient.
+func (c *WordPressClient) InitMenus(ctx context.Context) error {
+	if c.loadMenus(ctx) {
+		return nil
+	}
+	return c.RefreshMenus(ctx)
+}
+
+// Menu returns the cached menu for a language.  If the menus are older than
+// the refresh interval, a background refresh is started and the current
+// menus are returned.
+func (c *WordPressClient) Menu(lang string) (*models.MenuData, bool) {
+	snapshot := c.menuSnapshot()
+	menu, ok := snapshot.menus[lang]
+
+	c.refreshStaleMenus(snapshot)
+	return menu, ok
+}
+
+// Menus returns a copy of the cached menus keyed by language, starting a
+// background refresh like Menu if they are stale.  The copy is not changed
+// by later refreshes.
+func (c *WordPressClient) Menus() map[string]*models.MenuData {
+	snapshot := c.menuSnapshot()
+	menus := make(map[string]*models.MenuData, len(snapshot.menus))
+	for lang, menu := range snapshot.menus {
+		menus[lang] = menu
+	}
+
+	c.refreshStaleMenus(snapshot)
+	return menus
+}
+
+// SetMenus replaces the cached menus, for clients whose menus do not come
+// from WordPress.  The validators of earlier fetches are discarded.
+func (c *WordPressClient) SetMenus(menus map[string]*models.MenuData) {
+	c.menuMu.Lock()
+	defer c.menuMu.Unlock()
+	c.menus.Store(&menuSnapshot{menus: menus, refreshed: c.menuSnapshot().refreshed})
+}
+
+// menuSnapshot returns the current menu snapshot, which is empty until
+// menus are first loaded.
+func (c *WordPressClient) menuSnapshot() *menuSnapshot {
+	if snapshot := c.menus.Load(); snapshot != nil {
+		return snapshot
+	}
+	return &menuSnapshot{}
+}
+
+// refreshStaleMenus starts a background refresh if the snapshot is older
+// than the refresh interval and no refresh is running.
+func (c *WordPressClient) refreshStaleMenus(snapshot *menuSnapshot) {
+	stale := c.MenuRefreshInterval > 0 && time.Since(snapshot.refreshed) > c.MenuRefreshInterval
+	if stale && c.menuRefreshing.CompareAndSwap(false, true) {
+		go func() {
+			defer c.menuRefreshing.Store(false)
+			if err := c.RefreshMenus(context.Background()); err != nil {
+				log.Printf("Error refreshing menus: %v", err)
+			}
+		}()
 	}
+}
 
-	// Launch concurrent requests to retrieve the menus
-	languages := []string{"en", "fr"}
-	results := make(chan MenuResult, len(languages))
+// RefreshMenus fetches the menus for all languages concurrently and swaps
+// them in once every fetch has succeeded.  If any fetch fails the existing
+// menus are kept.  Menus are fetched conditionally, so a menu that has not
+// changed since the last refresh is kept as it is rather than parsed and
+// built again.
+func (c *WordPressClient) RefreshMenus(ctx context.Context) error {
+	snapshot := c.menuSnapshot()
+	current, validators := snapshot.menus, snapshot.validators
+
+	languages := make([]string, 0, len(c.MenuIds))
+	for lang := range c.MenuIds {
+		languages = append(languages, lang)
+	}
+	results := make(chan menuResult, len(languages))
 	for _, lang := range languages {
 		go func(language string) {
-			menuItems, err := client.FetchMenu(language)
-			results <- MenuResult{
-				Lang:      language,
-				MenuItems: menuItems,
-				Err:       err}
+			results <- c.refreshMenu(ctx, language, current[language], validators[language])
 		}(lang)
 	}
 
-	// Wait for both requests to complete
+	// Wait for all requests to complete
+	menus := make(map[string]*models.MenuData, len(languages))
+	fetched := make(map[string]menuValidator, len(languages))
+	var err error
 	for range languages {
 		result := <-results
-		if result.Err != nil {
-			log.Fatalf("Error fetching menu items for %s: %v", result.Lang, result.Err)
+		if result.err != nil {
+			err = fmt.Errorf("error fetching menu items for %s: %w", result.lang, result.err)
+			continue
 		}
-		log.Printf("Fetched %d menu items for %s", len(*result.MenuItems), result.Lang)
-		client.Menus[result.Lang] = models.NewMenuData(result.MenuItems, baseURL)
+		if result.changed && current[result.lang] != nil {
+			log.Printf("Menu for %s changed, now %d items", result.lang, len(result.menu.Items))
+		}
+		menus[result.lang] = result.menu
+		fetched[result.lang] = result.validator
 	}
 
-	return client
+	// Failed refreshes still count as refreshes, so that a WordPress
+	// outage is not retried on every request
+	refreshed := time.Now()
+	c.menuMu.Lock()
+	if err != nil {
+		latest := c.menuSnapshot()
+		c.menus.Store(&menuSnapshot{menus: latest.menus, validators: latest.validators, refreshed: refreshed})
+		c.menuMu.Unlock()
+		return err
+	}
+	c.menus.Store(&menuSnapshot{menus: menus, validators: fetched, refreshed: refreshed})
+	c.menuMu.Unlock()
+
+	if c.MenuStore != nil {
+		c.saveMenus(ctx, menus, fetched, refreshed)
+	}
+	return nil
 }
 
-// FetchMenu retrieves the menu items for a given language.
-func (c *WordPressClient) FetchMenu(lang string) (*[]models.WordPressMenuItem, error) {
-	menuId := c.MenuIdEn
-	if lang == "fr" {
-		menuId = c.MenuIdFr
+// refreshMenu fetches the menu for a language, reusing the current menu if
+// WordPress reports it is not modified or returns the same body.
+func (c *WordPressClient) refreshMenu(ctx context.Context, lang string, current *models.MenuData, validator menuValidator) menuResult {
+	if current == nil {
+		validator = menuValidator{}
+	}
+	body, fetched, err := c.fetchMenuBody(ctx, lang, validator)
+	if err != nil {
+		return menuResult{lang: lang, err: err}
+	}
+	if body == nil || (validator.Hash != "" && fetched.Hash == validator.Hash) {
+		if fetched.ETag == "" && fetched.LastModified == "" {
+			fetched.ETag, fetched.LastModified = validator.ETag, validator.LastModified
+		}
+		fetched.Hash = validator.Hash
+		return menuResult{lang: lang, menu: current, validator: fetched}
 	}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/wp-json/wp/v2/menu-items?menus=%s", c.BaseURL, menuId), nil)
-	req.Header.Add("Authorization", "Basic "+c.WordPressAuth)
+	var menuItems []models.WordPressMenuItem
+	if err := json.Unmarshal(body, &menuItems); err != nil {
+		return menuResult{lang: lang, err: err}
+	}
+	if current == nil {
+		log.Printf("Fetched %d menu items for %s", len(menuItems), lang)
+	}
+	return menuResult{
+		lang:      lang,
+		menu:      models.NewMenuData(&menuItems, c.BaseURL),
+		validator: fetched,
+		changed:   true,
+	}
+}
+
+// loadMenus loads persisted menus from the menu store.  It reports whether
+// menus were found for every language.
+func (c *WordPressClient) loadMenus(ctx context.Context) bool {
+	if c.MenuStore == nil {
+		return false
+	}
+
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Menu, DefaultTimeouts.Menu)
+	defer cancel()
+	data, found, err := c.MenuStore.Get(ctx, menuStoreKey)
 	if err != nil {
-		return nil, err
+		log.Printf("Error loading stored menus: %v", err)
+		return false
+	}
+	if !found {
+		return false
 	}
 
-	// Execute the request
-	client := &http.Client{
-		Timeout: 3 * time.Second,
+	var stored storedMenus
+	if err := json.Unmarshal(data, &stored); err != nil {
+		log.Printf("Error decoding stored menus: %v", err)
+		return false
 	}
-	resp, err := client.Do(req)
+	for lang := range c.MenuIds {
+		if stored.Menus[lang] == nil {
+			return false
+		}
+	}
+
+	log.Printf("Loaded stored menus refreshed at %s", stored.Refreshed.Format(time.RFC3339))
+	c.menuMu.Lock()
+	defer c.menuMu.Unlock()
+	c.menus.Store(&menuSnapshot{menus: stored.Menus, validators: stored.Validators, refreshed: stored.Refreshed})
+	return true
+}
+
+// saveMenus persists menus to the menu store.  Errors are logged since the
+// menus are already in use.
+func (c *WordPressClient) saveMenus(ctx context.Context, menus map[string]*models.MenuData, validators map[string]menuValidator, refreshed time.Time) {
+	data, err := json.Marshal(storedMenus{Menus: menus, Validators: validators, Refreshed: refreshed})
 	if err != nil {
-		return nil, err
+		log.Printf("Error encoding menus: %v", err)
+		return
+	}
+	ctx, cancel := withTimeout(context.WithoutCancel(ctx), c.Timeouts.Menu, DefaultTimeouts.Menu)
+	defer cancel()
+	if err := c.MenuStore.Set(ctx, menuStoreKey, data, menuStoreRetention); err != nil {
+		log.Printf("Error storing menus: %v", err)
+	}
+}
+
+// httpClient returns the HTTP client used for upstream requests.
+func (c *WordPressClient) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// withTimeout derives a context from ctx that is cancelled after the given
+// timeout, or the fallback timeout if none is configured.
+func withTimeout(ctx context.Context, timeout time.Duration, fallback time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = fallback
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Ping checks that the WordPress REST API is reachable by sending a HEAD
+// request to the wp-json root.  It returns the upstream status code.
+func (c *WordPressClient) Ping(ctx context.Context) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", fmt.Sprintf("%s/wp-json/", c.BaseURL), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("WordPress API returned status: %d, body: %s", resp.StatusCode, string(body))
+		return resp.StatusCode, &StatusError{StatusCode: resp.StatusCode}
 	}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, nil
+}
+
+// FetchMedia requests a media file from the WordPress uploads directory.
+// The given headers are forwarded so conditional and range requests are
+// handled by the origin.  The client's credentials are only sent for signed
+// in visitors, so that protected uploads are never fetched for anyone
+// else.  The caller is responsible for closing the response body, which is
+// returned unread so it can be streamed.
+func (c *WordPressClient) FetchMedia(ctx context.Context, path string, header http.Header, signedIn bool) (*http.Response, error) {
+	// The path is escaped, so that a decoded ? or # stays part of it
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	u = u.JoinPath("wp-content", "uploads", path)
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if signedIn {
+		c.authenticateContent(req)
+	}
+
+	log.Printf("Fetching media: %s", req.URL.String())
+	return c.do(req)
+}
+
+// FetchMenu retrieves the menu items for a given language.
+func (c *WordPressClient) FetchMenu(ctx context.Context, lang string) (*[]models.WordPressMenuItem, error) {
+	body, _, err := c.fetchMenuBody(ctx, lang, menuValidator{})
 	if err != nil {
 		return nil, err
 	}
@@ -113,45 +481,316 @@ func (c *WordPressClient) FetchMenu(lang string) (*[]models.WordPressMenuItem, e
 	return &menuItems, nil
 }
 
-// FetchPage retrieves a page from WordPress by its path.
-// The path is split and the last segment is the slug used to fetch the page.
-// The language is determined by the second segment of the path.
-func (c *WordPressClient) FetchPage(path string) (*models.WordPressPage, error) {
-	path = strings.TrimSuffix(path, "/")
-	slug := path[strings.LastIndex(path, "/")+1:]
-	segments := strings.Split(path, "/")
+// fetchMenuBody requests the menu items for a language, sending the
+// validator of the previous fetch as conditional request headers.  It
+// returns the validator of the response, and a nil body if WordPress
+// reports the menu has not been modified.
+func (c *WordPressClient) fetchMenuBody(ctx context.Context, lang string, validator menuValidator) ([]byte, menuValidator, error) {
+	menuId, ok := c.MenuIds[lang]
+	if !ok {
+		return nil, menuValidator{}, fmt.Errorf("no menu configured for language: %s", lang)
+	}
+
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Menu, DefaultTimeouts.Menu)
+	defer cancel()
 
-	lang := "en"
-	if len(segments) > 1 && segments[1] == "fr" {
-		lang = "fr"
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/wp/v2/menu-items?menus=%s&_fields=%s", c.BaseURL, menuId, menuItemFields), nil)
+	if err != nil {
+		return nil, menuValidator{}, err
+	}
+	req.Header.Add("Authorization", c.authorization())
+	if validator.ETag != "" {
+		req.Header.Set("If-None-Match", validator.ETag)
+	}
+	if validator.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validator.LastModified)
 	}
 
-	homePages := map[string]string{
-		"":   "home",
-		"fr": "home-fr",
+	// Execute the request
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, menuValidator{}, err
 	}
-	if homeSlug, isHome := homePages[slug]; isHome {
-		slug = homeSlug
+	defer resp.Body.Close()
+
+	fetched := menuValidator{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if resp.StatusCode == http.StatusNotModified && (validator.ETag != "" || validator.LastModified != "") {
+		return nil, fetched, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, menuValidator{}, newStatusError(resp)
 	}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/wp-json/wp/v2/pages?slug=%s&lang=%s", c.BaseURL, slug, lang), nil)
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
+		return nil, menuValidator{}, err
+	}
+	sum := sha256.Sum256(body)
+	fetched.Hash = hex.EncodeToString(sum[:])
+	return body, fetched, nil
+}
+
+// FetchPage retrieves a page from WordPress by its decoded path, such as
+// the path of a request.  The last segment of the path is the slug used
+// to fetch the page, and any segments before it must be the slugs of the
+// page's ancestors, so that pages sharing a slug under different parents
+// are told apart.  ErrPageNotFound is returned if no page has the path,
+// and paths of a single segment only match top level pages.  The language
+// is determined by the first segment of the path.  If the client has a
+// page cache, pages are served from it when available, and expired pages
+// are revalidated with WordPress before they are fetched again.
+func (c *WordPressClient) FetchPage(ctx context.Context, path string) (*models.WordPressPage, error) {
+	// Paths are decoded once, as requests' are, so a percent sign left in
+	// one is not part of any slug
+	if strings.Contains(path, "%") {
+		return nil, ErrPageNotFound
+	}
+	slug, parents, lang := pageSlug(path)
+	tracing.Annotate(ctx, attribute.String("wordpress.slug", slug), attribute.String("wordpress.lang", lang))
+	if c.PageCache == nil {
+		return c.fetchPageShared(ctx, pagesRoute, parents, slug, lang)
+	}
+	key := pageKey(lang, parents, slug)
+	return c.PageCache.Get(ctx, key, c.revalidated(key, pagesRoute, func(ctx context.Context) (*models.WordPressPage, error) {
+		return c.fetchPageShared(ctx, pagesRoute, parents, slug, lang)
+	}))
+}
+
+// pagePattern matches the path of a page in any language.
+var pagePattern = router.MustParse("/{path...}")
+
+// pageSlug returns the slug of the page at a decoded path, the slugs of the
+// ancestors given by the path from the top of the hierarchy down, and the
+// language of the page.
+func pageSlug(path string) (string, []string, string) {
+	params, _ := pagePattern.MatchLocalized(path)
+	lang := params[router.LangParam]
+
+	// The root of each language is its home page
+	if params["path"] == "" {
+		return models.DecodeSlug(models.Languages.Resolve(lang).HomeSlug), nil, lang
+	}
+	segments := strings.Split(params["path"], "/")
+	return segments[len(segments)-1], segments[:len(segments)-1], lang
+}
+
+// pageKey returns the key a page is cached and shared under, its language
+// and path.
+func pageKey(lang string, parents []string, slug string) string {
+	return lang + "/" + strings.Join(append(slices.Clip(parents), slug), "/")
+}
+
+// pagesRoute is the REST route of WordPress pages.
+const pagesRoute = "wp/v2/pages"
+
+// fetchPageShared fetches a page from a REST route, joining an upstream
+// request already in flight for the same route, path and language.  The
+// shared request is detached from the caller's cancellation so that one
+// caller going away does not fail the others, but each caller stops
+// waiting when its context is done.
+func (c *WordPressClient) fetchPageShared(ctx context.Context, route string, parents []string, slug string, lang string) (*models.WordPressPage, error) {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	log.Printf("Fetching page: %s", req.URL.String())
-	client := &http.Client{
-		Timeout: 3 * time.Second,
+	result := c.pageFlights.DoChan(route+"?"+pageKey(lang, parents, slug), func() (interface{}, error) {
+		return c.resolvePage(context.WithoutCancel(ctx), route, parents, slug, lang, "")
+	})
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*models.WordPressPage), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
-	resp, err := client.Do(req)
+}
+
+// resolvePage fetches the pages with a slug and returns the one whose
+// ancestors have the given slugs.  Without ancestor slugs, only a top
+// level page is returned.  Pages in the requested language are preferred
+// over translations sharing their slug, but a page in another language is
+// returned if it is the only match, so callers must check its language.
+func (c *WordPressClient) resolvePage(ctx context.Context, route string, parents []string, slug string, lang string, password string) (*models.WordPressPage, error) {
+	pages, err := c.fetchPages(ctx, route, []string{slug}, lang, password)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, ErrPageNotFound
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		return inLanguage(pages[i], lang) && !inLanguage(pages[j], lang)
+	})
+
+	if len(parents) == 0 {
+		if page := preferredPage(pages); page != nil {
+			return page, nil
+		}
+		log.Printf("No %s page has the path %s", lang, slug)
+		return nil, ErrPageNotFound
+	}
+
+	for i := range pages {
+		ancestors, err := c.FetchAncestors(ctx, &pages[i])
+		if err != nil {
+			return nil, err
+		}
+		if ancestorSlugsMatch(ancestors, parents) {
+			return &pages[i], nil
+		}
+	}
+	log.Printf("No %s page has the path %s/%s", lang, strings.Join(parents, "/"), slug)
+	return nil, ErrPageNotFound
+}
+
+// preferredPage returns the page a path of a single segment resolves to
+// among pages sharing its slug, sorted with pages in the language first:
+// the top level page in the best language available, or nil if none is at
+// the top level.  Pages further down are only reached by their full path.
+func preferredPage(pages []models.WordPressPage) *models.WordPressPage {
+	for i := range pages {
+		if pages[i].Parent == 0 {
+			return &pages[i]
+		}
+	}
+	return nil
+}
+
+// inLanguage reports whether a page is in a language.  Pages without a
+// language, when WordPress is not multilingual, are in every language.
+func inLanguage(page models.WordPressPage, lang string) bool {
+	return page.Lang == "" || page.Lang == lang
+}
+
+// ancestorSlugsMatch reports whether the slugs of a page's ancestors, from
+// the top of the hierarchy down, are the given slugs.
+func ancestorSlugsMatch(ancestors []models.WordPressPage, slugs []string) bool {
+	if len(ancestors) != len(slugs) {
+		return false
+	}
+	for i, ancestor := range ancestors {
+		if !models.SameSlug(ancestor.Slug, slugs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// FetchProtectedPage retrieves a password protected page from WordPress by
+// its path, with the password that unlocks its content.  The page is not
+// cached, or shared with other requests, since it depends on the password.
+// ErrIncorrectPassword is returned if the password does not unlock it.
+func (c *WordPressClient) FetchProtectedPage(ctx context.Context, path string, password string) (*models.WordPressPage, error) {
+	slug, parents, lang := pageSlug(path)
+	page, err := c.resolvePage(ctx, pagesRoute, parents, slug, lang, password)
+	if err != nil {
+		return nil, err
+	}
+	if page.Locked() {
+		return nil, ErrIncorrectPassword
+	}
+	return page, nil
+}
+
+// ErrIncorrectPassword is returned when a password does not unlock a
+// protected page.
+var ErrIncorrectPassword = errors.New("incorrect page password")
+
+// ErrPageNotFound is returned when no page or post has the requested path.
+// It is in the ErrNotFound class.
+var ErrPageNotFound = fmt.Errorf("page %w", ErrNotFound)
+
+// authenticateContent adds the client's credentials to a page or media
+// request if content requests are authenticated.
+func (c *WordPressClient) authenticateContent(req *http.Request) {
+	if c.AuthenticateContent {
+		req.Header.Add("Authorization", c.authorization())
+	}
+}
+
+// SetCredentials replaces the credentials sent to WordPress, such as when
+// the application password is rotated.
+func (c *WordPressClient) SetCredentials(username string, password string) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.WordPressAuth = basicAuth(username, password)
+}
+
+// authorization returns the Authorization header of authenticated
+// requests.
+func (c *WordPressClient) authorization() string {
+	c.authMu.RLock()
+	defer c.authMu.RUnlock()
+	return "Basic " + c.WordPressAuth
+}
+
+// basicAuth encodes credentials for HTTP basic authentication.
+func basicAuth(username string, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
+
+// fetchPages retrieves the pages, or posts of a custom post type, with any
+// of the slugs and a language from a WordPress REST route.  Pages under
+// different parents may share a slug.  Only the fields the proxy uses are
+// requested, and Advanced Custom Fields are requested in their standard
+// format, so that image and link fields hold URLs rather than IDs.  A
+// password unlocks the content of a protected page.  Authenticated
+// requests include private pages.  The request is traced with a
+// fetch-page span.
+func (c *WordPressClient) fetchPages(ctx context.Context, route string, slugs []string, lang string, password string) (_ []models.WordPressPage, err error) {
+	slug := strings.Join(slugs, ",")
+	ctx, span := tracing.Start(ctx, "fetch-page",
+		attribute.String("wordpress.route", route),
+		attribute.String("wordpress.slug", slug),
+		attribute.String("wordpress.lang", lang))
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Page, DefaultTimeouts.Page)
+	defer cancel()
+
+	// Slugs are sent as WordPress stores them, so that those with
+	// non-ASCII characters match however the path was encoded
+	encoded := make([]string, len(slugs))
+	for i, slug := range slugs {
+		encoded[i] = models.EncodeSlug(models.DecodeSlug(slug))
+	}
+	query := url.Values{
+		"slug":       {strings.Join(encoded, ",")},
+		"lang":       {lang},
+		"acf_format": {"standard"},
+		"_fields":    {pageFields()},
+	}
+	if len(slugs) > 1 {
+		query.Set("per_page", strconv.Itoa(maxBatchPages))
+	}
+	if c.AuthenticateContent {
+		query.Set("status", "publish,private")
+	}
+	if password != "" {
+		query.Set("password", password)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wp-json/%s?%s", c.BaseURL, route, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.authenticateContent(req)
+
+	log.Printf("Fetching page: %s/wp-json/%s?slug=%s&lang=%s", c.BaseURL, route, slug, lang)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("wordpress.status", resp.StatusCode))
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("WordPress API returned status: %d, body: %s", resp.StatusCode, string(body))
+		return nil, newStatusError(resp)
 	}
 
 	// Read response body
@@ -167,9 +806,19 @@ func (c *WordPressClient) FetchPage(path string) (*models.WordPressPage, error)
 		return nil, err
 	}
 
-	if len(pages) == 0 {
-		return nil, fmt.Errorf("page not found")
-	}
+	return c.publishedPages(pages), nil
+}
 
-	return &pages[0], nil
+// publishedPages drops the pages that are neither published nor, when
+// content requests are authenticated, private.  Drafts, scheduled pages
+// and pages without a status are never cached or rendered, even if a
+// plugin makes WordPress return them or leave out their status.
+func (c *WordPressClient) publishedPages(pages []models.WordPressPage) []models.WordPressPage {
+	return slices.DeleteFunc(pages, func(page models.WordPressPage) bool {
+		if page.Viewable(c.AuthenticateContent) {
+			return false
+		}
+		log.Printf("Ignoring page %d with status %q", page.ID, page.Status)
+		return true
+	})
 }
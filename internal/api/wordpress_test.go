@@ -1,13 +1,21 @@
 package api
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"wordpress-go-proxy/internal/cache"
 	"wordpress-go-proxy/pkg/models"
 )
 
@@ -33,7 +41,8 @@ func TestFetchPage(t *testing.T) {
 			expectedLang: "en",
 			mockedResponse: []models.WordPressPage{
 				{
-					ID: 123,
+					ID:     123,
+					Status: "publish",
 					Title: Rendered{
 						Rendered: "About Us",
 					},
@@ -48,7 +57,8 @@ func TestFetchPage(t *testing.T) {
 			expectedLang: "fr",
 			mockedResponse: []models.WordPressPage{
 				{
-					ID: 124,
+					ID:     124,
+					Status: "publish",
 					Title: Rendered{
 						Rendered: "À propos",
 					},
@@ -63,7 +73,8 @@ func TestFetchPage(t *testing.T) {
 			expectedLang: "en",
 			mockedResponse: []models.WordPressPage{
 				{
-					ID: 125,
+					ID:     125,
+					Status: "publish",
 					Title: Rendered{
 						Rendered: "Home",
 					},
@@ -78,7 +89,8 @@ func TestFetchPage(t *testing.T) {
 			expectedLang: "fr",
 			mockedResponse: []models.WordPressPage{
 				{
-					ID: 126,
+					ID:     126,
+					Status: "publish",
 					Title: Rendered{
 						Rendered: "About Us",
 					},
@@ -95,6 +107,42 @@ func TestFetchPage(t *testing.T) {
 			shouldError:    true,
 			errorMessage:   "page not found",
 		},
+		{
+			name:           "Draft page",
+			path:           "/draft",
+			expectedSlug:   "draft",
+			expectedLang:   "en",
+			mockedResponse: []models.WordPressPage{{ID: 127, Slug: "draft", Status: "draft"}},
+			shouldError:    true,
+			errorMessage:   "page not found",
+		},
+		{
+			name:           "Scheduled page",
+			path:           "/launch",
+			expectedSlug:   "launch",
+			expectedLang:   "en",
+			mockedResponse: []models.WordPressPage{{ID: 128, Slug: "launch", Status: "future"}},
+			shouldError:    true,
+			errorMessage:   "page not found",
+		},
+		{
+			name:           "Page without a status",
+			path:           "/unknown",
+			expectedSlug:   "unknown",
+			expectedLang:   "en",
+			mockedResponse: []models.WordPressPage{{ID: 130, Slug: "unknown"}},
+			shouldError:    true,
+			errorMessage:   "page not found",
+		},
+		{
+			name:           "Private page without authenticated content",
+			path:           "/staff",
+			expectedSlug:   "staff",
+			expectedLang:   "en",
+			mockedResponse: []models.WordPressPage{{ID: 129, Slug: "staff", Status: "private"}},
+			shouldError:    true,
+			errorMessage:   "page not found",
+		},
 		{
 			name:         "API error response",
 			path:         "/error-page",
@@ -141,13 +189,12 @@ func TestFetchPage(t *testing.T) {
 
 			// Create WordPress client pointing to our test server
 			client := &WordPressClient{
-				BaseURL:  server.URL,
-				MenuIdEn: "1",
-				MenuIdFr: "2",
+				BaseURL: server.URL,
+				MenuIds: map[string]string{"en": "1", "fr": "2"},
 			}
 
 			// Call the method being tested
-			page, err := client.FetchPage(tc.path)
+			page, err := client.FetchPage(context.Background(), tc.path)
 
 			// Verify results
 			if tc.shouldError {
@@ -184,7 +231,8 @@ func TestFetchPageWithTrailingSlash(t *testing.T) {
 
 		response := []models.WordPressPage{
 			{
-				ID: 123,
+				ID:     123,
+				Status: "publish",
 				Title: Rendered{
 					Rendered: "About Us",
 				},
@@ -198,7 +246,7 @@ func TestFetchPageWithTrailingSlash(t *testing.T) {
 	defer server.Close()
 
 	client := &WordPressClient{BaseURL: server.URL}
-	page, err := client.FetchPage("/about-us/")
+	page, err := client.FetchPage(context.Background(), "/about-us/")
 
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
@@ -211,12 +259,92 @@ func TestFetchPageWithTrailingSlash(t *testing.T) {
 	}
 }
 
+// TestFetchPageNestedPath tests that pages sharing a slug are told apart
+// by the slugs of their ancestors, and that pages below the top level are
+// not found by their slug alone
+func TestFetchPageNestedPath(t *testing.T) {
+	pages := map[int]models.WordPressPage{
+		1: {ID: 1, Status: "publish", Slug: "services"},
+		2: {ID: 2, Status: "publish", Slug: "about"},
+		3: {ID: 3, Status: "publish", Parent: 1, Slug: "benefits"},
+		4: {ID: 4, Status: "publish", Parent: 2, Slug: "benefits"},
+		5: {ID: 5, Status: "publish", Parent: 4, Slug: "eligibility"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/wp-json/wp/v2/pages/")); err == nil {
+			json.NewEncoder(w).Encode(pages[id])
+			return
+		}
+		matches := []models.WordPressPage{}
+		for id := 1; id <= len(pages); id++ {
+			if pages[id].Slug == r.URL.Query().Get("slug") {
+				matches = append(matches, pages[id])
+			}
+		}
+		json.NewEncoder(w).Encode(matches)
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	testCases := []struct {
+		path     string
+		expected int
+	}{
+		{"/services/benefits", 3},
+		{"/about/benefits/", 4},
+		{"/fr/about/benefits", 4},
+		{"/about/benefits/eligibility", 5},
+		{"/services", 1},
+		{"/benefits", 0},
+		{"/eligibility", 0},
+		{"/other/benefits", 0},
+		{"/benefits/eligibility", 0},
+		{"/services/about/benefits", 0},
+		{"/services/missing", 0},
+	}
+	for _, tc := range testCases {
+		page, err := client.FetchPage(context.Background(), tc.path)
+		if tc.expected == 0 {
+			if !errors.Is(err, ErrPageNotFound) {
+				t.Errorf("FetchPage(%q): expected ErrPageNotFound, got %v, %v", tc.path, page, err)
+			}
+			continue
+		}
+		if err != nil || page.ID != tc.expected {
+			t.Errorf("FetchPage(%q): expected page %d, got %v, %v", tc.path, tc.expected, page, err)
+		}
+	}
+}
+
+// TestFetchPageLanguagePreferred tests that pages in the requested
+// language are preferred over translations sharing their slug
+func TestFetchPageLanguagePreferred(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPage{
+			{ID: 1, Status: "publish", Slug: "contact", Lang: "en"},
+			{ID: 2, Status: "publish", Slug: "contact", Lang: "fr", Parent: 3},
+			{ID: 4, Status: "publish", Slug: "contact", Lang: "fr"},
+		})
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	for path, expected := range map[string]int{"/contact": 1, "/fr/contact": 4} {
+		page, err := client.FetchPage(context.Background(), path)
+		if err != nil || page.ID != expected {
+			t.Errorf("FetchPage(%q): expected page %d, got %v, %v", path, expected, page, err)
+		}
+	}
+}
+
 // TestFetchPageNetworkError tests handling of network errors
 func TestFetchPageNetworkError(t *testing.T) {
 	// Create client with invalid URL to trigger network error
 	client := &WordPressClient{BaseURL: "http://invalid-domain-that-does-not-exist.example"}
 
-	_, err := client.FetchPage("/any-page")
+	_, err := client.FetchPage(context.Background(), "/any-page")
 
 	if err == nil {
 		t.Errorf("Expected network error, got nil")
@@ -304,6 +432,9 @@ func TestFetchMenu(t *testing.T) {
 				if q.Get("menus") != tc.expectedMenuId {
 					t.Errorf("Expected menus=%s, got %s", tc.expectedMenuId, q.Get("menus"))
 				}
+				if q.Get("_fields") != menuItemFields {
+					t.Errorf("Expected _fields=%s, got %s", menuItemFields, q.Get("_fields"))
+				}
 
 				// Verify authorization header is present
 				authHeader := r.Header.Get("Authorization")
@@ -335,12 +466,11 @@ func TestFetchMenu(t *testing.T) {
 			client := &WordPressClient{
 				BaseURL:       server.URL,
 				WordPressAuth: "dGVzdHVzZXI6dGVzdHBhc3N3b3Jk", // Base64 of "testuser:testpassword"
-				MenuIdEn:      "123",
-				MenuIdFr:      "456",
+				MenuIds:       map[string]string{"en": "123", "fr": "456"},
 			}
 
 			// Call the method being tested
-			menuItems, err := client.FetchMenu(tc.language)
+			menuItems, err := client.FetchMenu(context.Background(), tc.language)
 
 			// Verify results
 			if tc.shouldError {
@@ -455,7 +585,7 @@ func TestNewWordPressClient(t *testing.T) {
 	menuIdFr := "456"
 
 	// Create client - this will trigger concurrent menu fetches
-	client := NewWordPressClient(baseURL, username, password, menuIdEn, menuIdFr)
+	client := NewWordPressClient(baseURL, username, password, map[string]string{"en": menuIdEn, "fr": menuIdFr}, DefaultTimeouts)
 
 	// Verify client initialization
 	expectedAuth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
@@ -465,17 +595,17 @@ func TestNewWordPressClient(t *testing.T) {
 	if client.WordPressAuth != expectedAuth {
 		t.Errorf("Expected WordPressAuth %s, got %s", expectedAuth, client.WordPressAuth)
 	}
-	if client.MenuIdEn != menuIdEn {
-		t.Errorf("Expected MenuIdEn %s, got %s", menuIdEn, client.MenuIdEn)
+	if client.MenuIds["en"] != menuIdEn {
+		t.Errorf("Expected English menu ID %s, got %s", menuIdEn, client.MenuIds["en"])
 	}
-	if client.MenuIdFr != menuIdFr {
-		t.Errorf("Expected MenuIdFr %s, got %s", menuIdFr, client.MenuIdFr)
+	if client.MenuIds["fr"] != menuIdFr {
+		t.Errorf("Expected French menu ID %s, got %s", menuIdFr, client.MenuIds["fr"])
 	}
 
 	// Verify menus were fetched and processed
 	expectedLanguages := []string{"en", "fr"}
 	for _, lang := range expectedLanguages {
-		menu, exists := client.Menus[lang]
+		menu, exists := client.Menus()[lang]
 		if !exists {
 			t.Errorf("Expected menu for language %s to be present", lang)
 			continue
@@ -495,3 +625,607 @@ func TestNewWordPressClient(t *testing.T) {
 		}
 	}
 }
+
+// TestPing tests the WordPress API reachability check
+func TestPing(t *testing.T) {
+	testCases := []struct {
+		name        string
+		statusCode  int
+		shouldError bool
+	}{
+		{name: "Upstream available", statusCode: http.StatusOK},
+		{name: "Upstream error", statusCode: http.StatusServiceUnavailable, shouldError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodHead {
+					t.Errorf("Expected HEAD request, got %s", r.Method)
+				}
+				if r.URL.Path != "/wp-json/" {
+					t.Errorf("Expected path /wp-json/, got %s", r.URL.Path)
+				}
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer server.Close()
+
+			client := &WordPressClient{BaseURL: server.URL}
+			statusCode, err := client.Ping(context.Background())
+
+			if statusCode != tc.statusCode {
+				t.Errorf("Expected status %d, got %d", tc.statusCode, statusCode)
+			}
+			if tc.shouldError && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !tc.shouldError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestFetchPageTimeout tests that the configured page timeout cancels slow upstream calls
+func TestFetchPageTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{
+		BaseURL:  server.URL,
+		Timeouts: Timeouts{Page: 50 * time.Millisecond},
+	}
+
+	start := time.Now()
+	_, err := client.FetchPage(context.Background(), "/slow-page")
+	if err == nil {
+		t.Fatal("Expected timeout error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected request to time out quickly, took %v", elapsed)
+	}
+}
+
+// TestFetchPageContextCancelled tests that a cancelled request context aborts the upstream call
+func TestFetchPageContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected no upstream request for a cancelled context")
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.FetchPage(ctx, "/about-us")
+	if err == nil {
+		t.Fatal("Expected error for cancelled context, got nil")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled error, got %v", err)
+	}
+}
+
+// TestFetchPageCached tests that pages are served from the page cache
+func TestFetchPageCached(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPage{
+			{ID: 123, Status: "publish", Slug: r.URL.Query().Get("slug"), Lang: r.URL.Query().Get("lang")},
+		})
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{
+		BaseURL:   server.URL,
+		PageCache: cache.New[*models.WordPressPage](cache.Policy{TTL: time.Minute}),
+	}
+
+	for _, path := range []string{"/about-us", "/about-us/", "/fr/about-us", "/about-us"} {
+		if _, err := client.FetchPage(context.Background(), path); err != nil {
+			t.Fatalf("Expected no error for %s, got %v", path, err)
+		}
+	}
+
+	// The English and French pages are cached under separate keys
+	if requests != 2 {
+		t.Errorf("Expected 2 upstream requests, got %d", requests)
+	}
+}
+
+// TestFetchPageCoalesced tests that concurrent fetches of a page share one upstream request
+func TestFetchPageCoalesced(t *testing.T) {
+	var requests atomic.Int32
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		arrived <- struct{}{}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPage{
+			{ID: 123, Status: "publish", Slug: r.URL.Query().Get("slug"), Lang: r.URL.Query().Get("lang")},
+		})
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	// The first caller gives up while the request is in flight, which
+	// must not fail the callers sharing it
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancelledErr := make(chan error, 1)
+	go func() {
+		_, err := client.FetchPage(cancelled, "/about-us")
+		cancelledErr <- err
+	}()
+	<-arrived
+
+	const callers = 10
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			page, err := client.FetchPage(context.Background(), "/about-us/")
+			if err == nil && page.ID != 123 {
+				err = errors.New("unexpected page")
+			}
+			errs <- err
+		}()
+	}
+
+	cancel()
+	if err := <-cancelledErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled for the cancelled caller, got %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for i := 0; i < callers; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 upstream request, got %d", got)
+	}
+}
+
+// TestNewWordPressClientWithStore tests that stored menus are used on start
+func TestNewWordPressClientWithStore(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressMenuItem{
+			{ID: 1, Title: Rendered{Rendered: "Home " + r.URL.Query().Get("menus")}, Url: "https://example.com/"},
+		})
+	}))
+	defer server.Close()
+
+	store, err := cache.NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	menuIds := map[string]string{"en": "1", "fr": "2"}
+
+	// The first client fetches and stores the menus
+	NewWordPressClientWithStore(server.URL, "user", "pass", menuIds, DefaultTimeouts, store)
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("Expected 2 menu requests, got %d", got)
+	}
+
+	// A new client starts with the stored menus
+	client := NewWordPressClientWithStore(server.URL, "user", "pass", menuIds, DefaultTimeouts, store)
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected stored menus to be used, got %d menu requests", got)
+	}
+	menu, ok := client.Menu("fr")
+	if !ok || len(menu.Items) != 1 || menu.Items[0].Title != "Home 2" {
+		t.Errorf("Expected stored French menu, got %+v", menu)
+	}
+
+	// Stored menus missing a language are fetched again
+	menuIds["es"] = "3"
+	NewWordPressClientWithStore(server.URL, "user", "pass", menuIds, DefaultTimeouts, store)
+	if got := requests.Load(); got != 5 {
+		t.Errorf("Expected menus to be fetched for a new language, got %d menu requests", got)
+	}
+}
+
+// TestRefreshMenus tests that menus are swapped in on refresh and kept on failure
+func TestRefreshMenus(t *testing.T) {
+	var title atomic.Value
+	title.Store("Home")
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressMenuItem{
+			{ID: 1, Title: Rendered{Rendered: title.Load().(string)}, Url: "/"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewWordPressClient(server.URL, "user", "pass", map[string]string{"en": "1", "fr": "2"}, DefaultTimeouts)
+
+	menuTitle := func(lang string) string {
+		menu, ok := client.Menu(lang)
+		if !ok || len(menu.Items) == 0 {
+			t.Fatalf("Expected menu for %s", lang)
+		}
+		return menu.Items[0].Title
+	}
+	if got := menuTitle("en"); got != "Home" {
+		t.Errorf("Expected menu title 'Home', got %q", got)
+	}
+
+	// Menu edits are picked up on refresh
+	title.Store("Accueil")
+	if err := client.RefreshMenus(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := menuTitle("fr"); got != "Accueil" {
+		t.Errorf("Expected refreshed menu title 'Accueil', got %q", got)
+	}
+
+	// Failed refreshes keep the existing menus
+	fail.Store(true)
+	if err := client.RefreshMenus(context.Background()); err == nil {
+		t.Error("Expected error from failed refresh, got nil")
+	}
+	if got := menuTitle("fr"); got != "Accueil" {
+		t.Errorf("Expected existing menu title 'Accueil', got %q", got)
+	}
+}
+
+// TestRefreshMenusConditional tests that unchanged menus are revalidated
+// and kept rather than rebuilt
+func TestRefreshMenusConditional(t *testing.T) {
+	var title atomic.Value
+	title.Store("Home")
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	var conditional atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("menus") == "2" {
+			// The French menu has no validators, so it is compared by hash
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode([]models.WordPressMenuItem{{ID: 2, Title: Rendered{Rendered: "Accueil"}, Url: "/"}})
+			return
+		}
+		if r.Header.Get("If-None-Match") != "" {
+			conditional.Add(1)
+			if r.Header.Get("If-None-Match") == etag.Load().(string) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Header().Set("ETag", etag.Load().(string))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressMenuItem{
+			{ID: 1, Title: Rendered{Rendered: title.Load().(string)}, Url: "/"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewWordPressClient(server.URL, "user", "pass", map[string]string{"en": "1", "fr": "2"}, DefaultTimeouts)
+	before := client.Menus()
+
+	// Unchanged menus keep the same menu data
+	if err := client.RefreshMenus(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	after := client.Menus()
+	if conditional.Load() != 1 {
+		t.Errorf("Expected a conditional request for the English menu, got %d", conditional.Load())
+	}
+	for _, lang := range []string{"en", "fr"} {
+		if after[lang] != before[lang] {
+			t.Errorf("Expected unchanged %s menu to be kept", lang)
+		}
+	}
+
+	// Changed menus are rebuilt
+	title.Store("Start")
+	etag.Store(`"v2"`)
+	if err := client.RefreshMenus(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	menu, _ := client.Menu("en")
+	if menu == before["en"] || len(menu.Items) != 1 || menu.Items[0].Title != "Start" {
+		t.Errorf("Expected rebuilt English menu, got %+v", menu)
+	}
+
+	// The returned map is a copy
+	after["en"] = nil
+	if menu, _ := client.Menu("en"); menu == nil {
+		t.Error("Expected Menus to return a copy of the cached menus")
+	}
+}
+
+// TestMenusConcurrentRefresh tests that menus can be read while they are
+// refreshed, for use with the race detector
+func TestMenusConcurrentRefresh(t *testing.T) {
+	var version atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressMenuItem{
+			{ID: int(version.Add(1)), Title: Rendered{Rendered: "Home"}, Url: "/"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewWordPressClient(server.URL, "user", "pass", map[string]string{"en": "1", "fr": "2"}, DefaultTimeouts)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				if menu, ok := client.Menu("en"); !ok || len(menu.Items) != 1 {
+					t.Errorf("Expected English menu during refresh, got %+v", menu)
+					return
+				}
+				if menus := client.Menus(); len(menus) != 2 {
+					t.Errorf("Expected 2 menus during refresh, got %d", len(menus))
+					return
+				}
+			}
+		}()
+	}
+	for range 5 {
+		if err := client.RefreshMenus(context.Background()); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+// TestMenuBackgroundRefresh tests that stale menus trigger a background refresh
+func TestMenuBackgroundRefresh(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressMenuItem{})
+	}))
+	defer server.Close()
+
+	client := NewWordPressClient(server.URL, "user", "pass", map[string]string{"en": "1", "fr": "2"}, DefaultTimeouts)
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("Expected 2 initial menu requests, got %d", got)
+	}
+
+	// Fresh menus do not trigger a refresh
+	client.MenuRefreshInterval = time.Hour
+	client.Menu("en")
+
+	// Stale menus are refreshed in the background
+	client.MenuRefreshInterval = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	client.Menu("en")
+
+	deadline := time.Now().Add(time.Second)
+	for requests.Load() < 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("Expected 4 menu requests after refresh, got %d", got)
+	}
+}
+
+// TestFetchPageLanguages tests that page languages come from the registry
+func TestFetchPageLanguages(t *testing.T) {
+	original := models.Languages
+	models.Languages = models.LanguageRegistry{
+		models.NewLanguage("en", true),
+		models.NewLanguage("fr", false),
+		models.NewLanguage("es", false),
+	}
+	defer func() { models.Languages = original }()
+
+	var lastQuery url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastQuery = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPage{{Status: "publish", ID: 1}})
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+
+	// Only the fields of a page are requested, with the translation slug
+	// of every language
+	fields := "id,parent,slug,lang,modified,modified_gmt,date,date_gmt,status,content,title,excerpt,featured_media,categories,meta,acf,comment_status,template,yoast_head_json,slug_en,slug_fr,slug_es"
+	testCases := []struct {
+		path         string
+		expectedSlug string
+		expectedLang string
+	}{
+		{"/es", "home-es", "es"},
+		{"/es/acerca", "acerca", "es"},
+		{"/fr/", "home-fr", "fr"},
+		{"/", "home", "en"},
+	}
+
+	for _, tc := range testCases {
+		if _, err := client.FetchPage(context.Background(), tc.path); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := url.Values{"slug": {tc.expectedSlug}, "lang": {tc.expectedLang}, "acf_format": {"standard"}, "_fields": {fields}}
+		if lastQuery.Encode() != expected.Encode() {
+			t.Errorf("FetchPage(%q): expected query %q, got %q", tc.path, expected.Encode(), lastQuery.Encode())
+		}
+	}
+}
+
+// TestFetchPageEncodedSlug tests that slugs with non-ASCII characters are
+// requested as WordPress stores them and match their ancestors, and that
+// paths still percent-encoded once decoded are not found
+func TestFetchPageEncodedSlug(t *testing.T) {
+	var slugs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/wp-json/wp/v2/pages/1":
+			json.NewEncoder(w).Encode(models.WordPressPage{ID: 1, Status: "publish", Slug: "%c3%a9t%c3%a9"})
+		default:
+			slugs = append(slugs, r.URL.Query().Get("slug"))
+			json.NewEncoder(w).Encode([]models.WordPressPage{{ID: 2, Status: "publish", Parent: 1, Slug: "activit%c3%a9s", Lang: "fr"}})
+		}
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	page, err := client.FetchPage(context.Background(), "/fr/été/activités")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if page.ID != 2 {
+		t.Errorf("Expected page 2, got %d", page.ID)
+	}
+	if _, err := client.FetchPage(context.Background(), "/fr/%c3%a9t%c3%a9/activit%C3%A9s"); !errors.Is(err, ErrPageNotFound) {
+		t.Errorf("Expected page not found for an encoded path, got %v", err)
+	}
+	if len(slugs) != 1 {
+		t.Errorf("Expected one request for the page, got %d", len(slugs))
+	}
+	for _, slug := range slugs {
+		if slug != "activit%c3%a9s" {
+			t.Errorf("Expected the slug as WordPress stores it, got %q", slug)
+		}
+	}
+}
+
+// TestFetchMenuUnknownLanguage tests that languages without a menu are rejected
+func TestFetchMenuUnknownLanguage(t *testing.T) {
+	client := &WordPressClient{MenuIds: map[string]string{"en": "1"}}
+
+	_, err := client.FetchMenu(context.Background(), "es")
+	if err == nil || !strings.Contains(err.Error(), "no menu configured") {
+		t.Errorf("Expected no menu configured error, got %v", err)
+	}
+}
+
+// TestFetchPageAuthenticateContent tests that page and media requests only
+// send credentials, and ask for private pages, when enabled
+func TestFetchPageAuthenticateContent(t *testing.T) {
+	var lastAuth, lastStatus string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastAuth = r.Header.Get("Authorization")
+		lastStatus = r.URL.Query().Get("status")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPage{{Status: "publish", ID: 1}})
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL, WordPressAuth: "dXNlcjpwYXNz"}
+
+	if _, err := client.FetchPage(context.Background(), "/private"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lastAuth != "" || lastStatus != "" {
+		t.Errorf("Expected an anonymous request, got auth %q and status %q", lastAuth, lastStatus)
+	}
+
+	client.AuthenticateContent = true
+	if _, err := client.FetchPage(context.Background(), "/private"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lastAuth != "Basic dXNlcjpwYXNz" || lastStatus != "publish,private" {
+		t.Errorf("Expected an authenticated request for private pages, got auth %q and status %q", lastAuth, lastStatus)
+	}
+
+	resp, err := client.FetchMedia(context.Background(), "2025/01/file.pdf", nil, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if lastAuth != "Basic dXNlcjpwYXNz" {
+		t.Errorf("Expected an authenticated media request, got %q", lastAuth)
+	}
+
+	resp, err = client.FetchMedia(context.Background(), "2025/01/file.pdf", nil, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if lastAuth != "" {
+		t.Errorf("Expected an anonymous media request for visitors not signed in, got %q", lastAuth)
+	}
+}
+
+// TestFetchMediaEscapedPath tests that a decoded ? or # in an upload path
+// stays part of the path of the WordPress request
+func TestFetchMediaEscapedPath(t *testing.T) {
+	var path, query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.RawQuery
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{BaseURL: server.URL}
+	resp, err := client.FetchMedia(context.Background(), "2025/01/file?id=1#top.pdf", nil, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if path != "/wp-content/uploads/2025/01/file?id=1#top.pdf" || query != "" {
+		t.Errorf("Expected the whole upload path without a query, got %q and %q", path, query)
+	}
+}
+
+// TestFetchProtectedPage tests that the password is passed to WordPress and
+// an incorrect password is reported
+func TestFetchProtectedPage(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page := models.WordPressPage{ID: 1, Status: "publish", Slug: r.URL.Query().Get("slug")}
+		page.Content.Protected = true
+		if r.URL.Query().Get("password") == "p&ss word" {
+			page.Content.Rendered = "<p>Secret</p>"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPage{page})
+	}))
+	defer server.Close()
+
+	client := &WordPressClient{
+		BaseURL:   server.URL,
+		PageCache: cache.New[*models.WordPressPage](cache.Policy{TTL: time.Minute}),
+	}
+
+	page, err := client.FetchProtectedPage(context.Background(), "/secret", "p&ss word")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if page.Slug != "secret" || page.Content.Rendered != "<p>Secret</p>" {
+		t.Errorf("Expected unlocked page, got %+v", page)
+	}
+
+	if _, err := client.FetchProtectedPage(context.Background(), "/secret", "guess"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("Expected ErrIncorrectPassword, got %v", err)
+	}
+
+	// Unlocked pages are not cached
+	page, err = client.FetchPage(context.Background(), "/secret")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !page.Locked() || requests != 3 {
+		t.Errorf("Expected a locked page from a new request, got %+v after %d requests", page, requests)
+	}
+}
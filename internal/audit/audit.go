@@ -0,0 +1,299 @@
+// Package audit finds broken internal links by rendering every page of the
+// site and requesting each link to the site that the pages contain, so
+// that editors get a report of the links to fix.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// UserAgent identifies audit requests in the logs.
+const UserAgent = "wordpress-go-proxy-auditor"
+
+// ErrAuditing is returned when an audit is requested while one is running.
+var ErrAuditing = errors.New("link audit already running")
+
+// PageSource lists the paths of the pages to audit.
+type PageSource interface {
+	Paths(ctx context.Context) ([]string, error)
+}
+
+// BrokenLink is a link on a page to a path of the site that was not found.
+type BrokenLink struct {
+	Page   string `json:"page"`
+	Link   string `json:"link"`
+	Status int    `json:"status"`
+}
+
+// Report lists the broken links found by an audit, ordered by page and
+// link.  Pages is the number of pages audited, Failed the number that
+// could not be rendered, and Links the number of distinct links checked.
+type Report struct {
+	Pages  int          `json:"pages"`
+	Failed int          `json:"failed"`
+	Links  int          `json:"links"`
+	Broken []BrokenLink `json:"broken"`
+}
+
+// WriteCSV writes the broken links of the report as CSV with a header row.
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"page", "link", "status"})
+	for _, link := range r.Broken {
+		cw.Write([]string{link.Page, link.Link, strconv.Itoa(link.Status)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Auditor renders each page listed by Pages and checks the links on it
+// that point to the site.  Requests are served in process by Handler, the
+// same handler visitors reach, so links to media and static files are
+// checked along with pages.  Links are checked with HEAD requests, once
+// each however many pages they are on, and are broken if they are not
+// found or gone.
+type Auditor struct {
+	Handler http.Handler
+	Pages   PageSource
+
+	// Hosts are the hosts of absolute links that are the site's own, such
+	// as the WordPress and proxy hosts.  Links to other hosts are not
+	// checked.
+	Hosts []string
+
+	// Concurrency is the number of pages audited at once.
+	Concurrency int
+
+	running sync.Mutex
+}
+
+// New creates an auditor for the pages listed by pages, served by handler.
+func New(handler http.Handler, pages PageSource, hosts []string) *Auditor {
+	return &Auditor{
+		Handler:     handler,
+		Pages:       pages,
+		Hosts:       hosts,
+		Concurrency: 1,
+	}
+}
+
+// linkStatus is the status of a link, requested once.
+type linkStatus struct {
+	once   sync.Once
+	status int
+}
+
+// Audit renders every page, Concurrency at a time, checks the links on
+// them and returns the report of broken links.  Only one audit runs at a
+// time, and ErrAuditing is returned if one is already running.  Pages not
+// yet audited when ctx is done are skipped.
+func (a *Auditor) Audit(ctx context.Context) (Report, error) {
+	if !a.running.TryLock() {
+		return Report{}, ErrAuditing
+	}
+	defer a.running.Unlock()
+
+	start := time.Now()
+	paths, err := a.Pages.Paths(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var mu sync.Mutex
+	report := Report{Broken: []BrokenLink{}}
+	statuses := make(map[string]*linkStatus)
+	check := func(link string) int {
+		mu.Lock()
+		s, ok := statuses[link]
+		if !ok {
+			s = &linkStatus{}
+			statuses[link] = s
+		}
+		mu.Unlock()
+		s.once.Do(func() { s.status = a.status(ctx, link) })
+		return s.status
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for range max(a.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range jobs {
+				links, ok := a.links(ctx, page)
+				var broken []BrokenLink
+				for _, link := range links {
+					if status := check(link); status == http.StatusNotFound || status == http.StatusGone {
+						broken = append(broken, BrokenLink{Page: page, Link: link, Status: status})
+					}
+				}
+				mu.Lock()
+				report.Pages++
+				if !ok {
+					report.Failed++
+				}
+				report.Broken = append(report.Broken, broken...)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+
+	report.Links = len(statuses)
+	slices.SortFunc(report.Broken, func(x, y BrokenLink) int {
+		if c := strings.Compare(x.Page, y.Page); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Link, y.Link)
+	})
+	log.Printf("Audited %d links on %d pages in %v, %d broken", report.Links, report.Pages, time.Since(start).Round(time.Millisecond), len(report.Broken))
+	return report, ctx.Err()
+}
+
+// links renders a page and returns the distinct links on it to the site,
+// reporting false if the page could not be rendered.
+func (a *Auditor) links(ctx context.Context, page string) ([]string, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, page, nil)
+	if err != nil {
+		log.Printf("Error auditing %s: %v", page, err)
+		return nil, false
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "text/html")
+
+	rw := &bufferWriter{header: make(http.Header), status: http.StatusOK}
+	a.Handler.ServeHTTP(rw, req)
+	if rw.status != http.StatusOK {
+		log.Printf("Error auditing %s: status %d", page, rw.status)
+		return nil, false
+	}
+
+	var links []string
+	seen := make(map[string]bool)
+	z := html.NewTokenizer(&rw.body)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return links, true
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		token := z.Token()
+		if token.DataAtom != atom.A && token.DataAtom != atom.Area {
+			continue
+		}
+		for _, attr := range token.Attr {
+			if attr.Key != "href" {
+				continue
+			}
+			if link, ok := a.localLink(page, attr.Val); ok && !seen[link] {
+				seen[link] = true
+				links = append(links, link)
+			}
+		}
+	}
+}
+
+// localLink returns the path and query of a link on a page if it points
+// to the site.  Links to the page itself, such as #anchors, are skipped.
+func (a *Auditor) localLink(page string, href string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	if u.Host != "" && !a.isLocal(u.Hostname()) {
+		return "", false
+	}
+	if u.Host == "" && u.Path == "" && u.RawQuery == "" {
+		return "", false
+	}
+
+	resolved := (&url.URL{Path: page}).ResolveReference(&url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery})
+	link := resolved.EscapedPath()
+	if link == "" {
+		link = "/"
+	}
+	if resolved.RawQuery != "" {
+		link += "?" + resolved.RawQuery
+	}
+	return link, true
+}
+
+// isLocal reports whether a host is one of the site's own.
+func (a *Auditor) isLocal(host string) bool {
+	for _, h := range a.Hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// status returns the status of a HEAD request for a link.  Redirects are
+// not followed, since the redirect is what visitors of the link get.
+func (a *Auditor) status(ctx context.Context, link string) int {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, link, nil)
+	if err != nil {
+		log.Printf("Error checking %s: %v", link, err)
+		return 0
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	rw := &bufferWriter{header: make(http.Header), status: http.StatusOK, discard: true}
+	a.Handler.ServeHTTP(rw, req)
+	return rw.status
+}
+
+// bufferWriter is a response writer that keeps the status of a response
+// and its body, unless discard is set.
+type bufferWriter struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	discard     bool
+	body        bytes.Buffer
+}
+
+func (b *bufferWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferWriter) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status = status
+		b.wroteHeader = true
+	}
+}
+
+func (b *bufferWriter) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	if b.discard {
+		return len(p), nil
+	}
+	return b.body.Write(p)
+}
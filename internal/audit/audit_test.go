@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// fakePages lists fixed page paths
+type fakePages []string
+
+func (f fakePages) Paths(ctx context.Context) ([]string, error) {
+	return f, nil
+}
+
+// testSite serves two pages linking to each other and to missing pages,
+// counting the HEAD requests of each path
+type testSite struct {
+	heads map[string]*atomic.Int32
+}
+
+func (s *testSite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodHead {
+		s.heads[r.URL.RequestURI()].Add(1)
+	}
+	switch r.URL.Path {
+	case "/":
+		w.Write([]byte(`<a href="/about">About</a> <a href="https://www.example.com/gone">Gone</a>` +
+			`<a href="#main">Skip</a> <a href="mailto:info@example.com">Email</a> <a href="https://www.canada.ca/missing">Canada</a>`))
+	case "/about":
+		w.Write([]byte(`<a href="/">Home</a> <a href="team">Team</a> <a href="/old">Old</a> <a href="/about?page=2">Next</a>` +
+			`<map><area href="/missing-area"></map>`))
+	case "/old":
+		http.Redirect(w, r, "/about", http.StatusMovedPermanently)
+	case "/gone":
+		http.Error(w, "Gone", http.StatusGone)
+	case "/broken":
+		http.Error(w, "Error", http.StatusInternalServerError)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	site := &testSite{heads: make(map[string]*atomic.Int32)}
+	for _, path := range []string{"/", "/about", "/gone", "/team", "/old", "/about?page=2", "/missing-area"} {
+		site.heads[path] = &atomic.Int32{}
+	}
+	auditor := New(site, fakePages{"/", "/about", "/broken"}, []string{"www.example.com"})
+	auditor.Concurrency = 2
+
+	report, err := auditor.Audit(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []BrokenLink{
+		{Page: "/", Link: "/gone", Status: http.StatusGone},
+		{Page: "/about", Link: "/missing-area", Status: http.StatusNotFound},
+		{Page: "/about", Link: "/team", Status: http.StatusNotFound},
+	}
+	if !reflect.DeepEqual(report.Broken, expected) {
+		t.Errorf("Expected broken links %v, got %v", expected, report.Broken)
+	}
+	if report.Pages != 3 || report.Failed != 1 || report.Links != 7 {
+		t.Errorf("Expected 7 links on 3 pages with 1 failed, got %+v", report)
+	}
+	for path, heads := range site.heads {
+		if heads.Load() != 1 {
+			t.Errorf("Expected %s checked once, got %d", path, heads.Load())
+		}
+	}
+}
+
+func TestAuditRunning(t *testing.T) {
+	auditor := New(http.NotFoundHandler(), fakePages{}, nil)
+	auditor.running.Lock()
+	defer auditor.running.Unlock()
+
+	if _, err := auditor.Audit(context.Background()); !errors.Is(err, ErrAuditing) {
+		t.Errorf("Expected ErrAuditing, got %v", err)
+	}
+}
+
+func TestReportWriteCSV(t *testing.T) {
+	report := Report{Broken: []BrokenLink{
+		{Page: "/", Link: "/a,b", Status: http.StatusNotFound},
+		{Page: "/fr/", Link: "/fr/c", Status: http.StatusGone},
+	}}
+
+	var out bytes.Buffer
+	if err := report.WriteCSV(&out); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "page,link,status\n/,\"/a,b\",404\n/fr/,/fr/c,410\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ALBHeader is the header in which an Application Load Balancer passes the
+// signed claims of the user it signed in with OIDC, such as through a
+// Cognito user pool.
+const ALBHeader = "X-Amzn-Oidc-Data"
+
+// albKeyID matches the ID of an ALB signing key.
+var albKeyID = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// maxALBKeySize caps the size of a public key fetched from the ALB.
+const maxALBKeySize = 4 << 10
+
+// ALBClaims are the claims of a user signed in by an ALB.
+type ALBClaims struct {
+	Subject   string `json:"sub"`
+	Email     string `json:"email"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// ALBVerifier verifies the ES256 tokens an Application Load Balancer signs
+// the claims of signed in users with.  Tokens must be signed by the load
+// balancer named by ARN, with a key fetched from the public key endpoint
+// of its region, which is cached by key ID.
+type ALBVerifier struct {
+	ARN string
+
+	// KeysURL is the URL public keys are fetched from by appending their
+	// key ID.
+	KeysURL string
+	Client  *http.Client
+
+	mu   sync.Mutex
+	keys map[string]*ecdsa.PublicKey
+	now  func() time.Time
+}
+
+// NewALBVerifier creates a verifier of the tokens of the Application Load
+// Balancer with the given ARN.
+func NewALBVerifier(arn string) (*ALBVerifier, error) {
+	parts := strings.Split(arn, ":")
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "elasticloadbalancing" || parts[3] == "" ||
+		!strings.HasPrefix(parts[5], "loadbalancer/app/") {
+		return nil, fmt.Errorf("invalid load balancer ARN: %q", arn)
+	}
+	return &ALBVerifier{
+		ARN:     arn,
+		KeysURL: "https://public-keys.auth.elb." + parts[3] + ".amazonaws.com/",
+		Client:  &http.Client{Timeout: 5 * time.Second},
+		keys:    make(map[string]*ecdsa.PublicKey),
+		now:     time.Now,
+	}, nil
+}
+
+// albHeader is the header of an ALB token.
+type albHeader struct {
+	Alg    string `json:"alg"`
+	Kid    string `json:"kid"`
+	Signer string `json:"signer"`
+}
+
+// Verify checks the signature and expiry of a token and returns its
+// claims.
+func (v *ALBVerifier) Verify(ctx context.Context, token string) (ALBClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ALBClaims{}, ErrInvalidToken
+	}
+
+	var header albHeader
+	if err := decodeALBPart(parts[0], &header); err != nil || header.Alg != "ES256" || header.Signer != v.ARN || !albKeyID.MatchString(header.Kid) {
+		return ALBClaims{}, ErrInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil || len(sig) != 64 {
+		return ALBClaims{}, ErrInvalidToken
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return ALBClaims{}, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(key, digest[:], r, s) {
+		return ALBClaims{}, ErrInvalidToken
+	}
+
+	var claims ALBClaims
+	if err := decodeALBPart(parts[1], &claims); err != nil {
+		return ALBClaims{}, ErrInvalidToken
+	}
+	if v.now().Unix() >= claims.ExpiresAt {
+		return ALBClaims{}, ErrExpiredToken
+	}
+	return claims, nil
+}
+
+// key returns the public key with the given ID, fetching it once.
+func (v *ALBVerifier) key(ctx context.Context, kid string) (*ecdsa.PublicKey, error) {
+	v.mu.Lock()
+	key, ok := v.keys[kid]
+	v.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.KeysURL+kid, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching ALB key %s: %w", kid, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching ALB key %s: status %d", kid, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxALBKeySize))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching ALB key %s: %w", kid, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid ALB key %s", kid)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ALB key %s: %w", kid, err)
+	}
+	key, ok = parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid ALB key %s: not an ECDSA key", kid)
+	}
+
+	v.mu.Lock()
+	v.keys[kid] = key
+	v.mu.Unlock()
+	return key, nil
+}
+
+// decodeALBPart decodes a part of an ALB token, which is base64url
+// encoded, sometimes with padding.
+func decodeALBPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
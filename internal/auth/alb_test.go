@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testALBARN = "arn:aws:elasticloadbalancing:ca-central-1:123456789012:loadbalancer/app/staging/50dc6c495c0c9188"
+
+// signALBToken returns a token signed by key as an ALB signs it, with
+// padded base64 parts
+func signALBToken(t *testing.T, key *ecdsa.PrivateKey, header albHeader, claims ALBClaims) string {
+	t.Helper()
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := base64.URLEncoding.EncodeToString(h) + "." + base64.URLEncoding.EncodeToString(c)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signed + "." + base64.URLEncoding.EncodeToString(sig)
+}
+
+func TestALBVerifier(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/key-1" {
+			http.NotFound(w, r)
+			return
+		}
+		fetches.Add(1)
+		pem.Encode(w, &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	}))
+	defer server.Close()
+
+	verifier, err := NewALBVerifier(testALBARN)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if verifier.KeysURL != "https://public-keys.auth.elb.ca-central-1.amazonaws.com/" {
+		t.Errorf("Expected the keys URL of the region, got %q", verifier.KeysURL)
+	}
+	verifier.KeysURL = server.URL + "/"
+	now := time.Unix(1700000000, 0)
+	verifier.now = func() time.Time { return now }
+
+	header := albHeader{Alg: "ES256", Kid: "key-1", Signer: testALBARN}
+	claims := ALBClaims{Subject: "1234", Email: "editor@example.com", ExpiresAt: now.Add(time.Minute).Unix()}
+	for range 2 {
+		verified, err := verifier.Verify(context.Background(), signALBToken(t, key, header, claims))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if verified != claims {
+			t.Errorf("Expected claims %+v, got %+v", claims, verified)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("Expected the key fetched once, got %d", fetches.Load())
+	}
+
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	expired := claims
+	expired.ExpiresAt = now.Unix()
+	otherSigner := header
+	otherSigner.Signer = "arn:aws:elasticloadbalancing:ca-central-1:123456789012:loadbalancer/app/other/1"
+	for name, tc := range map[string]struct {
+		token    string
+		expected error
+	}{
+		"forged":          {signALBToken(t, other, header, claims), ErrInvalidToken},
+		"expired":         {signALBToken(t, key, header, expired), ErrExpiredToken},
+		"other signer":    {signALBToken(t, key, otherSigner, claims), ErrInvalidToken},
+		"malformed":       {"not.a-token", ErrInvalidToken},
+		"unsafe key ID":   {signALBToken(t, key, albHeader{Alg: "ES256", Kid: "../key-1", Signer: testALBARN}, claims), ErrInvalidToken},
+		"unsupported alg": {signALBToken(t, key, albHeader{Alg: "none", Kid: "key-1", Signer: testALBARN}, claims), ErrInvalidToken},
+	} {
+		if _, err := verifier.Verify(context.Background(), tc.token); !errors.Is(err, tc.expected) {
+			t.Errorf("Expected %v for %s token, got %v", tc.expected, name, err)
+		}
+	}
+
+	if _, err := verifier.Verify(context.Background(), signALBToken(t, key, albHeader{Alg: "ES256", Kid: "key-2", Signer: testALBARN}, claims)); err == nil {
+		t.Error("Expected error for an unknown key")
+	}
+}
+
+func TestNewALBVerifierInvalid(t *testing.T) {
+	for _, arn := range []string{"", "arn:aws:s3:::bucket", "arn:aws:elasticloadbalancing:ca-central-1:123456789012:loadbalancer/net/nlb/1"} {
+		if _, err := NewALBVerifier(arn); err == nil {
+			t.Errorf("Expected %q to be invalid", arn)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// apr1Prefix and shaPrefix start the htpasswd password hashes Htpasswd
+// supports: Apache's MD5 (htpasswd -m) and SHA-1 (htpasswd -s).
+const (
+	apr1Prefix = "$apr1$"
+	shaPrefix  = "{SHA}"
+)
+
+// apr1Alphabet is the alphabet of the base64 variant of MD5 crypt.
+const apr1Alphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Htpasswd holds the password hashes of users in htpasswd format, keyed
+// by user name.
+type Htpasswd map[string]string
+
+// ParseHtpasswd parses user:hash entries, one per line as in an htpasswd
+// file or separated by commas.  Hashes must be $apr1$ or {SHA} hashes,
+// since bcrypt hashes cannot be verified without a bcrypt library.
+func ParseHtpasswd(s string) (Htpasswd, error) {
+	users := make(Htpasswd)
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == ',' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(entry, ":")
+		if !ok || user == "" || !validHash(hash) {
+			return nil, fmt.Errorf("invalid htpasswd entry for %q", user)
+		}
+		users[user] = hash
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("no htpasswd entries")
+	}
+	return users, nil
+}
+
+// validHash reports whether a hash is in a supported format.
+func validHash(hash string) bool {
+	if rest, ok := strings.CutPrefix(hash, apr1Prefix); ok {
+		salt, sum, ok := strings.Cut(rest, "$")
+		return ok && salt != "" && len(salt) <= 8 && len(sum) == 22
+	}
+	if sum, ok := strings.CutPrefix(hash, shaPrefix); ok {
+		decoded, err := base64.StdEncoding.DecodeString(sum)
+		return err == nil && len(decoded) == sha1.Size
+	}
+	return false
+}
+
+// Verify reports whether password is the password of user.
+func (h Htpasswd) Verify(user string, password string) bool {
+	hash, ok := h[user]
+	if !ok {
+		return false
+	}
+	var computed string
+	switch {
+	case strings.HasPrefix(hash, apr1Prefix):
+		salt, _, _ := strings.Cut(strings.TrimPrefix(hash, apr1Prefix), "$")
+		computed = apr1(password, salt)
+	case strings.HasPrefix(hash, shaPrefix):
+		sum := sha1.Sum([]byte(password))
+		computed = shaPrefix + base64.StdEncoding.EncodeToString(sum[:])
+	}
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(hash)) == 1
+}
+
+// apr1 returns the Apache MD5 crypt hash of a password with a salt.
+func apr1(password string, salt string) string {
+	pw, s := []byte(password), []byte(salt)
+
+	alternate := md5.New()
+	alternate.Write(pw)
+	alternate.Write(s)
+	alternate.Write(pw)
+	altSum := alternate.Sum(nil)
+
+	h := md5.New()
+	h.Write(pw)
+	h.Write([]byte(apr1Prefix))
+	h.Write(s)
+	for i := len(pw); i > 0; i -= md5.Size {
+		h.Write(altSum[:min(i, md5.Size)])
+	}
+	for i := len(pw); i > 0; i >>= 1 {
+		if i&1 == 1 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(pw[:1])
+		}
+	}
+	sum := h.Sum(nil)
+
+	for i := range 1000 {
+		round := md5.New()
+		if i&1 == 1 {
+			round.Write(pw)
+		} else {
+			round.Write(sum)
+		}
+		if i%3 != 0 {
+			round.Write(s)
+		}
+		if i%7 != 0 {
+			round.Write(pw)
+		}
+		if i&1 == 1 {
+			round.Write(sum)
+		} else {
+			round.Write(pw)
+		}
+		sum = round.Sum(nil)
+	}
+
+	var b strings.Builder
+	b.WriteString(apr1Prefix + salt + "$")
+	for _, group := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		v := uint(sum[group[0]])<<16 | uint(sum[group[1]])<<8 | uint(sum[group[2]])
+		for range 4 {
+			b.WriteByte(apr1Alphabet[v&0x3f])
+			v >>= 6
+		}
+	}
+	v := uint(sum[11])
+	for range 2 {
+		b.WriteByte(apr1Alphabet[v&0x3f])
+		v >>= 6
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestHtpasswd(t *testing.T) {
+	users, err := ParseHtpasswd("# reviewers\nalice:$apr1$abcdefgh$h9FWgUz3n9YxylKLlR5SQ/\n" +
+		"bob:$apr1$xY1$DD5GXJDXCsMwwJszdn6zZ., carol:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		user     string
+		password string
+		expected bool
+	}{
+		{"alice", "secret", true},
+		{"alice", "Secret", false},
+		{"bob", "a much longer password over sixteen bytes", true},
+		{"carol", "secret", true},
+		{"carol", "", false},
+		{"dave", "secret", false},
+	}
+	for _, tt := range tests {
+		if got := users.Verify(tt.user, tt.password); got != tt.expected {
+			t.Errorf("Expected %s with %q verified %v, got %v", tt.user, tt.password, tt.expected, got)
+		}
+	}
+}
+
+func TestParseHtpasswdInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"alice",
+		"alice:secret",
+		"alice:$2y$10$abcdefghijklmnopqrstuuJ0cQ1Z9qRrW1V0P4nWn2x8p9dK6o1e",
+		"alice:{SHA}not-base64",
+		":$apr1$abcdefgh$h9FWgUz3n9YxylKLlR5SQ/",
+	} {
+		if _, err := ParseHtpasswd(s); err == nil {
+			t.Errorf("Expected %q to be invalid", s)
+		}
+	}
+}
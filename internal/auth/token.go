@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token purposes.  A token is only valid for the purpose it was issued for.
+// The WordPress plugin, or a scheduler holding the shared secret, mints
+// tokens for the internal jobs itself.
+const (
+	PurposeForm   = "form"
+	PurposeWarm   = "warm"
+	PurposeExport = "export"
+	PurposeAudit  = "audit"
+)
+
+var (
+	// ErrInvalidToken is returned for malformed tokens or bad signatures.
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrExpiredToken is returned for tokens outside their validity window.
+	ErrExpiredToken = errors.New("token expired")
+
+	// ErrWrongPurpose is returned for tokens issued for another purpose.
+	ErrWrongPurpose = errors.New("token issued for another purpose")
+
+	// ErrReplayedToken is returned when a token has already been used.
+	ErrReplayedToken = errors.New("token already used")
+)
+
+// Claims are the signed contents of a token.
+type Claims struct {
+	Purpose   string `json:"pur"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	ID        string `json:"jti"`
+}
+
+// Signer mints and verifies short-lived HMAC-SHA256 tokens using a secret
+// shared with the WordPress plugin.  Tokens are single use: each token ID
+// is remembered until it expires and verifying it again fails.  Used IDs
+// are held in memory so replay protection is per instance.
+type Signer struct {
+	Secret []byte
+	TTL    time.Duration
+
+	// ClockSkew is the tolerance applied to the issued and expiry times
+	// of tokens minted by clocks other than this one.
+	ClockSkew time.Duration
+
+	now  func() time.Time
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+// NewSigner creates a signer that issues tokens valid for ttl.
+func NewSigner(secret []byte, ttl time.Duration, clockSkew time.Duration) *Signer {
+	return &Signer{
+		Secret:    secret,
+		TTL:       ttl,
+		ClockSkew: clockSkew,
+		now:       time.Now,
+		used:      make(map[string]time.Time),
+	}
+}
+
+// Issue mints a token for a purpose and subject, such as a page ID.
+func (s *Signer) Issue(purpose string, subject string) (string, *Claims, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+
+	now := s.now()
+	claims := &Claims{
+		Purpose:   purpose,
+		Subject:   subject,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.TTL).Unix(),
+		ID:        hex.EncodeToString(id),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", nil, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + s.sign(encoded), claims, nil
+}
+
+// Verify checks a token's signature, validity window and purpose, then
+// marks it as used.  Tokens valid for longer than the signer's TTL are
+// rejected, so that a token minted elsewhere cannot be replayed for long.
+func (s *Signer) Verify(token string, purpose string) (*Claims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	if time.Duration(claims.ExpiresAt-claims.IssuedAt)*time.Second > s.TTL {
+		return nil, ErrInvalidToken
+	}
+
+	now := s.now()
+	if now.Add(s.ClockSkew).Before(time.Unix(claims.IssuedAt, 0)) ||
+		now.Add(-s.ClockSkew).After(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrExpiredToken
+	}
+	if claims.Purpose != purpose {
+		return nil, ErrWrongPurpose
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, expires := range s.used {
+		if now.After(expires) {
+			delete(s.used, id)
+		}
+	}
+	if _, replayed := s.used[claims.ID]; replayed {
+		return nil, ErrReplayedToken
+	}
+	s.used[claims.ID] = time.Unix(claims.ExpiresAt, 0).Add(s.ClockSkew)
+
+	return &claims, nil
+}
+
+// sign returns the base64 HMAC-SHA256 signature of an encoded payload.
+func (s *Signer) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
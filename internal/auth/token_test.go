@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// otherPurpose is a purpose tokens are not issued for.
+const otherPurpose = "other"
+
+// newTestSigner creates a signer with a controllable clock
+func newTestSigner(now *time.Time) *Signer {
+	signer := NewSigner([]byte("0123456789abcdef0123456789abcdef"), 5*time.Minute, 30*time.Second)
+	signer.now = func() time.Time { return *now }
+	return signer
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signer := newTestSigner(&now)
+
+	token, claims, err := signer.Issue(PurposeForm, "42")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if claims.ExpiresAt != now.Add(5*time.Minute).Unix() {
+		t.Errorf("Expected expiry in 5 minutes, got %d", claims.ExpiresAt)
+	}
+
+	verified, err := signer.Verify(token, PurposeForm)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if verified.Subject != "42" || verified.ID != claims.ID {
+		t.Errorf("Expected verified claims %+v, got %+v", claims, verified)
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signer := newTestSigner(&now)
+	token, _, err := signer.Issue(PurposeForm, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	other := newTestSigner(&now)
+	other.Secret = []byte("another secret that is long enough")
+	otherToken, _, _ := other.Issue(PurposeForm, "")
+
+	testCases := []struct {
+		name     string
+		token    string
+		purpose  string
+		offset   time.Duration
+		expected error
+	}{
+		{"Malformed", "not-a-token", PurposeForm, 0, ErrInvalidToken},
+		{"Tampered payload", "x" + token, PurposeForm, 0, ErrInvalidToken},
+		{"Wrong secret", otherToken, PurposeForm, 0, ErrInvalidToken},
+		{"Wrong purpose", token, otherPurpose, 0, ErrWrongPurpose},
+		{"Expired beyond skew", token, PurposeForm, 5*time.Minute + 31*time.Second, ErrExpiredToken},
+		{"Issued in the future beyond skew", token, PurposeForm, -31 * time.Second, ErrExpiredToken},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			current := now
+			now = now.Add(tc.offset)
+			defer func() { now = current }()
+
+			if _, err := signer.Verify(tc.token, tc.purpose); !errors.Is(err, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestVerifyLifetime(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signer := newTestSigner(&now)
+	lenient := newTestSigner(&now)
+	lenient.TTL = time.Hour
+	token, _, _ := lenient.Issue(PurposeForm, "")
+
+	if _, err := signer.Verify(token, PurposeForm); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected a token valid for longer than the TTL to be rejected, got %v", err)
+	}
+}
+
+func TestVerifyClockSkew(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signer := newTestSigner(&now)
+
+	early, _, _ := signer.Issue(PurposeForm, "")
+	late, _, _ := signer.Issue(PurposeForm, "")
+
+	now = now.Add(-20 * time.Second)
+	if _, err := signer.Verify(early, PurposeForm); err != nil {
+		t.Errorf("Expected token from a clock ahead within skew to verify, got %v", err)
+	}
+
+	now = now.Add(20*time.Second + 5*time.Minute + 20*time.Second)
+	if _, err := signer.Verify(late, PurposeForm); err != nil {
+		t.Errorf("Expected token expired within skew to verify, got %v", err)
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signer := newTestSigner(&now)
+	token, _, _ := signer.Issue(PurposeForm, "")
+
+	if _, err := signer.Verify(token, PurposeForm); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := signer.Verify(token, PurposeForm); !errors.Is(err, ErrReplayedToken) {
+		t.Errorf("Expected replayed token error, got %v", err)
+	}
+
+	// Used IDs are forgotten once the token can no longer verify
+	now = now.Add(10 * time.Minute)
+	signer.Verify("", PurposeForm)
+	fresh, _, _ := signer.Issue(PurposeForm, "")
+	if _, err := signer.Verify(fresh, PurposeForm); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(signer.used) != 1 {
+		t.Errorf("Expected expired token IDs to be pruned, got %d", len(signer.used))
+	}
+}
@@ -0,0 +1,129 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned for requests not sent while the breaker is open.
+var ErrOpen = errors.New("circuit breaker open")
+
+// Breaker stops requests to an origin after Threshold consecutive
+// failures, so that visitors are not kept waiting on an origin that is
+// down.  Once Cooldown has passed a single trial request is let through,
+// which closes the breaker if it succeeds or opens it for another
+// cooldown if it fails.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+	now      func() time.Time
+}
+
+// New creates a breaker opening after threshold consecutive failures for
+// the given cooldown.
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Open reports whether requests are being stopped: the breaker has opened
+// and either its cooldown has not passed or its trial request has not yet
+// completed.
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.opened() && (b.trial || b.now().Sub(b.openedAt) < b.Cooldown)
+}
+
+// Allow reports whether a request may be sent.  Once the cooldown of an
+// open breaker has passed, the first request allowed is its trial.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.opened() {
+		return true
+	}
+	if b.trial || b.now().Sub(b.openedAt) < b.Cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// Success records a request that succeeded, closing the breaker.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.opened() {
+		log.Printf("Circuit breaker closed")
+	}
+	b.failures = 0
+	b.trial = false
+}
+
+// Failure records a request that failed, opening the breaker once there
+// have been Threshold failures in a row or its trial request failed.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.trial || b.failures == b.Threshold {
+		log.Printf("Circuit breaker opened after %d failures", b.failures)
+		b.openedAt = b.now()
+	}
+	b.trial = false
+}
+
+// opened reports whether the breaker has opened and not closed since.
+func (b *Breaker) opened() bool {
+	return b.failures >= b.Threshold
+}
+
+// Transport wraps an HTTP transport so that requests fail with ErrOpen
+// without being sent while the breaker is open.  Responses with a server
+// error status and requests that fail, other than those canceled by their
+// caller, count as failures.
+func (b *Breaker) Transport(base http.RoundTripper) http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		if !b.Allow() {
+			return nil, ErrOpen
+		}
+		resp, err := base.RoundTrip(req)
+		switch {
+		case err != nil && errors.Is(req.Context().Err(), context.Canceled):
+			b.release()
+		case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+			b.Failure()
+		default:
+			b.Success()
+		}
+		return resp, err
+	})
+}
+
+// release lets another request be the trial when a trial request was
+// canceled before its outcome was known.
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// roundTripper is a function implementing http.RoundTripper.
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,87 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	b := New(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	if b.Open() || !b.Allow() {
+		t.Fatal("Expected breaker to stay closed below the threshold")
+	}
+	b.Failure()
+	if !b.Open() || b.Allow() {
+		t.Fatal("Expected breaker to open at the threshold")
+	}
+
+	now = now.Add(time.Minute)
+	if b.Open() {
+		t.Error("Expected breaker to let a trial through after the cooldown")
+	}
+	if !b.Allow() || b.Allow() {
+		t.Error("Expected a single trial request")
+	}
+	if !b.Open() {
+		t.Error("Expected breaker to stay open during its trial")
+	}
+	b.Failure()
+	if !b.Open() || b.Allow() {
+		t.Error("Expected a failed trial to open the breaker again")
+	}
+
+	now = now.Add(time.Minute)
+	b.Allow()
+	b.Success()
+	if b.Open() || !b.Allow() || !b.Allow() {
+		t.Error("Expected a successful trial to close the breaker")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	b := New(2, time.Hour)
+	client := &http.Client{Transport: b.Transport(http.DefaultTransport)}
+
+	for range 2 {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Expected the error response, got %v", err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := client.Get(server.URL); !errors.Is(err, ErrOpen) {
+		t.Errorf("Expected ErrOpen, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests sent, got %d", requests)
+	}
+
+	// Requests canceled by their caller are not failures
+	b = New(1, time.Hour)
+	client = &http.Client{Transport: b.Transport(http.DefaultTransport)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Error("Expected an error for a canceled request")
+	}
+	if b.Open() {
+		t.Error("Expected a canceled request not to open the breaker")
+	}
+}
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// Backend is a store shared between instances of the proxy, so cached
+// values survive cold starts and are reused by concurrent executions.
+// Implementations must be safe for concurrent use.
+type Backend interface {
+	// Get returns the data stored for key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores data for key, retaining it for ttl.
+	Set(ctx context.Context, key string, data []byte, ttl time.Duration) error
+}
+
+// DynamoDBAPI is the part of the DynamoDB client used by DynamoDBBackend.
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+// DynamoDBBackend stores entries in a DynamoDB table with a string
+// partition key named "key".  Entries are written with an "expires_at"
+// epoch timestamp that should be enabled as the table's TTL attribute.
+type DynamoDBBackend struct {
+	Client DynamoDBAPI
+	Table  string
+
+	now func() time.Time
+}
+
+// NewDynamoDBBackend creates a backend storing entries in a DynamoDB table.
+func NewDynamoDBBackend(client DynamoDBAPI, table string) *DynamoDBBackend {
+	return &DynamoDBBackend{
+		Client: client,
+		Table:  table,
+		now:    time.Now,
+	}
+}
+
+// Get implements Backend.  Items past their expiry are ignored since
+// DynamoDB deletes expired items lazily.
+func (b *DynamoDBBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	out, err := b.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(b.Table),
+		Key: map[string]types.AttributeValue{
+			"key": &types.AttributeValueMemberS{Value: key},
+		},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, ok := out.Item["value"].(*types.AttributeValueMemberB)
+	if !ok {
+		return nil, false, nil
+	}
+	if expires, ok := out.Item["expires_at"].(*types.AttributeValueMemberN); ok {
+		epoch, err := strconv.ParseInt(expires.Value, 10, 64)
+		if err != nil || b.now().Unix() >= epoch {
+			return nil, false, nil
+		}
+	}
+	return value.Value, true, nil
+}
+
+// Set implements Backend.
+func (b *DynamoDBBackend) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	expires := b.now().Add(ttl).Unix()
+	_, err := b.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(b.Table),
+		Item: map[string]types.AttributeValue{
+			"key":        &types.AttributeValueMemberS{Value: key},
+			"value":      &types.AttributeValueMemberB{Value: data},
+			"expires_at": &types.AttributeValueMemberN{Value: strconv.FormatInt(expires, 10)},
+		},
+	})
+	return err
+}
+
+// RedisAPI is the part of the Redis client used by RedisBackend.
+type RedisAPI interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+}
+
+// RedisBackend stores entries in Redis or ElastiCache, relying on key
+// expiry to remove them.
+type RedisBackend struct {
+	Client RedisAPI
+}
+
+// NewRedisBackend creates a backend storing entries in Redis.
+func NewRedisBackend(client RedisAPI) *RedisBackend {
+	return &RedisBackend{Client: client}
+}
+
+// Get implements Backend.
+func (b *RedisBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := b.Client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set implements Backend.
+func (b *RedisBackend) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return b.Client.Set(ctx, key, data, ttl).Err()
+}
+
+// FileBackend stores entries as files in a directory, such as Lambda's
+// /tmp, so they survive between invocations of the same execution
+// environment and are reloaded when the process restarts.
+type FileBackend struct {
+	Dir string
+
+	now func() time.Time
+}
+
+// NewFileBackend creates a backend storing entries in dir, creating the
+// directory if needed.
+func NewFileBackend(dir string) (*FileBackend, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileBackend{Dir: dir, now: time.Now}, nil
+}
+
+// Get implements Backend.  Expired files are removed.
+func (b *FileBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	path := b.path(key)
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Files hold the expiry epoch on the first line followed by the data
+	header, data, ok := bytes.Cut(contents, []byte("\n"))
+	epoch, err := strconv.ParseInt(string(header), 10, 64)
+	if !ok || err != nil || b.now().Unix() >= epoch {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, false, err
+		}
+		return nil, false, nil
+	}
+	return data, true, nil
+}
+
+// Set implements Backend.  The file is written to a temporary name and
+// renamed so that readers never see a partial entry.
+func (b *FileBackend) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	tmp, err := os.CreateTemp(b.Dir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	header := strconv.FormatInt(b.now().Add(ttl).Unix(), 10) + "\n"
+	if _, err := tmp.WriteString(header); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path(key))
+}
+
+// path returns the file an entry is stored in.  Keys are hashed since they
+// contain slashes and may be long.
+func (b *FileBackend) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(b.Dir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeDynamoDB is an in-memory DynamoDB table keyed by the "key" attribute
+type fakeDynamoDB struct {
+	mu    sync.Mutex
+	items map[string]map[string]types.AttributeValue
+	table string
+	err   error
+}
+
+func (f *fakeDynamoDB) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.table = aws.ToString(params.TableName)
+	key := params.Key["key"].(*types.AttributeValueMemberS).Value
+	return &dynamodb.GetItemOutput{Item: f.items[key]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.table = aws.ToString(params.TableName)
+	key := params.Item["key"].(*types.AttributeValueMemberS).Value
+	f.items[key] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestDynamoDBBackend(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	client := &fakeDynamoDB{items: make(map[string]map[string]types.AttributeValue)}
+	backend := NewDynamoDBBackend(client, "proxy-cache")
+	backend.now = func() time.Time { return now }
+
+	if _, found, err := backend.Get(context.Background(), "page/en/home"); found || err != nil {
+		t.Errorf("Expected a miss, got %v, %v", found, err)
+	}
+
+	if err := backend.Set(context.Background(), "page/en/home", []byte("data"), time.Minute); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if client.table != "proxy-cache" {
+		t.Errorf("Expected table proxy-cache, got %q", client.table)
+	}
+	expires := client.items["page/en/home"]["expires_at"].(*types.AttributeValueMemberN).Value
+	if expires != strconv.FormatInt(now.Add(time.Minute).Unix(), 10) {
+		t.Errorf("Expected expires_at one minute from now, got %s", expires)
+	}
+
+	data, found, err := backend.Get(context.Background(), "page/en/home")
+	if err != nil || !found || string(data) != "data" {
+		t.Errorf("Expected stored data, got %q, %v, %v", data, found, err)
+	}
+
+	// Expired items not yet deleted by DynamoDB are ignored
+	now = now.Add(2 * time.Minute)
+	if _, found, _ := backend.Get(context.Background(), "page/en/home"); found {
+		t.Error("Expected expired item to be a miss")
+	}
+
+	client.err = errors.New("throttled")
+	if _, _, err := backend.Get(context.Background(), "page/en/home"); err == nil {
+		t.Error("Expected error from the client")
+	}
+	if err := backend.Set(context.Background(), "page/en/home", []byte("data"), time.Minute); err == nil {
+		t.Error("Expected error from the client")
+	}
+}
+
+// fakeRedis is an in-memory Redis that records expirations
+type fakeRedis struct {
+	values      map[string]string
+	expirations map[string]time.Duration
+	err         error
+}
+
+func (f *fakeRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	if f.err != nil {
+		return redis.NewStringResult("", f.err)
+	}
+	value, ok := f.values[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(value, nil)
+}
+
+func (f *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	if f.err != nil {
+		return redis.NewStatusResult("", f.err)
+	}
+	f.values[key] = string(value.([]byte))
+	f.expirations[key] = expiration
+	return redis.NewStatusResult("OK", nil)
+}
+
+func TestRedisBackend(t *testing.T) {
+	client := &fakeRedis{values: make(map[string]string), expirations: make(map[string]time.Duration)}
+	backend := NewRedisBackend(client)
+
+	if _, found, err := backend.Get(context.Background(), "page/en/home"); found || err != nil {
+		t.Errorf("Expected a miss, got %v, %v", found, err)
+	}
+
+	if err := backend.Set(context.Background(), "page/en/home", []byte("data"), time.Minute); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if client.expirations["page/en/home"] != time.Minute {
+		t.Errorf("Expected a one minute expiration, got %v", client.expirations["page/en/home"])
+	}
+
+	data, found, err := backend.Get(context.Background(), "page/en/home")
+	if err != nil || !found || string(data) != "data" {
+		t.Errorf("Expected stored data, got %q, %v, %v", data, found, err)
+	}
+
+	client.err = errors.New("connection refused")
+	if _, _, err := backend.Get(context.Background(), "page/en/home"); err == nil {
+		t.Error("Expected error from the client")
+	}
+	if err := backend.Set(context.Background(), "page/en/home", []byte("data"), time.Minute); err == nil {
+		t.Error("Expected error from the client")
+	}
+}
+
+func TestFileBackend(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	dir := filepath.Join(t.TempDir(), "cache")
+	backend, err := NewFileBackend(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	backend.now = func() time.Time { return now }
+
+	if _, found, err := backend.Get(context.Background(), "page/en/home"); found || err != nil {
+		t.Errorf("Expected a miss, got %v, %v", found, err)
+	}
+
+	if err := backend.Set(context.Background(), "page/en/home", []byte("line one\nline two"), time.Minute); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A new backend on the same directory sees the entry
+	reopened, err := NewFileBackend(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	reopened.now = backend.now
+	data, found, err := reopened.Get(context.Background(), "page/en/home")
+	if err != nil || !found || string(data) != "line one\nline two" {
+		t.Errorf("Expected stored data, got %q, %v, %v", data, found, err)
+	}
+
+	// Expired entries are removed
+	now = now.Add(2 * time.Minute)
+	if _, found, _ := backend.Get(context.Background(), "page/en/home"); found {
+		t.Error("Expected expired entry to be a miss")
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("Expected expired entry to be removed, found %d files", len(files))
+	}
+
+	// Corrupt entries are treated as a miss
+	if err := os.WriteFile(backend.path("corrupt"), []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, err := backend.Get(context.Background(), "corrupt"); found || err != nil {
+		t.Errorf("Expected corrupt entry to be a miss, got %v, %v", found, err)
+	}
+}
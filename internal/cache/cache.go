@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"wordpress-go-proxy/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// backendTimeout bounds each read and write to a shared backend.
+const backendTimeout = time.Second
+
+// staleRetention is how long a shared backend keeps an expired entry so it
+// can still be served stale while it is refreshed.
+const staleRetention = 24 * time.Hour
+
+// ErrUnavailable is the class of fetch errors of an origin that is turning
+// requests away for a while, such as one rate limiting them.  Get serves an
+// expired entry rather than such an error, whatever the policy.  Fetchers
+// mark their errors with it through errors.Is.
+var ErrUnavailable = errors.New("origin unavailable")
+
+// Policy controls how long cached entries are considered fresh and
+// whether stale entries are served while they are refreshed.
+type Policy struct {
+	TTL                  time.Duration
+	StaleWhileRevalidate bool
+}
+
+// Fetcher loads the value for a key on a cache miss or refresh.
+type Fetcher[V any] func(ctx context.Context) (V, error)
+
+type entry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// sharedEntry is the encoding of an entry stored in a shared backend.
+type sharedEntry[V any] struct {
+	Value   V         `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// Cache is an in-memory, concurrency-safe cache with a TTL.  When the policy
+// enables stale-while-revalidate, expired entries are returned immediately
+// and refreshed in a background goroutine.  An optional shared backend is
+// checked on an in-memory miss and written through on every update.
+type Cache[V any] struct {
+	policy     Policy
+	backend    Backend
+	namespace  string
+	mu         sync.Mutex
+	entries    map[string]entry[V]
+	refreshing map[string]bool
+	now        func() time.Time
+}
+
+// New creates a new cache using the given policy.  A zero TTL disables
+// caching and every Get calls the fetcher.
+func New[V any](policy Policy) *Cache[V] {
+	return &Cache[V]{
+		policy:     policy,
+		entries:    make(map[string]entry[V]),
+		refreshing: make(map[string]bool),
+		now:        time.Now,
+	}
+}
+
+// NewShared creates a new cache backed by a shared backend.  Keys are
+// prefixed with namespace in the backend so that several caches can share
+// it.  A nil backend creates an in-memory cache.
+func NewShared[V any](policy Policy, backend Backend, namespace string) *Cache[V] {
+	c := New[V](policy)
+	c.backend = backend
+	c.namespace = namespace
+	return c
+}
+
+// Get returns the cached value for key, calling fetch to load it if it is
+// missing or expired.  Errors from fetch are returned and never cached,
+// except that an expired entry is returned instead of an ErrUnavailable
+// error.
+// Whether the value was cached is recorded on the span in ctx.
+func (c *Cache[V]) Get(ctx context.Context, key string, fetch Fetcher[V]) (V, error) {
+	if c.policy.TTL <= 0 {
+		return fetch(ctx)
+	}
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+
+	// Check the shared backend for an entry stored by another instance,
+	// unless this one is already refreshing it.
+	if (!ok || !c.now().Before(e.expires)) && c.backend != nil && !c.refreshing[key] {
+		c.mu.Unlock()
+		shared, found := c.load(ctx, key)
+		c.mu.Lock()
+		if found && (!ok || shared.expires.After(e.expires)) {
+			e, ok = shared, true
+			c.entries[key] = e
+		}
+	}
+	if ok && c.now().Before(e.expires) {
+		c.mu.Unlock()
+		tracing.Annotate(ctx, attribute.Bool("cache.hit", true))
+		return e.value, nil
+	}
+
+	// Serve the stale entry and refresh it in the background.  Only one
+	// refresh per key runs at a time.
+	if ok && c.policy.StaleWhileRevalidate {
+		if !c.refreshing[key] {
+			c.refreshing[key] = true
+			go c.refresh(key, fetch)
+		}
+		c.mu.Unlock()
+		tracing.Annotate(ctx, attribute.Bool("cache.hit", true))
+		return e.value, nil
+	}
+	c.mu.Unlock()
+	tracing.Annotate(ctx, attribute.Bool("cache.hit", false))
+
+	value, err := fetch(ctx)
+	if err != nil {
+		if ok && errors.Is(err, ErrUnavailable) {
+			log.Printf("Serving stale cache entry %s: %v", key, err)
+			tracing.Annotate(ctx, attribute.Bool("cache.stale", true))
+			return e.value, nil
+		}
+		return value, err
+	}
+	c.Set(key, value)
+	return value, nil
+}
+
+// Peek returns the value held in memory for key, even if it has expired,
+// without fetching it.  Fetchers use it to revalidate the value they are
+// refreshing rather than load it again.
+func (c *Cache[V]) Peek(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	return e.value, ok
+}
+
+// Fresh returns the value held in memory for key if it has not expired,
+// without fetching it.  It lets callers that load values in batches skip
+// the keys that are already cached.
+func (c *Cache[V]) Fresh(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || c.policy.TTL <= 0 || !c.now().Before(e.expires) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set stores a value for key, replacing any existing entry.
+func (c *Cache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	e := entry[V]{
+		value:   value,
+		expires: c.now().Add(c.policy.TTL),
+	}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	if c.backend != nil {
+		c.save(key, e)
+	}
+}
+
+// load reads an entry from the shared backend.  Backend and decoding
+// errors are logged and treated as a miss.
+func (c *Cache[V]) load(ctx context.Context, key string) (entry[V], bool) {
+	ctx, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+
+	data, found, err := c.backend.Get(ctx, c.namespace+key)
+	if err != nil {
+		log.Printf("Error reading cache entry %s: %v", c.namespace+key, err)
+		return entry[V]{}, false
+	}
+	if !found {
+		return entry[V]{}, false
+	}
+
+	var shared sharedEntry[V]
+	if err := json.Unmarshal(data, &shared); err != nil {
+		log.Printf("Error decoding cache entry %s: %v", c.namespace+key, err)
+		return entry[V]{}, false
+	}
+	return entry[V]{value: shared.Value, expires: shared.Expires}, true
+}
+
+// save writes an entry to the shared backend.  Expired entries are kept
+// in the backend while they can still be served stale.
+func (c *Cache[V]) save(key string, e entry[V]) {
+	data, err := json.Marshal(sharedEntry[V]{Value: e.value, Expires: e.expires})
+	if err != nil {
+		log.Printf("Error encoding cache entry %s: %v", c.namespace+key, err)
+		return
+	}
+
+	retain := c.policy.TTL
+	if c.policy.StaleWhileRevalidate {
+		retain += staleRetention
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	if err := c.backend.Set(ctx, c.namespace+key, data, retain); err != nil {
+		log.Printf("Error writing cache entry %s: %v", c.namespace+key, err)
+	}
+}
+
+// refresh reloads a stale entry.  It is detached from the request context
+// since the request that triggered it has already been served.
+func (c *Cache[V]) refresh(key string, fetch Fetcher[V]) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.refreshing, key)
+		c.mu.Unlock()
+	}()
+
+	value, err := fetch(context.Background())
+	if err != nil {
+		log.Printf("Error refreshing cache entry %s: %v", key, err)
+		return
+	}
+	c.Set(key, value)
+}
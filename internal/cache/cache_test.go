@@ -0,0 +1,337 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// countingFetcher returns a fetcher that counts its calls and returns the
+// current value of the counter.
+func countingFetcher(calls *int32) Fetcher[int32] {
+	return func(ctx context.Context) (int32, error) {
+		return atomic.AddInt32(calls, 1), nil
+	}
+}
+
+func TestCacheGet(t *testing.T) {
+	var calls int32
+	c := New[int32](Policy{TTL: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		value, err := c.Get(context.Background(), "key", countingFetcher(&calls))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if value != 1 {
+			t.Errorf("Expected cached value 1, got %d", value)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected 1 fetch, got %d", calls)
+	}
+}
+
+func TestCacheGetRecordsHit(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	var calls int32
+	c := New[int32](Policy{TTL: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		ctx, span := tracer.Start(context.Background(), "request")
+		c.Get(ctx, "key", countingFetcher(&calls))
+		span.End()
+	}
+
+	spans := recorder.Ended()
+	for i, expected := range []bool{false, true} {
+		hit := false
+		for _, attr := range spans[i].Attributes() {
+			if attr.Key == "cache.hit" {
+				hit = attr.Value.AsBool()
+			}
+		}
+		if hit != expected {
+			t.Errorf("Expected cache.hit %v on request %d, got %v", expected, i+1, hit)
+		}
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	var calls int32
+	c := New[int32](Policy{})
+
+	c.Get(context.Background(), "key", countingFetcher(&calls))
+	c.Get(context.Background(), "key", countingFetcher(&calls))
+
+	if calls != 2 {
+		t.Errorf("Expected 2 fetches with caching disabled, got %d", calls)
+	}
+}
+
+func TestCacheExpired(t *testing.T) {
+	var calls int32
+	now := time.Now()
+	c := New[int32](Policy{TTL: time.Minute})
+	c.now = func() time.Time { return now }
+
+	c.Get(context.Background(), "key", countingFetcher(&calls))
+
+	// Move past the TTL so the entry must be fetched again synchronously
+	now = now.Add(2 * time.Minute)
+	value, err := c.Get(context.Background(), "key", countingFetcher(&calls))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if value != 2 {
+		t.Errorf("Expected refreshed value 2, got %d", value)
+	}
+}
+
+// TestCachePeek tests that fetchers can see the expired value they refresh
+func TestCachePeek(t *testing.T) {
+	now := time.Now()
+	c := New[int32](Policy{TTL: time.Minute})
+	c.now = func() time.Time { return now }
+
+	if _, ok := c.Peek("key"); ok {
+		t.Error("Expected no value before the first fetch")
+	}
+	c.Set("key", 1)
+
+	now = now.Add(2 * time.Minute)
+	value, err := c.Get(context.Background(), "key", func(ctx context.Context) (int32, error) {
+		previous, ok := c.Peek("key")
+		if !ok {
+			t.Error("Expected expired value while refreshing")
+		}
+		return previous + 1, nil
+	})
+	if err != nil || value != 2 {
+		t.Errorf("Expected value revalidated from 1, got %d, %v", value, err)
+	}
+}
+
+func TestCacheFresh(t *testing.T) {
+	now := time.Now()
+	c := New[int32](Policy{TTL: time.Minute})
+	c.now = func() time.Time { return now }
+
+	if _, ok := c.Fresh("key"); ok {
+		t.Error("Expected no value before it is set")
+	}
+	c.Set("key", 1)
+	if value, ok := c.Fresh("key"); !ok || value != 1 {
+		t.Errorf("Expected fresh value 1, got %d, %v", value, ok)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Fresh("key"); ok {
+		t.Error("Expected expired value not to be fresh")
+	}
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	var calls int32
+	now := time.Now()
+	c := New[int32](Policy{TTL: time.Minute, StaleWhileRevalidate: true})
+	c.now = func() time.Time { return now }
+
+	c.Get(context.Background(), "key", countingFetcher(&calls))
+
+	// The stale value is served immediately while a refresh is triggered
+	now = now.Add(2 * time.Minute)
+	refreshed := make(chan struct{})
+	value, err := c.Get(context.Background(), "key", func(ctx context.Context) (int32, error) {
+		defer close(refreshed)
+		return atomic.AddInt32(&calls, 1), nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if value != 1 {
+		t.Errorf("Expected stale value 1, got %d", value)
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected background refresh to run")
+	}
+
+	// Wait for the refreshed value to be stored
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		value, _ = c.Get(context.Background(), "key", countingFetcher(&calls))
+		if value == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if value != 2 {
+		t.Errorf("Expected refreshed value 2, got %d", value)
+	}
+}
+
+func TestCacheFetchError(t *testing.T) {
+	c := New[int32](Policy{TTL: time.Minute})
+	fetchErr := errors.New("upstream error")
+
+	_, err := c.Get(context.Background(), "key", func(ctx context.Context) (int32, error) {
+		return 0, fetchErr
+	})
+	if !errors.Is(err, fetchErr) {
+		t.Errorf("Expected fetch error, got %v", err)
+	}
+
+	// Errors must not be cached
+	var calls int32
+	value, err := c.Get(context.Background(), "key", countingFetcher(&calls))
+	if err != nil || value != 1 {
+		t.Errorf("Expected fresh fetch after error, got value %d, err %v", value, err)
+	}
+}
+
+// TestCacheUnavailable tests that an expired entry is served rather than
+// an error of an origin that is turning requests away
+func TestCacheUnavailable(t *testing.T) {
+	var calls int32
+	now := time.Now()
+	c := New[int32](Policy{TTL: time.Minute})
+	c.now = func() time.Time { return now }
+	c.Get(context.Background(), "key", countingFetcher(&calls))
+
+	now = now.Add(2 * time.Minute)
+	value, err := c.Get(context.Background(), "key", func(ctx context.Context) (int32, error) {
+		return 0, fmt.Errorf("rate limited: %w", ErrUnavailable)
+	})
+	if err != nil || value != 1 {
+		t.Errorf("Expected stale value 1, got value %d, err %v", value, err)
+	}
+
+	// Other errors are still returned, as are errors without an entry
+	fetchErr := errors.New("upstream error")
+	if _, err := c.Get(context.Background(), "key", func(ctx context.Context) (int32, error) {
+		return 0, fetchErr
+	}); !errors.Is(err, fetchErr) {
+		t.Errorf("Expected fetch error, got %v", err)
+	}
+	if _, err := c.Get(context.Background(), "other", func(ctx context.Context) (int32, error) {
+		return 0, ErrUnavailable
+	}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable without an entry, got %v", err)
+	}
+}
+
+// mapBackend is an in-memory shared backend
+type mapBackend struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	ttls map[string]time.Duration
+	gets int
+}
+
+func newMapBackend() *mapBackend {
+	return &mapBackend{data: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (b *mapBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gets++
+	data, ok := b.data[key]
+	return data, ok, nil
+}
+
+func (b *mapBackend) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data[key] = data
+	b.ttls[key] = ttl
+	return nil
+}
+
+func TestCacheSharedBackend(t *testing.T) {
+	var calls int32
+	backend := newMapBackend()
+
+	// An entry stored by one instance is served by another
+	first := NewShared[int32](Policy{TTL: time.Minute}, backend, "page/")
+	if _, err := first.Get(context.Background(), "key", countingFetcher(&calls)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := backend.data["page/key"]; !ok {
+		t.Fatal("Expected entry to be written to the backend under its namespace")
+	}
+	if backend.ttls["page/key"] != time.Minute {
+		t.Errorf("Expected backend TTL of one minute, got %v", backend.ttls["page/key"])
+	}
+
+	second := NewShared[int32](Policy{TTL: time.Minute}, backend, "page/")
+	value, err := second.Get(context.Background(), "key", countingFetcher(&calls))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if value != 1 || calls != 1 {
+		t.Errorf("Expected shared value 1 with 1 fetch, got %d with %d fetches", value, calls)
+	}
+
+	// The entry is now held in memory
+	gets := backend.gets
+	second.Get(context.Background(), "key", countingFetcher(&calls))
+	if backend.gets != gets {
+		t.Errorf("Expected in-memory hit without reading the backend")
+	}
+}
+
+func TestCacheSharedBackendExpired(t *testing.T) {
+	var calls int32
+	now := time.Now()
+	backend := newMapBackend()
+
+	first := NewShared[int32](Policy{TTL: time.Minute}, backend, "")
+	first.now = func() time.Time { return now }
+	first.Get(context.Background(), "key", countingFetcher(&calls))
+
+	// An expired shared entry is fetched again
+	now = now.Add(2 * time.Minute)
+	second := NewShared[int32](Policy{TTL: time.Minute}, backend, "")
+	second.now = func() time.Time { return now }
+	value, _ := second.Get(context.Background(), "key", countingFetcher(&calls))
+	if value != 2 {
+		t.Errorf("Expected refetched value 2, got %d", value)
+	}
+}
+
+func TestCacheSharedBackendStaleRetention(t *testing.T) {
+	var calls int32
+	backend := newMapBackend()
+
+	c := NewShared[int32](Policy{TTL: time.Minute, StaleWhileRevalidate: true}, backend, "")
+	c.Get(context.Background(), "key", countingFetcher(&calls))
+
+	if backend.ttls["key"] <= time.Minute {
+		t.Errorf("Expected stale entries to be retained past the TTL, got %v", backend.ttls["key"])
+	}
+}
+
+func TestCacheSharedBackendInvalidEntry(t *testing.T) {
+	var calls int32
+	backend := newMapBackend()
+	backend.data["key"] = []byte("not json")
+
+	c := NewShared[int32](Policy{TTL: time.Minute}, backend, "")
+	value, err := c.Get(context.Background(), "key", countingFetcher(&calls))
+	if err != nil || value != 1 {
+		t.Errorf("Expected invalid entry to be refetched, got %d, %v", value, err)
+	}
+}
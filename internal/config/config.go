@@ -1,37 +1,448 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"wordpress-go-proxy/internal/access"
+	"wordpress-go-proxy/internal/analytics"
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/auth"
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/internal/embeds"
+	"wordpress-go-proxy/internal/flags"
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/internal/secrets"
+	"wordpress-go-proxy/internal/theme"
+	"wordpress-go-proxy/internal/transform"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// Cache backends.  The memory backend keeps each instance's cache to
+// itself and the disk backend persists it between restarts of the same
+// instance, such as invocations of a Lambda execution environment.
+const (
+	CacheBackendMemory   = "memory"
+	CacheBackendDisk     = "disk"
+	CacheBackendDynamoDB = "dynamodb"
+	CacheBackendRedis    = "redis"
+)
+
+// Sinks page feedback can be recorded to.  The EMF sink writes CloudWatch
+// embedded metric format lines to standard output.
+const (
+	FeedbackSinkEMF       = "emf"
+	FeedbackSinkDynamoDB  = "dynamodb"
+	FeedbackSinkWordPress = "wordpress"
+)
+
+// SiteIconWordPress generates the site's icons from the site icon set in
+// WordPress rather than a local file.
+const SiteIconWordPress = "wordpress"
+
+// How deployments can be locked down: HTTP Basic authentication of the
+// users of an htpasswd file, or the OIDC authentication of an Application
+// Load Balancer.
+const (
+	SiteAuthBasic = "basic"
+	SiteAuthALB   = "alb"
+)
+
+// Runtimes the proxy can run in.
+const (
+	RunModeLambda = "lambda"
+	RunModeServer = "server"
 )
 
 // Config holds all application configuration
 type Config struct {
-	// Server settings
-	Port       string
-	SiteNameEn string
-	SiteNameFr string
+	// Server settings.  RunMode is either a Lambda function or a
+	// standalone HTTP server listening on Port.
+	RunMode string
+	Port    string
+
+	// Server mode shutdown.  The proxy keeps serving for ShutdownDelay
+	// after a stop signal, then waits up to ShutdownTimeout for requests
+	// in flight.
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
+	SiteNameEn      string
+	SiteNameFr      string
+
+	// Requests with more than MaxHeaderCount header fields, or headers
+	// larger than MaxHeaderBytes, are rejected before any handler runs.
+	MaxHeaderCount int
+	MaxHeaderBytes int
 
-	// WordPress API settings
+	// Languages the site is served in.  The first language is the default.
+	// Site names and taglines that are not set are synced from the
+	// WordPress site settings at startup.
+	Languages models.LanguageRegistry
+
+	// Timezone of the site, synced from the WordPress site settings at
+	// startup if nil.
+	Timezone *time.Location
+
+	// Public URL of the proxy used for canonical links.  The request URL
+	// is used if empty, with the host of its X-Forwarded-Host header if
+	// TrustForwardedHost is set, for a proxy such as CloudFront that sets
+	// it in front of API Gateway or a Lambda function URL.
+	// CanonicalRedirect redirects requests over HTTP, or for the www or
+	// apex alias of its host, to BaseURL.
+	BaseURL            string
+	TrustForwardedHost bool
+	CanonicalRedirect  bool
+
+	// WordPress API settings.  The English and French menu IDs are set
+	// when those languages are configured.
 	WordPressBaseURL  string
 	WordPressUsername string
 	WordPressPassword string
 	WordPressMenuIdEn string
 	WordPressMenuIdFr string
+
+	// Send the WordPress credentials with page requests, and the media
+	// requests of signed in visitors, so that private pages and protected
+	// uploads can be proxied
+	WordPressAuthContent bool
+
+	// Each client address may try PasswordRateLimit passwords of protected
+	// pages per PasswordRateWindow, without limit if the window is zero
+	PasswordRateLimit  int
+	PasswordRateWindow time.Duration
+
+	// Headers added to every WordPress request, as "Name: value" entries,
+	// so that the origin can only be reached through the proxy
+	WordPressHeaders string
+
+	// Client certificate and key presented to a WordPress origin requiring
+	// mutual TLS, and the CAs trusted to sign its certificate, each as PEM
+	// or the path of a PEM file
+	WordPressTLSCert string
+	WordPressTLSKey  string
+	WordPressTLSCA   string
+
+	// WordPress API timeouts
+	WordPressPageTimeout time.Duration
+	WordPressMenuTimeout time.Duration
+
+	// Interval on which the connections of WordPress requests are counted
+	// in the logs, such as how many were reused.  Zero disables logging
+	// them.
+	ConnStatsInterval time.Duration
+
+	// Number of search results and archive posts per page
+	PageSize int
+
+	// Menu refresh interval
+	MenuRefreshInterval time.Duration
+
+	// Redirects managed with the WordPress Redirection plugin
+	RedirectsEnabled        bool
+	RedirectRefreshInterval time.Duration
+
+	// Redirect WordPress permalinks such as /?p=123 to proxy paths
+	PermalinkRedirects bool
+
+	// Serve listings of static directories without an index.html
+	StaticDirectoryListings bool
+
+	// Cache policies by content type
+	PageCache  cache.Policy
+	ImageCache cache.Policy
+	FeedCache  cache.Policy
+	AlertCache cache.Policy
+
+	// Cache warming.  The pages linked from the menus are requested on
+	// start if WarmOnStartup is set, every WarmInterval in server mode if
+	// set, and on POST /internal/warm with a warm token signed with the
+	// AuthSecret as a bearer credential.  WarmConcurrency is the number of
+	// pages requested at once.
+	WarmOnStartup   bool
+	WarmInterval    time.Duration
+	WarmConcurrency int
+
+	// Static export.  Every page and post is rendered and written to
+	// ExportTarget, a directory or an s3://bucket/prefix URL, on POST
+	// /internal/export with an export token signed with the AuthSecret as
+	// a bearer credential or when run with -export, so the site can fail
+	// over to a static copy.
+	ExportTarget string
+
+	// Static failover.  With FailoverEnabled, requests to WordPress stop
+	// for BreakerCooldown after BreakerThreshold consecutive failures, and
+	// meanwhile pages are served from the last export at ExportTarget.
+	FailoverEnabled  bool
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// Comments on posts of the custom post types, rendered below their
+	// content and submitted to /comments.  Each client address may submit
+	// CommentRateLimit comments per CommentRateWindow, without limit if
+	// the window is zero.
+	CommentsEnabled   bool
+	CommentRateLimit  int
+	CommentRateWindow time.Duration
+
+	// Answers to whether a page was helpful, submitted to /api/feedback
+	// and recorded to FeedbackSink, with the CloudWatch namespace, DynamoDB
+	// table or WordPress REST route it uses.  The widget is not shown if
+	// the sink is empty.  Each client address may submit
+	// FeedbackRateLimit answers per FeedbackRateWindow, without limit if
+	// the window is zero.
+	FeedbackSink          string
+	FeedbackNamespace     string
+	FeedbackDynamoDBTable string
+	FeedbackRoute         string
+	FeedbackRateLimit     int
+	FeedbackRateWindow    time.Duration
+
+	// Forms of a WordPress forms plugin served under /forms/<name>, each
+	// with its ID in the plugin.  Submissions are forwarded to FormRoute,
+	// a REST route with {id} in place of the form ID, and must carry a
+	// token signed with the AuthSecret that is valid for FormTokenTTL.
+	Forms        []models.Form
+	FormRoute    string
+	FormTokenTTL time.Duration
+
+	// Site-wide alerts shown at the top of every page, such as of service
+	// disruptions: the posts of AlertsRoute, a REST route such as that of
+	// an alerts custom post type, limited to the category AlertsCategory if
+	// it is not zero.  Setting only AlertsCategory shows the posts of that
+	// category.  Alerts are cached for AlertCache.TTL.
+	AlertsRoute    string
+	AlertsCategory int
+
+	// Well-known documents under /.well-known/.  security.txt is generated
+	// if SecurityContacts is set, expiring at SecurityExpires or on a
+	// rolling basis if zero, and change-password redirects to
+	// ChangePasswordURL if set.  Files in WellKnownDir are served as is,
+	// taking precedence over both.
+	SecurityContacts  []string
+	SecurityExpires   time.Time
+	SecurityPolicy    string
+	ChangePasswordURL string
+	WellKnownDir      string
+
+	// Rendering experiments visitors are assigned a variant of, exposed to
+	// templates so they can render alternate layouts or treatments
+	Experiments []middleware.Experiment
+
+	// Backend the page cache and menus are persisted to, with the
+	// directory, DynamoDB table or Redis URL it uses
+	CacheBackend       string
+	CacheDir           string
+	CacheDynamoDBTable string
+	CacheRedisURL      string
+
+	// Secret shared with the WordPress plugin for signed tokens, which may
+	// be valid for at most AuthTokenTTL.  The endpoints requiring them are
+	// disabled if empty.
+	AuthSecret    string
+	AuthTokenTTL  time.Duration
+	AuthClockSkew time.Duration
+
+	// Lock down of non-production deployments, one of the SiteAuth
+	// constants, or none if empty.  SiteAuthUsers holds the htpasswd
+	// entries of SiteAuthBasic and SiteAuthALBARN the load balancer whose
+	// signed claims SiteAuthALB requires.
+	SiteAuth       string
+	SiteAuthUsers  string
+	SiteAuthALBARN string
+
+	// Sections restricted to signed in visitors or allowlisted networks,
+	// as prefix=condition|condition rules, see access.ParseRule.  Visitors
+	// sign in with the credentials of AuthMethod.
+	AccessRules []string
+
+	// Canonical page paths
+	TrailingSlash  string
+	LowercasePaths bool
+
+	// How the language toggle of an untranslated page is rendered, either
+	// hidden or linked to the other language's home page
+	MissingTranslation string
+
+	// How links that leave the site are opened, one of the
+	// transform.ExternalTarget constants
+	ExternalLinkTarget string
+
+	// Content-Security-Policy settings
+	CSPPolicy     string
+	CSPReportOnly bool
+	CSPReportURI  string
+	CSPReportTo   string
+
+	// Resource hints: origins pages load resources from, such as a media
+	// CDN, to preconnect to, and whether hints are sent as 103 Early Hints
+	// before pages are rendered when running as a server
+	PreconnectOrigins []string
+	EarlyHints        bool
+
+	// Whether rendered pages are minified before they are sent
+	MinifyHTML bool
+
+	// Whether every response is kept out of search engines, such as on
+	// staging, and the custom field that keeps a single page out
+	NoIndex      bool
+	NoIndexField string
+
+	// Selectors of the markup removed from content, such as the scripts
+	// and styles WordPress injects, see transform.Cruft
+	ContentRemove []string
+
+	// Content sanitization and the hosts iframes may embed
+	SanitizeContent bool
+	EmbedHosts      []string
+
+	// How embedded content is rendered, by provider.  Providers without a
+	// mode, and unknown hosts, use EmbedDefaultMode.
+	EmbedModes       map[string]string
+	EmbedDefaultMode string
+
+	// Responsive image widths.  Image resizing is disabled if empty.
+	ImageWidths []int
+
+	// Template theme, loaded from templates/<Theme>/
+	Theme string
+
+	// Stylesheets and scripts loaded on every page after the theme's, as
+	// absolute URLs or site paths.  {analytics_id} in their URLs is
+	// replaced with AnalyticsID, the environment's analytics property,
+	// which scripts are also given as their data-analytics-id attribute.
+	// Stylesheets from other hosts must be allowed by CSP_POLICY.
+	AssetStylesheets []string
+	AssetScripts     []string
+	AnalyticsID      string
+
+	// Analytics provider whose script pages load with AnalyticsID, one of
+	// analytics.Providers, for visitors whose AnalyticsConsentCookie
+	// grants consent.  Analytics is not loaded if empty.
+	AnalyticsProvider      string
+	AnalyticsConsentCookie string
+
+	// Whether visitors can choose the color scheme pages are rendered in,
+	// which is remembered in ColorSchemeCookie.  Pages otherwise follow the
+	// scheme their browser prefers.  The stylesheets of the dark and high
+	// contrast schemes are given like AssetStylesheets and loaded after
+	// them.
+	ColorSchemes             bool
+	ColorSchemeCookie        string
+	AssetStylesheetsDark     []string
+	AssetStylesheetsContrast []string
+
+	// Organization publishing the site, described in the structured data
+	// of pages in the languages it has an ORGANIZATION_NAME_<LANG> in.  Its
+	// website, logo and other profiles are absolute URLs.
+	OrganizationURL    string
+	OrganizationLogo   string
+	OrganizationSameAs []string
+
+	// Image the favicon, touch icon and web app manifest icons are
+	// generated from, either a local file or SiteIconWordPress.  The
+	// theme's default icon is used if empty.
+	SiteIcon string
+
+	// WordPress custom post types served under /<name>/, each with its
+	// REST route
+	CustomTypes []models.PostType
+
+	// Request filtering.  Rules from FilterRulesURL, an s3:// or https://
+	// URL, are merged with the configured rules and refreshed on an
+	// interval.  FilterTrustedProxies is the number of proxies whose
+	// X-Forwarded-For entries identify the client.
+	FilterAllowCIDRs      []string
+	FilterDenyCIDRs       []string
+	FilterDenyUserAgents  []string
+	FilterDenyPaths       []string
+	FilterRulesURL        string
+	FilterRefreshInterval time.Duration
+	FilterTrustedProxies  int
+
+	// Secrets given as the ARN of a Secrets Manager secret or SSM
+	// parameter, keyed by variable name.  Their values are set by
+	// ResolveSecrets, and resolved again on SecretRefreshInterval so that
+	// rotated WordPress credentials are used.
+	SecretRefs            map[string]string
+	SecretRefreshInterval time.Duration
+
+	// Feature toggles gating routes.  Toggles from FlagsURL, an AWS
+	// AppConfig agent configuration URL, override the configured toggles
+	// and are refreshed on an interval.
+	EnableSearch         bool
+	EnableFeeds          bool
+	EnablePageAPI        bool
+	EnableGraphQL        bool
+	FlagsURL             string
+	FlagsRefreshInterval time.Duration
+
+	// OpenTelemetry tracing, exported over OTLP to TracingEndpoint.
+	// Tracing is disabled if no endpoint is set.  TracingSampleRatio is
+	// the fraction of new traces sampled.
+	TracingEndpoint    string
+	TracingServiceName string
+	TracingSampleRatio float64
+
+	// X-Ray tracing in Lambda, sending spans to the X-Ray daemon that
+	// Lambda runs for functions with active tracing.
+	XRayEnabled       bool
+	XRayDaemonAddress string
 }
 
 // Load reads configuration from environment variables and sets defaults
 func Load() (*Config, error) {
 	cfg := &Config{}
 
+	// Languages default to English and French
+	codes := []string{"en", "fr"}
+	if val := os.Getenv("LANGUAGES"); val != "" {
+		codes = nil
+		for _, field := range strings.Split(val, ",") {
+			code := strings.ToLower(strings.TrimSpace(field))
+			if !languageCode.MatchString(code) || containsCode(codes, code) {
+				return nil, fmt.Errorf("invalid language in LANGUAGES: %q", field)
+			}
+			codes = append(codes, code)
+		}
+	}
+	cfg.Languages = make(models.LanguageRegistry, len(codes))
+
 	requiredVars := map[string]*string{
-		"SITE_NAME_EN":         &cfg.SiteNameEn,
-		"SITE_NAME_FR":         &cfg.SiteNameFr,
-		"WORDPRESS_URL":        &cfg.WordPressBaseURL,
-		"WORDPRESS_USERNAME":   &cfg.WordPressUsername,
-		"WORDPRESS_PASSWORD":   &cfg.WordPressPassword,
-		"WORDPRESS_MENU_ID_EN": &cfg.WordPressMenuIdEn,
-		"WORDPRESS_MENU_ID_FR": &cfg.WordPressMenuIdFr,
+		"WORDPRESS_URL":      &cfg.WordPressBaseURL,
+		"WORDPRESS_USERNAME": &cfg.WordPressUsername,
+		"WORDPRESS_PASSWORD": &cfg.WordPressPassword,
+	}
+	for i, code := range codes {
+		cfg.Languages[i] = models.NewLanguage(code, i == 0)
+		suffix := languageSuffix(code)
+		requiredVars["WORDPRESS_MENU_ID_"+suffix] = &cfg.Languages[i].MenuID
+		cfg.Languages[i].SiteName = os.Getenv("SITE_NAME_" + suffix)
+		cfg.Languages[i].Tagline = os.Getenv("SITE_TAGLINE_" + suffix)
+		cfg.Languages[i].Organization = os.Getenv("ORGANIZATION_NAME_" + suffix)
+		if val := os.Getenv("HOME_SLUG_" + suffix); val != "" {
+			cfg.Languages[i].HomeSlug = val
+		}
+		if val := os.Getenv("HOME_PAGE_ID_" + suffix); val != "" {
+			id, err := strconv.Atoi(val)
+			if err != nil || id <= 0 {
+				return nil, fmt.Errorf("invalid value for HOME_PAGE_ID_%s: %q", suffix, val)
+			}
+			if os.Getenv("HOME_SLUG_"+suffix) != "" {
+				return nil, fmt.Errorf("HOME_SLUG_%s and HOME_PAGE_ID_%s cannot both be set", suffix, suffix)
+			}
+			cfg.Languages[i].HomePageID = id
+		}
 	}
 
 	// Check all required variables
@@ -47,14 +458,1132 @@ func Load() (*Config, error) {
 
 	// Return error if any required variables are missing
 	if len(missingVars) > 0 {
+		sort.Strings(missingVars)
 		return nil, fmt.Errorf("missing required environment variables: %v", missingVars)
 	}
 
+	// The WordPress URL may include the path WordPress is installed under
+	u, err := url.Parse(cfg.WordPressBaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid URL for WORDPRESS_URL: %q", cfg.WordPressBaseURL)
+	}
+	cfg.WordPressBaseURL = strings.TrimSuffix(cfg.WordPressBaseURL, "/")
+
+	// Keep the English and French settings for existing callers
+	if lang, ok := cfg.Languages.Get("en"); ok {
+		cfg.SiteNameEn, cfg.WordPressMenuIdEn = lang.SiteName, lang.MenuID
+	}
+	if lang, ok := cfg.Languages.Get("fr"); ok {
+		cfg.SiteNameFr, cfg.WordPressMenuIdFr = lang.SiteName, lang.MenuID
+	}
+
 	// Set optional variables
+	if val := os.Getenv("TIMEZONE"); val != "" {
+		loc, err := time.LoadLocation(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for TIMEZONE: %q", val)
+		}
+		cfg.Timezone = loc
+	}
+
 	cfg.Port = os.Getenv("PORT")
 	if cfg.Port == "" {
 		cfg.Port = "5000"
 	}
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid value for PORT: %q", cfg.Port)
+	}
+
+	// Set optional run mode, which defaults to Lambda when running in a
+	// Lambda function and a standalone server otherwise
+	cfg.RunMode = RunModeServer
+	if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
+		cfg.RunMode = RunModeLambda
+	}
+	if val := os.Getenv("RUN_MODE"); val != "" {
+		switch val {
+		case RunModeLambda, RunModeServer:
+			cfg.RunMode = val
+		default:
+			return nil, fmt.Errorf("invalid value for RUN_MODE: %q", val)
+		}
+	}
+
+	// Set optional token secret
+	cfg.AuthSecret = os.Getenv("AUTH_SECRET")
+	if !secrets.IsReference(cfg.AuthSecret) {
+		if err := validateAuthSecret(cfg.AuthSecret); err != nil {
+			return nil, err
+		}
+	}
+
+	// Set optional headers of WordPress requests
+	cfg.WordPressHeaders = os.Getenv("WORDPRESS_HEADERS")
+	if cfg.WordPressHeaders != "" && !secrets.IsReference(cfg.WordPressHeaders) {
+		if _, err := api.ParseHeaders(cfg.WordPressHeaders); err != nil {
+			return nil, fmt.Errorf("invalid value for WORDPRESS_HEADERS: %w", err)
+		}
+	}
+
+	// Set optional client certificate for mutual TLS with WordPress
+	cfg.WordPressTLSCert = os.Getenv("WORDPRESS_TLS_CERT")
+	cfg.WordPressTLSKey = os.Getenv("WORDPRESS_TLS_KEY")
+	if (cfg.WordPressTLSCert == "") != (cfg.WordPressTLSKey == "") {
+		return nil, fmt.Errorf("WORDPRESS_TLS_CERT and WORDPRESS_TLS_KEY must be set together")
+	}
+	if cfg.WordPressTLSCert != "" && !secrets.IsReference(cfg.WordPressTLSCert) && !secrets.IsReference(cfg.WordPressTLSKey) {
+		if _, err := api.LoadKeyPair(cfg.WordPressTLSCert, cfg.WordPressTLSKey); err != nil {
+			return nil, fmt.Errorf("invalid value for WORDPRESS_TLS_CERT or WORDPRESS_TLS_KEY: %w", err)
+		}
+	}
+	cfg.WordPressTLSCA = os.Getenv("WORDPRESS_TLS_CA")
+	if cfg.WordPressTLSCA != "" {
+		if _, err := api.LoadCertPool(cfg.WordPressTLSCA); err != nil {
+			return nil, fmt.Errorf("invalid value for WORDPRESS_TLS_CA: %w", err)
+		}
+	}
+
+	// Set optional lock down of the deployment.  Its users or load
+	// balancer also sign visitors in to the sections of ACCESS_RULES.
+	cfg.SiteAuthUsers = os.Getenv("SITE_AUTH_USERS")
+	if cfg.SiteAuthUsers != "" && !secrets.IsReference(cfg.SiteAuthUsers) {
+		if _, err := auth.ParseHtpasswd(cfg.SiteAuthUsers); err != nil {
+			return nil, fmt.Errorf("invalid value for SITE_AUTH_USERS: %w", err)
+		}
+	}
+	cfg.SiteAuthALBARN = os.Getenv("SITE_AUTH_ALB_ARN")
+	if cfg.SiteAuthALBARN != "" {
+		if _, err := auth.NewALBVerifier(cfg.SiteAuthALBARN); err != nil {
+			return nil, fmt.Errorf("invalid value for SITE_AUTH_ALB_ARN: %q", cfg.SiteAuthALBARN)
+		}
+	}
+	if val := os.Getenv("SITE_AUTH"); val != "" {
+		switch val {
+		case SiteAuthBasic:
+			if cfg.SiteAuthUsers == "" {
+				return nil, fmt.Errorf("SITE_AUTH_USERS is required when SITE_AUTH is %s", val)
+			}
+		case SiteAuthALB:
+			if cfg.SiteAuthALBARN == "" {
+				return nil, fmt.Errorf("SITE_AUTH_ALB_ARN is required when SITE_AUTH is %s", val)
+			}
+		default:
+			return nil, fmt.Errorf("invalid value for SITE_AUTH: %q", val)
+		}
+		cfg.SiteAuth = val
+	}
+
+	// Set optional restricted sections
+	if val := os.Getenv("ACCESS_RULES"); val != "" {
+		var rules []access.Rule
+		for _, field := range strings.Split(val, ",") {
+			rule, err := access.ParseRule(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule in ACCESS_RULES: %q", field)
+			}
+			rules = append(rules, rule)
+			cfg.AccessRules = append(cfg.AccessRules, strings.TrimSpace(field))
+		}
+		if access.NeedsAuth(rules) && cfg.AuthMethod() == "" {
+			return nil, fmt.Errorf("SITE_AUTH_USERS or SITE_AUTH_ALB_ARN is required when ACCESS_RULES has auth rules")
+		}
+	}
+
+	// Set optional public URL
+	if val := os.Getenv("BASE_URL"); val != "" {
+		u, err := url.Parse(val)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			strings.TrimSuffix(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("invalid URL for BASE_URL: %q", val)
+		}
+		cfg.BaseURL = u.Scheme + "://" + u.Host
+	}
+
+	// Set optional timeouts and intervals
+	durationVars := map[string]struct {
+		ptr          *time.Duration
+		defaultValue time.Duration
+	}{
+		"WORDPRESS_PAGE_TIMEOUT":    {&cfg.WordPressPageTimeout, 3 * time.Second},
+		"WORDPRESS_MENU_TIMEOUT":    {&cfg.WordPressMenuTimeout, 3 * time.Second},
+		"MENU_REFRESH_INTERVAL":     {&cfg.MenuRefreshInterval, 5 * time.Minute},
+		"REDIRECT_REFRESH_INTERVAL": {&cfg.RedirectRefreshInterval, 5 * time.Minute},
+		"AUTH_TOKEN_TTL":            {&cfg.AuthTokenTTL, 5 * time.Minute},
+		"AUTH_CLOCK_SKEW":           {&cfg.AuthClockSkew, 30 * time.Second},
+		"SHUTDOWN_DELAY":            {&cfg.ShutdownDelay, 0},
+		"SHUTDOWN_TIMEOUT":          {&cfg.ShutdownTimeout, 25 * time.Second},
+		"FILTER_REFRESH_INTERVAL":   {&cfg.FilterRefreshInterval, time.Minute},
+		"SECRET_REFRESH_INTERVAL":   {&cfg.SecretRefreshInterval, time.Hour},
+		"FLAGS_REFRESH_INTERVAL":    {&cfg.FlagsRefreshInterval, time.Minute},
+		"WARM_INTERVAL":             {&cfg.WarmInterval, 0},
+		"COMMENT_RATE_WINDOW":       {&cfg.CommentRateWindow, 10 * time.Minute},
+		"PASSWORD_RATE_WINDOW":      {&cfg.PasswordRateWindow, 10 * time.Minute},
+		"FORM_TOKEN_TTL":            {&cfg.FormTokenTTL, time.Hour},
+		"FEEDBACK_RATE_WINDOW":      {&cfg.FeedbackRateWindow, 10 * time.Minute},
+		"BREAKER_COOLDOWN":          {&cfg.BreakerCooldown, 30 * time.Second},
+		"CONN_STATS_INTERVAL":       {&cfg.ConnStatsInterval, 0},
+	}
+	for name, v := range durationVars {
+		*v.ptr = v.defaultValue
+		if val := os.Getenv(name); val != "" {
+			duration, err := time.ParseDuration(val)
+			if err != nil || duration < 0 {
+				return nil, fmt.Errorf("invalid duration for %s: %q", name, val)
+			}
+			*v.ptr = duration
+		}
+	}
+
+	// Set optional feature flags
+	boolVars := map[string]struct {
+		ptr          *bool
+		defaultValue bool
+	}{
+		"WORDPRESS_AUTH_CONTENT":    {&cfg.WordPressAuthContent, false},
+		"REDIRECTS_ENABLED":         {&cfg.RedirectsEnabled, false},
+		"PERMALINK_REDIRECTS":       {&cfg.PermalinkRedirects, true},
+		"LOWERCASE_PATHS":           {&cfg.LowercasePaths, true},
+		"CSP_REPORT_ONLY":           {&cfg.CSPReportOnly, false},
+		"CONTENT_SANITIZE":          {&cfg.SanitizeContent, true},
+		"ENABLE_SEARCH":             {&cfg.EnableSearch, true},
+		"ENABLE_FEEDS":              {&cfg.EnableFeeds, true},
+		"ENABLE_PAGE_API":           {&cfg.EnablePageAPI, false},
+		"ENABLE_GRAPHQL":            {&cfg.EnableGraphQL, false},
+		"XRAY_ENABLED":              {&cfg.XRayEnabled, false},
+		"WARM_ON_STARTUP":           {&cfg.WarmOnStartup, false},
+		"COMMENTS_ENABLED":          {&cfg.CommentsEnabled, false},
+		"STATIC_DIRECTORY_LISTINGS": {&cfg.StaticDirectoryListings, false},
+		"FAILOVER_ENABLED":          {&cfg.FailoverEnabled, false},
+		"EARLY_HINTS":               {&cfg.EarlyHints, false},
+		"MINIFY_HTML":               {&cfg.MinifyHTML, false},
+		"NOINDEX":                   {&cfg.NoIndex, false},
+		"TRUST_FORWARDED_HOST":      {&cfg.TrustForwardedHost, false},
+		"CANONICAL_REDIRECT":        {&cfg.CanonicalRedirect, false},
+		"COLOR_SCHEMES":             {&cfg.ColorSchemes, false},
+	}
+	for name, v := range boolVars {
+		*v.ptr = v.defaultValue
+		if val := os.Getenv(name); val != "" {
+			enabled, err := strconv.ParseBool(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid boolean for %s: %q", name, val)
+			}
+			*v.ptr = enabled
+		}
+	}
+	if cfg.CanonicalRedirect && cfg.BaseURL == "" {
+		return nil, fmt.Errorf("BASE_URL is required when CANONICAL_REDIRECT is true")
+	}
+
+	// Set optional counts and sizes
+	intVars := map[string]struct {
+		ptr          *int
+		defaultValue int
+		min, max     int
+	}{
+		"PAGE_SIZE":              {&cfg.PageSize, 10, 1, 100},
+		"FILTER_TRUSTED_PROXIES": {&cfg.FilterTrustedProxies, 0, 0, 10},
+		"WARM_CONCURRENCY":       {&cfg.WarmConcurrency, 4, 1, 32},
+		"COMMENT_RATE_LIMIT":     {&cfg.CommentRateLimit, 5, 1, 1000},
+		"PASSWORD_RATE_LIMIT":    {&cfg.PasswordRateLimit, 10, 1, 1000},
+		"FEEDBACK_RATE_LIMIT":    {&cfg.FeedbackRateLimit, 20, 1, 1000},
+		"BREAKER_THRESHOLD":      {&cfg.BreakerThreshold, 5, 1, 100},
+		"MAX_HEADER_COUNT":       {&cfg.MaxHeaderCount, 100, 10, 1000},
+		"MAX_HEADER_BYTES":       {&cfg.MaxHeaderBytes, 32 << 10, 4 << 10, 1 << 20},
+	}
+	for name, v := range intVars {
+		*v.ptr = v.defaultValue
+		if val := os.Getenv(name); val != "" {
+			n, err := strconv.Atoi(val)
+			if err != nil || n < v.min || n > v.max {
+				return nil, fmt.Errorf("invalid number for %s: %q", name, val)
+			}
+			*v.ptr = n
+		}
+	}
+
+	// Set optional cache policies
+	pageCache, err := loadCachePolicy("PAGE")
+	if err != nil {
+		return nil, err
+	}
+	cfg.PageCache = pageCache
+
+	imageCache, err := loadCachePolicy("IMAGE")
+	if err != nil {
+		return nil, err
+	}
+	cfg.ImageCache = imageCache
+
+	feedCache, err := loadCachePolicy("FEED")
+	if err != nil {
+		return nil, err
+	}
+	cfg.FeedCache = feedCache
+
+	// Alerts are cached for a minute unless set otherwise, so that they
+	// appear soon after they are published
+	alertCache, err := loadCachePolicy("ALERT")
+	if err != nil {
+		return nil, err
+	}
+	if os.Getenv("ALERT_CACHE_TTL") == "" {
+		alertCache.TTL = time.Minute
+	}
+	cfg.AlertCache = alertCache
+
+	// Set optional shared cache backend
+	cfg.CacheBackend = CacheBackendMemory
+	switch val := os.Getenv("CACHE_BACKEND"); val {
+	case "", CacheBackendMemory:
+	case CacheBackendDisk:
+		cfg.CacheBackend = val
+		cfg.CacheDir = os.Getenv("CACHE_DIR")
+		if cfg.CacheDir == "" {
+			cfg.CacheDir = filepath.Join(os.TempDir(), "wordpress-go-proxy")
+		}
+	case CacheBackendDynamoDB:
+		cfg.CacheBackend = val
+		cfg.CacheDynamoDBTable = os.Getenv("CACHE_DYNAMODB_TABLE")
+		if cfg.CacheDynamoDBTable == "" {
+			return nil, fmt.Errorf("CACHE_DYNAMODB_TABLE is required when CACHE_BACKEND is %s", val)
+		}
+	case CacheBackendRedis:
+		cfg.CacheBackend = val
+		cfg.CacheRedisURL = os.Getenv("CACHE_REDIS_URL")
+		if !secrets.IsReference(cfg.CacheRedisURL) {
+			if err := validateRedisURL(cfg.CacheRedisURL); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid value for CACHE_BACKEND: %q", val)
+	}
+
+	// Set optional page feedback sink
+	switch val := os.Getenv("FEEDBACK_SINK"); val {
+	case "":
+	case FeedbackSinkEMF:
+		cfg.FeedbackSink = val
+		cfg.FeedbackNamespace = os.Getenv("FEEDBACK_NAMESPACE")
+		if cfg.FeedbackNamespace == "" {
+			cfg.FeedbackNamespace = "WordPressProxy"
+		}
+	case FeedbackSinkDynamoDB:
+		cfg.FeedbackSink = val
+		cfg.FeedbackDynamoDBTable = os.Getenv("FEEDBACK_DYNAMODB_TABLE")
+		if cfg.FeedbackDynamoDBTable == "" {
+			return nil, fmt.Errorf("FEEDBACK_DYNAMODB_TABLE is required when FEEDBACK_SINK is %s", val)
+		}
+	case FeedbackSinkWordPress:
+		cfg.FeedbackSink = val
+		cfg.FeedbackRoute = os.Getenv("FEEDBACK_ROUTE")
+		if !postTypeRoute.MatchString(cfg.FeedbackRoute) {
+			return nil, fmt.Errorf("invalid value for FEEDBACK_ROUTE: %q", cfg.FeedbackRoute)
+		}
+	default:
+		return nil, fmt.Errorf("invalid value for FEEDBACK_SINK: %q", val)
+	}
+
+	// Set optional site-wide alerts
+	if val := os.Getenv("ALERTS_CATEGORY"); val != "" {
+		category, err := strconv.Atoi(val)
+		if err != nil || category <= 0 {
+			return nil, fmt.Errorf("invalid number for ALERTS_CATEGORY: %q", val)
+		}
+		cfg.AlertsCategory = category
+		cfg.AlertsRoute = "wp/v2/posts"
+	}
+	if val := os.Getenv("ALERTS_ROUTE"); val != "" {
+		if !postTypeRoute.MatchString(val) {
+			return nil, fmt.Errorf("invalid value for ALERTS_ROUTE: %q", val)
+		}
+		cfg.AlertsRoute = val
+	}
+
+	// Set optional well-known documents.  Contacts of security.txt are
+	// mailto:, tel: or https: URIs.
+	if val := os.Getenv("SECURITY_CONTACTS"); val != "" {
+		for _, field := range strings.Split(val, ",") {
+			contact := strings.TrimSpace(field)
+			u, err := url.Parse(contact)
+			if err != nil || !((u.Scheme == "mailto" || u.Scheme == "tel") && u.Opaque != "" ||
+				u.Scheme == "https" && u.Host != "") {
+				return nil, fmt.Errorf("invalid contact in SECURITY_CONTACTS: %q", field)
+			}
+			cfg.SecurityContacts = append(cfg.SecurityContacts, contact)
+		}
+	}
+	if val := os.Getenv("SECURITY_EXPIRES"); val != "" {
+		expires, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time for SECURITY_EXPIRES: %q", val)
+		}
+		cfg.SecurityExpires = expires
+	}
+	urlVars := map[string]*string{
+		"SECURITY_POLICY":     &cfg.SecurityPolicy,
+		"CHANGE_PASSWORD_URL": &cfg.ChangePasswordURL,
+	}
+	for name, ptr := range urlVars {
+		if val := os.Getenv(name); val != "" {
+			u, err := url.Parse(val)
+			if err != nil || u.Scheme != "https" || u.Host == "" {
+				return nil, fmt.Errorf("invalid URL for %s: %q", name, val)
+			}
+			*ptr = val
+		}
+	}
+	cfg.WellKnownDir = os.Getenv("WELL_KNOWN_DIR")
+
+	// Set optional canonical path policy
+	cfg.TrailingSlash = middleware.TrailingSlashStrip
+	if val := os.Getenv("TRAILING_SLASH"); val != "" {
+		switch val {
+		case middleware.TrailingSlashIgnore, middleware.TrailingSlashStrip, middleware.TrailingSlashAdd:
+			cfg.TrailingSlash = val
+		default:
+			return nil, fmt.Errorf("invalid value for TRAILING_SLASH: %q", val)
+		}
+	}
+
+	// Set optional language toggle of untranslated pages
+	cfg.MissingTranslation = models.MissingTranslationHide
+	if val := os.Getenv("MISSING_TRANSLATION"); val != "" {
+		switch val {
+		case models.MissingTranslationHide, models.MissingTranslationHome:
+			cfg.MissingTranslation = val
+		default:
+			return nil, fmt.Errorf("invalid value for MISSING_TRANSLATION: %q", val)
+		}
+	}
+
+	// Set optional target of links that leave the site
+	cfg.ExternalLinkTarget = transform.ExternalTargetKeep
+	if val := os.Getenv("EXTERNAL_LINK_TARGET"); val != "" {
+		switch val {
+		case transform.ExternalTargetKeep, transform.ExternalTargetNew, transform.ExternalTargetSame:
+			cfg.ExternalLinkTarget = val
+		default:
+			return nil, fmt.Errorf("invalid value for EXTERNAL_LINK_TARGET: %q", val)
+		}
+	}
+
+	// Set optional Content-Security-Policy, which defaults to a strict policy
+	cfg.CSPPolicy = os.Getenv("CSP_POLICY")
+	if cfg.CSPPolicy == "" {
+		cfg.CSPPolicy = middleware.DefaultCSP
+	}
+	reportVars := map[string]*string{
+		"CSP_REPORT_URI": &cfg.CSPReportURI,
+		"CSP_REPORT_TO":  &cfg.CSPReportTo,
+	}
+	for name, ptr := range reportVars {
+		if val := os.Getenv(name); val != "" {
+			u, err := url.Parse(val)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.ContainsAny(val, ";,\" \n") {
+				return nil, fmt.Errorf("invalid URL for %s: %q", name, val)
+			}
+			*ptr = val
+		}
+	}
+
+	// Set optional origins to preconnect to
+	if val := os.Getenv("PRECONNECT_ORIGINS"); val != "" {
+		for _, field := range strings.Split(val, ",") {
+			origin := strings.TrimSpace(field)
+			u, err := url.Parse(origin)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || strings.ContainsAny(origin, "<>;\" ") {
+				return nil, fmt.Errorf("invalid origin in PRECONNECT_ORIGINS: %q", field)
+			}
+			cfg.PreconnectOrigins = append(cfg.PreconnectOrigins, u.Scheme+"://"+u.Host)
+		}
+	}
+
+	// Set optional custom field that keeps a page out of search engines
+	cfg.NoIndexField = "noindex"
+	if val := os.Getenv("NOINDEX_FIELD"); val != "" {
+		if !fieldPath.MatchString(val) {
+			return nil, fmt.Errorf("invalid value for NOINDEX_FIELD: %q", val)
+		}
+		cfg.NoIndexField = val
+	}
+
+	// Set optional markup removed from content, which replaces the default
+	// WordPress emoji and block library scripts and styles
+	cfg.ContentRemove = transform.DefaultCruft
+	if val := os.Getenv("CONTENT_REMOVE_SELECTORS"); val != "" {
+		cfg.ContentRemove = nil
+		for _, field := range strings.Split(val, ",") {
+			if _, err := transform.ParseSelector(field); err != nil {
+				return nil, fmt.Errorf("invalid selector in CONTENT_REMOVE_SELECTORS: %q", field)
+			}
+			cfg.ContentRemove = append(cfg.ContentRemove, strings.TrimSpace(field))
+		}
+	}
+
+	// Set optional hosts content may embed iframes from
+	if val := os.Getenv("CONTENT_EMBED_HOSTS"); val != "" {
+		for _, field := range strings.Split(val, ",") {
+			host := strings.TrimSpace(field)
+			if host == "" || strings.ContainsAny(host, "/:") {
+				return nil, fmt.Errorf("invalid host in CONTENT_EMBED_HOSTS: %q", field)
+			}
+			cfg.EmbedHosts = append(cfg.EmbedHosts, host)
+		}
+	}
+
+	// Set optional embed modes, which default to click-to-load facades
+	cfg.EmbedDefaultMode = embeds.ModeFacade
+	if val := os.Getenv("EMBED_DEFAULT_MODE"); val != "" {
+		if !embeds.ValidMode(val) {
+			return nil, fmt.Errorf("invalid value for EMBED_DEFAULT_MODE: %q", val)
+		}
+		cfg.EmbedDefaultMode = val
+	}
+	if val := os.Getenv("EMBED_PROVIDERS"); val != "" {
+		cfg.EmbedModes = make(map[string]string)
+		for _, field := range strings.Split(val, ",") {
+			name, mode, _ := strings.Cut(strings.TrimSpace(field), ":")
+			if _, ok := embeds.LookupProvider(name); !ok || !embeds.ValidMode(mode) {
+				return nil, fmt.Errorf("invalid provider in EMBED_PROVIDERS: %q", field)
+			}
+			cfg.EmbedModes[name] = mode
+		}
+	}
+
+	// Set optional image widths
+	if val := os.Getenv("IMAGE_WIDTHS"); val != "" {
+		for _, field := range strings.Split(val, ",") {
+			width, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || width <= 0 || width > 4096 {
+				return nil, fmt.Errorf("invalid width in IMAGE_WIDTHS: %q", field)
+			}
+			cfg.ImageWidths = append(cfg.ImageWidths, width)
+		}
+	}
+
+	// Set optional template theme
+	cfg.Theme = theme.Default
+	if val := os.Getenv("THEME"); val != "" {
+		if !themeName.MatchString(val) {
+			return nil, fmt.Errorf("invalid value for THEME: %q", val)
+		}
+		cfg.Theme = val
+	}
+
+	// Set optional assets loaded on every page, and the analytics provider
+	// and ID, which their URLs may include
+	cfg.AnalyticsProvider = os.Getenv("ANALYTICS_PROVIDER")
+	pattern := analyticsID
+	if cfg.AnalyticsProvider != "" {
+		provider, ok := analytics.LookupProvider(cfg.AnalyticsProvider)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for ANALYTICS_PROVIDER: %q", cfg.AnalyticsProvider)
+		}
+		if os.Getenv("ANALYTICS_ID") == "" {
+			return nil, fmt.Errorf("ANALYTICS_ID is required when ANALYTICS_PROVIDER is %s", cfg.AnalyticsProvider)
+		}
+		pattern = provider.ID
+	}
+	if val := os.Getenv("ANALYTICS_ID"); val != "" {
+		if !pattern.MatchString(val) {
+			return nil, fmt.Errorf("invalid value for ANALYTICS_ID: %q", val)
+		}
+		cfg.AnalyticsID = val
+	}
+	cfg.AnalyticsConsentCookie = "analytics_consent"
+	if val := os.Getenv("ANALYTICS_CONSENT_COOKIE"); val != "" {
+		if !cookieName.MatchString(val) {
+			return nil, fmt.Errorf("invalid value for ANALYTICS_CONSENT_COOKIE: %q", val)
+		}
+		cfg.AnalyticsConsentCookie = val
+	}
+	cfg.ColorSchemeCookie = "color_scheme"
+	if val := os.Getenv("COLOR_SCHEME_COOKIE"); val != "" {
+		if !cookieName.MatchString(val) {
+			return nil, fmt.Errorf("invalid value for COLOR_SCHEME_COOKIE: %q", val)
+		}
+		cfg.ColorSchemeCookie = val
+	}
+	assetVars := map[string]*[]string{
+		"ASSET_STYLESHEETS":          &cfg.AssetStylesheets,
+		"ASSET_SCRIPTS":              &cfg.AssetScripts,
+		"ASSET_STYLESHEETS_DARK":     &cfg.AssetStylesheetsDark,
+		"ASSET_STYLESHEETS_CONTRAST": &cfg.AssetStylesheetsContrast,
+	}
+	for name, ptr := range assetVars {
+		if val := os.Getenv(name); val != "" {
+			for _, field := range strings.Split(val, ",") {
+				asset := strings.TrimSpace(field)
+				if cfg.AnalyticsID != "" {
+					asset = strings.ReplaceAll(asset, "{analytics_id}", cfg.AnalyticsID)
+				}
+				if !validAsset(asset) {
+					return nil, fmt.Errorf("invalid URL in %s: %q", name, field)
+				}
+				*ptr = append(*ptr, asset)
+			}
+		}
+	}
+
+	// Set optional details of the organization publishing the site
+	organizationVars := map[string]*string{
+		"ORGANIZATION_URL":  &cfg.OrganizationURL,
+		"ORGANIZATION_LOGO": &cfg.OrganizationLogo,
+	}
+	for name, ptr := range organizationVars {
+		if val := os.Getenv(name); val != "" {
+			if !validAbsoluteURL(val) {
+				return nil, fmt.Errorf("invalid value for %s: %q", name, val)
+			}
+			*ptr = val
+		}
+	}
+	if val := os.Getenv("ORGANIZATION_SAME_AS"); val != "" {
+		for _, field := range strings.Split(val, ",") {
+			profile := strings.TrimSpace(field)
+			if !validAbsoluteURL(profile) {
+				return nil, fmt.Errorf("invalid URL in ORGANIZATION_SAME_AS: %q", field)
+			}
+			cfg.OrganizationSameAs = append(cfg.OrganizationSameAs, profile)
+		}
+	}
+
+	// Set optional site icon
+	if val := os.Getenv("SITE_ICON"); val != "" {
+		if val != SiteIconWordPress {
+			if info, err := os.Stat(val); err != nil || !info.Mode().IsRegular() {
+				return nil, fmt.Errorf("invalid file for SITE_ICON: %q", val)
+			}
+		}
+		cfg.SiteIcon = val
+	}
+
+	// Set optional custom post types, as name:route pairs such as
+	// events:wp/v2/events
+	if val := os.Getenv("CUSTOM_TYPES"); val != "" {
+		for _, field := range strings.Split(val, ",") {
+			name, route, _ := strings.Cut(strings.TrimSpace(field), ":")
+			if !postTypeName.MatchString(name) || !postTypeRoute.MatchString(route) ||
+				reservedPaths[name] || containsCode(cfg.Languages.Codes(), name) || containsPostType(cfg.CustomTypes, name) {
+				return nil, fmt.Errorf("invalid post type in CUSTOM_TYPES: %q", field)
+			}
+			cfg.CustomTypes = append(cfg.CustomTypes, models.PostType{Name: name, Route: route})
+		}
+	}
+
+	// Set optional forms, as name:id pairs such as contact:12, which need
+	// the route of the forms plugin and the token secret
+	if val := os.Getenv("FORMS"); val != "" {
+		for _, field := range strings.Split(val, ",") {
+			name, id, _ := strings.Cut(strings.TrimSpace(field), ":")
+			if !postTypeName.MatchString(name) || !formID.MatchString(id) || containsForm(cfg.Forms, name) {
+				return nil, fmt.Errorf("invalid form in FORMS: %q", field)
+			}
+			cfg.Forms = append(cfg.Forms, models.Form{Name: name, ID: id})
+		}
+		cfg.FormRoute = os.Getenv("FORM_ROUTE")
+		if !postTypeRoute.MatchString(strings.ReplaceAll(cfg.FormRoute, "{id}", "id")) || !strings.Contains(cfg.FormRoute, "{id}") {
+			return nil, fmt.Errorf("invalid value for FORM_ROUTE: %q", cfg.FormRoute)
+		}
+		if cfg.AuthSecret == "" {
+			return nil, fmt.Errorf("FORMS requires AUTH_SECRET")
+		}
+		if cfg.FormTokenTTL == 0 {
+			return nil, fmt.Errorf("invalid duration for FORM_TOKEN_TTL: %q", os.Getenv("FORM_TOKEN_TTL"))
+		}
+	}
+
+	// Set optional experiments, as name:variant|variant entries such as
+	// hero:control|short
+	if val := os.Getenv("EXPERIMENTS"); val != "" {
+		for _, field := range strings.Split(val, ",") {
+			name, variants, _ := strings.Cut(strings.TrimSpace(field), ":")
+			experiment := middleware.Experiment{Name: name, Variants: strings.Split(variants, "|")}
+			if !postTypeName.MatchString(name) || !validVariants(experiment.Variants) || containsExperiment(cfg.Experiments, name) {
+				return nil, fmt.Errorf("invalid experiment in EXPERIMENTS: %q", field)
+			}
+			cfg.Experiments = append(cfg.Experiments, experiment)
+		}
+	}
+
+	// Set optional request filtering
+	cidrVars := map[string]*[]string{
+		"FILTER_ALLOW_CIDRS": &cfg.FilterAllowCIDRs,
+		"FILTER_DENY_CIDRS":  &cfg.FilterDenyCIDRs,
+	}
+	for name, ptr := range cidrVars {
+		if val := os.Getenv(name); val != "" {
+			for _, field := range strings.Split(val, ",") {
+				cidr := strings.TrimSpace(field)
+				if _, err := netip.ParsePrefix(cidr); err != nil {
+					if _, err := netip.ParseAddr(cidr); err != nil {
+						return nil, fmt.Errorf("invalid CIDR in %s: %q", name, field)
+					}
+				}
+				*ptr = append(*ptr, cidr)
+			}
+		}
+	}
+	patternVars := map[string]*[]string{
+		"FILTER_DENY_USER_AGENTS": &cfg.FilterDenyUserAgents,
+		"FILTER_DENY_PATHS":       &cfg.FilterDenyPaths,
+	}
+	for name, ptr := range patternVars {
+		if val := os.Getenv(name); val != "" {
+			if _, err := regexp.Compile(val); err != nil {
+				return nil, fmt.Errorf("invalid pattern for %s: %q", name, val)
+			}
+			*ptr = []string{val}
+		}
+	}
+	if val := os.Getenv("FILTER_RULES_URL"); val != "" {
+		u, err := url.Parse(val)
+		if err != nil || (u.Scheme != "s3" && u.Scheme != "https") || u.Host == "" || strings.TrimPrefix(u.Path, "/") == "" {
+			return nil, fmt.Errorf("invalid URL for FILTER_RULES_URL: %q", val)
+		}
+		cfg.FilterRulesURL = val
+	}
+
+	// Set optional static export target
+	if val := os.Getenv("EXPORT_TARGET"); val != "" {
+		if u, err := url.Parse(val); err == nil && u.Scheme == "s3" && u.Host == "" {
+			return nil, fmt.Errorf("invalid URL for EXPORT_TARGET: %q", val)
+		}
+		cfg.ExportTarget = val
+	}
+	if cfg.FailoverEnabled && cfg.ExportTarget == "" {
+		return nil, fmt.Errorf("EXPORT_TARGET is required when FAILOVER_ENABLED is true")
+	}
+
+	// Set optional feature flag source
+	if val := os.Getenv("FLAGS_URL"); val != "" {
+		u, err := url.Parse(val)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid URL for FLAGS_URL: %q", val)
+		}
+		cfg.FlagsURL = val
+	}
+
+	// Set optional tracing, using the standard OpenTelemetry variables that
+	// the OTLP exporter also reads
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"} {
+		if val := os.Getenv(name); val != "" {
+			u, err := url.Parse(val)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("invalid URL for %s: %q", name, val)
+			}
+			if cfg.TracingEndpoint == "" {
+				cfg.TracingEndpoint = val
+			}
+		}
+	}
+	cfg.TracingServiceName = "wordpress-go-proxy"
+	if val := os.Getenv("OTEL_SERVICE_NAME"); val != "" {
+		cfg.TracingServiceName = val
+	}
+	cfg.TracingSampleRatio = 1
+	if val := os.Getenv("TRACING_SAMPLE_RATIO"); val != "" {
+		ratio, err := strconv.ParseFloat(val, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("invalid number for TRACING_SAMPLE_RATIO: %q", val)
+		}
+		cfg.TracingSampleRatio = ratio
+	}
+
+	cfg.XRayDaemonAddress = "127.0.0.1:2000"
+	if val := os.Getenv("AWS_XRAY_DAEMON_ADDRESS"); val != "" {
+		if _, _, err := net.SplitHostPort(val); err != nil {
+			return nil, fmt.Errorf("invalid address for AWS_XRAY_DAEMON_ADDRESS: %q", val)
+		}
+		cfg.XRayDaemonAddress = val
+	}
+
+	// Record secrets given as Secrets Manager or SSM Parameter Store ARNs,
+	// which are resolved at startup instead of being set in plain text
+	for name, ptr := range cfg.secretVars() {
+		if secrets.IsReference(*ptr) {
+			if cfg.SecretRefs == nil {
+				cfg.SecretRefs = make(map[string]string)
+			}
+			cfg.SecretRefs[name] = *ptr
+		}
+	}
 
 	return cfg, nil
 }
+
+// languageCode matches a language code such as "en" or "pt-br".
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+// redactedFields are the settings holding secrets, which String does not
+// print.
+var redactedFields = map[string]bool{
+	"WordPressPassword": true,
+	"AuthSecret":        true,
+	"SiteAuthUsers":     true,
+	"WordPressHeaders":  true,
+	"WordPressTLSKey":   true,
+}
+
+// String returns the settings for logging, with secrets redacted and the
+// password of the Redis URL removed.
+func (c *Config) String() string {
+	v := reflect.ValueOf(*c)
+	fields := make([]string, 0, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		value := v.Field(i).Interface()
+		switch {
+		case redactedFields[name]:
+			if v.Field(i).String() != "" {
+				value = "[redacted]"
+			}
+		case name == "CacheRedisURL":
+			if u, err := url.Parse(c.CacheRedisURL); err == nil {
+				value = u.Redacted()
+			}
+		}
+		fields = append(fields, fmt.Sprintf("%s=%v", name, value))
+	}
+	return strings.Join(fields, " ")
+}
+
+// secretVars returns the variables that may be given as secret references,
+// keyed by name.
+func (c *Config) secretVars() map[string]*string {
+	return map[string]*string{
+		"WORDPRESS_USERNAME": &c.WordPressUsername,
+		"WORDPRESS_PASSWORD": &c.WordPressPassword,
+		"AUTH_SECRET":        &c.AuthSecret,
+		"CACHE_REDIS_URL":    &c.CacheRedisURL,
+		"SITE_AUTH_USERS":    &c.SiteAuthUsers,
+		"WORDPRESS_HEADERS":  &c.WordPressHeaders,
+		"WORDPRESS_TLS_CERT": &c.WordPressTLSCert,
+		"WORDPRESS_TLS_KEY":  &c.WordPressTLSKey,
+	}
+}
+
+// ResolveSecrets sets the variables given as secret references to their
+// values, looked up with resolve.  It can be called again to pick up
+// rotated secrets.
+func (c *Config) ResolveSecrets(ctx context.Context, resolve func(ctx context.Context, ref string) (string, error)) error {
+	vars := c.secretVars()
+	names := make([]string, 0, len(c.SecretRefs))
+	for name := range c.SecretRefs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		val, err := resolve(ctx, c.SecretRefs[name])
+		if err != nil {
+			return fmt.Errorf("error resolving %s: %w", name, err)
+		}
+		if val == "" {
+			return fmt.Errorf("empty secret for %s", name)
+		}
+		*vars[name] = val
+	}
+
+	if _, ok := c.SecretRefs["AUTH_SECRET"]; ok {
+		if err := validateAuthSecret(c.AuthSecret); err != nil {
+			return err
+		}
+	}
+	if _, ok := c.SecretRefs["WORDPRESS_HEADERS"]; ok {
+		if _, err := api.ParseHeaders(c.WordPressHeaders); err != nil {
+			return fmt.Errorf("invalid value for WORDPRESS_HEADERS from %s: %w", c.SecretRefs["WORDPRESS_HEADERS"], err)
+		}
+	}
+	_, certRef := c.SecretRefs["WORDPRESS_TLS_CERT"]
+	_, keyRef := c.SecretRefs["WORDPRESS_TLS_KEY"]
+	if certRef || keyRef {
+		if _, err := api.LoadKeyPair(c.WordPressTLSCert, c.WordPressTLSKey); err != nil {
+			return fmt.Errorf("invalid value for WORDPRESS_TLS_CERT or WORDPRESS_TLS_KEY: %w", err)
+		}
+	}
+	if _, ok := c.SecretRefs["CACHE_REDIS_URL"]; ok {
+		if err := validateRedisURL(c.CacheRedisURL); err != nil {
+			return fmt.Errorf("invalid URL for CACHE_REDIS_URL from %s", c.SecretRefs["CACHE_REDIS_URL"])
+		}
+	}
+	return nil
+}
+
+// validateAuthSecret checks that a token secret is long enough.
+func validateAuthSecret(secret string) error {
+	if secret != "" && len(secret) < 32 {
+		return fmt.Errorf("AUTH_SECRET must be at least 32 characters")
+	}
+	return nil
+}
+
+// validateRedisURL checks that a Redis URL has a redis or rediss scheme and
+// a host.
+func validateRedisURL(redisURL string) error {
+	u, err := url.Parse(redisURL)
+	if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+		return fmt.Errorf("invalid URL for CACHE_REDIS_URL: %q", redisURL)
+	}
+	return nil
+}
+
+// SyncHomeSlugs sets the home slug of each language with a home page ID to
+// the slug of that page, looked up with fetch.
+func (c *Config) SyncHomeSlugs(ctx context.Context, fetch func(ctx context.Context, id int) (string, error)) error {
+	for i := range c.Languages {
+		lang := &c.Languages[i]
+		if lang.HomePageID == 0 {
+			continue
+		}
+		slug, err := fetch(ctx, lang.HomePageID)
+		if err != nil {
+			return fmt.Errorf("error fetching the page of HOME_PAGE_ID_%s: %w", languageSuffix(lang.Code), err)
+		}
+		lang.HomeSlug = slug
+	}
+	return nil
+}
+
+// NeedsSiteSettings reports whether a site name or tagline, or the
+// timezone, is not set and should be synced from WordPress.
+func (c *Config) NeedsSiteSettings() bool {
+	if c.Timezone == nil {
+		return true
+	}
+	for _, lang := range c.Languages {
+		if lang.SiteName == "" || lang.Tagline == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncSiteSettings sets the site names, taglines and timezone that are not
+// set from the WordPress site settings of each language, looked up with
+// fetch.  Settings that are set override those of WordPress.  An error is
+// returned if a site name is still not set, since pages cannot be rendered
+// without one; a tagline or timezone that cannot be fetched is left empty.
+func (c *Config) SyncSiteSettings(ctx context.Context, fetch func(ctx context.Context, lang string) (*models.SiteSettings, error)) error {
+	var missing []string
+	for i := range c.Languages {
+		lang := &c.Languages[i]
+		if lang.SiteName != "" && lang.Tagline != "" && c.Timezone != nil {
+			continue
+		}
+
+		settings, err := fetch(ctx, lang.Code)
+		if err != nil {
+			if lang.SiteName == "" {
+				return fmt.Errorf("error fetching site settings for SITE_NAME_%s: %w", languageSuffix(lang.Code), err)
+			}
+			continue
+		}
+		if lang.SiteName == "" {
+			lang.SiteName = settings.Name
+		}
+		if lang.Tagline == "" {
+			lang.Tagline = settings.Description
+		}
+		if c.Timezone == nil {
+			c.Timezone = settings.Location()
+		}
+		if lang.SiteName == "" {
+			missing = append(missing, "SITE_NAME_"+languageSuffix(lang.Code))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing site names in WordPress and environment variables: %v", missing)
+	}
+
+	// Keep the English and French settings for existing callers
+	if lang, ok := c.Languages.Get("en"); ok {
+		c.SiteNameEn = lang.SiteName
+	}
+	if lang, ok := c.Languages.Get("fr"); ok {
+		c.SiteNameFr = lang.SiteName
+	}
+	return nil
+}
+
+// FeatureFlags returns the configured feature toggles, keyed by the
+// feature names of the flags package.
+func (c *Config) FeatureFlags() map[string]bool {
+	return map[string]bool{
+		flags.Search:  c.EnableSearch,
+		flags.Feeds:   c.EnableFeeds,
+		flags.PageAPI: c.EnablePageAPI,
+		flags.GraphQL: c.EnableGraphQL,
+	}
+}
+
+// AuthMethod returns the SiteAuth constant of the credentials visitors
+// sign in with: that of SITE_AUTH if set, otherwise basic if there are
+// users, or alb if there is a load balancer.  It is empty if no
+// credentials are set.
+func (c *Config) AuthMethod() string {
+	switch {
+	case c.SiteAuth != "":
+		return c.SiteAuth
+	case c.SiteAuthUsers != "":
+		return SiteAuthBasic
+	case c.SiteAuthALBARN != "":
+		return SiteAuthALB
+	}
+	return ""
+}
+
+// FilterEnabled reports whether any request filtering rules are set.
+func (c *Config) FilterEnabled() bool {
+	return len(c.FilterAllowCIDRs) > 0 || len(c.FilterDenyCIDRs) > 0 ||
+		len(c.FilterDenyUserAgents) > 0 || len(c.FilterDenyPaths) > 0 || c.FilterRulesURL != ""
+}
+
+// themeName matches a theme directory name such as "default" or "gc-dark".
+var themeName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// analyticsID matches the ID of an analytics property such as
+// "G-ABC123DEF4".
+var analyticsID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// fieldPath matches the path of a custom field such as "seo.noindex".
+var fieldPath = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// cookieName matches the name of a cookie such as "analytics_consent".
+var cookieName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// validAsset reports whether an asset URL is an absolute http(s) URL or a
+// path on the site.
+func validAsset(asset string) bool {
+	if strings.Contains(asset, "{analytics_id}") || strings.ContainsAny(asset, "\"<> ") {
+		return false
+	}
+	u, err := url.Parse(asset)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" {
+		return strings.HasPrefix(u.Path, "/")
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// validAbsoluteURL reports whether a URL is an absolute http(s) URL.
+func validAbsoluteURL(s string) bool {
+	return validAsset(s) && !strings.HasPrefix(s, "/")
+}
+
+// postTypeName matches a post type path such as "events".
+var postTypeName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// formID matches the ID of a form in a forms plugin.
+var formID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// postTypeRoute matches a REST route such as "wp/v2/events".
+var postTypeRoute = regexp.MustCompile(`^[A-Za-z0-9_-]+(/[A-Za-z0-9_-]+)+$`)
+
+// reservedPaths are the first path segments served by other handlers,
+// which post types cannot be served under.
+var reservedPaths = map[string]bool{
+	"api":        true,
+	"auth":       true,
+	"comments":   true,
+	"forms":      true,
+	"graphql":    true,
+	"healthz":    true,
+	"img":        true,
+	"media":      true,
+	"readyz":     true,
+	"search":     true,
+	"static":     true,
+	"wp-content": true,
+}
+
+// containsExperiment reports whether an experiment name is in the list.
+func containsExperiment(experiments []middleware.Experiment, name string) bool {
+	for _, e := range experiments {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validVariants reports whether an experiment has at least two distinct
+// variants with valid names.
+func validVariants(variants []string) bool {
+	if len(variants) < 2 {
+		return false
+	}
+	for i, v := range variants {
+		if !postTypeName.MatchString(v) || containsCode(variants[:i], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsForm reports whether a form name is in the list.
+func containsForm(forms []models.Form, name string) bool {
+	for _, f := range forms {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPostType reports whether a post type name is in the list.
+func containsPostType(types []models.PostType, name string) bool {
+	for _, t := range types {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// languageSuffix returns the environment variable suffix of a language,
+// for example "PT_BR" for "pt-br".
+func languageSuffix(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(code, "-", "_"))
+}
+
+// containsCode reports whether a language code is in the list.
+func containsCode(codes []string, code string) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// loadCachePolicy reads the cache policy for a content type from the
+// <PREFIX>_CACHE_TTL and <PREFIX>_CACHE_STALE_WHILE_REVALIDATE variables.
+// Caching is disabled unless a TTL is set.
+func loadCachePolicy(prefix string) (cache.Policy, error) {
+	policy := cache.Policy{}
+
+	ttlName := prefix + "_CACHE_TTL"
+	if val := os.Getenv(ttlName); val != "" {
+		ttl, err := time.ParseDuration(val)
+		if err != nil || ttl < 0 {
+			return policy, fmt.Errorf("invalid duration for %s: %q", ttlName, val)
+		}
+		policy.TTL = ttl
+	}
+
+	swrName := prefix + "_CACHE_STALE_WHILE_REVALIDATE"
+	if val := os.Getenv(swrName); val != "" {
+		swr, err := strconv.ParseBool(val)
+		if err != nil {
+			return policy, fmt.Errorf("invalid boolean for %s: %q", swrName, val)
+		}
+		policy.StaleWhileRevalidate = swr
+	}
+
+	return policy, nil
+}
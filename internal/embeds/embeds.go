@@ -0,0 +1,335 @@
+package embeds
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"wordpress-go-proxy/internal/transform"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Modes an embed can be rendered in.  Facades show a placeholder that
+// loads the embed when clicked, so no request reaches the provider until
+// the visitor asks for it.
+const (
+	ModeFacade = "facade"
+	ModeLink   = "link"
+	ModeAllow  = "allow"
+	ModeRemove = "remove"
+)
+
+// Provider is a site whose content WordPress embeds in an iframe.
+type Provider struct {
+	// Name identifies the provider in config, such as "youtube".
+	Name string
+
+	// Title is the name of the provider shown to visitors.
+	Title string
+
+	// Hosts are the hosts of the provider's embed URLs.
+	Hosts []string
+
+	// Private rewrites an embed URL to the provider's privacy-enhanced
+	// player.  If nil, the URL is used as is.
+	Private func(src *url.URL) *url.URL
+
+	// Link returns the URL of the embedded content on the provider's site.
+	// If nil, the embed URL is linked.
+	Link func(src *url.URL) string
+
+	// Autoplay starts the embed when a facade is clicked, so that the
+	// visitor does not need to click twice.
+	Autoplay bool
+}
+
+// Providers are the embed providers the proxy knows about.  Iframes from
+// other hosts are rendered with the default mode.
+var Providers = []Provider{
+	{
+		Name:     "youtube",
+		Title:    "YouTube",
+		Hosts:    []string{"www.youtube.com", "youtube.com", "www.youtube-nocookie.com", "youtube-nocookie.com"},
+		Private:  youTubePrivate,
+		Link:     youTubeLink,
+		Autoplay: true,
+	},
+	{
+		Name:     "vimeo",
+		Title:    "Vimeo",
+		Hosts:    []string{"player.vimeo.com"},
+		Private:  vimeoPrivate,
+		Link:     vimeoLink,
+		Autoplay: true,
+	},
+	{
+		Name:  "maps",
+		Title: "Google Maps",
+		Hosts: []string{"www.google.com", "maps.google.com"},
+	},
+	{
+		Name:  "spotify",
+		Title: "Spotify",
+		Hosts: []string{"open.spotify.com"},
+	},
+	{
+		Name:  "soundcloud",
+		Title: "SoundCloud",
+		Hosts: []string{"w.soundcloud.com"},
+	},
+}
+
+// LookupProvider returns the provider with the given name.
+func LookupProvider(name string) (Provider, bool) {
+	for _, provider := range Providers {
+		if provider.Name == name {
+			return provider, true
+		}
+	}
+	return Provider{}, false
+}
+
+// ValidMode reports whether mode is an embed mode.
+func ValidMode(mode string) bool {
+	switch mode {
+	case ModeFacade, ModeLink, ModeAllow, ModeRemove:
+		return true
+	}
+	return false
+}
+
+// Rewriter replaces the iframes of embedded content according to the mode
+// of their provider.
+type Rewriter struct {
+	// Modes holds the mode of each provider keyed by name.  Providers
+	// without a mode use the default mode.
+	Modes map[string]string
+
+	// DefaultMode is the mode of providers without one, and of iframes
+	// from unknown hosts.
+	DefaultMode string
+}
+
+// New creates a rewriter with the given provider modes.
+func New(modes map[string]string, defaultMode string) *Rewriter {
+	return &Rewriter{Modes: modes, DefaultMode: defaultMode}
+}
+
+// Hosts returns the hosts of the providers that are not removed, which
+// content sanitization must allow iframes from.
+func (r *Rewriter) Hosts() []string {
+	var hosts []string
+	for _, provider := range Providers {
+		if r.mode(provider.Name) != ModeRemove {
+			hosts = append(hosts, provider.Hosts...)
+		}
+	}
+	return hosts
+}
+
+// mode returns the mode of a provider.
+func (r *Rewriter) mode(name string) string {
+	if mode, ok := r.Modes[name]; ok {
+		return mode
+	}
+	return r.DefaultMode
+}
+
+// Transform implements transform.Transformer, replacing the iframes of
+// content with facades, links or the provider's privacy-enhanced player.
+// Text is in the language of the page.  It runs first in the content
+// pipeline, so that other transformers see the replacements.
+func (r *Rewriter) Transform(doc *html.Node, page transform.Page) {
+	for _, iframe := range transform.Elements(doc, atom.Iframe) {
+		r.rewriteIframe(iframe, page.Lang)
+	}
+}
+
+// rewriteIframe replaces an iframe according to the mode of its provider.
+func (r *Rewriter) rewriteIframe(n *html.Node, lang string) {
+	src, err := url.Parse(transform.Attr(n, "src"))
+	if err != nil || src.Scheme != "https" || src.Host == "" {
+		n.Parent.RemoveChild(n)
+		return
+	}
+
+	provider, ok := providerForHost(src.Hostname())
+	mode := r.DefaultMode
+	if ok {
+		mode = r.mode(provider.Name)
+	} else {
+		provider = Provider{Name: src.Hostname(), Title: src.Hostname()}
+	}
+
+	embedSrc := src
+	if provider.Private != nil {
+		embedSrc = provider.Private(src)
+	}
+	link := embedSrc.String()
+	if provider.Link != nil {
+		link = provider.Link(src)
+	}
+	title := transform.Attr(n, "title")
+
+	switch mode {
+	case ModeAllow:
+		transform.SetAttr(n, "src", embedSrc.String())
+		if transform.Attr(n, "loading") == "" {
+			transform.SetAttr(n, "loading", "lazy")
+		}
+	case ModeLink:
+		replace(n, linkNode(link, linkText(title, provider, lang)))
+	case ModeFacade:
+		if provider.Autoplay {
+			query := embedSrc.Query()
+			query.Set("autoplay", "1")
+			embedSrc.RawQuery = query.Encode()
+		}
+		replace(n, facadeNode(n, embedSrc.String(), link, title, provider, lang))
+	default:
+		n.Parent.RemoveChild(n)
+	}
+}
+
+// providerForHost returns the provider serving embeds from a host.
+func providerForHost(host string) (Provider, bool) {
+	host = strings.ToLower(host)
+	for _, provider := range Providers {
+		for _, h := range provider.Hosts {
+			if h == host {
+				return provider, true
+			}
+		}
+	}
+	return Provider{}, false
+}
+
+// facadeNode creates the click-to-load placeholder of an iframe.  The
+// embed is loaded by static/js/embeds.js from the data attributes.
+func facadeNode(iframe *html.Node, src string, link string, title string, provider Provider, lang string) *html.Node {
+	facade := element(atom.Div, "class", "embed-facade", "data-embed-src", src)
+	for _, key := range []string{"title", "width", "height", "allow"} {
+		if val := transform.Attr(iframe, key); val != "" {
+			transform.SetAttr(facade, "data-embed-"+key, val)
+		}
+	}
+
+	notice := element(atom.P, "class", "embed-facade__notice")
+	notice.AppendChild(text(fmt.Sprintf(translate(lang, "notice"), provider.Title)))
+	facade.AppendChild(notice)
+
+	button := element(atom.Button, "type", "button", "class", "embed-facade__load")
+	button.AppendChild(text(loadText(title, provider, lang)))
+	facade.AppendChild(button)
+
+	facade.AppendChild(text(" "))
+	facade.AppendChild(linkNode(link, linkText(title, provider, lang)))
+	return facade
+}
+
+// linkNode creates a link to embedded content on the provider's site.
+func linkNode(href string, label string) *html.Node {
+	a := element(atom.A, "href", href, "class", "embed-link")
+	a.AppendChild(text(label))
+	return a
+}
+
+// loadText returns the label of the button that loads an embed.
+func loadText(title string, provider Provider, lang string) string {
+	if title != "" {
+		return fmt.Sprintf(translate(lang, "load_title"), title)
+	}
+	return fmt.Sprintf(translate(lang, "load"), provider.Title)
+}
+
+// linkText returns the label of a link to embedded content.
+func linkText(title string, provider Provider, lang string) string {
+	if title != "" {
+		return fmt.Sprintf(translate(lang, "link_title"), title, provider.Title)
+	}
+	return fmt.Sprintf(translate(lang, "link"), provider.Title)
+}
+
+// messages are the texts of facades and links for each language.
+// Languages without messages use the English ones.
+var messages = map[string]map[string]string{
+	"en": {
+		"notice":     "This content is hosted by %s. Loading it shares information about your visit with them.",
+		"load":       "Load content from %s",
+		"load_title": "Load %s",
+		"link":       "View on %s",
+		"link_title": "%s (%s)",
+	},
+	"fr": {
+		"notice":     "Ce contenu est hébergé par %s. Le charger leur communique des renseignements sur votre visite.",
+		"load":       "Charger le contenu de %s",
+		"load_title": "Charger %s",
+		"link":       "Voir sur %s",
+		"link_title": "%s (%s)",
+	},
+}
+
+// translate returns a message in a language.
+func translate(lang string, key string) string {
+	if msgs, ok := messages[lang]; ok {
+		return msgs[key]
+	}
+	return messages["en"][key]
+}
+
+// youTubePrivate rewrites a YouTube embed URL to the no-cookie player.
+func youTubePrivate(src *url.URL) *url.URL {
+	private := *src
+	private.Host = "www.youtube-nocookie.com"
+	query := private.Query()
+	query.Del("feature")
+	private.RawQuery = query.Encode()
+	return &private
+}
+
+// youTubeLink returns the watch page of a YouTube embed.
+func youTubeLink(src *url.URL) string {
+	if id, ok := strings.CutPrefix(src.Path, "/embed/"); ok && id != "" && !strings.Contains(id, "/") {
+		return "https://www.youtube.com/watch?" + url.Values{"v": {id}}.Encode()
+	}
+	return "https://www.youtube.com/"
+}
+
+// vimeoPrivate asks the Vimeo player not to track the visitor.
+func vimeoPrivate(src *url.URL) *url.URL {
+	private := *src
+	query := private.Query()
+	query.Set("dnt", "1")
+	private.RawQuery = query.Encode()
+	return &private
+}
+
+// vimeoLink returns the page of a Vimeo embed.
+func vimeoLink(src *url.URL) string {
+	if id, ok := strings.CutPrefix(src.Path, "/video/"); ok && id != "" && !strings.Contains(id, "/") {
+		return "https://vimeo.com/" + id
+	}
+	return "https://vimeo.com/"
+}
+
+// element creates an element with the given attribute keys and values.
+func element(a atom.Atom, attrs ...string) *html.Node {
+	n := &html.Node{Type: html.ElementNode, Data: a.String(), DataAtom: a}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		n.Attr = append(n.Attr, html.Attribute{Key: attrs[i], Val: attrs[i+1]})
+	}
+	return n
+}
+
+// text creates a text node.
+func text(data string) *html.Node {
+	return &html.Node{Type: html.TextNode, Data: data}
+}
+
+// replace puts replacement in the place of n.
+func replace(n *html.Node, replacement *html.Node) {
+	n.Parent.InsertBefore(replacement, n)
+	n.Parent.RemoveChild(n)
+}
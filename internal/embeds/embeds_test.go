@@ -0,0 +1,129 @@
+package embeds
+
+import (
+	"strings"
+	"testing"
+
+	"wordpress-go-proxy/internal/transform"
+)
+
+const youTubeEmbed = `<figure class="wp-block-embed is-provider-youtube"><div class="wp-block-embed__wrapper">` +
+	`<iframe title="Budget 2025" width="500" height="281" src="https://www.youtube.com/embed/abc123?feature=oembed" ` +
+	`allow="accelerometer; encrypted-media" allowfullscreen></iframe></div></figure>`
+
+func TestRewrite(t *testing.T) {
+	testCases := []struct {
+		name       string
+		modes      map[string]string
+		content    string
+		lang       string
+		contains   []string
+		notContain []string
+	}{
+		{
+			name:    "YouTube facade",
+			content: youTubeEmbed,
+			lang:    "en",
+			contains: []string{
+				`<figure class="wp-block-embed is-provider-youtube"><div class="wp-block-embed__wrapper"><div class="embed-facade"`,
+				`data-embed-src="https://www.youtube-nocookie.com/embed/abc123?autoplay=1"`,
+				`data-embed-title="Budget 2025"`,
+				`data-embed-width="500"`,
+				`data-embed-allow="accelerometer; encrypted-media"`,
+				`This content is hosted by YouTube.`,
+				`<button type="button" class="embed-facade__load">Load Budget 2025</button>`,
+				`<a href="https://www.youtube.com/watch?v=abc123" class="embed-link">Budget 2025 (YouTube)</a>`,
+			},
+			notContain: []string{"<iframe"},
+		},
+		{
+			name:     "French facade",
+			content:  `<iframe src="https://player.vimeo.com/video/42"></iframe>`,
+			lang:     "fr",
+			contains: []string{"hébergé par Vimeo", "Charger le contenu de Vimeo", `href="https://vimeo.com/42"`, `autoplay=1&amp;dnt=1`},
+		},
+		{
+			name:       "Link mode",
+			modes:      map[string]string{"youtube": ModeLink},
+			content:    youTubeEmbed,
+			lang:       "en",
+			contains:   []string{`<a href="https://www.youtube.com/watch?v=abc123" class="embed-link">Budget 2025 (YouTube)</a>`},
+			notContain: []string{"<iframe", "embed-facade"},
+		},
+		{
+			name:       "Allow mode uses the privacy-enhanced player",
+			modes:      map[string]string{"youtube": ModeAllow},
+			content:    youTubeEmbed,
+			lang:       "en",
+			contains:   []string{`<iframe title="Budget 2025"`, `src="https://www.youtube-nocookie.com/embed/abc123"`, `loading="lazy"`},
+			notContain: []string{"feature=oembed", "embed-facade"},
+		},
+		{
+			name:       "Remove mode",
+			modes:      map[string]string{"youtube": ModeRemove},
+			content:    `<p>Before</p>` + youTubeEmbed + `<p>After</p>`,
+			lang:       "en",
+			contains:   []string{"<p>Before</p>", "<p>After</p>", "wp-block-embed__wrapper"},
+			notContain: []string{"<iframe", "youtube.com"},
+		},
+		{
+			name:     "Unknown host uses the default mode",
+			content:  `<iframe src="https://embed.example.com/widget"></iframe>`,
+			lang:     "en",
+			contains: []string{`data-embed-src="https://embed.example.com/widget"`, "hosted by embed.example.com"},
+		},
+		{
+			name:       "Insecure iframes are removed",
+			content:    `<p>Text</p><iframe src="http://www.youtube.com/embed/abc"></iframe>`,
+			lang:       "en",
+			contains:   []string{"<p>Text</p>"},
+			notContain: []string{"<iframe", "youtube", "embed-facade"},
+		},
+		{
+			name:     "Content without iframes is unchanged",
+			content:  `<p>Text</p>`,
+			lang:     "en",
+			contains: []string{`<p>Text</p>`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := transform.Pipeline{New(tc.modes, ModeFacade)}.Apply(tc.content, transform.Page{Lang: tc.lang})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			for _, expected := range tc.contains {
+				if !strings.Contains(got, expected) {
+					t.Errorf("Expected %q to contain %q", got, expected)
+				}
+			}
+			for _, unexpected := range tc.notContain {
+				if strings.Contains(got, unexpected) {
+					t.Errorf("Expected %q not to contain %q", got, unexpected)
+				}
+			}
+		})
+	}
+}
+
+func TestHosts(t *testing.T) {
+	hosts := New(map[string]string{"youtube": ModeRemove}, ModeFacade).Hosts()
+
+	joined := strings.Join(hosts, " ")
+	if strings.Contains(joined, "youtube") {
+		t.Errorf("Expected removed provider hosts to be excluded, got %v", hosts)
+	}
+	if !strings.Contains(joined, "player.vimeo.com") {
+		t.Errorf("Expected player.vimeo.com, got %v", hosts)
+	}
+}
+
+func TestLookupProvider(t *testing.T) {
+	if provider, ok := LookupProvider("youtube"); !ok || provider.Title != "YouTube" {
+		t.Errorf("Expected YouTube provider, got %+v", provider)
+	}
+	if _, ok := LookupProvider("myspace"); ok {
+		t.Error("Expected unknown provider not to be found")
+	}
+}
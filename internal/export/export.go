@@ -0,0 +1,339 @@
+// Package export writes a static copy of the site, rendered by the proxy's
+// own handlers, to a directory or S3 bucket that the site can fail over to
+// when WordPress is unavailable.
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// UserAgent identifies export requests in the logs.
+const UserAgent = "wordpress-go-proxy-exporter"
+
+// pagesRoute is the REST route of WordPress pages.
+const pagesRoute = "wp/v2/pages"
+
+// ErrExporting is returned when an export is requested while one is
+// running.
+var ErrExporting = errors.New("export already running")
+
+// ContentSource lists the published pages and posts of a REST route.
+type ContentSource interface {
+	ListContent(ctx context.Context, route string, lang string) ([]models.WordPressPost, error)
+}
+
+// Result counts the paths written by an export.
+type Result struct {
+	Exported int `json:"exported"`
+	Failed   int `json:"failed"`
+}
+
+// Exporter renders the home page of each language, every page, and every
+// post and archive of the custom post types, and writes them to Target as
+// a static HTML tree with an index.html per path.  Pages are served in
+// process by Handler, the same handler visitors reach, so they are
+// rendered with the same templates and content rewriting.
+type Exporter struct {
+	Handler   http.Handler
+	Content   ContentSource
+	PostTypes []models.PostType
+	Target    Target
+
+	// WordPressURL is the base URL of the WordPress site, which the links
+	// of pages are made to.
+	WordPressURL string
+
+	// StaticDir, if set, is the directory of static assets copied under
+	// static/, so that the exported pages are styled.
+	StaticDir string
+
+	// Concurrency is the number of pages rendered at once.
+	Concurrency int
+
+	running sync.Mutex
+}
+
+// New creates an exporter writing the pages handler serves to target.
+func New(handler http.Handler, content ContentSource, postTypes []models.PostType, wordPressURL string, target Target) *Exporter {
+	return &Exporter{
+		Handler:      handler,
+		Content:      content,
+		PostTypes:    postTypes,
+		Target:       target,
+		WordPressURL: wordPressURL,
+		Concurrency:  1,
+	}
+}
+
+// Paths returns the paths to export: the home page of each language, the
+// pages of each language, then the archive and posts of each custom post
+// type, without duplicates.
+func (e *Exporter) Paths(ctx context.Context) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, lang := range models.Languages {
+		add(models.Languages.Prefix(lang.Code))
+	}
+	for _, lang := range models.Languages {
+		pages, err := e.Content.ListContent(ctx, pagesRoute, lang.Code)
+		if err != nil {
+			return nil, fmt.Errorf("error listing pages: %w", err)
+		}
+		for _, page := range pages {
+			if path, ok := e.pagePath(page.Link); ok {
+				add(path)
+			}
+		}
+	}
+	for _, postType := range e.PostTypes {
+		for _, lang := range models.Languages {
+			posts, err := e.Content.ListContent(ctx, postType.Route, lang.Code)
+			if err != nil {
+				return nil, fmt.Errorf("error listing %s: %w", postType.Name, err)
+			}
+			add(postType.Path(lang.Code))
+			for _, post := range posts {
+				add(postType.PostPath(lang.Code, post.Slug))
+			}
+		}
+	}
+	return paths, nil
+}
+
+// pagePath returns the proxy path of a page given its WordPress link,
+// without a trailing slash.
+func (e *Exporter) pagePath(link string) (string, bool) {
+	p, ok := models.InternalPath(link, e.WordPressURL)
+	if !ok {
+		return "", false
+	}
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+	if p != "/" {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p, true
+}
+
+// Export renders every path to export, Concurrency at a time, writes them
+// to the target along with the static assets, and returns how many were
+// written.  Only one export runs at a time, and ErrExporting is returned
+// if one is already running.  Paths not yet rendered when ctx is done are
+// skipped.
+func (e *Exporter) Export(ctx context.Context) (Result, error) {
+	if !e.running.TryLock() {
+		return Result{}, ErrExporting
+	}
+	defer e.running.Unlock()
+
+	start := time.Now()
+	paths, err := e.Paths(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	if e.StaticDir != "" {
+		if err := e.exportStatic(ctx); err != nil {
+			return Result{}, fmt.Errorf("error exporting static assets: %w", err)
+		}
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var result Result
+	var wg sync.WaitGroup
+	for range max(e.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				ok := e.export(ctx, path)
+				mu.Lock()
+				if ok {
+					result.Exported++
+				} else {
+					result.Failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+
+	log.Printf("Exported %d of %d paths in %v, %d failed", result.Exported, len(paths), time.Since(start).Round(time.Millisecond), result.Failed)
+	return result, ctx.Err()
+}
+
+// export renders a path and writes it to the target, reporting whether it
+// was written.  Redirects are written as pages that redirect to their
+// location, since static hosts cannot send them.
+func (e *Exporter) export(ctx context.Context, path string) bool {
+	name, ok := fileName(path)
+	if !ok {
+		log.Printf("Error exporting %s: invalid path", path)
+		return false
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		log.Printf("Error exporting %s: %v", path, err)
+		return false
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "text/html")
+
+	rw := &bufferWriter{header: make(http.Header), status: http.StatusOK}
+	e.Handler.ServeHTTP(rw, req)
+
+	body := rw.body.Bytes()
+	switch {
+	case rw.status == http.StatusOK:
+	case rw.status >= http.StatusMultipleChoices && rw.status < http.StatusBadRequest && rw.header.Get("Location") != "":
+		body = redirectPage(rw.header.Get("Location"))
+	default:
+		log.Printf("Error exporting %s: status %d", path, rw.status)
+		return false
+	}
+
+	body = stripNonce(body, rw.header)
+	if err := e.Target.Write(ctx, name, "text/html; charset=utf-8", body); err != nil {
+		log.Printf("Error writing %s: %v", name, err)
+		return false
+	}
+	return true
+}
+
+// exportedNonce is written in place of the CSP nonce attributes of
+// exported pages, and replaced with the nonce of the request a copy is
+// served to.
+const exportedNonce = `nonce="` + middleware.NoncePlaceholder + `"`
+
+// stripNonce replaces the CSP nonce of a rendered page, found in its
+// policy, with exportedNonce, so that the nonce of the export request is
+// never served again.
+func stripNonce(body []byte, header http.Header) []byte {
+	policy := header.Get("Content-Security-Policy")
+	if policy == "" {
+		policy = header.Get("Content-Security-Policy-Report-Only")
+	}
+	_, rest, found := strings.Cut(policy, "'nonce-")
+	nonce, _, _ := strings.Cut(rest, "'")
+	if !found || nonce == "" {
+		return body
+	}
+	return bytes.ReplaceAll(body, []byte(`nonce="`+nonce+`"`), []byte(exportedNonce))
+}
+
+// exportStatic copies the static assets to the target under static/.
+func (e *Exporter) exportStatic(ctx context.Context) error {
+	return filepath.WalkDir(e.StaticDir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(e.StaticDir, file)
+		if err != nil {
+			return err
+		}
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(file))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		return e.Target.Write(ctx, "static/"+filepath.ToSlash(rel), contentType, body)
+	})
+}
+
+// fileName returns the name of the file a path is written to, its
+// index.html.  It reports false for paths that would be written outside
+// the root.
+func fileName(p string) (string, bool) {
+	unescaped, err := url.PathUnescape(p)
+	if err != nil || !strings.HasPrefix(unescaped, "/") {
+		return "", false
+	}
+	for _, segment := range strings.Split(unescaped, "/") {
+		if segment == ".." || strings.ContainsRune(segment, '\\') {
+			return "", false
+		}
+	}
+	return strings.TrimPrefix(path.Join(unescaped, "index.html"), "/"), true
+}
+
+// redirectTemplate is the page written in place of a redirect.
+var redirectTemplate = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="0; url={{.}}">
+<link rel="canonical" href="{{.}}">
+</head>
+<body><a href="{{.}}">{{.}}</a></body>
+</html>
+`))
+
+// redirectPage returns a page that redirects to a location.
+func redirectPage(location string) []byte {
+	var buf bytes.Buffer
+	redirectTemplate.Execute(&buf, location)
+	return buf.Bytes()
+}
+
+// bufferWriter is a response writer that keeps the status, headers and
+// body of a response.
+type bufferWriter struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *bufferWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferWriter) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status = status
+		b.wroteHeader = true
+	}
+}
+
+func (b *bufferWriter) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
@@ -0,0 +1,185 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// fakeContent returns fixed content keyed by route and language
+type fakeContent map[string][]models.WordPressPost
+
+func (f fakeContent) ListContent(ctx context.Context, route string, lang string) ([]models.WordPressPost, error) {
+	content, ok := f[route+":"+lang]
+	if !ok {
+		return nil, errors.New("unknown route")
+	}
+	return content, nil
+}
+
+// memTarget keeps written files in memory
+type memTarget struct {
+	mu    sync.Mutex
+	files map[string]string
+}
+
+func (m *memTarget) Write(ctx context.Context, name string, contentType string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = make(map[string]string)
+	}
+	m.files[name] = contentType + "\n" + string(body)
+	return nil
+}
+
+var testContent = fakeContent{
+	"wp/v2/pages:en": {
+		{Slug: "home", Link: "https://wordpress.example.com/"},
+		{Slug: "about", Link: "https://wordpress.example.com/about/"},
+		{Slug: "team", Link: "https://wordpress.example.com/about/team/"},
+		{Slug: "elsewhere", Link: "https://partner.example.org/elsewhere/"},
+	},
+	"wp/v2/pages:fr": {
+		{Slug: "a-propos", Link: "https://www.wordpress.example.com/fr/a-propos/"},
+	},
+	"wp/v2/events:en": {{Slug: "launch"}},
+	"wp/v2/events:fr": {},
+}
+
+var testPostTypes = []models.PostType{{Name: "events", Route: "wp/v2/events"}}
+
+func TestPaths(t *testing.T) {
+	e := New(http.NotFoundHandler(), testContent, testPostTypes, "https://wordpress.example.com", &memTarget{})
+
+	paths, err := e.Paths(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{"/", "/fr/", "/about", "/about/team", "/fr/a-propos", "/events/", "/events/launch", "/fr/events/"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected paths %v, got %v", expected, paths)
+	}
+
+	e.PostTypes = []models.PostType{{Name: "jobs", Route: "wp/v2/jobs"}}
+	if _, err := e.Paths(context.Background()); err == nil || !strings.Contains(err.Error(), "jobs") {
+		t.Errorf("Expected an error listing jobs, got %v", err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	var mu sync.Mutex
+	requested := make(map[string]string)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = r.UserAgent()
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/events/":
+			http.Redirect(w, r, "/events", http.StatusMovedPermanently)
+		case "/fr/a-propos":
+			http.Error(w, "Error", http.StatusInternalServerError)
+		case "/about":
+			w.Header().Set("Content-Security-Policy", "script-src 'nonce-abc123'")
+			w.Write([]byte(`<script nonce="abc123"></script>`))
+		default:
+			w.Write([]byte("<html>" + r.URL.Path + "</html>"))
+		}
+	})
+
+	target := &memTarget{}
+	e := New(handler, testContent, testPostTypes, "https://wordpress.example.com", target)
+	e.Concurrency = 3
+	e.StaticDir = t.TempDir()
+	os.MkdirAll(filepath.Join(e.StaticDir, "css"), 0o755)
+	os.WriteFile(filepath.Join(e.StaticDir, "css", "site.css"), []byte("body{}"), 0o644)
+
+	result, err := e.Export(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result != (Result{Exported: 7, Failed: 1}) {
+		t.Errorf("Expected 7 exported and 1 failed, got %+v", result)
+	}
+	for path, userAgent := range requested {
+		if userAgent != UserAgent {
+			t.Errorf("%s: expected user agent %q, got %q", path, UserAgent, userAgent)
+		}
+	}
+
+	expected := map[string]string{
+		"index.html":             "text/html; charset=utf-8\n<html>/</html>",
+		"about/team/index.html":  "text/html; charset=utf-8\n<html>/about/team</html>",
+		"about/index.html":       "text/html; charset=utf-8\n<script nonce=\"{nonce}\"></script>",
+		"static/css/site.css":    "text/css; charset=utf-8\nbody{}",
+		"fr/a-propos/index.html": "",
+	}
+	for name, content := range expected {
+		if target.files[name] != content {
+			t.Errorf("%s: expected %q, got %q", name, content, target.files[name])
+		}
+	}
+	if redirect := target.files["events/index.html"]; !strings.Contains(redirect, `<meta http-equiv="refresh" content="0; url=/events">`) {
+		t.Errorf("Expected a redirect page, got %q", redirect)
+	}
+}
+
+// TestExportRunning tests that exports do not overlap
+func TestExportRunning(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			close(started)
+			<-release
+		}
+	})
+	content := fakeContent{"wp/v2/pages:en": {}, "wp/v2/pages:fr": {}}
+	e := New(handler, content, nil, "https://wordpress.example.com", &memTarget{})
+
+	done := make(chan Result)
+	go func() {
+		result, _ := e.Export(context.Background())
+		done <- result
+	}()
+	<-started
+
+	if _, err := e.Export(context.Background()); !errors.Is(err, ErrExporting) {
+		t.Errorf("Expected ErrExporting, got %v", err)
+	}
+	close(release)
+	if result := <-done; result.Exported != 2 {
+		t.Errorf("Expected 2 paths exported, got %+v", result)
+	}
+}
+
+func TestFileName(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+		ok       bool
+	}{
+		{"/", "index.html", true},
+		{"/fr/", "fr/index.html", true},
+		{"/about/team", "about/team/index.html", true},
+		{"/caf%C3%A9", "café/index.html", true},
+		{"/a/%2E%2E/%2E%2E/etc", "", false},
+		{"/a%5Cb", "", false},
+		{"about", "", false},
+	}
+
+	for _, tc := range testCases {
+		name, ok := fileName(tc.path)
+		if name != tc.expected || ok != tc.ok {
+			t.Errorf("fileName(%q): expected %q %v, got %q %v", tc.path, tc.expected, tc.ok, name, ok)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+
+	"wordpress-go-proxy/internal/middleware"
+)
+
+// Snapshot serves the pages of the last export, for when the origin they
+// are rendered from is unavailable.  Copies are served through Headers, the
+// security headers middleware of live pages, and their exported nonce is
+// replaced with the one of the request.
+type Snapshot struct {
+	Source  Source
+	Headers func(http.Handler) http.Handler
+}
+
+// NewSnapshot creates a snapshot serving the exported files of source with
+// the security headers set by headers.
+func NewSnapshot(source Source, headers func(http.Handler) http.Handler) *Snapshot {
+	return &Snapshot{Source: source, Headers: headers}
+}
+
+// Serve responds with the exported copy of the requested path, reporting
+// false without responding if the path was not exported.  Copies are not
+// cached, so visitors get the live pages again once the origin is back.
+func (s *Snapshot) Serve(w http.ResponseWriter, r *http.Request) bool {
+	name, ok := fileName(r.URL.EscapedPath())
+	if !ok {
+		return false
+	}
+	body, contentType, err := s.Source.Read(r.Context(), name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	if err != nil {
+		log.Printf("Error reading exported %s: %v", name, err)
+		return false
+	}
+
+	log.Printf("Serving exported copy of %s", r.URL.Path)
+	if contentType == "" {
+		contentType = "text/html; charset=utf-8"
+	}
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Without a policy, the attributes are dropped
+		old, nonce := " "+exportedNonce, ""
+		if n := middleware.Nonce(r.Context()); n != "" {
+			old, nonce = exportedNonce, `nonce="`+n+`"`
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		if r.Method != http.MethodHead {
+			w.Write(bytes.ReplaceAll(body, []byte(old), []byte(nonce)))
+		}
+	})
+	if s.Headers != nil {
+		handler = s.Headers(handler)
+	}
+	handler.ServeHTTP(w, r)
+	return true
+}
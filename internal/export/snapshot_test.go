@@ -0,0 +1,65 @@
+package export
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wordpress-go-proxy/internal/middleware"
+)
+
+func TestSnapshot(t *testing.T) {
+	target := NewDirTarget(t.TempDir())
+	target.Write(context.Background(), "fr/a-propos/index.html", "text/html", []byte("<html>copy</html>"))
+	snapshot := NewSnapshot(target, nil)
+
+	testCases := []struct {
+		method   string
+		target   string
+		served   bool
+		expected string
+	}{
+		{method: "GET", target: "/fr/a-propos", served: true, expected: "<html>copy</html>"},
+		{method: "GET", target: "/fr/a-propos/", served: true, expected: "<html>copy</html>"},
+		{method: "HEAD", target: "/fr/a-propos", served: true, expected: ""},
+		{method: "GET", target: "/about", served: false},
+		{method: "GET", target: "/a/%2E%2E/%2E%2E/etc", served: false},
+	}
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		served := snapshot.Serve(w, httptest.NewRequest(tc.method, tc.target, nil))
+		if served != tc.served || w.Body.String() != tc.expected {
+			t.Errorf("%s %s: expected %v %q, got %v %q", tc.method, tc.target, tc.served, tc.expected, served, w.Body.String())
+		}
+		if served && (w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/html; charset=utf-8" || w.Header().Get("Cache-Control") != "no-store") {
+			t.Errorf("%s %s: unexpected response %d %v", tc.method, tc.target, w.Code, w.Header())
+		}
+	}
+}
+
+func TestSnapshotNonce(t *testing.T) {
+	target := NewDirTarget(t.TempDir())
+	target.Write(context.Background(), "index.html", "text/html", []byte(`<script nonce="{nonce}" src="/static/js/site.js"></script>`))
+	snapshot := NewSnapshot(target, middleware.NewSecurityHeaders(middleware.CSP{Policy: "script-src 'nonce-{nonce}'"}))
+
+	w := httptest.NewRecorder()
+	if !snapshot.Serve(w, httptest.NewRequest("GET", "/", nil)) {
+		t.Fatal("Expected the copy to be served")
+	}
+	policy := w.Header().Get("Content-Security-Policy")
+	nonce := strings.TrimSuffix(strings.TrimPrefix(policy, "script-src 'nonce-"), "'")
+	if nonce == "" || nonce == policy || w.Body.String() != `<script nonce="`+nonce+`" src="/static/js/site.js"></script>` {
+		t.Errorf("Expected the policy's nonce in the copy, got %q and %q", policy, w.Body.String())
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("Expected security headers, got %v", w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	NewSnapshot(target, nil).Serve(w, httptest.NewRequest("GET", "/", nil))
+	if w.Body.String() != `<script src="/static/js/site.js"></script>` {
+		t.Errorf("Expected the nonce to be dropped without a policy, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,150 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Target receives the files of an export.  Names are slash separated and
+// relative to the root of the site, such as "fr/a-propos/index.html".
+type Target interface {
+	Write(ctx context.Context, name string, contentType string, body []byte) error
+}
+
+// Source reads back the files of an export, returning an error matching
+// fs.ErrNotExist for files that were not exported.
+type Source interface {
+	Read(ctx context.Context, name string) (body []byte, contentType string, err error)
+}
+
+// Store is a target whose exported files can be read back.
+type Store interface {
+	Target
+	Source
+}
+
+// DirTarget writes files to a directory on disk.
+type DirTarget struct {
+	Dir string
+}
+
+// NewDirTarget creates a target writing to a directory, which is created
+// along with any subdirectories as files are written.
+func NewDirTarget(dir string) *DirTarget {
+	return &DirTarget{Dir: dir}
+}
+
+// Write implements the Target interface.  Files are written to a temporary
+// file first and renamed, so that a site served from the directory never
+// has partly written files.
+func (d *DirTarget) Write(ctx context.Context, name string, contentType string, body []byte) error {
+	file := filepath.Join(d.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// Read implements the Source interface.
+func (d *DirTarget) Read(ctx context.Context, name string) ([]byte, string, error) {
+	body, err := os.ReadFile(filepath.Join(d.Dir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, "", err
+	}
+	return body, mime.TypeByExtension(path.Ext(name)), nil
+}
+
+// S3API is the part of the S3 client used to write and read files.
+type S3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3Target writes files to an S3 bucket under a key prefix, such as a
+// bucket behind a CloudFront origin group used for failover.
+type S3Target struct {
+	Client S3API
+	Bucket string
+	Prefix string
+}
+
+// NewS3Target creates a target writing to a bucket under a key prefix.
+func NewS3Target(client S3API, bucket string, prefix string) *S3Target {
+	return &S3Target{
+		Client: client,
+		Bucket: bucket,
+		Prefix: prefix,
+	}
+}
+
+// Write implements the Target interface.
+func (t *S3Target) Write(ctx context.Context, name string, contentType string, body []byte) error {
+	_, err := t.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(t.Bucket),
+		Key:         aws.String(path.Join(t.Prefix, name)),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+// Read implements the Source interface.
+func (t *S3Target) Read(ctx context.Context, name string) ([]byte, string, error) {
+	out, err := t.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(t.Bucket),
+		Key:    aws.String(path.Join(t.Prefix, name)),
+	})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, "", fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer out.Body.Close()
+
+	body, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, aws.ToString(out.ContentType), nil
+}
+
+// ParseS3URL splits an s3://bucket/prefix URL into its bucket and key
+// prefix.  The prefix may be empty to write to the root of the bucket.
+func ParseS3URL(value string) (string, string, bool) {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return "", "", false
+	}
+	return u.Host, strings.Trim(u.Path, "/"), true
+}
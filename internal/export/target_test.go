@@ -0,0 +1,97 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestDirTarget(t *testing.T) {
+	dir := t.TempDir()
+	target := NewDirTarget(dir)
+
+	if err := target.Write(context.Background(), "fr/a-propos/index.html", "text/html", []byte("<html></html>")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	body, err := os.ReadFile(filepath.Join(dir, "fr", "a-propos", "index.html"))
+	if err != nil || string(body) != "<html></html>" {
+		t.Errorf("Expected the file to be written, got %q, %v", body, err)
+	}
+	if _, _, err := target.Read(context.Background(), "about/index.html"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist for a missing file, got %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "fr", "a-propos"))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files left, got %v", entries)
+	}
+}
+
+// fakeS3 records the objects put
+type fakeS3 struct {
+	objects map[string]string
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	object, ok := f.objects[*params.Bucket+"/"+*params.Key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	contentType, body, _ := strings.Cut(object, "\n")
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(body)), ContentType: aws.String(contentType)}, nil
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, _ := io.ReadAll(params.Body)
+	f.objects[*params.Bucket+"/"+*params.Key] = *params.ContentType + "\n" + string(body)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestS3Target(t *testing.T) {
+	client := &fakeS3{objects: make(map[string]string)}
+	target := NewS3Target(client, "failover-site", "static")
+
+	if err := target.Write(context.Background(), "index.html", "text/html", []byte("<html></html>")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if client.objects["failover-site/static/index.html"] != "text/html\n<html></html>" {
+		t.Errorf("Expected the object under the prefix, got %v", client.objects)
+	}
+
+	body, contentType, err := target.Read(context.Background(), "index.html")
+	if err != nil || string(body) != "<html></html>" || contentType != "text/html" {
+		t.Errorf("Expected the object read back, got %q %q %v", body, contentType, err)
+	}
+	if _, _, err := target.Read(context.Background(), "about/index.html"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist for a missing object, got %v", err)
+	}
+}
+
+func TestParseS3URL(t *testing.T) {
+	testCases := []struct {
+		url            string
+		expectedBucket string
+		expectedPrefix string
+		expectedOK     bool
+	}{
+		{"s3://failover-site/static/", "failover-site", "static", true},
+		{"s3://failover-site", "failover-site", "", true},
+		{"s3:///static", "", "", false},
+		{"/var/www/static", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		bucket, prefix, ok := ParseS3URL(tc.url)
+		if bucket != tc.expectedBucket || prefix != tc.expectedPrefix || ok != tc.expectedOK {
+			t.Errorf("ParseS3URL(%q): expected %q %q %v, got %q %q %v", tc.url, tc.expectedBucket, tc.expectedPrefix, tc.expectedOK, bucket, prefix, ok)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package feedback
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"wordpress-go-proxy/pkg/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Sink records visitors' answers to whether a page was helpful, such as to
+// CloudWatch metrics, a DynamoDB table or a WordPress plugin.
+// Implementations must be safe for concurrent use.
+type Sink interface {
+	Record(ctx context.Context, feedback models.PageFeedback) error
+}
+
+// EMFSink writes feedback as CloudWatch embedded metric format log lines,
+// which CloudWatch Logs turns into Helpful and NotHelpful count metrics by
+// language.  The page ID is kept as a property of the log line rather than
+// a dimension, so it can be queried without a metric per page.
+type EMFSink struct {
+	Writer    io.Writer
+	Namespace string
+
+	mu sync.Mutex
+}
+
+// NewEMFSink creates a sink writing metrics in namespace to w, usually
+// standard output, which Lambda sends to CloudWatch Logs.
+func NewEMFSink(w io.Writer, namespace string) *EMFSink {
+	return &EMFSink{Writer: w, Namespace: namespace}
+}
+
+// Record implements Sink.
+func (s *EMFSink) Record(ctx context.Context, feedback models.PageFeedback) error {
+	helpful, notHelpful := 0, 1
+	if feedback.Helpful {
+		helpful, notHelpful = 1, 0
+	}
+	line, err := json.Marshal(map[string]any{
+		"_aws": map[string]any{
+			"Timestamp": feedback.Time.UnixMilli(),
+			"CloudWatchMetrics": []map[string]any{{
+				"Namespace":  s.Namespace,
+				"Dimensions": [][]string{{"Lang"}},
+				"Metrics": []map[string]string{
+					{"Name": "Helpful", "Unit": "Count"},
+					{"Name": "NotHelpful", "Unit": "Count"},
+				},
+			}},
+		},
+		"Lang":       feedback.Lang,
+		"PageID":     feedback.PageID,
+		"Helpful":    helpful,
+		"NotHelpful": notHelpful,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Lines are written whole so they are not interleaved
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.Writer.Write(append(line, '\n'))
+	return err
+}
+
+// DynamoDBAPI is the part of the DynamoDB client used by DynamoDBSink.
+type DynamoDBAPI interface {
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+// DynamoDBSink stores each answer as an item of a DynamoDB table with a
+// string partition key named "id", holding a random ID, along with its
+// "page_id", "lang", "helpful" and RFC 3339 "timestamp" attributes.
+type DynamoDBSink struct {
+	Client DynamoDBAPI
+	Table  string
+}
+
+// NewDynamoDBSink creates a sink storing feedback in a DynamoDB table.
+func NewDynamoDBSink(client DynamoDBAPI, table string) *DynamoDBSink {
+	return &DynamoDBSink{Client: client, Table: table}
+}
+
+// Record implements Sink.
+func (s *DynamoDBSink) Record(ctx context.Context, feedback models.PageFeedback) error {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	_, err := s.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.Table),
+		Item: map[string]types.AttributeValue{
+			"id":        &types.AttributeValueMemberS{Value: hex.EncodeToString(id)},
+			"page_id":   &types.AttributeValueMemberN{Value: strconv.Itoa(feedback.PageID)},
+			"lang":      &types.AttributeValueMemberS{Value: feedback.Lang},
+			"helpful":   &types.AttributeValueMemberBOOL{Value: feedback.Helpful},
+			"timestamp": &types.AttributeValueMemberS{Value: feedback.Time.UTC().Format(time.RFC3339)},
+		},
+	})
+	return err
+}
+
+// WordPressAPI is the part of the WordPress client used by WordPressSink.
+type WordPressAPI interface {
+	SubmitFeedback(ctx context.Context, route string, feedback models.PageFeedback) error
+}
+
+// WordPressSink posts feedback to a REST route of a WordPress plugin.
+type WordPressSink struct {
+	Client WordPressAPI
+	Route  string
+}
+
+// NewWordPressSink creates a sink posting feedback to a WordPress route.
+func NewWordPressSink(client WordPressAPI, route string) *WordPressSink {
+	return &WordPressSink{Client: client, Route: route}
+}
+
+// Record implements Sink.
+func (s *WordPressSink) Record(ctx context.Context, feedback models.PageFeedback) error {
+	return s.Client.SubmitFeedback(ctx, s.Route, feedback)
+}
@@ -0,0 +1,106 @@
+package feedback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/pkg/models"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var answer = models.PageFeedback{
+	PageID:  42,
+	Lang:    "fr",
+	Helpful: false,
+	Time:    time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func TestEMFSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewEMFSink(&buf, "Proxy")
+	if err := sink.Record(context.Background(), answer); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	var line struct {
+		AWS struct {
+			Timestamp         int64
+			CloudWatchMetrics []struct {
+				Namespace  string
+				Dimensions [][]string
+			}
+		} `json:"_aws"`
+		Lang       string
+		PageID     int
+		Helpful    int
+		NotHelpful int
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Invalid EMF line %q: %v", buf.String(), err)
+	}
+	if line.AWS.Timestamp != answer.Time.UnixMilli() || line.AWS.CloudWatchMetrics[0].Namespace != "Proxy" {
+		t.Errorf("Unexpected metadata: %+v", line.AWS)
+	}
+	if line.Lang != "fr" || line.PageID != 42 || line.Helpful != 0 || line.NotHelpful != 1 {
+		t.Errorf("Unexpected values: %+v", line)
+	}
+}
+
+type fakeDynamoDB struct {
+	input *dynamodb.PutItemInput
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.input = params
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestDynamoDBSink(t *testing.T) {
+	client := &fakeDynamoDB{}
+	sink := NewDynamoDBSink(client, "feedback")
+	if err := sink.Record(context.Background(), answer); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	if *client.input.TableName != "feedback" {
+		t.Errorf("Expected table feedback, got %s", *client.input.TableName)
+	}
+	item := client.input.Item
+	if id, ok := item["id"].(*types.AttributeValueMemberS); !ok || len(id.Value) != 32 {
+		t.Errorf("Expected random ID, got %v", item["id"])
+	}
+	if v := item["page_id"].(*types.AttributeValueMemberN).Value; v != "42" {
+		t.Errorf("Expected page_id 42, got %s", v)
+	}
+	if v := item["helpful"].(*types.AttributeValueMemberBOOL).Value; v {
+		t.Errorf("Expected helpful false")
+	}
+	if v := item["timestamp"].(*types.AttributeValueMemberS).Value; v != "2025-03-01T12:00:00Z" {
+		t.Errorf("Expected RFC 3339 timestamp, got %s", v)
+	}
+}
+
+type fakeWordPress struct {
+	route    string
+	feedback models.PageFeedback
+}
+
+func (f *fakeWordPress) SubmitFeedback(ctx context.Context, route string, feedback models.PageFeedback) error {
+	f.route, f.feedback = route, feedback
+	return nil
+}
+
+func TestWordPressSink(t *testing.T) {
+	client := &fakeWordPress{}
+	if err := NewWordPressSink(client, "feedback/v1/answers").Record(context.Background(), answer); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if client.route != "feedback/v1/answers" || client.feedback != answer {
+		t.Errorf("Unexpected submission to %s: %+v", client.route, client.feedback)
+	}
+}
@@ -0,0 +1,242 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Rules are the request filtering rules.  CIDRs may also be single IP
+// addresses.  If AllowCIDRs is not empty, only clients in those ranges are
+// allowed.  User agent and path rules are regular expressions, and user
+// agent rules ignore case.
+type Rules struct {
+	AllowCIDRs     []string `json:"allow_cidrs"`
+	DenyCIDRs      []string `json:"deny_cidrs"`
+	DenyUserAgents []string `json:"deny_user_agents"`
+	DenyPaths      []string `json:"deny_paths"`
+}
+
+// Loader retrieves rules maintained outside of the proxy's config, such
+// as a file in S3.
+type Loader func(ctx context.Context) (Rules, error)
+
+// ruleSet is a compiled set of rules.
+type ruleSet struct {
+	allow      []netip.Prefix
+	deny       []netip.Prefix
+	userAgents []*regexp.Regexp
+	paths      []*regexp.Regexp
+}
+
+// Store holds the configured rules, merged with rules from an optional
+// loader that are refreshed once they are older than the refresh interval.
+type Store struct {
+	Static Rules
+	Load   Loader
+
+	// RefreshInterval is how long loaded rules are kept before they are
+	// refreshed in the background.  Zero disables refreshing.
+	RefreshInterval time.Duration
+
+	// TrustedProxies is the number of proxies in front of this one whose
+	// X-Forwarded-For entries are trusted to identify the client.  Zero
+	// uses the address of the connection.
+	TrustedProxies int
+
+	mu         sync.RWMutex
+	rules      *ruleSet
+	refreshed  time.Time
+	refreshing atomic.Bool
+}
+
+// NewStore creates a store from the configured rules.  load may be nil if
+// there are no other rules.  It returns an error if the rules are invalid.
+func NewStore(static Rules, load Loader, refreshInterval time.Duration, trustedProxies int) (*Store, error) {
+	rules, err := compile(static)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		Static:          static,
+		Load:            load,
+		RefreshInterval: refreshInterval,
+		TrustedProxies:  trustedProxies,
+		rules:           rules,
+	}, nil
+}
+
+// Refresh loads the rules and merges them with the configured rules.  If
+// loading fails or the rules are invalid, the current rules are kept.
+func (s *Store) Refresh(ctx context.Context) error {
+	if s.Load == nil {
+		return nil
+	}
+	loaded, err := s.Load(ctx)
+	if err == nil {
+		var rules *ruleSet
+		rules, err = compile(merge(s.Static, loaded))
+		if err == nil {
+			s.mu.Lock()
+			s.rules = rules
+			s.mu.Unlock()
+			log.Printf("Loaded %d CIDR and %d pattern filter rules",
+				len(rules.allow)+len(rules.deny), len(rules.userAgents)+len(rules.paths))
+		}
+	}
+
+	s.mu.Lock()
+	s.refreshed = time.Now()
+	s.mu.Unlock()
+	return err
+}
+
+// Block reports whether a request is blocked and the rule that blocked
+// it.  If the loaded rules are older than the refresh interval, a
+// background refresh is started and the current rules are used.
+func (s *Store) Block(r *http.Request) (string, bool) {
+	s.mu.RLock()
+	rules := s.rules
+	stale := s.Load != nil && s.RefreshInterval > 0 && time.Since(s.refreshed) > s.RefreshInterval
+	s.mu.RUnlock()
+
+	if stale && s.refreshing.CompareAndSwap(false, true) {
+		go func() {
+			defer s.refreshing.Store(false)
+			if err := s.Refresh(context.Background()); err != nil {
+				log.Printf("Error refreshing filter rules: %v", err)
+			}
+		}()
+	}
+
+	if len(rules.allow) > 0 || len(rules.deny) > 0 {
+		ip, ok := ClientIP(r, s.TrustedProxies)
+		if !ok {
+			return "unknown client address", true
+		}
+		for _, prefix := range rules.deny {
+			if prefix.Contains(ip) {
+				return "denied address " + ip.String(), true
+			}
+		}
+		if len(rules.allow) > 0 && !containsAddr(rules.allow, ip) {
+			return "address not allowed " + ip.String(), true
+		}
+	}
+	userAgent := r.UserAgent()
+	for _, pattern := range rules.userAgents {
+		if pattern.MatchString(userAgent) {
+			return "denied user agent " + userAgent, true
+		}
+	}
+	for _, pattern := range rules.paths {
+		if pattern.MatchString(r.URL.Path) {
+			return "denied path " + r.URL.Path, true
+		}
+	}
+	return "", false
+}
+
+// ClientIP returns the address of the client that made a request.  When
+// the request passed through trusted proxies, the client is the entry of
+// X-Forwarded-For added by the outermost trusted proxy.
+func ClientIP(r *http.Request, trustedProxies int) (netip.Addr, bool) {
+	if trustedProxies > 0 {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(header, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+		if len(hops) < trustedProxies {
+			return netip.Addr{}, false
+		}
+		return parseAddr(hops[len(hops)-trustedProxies])
+	}
+	return parseAddr(r.RemoteAddr)
+}
+
+// parseAddr parses an IP address with or without a port.
+func parseAddr(value string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// containsAddr reports whether an address is in any of the prefixes.
+func containsAddr(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge combines two sets of rules.
+func merge(a Rules, b Rules) Rules {
+	return Rules{
+		AllowCIDRs:     append(append([]string{}, a.AllowCIDRs...), b.AllowCIDRs...),
+		DenyCIDRs:      append(append([]string{}, a.DenyCIDRs...), b.DenyCIDRs...),
+		DenyUserAgents: append(append([]string{}, a.DenyUserAgents...), b.DenyUserAgents...),
+		DenyPaths:      append(append([]string{}, a.DenyPaths...), b.DenyPaths...),
+	}
+}
+
+// compile parses the CIDRs and patterns of a set of rules.
+func compile(rules Rules) (*ruleSet, error) {
+	var compiled ruleSet
+	var err error
+	if compiled.allow, err = parsePrefixes(rules.AllowCIDRs); err != nil {
+		return nil, err
+	}
+	if compiled.deny, err = parsePrefixes(rules.DenyCIDRs); err != nil {
+		return nil, err
+	}
+	for _, pattern := range rules.DenyUserAgents {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user agent pattern %q: %w", pattern, err)
+		}
+		compiled.userAgents = append(compiled.userAgents, re)
+	}
+	for _, pattern := range rules.DenyPaths {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+		}
+		compiled.paths = append(compiled.paths, re)
+	}
+	return &compiled, nil
+}
+
+// parsePrefixes parses CIDRs, treating single addresses as one address
+// prefixes.
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", value)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,170 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStoreBlock(t *testing.T) {
+	store, err := NewStore(Rules{
+		AllowCIDRs:     []string{"10.0.0.0/8", "2001:db8::/32"},
+		DenyCIDRs:      []string{"10.1.0.0/16", "10.2.3.4"},
+		DenyUserAgents: []string{"badbot", "^curl/"},
+		DenyPaths:      []string{`^/wp-(login|admin)`, `\.php$`},
+	}, nil, 0, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	testCases := []struct {
+		name          string
+		remoteAddr    string
+		userAgent     string
+		path          string
+		expectedBlock bool
+	}{
+		{"Allowed address", "10.5.0.1:1234", "Mozilla/5.0", "/about", false},
+		{"Allowed address without a port", "10.5.0.1", "Mozilla/5.0", "/about", false},
+		{"Allowed IPv6 address", "[2001:db8::1]:443", "Mozilla/5.0", "/about", false},
+		{"Denied range", "10.1.2.3:1234", "Mozilla/5.0", "/about", true},
+		{"Denied address", "10.2.3.4:1234", "Mozilla/5.0", "/about", true},
+		{"Address not allowed", "192.0.2.1:1234", "Mozilla/5.0", "/about", true},
+		{"Invalid address", "unknown", "Mozilla/5.0", "/about", true},
+		{"Denied user agent ignoring case", "10.5.0.1:1234", "Mozilla/5.0 (BadBot/2.1)", "/about", true},
+		{"Anchored user agent", "10.5.0.1:1234", "Mozilla/5.0 curl/8.0", "/about", false},
+		{"Denied path", "10.5.0.1:1234", "Mozilla/5.0", "/wp-login.php", true},
+		{"Denied extension", "10.5.0.1:1234", "Mozilla/5.0", "/xmlrpc.php", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.path, nil)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header.Set("User-Agent", tc.userAgent)
+
+			reason, blocked := store.Block(req)
+			if blocked != tc.expectedBlock {
+				t.Errorf("Expected blocked %v, got %v (%s)", tc.expectedBlock, blocked, reason)
+			}
+		})
+	}
+}
+
+func TestNewStoreInvalidRules(t *testing.T) {
+	testCases := []Rules{
+		{AllowCIDRs: []string{"10.0.0.0/33"}},
+		{DenyCIDRs: []string{"not an address"}},
+		{DenyUserAgents: []string{"bad("}},
+		{DenyPaths: []string{"[a-"}},
+	}
+
+	for _, rules := range testCases {
+		if _, err := NewStore(rules, nil, 0, 0); err == nil {
+			t.Errorf("Expected error for %+v", rules)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	testCases := []struct {
+		name           string
+		remoteAddr     string
+		forwardedFor   []string
+		trustedProxies int
+		expected       string
+		expectedOK     bool
+	}{
+		{"Connection address", "192.0.2.1:1234", []string{"203.0.113.9"}, 0, "192.0.2.1", true},
+		{"One trusted proxy", "10.0.0.2:1234", []string{"198.51.100.7, 203.0.113.9"}, 1, "203.0.113.9", true},
+		{"Two trusted proxies", "10.0.0.2:1234", []string{"198.51.100.7", "203.0.113.9"}, 2, "198.51.100.7", true},
+		{"Too few hops", "10.0.0.2:1234", []string{"203.0.113.9"}, 2, "", false},
+		{"IPv4-mapped address", "[::ffff:192.0.2.1]:1234", nil, 0, "192.0.2.1", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for _, value := range tc.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			ip, ok := ClientIP(req, tc.trustedProxies)
+			if ok != tc.expectedOK || (ok && ip.String() != tc.expected) {
+				t.Errorf("Expected %q %v, got %q %v", tc.expected, tc.expectedOK, ip, ok)
+			}
+		})
+	}
+}
+
+func TestStoreRefresh(t *testing.T) {
+	loaded := Rules{DenyPaths: []string{"^/scraped"}}
+	var loadErr error
+	store, err := NewStore(Rules{DenyUserAgents: []string{"badbot"}}, func(ctx context.Context) (Rules, error) {
+		return loaded, loadErr
+	}, 0, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	blocked := func(path string, userAgent string) bool {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("User-Agent", userAgent)
+		_, blocked := store.Block(req)
+		return blocked
+	}
+
+	if blocked("/scraped", "Mozilla/5.0") {
+		t.Error("Expected loaded rules not to apply before a refresh")
+	}
+	if err := store.Refresh(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !blocked("/scraped", "Mozilla/5.0") || !blocked("/about", "BadBot") {
+		t.Error("Expected loaded rules to be merged with the configured rules")
+	}
+
+	// Invalid or failed loads keep the current rules
+	loaded = Rules{DenyPaths: []string{"bad("}}
+	if err := store.Refresh(context.Background()); err == nil {
+		t.Error("Expected error for invalid loaded rules")
+	}
+	loadErr = errors.New("access denied")
+	if err := store.Refresh(context.Background()); err == nil {
+		t.Error("Expected error for a failed load")
+	}
+	if !blocked("/scraped", "Mozilla/5.0") {
+		t.Error("Expected current rules to be kept")
+	}
+}
+
+func TestStoreBackgroundRefresh(t *testing.T) {
+	var loads atomic.Int32
+	store, err := NewStore(Rules{}, func(ctx context.Context) (Rules, error) {
+		loads.Add(1)
+		return Rules{DenyPaths: []string{"^/scraped"}}, nil
+	}, time.Millisecond, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	store.Block(httptest.NewRequest("GET", "/scraped", nil))
+	for i := 0; i < 100 && loads.Load() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if loads.Load() == 0 {
+		t.Fatal("Expected stale rules to be refreshed in the background")
+	}
+
+	for i := 0; i < 100; i++ {
+		if _, blocked := store.Block(httptest.NewRequest("GET", "/scraped", nil)); blocked {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected refreshed rules to apply")
+}
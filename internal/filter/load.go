@@ -0,0 +1,79 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3API is the part of the S3 client used to load rules.
+type S3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3Loader returns a loader that reads JSON rules from an S3 object.
+func S3Loader(client S3API, bucket string, key string) Loader {
+	return func(ctx context.Context) (Rules, error) {
+		out, err := client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return Rules{}, err
+		}
+		defer out.Body.Close()
+		return decode(out.Body)
+	}
+}
+
+// URLLoader returns a loader that reads JSON rules from an HTTP URL.
+func URLLoader(client *http.Client, rulesURL string) Loader {
+	return func(ctx context.Context) (Rules, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", rulesURL, nil)
+		if err != nil {
+			return Rules{}, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return Rules{}, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return Rules{}, fmt.Errorf("filter rules returned status: %d", resp.StatusCode)
+		}
+		return decode(resp.Body)
+	}
+}
+
+// ParseS3URL splits an s3://bucket/key URL into its bucket and key.
+func ParseS3URL(value string) (string, string, bool) {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return "", "", false
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if key == "" {
+		return "", "", false
+	}
+	return u.Host, key, true
+}
+
+// decode reads JSON rules, rejecting unknown fields so that typos in the
+// rules file are reported rather than ignored.
+func decode(r io.Reader) (Rules, error) {
+	var rules Rules
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return Rules{}, fmt.Errorf("invalid filter rules: %w", err)
+	}
+	return rules, nil
+}
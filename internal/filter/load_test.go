@@ -0,0 +1,98 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 returns a single object
+type fakeS3 struct {
+	bucket string
+	key    string
+	body   string
+	err    error
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.bucket, f.key = aws.ToString(params.Bucket), aws.ToString(params.Key)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(f.body))}, nil
+}
+
+func TestS3Loader(t *testing.T) {
+	client := &fakeS3{body: `{"deny_cidrs":["192.0.2.0/24"],"deny_user_agents":["badbot"]}`}
+
+	rules, err := S3Loader(client, "proxy-config", "filter/rules.json")(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if client.bucket != "proxy-config" || client.key != "filter/rules.json" {
+		t.Errorf("Expected object proxy-config/filter/rules.json, got %s/%s", client.bucket, client.key)
+	}
+	if len(rules.DenyCIDRs) != 1 || len(rules.DenyUserAgents) != 1 {
+		t.Errorf("Expected decoded rules, got %+v", rules)
+	}
+
+	client.body = `{"deny_agents":["badbot"]}`
+	if _, err := S3Loader(client, "proxy-config", "filter/rules.json")(context.Background()); err == nil {
+		t.Error("Expected error for an unknown field")
+	}
+
+	client.err = errors.New("access denied")
+	if _, err := S3Loader(client, "proxy-config", "filter/rules.json")(context.Background()); err == nil {
+		t.Error("Expected error from the client")
+	}
+}
+
+func TestURLLoader(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"deny_paths":["^/wp-login"]}`))
+	}))
+	defer server.Close()
+
+	rules, err := URLLoader(server.Client(), server.URL+"/rules.json")(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rules.DenyPaths) != 1 || rules.DenyPaths[0] != "^/wp-login" {
+		t.Errorf("Expected decoded rules, got %+v", rules)
+	}
+
+	status = http.StatusNotFound
+	if _, err := URLLoader(server.Client(), server.URL+"/rules.json")(context.Background()); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
+
+func TestParseS3URL(t *testing.T) {
+	testCases := []struct {
+		url            string
+		expectedBucket string
+		expectedKey    string
+		expectedOK     bool
+	}{
+		{"s3://proxy-config/filter/rules.json", "proxy-config", "filter/rules.json", true},
+		{"s3://proxy-config/", "", "", false},
+		{"s3:///rules.json", "", "", false},
+		{"https://proxy-config/rules.json", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		bucket, key, ok := ParseS3URL(tc.url)
+		if bucket != tc.expectedBucket || key != tc.expectedKey || ok != tc.expectedOK {
+			t.Errorf("ParseS3URL(%q): expected %q %q %v, got %q %q %v", tc.url, tc.expectedBucket, tc.expectedKey, tc.expectedOK, bucket, key, ok)
+		}
+	}
+}
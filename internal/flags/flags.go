@@ -0,0 +1,159 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Names of the features that can be toggled.
+const (
+	Search  = "search"
+	Feeds   = "feeds"
+	PageAPI = "page_api"
+	GraphQL = "graphql"
+)
+
+// Loader fetches the current feature toggles, keyed by feature name.
+type Loader func(ctx context.Context) (map[string]bool, error)
+
+// Store holds the configured feature toggles, overridden by toggles from
+// an optional loader that are refreshed once they are older than the
+// refresh interval.  Features that are neither configured nor loaded are
+// disabled.
+type Store struct {
+	Defaults map[string]bool
+	Load     Loader
+
+	// RefreshInterval is how long loaded toggles are kept before they are
+	// refreshed in the background.  Zero disables refreshing.
+	RefreshInterval time.Duration
+
+	mu         sync.RWMutex
+	flags      map[string]bool
+	refreshed  time.Time
+	refreshing atomic.Bool
+}
+
+// NewStore creates a store from the configured toggles.  load may be nil
+// if the toggles only come from configuration.
+func NewStore(defaults map[string]bool, load Loader, refreshInterval time.Duration) *Store {
+	return &Store{
+		Defaults:        defaults,
+		Load:            load,
+		RefreshInterval: refreshInterval,
+		flags:           merge(defaults, nil),
+	}
+}
+
+// Refresh loads the toggles and merges them over the configured toggles.
+// If loading fails, the current toggles are kept.
+func (s *Store) Refresh(ctx context.Context) error {
+	if s.Load == nil {
+		return nil
+	}
+	loaded, err := s.Load(ctx)
+	if err == nil {
+		flags := merge(s.Defaults, loaded)
+		s.mu.Lock()
+		s.flags = flags
+		s.mu.Unlock()
+		log.Printf("Loaded %d feature flags", len(loaded))
+	}
+
+	s.mu.Lock()
+	s.refreshed = time.Now()
+	s.mu.Unlock()
+	return err
+}
+
+// Enabled reports whether a feature is enabled.  If the loaded toggles are
+// older than the refresh interval, a background refresh is started and the
+// current toggles are used.
+func (s *Store) Enabled(name string) bool {
+	s.mu.RLock()
+	enabled := s.flags[name]
+	stale := s.Load != nil && s.RefreshInterval > 0 && time.Since(s.refreshed) > s.RefreshInterval
+	s.mu.RUnlock()
+
+	if stale && s.refreshing.CompareAndSwap(false, true) {
+		go func() {
+			defer s.refreshing.Store(false)
+			if err := s.Refresh(context.Background()); err != nil {
+				log.Printf("Error refreshing feature flags: %v", err)
+			}
+		}()
+	}
+	return enabled
+}
+
+// AppConfigLoader returns a loader that reads toggles from an AWS AppConfig
+// configuration profile through the AppConfig agent or Lambda extension,
+// for example:
+//
+//	http://localhost:2772/applications/proxy/environments/prod/configurations/flags
+//
+// Both feature flag profiles, {"search": {"enabled": true}}, and freeform
+// JSON objects of booleans, {"search": true}, are supported.
+func AppConfigLoader(client *http.Client, profileURL string) Loader {
+	return func(ctx context.Context) (map[string]bool, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", profileURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("feature flags returned status: %d", resp.StatusCode)
+		}
+		return decode(resp.Body)
+	}
+}
+
+// decode reads a JSON object of toggles, each either a boolean or an
+// AppConfig feature flag with an enabled attribute.
+func decode(r io.Reader) (map[string]bool, error) {
+	var values map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid feature flags: %w", err)
+	}
+
+	flags := make(map[string]bool, len(values))
+	for name, value := range values {
+		var enabled bool
+		if err := json.Unmarshal(value, &enabled); err == nil {
+			flags[name] = enabled
+			continue
+		}
+		var flag struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.Unmarshal(value, &flag); err != nil || flag.Enabled == nil {
+			return nil, fmt.Errorf("invalid feature flag %q: %s", name, value)
+		}
+		flags[name] = *flag.Enabled
+	}
+	return flags, nil
+}
+
+// merge returns the toggles of a overridden by those of b.
+func merge(a map[string]bool, b map[string]bool) map[string]bool {
+	flags := make(map[string]bool, len(a)+len(b))
+	for name, enabled := range a {
+		flags[name] = enabled
+	}
+	for name, enabled := range b {
+		flags[name] = enabled
+	}
+	return flags
+}
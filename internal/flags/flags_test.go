@@ -0,0 +1,104 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStoreDefaults(t *testing.T) {
+	store := NewStore(map[string]bool{Search: true, PageAPI: false}, nil, 0)
+
+	if !store.Enabled(Search) {
+		t.Error("Expected configured feature to be enabled")
+	}
+	if store.Enabled(PageAPI) || store.Enabled("unknown") {
+		t.Error("Expected disabled and unknown features to be disabled")
+	}
+	if err := store.Refresh(context.Background()); err != nil {
+		t.Errorf("Expected no error without a loader, got %v", err)
+	}
+}
+
+func TestStoreRefresh(t *testing.T) {
+	loaded := map[string]bool{PageAPI: true, Search: false}
+	var loadErr error
+	store := NewStore(map[string]bool{Search: true, Feeds: true}, func(ctx context.Context) (map[string]bool, error) {
+		return loaded, loadErr
+	}, 0)
+
+	if err := store.Refresh(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !store.Enabled(PageAPI) || store.Enabled(Search) || !store.Enabled(Feeds) {
+		t.Error("Expected loaded flags to override the configured flags")
+	}
+
+	// Failed loads keep the current flags
+	loaded = nil
+	loadErr = errors.New("connection refused")
+	if err := store.Refresh(context.Background()); err == nil {
+		t.Error("Expected error for a failed load")
+	}
+	if !store.Enabled(PageAPI) {
+		t.Error("Expected current flags to be kept")
+	}
+}
+
+func TestStoreBackgroundRefresh(t *testing.T) {
+	var loads atomic.Int32
+	store := NewStore(nil, func(ctx context.Context) (map[string]bool, error) {
+		loads.Add(1)
+		return map[string]bool{PageAPI: true}, nil
+	}, time.Millisecond)
+
+	store.Enabled(PageAPI)
+	for i := 0; i < 100; i++ {
+		if loads.Load() > 0 && store.Enabled(PageAPI) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected stale flags to be refreshed in the background")
+}
+
+func TestAppConfigLoader(t *testing.T) {
+	status := http.StatusOK
+	body := `{"search":{"enabled":false},"page_api":{"enabled":true,"audience":"staff"},"feeds":true}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/applications/proxy/environments/prod/configurations/flags" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	load := AppConfigLoader(server.Client(), server.URL+"/applications/proxy/environments/prod/configurations/flags")
+	flags, err := load(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if flags[Search] || !flags[PageAPI] || !flags[Feeds] || len(flags) != 3 {
+		t.Errorf("Unexpected flags %v", flags)
+	}
+
+	body = `{"search":{"attribute":"on"}}`
+	if _, err := load(context.Background()); err == nil {
+		t.Error("Expected error for a flag without enabled")
+	}
+
+	body = `["search"]`
+	if _, err := load(context.Background()); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+
+	status = http.StatusNotFound
+	if _, err := load(context.Background()); err == nil {
+		t.Error("Expected error for a failed request")
+	}
+}
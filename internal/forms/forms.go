@@ -0,0 +1,120 @@
+package forms
+
+import (
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"wordpress-go-proxy/internal/transform"
+	"wordpress-go-proxy/pkg/models"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultMaxLength is the number of characters a field accepts when its
+// markup sets no maxlength.
+const DefaultMaxLength = 5000
+
+// fieldElements are the elements whose name attribute declares a field,
+// including the GC Design System form components.
+var fieldElements = map[string]bool{
+	"input":           true,
+	"textarea":        true,
+	"select":          true,
+	"gcds-input":      true,
+	"gcds-textarea":   true,
+	"gcds-select":     true,
+	"gcds-radios":     true,
+	"gcds-checkboxes": true,
+}
+
+// Field is a field of a form as declared by the markup of its template.
+type Field struct {
+	Name      string
+	Required  bool
+	Email     bool
+	MaxLength int
+}
+
+// Fields returns the fields declared by the rendered markup of a form's
+// template, in document order.  Buttons and unnamed elements are not
+// fields, and a name used more than once is a single field.
+func Fields(markup string) ([]Field, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(markup), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []Field
+	seen := make(map[string]bool)
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && fieldElements[n.Data] {
+			name := transform.Attr(n, "name")
+			kind := strings.ToLower(transform.Attr(n, "type"))
+			if name != "" && !seen[name] && kind != "submit" && kind != "button" && kind != "reset" {
+				seen[name] = true
+				field := Field{
+					Name:      name,
+					Required:  transform.HasAttr(n, "required"),
+					Email:     kind == "email",
+					MaxLength: DefaultMaxLength,
+				}
+				if maxLength, err := strconv.Atoi(transform.Attr(n, "maxlength")); err == nil && maxLength > 0 && maxLength < field.MaxLength {
+					field.MaxLength = maxLength
+				}
+				fields = append(fields, field)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return fields, nil
+}
+
+// Validate checks submitted values against the fields of a form.  It
+// returns the trimmed values of the declared fields, dropping any other
+// submitted field, along with the error of each invalid field, one of the
+// models.Field* codes.
+func Validate(fields []Field, submitted url.Values) (url.Values, map[string]string) {
+	values := make(url.Values)
+	errors := make(map[string]string)
+	for _, field := range fields {
+		var kept []string
+		for _, value := range submitted[field.Name] {
+			if value = strings.TrimSpace(value); value != "" {
+				kept = append(kept, value)
+			}
+		}
+
+		switch {
+		case len(kept) == 0:
+			if field.Required {
+				errors[field.Name] = models.FieldRequired
+			}
+			continue
+		case field.Email && !validEmail(kept[0]):
+			errors[field.Name] = models.FieldEmail
+		}
+		for _, value := range kept {
+			if utf8.RuneCountInString(value) > field.MaxLength {
+				errors[field.Name] = models.FieldLength
+			}
+		}
+		values[field.Name] = kept
+	}
+	return values, errors
+}
+
+// validEmail reports whether a value is a plain email address.
+func validEmail(value string) bool {
+	addr, err := mail.ParseAddress(value)
+	return err == nil && addr.Address == value
+}
@@ -0,0 +1,81 @@
+package forms
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+const testMarkup = `
+<gcds-input input-id="name" name="name" maxlength="20" required></gcds-input>
+<gcds-input type="email" input-id="email" name="email" required></gcds-input>
+<select name="topic"><option>Other</option></select>
+<input type="checkbox" name="lists" value="news">
+<input type="checkbox" name="lists" value="events">
+<textarea name="message"></textarea>
+<input type="hidden" name="_wpcf7_unit_tag" value="wpcf7-f12">
+<button type="submit" name="send">Send</button>
+<div name="ignored"></div>`
+
+func TestFields(t *testing.T) {
+	fields, err := Fields(testMarkup)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []Field{
+		{Name: "name", Required: true, MaxLength: 20},
+		{Name: "email", Required: true, Email: true, MaxLength: DefaultMaxLength},
+		{Name: "topic", MaxLength: DefaultMaxLength},
+		{Name: "lists", MaxLength: DefaultMaxLength},
+		{Name: "message", MaxLength: DefaultMaxLength},
+		{Name: "_wpcf7_unit_tag", MaxLength: DefaultMaxLength},
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected fields %+v, got %+v", expected, fields)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	fields, _ := Fields(testMarkup)
+
+	testCases := []struct {
+		name     string
+		form     url.Values
+		values   url.Values
+		expected map[string]string
+	}{
+		{
+			name:     "Valid",
+			form:     url.Values{"name": {" Ada "}, "email": {"ada@example.com"}, "lists": {"news", "events"}, "admin": {"true"}},
+			values:   url.Values{"name": {"Ada"}, "email": {"ada@example.com"}, "lists": {"news", "events"}},
+			expected: map[string]string{},
+		},
+		{
+			name:     "Missing required",
+			form:     url.Values{"name": {"  "}, "message": {"Hello"}},
+			values:   url.Values{"message": {"Hello"}},
+			expected: map[string]string{"name": models.FieldRequired, "email": models.FieldRequired},
+		},
+		{
+			name:     "Invalid",
+			form:     url.Values{"name": {"Ada Lovelace, Countess of Lovelace"}, "email": {"Ada <ada@example.com>"}},
+			values:   url.Values{"name": {"Ada Lovelace, Countess of Lovelace"}, "email": {"Ada <ada@example.com>"}},
+			expected: map[string]string{"name": models.FieldLength, "email": models.FieldEmail},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values, errors := Validate(fields, tc.form)
+			if !reflect.DeepEqual(values, tc.values) {
+				t.Errorf("Expected values %v, got %v", tc.values, values)
+			}
+			if !reflect.DeepEqual(errors, tc.expected) {
+				t.Errorf("Expected errors %v, got %v", tc.expected, errors)
+			}
+		})
+	}
+}
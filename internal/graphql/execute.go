@@ -0,0 +1,653 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Request is a GraphQL request, as posted in JSON.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is the result of a request.  Data is omitted when the request
+// is rejected before execution.
+type Response struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []*Error        `json:"errors,omitempty"`
+}
+
+// Error is an error of a request, located in the query and, once executing,
+// at the path of the field that failed.
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// newError returns an error located in the query.
+func newError(loc Location, format string, args ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}}
+}
+
+// Execute runs the query of a request.  Fields whose resolver fails are
+// null in the data, with an error at their path.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		var syntaxErr *syntaxError
+		if errors.As(err, &syntaxErr) {
+			return &Response{Errors: []*Error{newError(syntaxErr.loc, "%s", syntaxErr.Error())}}
+		}
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	op, errs := selectOperation(doc, req.OperationName)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	vars, errs := s.coerceVariables(op, req.Variables)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	e := &executor{schema: s, doc: doc, vars: vars}
+	if errs := e.validate(op); len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+
+	data, _ := e.selectionSet(ctx, s.Query, nil, op.selections, nil)
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	return &Response{Data: raw, Errors: e.errors}
+}
+
+// selectOperation returns the query to execute.  The operation name is
+// required when the document has several operations.
+func selectOperation(doc *document, name string) (*operation, []*Error) {
+	var op *operation
+	for _, candidate := range doc.operations {
+		if name == "" && len(doc.operations) > 1 {
+			return nil, []*Error{{Message: "Must provide operation name if query contains multiple operations."}}
+		}
+		if name == "" || candidate.name == name {
+			op = candidate
+			break
+		}
+	}
+	if op == nil {
+		if name == "" {
+			return nil, []*Error{{Message: "Must provide an operation."}}
+		}
+		return nil, []*Error{{Message: fmt.Sprintf("Unknown operation named %q.", name)}}
+	}
+	if op.kind != "query" {
+		return nil, []*Error{newError(op.loc, "Only queries are supported, %s operations are not.", op.kind)}
+	}
+	return op, nil
+}
+
+// coerceVariables checks the values of the variables of an operation
+// against their declared types, applying defaults.
+func (s *Schema) coerceVariables(op *operation, values map[string]any) (map[string]any, []*Error) {
+	vars := make(map[string]any, len(op.variables))
+	var errs []*Error
+	for _, def := range op.variables {
+		t, ok := s.inputType(def.typ)
+		if !ok {
+			errs = append(errs, newError(def.loc, "Variable \"$%s\" cannot be of type %q.", def.name, def.typ))
+			continue
+		}
+		value, given := values[def.name]
+		if !given && def.defaultVal != nil {
+			v, err := coerceLiteral(def.defaultVal, t, nil)
+			if err != nil {
+				errs = append(errs, newError(def.loc, "Variable \"$%s\" has an invalid default value: %v", def.name, err))
+				continue
+			}
+			vars[def.name] = v
+			continue
+		}
+		if !given {
+			if _, nonNull := t.(*NonNullType); nonNull {
+				errs = append(errs, newError(def.loc, "Variable \"$%s\" of required type %q was not provided.", def.name, def.typ))
+			}
+			continue
+		}
+		v, err := coerceInput(value, t)
+		if err != nil {
+			errs = append(errs, newError(def.loc, "Variable \"$%s\" got invalid value: %v", def.name, err))
+			continue
+		}
+		vars[def.name] = v
+	}
+	return vars, errs
+}
+
+// coerceInput coerces a JSON value to an input type.
+func coerceInput(v any, t Type) (any, error) {
+	if nonNull, ok := t.(*NonNullType); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected non-null %s", t)
+		}
+		t = nonNull.OfType
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case *ListType:
+		items, ok := v.([]any)
+		if !ok {
+			items = []any{v}
+		}
+		list := make([]any, len(items))
+		for i, item := range items {
+			var err error
+			if list[i], err = coerceInput(item, t.OfType); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case *Scalar:
+		if parsed, ok := t.Parse(v); ok {
+			return parsed, nil
+		}
+	case *Enum:
+		if name, ok := v.(string); ok && t.has(name) {
+			return name, nil
+		}
+	}
+	return nil, fmt.Errorf("%s cannot represent %s", t, formatJSON(v))
+}
+
+// coerceLiteral coerces a value written in a query to an input type,
+// substituting variables.
+func coerceLiteral(v any, t Type, vars map[string]any) (any, error) {
+	if name, ok := v.(variable); ok {
+		value, given := vars[string(name)]
+		if _, nonNull := t.(*NonNullType); nonNull && (!given || value == nil) {
+			return nil, fmt.Errorf("expected non-null %s, found variable $%s", t, name)
+		}
+		return value, nil
+	}
+	if nonNull, ok := t.(*NonNullType); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected non-null %s, found null", t)
+		}
+		t = nonNull.OfType
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case *ListType:
+		items, ok := v.([]any)
+		if !ok {
+			items = []any{v}
+		}
+		list := make([]any, len(items))
+		for i, item := range items {
+			var err error
+			if list[i], err = coerceLiteral(item, t.OfType, vars); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case *Scalar:
+		if _, ok := v.(enumValue); !ok {
+			if parsed, ok := t.Parse(v); ok {
+				return parsed, nil
+			}
+		}
+	case *Enum:
+		if name, ok := v.(enumValue); ok && t.has(string(name)) {
+			return string(name), nil
+		}
+	}
+	return nil, fmt.Errorf("%s cannot represent %s", t, formatJSON(v))
+}
+
+// formatJSON formats a value in errors.
+func formatJSON(v any) string {
+	if name, ok := v.(enumValue); ok {
+		return string(name)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// executor holds the state of the execution of a query.
+type executor struct {
+	schema *Schema
+	doc    *document
+	vars   map[string]any
+	errors []*Error
+}
+
+// validate checks the fields, arguments and fragments selected by an
+// operation against the schema, along with its size.
+func (e *executor) validate(op *operation) []*Error {
+	v := &validator{executor: e}
+	v.selections(e.schema.Query, op.selections, 1, nil)
+	defined := make(map[string]bool)
+	for _, def := range op.variables {
+		if defined[def.name] {
+			v.errorf(def.loc, "There can be only one variable named \"$%s\".", def.name)
+		}
+		defined[def.name] = true
+	}
+	for _, name := range v.variables {
+		if !defined[name] {
+			v.errorf(op.loc, "Variable \"$%s\" is not defined.", name)
+		}
+	}
+	return v.errs
+}
+
+// validator walks the selections of an operation.
+type validator struct {
+	*executor
+	variables []string
+	fields    int
+	errs      []*Error
+}
+
+// errorf records a validation error.
+func (v *validator) errorf(loc Location, format string, args ...any) {
+	v.errs = append(v.errs, newError(loc, format, args...))
+}
+
+// selections validates the selections of an object at a depth, with the
+// fragments being spread to detect cycles.
+func (v *validator) selections(obj *Object, selections []selection, depth int, spreading []string) {
+	for _, sel := range selections {
+		if v.schema.MaxFields > 0 && v.fields > v.schema.MaxFields {
+			return
+		}
+		switch sel := sel.(type) {
+		case *field:
+			v.directives(sel.directives)
+			v.field(obj, sel, depth, spreading)
+		case *fragmentSpread:
+			v.directives(sel.directives)
+			frag, ok := v.doc.fragments[sel.name]
+			if !ok {
+				v.errorf(sel.loc, "Unknown fragment %q.", sel.name)
+				continue
+			}
+			for _, name := range spreading {
+				if name == sel.name {
+					v.errorf(sel.loc, "Cannot spread fragment %q within itself.", sel.name)
+					return
+				}
+			}
+			if frag.typeCondition != obj.Name {
+				v.typeCondition(frag.loc, frag.typeCondition, obj)
+				continue
+			}
+			v.selections(obj, frag.selections, depth, append(spreading, sel.name))
+		case *inlineFragment:
+			v.directives(sel.directives)
+			if sel.typeCondition != "" && sel.typeCondition != obj.Name {
+				v.typeCondition(sel.loc, sel.typeCondition, obj)
+				continue
+			}
+			v.selections(obj, sel.selections, depth, spreading)
+		}
+	}
+}
+
+// typeCondition reports a fragment on a type other than the object it is
+// spread in.  Without interfaces or unions, such fragments never apply.
+func (v *validator) typeCondition(loc Location, typeCondition string, obj *Object) {
+	if _, ok := v.schema.types[typeCondition]; !ok {
+		v.errorf(loc, "Unknown type %q.", typeCondition)
+		return
+	}
+	v.errorf(loc, "Fragment cannot be spread here as objects of type %q can never be of type %q.", obj.Name, typeCondition)
+}
+
+// field validates a selected field and its selections.
+func (v *validator) field(obj *Object, f *field, depth int, spreading []string) {
+	v.fields++
+	if v.schema.MaxFields > 0 && v.fields > v.schema.MaxFields {
+		v.errorf(f.loc, "Query selects more than %d fields.", v.schema.MaxFields)
+		return
+	}
+	if v.schema.MaxDepth > 0 && depth > v.schema.MaxDepth {
+		v.errorf(f.loc, "Query is nested deeper than %d fields.", v.schema.MaxDepth)
+		return
+	}
+	if f.name == "__typename" {
+		if len(f.arguments) > 0 || f.selections != nil {
+			v.errorf(f.loc, "Field \"__typename\" takes no arguments or selections.")
+		}
+		return
+	}
+	def := v.schema.lookupField(obj, f.name)
+	if def == nil {
+		v.errorf(f.loc, "Cannot query field %q on type %q.", f.name, obj.Name)
+		return
+	}
+
+	v.arguments(f.loc, fmt.Sprintf("%s.%s", obj.Name, f.name), def.Args, f.arguments)
+	child, isObject := namedType(def.Type).(*Object)
+	switch {
+	case isObject && f.selections == nil:
+		v.errorf(f.loc, "Field %q of type %q must have a selection of subfields.", f.name, def.Type)
+	case !isObject && f.selections != nil:
+		v.errorf(f.loc, "Field %q must not have a selection since type %q has no subfields.", f.name, def.Type)
+	case isObject:
+		v.selections(child, f.selections, depth+1, spreading)
+	}
+}
+
+// directives validates the @skip and @include directives of a selection.
+func (v *validator) directives(directives []*directive) {
+	ifArg := []*Argument{{Name: "if", Type: NonNull(Boolean)}}
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			v.errorf(d.loc, "Unknown directive \"@%s\".", d.name)
+			continue
+		}
+		v.arguments(d.loc, "@"+d.name, ifArg, d.arguments)
+	}
+}
+
+// arguments validates the arguments given to a field or directive.
+func (v *validator) arguments(loc Location, owner string, defs []*Argument, args []*argument) {
+	given := make(map[string]bool, len(args))
+	for _, arg := range args {
+		if given[arg.name] {
+			v.errorf(arg.loc, "There can be only one argument named %q.", arg.name)
+		}
+		given[arg.name] = true
+		v.collectVariables(arg.value)
+
+		var def *Argument
+		for _, candidate := range defs {
+			if candidate.Name == arg.name {
+				def = candidate
+			}
+		}
+		if def == nil {
+			v.errorf(arg.loc, "Unknown argument %q on %s.", arg.name, owner)
+			continue
+		}
+		if _, err := coerceLiteral(arg.value, def.Type, v.vars); err != nil {
+			v.errorf(arg.loc, "Argument %q of %s has an invalid value: %v.", arg.name, owner, err)
+		}
+	}
+	for _, def := range defs {
+		if _, nonNull := def.Type.(*NonNullType); nonNull && !given[def.Name] && def.Default == nil {
+			v.errorf(loc, "Argument %q of type %q is required on %s.", def.Name, def.Type, owner)
+		}
+	}
+}
+
+// collectVariables records the variables used by a value.
+func (v *validator) collectVariables(value any) {
+	switch value := value.(type) {
+	case variable:
+		v.variables = append(v.variables, string(value))
+	case []any:
+		for _, item := range value {
+			v.collectVariables(item)
+		}
+	case map[string]any:
+		for _, item := range value {
+			v.collectVariables(item)
+		}
+	}
+}
+
+// orderedMap is an object of the response, whose fields are serialized in
+// the order they were selected.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
+
+// collectFields groups the fields selected on an object by response key,
+// expanding fragments and applying directives.
+func (e *executor) collectFields(obj *Object, selections []selection, keys *[]string, fields map[string][]*field, visited map[string]bool) {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			if !e.included(sel.directives) {
+				continue
+			}
+			key := sel.responseKey()
+			if _, ok := fields[key]; !ok {
+				*keys = append(*keys, key)
+			}
+			fields[key] = append(fields[key], sel)
+		case *fragmentSpread:
+			if visited[sel.name] || !e.included(sel.directives) {
+				continue
+			}
+			visited[sel.name] = true
+			if frag := e.doc.fragments[sel.name]; frag.typeCondition == obj.Name {
+				e.collectFields(obj, frag.selections, keys, fields, visited)
+			}
+		case *inlineFragment:
+			if !e.included(sel.directives) || (sel.typeCondition != "" && sel.typeCondition != obj.Name) {
+				continue
+			}
+			e.collectFields(obj, sel.selections, keys, fields, visited)
+		}
+	}
+}
+
+// included applies the @skip and @include directives of a selection.
+func (e *executor) included(directives []*directive) bool {
+	for _, d := range directives {
+		for _, arg := range d.arguments {
+			value, _ := coerceLiteral(arg.value, NonNull(Boolean), e.vars)
+			if arg.name == "if" && value == (d.name == "skip") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// selectionSet resolves the fields selected on an object.  It reports
+// false if a non-null field is null, in which case the object is null.
+func (e *executor) selectionSet(ctx context.Context, obj *Object, source any, selections []selection, path []any) (*orderedMap, bool) {
+	var keys []string
+	fields := make(map[string][]*field)
+	e.collectFields(obj, selections, &keys, fields, make(map[string]bool))
+
+	result := &orderedMap{keys: keys, values: make(map[string]any, len(keys))}
+	for _, key := range keys {
+		f := fields[key][0]
+		fieldPath := append(path[:len(path):len(path)], key)
+		if f.name == "__typename" {
+			result.values[key] = obj.Name
+			continue
+		}
+
+		def := e.schema.lookupField(obj, f.name)
+		value, err := e.resolve(ctx, def, source, f)
+		if err != nil {
+			e.errors = append(e.errors, &Error{Message: err.Error(), Locations: []Location{f.loc}, Path: fieldPath})
+			if _, nonNull := def.Type.(*NonNullType); nonNull {
+				return nil, false
+			}
+			result.values[key] = nil
+			continue
+		}
+
+		// Fields selected several times under one key merge their
+		// selections
+		var subselections []selection
+		for _, same := range fields[key] {
+			subselections = append(subselections, same.selections...)
+		}
+		completed, ok := e.complete(ctx, def.Type, value, subselections, fieldPath, f.loc)
+		if !ok {
+			return nil, false
+		}
+		result.values[key] = completed
+	}
+	return result, true
+}
+
+// resolve calls the resolver of a field with its coerced arguments.
+func (e *executor) resolve(ctx context.Context, def *Field, source any, f *field) (any, error) {
+	args := make(map[string]any, len(def.Args))
+	for _, argDef := range def.Args {
+		if argDef.Default != nil {
+			args[argDef.Name] = argDef.Default
+		}
+	}
+	for _, arg := range f.arguments {
+		argDef := def.argument(arg.name)
+		if name, ok := arg.value.(variable); ok {
+			if _, given := e.vars[string(name)]; !given {
+				continue
+			}
+		}
+		value, err := coerceLiteral(arg.value, argDef.Type, e.vars)
+		if err != nil {
+			return nil, err
+		}
+		args[arg.name] = value
+	}
+	if def.Resolve == nil {
+		return defaultResolve(source, def.Name), nil
+	}
+	return def.Resolve(ctx, source, args)
+}
+
+// complete serializes the value of a field of a type.  It reports false
+// if a non-null value is null, which makes its parent null.
+func (e *executor) complete(ctx context.Context, t Type, value any, selections []selection, path []any, loc Location) (any, bool) {
+	nonNull, ok := t.(*NonNullType)
+	if !ok {
+		// Nullable values are null when one of their non-null fields is
+		completed, ok := e.completeNullable(ctx, t, value, selections, path, loc)
+		if !ok {
+			return nil, true
+		}
+		return completed, true
+	}
+	completed, ok := e.completeNullable(ctx, nonNull.OfType, value, selections, path, loc)
+	if !ok {
+		return nil, false
+	}
+	if completed == nil {
+		e.errors = append(e.errors, &Error{Message: fmt.Sprintf("Cannot return null for non-nullable field at %s.", formatPath(path)), Locations: []Location{loc}, Path: path})
+		return nil, false
+	}
+	return completed, true
+}
+
+// completeNullable serializes a value of a nullable type.  It reports
+// false once an error has been recorded for the value.
+func (e *executor) completeNullable(ctx context.Context, t Type, value any, selections []selection, path []any, loc Location) (any, bool) {
+	if isNil(value) {
+		return nil, true
+	}
+
+	switch t := t.(type) {
+	case *ListType:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.errors = append(e.errors, &Error{Message: fmt.Sprintf("Expected a list at %s.", formatPath(path)), Locations: []Location{loc}, Path: path})
+			return nil, false
+		}
+		list := make([]any, rv.Len())
+		for i := range list {
+			item, ok := e.complete(ctx, t.OfType, rv.Index(i).Interface(), selections, append(path[:len(path):len(path)], i), loc)
+			if !ok {
+				return nil, false
+			}
+			list[i] = item
+		}
+		return list, true
+	case *Object:
+		result, ok := e.selectionSet(ctx, t, value, selections, path)
+		if !ok {
+			return nil, false
+		}
+		return result, true
+	case *Scalar:
+		if serialized, ok := t.Serialize(value); ok {
+			return serialized, true
+		}
+	case *Enum:
+		if name, ok := serializeString(value); ok && t.has(name.(string)) {
+			return name, true
+		}
+	}
+	e.errors = append(e.errors, &Error{Message: fmt.Sprintf("%s cannot represent %v.", t, value), Locations: []Location{loc}, Path: path})
+	return nil, false
+}
+
+// isNil reports whether a value is nil, including nil pointers, maps and
+// slices.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// formatPath formats the path of a field in errors.
+func formatPath(path []any) string {
+	var b strings.Builder
+	for i, segment := range path {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		fmt.Fprint(&b, segment)
+	}
+	return b.String()
+}
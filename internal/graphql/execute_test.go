@@ -0,0 +1,272 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testPage is resolved by the default resolver of the test schema.
+type testPage struct {
+	ID       int         `json:"id"`
+	Title    string      `json:"title"`
+	Children []*testPage `json:"children"`
+}
+
+// testSchema returns a schema of pages for testing.
+func testSchema(t *testing.T) *Schema {
+	color := &Enum{Name: "Color", Values: []string{"RED", "BLUE"}}
+	page := &Object{Name: "Page", Description: "A page."}
+	page.Fields = []*Field{
+		{Name: "id", Type: NonNull(ID)},
+		{Name: "title", Type: String},
+		{Name: "children", Type: List(NonNull(page))},
+		{Name: "color", Type: color, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return "GREEN", nil
+		}},
+		{Name: "broken", Type: NonNull(String), Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return nil, errors.New("broken")
+		}},
+	}
+	pages := map[string]*testPage{
+		"/": {ID: 1, Title: "Home", Children: []*testPage{{ID: 2, Title: "About"}}},
+	}
+
+	schema, err := NewSchema(&Object{
+		Name: "Query",
+		Fields: []*Field{
+			{
+				Name: "page",
+				Type: page,
+				Args: []*Argument{{Name: "path", Type: NonNull(String)}},
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					if p, ok := pages[args["path"].(string)]; ok {
+						return p, nil
+					}
+					return nil, nil
+				},
+			},
+			{
+				Name: "echo",
+				Type: String,
+				Args: []*Argument{
+					{Name: "text", Type: String, Default: "default"},
+					{Name: "times", Type: Int, Default: 1},
+					{Name: "tags", Type: List(String)},
+					{Name: "color", Type: color},
+				},
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					data, err := json.Marshal(args)
+					return string(data), err
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+// execute runs a query and encodes its response.
+func execute(schema *Schema, req Request) string {
+	data, _ := json.Marshal(schema.Execute(context.Background(), req))
+	return string(data)
+}
+
+func TestExecute(t *testing.T) {
+	schema := testSchema(t)
+
+	testCases := []struct {
+		name     string
+		req      Request
+		expected string
+	}{
+		{
+			name:     "Fields in selection order",
+			req:      Request{Query: `{ page(path: "/") { title id __typename } }`},
+			expected: `{"data":{"page":{"title":"Home","id":"1","__typename":"Page"}}}`,
+		},
+		{
+			name:     "Aliases and nested lists",
+			req:      Request{Query: `{ home: page(path: "/") { children { id title children { id } } } missing: page(path: "/missing") { id } }`},
+			expected: `{"data":{"home":{"children":[{"id":"2","title":"About","children":null}]},"missing":null}}`,
+		},
+		{
+			name:     "Fragments merge fields",
+			req:      Request{Query: `{ page(path: "/") { ...a ... on Page { id } ... @skip(if: true) { title } } } fragment a on Page { id children { id } children { title } }`},
+			expected: `{"data":{"page":{"id":"1","children":[{"id":"2","title":"About"}]}}}`,
+		},
+		{
+			name: "Variables and defaults",
+			req: Request{
+				Query:     `query ($text: String, $show: Boolean = true, $tags: [String]) { echo(text: $text, tags: $tags, color: BLUE) @include(if: $show) }`,
+				Variables: map[string]any{"tags": "single"},
+			},
+			expected: `{"data":{"echo":"{\"color\":\"BLUE\",\"tags\":[\"single\"],\"text\":\"default\",\"times\":1}"}}`,
+		},
+		{
+			name: "Integral floats are ints",
+			req: Request{
+				Query:     `query ($times: Int) { echo(text: null, times: $times) }`,
+				Variables: map[string]any{"times": 3.0},
+			},
+			expected: `{"data":{"echo":"{\"text\":null,\"times\":3}"}}`,
+		},
+		{
+			name:     "Operation name",
+			req:      Request{Query: `query A { echo } query B { page(path: "/") { id } }`, OperationName: "B"},
+			expected: `{"data":{"page":{"id":"1"}}}`,
+		},
+		{
+			name:     "Non-null errors null the parent",
+			req:      Request{Query: `{ page(path: "/") { id broken } echo }`},
+			expected: `{"data":{"page":null,"echo":"{\"text\":\"default\",\"times\":1}"},"errors":[{"message":"broken","locations":[{"line":1,"column":24}],"path":["page","broken"]}]}`,
+		},
+		{
+			name:     "Invalid enum values",
+			req:      Request{Query: `{ page(path: "/") { color } }`},
+			expected: `{"data":{"page":{"color":null}},"errors":[{"message":"Color cannot represent GREEN.","locations":[{"line":1,"column":21}],"path":["page","color"]}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := execute(schema, tc.req); actual != tc.expected {
+				t.Errorf("Expected\n%s\ngot\n%s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExecuteErrors(t *testing.T) {
+	schema := testSchema(t)
+	schema.MaxDepth = 3
+	schema.MaxFields = 10
+
+	testCases := []struct {
+		name    string
+		req     Request
+		message string
+	}{
+		{name: "Syntax", req: Request{Query: `{ page(`}, message: "Syntax Error: Expected Name, found <EOF>."},
+		{name: "Mutation", req: Request{Query: `mutation { echo }`}, message: "Only queries are supported, mutation operations are not."},
+		{name: "Several operations", req: Request{Query: `query A { echo } query B { echo }`}, message: "Must provide operation name if query contains multiple operations."},
+		{name: "Unknown operation", req: Request{Query: `query A { echo }`, OperationName: "B"}, message: `Unknown operation named "B".`},
+		{name: "Unknown field", req: Request{Query: `{ pages }`}, message: `Cannot query field "pages" on type "Query".`},
+		{name: "Unknown argument", req: Request{Query: `{ echo(txt: "a") }`}, message: `Unknown argument "txt" on Query.echo.`},
+		{name: "Missing argument", req: Request{Query: `{ page { id } }`}, message: `Argument "path" of type "String!" is required on Query.page.`},
+		{name: "Invalid argument", req: Request{Query: `{ echo(times: "a") }`}, message: `Argument "times" of Query.echo has an invalid value: Int cannot represent "a".`},
+		{name: "Missing selection", req: Request{Query: `{ page(path: "/") }`}, message: `Field "page" of type "Page" must have a selection of subfields.`},
+		{name: "Selection on scalar", req: Request{Query: `{ echo { id } }`}, message: `Field "echo" must not have a selection since type "String" has no subfields.`},
+		{name: "Unknown fragment", req: Request{Query: `{ page(path: "/") { ...missing } }`}, message: `Unknown fragment "missing".`},
+		{name: "Fragment cycle", req: Request{Query: `{ page(path: "/") { ...a } } fragment a on Page { children { ...a } }`}, message: `Cannot spread fragment "a" within itself.`},
+		{name: "Fragment type", req: Request{Query: `{ page(path: "/") { ... on Query { echo } } }`}, message: `Fragment cannot be spread here as objects of type "Page" can never be of type "Query".`},
+		{name: "Unknown directive", req: Request{Query: `{ echo @cached }`}, message: `Unknown directive "@cached".`},
+		{name: "Undefined variable", req: Request{Query: `{ echo(text: $text) }`}, message: `Variable "$text" is not defined.`},
+		{name: "Missing variable", req: Request{Query: `query ($path: String!) { page(path: $path) { id } }`}, message: `Variable "$path" of required type "String!" was not provided.`},
+		{name: "Invalid variable", req: Request{Query: `query ($times: Int) { echo(times: $times) }`, Variables: map[string]any{"times": 1.5}}, message: `Variable "$times" got invalid value: Int cannot represent 1.5`},
+		{name: "Object variable", req: Request{Query: `query ($page: Page) { echo }`}, message: `Variable "$page" cannot be of type "Page".`},
+		{name: "Depth", req: Request{Query: `{ page(path: "/") { children { children { id } } } }`}, message: "Query is nested deeper than 3 fields."},
+		{name: "Fields", req: Request{Query: `{ a: echo b: echo c: echo d: echo e: echo f: echo g: echo h: echo i: echo j: echo k: echo }`}, message: "Query selects more than 10 fields."},
+		{name: "Fragment fan out", req: Request{Query: `{ ...a ...a } fragment a on Query { b: echo ...b ...b } fragment b on Query { c: echo ...c ...c } fragment c on Query { d: echo e: echo }`}, message: "Query selects more than 10 fields."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := schema.Execute(context.Background(), tc.req)
+			if resp.Data != nil {
+				t.Errorf("Expected no data, got %s", resp.Data)
+			}
+			if len(resp.Errors) == 0 || resp.Errors[0].Message != tc.message {
+				t.Errorf("Expected error %q, got %+v", tc.message, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	schema := testSchema(t)
+
+	var resp struct {
+		Data struct {
+			Schema struct {
+				QueryType struct{ Name string }
+				Types     []struct {
+					Kind string
+					Name string
+				}
+			} `json:"__schema"`
+			Type struct {
+				Name   string
+				Fields []struct {
+					Name string
+					Args []struct {
+						Name         string
+						DefaultValue *string
+					}
+					Type struct {
+						Kind   string
+						OfType struct{ Name string }
+					}
+				}
+			} `json:"__type"`
+		}
+		Errors []*Error
+	}
+	data := execute(schema, Request{Query: `{
+		__schema { queryType { name } types { kind name } }
+		__type(name: "Query") { name fields { name args { name defaultValue } type { kind ofType { name } } } }
+	}`})
+	if err := json.Unmarshal([]byte(data), &resp); err != nil || len(resp.Errors) > 0 {
+		t.Fatalf("Expected an introspection result, got %s", data)
+	}
+
+	if resp.Data.Schema.QueryType.Name != "Query" {
+		t.Errorf("Expected the Query type, got %+v", resp.Data.Schema.QueryType)
+	}
+	kinds := map[string]string{}
+	for _, typ := range resp.Data.Schema.Types {
+		kinds[typ.Name] = typ.Kind
+	}
+	for name, kind := range map[string]string{"Query": "OBJECT", "Page": "OBJECT", "Color": "ENUM", "String": "SCALAR", "__Schema": "OBJECT", "__TypeKind": "ENUM"} {
+		if kinds[name] != kind {
+			t.Errorf("Expected type %s of kind %s, got %q", name, kind, kinds[name])
+		}
+	}
+
+	fields := resp.Data.Type.Fields
+	if len(fields) != 2 || fields[0].Name != "page" || fields[0].Type.Kind != "OBJECT" {
+		t.Fatalf("Expected the fields of Query, got %+v", fields)
+	}
+	echo := fields[1]
+	if len(echo.Args) != 4 || *echo.Args[0].DefaultValue != `"default"` || *echo.Args[1].DefaultValue != "1" || echo.Args[2].DefaultValue != nil {
+		t.Errorf("Expected the arguments of echo with their defaults, got %+v", echo.Args)
+	}
+}
+
+func TestNewSchema(t *testing.T) {
+	page := &Object{Name: "Page", Fields: []*Field{{Name: "id", Type: ID}}}
+	other := &Object{Name: "Page", Fields: []*Field{{Name: "title", Type: String}}}
+
+	testCases := []struct {
+		name  string
+		query *Object
+		err   string
+	}{
+		{name: "Query name", query: &Object{Name: "Root", Fields: []*Field{{Name: "id", Type: ID}}}, err: "must be named Query"},
+		{name: "Duplicate types", query: &Object{Name: "Query", Fields: []*Field{{Name: "a", Type: page}, {Name: "b", Type: other}}}, err: "two types are named Page"},
+		{name: "Empty object", query: &Object{Name: "Query", Fields: []*Field{{Name: "a", Type: &Object{Name: "Empty"}}}}, err: "has no fields"},
+		{name: "Object argument", query: &Object{Name: "Query", Fields: []*Field{{Name: "a", Type: ID, Args: []*Argument{{Name: "page", Type: page}}}}}, err: "is an object"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewSchema(tc.query); err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
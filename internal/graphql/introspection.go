@@ -0,0 +1,233 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Directives supported in queries.
+var directives = []struct {
+	name          string
+	description   string
+	ifDescription string
+}{
+	{name: "include", description: "Directs the executor to include this field or fragment only when the `if` argument is true.", ifDescription: "Included when true."},
+	{name: "skip", description: "Directs the executor to skip this field or fragment when the `if` argument is true.", ifDescription: "Skipped when true."},
+}
+
+// introspection returns the __Schema and __Type objects describing a
+// schema, resolved from its Go types.
+func introspection(s *Schema) (*Object, *Object) {
+	typeKind := &Enum{
+		Name:        "__TypeKind",
+		Description: "An enum describing what kind of type a given `__Type` is.",
+		Values:      []string{"SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "LIST", "NON_NULL"},
+	}
+	directiveLocation := &Enum{
+		Name:        "__DirectiveLocation",
+		Description: "A Directive can be adjacent to many parts of the GraphQL language.",
+		Values:      []string{"QUERY", "FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+	}
+	typeType := &Object{Name: "__Type", Description: "The fundamental unit of any GraphQL Schema is the type."}
+	fieldType := &Object{Name: "__Field", Description: "Object and Interface types are described by a list of Fields, each of which has a name, potentially a list of arguments, and a return type."}
+	inputValueType := &Object{Name: "__InputValue", Description: "Arguments provided to Fields or Directives are represented as Input Values."}
+	enumValueType := &Object{Name: "__EnumValue", Description: "One possible value for a given Enum."}
+	directiveType := &Object{Name: "__Directive", Description: "A Directive provides a way to describe alternate runtime execution and type validation behavior in a GraphQL document."}
+	schemaType := &Object{Name: "__Schema", Description: "A GraphQL Schema defines the capabilities of a GraphQL server."}
+
+	constant := func(v any) ResolveFunc {
+		return func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return v, nil
+		}
+	}
+	resolve := func(f func(source any) any) ResolveFunc {
+		return func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return f(source), nil
+		}
+	}
+	includeDeprecated := []*Argument{{Name: "includeDeprecated", Type: Boolean, Default: false}}
+	deprecation := []*Field{
+		{Name: "isDeprecated", Type: NonNull(Boolean), Resolve: constant(false)},
+		{Name: "deprecationReason", Type: String, Resolve: constant(nil)},
+	}
+
+	schemaType.Fields = []*Field{
+		{Name: "description", Type: String, Resolve: constant(nil)},
+		{Name: "types", Description: "A list of all types supported by this server.", Type: NonNull(List(NonNull(typeType))), Resolve: resolve(func(source any) any {
+			names := append([]string(nil), s.typeNames...)
+			sort.Strings(names)
+			types := make([]Type, len(names))
+			for i, name := range names {
+				types[i] = s.types[name]
+			}
+			return types
+		})},
+		{Name: "queryType", Description: "The type that query operations will be rooted at.", Type: NonNull(typeType), Resolve: constant(s.Query)},
+		{Name: "mutationType", Type: typeType, Resolve: constant(nil)},
+		{Name: "subscriptionType", Type: typeType, Resolve: constant(nil)},
+		{Name: "directives", Description: "A list of all directives supported by this server.", Type: NonNull(List(NonNull(directiveType))), Resolve: resolve(func(source any) any {
+			names := make([]string, len(directives))
+			for i, d := range directives {
+				names[i] = d.name
+			}
+			return names
+		})},
+	}
+
+	typeType.Fields = []*Field{
+		{Name: "kind", Type: NonNull(typeKind), Resolve: resolve(func(source any) any {
+			switch source.(type) {
+			case *Scalar:
+				return "SCALAR"
+			case *Enum:
+				return "ENUM"
+			case *Object:
+				return "OBJECT"
+			case *ListType:
+				return "LIST"
+			}
+			return "NON_NULL"
+		})},
+		{Name: "name", Type: String, Resolve: resolve(func(source any) any {
+			switch source.(type) {
+			case *ListType, *NonNullType:
+				return nil
+			}
+			return source.(Type).String()
+		})},
+		{Name: "description", Type: String, Resolve: resolve(func(source any) any {
+			switch t := source.(type) {
+			case *Scalar:
+				return optional(t.Description)
+			case *Enum:
+				return optional(t.Description)
+			case *Object:
+				return optional(t.Description)
+			}
+			return nil
+		})},
+		{Name: "specifiedByURL", Type: String, Resolve: constant(nil)},
+		{Name: "fields", Type: List(NonNull(fieldType)), Args: includeDeprecated, Resolve: resolve(func(source any) any {
+			if obj, ok := source.(*Object); ok {
+				return obj.Fields
+			}
+			return nil
+		})},
+		{Name: "interfaces", Type: List(NonNull(typeType)), Resolve: resolve(func(source any) any {
+			if _, ok := source.(*Object); ok {
+				return []Type{}
+			}
+			return nil
+		})},
+		{Name: "possibleTypes", Type: List(NonNull(typeType)), Resolve: constant(nil)},
+		{Name: "enumValues", Type: List(NonNull(enumValueType)), Args: includeDeprecated, Resolve: resolve(func(source any) any {
+			if e, ok := source.(*Enum); ok {
+				return e.Values
+			}
+			return nil
+		})},
+		{Name: "inputFields", Type: List(NonNull(inputValueType)), Args: includeDeprecated, Resolve: constant(nil)},
+		{Name: "ofType", Type: typeType, Resolve: resolve(func(source any) any {
+			switch t := source.(type) {
+			case *ListType:
+				return t.OfType
+			case *NonNullType:
+				return t.OfType
+			}
+			return nil
+		})},
+		{Name: "isOneOf", Type: Boolean, Resolve: constant(nil)},
+	}
+
+	fieldType.Fields = append([]*Field{
+		{Name: "name", Type: NonNull(String), Resolve: resolve(func(source any) any { return source.(*Field).Name })},
+		{Name: "description", Type: String, Resolve: resolve(func(source any) any { return optional(source.(*Field).Description) })},
+		{Name: "args", Type: NonNull(List(NonNull(inputValueType))), Args: includeDeprecated, Resolve: resolve(func(source any) any {
+			if args := source.(*Field).Args; args != nil {
+				return args
+			}
+			return []*Argument{}
+		})},
+		{Name: "type", Type: NonNull(typeType), Resolve: resolve(func(source any) any { return source.(*Field).Type })},
+	}, deprecation...)
+
+	inputValueType.Fields = append([]*Field{
+		{Name: "name", Type: NonNull(String), Resolve: resolve(func(source any) any { return source.(*Argument).Name })},
+		{Name: "description", Type: String, Resolve: resolve(func(source any) any { return optional(source.(*Argument).Description) })},
+		{Name: "type", Type: NonNull(typeType), Resolve: resolve(func(source any) any { return source.(*Argument).Type })},
+		{Name: "defaultValue", Description: "A GraphQL-formatted string representing the default value for this input value.", Type: String, Resolve: resolve(func(source any) any {
+			arg := source.(*Argument)
+			if arg.Default == nil {
+				return nil
+			}
+			return formatValue(arg.Default, arg.Type)
+		})},
+	}, deprecation...)
+
+	enumValueType.Fields = append([]*Field{
+		{Name: "name", Type: NonNull(String), Resolve: resolve(func(source any) any { return source })},
+		{Name: "description", Type: String, Resolve: constant(nil)},
+	}, deprecation...)
+
+	directiveType.Fields = []*Field{
+		{Name: "name", Type: NonNull(String), Resolve: resolve(func(source any) any { return source })},
+		{Name: "description", Type: String, Resolve: resolve(func(source any) any {
+			for _, d := range directives {
+				if d.name == source {
+					return d.description
+				}
+			}
+			return nil
+		})},
+		{Name: "isRepeatable", Type: NonNull(Boolean), Resolve: constant(false)},
+		{Name: "locations", Type: NonNull(List(NonNull(directiveLocation))), Resolve: constant([]string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"})},
+		{Name: "args", Type: NonNull(List(NonNull(inputValueType))), Args: includeDeprecated, Resolve: resolve(func(source any) any {
+			for _, d := range directives {
+				if d.name == source {
+					return []*Argument{{Name: "if", Description: d.ifDescription, Type: NonNull(Boolean)}}
+				}
+			}
+			return []*Argument{}
+		})},
+	}
+
+	return schemaType, typeType
+}
+
+// optional returns nil for an empty description.
+func optional(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// formatValue formats a default value as a GraphQL literal.
+func formatValue(v any, t Type) string {
+	if nonNull, ok := t.(*NonNullType); ok {
+		t = nonNull.OfType
+	}
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		if _, ok := t.(*Enum); ok {
+			return v
+		}
+		return strconv.Quote(v)
+	case []any:
+		var elem Type = String
+		if list, ok := t.(*ListType); ok {
+			elem = list.OfType
+		}
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatValue(item, elem)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
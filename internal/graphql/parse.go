@@ -0,0 +1,756 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Location is a line and column of a query, counted from 1.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// document is a parsed query document.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation is an operation of a document.  Only queries are executed.
+type operation struct {
+	kind       string
+	name       string
+	variables  []*variableDefinition
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+// variableDefinition declares a variable of an operation.
+type variableDefinition struct {
+	name       string
+	typ        *typeRef
+	defaultVal any
+	loc        Location
+}
+
+// typeRef is the type of a variable as written in a query.
+type typeRef struct {
+	name    string
+	elem    *typeRef
+	nonNull bool
+}
+
+// String returns the type as written in a query.
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// fragment is a named fragment of a document.
+type fragment struct {
+	name          string
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+	loc           Location
+}
+
+// selection is a field, fragment spread or inline fragment.
+type selection interface {
+	location() Location
+}
+
+// field selects a field of an object.
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+// fragmentSpread includes a named fragment.
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	loc        Location
+}
+
+// inlineFragment includes selections, optionally on a type condition.
+type inlineFragment struct {
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+	loc           Location
+}
+
+func (f *field) location() Location          { return f.loc }
+func (f *fragmentSpread) location() Location { return f.loc }
+func (f *inlineFragment) location() Location { return f.loc }
+
+// responseKey returns the key of the field in the response.
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// directive is a directive such as @skip(if: true).
+type directive struct {
+	name      string
+	arguments []*argument
+	loc       Location
+}
+
+// argument is an argument of a field or directive.
+type argument struct {
+	name  string
+	value any
+	loc   Location
+}
+
+// Values of arguments are parsed into strings, ints, float64s, bools, nil,
+// []any and map[string]any, with the following types for variables and
+// enum values.
+type (
+	variable  string
+	enumValue string
+)
+
+// Kinds of tokens.
+const (
+	tokenEOF = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+// token is a lexical token of a query.
+type token struct {
+	kind  int
+	value string
+	loc   Location
+}
+
+// String describes the token in syntax errors.
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "<EOF>"
+	case tokenString:
+		return strconv.Quote(t.value)
+	}
+	return t.value
+}
+
+// syntaxError is an error in the syntax of a query.
+type syntaxError struct {
+	message string
+	loc     Location
+}
+
+func (e *syntaxError) Error() string {
+	return "Syntax Error: " + e.message
+}
+
+// lexer splits a query into tokens.
+type lexer struct {
+	src       string
+	pos       int
+	line      int
+	lineStart int
+}
+
+// next returns the next token of the query.
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	loc := Location{Line: l.line, Column: l.pos - l.lineStart + 1}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, loc: loc}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$&()=:@[]{}|", c) >= 0:
+		l.pos++
+		return token{kind: tokenPunctuator, value: string(c), loc: loc}, nil
+	case c == '.':
+		if strings.HasPrefix(l.src[l.pos:], "...") {
+			l.pos += 3
+			return token{kind: tokenPunctuator, value: "...", loc: loc}, nil
+		}
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], loc: loc}, nil
+	case c == '-' || isDigit(c):
+		return l.number(loc)
+	case c == '"':
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			return token{}, &syntaxError{message: "Block strings are not supported.", loc: loc}
+		}
+		return l.string(loc)
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, &syntaxError{message: fmt.Sprintf("Unexpected character %q.", r), loc: loc}
+}
+
+// skipIgnored skips whitespace, commas and comments.
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case '\n':
+			l.pos++
+			l.line++
+			l.lineStart = l.pos
+		case ' ', '\t', '\r', ',':
+			l.pos++
+		case '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			if strings.HasPrefix(l.src[l.pos:], "\uFEFF") {
+				l.pos += len("\uFEFF")
+				continue
+			}
+			return
+		}
+	}
+}
+
+// number reads an int or float token.
+func (l *lexer) number(loc Location) (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	digits := func() bool {
+		from := l.pos
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		return l.pos > from
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '0' {
+		l.pos++
+	} else if !digits() {
+		return token{}, &syntaxError{message: "Invalid number.", loc: loc}
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		if !digits() {
+			return token{}, &syntaxError{message: "Invalid number.", loc: loc}
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if !digits() {
+			return token{}, &syntaxError{message: "Invalid number.", loc: loc}
+		}
+	}
+	if l.pos < len(l.src) && (isDigit(l.src[l.pos]) || isLetter(l.src[l.pos]) || l.src[l.pos] == '_' || l.src[l.pos] == '.') {
+		return token{}, &syntaxError{message: "Invalid number.", loc: loc}
+	}
+	return token{kind: kind, value: l.src[start:l.pos], loc: loc}, nil
+}
+
+// string reads a quoted string token, decoding its escape sequences.
+func (l *lexer) string(loc Location) (token, error) {
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: tokenString, value: b.String(), loc: loc}, nil
+		case c == '\n' || c == '\r':
+			return token{}, &syntaxError{message: "Unterminated string.", loc: loc}
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, &syntaxError{message: "Unterminated string.", loc: loc}
+			}
+			escape := l.src[l.pos+1]
+			l.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				b.WriteByte(escape)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.src) {
+					return token{}, &syntaxError{message: "Invalid unicode escape sequence.", loc: loc}
+				}
+				code, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return token{}, &syntaxError{message: "Invalid unicode escape sequence.", loc: loc}
+				}
+				b.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				return token{}, &syntaxError{message: fmt.Sprintf("Invalid escape sequence \\%c.", escape), loc: loc}
+			}
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	return token{}, &syntaxError{message: "Unterminated string.", loc: loc}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parser builds a document from the tokens of a query.
+type parser struct {
+	lexer lexer
+	tok   token
+}
+
+// parse parses a query document.
+func parse(query string) (*document, error) {
+	p := &parser{lexer: lexer{src: query, line: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: make(map[string]*fragment)}
+	if p.tok.kind == tokenEOF {
+		return nil, p.unexpected()
+	}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: selections, loc: selections[0].location()})
+		case p.tok.kind == tokenName && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.tok.kind == tokenName && p.tok.value == "fragment":
+			frag, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[frag.name]; ok {
+				return nil, &syntaxError{message: fmt.Sprintf("There can be only one fragment named %q.", frag.name), loc: frag.loc}
+			}
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	return doc, nil
+}
+
+// advance reads the next token.
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+// peek reports whether the current token is the given punctuator.
+func (p *parser) peek(punctuator string) bool {
+	return p.tok.kind == tokenPunctuator && p.tok.value == punctuator
+}
+
+// expect consumes the given punctuator.
+func (p *parser) expect(punctuator string) error {
+	if !p.peek(punctuator) {
+		return &syntaxError{message: fmt.Sprintf("Expected %q, found %s.", punctuator, p.tok), loc: p.tok.loc}
+	}
+	return p.advance()
+}
+
+// skip consumes the given punctuator if it is the current token.
+func (p *parser) skip(punctuator string) (bool, error) {
+	if !p.peek(punctuator) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+// name consumes a name.
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", &syntaxError{message: fmt.Sprintf("Expected Name, found %s.", p.tok), loc: p.tok.loc}
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+// unexpected returns an error for the current token.
+func (p *parser) unexpected() error {
+	return &syntaxError{message: fmt.Sprintf("Unexpected %s.", p.tok), loc: p.tok.loc}
+}
+
+// operation parses an operation with its keyword.
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.tok.value, loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var err error
+	if p.tok.kind == tokenName {
+		if op.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		if op.variables, err = p.variableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+	if op.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if op.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// variableDefinitions parses the variables declared by an operation.
+func (p *parser) variableDefinitions() ([]*variableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if p.peek(")") {
+		return nil, p.unexpected()
+	}
+	var defs []*variableDefinition
+	for {
+		if done, err := p.skip(")"); err != nil || done {
+			return defs, err
+		}
+		def := &variableDefinition{loc: p.tok.loc}
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		var err error
+		if def.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if def.typ, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if def.defaultVal, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+}
+
+// typeRef parses the type of a variable.
+func (p *parser) typeRef() (*typeRef, error) {
+	t := &typeRef{}
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		if t.elem, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	} else if t.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	var err error
+	t.nonNull, err = p.skip("!")
+	return t, err
+}
+
+// fragment parses a named fragment definition.
+func (p *parser) fragment() (*fragment, error) {
+	frag := &fragment{loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var err error
+	if frag.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if frag.name == "on" {
+		return nil, &syntaxError{message: `Unexpected Name "on".`, loc: frag.loc}
+	}
+	if p.tok.kind != tokenName || p.tok.value != "on" {
+		return nil, &syntaxError{message: fmt.Sprintf(`Expected "on", found %s.`, p.tok), loc: p.tok.loc}
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if frag.typeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if frag.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if frag.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+// selectionSet parses a non-empty selection set in braces.
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	if p.peek("}") {
+		return nil, &syntaxError{message: fmt.Sprintf("Expected Name, found %s.", p.tok), loc: p.tok.loc}
+	}
+	var selections []selection
+	for {
+		if done, err := p.skip("}"); err != nil || done {
+			return selections, err
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+}
+
+// selection parses a field or fragment.
+func (p *parser) selection() (selection, error) {
+	loc := p.tok.loc
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			spread := &fragmentSpread{loc: loc}
+			if spread.name, err = p.name(); err != nil {
+				return nil, err
+			}
+			if spread.directives, err = p.directives(); err != nil {
+				return nil, err
+			}
+			return spread, nil
+		}
+		inline := &inlineFragment{loc: loc}
+		if p.tok.kind == tokenName {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if inline.typeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if inline.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if inline.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return inline, nil
+	}
+
+	f := &field{loc: loc}
+	var err error
+	if f.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = f.name
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if f.arguments, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// arguments parses the optional arguments of a field or directive.
+func (p *parser) arguments(constant bool) ([]*argument, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+	if p.peek(")") {
+		return nil, p.unexpected()
+	}
+	var args []*argument
+	for {
+		if done, err := p.skip(")"); err != nil || done {
+			return args, err
+		}
+		arg := &argument{loc: p.tok.loc}
+		var err error
+		if arg.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if arg.value, err = p.value(constant); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+}
+
+// directives parses the optional directives of a definition or selection.
+func (p *parser) directives() ([]*directive, error) {
+	var directives []*directive
+	for p.peek("@") {
+		d := &directive{loc: p.tok.loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err error
+		if d.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if d.arguments, err = p.arguments(false); err != nil {
+			return nil, err
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+// value parses a value.  Constant values may not contain variables.
+func (p *parser) value(constant bool) (any, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenPunctuator:
+		switch tok.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected()
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			return variable(name), err
+		case "[":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			list := []any{}
+			for !p.peek("]") {
+				if p.tok.kind == tokenEOF {
+					return nil, p.unexpected()
+				}
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			return list, p.advance()
+		case "{":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			obj := map[string]any{}
+			for !p.peek("}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if obj[name], err = p.value(constant); err != nil {
+					return nil, err
+				}
+			}
+			return obj, p.advance()
+		}
+	case tokenInt:
+		n, err := strconv.Atoi(tok.value)
+		if err != nil {
+			return nil, &syntaxError{message: fmt.Sprintf("Int cannot represent %s.", tok.value), loc: tok.loc}
+		}
+		return n, p.advance()
+	case tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, &syntaxError{message: fmt.Sprintf("Float cannot represent %s.", tok.value), loc: tok.loc}
+		}
+		return f, p.advance()
+	case tokenString:
+		return tok.value, p.advance()
+	case tokenName:
+		switch tok.value {
+		case "true":
+			return true, p.advance()
+		case "false":
+			return false, p.advance()
+		case "null":
+			return nil, p.advance()
+		}
+		return enumValue(tok.value), p.advance()
+	}
+	return nil, p.unexpected()
+}
@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := parse(`
+		# Pages with their children
+		query Pages($path: String! = "/", $langs: [String]) @skip(if: false) {
+			home: page(path: $path, limit: -1.5e2, tags: ["a", 2, true, null, RED], filter: {lang: "fr"}) {
+				id
+				...fields @include(if: true)
+				... on Page { title }
+				... { slug }
+			}
+		}
+		fragment fields on Page { content }
+		{ menu }
+	`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(doc.operations) != 2 || len(doc.fragments) != 1 {
+		t.Fatalf("Expected two operations and a fragment, got %+v", doc)
+	}
+
+	op := doc.operations[0]
+	if op.kind != "query" || op.name != "Pages" || len(op.directives) != 1 || op.loc != (Location{Line: 3, Column: 3}) {
+		t.Errorf("Unexpected operation %+v", op)
+	}
+	if len(op.variables) != 2 || op.variables[0].typ.String() != "String!" || op.variables[0].defaultVal != "/" || op.variables[1].typ.String() != "[String]" {
+		t.Errorf("Unexpected variables %+v", op.variables)
+	}
+
+	home := op.selections[0].(*field)
+	if home.alias != "home" || home.name != "page" || home.responseKey() != "home" {
+		t.Errorf("Unexpected field %+v", home)
+	}
+	args := map[string]any{}
+	for _, arg := range home.arguments {
+		args[arg.name] = arg.value
+	}
+	expected := map[string]any{
+		"path":   variable("path"),
+		"limit":  -150.0,
+		"tags":   []any{"a", 2, true, nil, enumValue("RED")},
+		"filter": map[string]any{"lang": "fr"},
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected arguments %v, got %v", expected, args)
+	}
+
+	if len(home.selections) != 4 {
+		t.Fatalf("Expected four selections, got %d", len(home.selections))
+	}
+	if spread, ok := home.selections[1].(*fragmentSpread); !ok || spread.name != "fields" || len(spread.directives) != 1 {
+		t.Errorf("Expected a fragment spread, got %+v", home.selections[1])
+	}
+	if inline, ok := home.selections[2].(*inlineFragment); !ok || inline.typeCondition != "Page" {
+		t.Errorf("Expected an inline fragment on Page, got %+v", home.selections[2])
+	}
+	if inline, ok := home.selections[3].(*inlineFragment); !ok || inline.typeCondition != "" {
+		t.Errorf("Expected an inline fragment without type condition, got %+v", home.selections[3])
+	}
+	if frag := doc.fragments["fields"]; frag.typeCondition != "Page" || len(frag.selections) != 1 {
+		t.Errorf("Unexpected fragment %+v", frag)
+	}
+}
+
+func TestParseStrings(t *testing.T) {
+	doc, err := parse(`{ page(path: "a\"b\\c\/d\né") { id } }`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	value := doc.operations[0].selections[0].(*field).arguments[0].value
+	if value != "a\"b\\c/d\né" {
+		t.Errorf("Unexpected string %q", value)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		query string
+		loc   Location
+	}{
+		{query: "", loc: Location{Line: 1, Column: 1}},
+		{query: "{}", loc: Location{Line: 1, Column: 2}},
+		{query: "{ page(path: \"/\" }", loc: Location{Line: 1, Column: 18}},
+		{query: "{ page(path: \"/) }", loc: Location{Line: 1, Column: 14}},
+		{query: "{ page(path: \"\"\"/\"\"\") }", loc: Location{Line: 1, Column: 14}},
+		{query: "{ page(limit: 01) }", loc: Location{Line: 1, Column: 15}},
+		{query: "{ page(limit: 1.) }", loc: Location{Line: 1, Column: 15}},
+		{query: "{\n  page ? }", loc: Location{Line: 2, Column: 8}},
+		{query: "query ($path: String = $other) { id }", loc: Location{Line: 1, Column: 24}},
+		{query: "fragment on on Page { id }", loc: Location{Line: 1, Column: 1}},
+		{query: "fragment a on Page { id } fragment a on Page { id }", loc: Location{Line: 1, Column: 27}},
+		{query: "{ id } schema", loc: Location{Line: 1, Column: 8}},
+	}
+
+	for _, tc := range testCases {
+		_, err := parse(tc.query)
+		syntaxErr, ok := err.(*syntaxError)
+		if !ok {
+			t.Errorf("%q: expected a syntax error, got %v", tc.query, err)
+			continue
+		}
+		if syntaxErr.loc != tc.loc {
+			t.Errorf("%q: expected error at %+v, got %+v: %v", tc.query, tc.loc, syntaxErr.loc, err)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+// Package graphql executes read-only GraphQL queries against a schema of
+// objects whose fields are resolved by Go functions.  It implements the
+// query language with variables, fragments, directives and introspection,
+// but no mutations, subscriptions, interfaces or input objects.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Type is a GraphQL type: a *Scalar, *Enum or *Object, or a list or
+// non-null wrapper of one.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type.  Values are serialized with Serialize and input
+// values are coerced with Parse, which reject invalid values.
+type Scalar struct {
+	Name        string
+	Description string
+	Serialize   func(v any) (any, bool)
+	Parse       func(v any) (any, bool)
+}
+
+// String returns the name of the scalar.
+func (s *Scalar) String() string { return s.Name }
+
+// Enum is a leaf type whose values are one of a set of names.  Values are
+// resolved as strings.
+type Enum struct {
+	Name        string
+	Description string
+	Values      []string
+}
+
+// String returns the name of the enum.
+func (e *Enum) String() string { return e.Name }
+
+// has reports whether a name is a value of the enum.
+func (e *Enum) has(name string) bool {
+	for _, value := range e.Values {
+		if value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Object is a type with fields.
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+// String returns the name of the object.
+func (o *Object) String() string { return o.Name }
+
+// field returns the field of the object with a name.
+func (o *Object) field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// ListType is a list of values of a type.
+type ListType struct {
+	OfType Type
+}
+
+// List returns the type of lists of a type.
+func List(t Type) *ListType { return &ListType{OfType: t} }
+
+// String returns the type as written in a query.
+func (l *ListType) String() string { return "[" + l.OfType.String() + "]" }
+
+// NonNullType is a type whose values may not be null.
+type NonNullType struct {
+	OfType Type
+}
+
+// NonNull returns the non-null type of a type.
+func NonNull(t Type) *NonNullType { return &NonNullType{OfType: t} }
+
+// String returns the type as written in a query.
+func (n *NonNullType) String() string { return n.OfType.String() + "!" }
+
+// ResolveFunc resolves the value of a field given the value of its parent
+// object and its coerced arguments.
+type ResolveFunc func(ctx context.Context, source any, args map[string]any) (any, error)
+
+// Field is a field of an object.  Fields without a resolver read the
+// entry of a map or the field of a struct, matched by its JSON name, with
+// the name of the field.
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*Argument
+	Resolve     ResolveFunc
+}
+
+// argument returns the argument of the field with a name.
+func (f *Field) argument(name string) *Argument {
+	for _, arg := range f.Args {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
+
+// Argument is an argument of a field.  Arguments are scalars, enums or
+// lists of them.  Default is used when the argument is not given.
+type Argument struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     any
+}
+
+// Built-in scalars.
+var (
+	String = &Scalar{
+		Name:        "String",
+		Description: "Textual data, represented as UTF-8 character sequences.",
+		Serialize:   serializeString,
+		Parse: func(v any) (any, bool) {
+			s, ok := v.(string)
+			return s, ok
+		},
+	}
+	Int = &Scalar{
+		Name:        "Int",
+		Description: "Signed 32-bit integers.",
+		Serialize: func(v any) (any, bool) {
+			return parseInt(reflectNumber(v))
+		},
+		Parse: parseInt,
+	}
+	Float = &Scalar{
+		Name:        "Float",
+		Description: "Signed double-precision floating-point values.",
+		Serialize: func(v any) (any, bool) {
+			return parseFloat(reflectNumber(v))
+		},
+		Parse: parseFloat,
+	}
+	Boolean = &Scalar{
+		Name:        "Boolean",
+		Description: "true or false.",
+		Serialize: func(v any) (any, bool) {
+			rv := reflect.ValueOf(v)
+			if rv.Kind() != reflect.Bool {
+				return nil, false
+			}
+			return rv.Bool(), true
+		},
+		Parse: func(v any) (any, bool) {
+			b, ok := v.(bool)
+			return b, ok
+		},
+	}
+	ID = &Scalar{
+		Name:        "ID",
+		Description: "A unique identifier, serialized as a string.",
+		Serialize: func(v any) (any, bool) {
+			if n, ok := parseInt(reflectNumber(v)); ok {
+				return strconv.Itoa(n.(int)), true
+			}
+			return serializeString(v)
+		},
+		Parse: func(v any) (any, bool) {
+			if n, ok := parseInt(v); ok {
+				return strconv.Itoa(n.(int)), true
+			}
+			s, ok := v.(string)
+			return s, ok
+		},
+	}
+)
+
+// serializeString serializes values of string kinds, such as
+// template.HTML, as strings.
+func serializeString(v any) (any, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.String {
+		return nil, false
+	}
+	return rv.String(), true
+}
+
+// reflectNumber converts values of integer and float kinds to int and
+// float64.
+func reflectNumber(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt32 {
+			return nil
+		}
+		return int(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return nil
+}
+
+// parseInt accepts ints and integral float64s, as decoded from JSON, in the
+// 32-bit range.
+func parseInt(v any) (any, bool) {
+	switch n := v.(type) {
+	case int:
+		if n >= math.MinInt32 && n <= math.MaxInt32 {
+			return n, true
+		}
+	case float64:
+		if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+			return int(n), true
+		}
+	}
+	return nil, false
+}
+
+// parseFloat accepts ints and finite float64s.
+func parseFloat(v any) (any, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		if !math.IsInf(n, 0) && !math.IsNaN(n) {
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+// Schema is a schema of read-only queries starting from the Query object.
+type Schema struct {
+	Query *Object
+
+	// MaxDepth is the deepest nesting of fields a query may select and
+	// MaxFields the most fields it may select, counting the fields of
+	// each fragment spread.  Zero disables the limit.
+	MaxDepth  int
+	MaxFields int
+
+	types       map[string]Type
+	typeNames   []string
+	schemaField *Field
+	typeField   *Field
+}
+
+// NewSchema creates a schema of queries of the given object, which must
+// be named Query.  The types reachable from it must have unique names.
+func NewSchema(query *Object) (*Schema, error) {
+	s := &Schema{Query: query, types: make(map[string]Type)}
+	var add func(t Type) error
+	add = func(t Type) error {
+		t = namedType(t)
+		name := t.String()
+		if existing, ok := s.types[name]; ok {
+			if existing != t {
+				return fmt.Errorf("graphql: two types are named %s", name)
+			}
+			return nil
+		}
+		s.types[name] = t
+		s.typeNames = append(s.typeNames, name)
+		if obj, ok := t.(*Object); ok {
+			if len(obj.Fields) == 0 {
+				return fmt.Errorf("graphql: object %s has no fields", name)
+			}
+			for _, f := range obj.Fields {
+				if err := add(f.Type); err != nil {
+					return err
+				}
+				for _, arg := range f.Args {
+					if _, ok := namedType(arg.Type).(*Object); ok {
+						return fmt.Errorf("graphql: argument %s of %s.%s is an object", arg.Name, name, f.Name)
+					}
+					if err := add(arg.Type); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	if query == nil || query.Name != "Query" {
+		return nil, fmt.Errorf("graphql: the query object must be named Query")
+	}
+	for _, scalar := range []*Scalar{String, Int, Float, Boolean, ID} {
+		if err := add(scalar); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(query); err != nil {
+		return nil, err
+	}
+	schemaType, typeType := introspection(s)
+	if err := add(schemaType); err != nil {
+		return nil, err
+	}
+	s.schemaField = &Field{
+		Name:        "__schema",
+		Description: "Access the current type schema of this server.",
+		Type:        NonNull(schemaType),
+		Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return s, nil
+		},
+	}
+	s.typeField = &Field{
+		Name:        "__type",
+		Description: "Request the type information of a single type.",
+		Type:        typeType,
+		Args:        []*Argument{{Name: "name", Type: NonNull(String)}},
+		Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			if t, ok := s.types[args["name"].(string)]; ok {
+				return t, nil
+			}
+			return nil, nil
+		},
+	}
+	return s, nil
+}
+
+// namedType returns the scalar, enum or object of a type.
+func namedType(t Type) Type {
+	for {
+		switch wrapper := t.(type) {
+		case *ListType:
+			t = wrapper.OfType
+		case *NonNullType:
+			t = wrapper.OfType
+		default:
+			return t
+		}
+	}
+}
+
+// lookupField returns the field of an object with a name, including the
+// introspection fields of the query.
+func (s *Schema) lookupField(obj *Object, name string) *Field {
+	if obj == s.Query {
+		switch name {
+		case "__schema":
+			return s.schemaField
+		case "__type":
+			return s.typeField
+		}
+	}
+	return obj.field(name)
+}
+
+// inputType returns the type of a variable as written in a query.
+func (s *Schema) inputType(ref *typeRef) (Type, bool) {
+	var t Type
+	if ref.elem != nil {
+		elem, ok := s.inputType(ref.elem)
+		if !ok {
+			return nil, false
+		}
+		t = List(elem)
+	} else {
+		named, ok := s.types[ref.name]
+		if !ok {
+			return nil, false
+		}
+		if _, ok := named.(*Object); ok {
+			return nil, false
+		}
+		t = named
+	}
+	if ref.nonNull {
+		t = NonNull(t)
+	}
+	return t, true
+}
+
+// defaultResolve reads a field from a map or struct.
+func defaultResolve(source any, name string) any {
+	rv := reflect.ValueOf(source)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		v := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+		if !v.IsValid() {
+			return nil
+		}
+		return v.Interface()
+	case reflect.Struct:
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if jsonName == name || (jsonName == "" && strings.EqualFold(sf.Name, name)) {
+				return rv.Field(i).Interface()
+			}
+		}
+	}
+	return nil
+}
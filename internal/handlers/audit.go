@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"wordpress-go-proxy/internal/audit"
+	"wordpress-go-proxy/internal/auth"
+)
+
+// AuditHandler checks the internal links of every page on request and
+// responds with the report of broken ones, as JSON or, with format=csv, as
+// CSV that editors can open in a spreadsheet.  Requests must carry an audit
+// token, signed with the secret shared with the WordPress plugin, as a
+// bearer credential.
+type AuditHandler struct {
+	Auditor *audit.Auditor
+	Signer  *auth.Signer
+}
+
+// NewAuditHandler creates a new link audit handler.
+func NewAuditHandler(auditor *audit.Auditor, signer *auth.Signer) *AuditHandler {
+	return &AuditHandler{
+		Auditor: auditor,
+		Signer:  signer,
+	}
+}
+
+// errInvalidAuditFormat is sent for link audit requests with a format
+// other than json or csv.
+var errInvalidAuditFormat = errors.New("invalid format")
+
+// ServeHTTP implements the http.Handler interface.
+func (h *AuditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	job[audit.Report]{
+		name:    "Link audit",
+		failure: "Error auditing links",
+		purpose: auth.PurposeAudit,
+		signer:  h.Signer,
+		running: audit.ErrAuditing,
+		check: func(r *http.Request) error {
+			switch r.URL.Query().Get("format") {
+			case "", "json", "csv":
+				return nil
+			}
+			return errInvalidAuditFormat
+		},
+		run: h.Auditor.Audit,
+		respond: func(w http.ResponseWriter, r *http.Request, report audit.Report) error {
+			if r.URL.Query().Get("format") != "csv" {
+				w.Header().Set("Content-Type", "application/json")
+				return json.NewEncoder(w).Encode(report)
+			}
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="broken-links.csv"`)
+			return report.WriteCSV(w)
+		},
+	}.ServeHTTP(w, r)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/audit"
+	"wordpress-go-proxy/internal/auth"
+)
+
+// fakeAuditPages lists fixed page paths
+type fakeAuditPages []string
+
+func (f fakeAuditPages) Paths(ctx context.Context) ([]string, error) {
+	return f, nil
+}
+
+func TestAuditHandlerServeHTTP(t *testing.T) {
+	secret := "0123456789abcdef0123456789abcdef"
+	signer := auth.NewSigner([]byte(secret), 5*time.Minute, 30*time.Second)
+	bearer := func(purpose string) string {
+		token, _, _ := signer.Issue(purpose, "")
+		return "Bearer " + token
+	}
+
+	site := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<a href="/missing">Missing</a>`))
+	})
+	handler := NewAuditHandler(audit.New(site, fakeAuditPages{"/"}, nil), signer)
+
+	testCases := []struct {
+		name           string
+		method         string
+		query          string
+		authorization  string
+		expectedStatus int
+		expectedType   string
+	}{
+		{"Invalid method", "GET", "", bearer(auth.PurposeAudit), http.StatusMethodNotAllowed, ""},
+		{"Missing credential", "POST", "", "", http.StatusUnauthorized, ""},
+		{"Wrong credential", "POST", "", "Bearer wrong", http.StatusUnauthorized, ""},
+		{"Shared secret", "POST", "", "Bearer " + secret, http.StatusUnauthorized, ""},
+		{"Invalid format", "POST", "?format=xml", bearer(auth.PurposeAudit), http.StatusBadRequest, ""},
+		{"JSON report", "POST", "", bearer(auth.PurposeAudit), http.StatusOK, "application/json"},
+		{"CSV report", "POST", "?format=csv", bearer(auth.PurposeAudit), http.StatusOK, "text/csv; charset=utf-8"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/internal/audit"+tc.query, nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "no-store" {
+				t.Errorf("Expected Cache-Control no-store, got %q", cacheControl)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tc.expectedType {
+				t.Errorf("Expected Content-Type %q, got %q", tc.expectedType, contentType)
+			}
+
+			if tc.expectedType == "application/json" {
+				var report audit.Report
+				if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+					t.Fatalf("Error decoding response: %v", err)
+				}
+				if report.Pages != 1 || len(report.Broken) != 1 || report.Broken[0].Link != "/missing" {
+					t.Errorf("Expected the missing link reported, got %+v", report)
+				}
+			} else if !strings.Contains(w.Body.String(), "/,/missing,404") {
+				t.Errorf("Expected the missing link in the CSV, got %q", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// ColorSchemePath is the path the color scheme visitors choose is
+// submitted to.
+const ColorSchemePath = "/api/color-scheme"
+
+// colorSchemeAuto is submitted to clear the chosen color scheme, so that
+// pages follow the one the visitor's browser prefers.
+const colorSchemeAuto = "auto"
+
+// colorSchemeMaxAge is how long, in seconds, a chosen color scheme is
+// remembered.
+const colorSchemeMaxAge = 365 * 24 * 60 * 60
+
+// maxColorSchemeForm limits the size of a color scheme submission.
+const maxColorSchemeForm = 1 << 10
+
+// ColorSchemes reports whether visitors can choose the color scheme pages
+// are rendered in, with the form posted to ColorSchemePath.  It is set at
+// startup from config.
+var ColorSchemes bool
+
+// ColorSchemeHandler remembers the color scheme visitors choose in a
+// cookie, read by the color scheme middleware.  The form carries a scheme
+// of light, dark, high-contrast or auto.  Forms posted with a path send
+// the visitor back to it, while scripts posting without one get a status
+// code.
+type ColorSchemeHandler struct {
+	Cookie string
+}
+
+// NewColorSchemeHandler creates a new color scheme handler setting the
+// named cookie.
+func NewColorSchemeHandler(cookie string) *ColorSchemeHandler {
+	return &ColorSchemeHandler{Cookie: cookie}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *ColorSchemeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Color scheme request")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.Method != http.MethodPost {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Other sites cannot switch the scheme visitors see
+	if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		log.Printf("Refusing cross-site color scheme")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxColorSchemeForm)
+	if err := r.ParseForm(); err != nil {
+		log.Printf("Invalid color scheme form: %v", err)
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	path := r.PostFormValue("path")
+	if path != "" && !returnPath(path) {
+		log.Printf("Invalid color scheme return path: %q", path)
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	scheme := r.PostFormValue("scheme")
+	cookie := &http.Cookie{
+		Name:     h.Cookie,
+		Value:    scheme,
+		Path:     "/",
+		MaxAge:   colorSchemeMaxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(requestOrigin(r), "https:"),
+		SameSite: http.SameSiteLaxMode,
+	}
+	switch {
+	case scheme == colorSchemeAuto:
+		cookie.Value = ""
+		cookie.MaxAge = -1
+	case !slices.Contains(models.ColorSchemes, scheme):
+		log.Printf("Invalid color scheme: %q", scheme)
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, cookie)
+
+	if path != "" {
+		http.Redirect(w, r, path, http.StatusSeeOther)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newColorSchemeData returns the color scheme a page is rendered in, with
+// the configured stylesheets of dark and high contrast schemes.  The
+// stylesheets of a scheme apply if the visitor chose it or their browser
+// prefers it, and otherwise by media query while neither is known, so
+// pages never flash in the wrong scheme.
+func newColorSchemeData(r *http.Request) *models.ColorSchemeData {
+	name, chosen := middleware.PreferredColorScheme(r.Context())
+	data := &models.ColorSchemeData{
+		Name:   name,
+		Chosen: chosen,
+		Action: ColorSchemePath,
+		Path:   r.URL.Path,
+	}
+	variants := []struct {
+		scheme      string
+		media       string
+		stylesheets []string
+	}{
+		{models.ColorSchemeDark, "(prefers-color-scheme: dark)", Assets.DarkStylesheets},
+		{models.ColorSchemeContrast, "(prefers-contrast: more)", Assets.ContrastStylesheets},
+	}
+	for _, variant := range variants {
+		var media string
+		switch {
+		case name == variant.scheme:
+		case name == "", !chosen && variant.scheme == models.ColorSchemeContrast:
+			// Browsers only hint at light or dark, so whether their
+			// visitor prefers more contrast is left to the media query
+			media = variant.media
+		default:
+			continue
+		}
+		for _, href := range variant.stylesheets {
+			data.Stylesheets = append(data.Stylesheets, models.StylesheetLink{Href: href, Media: media})
+		}
+	}
+	return data
+}
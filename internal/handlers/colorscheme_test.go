@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/internal/theme"
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestColorSchemeHandler(t *testing.T) {
+	testCases := []struct {
+		name     string
+		method   string
+		form     url.Values
+		site     string
+		status   int
+		location string
+		cookie   string
+	}{
+		{name: "Chosen", form: url.Values{"scheme": {"dark"}, "path": {"/fr/a-propos"}}, status: http.StatusSeeOther, location: "/fr/a-propos", cookie: "color_scheme=dark; Path=/; Max-Age=31536000; HttpOnly; SameSite=Lax"},
+		{name: "Script", form: url.Values{"scheme": {"high-contrast"}}, status: http.StatusNoContent, cookie: "color_scheme=high-contrast; Path=/; Max-Age=31536000; HttpOnly; SameSite=Lax"},
+		{name: "Auto", form: url.Values{"scheme": {"auto"}, "path": {"/"}}, status: http.StatusSeeOther, location: "/", cookie: "color_scheme=; Path=/; Max-Age=0; HttpOnly; SameSite=Lax"},
+		{name: "Invalid scheme", form: url.Values{"scheme": {"sepia"}}, status: http.StatusBadRequest},
+		{name: "Missing scheme", form: url.Values{"path": {"/"}}, status: http.StatusBadRequest},
+		{name: "Other site", form: url.Values{"scheme": {"dark"}, "path": {"//evil.example.com/"}}, status: http.StatusBadRequest},
+		{name: "Cross-site", form: url.Values{"scheme": {"dark"}}, site: "cross-site", status: http.StatusForbidden},
+		{name: "GET", method: "GET", status: http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = "POST"
+			}
+			req := httptest.NewRequest(method, ColorSchemePath, strings.NewReader(tc.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.site != "" {
+				req.Header.Set("Sec-Fetch-Site", tc.site)
+			}
+			w := httptest.NewRecorder()
+			NewColorSchemeHandler("color_scheme").ServeHTTP(w, req)
+
+			if w.Code != tc.status || w.Header().Get("Location") != tc.location {
+				t.Errorf("Expected %d to %q, got %d to %q", tc.status, tc.location, w.Code, w.Header().Get("Location"))
+			}
+			if cookie := w.Header().Get("Set-Cookie"); cookie != tc.cookie {
+				t.Errorf("Expected cookie %q, got %q", tc.cookie, cookie)
+			}
+		})
+	}
+
+	// The cookie is only sent over HTTPS to sites served over it
+	req := httptest.NewRequest("POST", ColorSchemePath, strings.NewReader("scheme=light"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	w := httptest.NewRecorder()
+	NewColorSchemeHandler("color_scheme").ServeHTTP(w, req)
+	if !strings.Contains(w.Header().Get("Set-Cookie"), "Secure") {
+		t.Errorf("Expected a secure cookie, got %q", w.Header().Get("Set-Cookie"))
+	}
+}
+
+// TestColorSchemeData verifies the stylesheets of each scheme apply when it
+// is chosen or preferred, and by media query when neither is known
+func TestColorSchemeData(t *testing.T) {
+	Assets = models.Assets{DarkStylesheets: []string{"/dark.css"}, ContrastStylesheets: []string{"/contrast.css"}}
+	defer func() { Assets = models.Assets{} }()
+
+	dark := models.StylesheetLink{Href: "/dark.css"}
+	contrast := models.StylesheetLink{Href: "/contrast.css"}
+	darkMedia := models.StylesheetLink{Href: "/dark.css", Media: "(prefers-color-scheme: dark)"}
+	contrastMedia := models.StylesheetLink{Href: "/contrast.css", Media: "(prefers-contrast: more)"}
+	tests := []struct {
+		cookie   string
+		hint     string
+		expected []models.StylesheetLink
+	}{
+		{"", "", []models.StylesheetLink{darkMedia, contrastMedia}},
+		{"", `"dark"`, []models.StylesheetLink{dark, contrastMedia}},
+		{"", `"light"`, []models.StylesheetLink{contrastMedia}},
+		{"color_scheme=dark", "", []models.StylesheetLink{dark}},
+		{"color_scheme=light", `"dark"`, nil},
+		{"color_scheme=high-contrast", "", []models.StylesheetLink{contrast}},
+	}
+
+	var data *models.ColorSchemeData
+	handler := middleware.ColorScheme("color_scheme")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data = newColorSchemeData(r)
+	}))
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/about", nil)
+		if tt.cookie != "" {
+			req.Header.Set("Cookie", tt.cookie)
+		}
+		if tt.hint != "" {
+			req.Header.Set("Sec-CH-Prefers-Color-Scheme", tt.hint)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if !reflect.DeepEqual(data.Stylesheets, tt.expected) {
+			t.Errorf("Expected %+v for cookie %q and hint %q, got %+v", tt.expected, tt.cookie, tt.hint, data.Stylesheets)
+		}
+		if data.Action != ColorSchemePath || data.Path != "/about" {
+			t.Errorf("Expected the form to post to %s from /about, got %q and %q", ColorSchemePath, data.Action, data.Path)
+		}
+	}
+}
+
+// TestRenderColorScheme verifies the default theme renders pages in the
+// chosen scheme with the form switching it
+func TestRenderColorScheme(t *testing.T) {
+	originalTheme := Theme
+	Theme = theme.New("../../templates", theme.Default)
+	defer func() { Theme = originalTheme }()
+
+	tmpl, err := parseTheme("layout.html")
+	if err != nil {
+		t.Fatalf("Error parsing theme: %v", err)
+	}
+	data := models.PageData{
+		Lang:     "en",
+		Title:    "Test Page",
+		Menu:     &models.MenuData{},
+		Assets:   models.Assets{DarkStylesheets: []string{"/dark.css"}},
+		Content:  "<p>Test content</p>",
+		SiteName: "English Site",
+		ColorScheme: &models.ColorSchemeData{
+			Name:        "dark",
+			Chosen:      true,
+			Action:      ColorSchemePath,
+			Path:        "/test-page",
+			Stylesheets: []models.StylesheetLink{{Href: "/dark.css"}},
+		},
+	}
+	var out bytes.Buffer
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	for _, expected := range []string{
+		`<html dir="ltr" lang="en" class="scheme-dark">`,
+		`<meta name="color-scheme" content="dark">`,
+		`<link rel="stylesheet" href="/dark.css">`,
+		`action="/api/color-scheme"`,
+		`<input type="hidden" name="path" value="/test-page">`,
+		`value="dark" button-role="secondary" aria-pressed="true"`,
+		`value="auto" button-role="secondary" aria-pressed="false"`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got: %s", expected, out.String())
+		}
+	}
+
+	data.ColorScheme = nil
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	if strings.Contains(out.String(), "color-scheme") || strings.Contains(out.String(), "/dark.css") {
+		t.Errorf("Expected no color scheme when visitors cannot choose one, got: %s", out.String())
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/filter"
+	"wordpress-go-proxy/internal/ratelimit"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// CommentPath is the path comments on posts are submitted to.
+const CommentPath = "/comments"
+
+// HoneypotField is the name of a comment form field hidden from visitors.
+// Submissions that fill it in are made by bots and are dropped.
+const HoneypotField = "website"
+
+// MaxCommentForm limits the size of a comment submission.  The form is
+// read by the CSRF middleware first, so the limit is also applied to the
+// route.
+const MaxCommentForm = 128 << 10
+
+// Limits on comment fields, those of the WordPress comments table.
+const (
+	maxCommentAuthor = 245
+	maxCommentEmail  = 100
+	maxComment       = 65525
+)
+
+// CommentHandler forwards comments submitted with the comment form of a
+// post to WordPress, then sends the visitor back to the post with the
+// outcome in the comment query parameter.  Submissions that fill in the
+// honeypot field are dropped as if they were accepted, and each client
+// address may only submit as many comments as the limiter allows.
+type CommentHandler struct {
+	WordPressClient *api.WordPressClient
+	Limiter         *ratelimit.Limiter
+
+	// TrustedProxies is the number of proxies whose X-Forwarded-For
+	// entries identify the client, as for request filtering.
+	TrustedProxies int
+}
+
+// NewCommentHandler creates a new comment handler.
+func NewCommentHandler(wordPressClient *api.WordPressClient, limiter *ratelimit.Limiter) *CommentHandler {
+	return &CommentHandler{
+		WordPressClient: wordPressClient,
+		Limiter:         limiter,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *CommentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Comment request")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.Method != http.MethodPost {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxCommentForm)
+	if err := r.ParseForm(); err != nil {
+		log.Printf("Invalid comment form: %v", err)
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	path := r.PostFormValue("path")
+	if !returnPath(path) {
+		log.Printf("Invalid comment return path: %q", path)
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	if r.PostFormValue(HoneypotField) != "" {
+		log.Printf("Dropping comment with honeypot field on %s", path)
+		redirectComment(w, r, path, models.CommentPending)
+		return
+	}
+
+	if h.Limiter != nil {
+		ip, ok := filter.ClientIP(r, h.TrustedProxies)
+		if !ok || !h.Limiter.Allow(ip.String()) {
+			log.Printf("Comment rate limit reached for %s", ip)
+			redirectComment(w, r, path, models.CommentLimited)
+			return
+		}
+	}
+
+	comment, ok := commentSubmission(r)
+	if !ok {
+		log.Printf("Invalid comment on %s", path)
+		redirectComment(w, r, path, models.CommentInvalid)
+		return
+	}
+
+	err := h.WordPressClient.SubmitComment(r.Context(), comment)
+	switch {
+	case errors.Is(err, api.ErrCommentRejected):
+		log.Printf("Comment on post %d rejected: %v", comment.PostID, err)
+		redirectComment(w, r, path, models.CommentRejected)
+	case err != nil:
+		log.Printf("Error submitting comment: %v", err)
+		redirectComment(w, r, path, models.CommentError)
+	default:
+		redirectComment(w, r, path, models.CommentPending)
+	}
+}
+
+// commentSubmission reads a comment from the submitted form, reporting
+// false if a field is missing or invalid.
+func commentSubmission(r *http.Request) (api.CommentSubmission, bool) {
+	comment := api.CommentSubmission{
+		AuthorName:  strings.TrimSpace(r.PostFormValue("author_name")),
+		AuthorEmail: strings.TrimSpace(r.PostFormValue("author_email")),
+		Content:     strings.TrimSpace(r.PostFormValue("content")),
+	}
+	postID, err := strconv.Atoi(r.PostFormValue("post_id"))
+	if err != nil || postID <= 0 {
+		return comment, false
+	}
+	comment.PostID = postID
+	if parent := r.PostFormValue("parent"); parent != "" {
+		comment.Parent, err = strconv.Atoi(parent)
+		if err != nil || comment.Parent < 0 {
+			return comment, false
+		}
+	}
+
+	if comment.AuthorName == "" || len(comment.AuthorName) > maxCommentAuthor {
+		return comment, false
+	}
+	if addr, err := mail.ParseAddress(comment.AuthorEmail); err != nil || addr.Address != comment.AuthorEmail || len(comment.AuthorEmail) > maxCommentEmail {
+		return comment, false
+	}
+	if comment.Content == "" || len(comment.Content) > maxComment {
+		return comment, false
+	}
+	return comment, true
+}
+
+// returnPath reports whether a path a visitor is sent back to after
+// submitting a form, such as a comment, is a path of the site.  Control
+// characters are refused, since browsers drop tabs and newlines from the
+// Location header and could read a path such as "/\t/host" as another
+// site.
+func returnPath(path string) bool {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") ||
+		strings.ContainsAny(path, "<>\"'%\\`^{}|?#") || len(path) > 255 {
+		return false
+	}
+	if strings.IndexFunc(path, func(r rune) bool { return r < 0x20 || r == 0x7f }) >= 0 {
+		return false
+	}
+	u, err := url.Parse(path)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
+
+// redirectComment sends a visitor back to the post they commented on with
+// the outcome of their submission.
+func redirectComment(w http.ResponseWriter, r *http.Request, path string, status string) {
+	http.Redirect(w, r, path+"?comment="+status+"#comments", http.StatusSeeOther)
+}
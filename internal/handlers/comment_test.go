@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/ratelimit"
+)
+
+func TestCommentHandler(t *testing.T) {
+	var submitted []api.CommentSubmission
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var comment api.CommentSubmission
+		json.NewDecoder(r.Body).Decode(&comment)
+		submitted = append(submitted, comment)
+		switch comment.Content {
+		case "Duplicate":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":"comment_duplicate"}`))
+		case "Error":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	valid := url.Values{
+		"post_id":      {"42"},
+		"path":         {"/events/launch"},
+		"author_name":  {"Ada"},
+		"author_email": {"ada@example.com"},
+		"content":      {"Thanks"},
+	}
+	with := func(name string, value string) url.Values {
+		form := url.Values{}
+		for k, v := range valid {
+			form[k] = v
+		}
+		form.Set(name, value)
+		return form
+	}
+
+	testCases := []struct {
+		name      string
+		form      url.Values
+		status    int
+		location  string
+		submitted bool
+		parent    int
+	}{
+		{name: "Submitted", form: valid, status: http.StatusSeeOther, location: "/events/launch?comment=pending#comments", submitted: true},
+		{name: "Reply", form: with("parent", "7"), status: http.StatusSeeOther, location: "/events/launch?comment=pending#comments", submitted: true, parent: 7},
+		{name: "Honeypot", form: with("website", "https://spam.example.com"), status: http.StatusSeeOther, location: "/events/launch?comment=pending#comments"},
+		{name: "Missing name", form: with("author_name", " "), status: http.StatusSeeOther, location: "/events/launch?comment=invalid#comments"},
+		{name: "Invalid email", form: with("author_email", "ada"), status: http.StatusSeeOther, location: "/events/launch?comment=invalid#comments"},
+		{name: "Invalid post", form: with("post_id", "launch"), status: http.StatusSeeOther, location: "/events/launch?comment=invalid#comments"},
+		{name: "Rejected", form: with("content", "Duplicate"), status: http.StatusSeeOther, location: "/events/launch?comment=rejected#comments", submitted: true},
+		{name: "Error", form: with("content", "Error"), status: http.StatusSeeOther, location: "/events/launch?comment=error#comments", submitted: true},
+		{name: "Other site", form: with("path", "//evil.example.com/"), status: http.StatusBadRequest},
+		{name: "Control character in path", form: with("path", "/\t/evil.example.com/"), status: http.StatusBadRequest},
+		{name: "Query in path", form: with("path", "/events/launch?comment=pending"), status: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			submitted = nil
+			handler := NewCommentHandler(&api.WordPressClient{BaseURL: server.URL}, nil)
+
+			req := httptest.NewRequest("POST", CommentPath, strings.NewReader(tc.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.status || w.Header().Get("Location") != tc.location {
+				t.Errorf("Expected %d to %q, got %d to %q", tc.status, tc.location, w.Code, w.Header().Get("Location"))
+			}
+			if (len(submitted) > 0) != tc.submitted {
+				t.Errorf("Expected submitted %v, got %+v", tc.submitted, submitted)
+			}
+			if tc.submitted && (submitted[0].PostID != 42 || submitted[0].Parent != tc.parent || submitted[0].AuthorEmail != "ada@example.com") {
+				t.Errorf("Unexpected submission: %+v", submitted[0])
+			}
+		})
+	}
+}
+
+func TestCommentHandlerMethod(t *testing.T) {
+	handler := NewCommentHandler(&api.WordPressClient{}, nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", CommentPath, nil))
+
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("Expected 405 allowing POST, got %d allowing %q", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+// TestCommentHandlerRateLimit tests that each client address is limited
+// separately
+func TestCommentHandlerRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	handler := NewCommentHandler(&api.WordPressClient{BaseURL: server.URL}, ratelimit.New(1, time.Minute))
+	handler.TrustedProxies = 1
+	form := url.Values{
+		"post_id":      {"42"},
+		"path":         {"/events/launch"},
+		"author_name":  {"Ada"},
+		"author_email": {"ada@example.com"},
+		"content":      {"Thanks"},
+	}
+
+	testCases := []struct {
+		client   string
+		location string
+	}{
+		{client: "192.0.2.1", location: "/events/launch?comment=pending#comments"},
+		{client: "192.0.2.1", location: "/events/launch?comment=limited#comments"},
+		{client: "192.0.2.2", location: "/events/launch?comment=pending#comments"},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("POST", CommentPath, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Forwarded-For", tc.client)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Header().Get("Location") != tc.location {
+			t.Errorf("%s: expected redirect to %q, got %q", tc.client, tc.location, w.Header().Get("Location"))
+		}
+	}
+}
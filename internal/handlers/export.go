@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"wordpress-go-proxy/internal/auth"
+	"wordpress-go-proxy/internal/export"
+)
+
+// ExportHandler writes a static copy of the site on request, such as from
+// a WordPress webhook when content is published, so that the copy the site
+// fails over to stays current.  Requests must carry an export token,
+// signed with the secret shared with the WordPress plugin, as a bearer
+// credential.  The response is sent once the export is done, with the
+// number of paths written.
+type ExportHandler struct {
+	Exporter *export.Exporter
+	Signer   *auth.Signer
+}
+
+// NewExportHandler creates a new export handler.
+func NewExportHandler(exporter *export.Exporter, signer *auth.Signer) *ExportHandler {
+	return &ExportHandler{
+		Exporter: exporter,
+		Signer:   signer,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *ExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	job[export.Result]{
+		name:    "Export",
+		failure: "Error exporting site",
+		purpose: auth.PurposeExport,
+		signer:  h.Signer,
+		running: export.ErrExporting,
+		run:     h.Exporter.Export,
+	}.ServeHTTP(w, r)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/auth"
+	"wordpress-go-proxy/internal/export"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// fakeExportContent returns the same pages in every language
+type fakeExportContent []models.WordPressPost
+
+func (f fakeExportContent) ListContent(ctx context.Context, route string, lang string) ([]models.WordPressPost, error) {
+	return f, nil
+}
+
+// fakeExportTarget records the names of the files written
+type fakeExportTarget []string
+
+func (f *fakeExportTarget) Write(ctx context.Context, name string, contentType string, body []byte) error {
+	*f = append(*f, name)
+	return nil
+}
+
+func TestExportHandlerServeHTTP(t *testing.T) {
+	secret := "0123456789abcdef0123456789abcdef"
+	signer := auth.NewSigner([]byte(secret), 5*time.Minute, 30*time.Second)
+	bearer := func(purpose string) string {
+		token, _, _ := signer.Issue(purpose, "")
+		return "Bearer " + token
+	}
+
+	pages := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html></html>"))
+	})
+	content := fakeExportContent{{Slug: "about", Link: "https://wordpress.example.com/about/"}}
+	target := &fakeExportTarget{}
+	handler := NewExportHandler(export.New(pages, content, nil, "https://wordpress.example.com", target), signer)
+
+	testCases := []struct {
+		name           string
+		method         string
+		authorization  string
+		expectedStatus int
+	}{
+		{"Invalid method", "GET", bearer(auth.PurposeExport), http.StatusMethodNotAllowed},
+		{"Missing credential", "POST", "", http.StatusUnauthorized},
+		{"Wrong credential", "POST", "Bearer wrong", http.StatusUnauthorized},
+		{"Shared secret", "POST", "Bearer " + secret, http.StatusUnauthorized},
+		{"Export", "POST", bearer(auth.PurposeExport), http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			*target = nil
+			req := httptest.NewRequest(tc.method, "/internal/export", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "no-store" {
+				t.Errorf("Expected Cache-Control no-store, got %q", cacheControl)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				if len(*target) != 0 {
+					t.Errorf("Expected no files written, got %v", *target)
+				}
+				return
+			}
+
+			var result export.Result
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Error decoding response: %v", err)
+			}
+			if result.Exported != len(*target) || result.Failed != 0 {
+				t.Errorf("Expected %d paths exported, got %+v", len(*target), result)
+			}
+			if len(*target) == 0 || (*target)[len(*target)-1] != "about/index.html" {
+				t.Errorf("Expected the page written, got %v", *target)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"log"
+	"net/http"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/internal/router"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// feedPostCount is the number of posts included in a feed.
+const feedPostCount = 20
+
+// Feed file names, served at the root of each language.
+const (
+	rssFeed  = "feed.xml"
+	atomFeed = "atom.xml"
+)
+
+// FeedPatterns are the route patterns of the RSS and Atom feeds, which are
+// registered for every language.
+var FeedPatterns = []string{"/" + rssFeed, "/" + atomFeed}
+
+// feedPattern matches the path of a feed in any language.
+var feedPattern = router.MustParse("/{feed}")
+
+// FeedHandler serves RSS 2.0 and Atom feeds of the most recent posts.
+type FeedHandler struct {
+	SiteNames       map[string]string
+	WordPressClient *api.WordPressClient
+	Cache           *cache.Cache[[]models.WordPressPost]
+
+	// SiteURL is the public URL of the proxy that post links are
+	// rewritten to.  If empty, the URL of the request is used.
+	SiteURL string
+}
+
+// NewFeedHandler creates a new feed handler.  The posts in each feed are
+// cached using the given policy.
+func NewFeedHandler(siteNames map[string]string, wordPressClient *api.WordPressClient, policy cache.Policy) *FeedHandler {
+	return &FeedHandler{
+		SiteNames:       siteNames,
+		WordPressClient: wordPressClient,
+		Cache:           cache.New[[]models.WordPressPost](policy),
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.  The feed language is
+// determined by the path prefix and the format by the file name.
+func (h *FeedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	log.Printf("Feed request: %s", path)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := feedPattern.MatchLocalized(path)
+	if !ok || (params["feed"] != rssFeed && params["feed"] != atomFeed) {
+		http.NotFound(w, r)
+		return
+	}
+	lang := params[router.LangParam]
+	home := models.Languages.Prefix(lang)
+
+	posts, err := h.Cache.Get(r.Context(), lang, func(ctx context.Context) ([]models.WordPressPost, error) {
+		return h.WordPressClient.FetchPosts(ctx, lang, feedPostCount)
+	})
+	if err != nil {
+		upstreamError(w, r, "Error fetching posts", err)
+		log.Printf("Error fetching posts: %v", err)
+		return
+	}
+
+	info := models.FeedInfo{
+		Title:       h.SiteNames[lang],
+		Description: models.Languages.Resolve(lang).Tagline,
+		Lang:        lang,
+		Origin:      siteURL(w, h.SiteURL, r),
+		Home:        home,
+		FeedPath:    path,
+		BaseUrl:     h.WordPressClient.BaseURL,
+	}
+
+	var feed interface{}
+	if params["feed"] == atomFeed {
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		feed = models.NewAtomFeed(info, posts)
+	} else {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		feed = models.NewRSSFeed(info, posts)
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300") // 5 minutes
+	addSurrogateKeys(w, models.TypeSurrogateKey(models.SurrogateKeyPostType), models.LangSurrogateKey(lang))
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		log.Printf("Error writing feed: %v", err)
+		return
+	}
+	if err := xml.NewEncoder(w).Encode(feed); err != nil {
+		log.Printf("Error encoding feed: %v", err)
+	}
+}
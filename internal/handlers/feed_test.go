@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestFeedHandlerServeHTTP(t *testing.T) {
+	requests := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		lang := r.URL.Query().Get("lang")
+		post := models.WordPressPost{
+			ID:      1,
+			Link:    server.URL + "/" + lang + "-post/",
+			DateGmt: "2024-05-15T10:30:45",
+		}
+		post.Title.Rendered = "Post in " + lang
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPost{post})
+	}))
+	defer server.Close()
+
+	handler := NewFeedHandler(
+		map[string]string{"en": "English Site", "fr": "French Site"},
+		&api.WordPressClient{BaseURL: server.URL},
+		cache.Policy{TTL: time.Minute},
+	)
+
+	testCases := []struct {
+		name         string
+		path         string
+		expectedType string
+		expectedBody []string
+	}{
+		{
+			name:         "English RSS",
+			path:         "/feed.xml",
+			expectedType: "application/rss+xml; charset=utf-8",
+			expectedBody: []string{
+				`<rss version="2.0"`,
+				"<title>English Site</title>",
+				"<link>https://proxy.example.com/en-post/</link>",
+				"<atom:link href=\"https://proxy.example.com/feed.xml\" rel=\"self\"",
+			},
+		},
+		{
+			name:         "French RSS",
+			path:         "/fr/feed.xml",
+			expectedType: "application/rss+xml; charset=utf-8",
+			expectedBody: []string{
+				"<title>Post in fr</title>",
+				"<language>fr</language>",
+			},
+		},
+		{
+			name:         "English Atom",
+			path:         "/atom.xml",
+			expectedType: "application/atom+xml; charset=utf-8",
+			expectedBody: []string{
+				`<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">`,
+				`<link href="https://proxy.example.com/en-post/" rel="alternate" type="text/html">`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://proxy.example.com"+tc.path, nil)
+			req.Header.Set("X-Forwarded-Proto", "https")
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", resp.StatusCode)
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != tc.expectedType {
+				t.Errorf("Expected Content-Type %q, got %q", tc.expectedType, contentType)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.HasPrefix(string(body), "<?xml") {
+				t.Errorf("Expected XML declaration, got: %s", string(body))
+			}
+			for _, expected := range tc.expectedBody {
+				if !strings.Contains(string(body), expected) {
+					t.Errorf("Expected body to contain %q, got: %s", expected, string(body))
+				}
+			}
+		})
+	}
+
+	// English feeds share cached posts regardless of format
+	if requests != 2 {
+		t.Errorf("Expected 2 upstream requests, got %d", requests)
+	}
+}
+
+func TestFeedHandlerUpstreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	handler := NewFeedHandler(nil, &api.WordPressClient{BaseURL: server.URL}, cache.Policy{})
+
+	req := httptest.NewRequest("GET", "/feed.xml", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"wordpress-go-proxy/internal/feedback"
+	"wordpress-go-proxy/internal/filter"
+	"wordpress-go-proxy/internal/ratelimit"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// FeedbackPath is the path answers to whether a page was helpful are
+// submitted to.
+const FeedbackPath = "/api/feedback"
+
+// maxFeedbackForm limits the size of a feedback submission.
+const maxFeedbackForm = 4 << 10
+
+// FeedbackHandler records visitors' answers to whether a page was helpful
+// to a sink.  Answers are not tied to a session: the form carries the page
+// ID, language and a helpful field of yes or no.  Forms posted with a path
+// send the visitor back to it with the outcome in the feedback query
+// parameter, while scripts posting without one get a status code.  Each
+// client address may only submit as many answers as the limiter allows,
+// and answers past the limit are dropped as if they were recorded.
+type FeedbackHandler struct {
+	Sink    feedback.Sink
+	Limiter *ratelimit.Limiter
+
+	// TrustedProxies is the number of proxies whose X-Forwarded-For
+	// entries identify the client, as for request filtering.
+	TrustedProxies int
+
+	now func() time.Time
+}
+
+// NewFeedbackHandler creates a new feedback handler recording answers to
+// sink.
+func NewFeedbackHandler(sink feedback.Sink, limiter *ratelimit.Limiter) *FeedbackHandler {
+	return &FeedbackHandler{
+		Sink:    sink,
+		Limiter: limiter,
+		now:     time.Now,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *FeedbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Feedback request")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.Method != http.MethodPost {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Without a session to check, answers posted by other sites are
+	// refused by browsers' fetch metadata
+	if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		log.Printf("Refusing cross-site feedback")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxFeedbackForm)
+	if err := r.ParseForm(); err != nil {
+		log.Printf("Invalid feedback form: %v", err)
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	path := r.PostFormValue("path")
+	if path != "" && !returnPath(path) {
+		log.Printf("Invalid feedback return path: %q", path)
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	answer, ok := feedbackSubmission(r)
+	if !ok {
+		log.Printf("Invalid feedback")
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	answer.Time = h.now()
+
+	if h.Limiter != nil {
+		ip, ok := filter.ClientIP(r, h.TrustedProxies)
+		if !ok || !h.Limiter.Allow(ip.String()) {
+			log.Printf("Feedback rate limit reached for %s", ip)
+			respondFeedback(w, r, path, models.FeedbackThanks)
+			return
+		}
+	}
+
+	if err := h.Sink.Record(r.Context(), answer); err != nil {
+		log.Printf("Error recording feedback on page %d: %v", answer.PageID, err)
+		respondFeedback(w, r, path, models.FeedbackError)
+		return
+	}
+	respondFeedback(w, r, path, models.FeedbackThanks)
+}
+
+// feedbackSubmission reads an answer from the submitted form, reporting
+// false if a field is missing or invalid.
+func feedbackSubmission(r *http.Request) (models.PageFeedback, bool) {
+	answer := models.PageFeedback{Lang: r.PostFormValue("lang")}
+	pageID, err := strconv.Atoi(r.PostFormValue("page_id"))
+	if err != nil || pageID <= 0 {
+		return answer, false
+	}
+	answer.PageID = pageID
+	if _, ok := models.Languages.Get(answer.Lang); !ok {
+		return answer, false
+	}
+	switch r.PostFormValue("helpful") {
+	case "yes":
+		answer.Helpful = true
+	case "no":
+	default:
+		return answer, false
+	}
+	return answer, true
+}
+
+// respondFeedback sends a visitor back to the page they answered for with
+// the outcome of their submission, or responds with a status code if the
+// form had no path.
+func respondFeedback(w http.ResponseWriter, r *http.Request, path string, status string) {
+	if path != "" {
+		http.Redirect(w, r, path+"?feedback="+status+"#feedback", http.StatusSeeOther)
+		return
+	}
+	if status == models.FeedbackError {
+		http.Error(w, "Error recording feedback", http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newFeedbackData returns the data of the feedback widget rendered on a
+// page, with the outcome of the visitor's last answer if any.
+func newFeedbackData(r *http.Request, lang string, pageID int) *models.FeedbackData {
+	status := r.URL.Query().Get("feedback")
+	if !slices.Contains(models.FeedbackStatuses, status) {
+		status = ""
+	}
+	return &models.FeedbackData{
+		Lang:   lang,
+		Action: FeedbackPath,
+		PageID: pageID,
+		Path:   r.URL.Path,
+		Status: status,
+	}
+}
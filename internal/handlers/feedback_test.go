@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/ratelimit"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// recordingSink is a feedback sink that keeps the answers it records
+type recordingSink struct {
+	answers []models.PageFeedback
+	err     error
+}
+
+func (s *recordingSink) Record(ctx context.Context, feedback models.PageFeedback) error {
+	s.answers = append(s.answers, feedback)
+	return s.err
+}
+
+func TestFeedbackHandler(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	valid := url.Values{
+		"page_id": {"42"},
+		"lang":    {"fr"},
+		"helpful": {"no"},
+		"path":    {"/fr/a-propos"},
+	}
+	with := func(name string, value string) url.Values {
+		form := url.Values{}
+		for k, v := range valid {
+			form[k] = v
+		}
+		form.Set(name, value)
+		return form
+	}
+
+	testCases := []struct {
+		name     string
+		form     url.Values
+		sinkErr  error
+		site     string
+		status   int
+		location string
+		recorded bool
+	}{
+		{name: "Recorded", form: valid, status: http.StatusSeeOther, location: "/fr/a-propos?feedback=thanks#feedback", recorded: true},
+		{name: "Script", form: with("path", ""), status: http.StatusNoContent, recorded: true},
+		{name: "Sink error", form: valid, sinkErr: errors.New("down"), status: http.StatusSeeOther, location: "/fr/a-propos?feedback=error#feedback", recorded: true},
+		{name: "Script sink error", form: with("path", ""), sinkErr: errors.New("down"), status: http.StatusBadGateway, recorded: true},
+		{name: "Invalid answer", form: with("helpful", "maybe"), status: http.StatusBadRequest},
+		{name: "Invalid page", form: with("page_id", "0"), status: http.StatusBadRequest},
+		{name: "Unknown language", form: with("lang", "de"), status: http.StatusBadRequest},
+		{name: "Other site", form: with("path", "//evil.example.com/"), status: http.StatusBadRequest},
+		{name: "Cross-site", form: valid, site: "cross-site", status: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sink := &recordingSink{err: tc.sinkErr}
+			handler := NewFeedbackHandler(sink, nil)
+			handler.now = func() time.Time { return now }
+
+			req := httptest.NewRequest("POST", FeedbackPath, strings.NewReader(tc.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.site != "" {
+				req.Header.Set("Sec-Fetch-Site", tc.site)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.status || w.Header().Get("Location") != tc.location {
+				t.Errorf("Expected %d to %q, got %d to %q", tc.status, tc.location, w.Code, w.Header().Get("Location"))
+			}
+			if !tc.recorded {
+				if len(sink.answers) != 0 {
+					t.Errorf("Expected no answer, got %+v", sink.answers)
+				}
+				return
+			}
+			expected := models.PageFeedback{PageID: 42, Lang: "fr", Helpful: false, Time: now}
+			if len(sink.answers) != 1 || sink.answers[0] != expected {
+				t.Errorf("Expected %+v, got %+v", expected, sink.answers)
+			}
+		})
+	}
+}
+
+func TestFeedbackHandlerLimit(t *testing.T) {
+	sink := &recordingSink{}
+	handler := NewFeedbackHandler(sink, ratelimit.New(1, time.Minute))
+	form := url.Values{"page_id": {"42"}, "lang": {"en"}, "helpful": {"yes"}}
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", FeedbackPath, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Errorf("Expected 204, got %d", w.Code)
+		}
+	}
+	if len(sink.answers) != 1 {
+		t.Errorf("Expected answers past the limit to be dropped, got %d", len(sink.answers))
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", FeedbackPath, nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/auth"
+	"wordpress-go-proxy/internal/forms"
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/internal/router"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// FormPatterns are the route patterns of forms and the pages shown once
+// they are sent.
+var FormPatterns = []string{"/forms/{name}", "/forms/{name}/thanks"}
+
+// maxFormBytes limits the size of a form submission.
+const maxFormBytes = 64 << 10
+
+// tokenField is the name of the form field holding the form's token.
+const tokenField = "_token"
+
+// FormHandler renders the forms of a WordPress forms plugin and forwards
+// their submissions to the plugin's REST route.  Each form's fields are
+// rendered with its form-<name>.html template inside form.html, and their
+// validation rules are read from that markup: fields marked required must
+// be filled in, email fields must hold an address and values may not be
+// longer than their maxlength.  Only declared fields are forwarded.
+//
+// Forms carry a signed, single use token so that submissions must come
+// from a form the proxy served.  Sent forms redirect to a thank-you page
+// rendered with form-thanks.html.
+type FormHandler struct {
+	Forms           map[string]models.Form
+	Route           string
+	SiteNames       map[string]string
+	WordPressClient *api.WordPressClient
+	Templates       *template.Template
+	Signer          *auth.Signer
+
+	fields map[string][]forms.Field
+}
+
+// NewFormHandler creates a new handler for forms submitted to the given
+// REST route of the forms plugin.
+func NewFormHandler(formList []models.Form, route string, siteNames map[string]string, wordPressClient *api.WordPressClient, signer *auth.Signer) *FormHandler {
+	names := []string{"layout.html", "form.html", "form-thanks.html"}
+	for _, form := range formList {
+		names = append(names, formTemplate(form))
+	}
+	tmpl, err := parseTheme(names...)
+	if err != nil {
+		log.Fatal("Error parsing template:", err)
+	}
+
+	h := &FormHandler{
+		Forms:           make(map[string]models.Form, len(formList)),
+		Route:           route,
+		SiteNames:       siteNames,
+		WordPressClient: wordPressClient,
+		Templates:       tmpl,
+		Signer:          signer,
+	}
+	for _, form := range formList {
+		h.Forms[form.Name] = form
+	}
+	if err := h.loadFields(); err != nil {
+		log.Fatal("Error reading form fields:", err)
+	}
+	return h
+}
+
+// formTemplate returns the name of the template rendering a form's fields.
+func formTemplate(form models.Form) string {
+	return "form-" + form.Name + ".html"
+}
+
+// loadFields reads the fields of each form from its template, rendered
+// without values.
+func (h *FormHandler) loadFields() error {
+	h.fields = make(map[string][]forms.Field, len(h.Forms))
+	for name, form := range h.Forms {
+		var markup bytes.Buffer
+		data := models.FormData{Lang: models.Languages.Default().Code, Name: name}
+		tmpl, err := localize(h.Templates, data.Lang)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.ExecuteTemplate(&markup, formTemplate(form), data); err != nil {
+			return err
+		}
+		fields, err := forms.Fields(markup.String())
+		if err != nil {
+			return err
+		}
+		h.fields[name] = fields
+	}
+	return nil
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *FormHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	log.Printf("Form request: %s", path)
+	w.Header().Set("Cache-Control", "no-store")
+
+	if params, ok := router.MustParse(FormPatterns[1]).MatchLocalized(path); ok {
+		form, ok := h.Forms[params["name"]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			log.Printf("Invalid HTTP method: %s", r.Method)
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.renderThanks(w, r, form, params[router.LangParam])
+		return
+	}
+
+	params, ok := router.MustParse(FormPatterns[0]).MatchLocalized(path)
+	form, known := h.Forms[params["name"]]
+	if !ok || !known {
+		http.NotFound(w, r)
+		return
+	}
+	lang := params[router.LangParam]
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.renderForm(w, r, form, models.FormData{Lang: lang}, http.StatusOK)
+	case http.MethodPost:
+		h.submit(w, r, form, lang)
+	default:
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// submit validates a submission of a form and forwards it to the forms
+// plugin.  Invalid submissions are shown again with their errors.
+func (h *FormHandler) submit(w http.ResponseWriter, r *http.Request, form models.Form, lang string) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxFormBytes)
+	if err := r.ParseForm(); err != nil {
+		log.Printf("Invalid form submission: %v", err)
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	values, fieldErrors := forms.Validate(h.fields[form.Name], r.PostForm)
+	data := models.FormData{Lang: lang, Values: make(map[string]string, len(values)), Errors: fieldErrors}
+	for name := range values {
+		data.Values[name] = values.Get(name)
+	}
+
+	if _, err := h.Signer.Verify(r.PostFormValue(tokenField), auth.PurposeForm); err != nil {
+		log.Printf("Invalid token for form %s: %v", form.Name, err)
+		data.Expired = true
+		h.renderForm(w, r, form, data, http.StatusForbidden)
+		return
+	}
+	if len(fieldErrors) > 0 {
+		log.Printf("Invalid fields in form %s: %v", form.Name, fieldErrors)
+		h.renderForm(w, r, form, data, http.StatusBadRequest)
+		return
+	}
+
+	err := h.WordPressClient.SubmitForm(r.Context(), h.Route, form.ID, values)
+	if errors.Is(err, api.ErrFormRejected) {
+		log.Printf("Form %s rejected: %v", form.Name, err)
+		data.Failed = true
+		h.renderForm(w, r, form, data, http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		log.Printf("Error submitting form %s: %v", form.Name, err)
+		data.Failed = true
+		h.renderForm(w, r, form, data, http.StatusBadGateway)
+		return
+	}
+
+	log.Printf("Form %s sent", form.Name)
+	http.Redirect(w, r, form.ThanksPath(lang), http.StatusSeeOther)
+}
+
+// renderForm renders a form inside the site layout with a new token.
+func (h *FormHandler) renderForm(w http.ResponseWriter, r *http.Request, form models.Form, data models.FormData, status int) {
+	data.Name = form.Name
+	data.Action = form.Path(data.Lang)
+	token, _, err := h.Signer.Issue(auth.PurposeForm, form.Name)
+	if err != nil {
+		log.Printf("Error issuing form token: %v", err)
+		renderError(w, r, h.Templates, data.Lang)
+		return
+	}
+	data.Token = token
+	data.CSRFToken = middleware.CSRFToken(r.Context())
+
+	var fields bytes.Buffer
+	if err := executeTemplate(r.Context(), h.Templates, &fields, formTemplate(form), data.Lang, data); err != nil {
+		log.Printf("Error rendering %s template: %v", formTemplate(form), err)
+		renderError(w, r, h.Templates, data.Lang)
+		return
+	}
+	data.Fields = template.HTML(fields.String())
+
+	var content bytes.Buffer
+	if err := executeTemplate(r.Context(), h.Templates, &content, "form.html", data.Lang, data); err != nil {
+		log.Printf("Error rendering form template: %v", err)
+		renderError(w, r, h.Templates, data.Lang)
+		return
+	}
+	h.renderPage(w, r, form, form.Path, data.Lang, template.HTML(content.String()), status)
+}
+
+// renderThanks renders the page shown once a form is sent.
+func (h *FormHandler) renderThanks(w http.ResponseWriter, r *http.Request, form models.Form, lang string) {
+	var content bytes.Buffer
+	data := models.FormThanksData{Lang: lang, Name: form.Name, Home: models.Languages.Prefix(lang)}
+	if err := executeTemplate(r.Context(), h.Templates, &content, "form-thanks.html", lang, data); err != nil {
+		log.Printf("Error rendering form thanks template: %v", err)
+		renderError(w, r, h.Templates, lang)
+		return
+	}
+	h.renderPage(w, r, form, form.ThanksPath, lang, template.HTML(content.String()), http.StatusOK)
+}
+
+// renderPage renders the content of a form page inside the site layout.
+func (h *FormHandler) renderPage(w http.ResponseWriter, r *http.Request, form models.Form, path func(string) string, lang string, content template.HTML, status int) {
+	menu, _ := h.WordPressClient.Menu(lang)
+	data := models.NewFormPageData(lang, form, path, content, menu, h.SiteNames)
+	data.Nonce = middleware.Nonce(r.Context())
+	renderPage(w, r, h.Templates, status, data)
+}
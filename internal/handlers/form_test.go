@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/auth"
+	"wordpress-go-proxy/pkg/models"
+)
+
+var contactForm = models.Form{Name: "contact", ID: "12"}
+
+// setupFormHandler creates a form handler for the contact form with mock
+// templates, forwarding submissions to the given WordPress server
+func setupFormHandler(t *testing.T, baseURL string) *FormHandler {
+	tmpl := template.Must(template.New("layout.html").Parse(`<title>{{.Title}}</title>{{.Content}}`))
+	template.Must(tmpl.New("form.html").Parse(
+		`<form action="{{.Action}}">{{if .Expired}}expired{{end}}{{if .Failed}}failed{{end}}` +
+			`<input type="hidden" name="_token" value="{{.Token}}">{{.Fields}}</form>`))
+	template.Must(tmpl.New("form-thanks.html").Parse(`Thanks <a href="{{.Home}}">home</a>`))
+	template.Must(tmpl.New("form-contact.html").Parse(
+		`<input name="name" value="{{.Value "name"}}" required>{{with .Error "name"}}<p>{{.Code}}</p>{{end}}` +
+			`<input type="email" name="email" value="{{.Value "email"}}" required>{{with .Error "email"}}<p>{{.Code}}</p>{{end}}`))
+
+	handler := &FormHandler{
+		Forms:           map[string]models.Form{contactForm.Name: contactForm},
+		Route:           "contact-form-7/v1/contact-forms/{id}/feedback",
+		SiteNames:       map[string]string{"en": "English Site", "fr": "French Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: baseURL},
+		Templates:       tmpl,
+		Signer:          auth.NewSigner([]byte(strings.Repeat("s", 32)), time.Hour, 0),
+	}
+	if err := handler.loadFields(); err != nil {
+		t.Fatalf("Error loading fields: %v", err)
+	}
+	return handler
+}
+
+// formToken returns the token of a rendered form
+func formToken(body string) string {
+	match := regexp.MustCompile(`name="_token" value="([^"]+)"`).FindStringSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+func TestFormHandler(t *testing.T) {
+	var forwarded url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/contact-form-7/v1/contact-forms/12/feedback" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		r.ParseForm()
+		forwarded = r.PostForm
+		w.Write([]byte(`{"status":"mail_sent"}`))
+	}))
+	defer server.Close()
+	handler := setupFormHandler(t, server.URL)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/fr/forms/contact", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<form action="/fr/forms/contact">`) {
+		t.Fatalf("Expected French form, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Expected form not to be stored, got %q", w.Header().Get("Cache-Control"))
+	}
+	token := formToken(w.Body.String())
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/fr/forms/contact", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Invalid fields are shown again with their errors and a new token
+	w = post(url.Values{"_token": {token}, "name": {"Ada"}, "email": {"ada"}})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `value="Ada"`) || !strings.Contains(w.Body.String(), "<p>email</p>") {
+		t.Errorf("Expected form with email error, got %d: %s", w.Code, w.Body.String())
+	}
+	if forwarded != nil {
+		t.Errorf("Expected invalid form not to be forwarded, got %v", forwarded)
+	}
+	next := formToken(w.Body.String())
+	if next == "" || next == token {
+		t.Errorf("Expected a new token, got %q", next)
+	}
+
+	// Tokens are single use
+	w = post(url.Values{"_token": {token}, "name": {"Ada"}, "email": {"ada@example.com"}})
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "expired") {
+		t.Errorf("Expected expired form, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = post(url.Values{"_token": {next}, "name": {"Ada"}, "email": {"ada@example.com"}, "admin": {"1"}})
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/fr/forms/contact/thanks" {
+		t.Errorf("Expected redirect to thank-you page, got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+	expected := url.Values{"name": {"Ada"}, "email": {"ada@example.com"}}
+	if forwarded.Encode() != expected.Encode() {
+		t.Errorf("Expected declared fields to be forwarded, got %v", forwarded)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/fr/forms/contact/thanks", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `Thanks <a href="/fr/">`) {
+		t.Errorf("Expected thank-you page, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestFormHandlerNotFound(t *testing.T) {
+	handler := setupFormHandler(t, "")
+
+	for _, path := range []string{"/forms/feedback", "/forms/feedback/thanks", "/forms/contact/other"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("DELETE", "/forms/contact", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD, POST" {
+		t.Errorf("Expected 405, got %d allowing %q", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+// TestFormHandlerFailed tests that forms the plugin refuses are shown
+// again
+func TestFormHandlerFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"spam"}`))
+	}))
+	defer server.Close()
+	handler := setupFormHandler(t, server.URL)
+
+	token, _, _ := handler.Signer.Issue(auth.PurposeForm, "contact")
+	form := url.Values{"_token": {token}, "name": {"Ada"}, "email": {"ada@example.com"}}
+	req := httptest.NewRequest("POST", "/forms/contact", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "failed") {
+		t.Errorf("Expected failed form, got %d: %s", w.Code, w.Body.String())
+	}
+}
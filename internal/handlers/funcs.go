@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"wordpress-go-proxy/internal/theme"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// BaseURL is the public URL of the site, which the absURL template
+// function makes URLs absolute with.  It is set at startup from config.
+var BaseURL string
+
+// templateFuncs returns the functions templates are rendered with for a
+// language:
+//
+//   - t "key" args... translates an interface message, formatting args into
+//     it as fmt.Sprintf does
+//   - date value formats a date, such as Modified, in the language
+//   - datetime value formats a time for the datetime attribute of <time>
+//   - url "path" returns a path under the language's home
+//   - absURL "path" returns a path as an absolute URL of the site
+//   - attr "name" value returns an attribute with an escaped value
+func templateFuncs(messages theme.Messages, lang string) template.FuncMap {
+	return template.FuncMap{
+		"t": func(key string, args ...any) string {
+			return translate(messages, lang, key, args...)
+		},
+		"date": func(value any) string {
+			return formatDate(messages, lang, value)
+		},
+		"datetime": machineTime,
+		"url": func(path string) string {
+			return localURL(lang, path)
+		},
+		"absURL": absoluteURL,
+		"attr":   safeAttr,
+	}
+}
+
+// translate returns the text of a message in a language, falling back to
+// the default language and then to the key itself.
+func translate(messages theme.Messages, lang string, key string, args ...any) string {
+	text, ok := messages.Lookup(lang, key)
+	if !ok {
+		text, ok = messages.Lookup(models.Languages.Default().Code, key)
+	}
+	if !ok {
+		text = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// formatDate formats a date as the date.format message of a language, in
+// which {day}, {month} and {year} are replaced by the day of the month,
+// the date.month.<n> message of the month and the year.  Dates are
+// time.Time values or strings starting with a YYYY-MM-DD date, and are
+// formatted as YYYY-MM-DD in languages without a date format.  The zero
+// time gives an empty string, and other values are returned as they are.
+func formatDate(messages theme.Messages, lang string, value any) string {
+	var date time.Time
+	switch v := value.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		date = v
+	case string:
+		parsed, err := time.Parse(time.DateOnly, v[:min(len(v), len(time.DateOnly))])
+		if err != nil {
+			return v
+		}
+		date = parsed
+	default:
+		return fmt.Sprint(value)
+	}
+
+	format, ok := messages.Lookup(lang, "date.format")
+	if !ok {
+		return date.Format(time.DateOnly)
+	}
+	month := translate(messages, lang, "date.month."+strconv.Itoa(int(date.Month())))
+	return strings.NewReplacer(
+		"{day}", strconv.Itoa(date.Day()),
+		"{month}", month,
+		"{year}", strconv.Itoa(date.Year()),
+	).Replace(format)
+}
+
+// machineTime formats a time as RFC 3339 with its offset, as the datetime
+// attribute of <time> expects.  The zero time gives an empty string, and
+// other values are returned as they are.
+func machineTime(value any) string {
+	t, ok := value.(time.Time)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// localURL returns a path relative to the home of a language, such as
+// "search" or "/search", under its prefix.  URLs with a scheme or host are
+// returned as they are.
+func localURL(lang string, path string) string {
+	if strings.Contains(path, "://") || strings.HasPrefix(path, "//") {
+		return path
+	}
+	return models.Languages.Prefix(lang) + strings.TrimPrefix(path, "/")
+}
+
+// absoluteURL returns a path as an absolute URL of the site at BaseURL, or
+// the path alone if no base URL is set.
+func absoluteURL(path string) string {
+	if strings.Contains(path, "://") || strings.HasPrefix(path, "//") {
+		return path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return strings.TrimSuffix(BaseURL, "/") + path
+}
+
+// attrNamePattern matches the attribute names attr accepts.
+var attrNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// unsafeAttrs are attributes whose values are URLs or styles, which attr
+// refuses since escaping alone does not make their values safe.
+var unsafeAttrs = map[string]bool{
+	"action": true, "background": true, "cite": true, "data": true,
+	"formaction": true, "href": true, "ping": true, "poster": true,
+	"src": true, "srcset": true, "style": true,
+}
+
+// safeAttr returns an attribute with an escaped value, for attributes
+// whose names are only known when rendering, such as those of custom
+// fields.  Names that are invalid, event handlers, URLs or styles give no
+// attribute.
+func safeAttr(name string, value any) template.HTMLAttr {
+	name = strings.ToLower(name)
+	if !attrNamePattern.MatchString(name) || strings.HasPrefix(name, "on") || unsafeAttrs[name] {
+		return ""
+	}
+	return template.HTMLAttr(name + `="` + template.HTMLEscapeString(fmt.Sprint(value)) + `"`)
+}
+
+// localizedKey identifies a template set rendered in a language.
+type localizedKey struct {
+	t    *template.Template
+	lang string
+}
+
+var (
+	// localized holds the clone of each template set made for each
+	// language, so templates are cloned and escaped once per language.
+	localized sync.Map
+
+	// templateMessages holds the theme messages of the template sets
+	// parsed by parseTheme.
+	templateMessages sync.Map
+)
+
+// localize returns a template set with its functions bound to a language.
+// Clones are made before the set is first executed, so template sets
+// should only be executed through localize.
+func localize(t *template.Template, lang string) (*template.Template, error) {
+	key := localizedKey{t: t, lang: lang}
+	if clone, ok := localized.Load(key); ok {
+		return clone.(*template.Template), nil
+	}
+
+	clone, err := t.Clone()
+	if err != nil {
+		return nil, err
+	}
+	messages, _ := templateMessages.Load(t)
+	catalog, _ := messages.(theme.Messages)
+	clone.Funcs(templateFuncs(catalog, lang))
+	actual, _ := localized.LoadOrStore(key, clone)
+	return actual.(*template.Template), nil
+}
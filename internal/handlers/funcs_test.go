@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/theme"
+)
+
+var testMessages = theme.Messages{
+	"en": {
+		"search.results": "%d result(s) for “%s”",
+		"nav.label":      "Main menu",
+		"date.format":    "{month} {day}, {year}",
+		"date.month.5":   "May",
+	},
+	"fr": {
+		"search.results": "%d résultat(s) pour « %s »",
+		"date.format":    "{day} {month} {year}",
+		"date.month.5":   "mai",
+	},
+}
+
+func TestTranslate(t *testing.T) {
+	testCases := []struct {
+		lang     string
+		key      string
+		args     []any
+		expected string
+	}{
+		{"fr", "search.results", []any{3, "impôts"}, "3 résultat(s) pour « impôts »"},
+		{"fr", "nav.label", nil, "Main menu"},
+		{"fr", "missing", nil, "missing"},
+	}
+	for _, tc := range testCases {
+		if text := translate(testMessages, tc.lang, tc.key, tc.args...); text != tc.expected {
+			t.Errorf("translate(%q, %q): expected %q, got %q", tc.lang, tc.key, tc.expected, text)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	testCases := []struct {
+		lang     string
+		value    any
+		expected string
+	}{
+		{"en", "2024-05-01", "May 1, 2024"},
+		{"fr", "2024-05-01T10:00:00", "1 mai 2024"},
+		{"fr", time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC), "9 mai 2024"},
+		{"de", "2024-05-01", "2024-05-01"},
+		{"en", "soon", "soon"},
+		{"en", 5, "5"},
+		{"en", time.Time{}, ""},
+	}
+	for _, tc := range testCases {
+		if date := formatDate(testMessages, tc.lang, tc.value); date != tc.expected {
+			t.Errorf("formatDate(%q, %v): expected %q, got %q", tc.lang, tc.value, tc.expected, date)
+		}
+	}
+}
+
+func TestMachineTime(t *testing.T) {
+	published := time.Date(2024, 5, 1, 9, 0, 0, 0, time.FixedZone("EDT", -4*3600))
+	if datetime := machineTime(published); datetime != "2024-05-01T09:00:00-04:00" {
+		t.Errorf("Expected an RFC 3339 time, got %q", datetime)
+	}
+	if datetime := machineTime(time.Time{}); datetime != "" {
+		t.Errorf("Expected no time for the zero time, got %q", datetime)
+	}
+	if datetime := machineTime("2024-05-01"); datetime != "2024-05-01" {
+		t.Errorf("Expected strings unchanged, got %q", datetime)
+	}
+}
+
+func TestURLFuncs(t *testing.T) {
+	originalBaseURL := BaseURL
+	defer func() { BaseURL = originalBaseURL }()
+
+	if url := localURL("fr", "/search"); url != "/fr/search" {
+		t.Errorf("Expected /fr/search, got %q", url)
+	}
+	if url := localURL("en", "contact"); url != "/contact" {
+		t.Errorf("Expected /contact, got %q", url)
+	}
+	if url := localURL("fr", "https://example.org/"); url != "https://example.org/" {
+		t.Errorf("Expected external URL unchanged, got %q", url)
+	}
+
+	BaseURL = ""
+	if url := absoluteURL("/fr/"); url != "/fr/" {
+		t.Errorf("Expected the path without a base URL, got %q", url)
+	}
+	BaseURL = "https://www.example.com/"
+	if url := absoluteURL("fr/"); url != "https://www.example.com/fr/" {
+		t.Errorf("Expected an absolute URL, got %q", url)
+	}
+}
+
+func TestSafeAttr(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    any
+		expected template.HTMLAttr
+	}{
+		{"aria-label", `Say "hi" & <go>`, `aria-label="Say &#34;hi&#34; &amp; &lt;go&gt;"`},
+		{"data-id", 42, `data-id="42"`},
+		{"onclick", "alert(1)", ""},
+		{"HREF", "javascript:alert(1)", ""},
+		{"style", "color: red", ""},
+		{`title" onload="x`, "", ""},
+	}
+	for _, tc := range testCases {
+		if attr := safeAttr(tc.name, tc.value); attr != tc.expected {
+			t.Errorf("safeAttr(%q): expected %q, got %q", tc.name, tc.expected, attr)
+		}
+	}
+}
+
+// TestLocalize tests that template functions are bound to the language
+// templates are rendered in
+func TestLocalize(t *testing.T) {
+	tmpl := template.Must(template.New("page").Funcs(templateFuncs(nil, "")).Parse(
+		`<p {{attr "title" (t "nav.label")}}>{{date "2024-05-01"}} <a href="{{url "search"}}">{{t "search.results" 2 "tax"}}</a></p>`))
+	templateMessages.Store(tmpl, testMessages)
+
+	testCases := []struct {
+		lang     string
+		expected string
+	}{
+		{"en", `<p title="Main menu">May 1, 2024 <a href="/search">2 result(s) for “tax”</a></p>`},
+		{"fr", `<p title="Main menu">1 mai 2024 <a href="/fr/search">2 résultat(s) pour « tax »</a></p>`},
+	}
+	for _, tc := range testCases {
+		for range 2 {
+			var out bytes.Buffer
+			if err := executeTemplate(context.Background(), tmpl, &out, "page", tc.lang, nil); err != nil {
+				t.Fatalf("Error executing template: %v", err)
+			}
+			if out.String() != tc.expected {
+				t.Errorf("%s: expected %s, got %s", tc.lang, tc.expected, out.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"wordpress-go-proxy/internal/access"
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/graphql"
+	"wordpress-go-proxy/internal/transform"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// GraphQLPath is the path of the GraphQL endpoint.
+const GraphQLPath = "/graphql"
+
+// Limits of GraphQL requests, so that a single query cannot fan out into
+// many WordPress requests.  The depth allows the introspection queries of
+// GraphQL tools.
+const (
+	maxGraphQLBody   = 64 << 10
+	maxGraphQLDepth  = 15
+	maxGraphQLFields = 200
+	maxGraphQLPosts  = 100
+)
+
+// AccessRules are the rules restricting sections of the site to some
+// visitors.  GraphQL responses are public, so the content of restricted
+// sections is left out of them.  It is set at startup from config.
+var AccessRules []access.Rule
+
+// GraphQLHandler serves read-only GraphQL queries of pages, posts, menus
+// and search results.  Content is built by a PageHandler, so it is
+// sanitized and has its links rewritten as it is for the HTML pages, and
+// links are paths of the HTML pages.
+type GraphQLHandler struct {
+	Pages  *PageHandler
+	Schema *graphql.Schema
+}
+
+// NewGraphQLHandler creates a new GraphQL handler resolving content with
+// the given page handler.  Posts of the given custom post types can be
+// queried by type name.
+func NewGraphQLHandler(pages *PageHandler, postTypes []models.PostType) *GraphQLHandler {
+	schema, err := graphql.NewSchema(graphQLQuery(pages, postTypes))
+	if err != nil {
+		log.Fatal("Error creating GraphQL schema:", err)
+	}
+	schema.MaxDepth = maxGraphQLDepth
+	schema.MaxFields = maxGraphQLFields
+	return &GraphQLHandler{Pages: pages, Schema: schema}
+}
+
+// ServeHTTP implements the http.Handler interface.  Queries are read from
+// the query string of GET requests and from JSON bodies of POST requests.
+func (h *GraphQLHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("GraphQL request: %s", r.Method)
+
+	// The content is public, so apps on other origins may query it
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	var req graphql.Request
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Max-Age", "86400")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				http.Error(w, "Invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/json" {
+			http.Error(w, "Unsupported content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxGraphQLBody)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	default:
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "Missing query", http.StatusBadRequest)
+		return
+	}
+	if len(req.Query) > maxGraphQLBody {
+		http.Error(w, "Query too long", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	resp := h.Schema.Execute(r.Context(), req)
+	for _, err := range resp.Errors {
+		log.Printf("GraphQL error: %s", err.Message)
+	}
+
+	// Successful queries of GET requests are cached like feeds
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodGet && len(resp.Errors) == 0 {
+		w.Header().Set("Cache-Control", "public, max-age=300") // 5 minutes
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding GraphQL response: %v", err)
+	}
+}
+
+// graphQLPage is a page or post of a custom post type, with the function
+// building the paths of its translations.
+type graphQLPage struct {
+	page *models.WordPressPage
+	path func(lang string, slug string) string
+}
+
+// graphQLQuery returns the Query object of the GraphQL schema.
+func graphQLQuery(pages *PageHandler, postTypes []models.PostType) *graphql.Object {
+	client := pages.WordPressClient
+	page := func(source any) *graphQLPage { return source.(*graphQLPage) }
+
+	translationType := &graphql.Object{
+		Name:        "Translation",
+		Description: "A translation of a page.",
+		Fields: []*graphql.Field{
+			{Name: "lang", Type: graphql.NonNull(graphql.String)},
+			{Name: "path", Type: graphql.NonNull(graphql.String)},
+		},
+	}
+	pageType := &graphql.Object{
+		Name:        "Page",
+		Description: "A page, or a post of a custom post type.",
+		Fields: []*graphql.Field{
+			{Name: "id", Type: graphql.NonNull(graphql.ID), Resolve: graphQLResolve(func(source any) any { return page(source).page.ID })},
+			{Name: "lang", Type: graphql.NonNull(graphql.String), Resolve: graphQLResolve(func(source any) any { return page(source).page.Lang })},
+			{Name: "slug", Type: graphql.NonNull(graphql.String), Resolve: graphQLResolve(func(source any) any { return page(source).page.Slug })},
+			{Name: "path", Description: "The path of the page on the site.", Type: graphql.NonNull(graphql.String), Resolve: graphQLResolve(func(source any) any {
+				p := page(source)
+				return p.path(p.page.Lang, p.page.Slug)
+			})},
+			{Name: "title", Type: graphql.NonNull(graphql.String), Resolve: graphQLResolve(func(source any) any { return page(source).page.Title.Rendered })},
+			{Name: "content", Description: "The sanitized HTML content of the page, null if the page is password protected.", Type: graphql.String, Resolve: graphQLResolve(func(source any) any {
+				p := page(source)
+				if p.page.Locked() {
+					return nil
+				}
+				return pages.processContent(template.HTML(p.page.Content.Rendered), transform.Page{Lang: p.page.Lang, Path: p.path(p.page.Lang, p.page.Slug)})
+			})},
+			{Name: "excerpt", Type: graphql.String, Resolve: graphQLResolve(func(source any) any { return page(source).page.Excerpt.Rendered })},
+			{Name: "modified", Description: "The time the page was last modified, in the site's timezone.", Type: graphql.String, Resolve: graphQLResolve(func(source any) any { return page(source).page.Modified })},
+			{Name: "protected", Description: "Whether the page is protected by a password.", Type: graphql.NonNull(graphql.Boolean), Resolve: graphQLResolve(func(source any) any { return page(source).page.Content.Protected })},
+			{Name: "translations", Type: graphql.NonNull(graphql.List(graphql.NonNull(translationType))), Resolve: graphQLResolve(func(source any) any {
+				p := page(source)
+				translations := []map[string]string{}
+				for _, lang := range models.Languages {
+					if slug := p.page.TranslationSlug(lang.Code); slug != "" && lang.Code != p.page.Lang {
+						translations = append(translations, map[string]string{"lang": lang.Code, "path": p.path(lang.Code, slug)})
+					}
+				}
+				return translations
+			})},
+		},
+	}
+
+	postType := &graphql.Object{
+		Name:        "Post",
+		Description: "A blog post.",
+		Fields: []*graphql.Field{
+			{Name: "id", Type: graphql.NonNull(graphql.ID)},
+			{Name: "lang", Type: graphql.NonNull(graphql.String)},
+			{Name: "slug", Type: graphql.NonNull(graphql.String)},
+			{Name: "path", Description: "The path of the post on the site.", Type: graphql.NonNull(graphql.String), Resolve: graphQLResolve(func(source any) any {
+				return models.RelativeUrl(source.(models.WordPressPost).Link, client.BaseURL)
+			})},
+			{Name: "title", Type: graphql.NonNull(graphql.String), Resolve: graphQLResolve(func(source any) any { return source.(models.WordPressPost).Title.Rendered })},
+			{Name: "excerpt", Type: graphql.String, Resolve: graphQLResolve(func(source any) any { return source.(models.WordPressPost).Excerpt.Rendered })},
+			{Name: "date", Description: "The time the post was published, in UTC.", Type: graphql.String, Resolve: graphQLResolve(func(source any) any { return source.(models.WordPressPost).DateGmt })},
+			{Name: "modified", Description: "The time the post was last modified, in UTC.", Type: graphql.String, Resolve: graphQLResolve(func(source any) any { return source.(models.WordPressPost).ModifiedGmt })},
+		},
+	}
+
+	menuItemType := &graphql.Object{Name: "MenuItem", Description: "An item of the site menu."}
+	menuItemType.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NonNull(graphql.ID)},
+		{Name: "title", Type: graphql.NonNull(graphql.String)},
+		{Name: "url", Type: graphql.NonNull(graphql.String)},
+		{Name: "description", Type: graphql.String},
+		{Name: "target", Type: graphql.String},
+		{Name: "children", Type: graphql.NonNull(graphql.List(graphql.NonNull(menuItemType))), Resolve: graphQLResolve(func(source any) any {
+			if children := source.(*models.MenuItemData).Children; children != nil {
+				return children
+			}
+			return []*models.MenuItemData{}
+		})},
+	}
+
+	searchResultType := &graphql.Object{
+		Name:        "SearchResult",
+		Description: "A page or post matching a search.",
+		Fields: []*graphql.Field{
+			{Name: "id", Type: graphql.NonNull(graphql.ID)},
+			{Name: "title", Type: graphql.NonNull(graphql.String)},
+			{Name: "url", Type: graphql.NonNull(graphql.String), Resolve: graphQLResolve(func(source any) any {
+				return models.RelativeUrl(source.(models.WordPressSearchResult).Url, client.BaseURL)
+			})},
+			{Name: "type", Type: graphql.NonNull(graphql.String)},
+			{Name: "subtype", Type: graphql.NonNull(graphql.String)},
+		},
+	}
+	searchResultsType := &graphql.Object{
+		Name:        "SearchResults",
+		Description: "A page of search results.",
+		Fields: []*graphql.Field{
+			{Name: "total", Type: graphql.NonNull(graphql.Int)},
+			{Name: "totalPages", Type: graphql.NonNull(graphql.Int)},
+			{Name: "results", Type: graphql.NonNull(graphql.List(graphql.NonNull(searchResultType)))},
+		},
+	}
+
+	langArg := &graphql.Argument{Name: "lang", Description: "The language code, defaulting to the site's default language.", Type: graphql.String}
+	typeNames := make([]string, len(postTypes))
+	for i, t := range postTypes {
+		typeNames[i] = t.Name
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.Field{
+			{
+				Name:        "page",
+				Description: "The page at a path of the site, such as /fr/a-propos, or null if there is none.",
+				Type:        pageType,
+				Args:        []*graphql.Argument{{Name: "path", Type: graphql.NonNull(graphql.String)}},
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					path := "/" + strings.Trim(args["path"].(string), "/")
+					if restrictedPath(path) {
+						return nil, nil
+					}
+					p, err := client.FetchPage(ctx, path)
+					if errors.Is(err, api.ErrPageNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						log.Printf("Error fetching page: %v", err)
+						return nil, errors.New("error fetching page")
+					}
+					if !p.Viewable(false) {
+						// Responses are cached publicly, so private pages are left out
+						return nil, nil
+					}
+					return &graphQLPage{page: p, path: models.PagePath}, nil
+				},
+			},
+			{
+				Name:        "posts",
+				Description: "The most recent blog posts.",
+				Type:        graphql.NonNull(graphql.List(graphql.NonNull(postType))),
+				Args: []*graphql.Argument{
+					langArg,
+					{Name: "first", Description: fmt.Sprintf("The number of posts, at most %d.", maxGraphQLPosts), Type: graphql.Int, Default: 10},
+				},
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					lang, err := graphQLLang(args)
+					if err != nil {
+						return nil, err
+					}
+					first, _ := args["first"].(int)
+					if first < 1 || first > maxGraphQLPosts {
+						return nil, fmt.Errorf("first must be between 1 and %d", maxGraphQLPosts)
+					}
+					posts, err := client.FetchPosts(ctx, lang, first)
+					if err != nil {
+						log.Printf("Error fetching posts: %v", err)
+						return nil, errors.New("error fetching posts")
+					}
+					posts = slices.DeleteFunc(posts, func(post models.WordPressPost) bool {
+						return restrictedPath(models.RelativeUrl(post.Link, client.BaseURL))
+					})
+					return posts, nil
+				},
+			},
+			{
+				Name:        "menu",
+				Description: "The items of the site menu.",
+				Type:        graphql.NonNull(graphql.List(graphql.NonNull(menuItemType))),
+				Args:        []*graphql.Argument{langArg},
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					lang, err := graphQLLang(args)
+					if err != nil {
+						return nil, err
+					}
+					menu, ok := client.Menu(lang)
+					if !ok {
+						return []*models.MenuItemData{}, nil
+					}
+					return menu.Items, nil
+				},
+			},
+			{
+				Name:        "search",
+				Description: "A page of the pages and posts matching a query.",
+				Type:        graphql.NonNull(searchResultsType),
+				Args: []*graphql.Argument{
+					{Name: "query", Type: graphql.NonNull(graphql.String)},
+					langArg,
+					{Name: "page", Type: graphql.Int, Default: 1},
+				},
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					lang, err := graphQLLang(args)
+					if err != nil {
+						return nil, err
+					}
+					page, _ := args["page"].(int)
+					if page < 1 || page > maxSearchPage {
+						return nil, fmt.Errorf("page must be between 1 and %d", maxSearchPage)
+					}
+					query := sanitizeQuery(args["query"].(string))
+					if query == "" {
+						return &models.SearchResults{Results: []models.WordPressSearchResult{}}, nil
+					}
+					results, err := client.Search(ctx, query, lang, page)
+					if err != nil {
+						log.Printf("Error fetching search results: %v", err)
+						return nil, errors.New("error fetching search results")
+					}
+					results.Results = slices.DeleteFunc(results.Results, func(result models.WordPressSearchResult) bool {
+						return restrictedPath(models.RelativeUrl(result.Url, client.BaseURL))
+					})
+					if results.Results == nil {
+						results.Results = []models.WordPressSearchResult{}
+					}
+					return results, nil
+				},
+			},
+		},
+	}
+
+	// Posts of custom post types are queried by type name
+	if len(postTypes) > 0 {
+		query.Fields = append(query.Fields, &graphql.Field{
+			Name:        "post",
+			Description: "The post of a custom post type with a slug, or null if there is none.",
+			Type:        pageType,
+			Args: []*graphql.Argument{
+				{Name: "type", Description: "The name of the post type, one of " + strings.Join(typeNames, ", ") + ".", Type: graphql.NonNull(graphql.String)},
+				{Name: "slug", Type: graphql.NonNull(graphql.String)},
+				langArg,
+			},
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				var customType *models.PostType
+				for i := range postTypes {
+					if postTypes[i].Name == args["type"] {
+						customType = &postTypes[i]
+					}
+				}
+				if customType == nil {
+					return nil, fmt.Errorf("unknown post type %q", args["type"])
+				}
+				lang, err := graphQLLang(args)
+				if err != nil {
+					return nil, err
+				}
+				p, err := client.FetchPost(ctx, *customType, args["slug"].(string), lang)
+				if errors.Is(err, api.ErrPageNotFound) {
+					return nil, nil
+				}
+				if err != nil {
+					log.Printf("Error fetching %s post: %v", customType.Name, err)
+					return nil, errors.New("error fetching post")
+				}
+				if restrictedPath(customType.PostPath(p.Lang, p.Slug)) {
+					return nil, nil
+				}
+				return &graphQLPage{page: p, path: customType.PostPath}, nil
+			},
+		})
+	}
+	return query
+}
+
+// restrictedPath reports whether a path of the site is in a section
+// restricted by AccessRules.
+func restrictedPath(path string) bool {
+	_, ok := access.Match(AccessRules, path)
+	return ok
+}
+
+// graphQLResolve returns a resolver computing a field from its parent.
+func graphQLResolve(f func(source any) any) graphql.ResolveFunc {
+	return func(ctx context.Context, source any, args map[string]any) (any, error) {
+		return f(source), nil
+	}
+}
+
+// graphQLLang returns the language argument of a field, defaulting to the
+// site's default language.
+func graphQLLang(args map[string]any) (string, error) {
+	code, _ := args["lang"].(string)
+	if code == "" {
+		return models.Languages.Default().Code, nil
+	}
+	if _, ok := models.Languages.Get(code); !ok {
+		return "", fmt.Errorf("unknown language %q", code)
+	}
+	return code, nil
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"wordpress-go-proxy/internal/access"
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/sanitize"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// graphQLResult is a decoded GraphQL response.
+type graphQLResult struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+		Path    []any  `json:"path"`
+	} `json:"errors"`
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	page := func(id int, slug string, content string, protected bool) []models.WordPressPage {
+		p := models.WordPressPage{ID: id, Status: "publish", Slug: slug, SlugEn: slug, Lang: "en", Modified: "2025-01-02T03:04:05"}
+		p.Title.Rendered = "Title of " + slug
+		p.Content.Rendered = content
+		p.Content.Protected = protected
+		return []models.WordPressPage{p}
+	}
+	responses := map[string]interface{}{
+		"pages/secret": page(3, "secret", "", true),
+	}
+	server := setupTestServer(t, responses)
+	defer server.Close()
+	about := page(1, "about", `<p><a href="`+server.URL+`/contact">Contact</a></p><script>alert(1)</script>`, false)
+	about[0].SlugFr = "a-propos"
+	responses["pages/about"] = about
+
+	client := &api.WordPressClient{BaseURL: server.URL}
+	client.SetMenus(map[string]*models.MenuData{
+		"en": {Items: []*models.MenuItemData{
+			{ID: 10, Title: "About", Url: "/about", Children: []*models.MenuItemData{{ID: 11, Title: "Team", Url: "/about/team"}}},
+		}},
+	})
+	pages := &PageHandler{
+		WordPressClient: client,
+		Templates:       setupTestTemplates(),
+		Sanitizer:       sanitize.New(nil),
+	}
+	handler := NewGraphQLHandler(pages, nil)
+
+	post := func(query string, variables map[string]any) (*httptest.ResponseRecorder, graphQLResult) {
+		t.Helper()
+		body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+		req := httptest.NewRequest("POST", GraphQLPath, strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		var result graphQLResult
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Expected a JSON response, got %d: %s", w.Code, w.Body.String())
+		}
+		return w, result
+	}
+
+	t.Run("Page", func(t *testing.T) {
+		w, result := post(`query About($path: String!) {
+			page(path: $path) { id title content path translations { lang path } }
+			missing: page(path: "/missing") { id }
+		}`, map[string]any{"path": "/about"})
+		if len(result.Errors) > 0 {
+			t.Fatalf("Unexpected errors %+v", result.Errors)
+		}
+		var about struct {
+			ID           string
+			Title        string
+			Content      string
+			Path         string
+			Translations []map[string]string
+		}
+		if err := json.Unmarshal(result.Data["page"], &about); err != nil {
+			t.Fatalf("Error decoding page: %v", err)
+		}
+		if about.ID != "1" || about.Title != "Title of about" || about.Path != "/about" {
+			t.Errorf("Unexpected page %+v", about)
+		}
+		if about.Content != `<p><a href="/contact">Contact</a></p>` {
+			t.Errorf("Expected sanitized content with rewritten links, got %q", about.Content)
+		}
+		if len(about.Translations) != 1 || about.Translations[0]["path"] != "/fr/a-propos" {
+			t.Errorf("Expected the path of the French translation, got %v", about.Translations)
+		}
+		if string(result.Data["missing"]) != "null" {
+			t.Errorf("Expected null for a missing page, got %s", result.Data["missing"])
+		}
+		if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("Unexpected headers %v", w.Header())
+		}
+	})
+
+	t.Run("Protected", func(t *testing.T) {
+		_, result := post(`{ page(path: "/secret") { protected content } }`, nil)
+		if string(result.Data["page"]) != `{"protected":true,"content":null}` {
+			t.Errorf("Expected a protected page without content, got %s", result.Data["page"])
+		}
+	})
+
+	t.Run("Menu", func(t *testing.T) {
+		_, result := post(`{ menu { ...item children { ...item children { id } } } } fragment item on MenuItem { title url }`, nil)
+		expected := `[{"title":"About","url":"/about","children":[{"title":"Team","url":"/about/team","children":[]}]}]`
+		if string(result.Data["menu"]) != expected {
+			t.Errorf("Expected %s, got %s", expected, result.Data["menu"])
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, result := post(`{ menu(lang: "de") { id } }`, nil)
+		if len(result.Errors) != 1 || result.Errors[0].Message != `unknown language "de"` || len(result.Errors[0].Path) != 1 {
+			t.Errorf("Expected a field error for an unknown language, got %+v", result)
+		}
+
+		_, result = post(`{ post(type: "event", slug: "launch") { id } }`, nil)
+		if len(result.Errors) != 1 || result.Data != nil {
+			t.Errorf("Expected a validation error without custom post types, got %+v", result)
+		}
+
+		_, result = post(`mutation { page(path: "/about") { id } }`, nil)
+		if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "Only queries") {
+			t.Errorf("Expected mutations to be rejected, got %+v", result)
+		}
+	})
+
+	t.Run("GET", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", GraphQLPath+"?query="+url.QueryEscape(`{ page(path: "/about") { title } }`), nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"title":"Title of about"`) {
+			t.Errorf("Expected the page, got %d %s", w.Code, w.Body.String())
+		}
+		if w.Header().Get("Cache-Control") != "public, max-age=300" {
+			t.Errorf("Expected GET queries to be cached, got %q", w.Header().Get("Cache-Control"))
+		}
+	})
+
+	t.Run("Requests", func(t *testing.T) {
+		testCases := []struct {
+			method      string
+			target      string
+			contentType string
+			body        string
+			status      int
+		}{
+			{method: "OPTIONS", target: GraphQLPath, status: http.StatusNoContent},
+			{method: "GET", target: GraphQLPath, status: http.StatusBadRequest},
+			{method: "GET", target: GraphQLPath + "?query=%7Bmenu%7Bid%7D%7D&variables=%7B", status: http.StatusBadRequest},
+			{method: "POST", target: GraphQLPath, contentType: "text/plain", body: `{"query":"{menu{id}}"}`, status: http.StatusUnsupportedMediaType},
+			{method: "POST", target: GraphQLPath, contentType: "application/json", body: `{"query":`, status: http.StatusBadRequest},
+			{method: "PUT", target: GraphQLPath, status: http.StatusMethodNotAllowed},
+		}
+		for _, tc := range testCases {
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tc.status {
+				t.Errorf("%s %s: expected status %d, got %d", tc.method, tc.target, tc.status, w.Code)
+			}
+		}
+	})
+}
+
+func TestGraphQLHandlerPostsAndSearch(t *testing.T) {
+	var lastPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/wp/v2/search"):
+			w.Header().Set("X-WP-Total", "11")
+			w.Header().Set("X-WP-TotalPages", "2")
+			json.NewEncoder(w).Encode([]models.WordPressSearchResult{
+				{ID: 1, Title: "Tax credits", Url: "http://" + r.Host + "/tax-credits/", Type: "post", Subtype: "page"},
+			})
+		case strings.HasSuffix(r.URL.Path, "/wp/v2/posts"):
+			p := models.WordPressPost{ID: 5, Slug: "news", Lang: "fr", Link: "http://" + r.Host + "/fr/news/", DateGmt: "2025-01-02T03:04:05"}
+			p.Title.Rendered = "Nouvelles"
+			json.NewEncoder(w).Encode([]models.WordPressPost{p})
+		case strings.HasSuffix(r.URL.Path, "/wp/v2/events"):
+			p := models.WordPressPage{ID: 7, Status: "publish", Slug: "launch", Lang: "en"}
+			p.Title.Rendered = "Launch"
+			json.NewEncoder(w).Encode([]models.WordPressPage{p})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	pages := &PageHandler{WordPressClient: &api.WordPressClient{BaseURL: server.URL}}
+	handler := NewGraphQLHandler(pages, []models.PostType{{Name: "events", Route: "wp/v2/events"}})
+
+	testCases := []struct {
+		name     string
+		query    string
+		path     string
+		expected string
+	}{
+		{
+			name:     "Search",
+			query:    `{ search(query: "tax", page: 2) { total totalPages results { id title url } } }`,
+			path:     "/wp-json/wp/v2/search",
+			expected: `{"data":{"search":{"total":11,"totalPages":2,"results":[{"id":"1","title":"Tax credits","url":"/tax-credits/"}]}}}`,
+		},
+		{
+			name:     "Posts",
+			query:    `{ posts(lang: "fr", first: 1) { id title path date __typename } }`,
+			path:     "/wp-json/wp/v2/posts",
+			expected: `{"data":{"posts":[{"id":"5","title":"Nouvelles","path":"/fr/news/","date":"2025-01-02T03:04:05","__typename":"Post"}]}}`,
+		},
+		{
+			name:     "Custom post",
+			query:    `{ post(type: "events", slug: "launch") { id title path } }`,
+			path:     "/wp-json/wp/v2/events",
+			expected: `{"data":{"post":{"id":"7","title":"Launch","path":"/events/launch"}}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", GraphQLPath+"?query="+url.QueryEscape(tc.query), nil))
+			if strings.TrimSpace(w.Body.String()) != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, w.Body.String())
+			}
+			if lastPath != tc.path {
+				t.Errorf("Expected a request to %s, got %s", tc.path, lastPath)
+			}
+		})
+	}
+}
+
+// TestGraphQLHandlerAccessRules tests that content in sections restricted
+// by access rules is left out of responses
+func TestGraphQLHandlerAccessRules(t *testing.T) {
+	var fetched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched = append(fetched, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/wp/v2/search"):
+			json.NewEncoder(w).Encode([]models.WordPressSearchResult{
+				{ID: 1, Title: "Intranet", Url: "http://" + r.Host + "/intranet/", Type: "post", Subtype: "page"},
+				{ID: 2, Title: "Tax credits", Url: "http://" + r.Host + "/tax-credits/", Type: "post", Subtype: "page"},
+			})
+		case strings.HasSuffix(r.URL.Path, "/wp/v2/posts"):
+			json.NewEncoder(w).Encode([]models.WordPressPost{{ID: 5, Slug: "news", Lang: "en", Link: "http://" + r.Host + "/intranet/news/"}})
+		case strings.HasSuffix(r.URL.Path, "/wp/v2/events"):
+			json.NewEncoder(w).Encode([]models.WordPressPage{{ID: 7, Status: "publish", Slug: "launch", Lang: "en"}})
+		default:
+			json.NewEncoder(w).Encode([]models.WordPressPage{{ID: 3, Status: "publish", Slug: "secret", Lang: "en"}})
+		}
+	}))
+	defer server.Close()
+
+	rules, err := access.ParseRules([]string{"/intranet/=10.0.0.0/8", "/events/=auth"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	AccessRules = rules
+	defer func() { AccessRules = nil }()
+
+	pages := &PageHandler{WordPressClient: &api.WordPressClient{BaseURL: server.URL}}
+	handler := NewGraphQLHandler(pages, []models.PostType{{Name: "events", Route: "wp/v2/events"}})
+
+	query := `{
+		page(path: "/Intranet/secret") { id }
+		post(type: "events", slug: "launch") { id }
+		posts { id }
+		search(query: "tax") { results { id } }
+	}`
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", GraphQLPath+"?query="+url.QueryEscape(query), nil))
+
+	expected := `{"data":{"page":null,"post":null,"posts":[],"search":{"results":[{"id":"2"}]}}}`
+	if strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("Expected %s, got %s", expected, w.Body.String())
+	}
+	for _, path := range fetched {
+		if strings.HasPrefix(path, "/wp-json/wp/v2/pages") {
+			t.Errorf("Expected the restricted page not to be fetched, got a request to %s", path)
+		}
+	}
+}
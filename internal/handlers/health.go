@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// HealthHandler handles liveness and readiness checks used by load
+// balancers and container orchestrators to monitor the proxy.
+type HealthHandler struct {
+	WordPressClient *api.WordPressClient
+	Timeout         time.Duration
+
+	draining atomic.Bool
+}
+
+// HealthStatus is the JSON response returned by the health endpoints.
+type HealthStatus struct {
+	Status   string               `json:"status"`
+	Upstream *UpstreamStatus      `json:"upstream,omitempty"`
+	Menus    map[string]MenuState `json:"menus,omitempty"`
+}
+
+// UpstreamStatus reports the result of probing the WordPress API.  The
+// status code is zero if the API could not be reached.  Errors are only
+// logged, since they name the upstream.
+type UpstreamStatus struct {
+	StatusCode int `json:"statusCode"`
+}
+
+// MenuState reports whether a menu has been loaded for a language.
+type MenuState struct {
+	Loaded bool `json:"loaded"`
+	Items  int  `json:"items"`
+}
+
+// NewHealthHandler creates a new health handler that will probe the
+// given WordPress client's API when checking readiness.
+func NewHealthHandler(wordPressClient *api.WordPressClient) *HealthHandler {
+	return &HealthHandler{
+		WordPressClient: wordPressClient,
+		Timeout:         2 * time.Second,
+	}
+}
+
+// Liveness reports that the process is up and able to serve requests.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, _ *http.Request) {
+	writeHealthStatus(w, http.StatusOK, HealthStatus{Status: "ok"})
+}
+
+// Drain marks the proxy as shutting down so that readiness checks fail
+// and load balancers stop sending it new requests.
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// Readiness probes the WordPress API and reports the upstream status along
+// with the state of the menu cache.  It returns a 503 if the proxy is
+// draining, the upstream is unreachable or any menu is missing.  An
+// upstream that is rate limiting the proxy is reported as rate_limited but
+// leaves it ready, since it serves cached pages meanwhile and taking every
+// instance out of service would turn the rate limiting into an outage.
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		writeHealthStatus(w, http.StatusServiceUnavailable, HealthStatus{Status: "draining"})
+		return
+	}
+
+	status := HealthStatus{
+		Status:   "ok",
+		Upstream: &UpstreamStatus{},
+		Menus:    make(map[string]MenuState),
+	}
+	statusCode := http.StatusOK
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.Timeout)
+	defer cancel()
+
+	upstreamStatus, err := h.WordPressClient.Ping(ctx)
+	status.Upstream.StatusCode = upstreamStatus
+	switch {
+	case errors.Is(err, api.ErrRateLimited):
+		log.Printf("Readiness check rate limited: %v", err)
+		status.Status = "rate_limited"
+	case err != nil:
+		log.Printf("Readiness check failed: %v", err)
+		status.Status = "unavailable"
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	for _, lang := range models.Languages.Codes() {
+		menu, ok := h.WordPressClient.Menu(lang)
+		if !ok || menu == nil {
+			status.Menus[lang] = MenuState{}
+			status.Status = "unavailable"
+			statusCode = http.StatusServiceUnavailable
+			continue
+		}
+		status.Menus[lang] = MenuState{Loaded: true, Items: len(menu.Items)}
+	}
+
+	writeHealthStatus(w, statusCode, status)
+}
+
+// writeHealthStatus writes the health status as JSON.  Health responses
+// are never cached.
+func writeHealthStatus(w http.ResponseWriter, statusCode int, status HealthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Printf("Error encoding health status: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestLiveness(t *testing.T) {
+	handler := NewHealthHandler(&api.WordPressClient{})
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	w := httptest.NewRecorder()
+
+	handler.Liveness(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var status HealthStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+	if status.Status != "ok" {
+		t.Errorf("Expected status 'ok', got %q", status.Status)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	menus := map[string]*models.MenuData{
+		"en": {Items: []*models.MenuItemData{{ID: 1}, {ID: 2}}},
+		"fr": {Items: []*models.MenuItemData{{ID: 3}}},
+	}
+
+	testCases := []struct {
+		name           string
+		upstreamStatus int
+		menus          map[string]*models.MenuData
+		expectedStatus int
+		expectedState  string
+	}{
+		{
+			name:           "Upstream healthy and menus loaded",
+			upstreamStatus: http.StatusOK,
+			menus:          menus,
+			expectedStatus: http.StatusOK,
+			expectedState:  "ok",
+		},
+		{
+			name:           "Upstream error",
+			upstreamStatus: http.StatusBadGateway,
+			menus:          menus,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedState:  "unavailable",
+		},
+		{
+			name:           "Upstream rate limiting",
+			upstreamStatus: http.StatusTooManyRequests,
+			menus:          menus,
+			expectedStatus: http.StatusOK,
+			expectedState:  "rate_limited",
+		},
+		{
+			name:           "Menu missing",
+			upstreamStatus: http.StatusOK,
+			menus:          map[string]*models.MenuData{"en": menus["en"]},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedState:  "unavailable",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodHead {
+					t.Errorf("Expected HEAD request, got %s", r.Method)
+				}
+				if r.URL.Path != "/wp-json/" {
+					t.Errorf("Expected path /wp-json/, got %s", r.URL.Path)
+				}
+				w.WriteHeader(tc.upstreamStatus)
+			}))
+			defer server.Close()
+
+			client := &api.WordPressClient{BaseURL: server.URL}
+			client.SetMenus(tc.menus)
+			handler := NewHealthHandler(client)
+
+			req := httptest.NewRequest("GET", "/readyz", nil)
+			w := httptest.NewRecorder()
+
+			handler.Readiness(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, resp.StatusCode)
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("Expected Content-Type application/json, got %q", contentType)
+			}
+
+			var status HealthStatus
+			if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+				t.Fatalf("Error decoding response: %v", err)
+			}
+			if status.Status != tc.expectedState {
+				t.Errorf("Expected status %q, got %q", tc.expectedState, status.Status)
+			}
+			if status.Upstream == nil || status.Upstream.StatusCode != tc.upstreamStatus {
+				t.Errorf("Expected upstream status %d, got %+v", tc.upstreamStatus, status.Upstream)
+			}
+			if menu := status.Menus["en"]; !menu.Loaded || menu.Items != 2 {
+				t.Errorf("Expected English menu loaded with 2 items, got %+v", menu)
+			}
+		})
+	}
+}
+
+// TestReadinessUnreachable verifies that the error of an unreachable
+// upstream, which names it, is not returned
+func TestReadinessUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	handler := NewHealthHandler(&api.WordPressClient{BaseURL: server.URL})
+
+	w := httptest.NewRecorder()
+	handler.Readiness(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if body := w.Body.String(); strings.Contains(body, server.Listener.Addr().String()) || !strings.Contains(body, `"upstream":{"statusCode":0}`) {
+		t.Errorf("Expected only the upstream status code, got %s", body)
+	}
+}
+
+func TestReadinessDraining(t *testing.T) {
+	upstreamCalled := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalled = true
+	}))
+	defer server.Close()
+
+	handler := NewHealthHandler(&api.WordPressClient{BaseURL: server.URL})
+	handler.Drain()
+
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	w := httptest.NewRecorder()
+
+	handler.Readiness(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	var status HealthStatus
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+	if status.Status != "draining" {
+		t.Errorf("Expected status draining, got %q", status.Status)
+	}
+	if upstreamCalled {
+		t.Error("Expected upstream not to be probed while draining")
+	}
+}
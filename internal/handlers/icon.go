@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/internal/images"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// Paths of the site's icons and web app manifest.
+const (
+	FaviconPath        = "/favicon.ico"
+	AppleTouchIconPath = "/apple-touch-icon.png"
+	ManifestPath       = "/site.webmanifest"
+)
+
+// iconSizes are the sizes of the PNG icons, by path.  The 192 and 512
+// pixel icons are listed in the manifest.
+var iconSizes = map[string]int{
+	AppleTouchIconPath: 180,
+	"/icon-192.png":    192,
+	"/icon-512.png":    512,
+}
+
+// faviconSizes are the sizes held in favicon.ico.
+var faviconSizes = []int{16, 32, 48}
+
+// iconCachePolicy keeps generated icons for a day, refreshing them in the
+// background so a changed site icon is picked up.
+var iconCachePolicy = cache.Policy{TTL: 24 * time.Hour, StaleWhileRevalidate: true}
+
+// SiteIcons reports whether the site's icons are served by an IconHandler,
+// in which case pages link to them in place of the theme's default icon.
+var SiteIcons bool
+
+// IconPaths returns the paths served by an IconHandler.
+func IconPaths() []string {
+	paths := []string{FaviconPath, ManifestPath}
+	for path := range iconSizes {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// webManifest is a web app manifest, describing the site to browsers that
+// can install it.
+type webManifest struct {
+	Name      string            `json:"name"`
+	ShortName string            `json:"short_name"`
+	Lang      string            `json:"lang"`
+	StartURL  string            `json:"start_url"`
+	Display   string            `json:"display"`
+	Icons     []webManifestIcon `json:"icons"`
+}
+
+// webManifestIcon is an icon listed in a web app manifest.
+type webManifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+// IconHandler serves favicon.ico, apple-touch-icon.png and the icons of
+// the web app manifest, generated from a single site icon, along with the
+// manifest itself.  The site icon is read from File, or is the icon set in
+// WordPress if File is empty.
+type IconHandler struct {
+	WordPressClient *api.WordPressClient
+	File            string
+	Cache           *cache.Cache[*images.Result]
+}
+
+// NewIconHandler creates a new handler for icons generated from the given
+// file, or from the WordPress site icon if file is empty.
+func NewIconHandler(wordPressClient *api.WordPressClient, file string) *IconHandler {
+	return &IconHandler{
+		WordPressClient: wordPressClient,
+		File:            file,
+		Cache:           cache.New[*images.Result](iconCachePolicy),
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *IconHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Icon request: %s", r.URL.Path)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var result *images.Result
+	var err error
+	if r.URL.Path == ManifestPath {
+		result, err = manifest()
+	} else if _, ok := iconSizes[r.URL.Path]; ok || r.URL.Path == FaviconPath {
+		result, err = h.Cache.Get(r.Context(), r.URL.Path, func(ctx context.Context) (*images.Result, error) {
+			return h.generate(ctx, r.URL.Path)
+		})
+	} else {
+		http.NotFound(w, r)
+		return
+	}
+	if errors.Is(err, errImageNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("Error generating icon: %v", err)
+		http.Error(w, "Error generating icon", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", result.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(result.Data)))
+	w.Header().Set("Cache-Control", "public, max-age=86400") // 1 day
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(result.Data); err != nil {
+		log.Printf("Error writing icon: %v", err)
+	}
+}
+
+// generate renders the icon at a path from the site icon.
+func (h *IconHandler) generate(ctx context.Context, path string) (*images.Result, error) {
+	src, err := h.source(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if path != FaviconPath {
+		return images.PNG(images.Icon(src, iconSizes[path]))
+	}
+	icons := make([]image.Image, len(faviconSizes))
+	for i, size := range faviconSizes {
+		icons[i] = images.Icon(src, size)
+	}
+	var buf bytes.Buffer
+	if err := images.EncodeICO(&buf, icons); err != nil {
+		return nil, err
+	}
+	return &images.Result{ContentType: "image/x-icon", Data: buf.Bytes()}, nil
+}
+
+// source reads and decodes the site icon.
+func (h *IconHandler) source(ctx context.Context) (image.Image, error) {
+	if h.File != "" {
+		f, err := os.Open(h.File)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return images.Decode(f)
+	}
+
+	iconURL, err := h.WordPressClient.FetchSiteIconURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if iconURL == "" {
+		return nil, errImageNotFound
+	}
+	mediaPath, ok := h.WordPressClient.SiteIconPath(iconURL)
+	if !ok {
+		return nil, fmt.Errorf("site icon is not a WordPress upload: %s", iconURL)
+	}
+
+	resp, err := h.WordPressClient.FetchMedia(ctx, mediaPath, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errImageNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("WordPress returned status %d for site icon: %s", resp.StatusCode, mediaPath)
+	}
+	return images.Decode(resp.Body)
+}
+
+// manifest returns the web app manifest, describing the site in its
+// default language.
+func manifest() (*images.Result, error) {
+	lang := models.Languages.Default()
+	m := webManifest{
+		Name:      lang.SiteName,
+		ShortName: lang.SiteName,
+		Lang:      lang.Code,
+		StartURL:  models.Languages.Prefix(lang.Code),
+		Display:   "browser",
+		Icons: []webManifestIcon{
+			{Src: "/icon-192.png", Sizes: "192x192", Type: "image/png"},
+			{Src: "/icon-512.png", Sizes: "512x512", Type: "image/png"},
+		},
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return &images.Result{ContentType: "application/manifest+json", Data: data}, nil
+}
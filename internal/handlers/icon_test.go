@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wordpress-go-proxy/internal/api"
+)
+
+// siteIconPNG encodes a square site icon for testing.
+func siteIconPNG(t *testing.T) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			img.Set(x, y, color.NRGBA{R: 38, G: 55, B: 74, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestIconHandler(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "icon.png")
+	if err := os.WriteFile(file, siteIconPNG(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewIconHandler(nil, file)
+
+	testCases := []struct {
+		path        string
+		contentType string
+		size        int
+	}{
+		{path: FaviconPath, contentType: "image/x-icon"},
+		{path: AppleTouchIconPath, contentType: "image/png", size: 180},
+		{path: "/icon-192.png", contentType: "image/png", size: 192},
+		{path: "/icon-512.png", contentType: "image/png", size: 512},
+		{path: ManifestPath, contentType: "application/manifest+json"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if w.Header().Get("Content-Type") != tc.contentType {
+				t.Errorf("Expected Content-Type %q, got %q", tc.contentType, w.Header().Get("Content-Type"))
+			}
+			if w.Header().Get("Cache-Control") != "public, max-age=86400" {
+				t.Errorf("Unexpected Cache-Control %q", w.Header().Get("Cache-Control"))
+			}
+			if tc.size > 0 {
+				img, err := png.Decode(w.Body)
+				if err != nil || img.Bounds().Dx() != tc.size || img.Bounds().Dy() != tc.size {
+					t.Errorf("Expected a %dx%d PNG, got %v", tc.size, tc.size, err)
+				}
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", ManifestPath, nil))
+	var manifest webManifest
+	if err := json.Unmarshal(w.Body.Bytes(), &manifest); err != nil {
+		t.Fatalf("Expected a JSON manifest, got %v", err)
+	}
+	if manifest.StartURL != "/" || manifest.Lang != "en" || len(manifest.Icons) != 2 || manifest.Icons[1].Src != "/icon-512.png" {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", FaviconPath, nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", w.Code)
+	}
+}
+
+func TestIconHandlerWordPress(t *testing.T) {
+	icon := siteIconPNG(t)
+	iconURL := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wp-json/":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"site_icon_url": iconURL})
+		case "/wp-content/uploads/2025/01/icon.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(icon)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name    string
+		iconURL string
+		status  int
+	}{
+		{name: "Site icon", iconURL: server.URL + "/wp-content/uploads/2025/01/icon.png", status: http.StatusOK},
+		{name: "No site icon", status: http.StatusNotFound},
+		{name: "Missing upload", iconURL: server.URL + "/wp-content/uploads/2025/01/gone.png", status: http.StatusNotFound},
+		{name: "Other host", iconURL: "https://cdn.example.com/wp-content/uploads/2025/01/icon.png", status: http.StatusBadGateway},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			iconURL = tc.iconURL
+			handler := NewIconHandler(&api.WordPressClient{BaseURL: server.URL}, "")
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", AppleTouchIconPath, nil))
+			if w.Code != tc.status {
+				t.Errorf("Expected status %d, got %d", tc.status, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/internal/images"
+)
+
+// errImageNotFound is returned when the source image does not exist.
+var errImageNotFound = errors.New("image not found")
+
+// ImageHandler serves resized copies of WordPress uploads.  Requests take
+// the form /img?src=/wp-content/uploads/...&w=800 and only the configured
+// widths are allowed so the number of variants per image is bounded.
+type ImageHandler struct {
+	WordPressClient *api.WordPressClient
+	Widths          []int
+	Cache           *cache.Cache[*images.Result]
+}
+
+// NewImageHandler creates a new image handler that resizes uploads fetched
+// from the given WordPress client.  Resized images are cached using the
+// given policy.
+func NewImageHandler(wordPressClient *api.WordPressClient, widths []int, policy cache.Policy) *ImageHandler {
+	return &ImageHandler{
+		WordPressClient: wordPressClient,
+		Widths:          widths,
+		Cache:           cache.New[*images.Result](policy),
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *ImageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Image request: %s", r.URL.RequestURI())
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	src := r.URL.Query().Get("src")
+	mediaPath, ok := uploadPath(src)
+	if !ok || !images.IsResizable(src) {
+		log.Printf("Invalid image source: %s", src)
+		http.Error(w, "Invalid image source", http.StatusBadRequest)
+		return
+	}
+
+	width, err := strconv.Atoi(r.URL.Query().Get("w"))
+	if err != nil || !slices.Contains(h.Widths, width) {
+		log.Printf("Invalid image width: %s", r.URL.Query().Get("w"))
+		http.Error(w, "Invalid image width", http.StatusBadRequest)
+		return
+	}
+
+	key := fmt.Sprintf("%s|%d", mediaPath, width)
+	result, err := h.Cache.Get(r.Context(), key, func(ctx context.Context) (*images.Result, error) {
+		return h.resize(ctx, mediaPath, width)
+	})
+	if errors.Is(err, errImageNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("Error resizing image: %v", err)
+		http.Error(w, "Error resizing image", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", result.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(result.Data)))
+	w.Header().Set("Cache-Control", "public, max-age=604800") // 7 days
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(result.Data); err != nil {
+		log.Printf("Error writing image: %v", err)
+	}
+}
+
+// resize fetches an upload from WordPress and resizes it to the given width.
+func (h *ImageHandler) resize(ctx context.Context, mediaPath string, width int) (*images.Result, error) {
+	resp, err := h.WordPressClient.FetchMedia(ctx, mediaPath, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errImageNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("WordPress returned status %d for image: %s", resp.StatusCode, mediaPath)
+	}
+
+	return images.Process(resp.Body, width)
+}
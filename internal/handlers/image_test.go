@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/cache"
+)
+
+func TestImageHandlerServeHTTP(t *testing.T) {
+	// Create a 400x200 opaque source image
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 200; y++ {
+			src.Set(x, y, color.RGBA{G: 255, A: 255})
+		}
+	}
+	var srcData bytes.Buffer
+	if err := png.Encode(&srcData, src); err != nil {
+		t.Fatalf("Error encoding source image: %v", err)
+	}
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/wp-content/uploads/photo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(srcData.Bytes())
+		case "/wp-content/uploads/broken.png":
+			w.Write([]byte("not an image"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	handler := NewImageHandler(
+		&api.WordPressClient{BaseURL: server.URL},
+		[]int{100, 200},
+		cache.Policy{TTL: time.Minute},
+	)
+
+	testCases := []struct {
+		name           string
+		method         string
+		url            string
+		expectedStatus int
+		expectedWidth  int
+	}{
+		{
+			name:           "Resize upload",
+			method:         "GET",
+			url:            "/img?src=/wp-content/uploads/photo.png&w=100",
+			expectedStatus: http.StatusOK,
+			expectedWidth:  100,
+		},
+		{
+			name:           "Resize media alias",
+			method:         "GET",
+			url:            "/img?src=/media/photo.png&w=200",
+			expectedStatus: http.StatusOK,
+			expectedWidth:  200,
+		},
+		{
+			name:           "Width not allowed",
+			method:         "GET",
+			url:            "/img?src=/media/photo.png&w=150",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "External source",
+			method:         "GET",
+			url:            "/img?src=https://example.com/photo.png&w=100",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Directory traversal",
+			method:         "GET",
+			url:            "/img?src=/media/../wp-config.png&w=100",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing image",
+			method:         "GET",
+			url:            "/img?src=/media/missing.png&w=100",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid image",
+			method:         "GET",
+			url:            "/img?src=/media/broken.png&w=100",
+			expectedStatus: http.StatusBadGateway,
+		},
+		{
+			name:           "Invalid method",
+			method:         "POST",
+			url:            "/img?src=/media/photo.png&w=100",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, resp.StatusCode)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			if contentType := resp.Header.Get("Content-Type"); contentType != "image/jpeg" {
+				t.Errorf("Expected Content-Type image/jpeg, got %q", contentType)
+			}
+			cfg, err := jpeg.DecodeConfig(resp.Body)
+			if err != nil {
+				t.Fatalf("Error decoding response image: %v", err)
+			}
+			if cfg.Width != tc.expectedWidth {
+				t.Errorf("Expected width %d, got %d", tc.expectedWidth, cfg.Width)
+			}
+		})
+	}
+
+	// Resized images are served from the cache
+	before := requests.Load()
+	req := httptest.NewRequest("GET", "/img?src=/wp-content/uploads/photo.png&w=100", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if after := requests.Load(); after != before {
+		t.Errorf("Expected cached image to be served without an upstream request")
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"wordpress-go-proxy/internal/auth"
+)
+
+// job is an internal job run on request, such as warming the caches.
+// Requests must be POSTs carrying a token for the job's purpose, signed
+// with the secret shared with the WordPress plugin, as a bearer credential.
+// The response is sent once the job is done, with its result.
+type job[T any] struct {
+	// name names the job in logs and responses, such as "Warm".
+	name string
+	// failure is the response sent when the job fails.
+	failure string
+	purpose string
+	signer  *auth.Signer
+	// running is the error the job returns while it is already running.
+	running error
+	// check validates authorized requests before the job is run, and its
+	// error is logged and sent as a bad request.  It may be nil.
+	check func(r *http.Request) error
+	run   func(ctx context.Context) (T, error)
+	// respond writes the result of the job, or it is sent as JSON if nil.
+	respond func(w http.ResponseWriter, r *http.Request, result T) error
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (j job[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("%s request", j.name)
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.Method != http.MethodPost {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	credential, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if _, err := j.signer.Verify(credential, j.purpose); err != nil {
+		log.Printf("%s request is not authorized: %v", j.name, err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if j.check != nil {
+		if err := j.check(r); err != nil {
+			log.Printf("Invalid %s request: %v", strings.ToLower(j.name), err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := j.run(r.Context())
+	if errors.Is(err, j.running) {
+		log.Printf("%s request while running", j.name)
+		http.Error(w, j.name+" already running", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("%s: %v", j.failure, err)
+		http.Error(w, j.failure, http.StatusServiceUnavailable)
+		return
+	}
+
+	if j.respond != nil {
+		err = j.respond(w, r, result)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(result)
+	}
+	if err != nil {
+		log.Printf("Error encoding %s response: %v", strings.ToLower(j.name), err)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/middleware"
+)
+
+// mediaRequestHeaders are forwarded to the WordPress origin so it can
+// answer conditional and range requests.
+var mediaRequestHeaders = []string{
+	"Accept",
+	"If-Modified-Since",
+	"If-None-Match",
+	"Range",
+}
+
+// mediaResponseHeaders are passed through from the WordPress origin.
+var mediaResponseHeaders = []string{
+	"Accept-Ranges",
+	"Content-Length",
+	"Content-Range",
+	"Content-Type",
+	"ETag",
+	"Last-Modified",
+}
+
+// MediaHandler proxies requests for WordPress uploads to the origin.  It
+// serves both /wp-content/uploads/* and the shorter /media/* paths.
+type MediaHandler struct {
+	WordPressClient *api.WordPressClient
+}
+
+// NewMediaHandler creates a new media handler that streams uploads from
+// the given WordPress client's origin.
+func NewMediaHandler(wordPressClient *api.WordPressClient) *MediaHandler {
+	return &MediaHandler{
+		WordPressClient: wordPressClient,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.  The upstream response
+// is streamed to the client with its content type and caching validators.
+func (h *MediaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Media request: %s", r.URL.Path)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mediaPath, ok := uploadPath(r.URL.Path)
+	if !ok {
+		log.Printf("Invalid media path: %s", r.URL.Path)
+		http.NotFound(w, r)
+		return
+	}
+
+	header := make(http.Header)
+	for _, name := range mediaRequestHeaders {
+		if value := r.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+
+	// Uploads fetched with the proxy's credentials may be protected, so
+	// like private pages they are only served to signed in visitors and
+	// never cached
+	signedIn := h.WordPressClient.AuthenticateContent && middleware.Authenticated(r.Context())
+	resp, err := h.WordPressClient.FetchMedia(r.Context(), mediaPath, header, signedIn)
+	if err != nil {
+		log.Printf("Error fetching media: %v", err)
+		http.Error(w, "Error fetching media", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent, http.StatusNotModified:
+		for _, name := range mediaResponseHeaders {
+			if value := resp.Header.Get(name); value != "" {
+				w.Header().Set(name, value)
+			}
+		}
+		if signedIn {
+			w.Header().Set("Cache-Control", "private, no-store")
+		} else {
+			w.Header().Set("Cache-Control", "public, max-age=604800") // 7 days
+		}
+	case http.StatusNotFound, http.StatusUnauthorized, http.StatusForbidden:
+		// Protected uploads are not found for anyone else, like private
+		// pages
+		http.NotFound(w, r)
+		return
+	default:
+		log.Printf("WordPress returned status %d for media: %s", resp.StatusCode, mediaPath)
+		http.Error(w, "Error fetching media", http.StatusBadGateway)
+		return
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		log.Printf("Error streaming media: %v", err)
+	}
+}
+
+// uploadPath returns the path of the requested file relative to the
+// uploads directory.  It returns false for paths that are not uploads or
+// that try to escape the uploads directory.
+func uploadPath(requestPath string) (string, bool) {
+	var rel string
+	switch {
+	case strings.HasPrefix(requestPath, "/wp-content/uploads/"):
+		rel = strings.TrimPrefix(requestPath, "/wp-content/uploads/")
+	case strings.HasPrefix(requestPath, "/media/"):
+		rel = strings.TrimPrefix(requestPath, "/media/")
+	default:
+		return "", false
+	}
+
+	cleaned := path.Clean("/" + rel)
+	if cleaned == "/" || cleaned != "/"+rel {
+		return "", false
+	}
+	return strings.TrimPrefix(cleaned, "/"), true
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/auth"
+	"wordpress-go-proxy/internal/middleware"
+)
+
+// setupMediaServer creates a test HTTP server that mimics the WordPress uploads directory
+func setupMediaServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wp-content/uploads/2024/05/logo.png":
+			if r.Header.Get("If-None-Match") == `"abc123"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("ETag", `"abc123"`)
+			w.Header().Set("X-Powered-By", "WordPress")
+			w.Write([]byte("PNG data"))
+		case "/wp-content/uploads/private.pdf":
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("PDF data"))
+		case "/wp-content/uploads/broken.pdf":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestMediaHandlerServeHTTP(t *testing.T) {
+	server := setupMediaServer(t)
+	defer server.Close()
+
+	handler := NewMediaHandler(&api.WordPressClient{BaseURL: server.URL})
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		header         map[string]string
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{
+			name:           "Uploads path",
+			method:         "GET",
+			path:           "/wp-content/uploads/2024/05/logo.png",
+			expectedStatus: http.StatusOK,
+			expectedType:   "image/png",
+			expectedBody:   "PNG data",
+		},
+		{
+			name:           "Media alias path",
+			method:         "GET",
+			path:           "/media/2024/05/logo.png",
+			expectedStatus: http.StatusOK,
+			expectedType:   "image/png",
+			expectedBody:   "PNG data",
+		},
+		{
+			name:           "HEAD request",
+			method:         "HEAD",
+			path:           "/media/2024/05/logo.png",
+			expectedStatus: http.StatusOK,
+			expectedType:   "image/png",
+		},
+		{
+			name:           "Conditional request",
+			method:         "GET",
+			path:           "/media/2024/05/logo.png",
+			header:         map[string]string{"If-None-Match": `"abc123"`},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "Missing file",
+			method:         "GET",
+			path:           "/media/missing.png",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Protected upload",
+			method:         "GET",
+			path:           "/media/private.pdf",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Upstream error",
+			method:         "GET",
+			path:           "/media/broken.pdf",
+			expectedStatus: http.StatusBadGateway,
+		},
+		{
+			name:           "Directory traversal",
+			method:         "GET",
+			path:           "/media/../wp-config.php",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid method",
+			method:         "POST",
+			path:           "/media/2024/05/logo.png",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			for name, value := range tc.header {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, resp.StatusCode)
+			}
+			if tc.expectedType != "" && resp.Header.Get("Content-Type") != tc.expectedType {
+				t.Errorf("Expected Content-Type %q, got %q", tc.expectedType, resp.Header.Get("Content-Type"))
+			}
+			if tc.expectedStatus == http.StatusOK {
+				if cacheControl := resp.Header.Get("Cache-Control"); cacheControl != "public, max-age=604800" {
+					t.Errorf("Expected Cache-Control header, got %q", cacheControl)
+				}
+				if etag := resp.Header.Get("ETag"); etag != `"abc123"` {
+					t.Errorf("Expected ETag to be passed through, got %q", etag)
+				}
+				if resp.Header.Get("X-Powered-By") != "" {
+					t.Error("Expected origin X-Powered-By header to be dropped")
+				}
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if tc.expectedBody != "" && string(body) != tc.expectedBody {
+				t.Errorf("Expected body %q, got %q", tc.expectedBody, string(body))
+			}
+		})
+	}
+}
+
+func TestUploadPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+		ok       bool
+	}{
+		{"/wp-content/uploads/2024/05/logo.png", "2024/05/logo.png", true},
+		{"/media/2024/05/logo.png", "2024/05/logo.png", true},
+		{"/media/", "", false},
+		{"/media/../secret", "", false},
+		{"/media/a/../../secret", "", false},
+		{"/media//double", "", false},
+		{"/about-us", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			got, ok := uploadPath(tc.path)
+			if ok != tc.ok || got != tc.expected {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tc.expected, tc.ok, got, ok)
+			}
+		})
+	}
+}
+
+// TestMediaHandlerAuthenticateContent tests that uploads are only fetched
+// with the proxy's credentials for signed in visitors, and are then not
+// cached
+func TestMediaHandlerAuthenticateContent(t *testing.T) {
+	server := setupMediaServer(t)
+	defer server.Close()
+
+	handler := NewMediaHandler(&api.WordPressClient{BaseURL: server.URL, WordPressAuth: "dXNlcjpwYXNz", AuthenticateContent: true})
+	users, err := auth.ParseHtpasswd("editor:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	signedIn := middleware.BasicAuth(users, "Intranet", nil)(handler)
+
+	testCases := []struct {
+		name                 string
+		path                 string
+		handler              http.Handler
+		expectedStatus       int
+		expectedCacheControl string
+	}{
+		{"Protected upload for anonymous visitor", "/media/private.pdf", handler, http.StatusNotFound, ""},
+		{"Protected upload for signed in visitor", "/media/private.pdf", signedIn, http.StatusOK, "private, no-store"},
+		{"Public upload for anonymous visitor", "/media/2024/05/logo.png", handler, http.StatusOK, "public, max-age=604800"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.path, nil)
+			req.SetBasicAuth("editor", "secret")
+			w := httptest.NewRecorder()
+
+			tc.handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if cacheControl := w.Header().Get("Cache-Control"); cacheControl != tc.expectedCacheControl {
+				t.Errorf("Expected Cache-Control %q, got %q", tc.expectedCacheControl, cacheControl)
+			}
+		})
+	}
+}
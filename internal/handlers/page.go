@@ -1,16 +1,33 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"html/template"
 	"log"
+	"mime"
 	"net/http"
 	"path/filepath"
 	"strings"
 
 	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/embeds"
+	"wordpress-go-proxy/internal/filter"
+	"wordpress-go-proxy/internal/images"
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/internal/ratelimit"
+	"wordpress-go-proxy/internal/router"
+	"wordpress-go-proxy/internal/sanitize"
+	"wordpress-go-proxy/internal/theme"
+	"wordpress-go-proxy/internal/transform"
 	"wordpress-go-proxy/pkg/models"
 )
 
+// maxPasswordForm limits the size of the password form of a protected
+// page.
+const maxPasswordForm = 4 << 10
+
 // PageHandler handles requests for WordPress pages.  It is responsible for
 // fetching the page content from the WordPress API and rendering it using
 // an HTML template.
@@ -18,15 +35,96 @@ type PageHandler struct {
 	SiteNames       map[string]string
 	WordPressClient *api.WordPressClient
 	Templates       *template.Template
+
+	// SiteURL is the public URL of the proxy used for canonical and
+	// hreflang links.  If empty, the URL of the request is used.
+	SiteURL string
+
+	// Sanitizer removes unsafe markup from page content.  If nil, content
+	// is rendered as returned by WordPress.
+	Sanitizer *sanitize.Sanitizer
+
+	// Embeds replaces embedded iframes with facades or links.  If nil,
+	// iframes are rendered as they are.
+	Embeds *embeds.Rewriter
+
+	// Transforms are applied to the content before it is rendered.  If
+	// nil, only links to the WordPress site are rewritten.
+	Transforms transform.Pipeline
+
+	// ImageWidths are the widths offered in the responsive srcset of
+	// content images.  If empty, images are not rewritten.
+	ImageWidths []int
+
+	// MissingTranslation is how the language toggle of untranslated
+	// content is rendered, one of models.MissingTranslationHide or
+	// models.MissingTranslationHome.  The toggle is hidden if empty.
+	MissingTranslation string
+
+	// Feedback renders the widget asking visitors whether a page was
+	// helpful, submitted to FeedbackPath.
+	Feedback bool
+
+	// PasswordLimiter limits the passwords of protected pages each client
+	// address may try, identified behind TrustedProxies proxies as for
+	// request filtering.  Passwords are not limited if nil.
+	PasswordLimiter *ratelimit.Limiter
+	TrustedProxies  int
+
+	// PrefetchTranslations adds the translations of the pages served to
+	// the page cache in the background, so that switching language is
+	// served from the cache.
+	PrefetchTranslations bool
+
+	// NegotiateJSON reports whether pages are sent as JSON, as they are by
+	// the page API, to clients preferring application/json to text/html in
+	// their Accept header.  If nil, pages are always sent as HTML.
+	NegotiateJSON func() bool
 }
 
-var parseTemplateFiles = template.ParseFiles
+// parseTemplateFiles parses template files with the template functions,
+// which are bound to a language when the templates are rendered.
+var parseTemplateFiles = func(filenames ...string) (*template.Template, error) {
+	return template.New(filepath.Base(filenames[0])).Funcs(templateFuncs(nil, "")).ParseFiles(filenames...)
+}
+
+// Theme is the template theme pages are rendered with.  It defaults to the
+// default theme and is replaced at startup from config.
+var Theme = theme.New("templates", theme.Default)
+
+// parseTheme parses the named templates and partials of the current theme,
+// along with its error page if it has one, and loads the messages they are
+// translated with.
+func parseTheme(names ...string) (*template.Template, error) {
+	if Theme.Exists("error.html") {
+		names = append(names, "error.html")
+	}
+	files, err := Theme.Files(names...)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := Theme.Messages()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := parseTemplateFiles(files...)
+	if err != nil {
+		return nil, err
+	}
+	templateMessages.Store(tmpl, messages)
+	return tmpl, nil
+}
 
 // NewPageHandler creates a new page handler that will be used
 // to retrieve and render WordPress pages.
 func NewPageHandler(siteNames map[string]string, wordPressClient *api.WordPressClient) *PageHandler {
-	// Load templates
-	tmpl, err := parseTemplateFiles("templates/layout.html")
+	// Load templates, with the layouts of the page templates editors
+	// may choose
+	layouts, err := templateFiles(pageContent, "*")
+	if err != nil {
+		log.Fatal("Error finding page templates:", err)
+	}
+	tmpl, err := parseTheme(append(layouts, "password.html")...)
 	if err != nil {
 		log.Fatal("Error parsing template:", err)
 	}
@@ -35,6 +133,7 @@ func NewPageHandler(siteNames map[string]string, wordPressClient *api.WordPressC
 		SiteNames:       siteNames,
 		WordPressClient: wordPressClient,
 		Templates:       tmpl,
+		Transforms:      transform.Default(wordPressClient.BaseURL),
 	}
 }
 
@@ -44,61 +143,360 @@ func (h *PageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
 	log.Printf("Page request: %s", path)
 
-	// Only allow GET, HEAD and OPTIONS methods
-	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions {
+	// Only allow GET, HEAD and OPTIONS methods, and POST with the password
+	// of a protected page.  Only small urlencoded forms are read, so that
+	// posts to any page cannot fill memory or disk
+	var passwordPost bool
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); r.Method == http.MethodPost && mediaType == "application/x-www-form-urlencoded" {
+		r.Body = http.MaxBytesReader(w, r.Body, maxPasswordForm)
+		passwordPost = r.PostFormValue("post_password") != ""
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions && !passwordPost {
 		log.Printf("Invalid HTTP method: %s", r.Method)
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Do not allow paths with file extensions
-	if ext := filepath.Ext(path); ext != "" {
-		log.Printf("Invalid path: contains file extension: %s", path)
-		http.NotFound(w, r)
+	if !validPath(w, r, path) {
 		return
 	}
 
-	// Check for invalid URL characters
-	if strings.ContainsAny(path, "<>\"'%\\`^{}|") {
-		log.Printf("URL contains invalid characters: %s", path)
-		http.Error(w, "Invalid URL", http.StatusBadRequest)
+	h.handlePage(w, r, path)
+}
+
+// allowPassword reports whether the client may try another password of a
+// protected page.  Passwords are tried against WordPress, so guessing them
+// is slowed down per client address.
+func (h *PageHandler) allowPassword(w http.ResponseWriter, r *http.Request) bool {
+	if h.PasswordLimiter == nil {
+		return true
+	}
+	ip, ok := filter.ClientIP(r, h.TrustedProxies)
+	if !ok || !h.PasswordLimiter.Allow(ip.String()) {
+		log.Printf("Password rate limit reached for %s", ip)
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// renderPasswordForm renders a protected page with a prompt for its
+// password in place of its content.
+func (h *PageHandler) renderPasswordForm(w http.ResponseWriter, r *http.Request, data models.PageData, incorrect bool) {
+	var content bytes.Buffer
+	form := models.PasswordFormData{Lang: data.Lang, Action: r.URL.Path, Incorrect: incorrect}
+	if err := executeTemplate(r.Context(), h.Templates, &content, "password.html", form.Lang, form); err != nil {
+		log.Printf("Error rendering password template: %v", err)
+		renderError(w, r, h.Templates, data.Lang)
 		return
 	}
+	data.Content = template.HTML(content.String())
+
+	status := http.StatusOK
+	if incorrect {
+		status = http.StatusForbidden
+	}
+	renderPage(w, r, h.Templates, status, data)
+}
+
+// contentTransforms returns the pipeline the content of a page or post is
+// transformed with: embeds are rewritten before the transforms, so they see
+// the replacements, and images are pointed at the resize endpoint after
+// them, once their links are relative.  Without transforms, links to the
+// WordPress site at baseURL are still rewritten to the proxy.
+func contentTransforms(transforms transform.Pipeline, baseURL string, rewriter *embeds.Rewriter, widths []int) transform.Pipeline {
+	if transforms == nil {
+		transforms = transform.Pipeline{transform.InternalLinks{BaseURL: baseURL}}
+	}
+	pipeline := make(transform.Pipeline, 0, len(transforms)+2)
+	if rewriter != nil {
+		pipeline = append(pipeline, rewriter)
+	}
+	pipeline = append(pipeline, transforms...)
+	if len(widths) > 0 {
+		pipeline = append(pipeline, images.Rewriter{Widths: widths})
+	}
+	return pipeline
+}
+
+// transformContent applies the content transforms to the content of a
+// page.  Without transforms, links to the WordPress site at baseURL are
+// still rewritten to the proxy.
+func transformContent(transforms transform.Pipeline, baseURL string, content template.HTML, page transform.Page) template.HTML {
+	if transforms == nil {
+		transforms = transform.Pipeline{transform.InternalLinks{BaseURL: baseURL}}
+	}
+	transformed, err := transforms.Apply(string(content), page)
+	if err != nil {
+		log.Printf("Error transforming content: %v", err)
+		return content
+	}
+	return template.HTML(transformed)
+}
+
+// validPath checks that a path can name a page or post, responding with
+// an error if it cannot.
+func validPath(w http.ResponseWriter, r *http.Request, path string) bool {
+	// Do not allow paths with file extensions
+	if ext := filepath.Ext(path); ext != "" {
+		log.Printf("Invalid path: contains file extension: %s", path)
+		http.NotFound(w, r)
+		return false
+	}
 
 	// Prevent DoS via long URLs
 	if len(path) > 255 {
 		log.Printf("URL path too long: %d characters", len(path))
 		http.Error(w, "URI too long", http.StatusRequestURITooLong)
-		return
+		return false
 	}
 
-	h.handlePage(w, r, path)
+	// Only query WordPress for paths whose segments could be slugs.  The
+	// path has already been decoded once, and is not decoded again, so a
+	// percent sign left in it is refused rather than letting encoded paths
+	// get around the rules matching decoded ones.
+	if !validSlugs(path) {
+		log.Printf("URL contains invalid slugs: %s", path)
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// validSlugs reports whether each segment of a decoded path is a valid
+// slug, such as a language, a post type or a page number.
+func validSlugs(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" && !models.ValidSlug(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitPagePath returns the path of the content a request is for and the
+// page number requested, for content paginated with <!--nextpage-->.  The
+// path of the first page is redirected to the content's own path, and
+// false is returned.
+func splitPagePath(w http.ResponseWriter, r *http.Request, path string) (string, int, bool) {
+	contentPath, pageNumber := models.SplitPagePath(path)
+	if pageNumber == 1 && contentPath != path {
+		target := contentPath
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		log.Printf("Redirecting %s to its first page: %s", path, target)
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return "", 0, false
+	}
+	return contentPath, pageNumber, true
+}
+
+// contentPattern matches the path of a page or post in any language.
+var contentPattern = router.MustParse("/{path...}")
+
+// languageRedirect redirects to the path of a page or post under the
+// prefix of its own language when it was requested under the prefix of
+// another, such as when WordPress returns a translation sharing its slug.
+// It reports whether the request was redirected.
+func languageRedirect(w http.ResponseWriter, r *http.Request, path string, lang string) bool {
+	params, _ := contentPattern.MatchLocalized(path)
+	if lang == "" || lang == params[router.LangParam] {
+		return false
+	}
+	if _, ok := models.Languages.Get(lang); !ok {
+		return false
+	}
+
+	target := models.Languages.Prefix(lang) + params["path"]
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	status := http.StatusPermanentRedirect
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		status = http.StatusMovedPermanently
+	}
+	log.Printf("Redirecting %s to its %s path: %s", path, lang, target)
+	http.Redirect(w, r, target, status)
+	return true
 }
 
 // handlePage processes a page request by retrieving the page content
 // from the WordPress API and rendering it using an HTML template.
-func (h *PageHandler) handlePage(w http.ResponseWriter, _ *http.Request, path string) {
-	page, err := h.WordPressClient.FetchPage(path)
+func (h *PageHandler) handlePage(w http.ResponseWriter, r *http.Request, path string) {
+	respond := h.renderHTML
+	if h.NegotiateJSON != nil && h.NegotiateJSON() {
+		middleware.AddVary(w.Header(), "Accept")
+		if prefersJSON(r.Header.Get("Accept")) {
+			respond = respondJSON
+		}
+	}
+	h.servePage(w, r, path, respond)
+}
+
+// pageResponder sends a page once its data is built.  Locked pages are sent
+// before their content is processed, with incorrect set if the visitor's
+// password was wrong.
+type pageResponder func(w http.ResponseWriter, r *http.Request, page *models.WordPressPage, data models.PageData, incorrect bool)
+
+// renderHTML renders a page inside the site layout, or the password prompt
+// of a locked page.
+func (h *PageHandler) renderHTML(w http.ResponseWriter, r *http.Request, page *models.WordPressPage, data models.PageData, incorrect bool) {
+	if page.Locked() {
+		h.renderPasswordForm(w, r, data, incorrect)
+		return
+	}
+	log.Printf("Rendering page template")
+	renderPage(w, r, h.Templates, http.StatusOK, data)
+}
+
+// servePage retrieves the content of a page from the WordPress API and
+// sends it with respond.
+func (h *PageHandler) servePage(w http.ResponseWriter, r *http.Request, path string, respond pageResponder) {
+	contentPath, pageNumber, ok := splitPagePath(w, r, path)
+	if !ok {
+		return
+	}
+
+	page, err := h.WordPressClient.FetchPage(r.Context(), contentPath)
+	if errors.Is(err, api.ErrPageNotFound) {
+		log.Printf("Page not found: %s", path)
+		http.NotFound(w, r)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Error fetching page content", http.StatusInternalServerError)
+		upstreamError(w, r, "Error fetching page content", err)
 		log.Printf("Error fetching page: %v", err)
 		return
 	}
 
-	menu, ok := h.WordPressClient.Menus[page.Lang]
+	// Private pages are only shown to visitors signed in through the site
+	// or section authentication, and are not found for anyone else so that
+	// their paths do not leak
+	if !page.Viewable(middleware.Authenticated(r.Context())) {
+		log.Printf("Page not viewable with status %q: %s", page.Status, path)
+		http.NotFound(w, r)
+		return
+	}
+	if languageRedirect(w, r, path, page.Lang) {
+		return
+	}
+	if h.PrefetchTranslations {
+		h.prefetchTranslations(r.Context(), page)
+	}
+
+	// Protected pages depend on the visitor's password, and private pages
+	// on the visitor being signed in, so neither are cached or indexed
+	var incorrect bool
+	if page.Content.Protected || page.Private() {
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
+	if page.Private() {
+		w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	}
+	if password := r.PostFormValue("post_password"); page.Locked() && r.Method == http.MethodPost && password != "" {
+		if !h.allowPassword(w, r) {
+			return
+		}
+		unlocked, err := h.WordPressClient.FetchProtectedPage(r.Context(), contentPath, password)
+		switch {
+		case errors.Is(err, api.ErrIncorrectPassword):
+			incorrect = true
+		case err != nil:
+			upstreamError(w, r, "Error fetching page content", err)
+			log.Printf("Error fetching protected page: %v", err)
+			return
+		default:
+			page = unlocked
+		}
+	}
+
+	menu, ok := h.WordPressClient.Menu(page.Lang)
 	if !ok {
-		log.Printf("Warning: No menu found for language %s defaulting to 'en'", page.Lang)
-		menu = h.WordPressClient.Menus["en"]
+		defaultLang := models.Languages.Default().Code
+		log.Printf("Warning: No menu found for language %s defaulting to '%s'", page.Lang, defaultLang)
+		menu, _ = h.WordPressClient.Menu(defaultLang)
+	}
+
+	data := models.NewPageData(page, menu, h.SiteNames)
+	addSurrogateKeys(w, models.PageSurrogateKey(page.ID), models.TypeSurrogateKey(models.SurrogateKeyPageType), models.LangSurrogateKey(data.Lang))
+	data.SetLinks(page, siteURL(w, h.SiteURL, r))
+	data.SetMissingTranslation(h.MissingTranslation)
+	data.Nonce = middleware.Nonce(r.Context())
+	if page.Locked() {
+		respond(w, r, page, data, incorrect)
+		return
+	}
+	if !data.Paginate(contentPath, pageNumber) {
+		log.Printf("Page %d not found: %s", pageNumber, contentPath)
+		http.NotFound(w, r)
+		return
+	}
+	if h.Feedback {
+		data.Feedback = newFeedbackData(r, data.Lang, page.ID)
+	}
+	data.Content = h.processContent(data.Content, transform.Page{Lang: data.Lang, Path: contentPath})
+
+	// The breadcrumb trail is optional so errors only drop the ancestors
+	if data.ShowBreadcrumb && page.Parent != 0 {
+		ancestors, err := h.WordPressClient.FetchAncestors(r.Context(), page)
+		if err != nil {
+			log.Printf("Error fetching page ancestors: %v", err)
+		} else {
+			data.Breadcrumbs = models.NewBreadcrumbs(data.Lang, ancestors)
+			for _, ancestor := range ancestors {
+				addSurrogateKeys(w, models.PageSurrogateKey(ancestor.ID))
+			}
+		}
 	}
 
-	data := models.NewPageData(page, menu, h.SiteNames, h.WordPressClient.BaseURL)
+	// Link to child pages so deep sections get local navigation.  The home
+	// page is skipped since the site menu already covers the top level.
+	if data.ShowBreadcrumb {
+		children, err := h.WordPressClient.FetchChildren(r.Context(), page.ID, data.Lang)
+		if err != nil {
+			log.Printf("Error fetching child pages: %v", err)
+		} else {
+			data.SectionNav = models.NewSectionNav(data.Lang, children)
+			for _, child := range children {
+				addSurrogateKeys(w, models.PageSurrogateKey(child.ID))
+			}
+		}
+	}
 
-	log.Printf("Rendering page template")
-	err = h.Templates.ExecuteTemplate(w, "layout.html", data)
-	if err != nil {
-		http.Error(w, "Error rendering template", http.StatusInternalServerError)
-		log.Printf("Error rendering template: %v", err)
+	respond(w, r, page, data, false)
+}
+
+// processContent sanitizes the content of a page, rewrites its embeds and
+// links, and points its images at the resize endpoint.
+func (h *PageHandler) processContent(content template.HTML, page transform.Page) template.HTML {
+	if h.Sanitizer != nil {
+		content = template.HTML(h.Sanitizer.Sanitize(string(content)))
+	}
+	transforms := contentTransforms(h.Transforms, h.WordPressClient.BaseURL, h.Embeds, h.ImageWidths)
+	return transformContent(transforms, h.WordPressClient.BaseURL, content, page)
+}
+
+// prefetchTranslations fetches the translations of a page that are not in
+// the page cache in the background, one request per language.
+func (h *PageHandler) prefetchTranslations(ctx context.Context, page *models.WordPressPage) {
+	translations := make(map[string]string)
+	for _, lang := range models.Languages {
+		if slug := page.TranslationSlug(lang.Code); slug != "" && lang.Code != page.Lang {
+			translations[lang.Code] = slug
+		}
+	}
+	if len(translations) == 0 {
 		return
 	}
-	log.Printf("Rendering page template complete")
+
+	// The prefetch outlives the request, which is served without waiting
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		for lang, slug := range translations {
+			if _, err := h.WordPressClient.FetchPages(ctx, lang, []string{slug}); err != nil {
+				log.Printf("Error prefetching %s translation %s: %v", lang, slug, err)
+			}
+		}
+	}()
 }
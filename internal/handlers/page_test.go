@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"html/template"
 	"io"
@@ -9,8 +10,17 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"wordpress-go-proxy/internal/access"
 	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/auth"
+	"wordpress-go-proxy/internal/cache"
+	"wordpress-go-proxy/internal/embeds"
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/internal/ratelimit"
+	"wordpress-go-proxy/internal/sanitize"
+	"wordpress-go-proxy/internal/theme"
 	"wordpress-go-proxy/pkg/models"
 )
 
@@ -28,6 +38,314 @@ func setupTestTemplates() *template.Template {
 	return tmpl
 }
 
+// TestParseTheme verifies the default theme and its partials render
+func TestParseTheme(t *testing.T) {
+	originalTheme := Theme
+	Theme = theme.New("../../templates", theme.Default)
+	defer func() { Theme = originalTheme }()
+
+	tmpl, err := parseTheme("layout.html", "search.html", "post.html", "archive.html", "password.html", "form.html", "form-thanks.html", "form-contact.html")
+	if err != nil {
+		t.Fatalf("Error parsing theme: %v", err)
+	}
+
+	data := models.PageData{
+		Lang:       "en",
+		Title:      "Test Page",
+		SiteName:   "English Site",
+		Home:       "/",
+		SearchPath: "/search",
+		Content:    template.HTML("<p>Test content</p>"),
+		Menu:       &models.MenuData{},
+	}
+	var out bytes.Buffer
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+
+	for _, expected := range []string{"<gcds-header", "<gcds-top-nav", "<p>Test content</p>", "<gcds-footer", `<gcds-link href="#main-content">Skip to main content</gcds-link>`} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got: %s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "<gcds-notice") {
+		t.Errorf("Expected no alert without fields, got: %s", out.String())
+	}
+	if strings.Contains(out.String(), "lang-href") {
+		t.Errorf("Expected no language toggle without a translation, got: %s", out.String())
+	}
+	if strings.Contains(out.String(), "<gcds-date-modified") {
+		t.Errorf("Expected no modified date without one, got: %s", out.String())
+	}
+
+	// Dates are formatted in the page language
+	data.Lang = "fr"
+	data.Modified = time.Date(2023, 5, 15, 10, 30, 0, 0, time.FixedZone("EDT", -4*3600))
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	if !strings.Contains(out.String(), `<time datetime="2023-05-15T10:30:00-04:00">15 mai 2023</time>`) {
+		t.Errorf("Expected the modified date in French, got: %s", out.String())
+	}
+	data.Lang = "en"
+	data.Modified = time.Time{}
+
+	// Untranslated pages may link the toggle to the other home page
+	data.LangSwapFallback = "/fr/"
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	if !strings.Contains(out.String(), `lang-href="/fr/"`) {
+		t.Errorf("Expected language toggle to the French home page, got: %s", out.String())
+	}
+	data.LangSwapFallback = ""
+
+	data.Menu = &models.MenuData{Items: []*models.MenuItemData{
+		{Title: "Services", Url: "/services", Class: "mega", Children: []*models.MenuItemData{
+			{Title: "Renew", Url: "/renew", Depth: 1, Children: []*models.MenuItemData{
+				{Title: "Test Page", Url: "/online", Depth: 2, Target: "_blank", Children: []*models.MenuItemData{}},
+			}},
+		}},
+	}}
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	for _, expected := range []string{
+		`<gcds-nav-group open-trigger="Services" menu-label="Services" class="mega">`,
+		`<gcds-nav-group open-trigger="Renew" menu-label="Renew">`,
+		`<gcds-nav-link href="/online" current aria-current="page" target="_blank">Test Page</gcds-nav-link>`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected nested menu to contain %q, got: %s", expected, out.String())
+		}
+	}
+
+	// Menu items are current by path once it is known, and links to the
+	// other language are marked as parts in it
+	data.Path = "/renew/"
+	data.Menu.Items = append(data.Menu.Items, &models.MenuItemData{Title: "Français", Url: "/fr/", Children: []*models.MenuItemData{}})
+	data.Menu.Items[0].Children[0].Children = []*models.MenuItemData{}
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	for _, expected := range []string{
+		`<gcds-nav-link href="/renew" current aria-current="page">Renew</gcds-nav-link>`,
+		`<gcds-nav-link href="/fr/" lang="fr" hreflang="fr">Français</gcds-nav-link>`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected menu to contain %q, got: %s", expected, out.String())
+		}
+	}
+	data.Path = ""
+	data.Menu = &models.MenuData{}
+
+	data.Fields = models.Fields{"alert": map[string]any{"type": "warning", "title": "Closed", "message": "<b>Office</b> closed"}}
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	if !strings.Contains(out.String(), `<gcds-notice type="warning" notice-title-tag="h2" notice-title="Closed">`) {
+		t.Errorf("Expected alert from fields, got: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "&lt;b&gt;Office&lt;/b&gt; closed") {
+		t.Errorf("Expected alert message to be escaped, got: %s", out.String())
+	}
+
+	// Site-wide alerts are shown above the page's
+	data.Alerts = []models.Alert{{Type: "danger", Title: "Outage & delays", Message: "<p>Services are down</p>"}}
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	for _, expected := range []string{
+		`<section class="site-alerts" aria-label="Alerts">`,
+		`<gcds-notice type="danger" notice-title-tag="h2" notice-title="Outage &amp; delays">`,
+		`<p>Services are down</p>`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected site-wide alert to contain %q, got: %s", expected, out.String())
+		}
+	}
+	data.Alerts = nil
+	data.Fields = nil
+
+	// Pages ask visitors whether they were helpful, then thank them
+	data.Feedback = &models.FeedbackData{Lang: "en", Action: FeedbackPath, PageID: 42, Path: "/about"}
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	for _, expected := range []string{`action="/api/feedback"`, `name="page_id" value="42"`, `name="helpful" value="no"`} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected feedback widget to contain %q, got: %s", expected, out.String())
+		}
+	}
+	data.Feedback.Status = models.FeedbackThanks
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	if !strings.Contains(out.String(), "Thank you for your feedback") || strings.Contains(out.String(), "<form") {
+		t.Errorf("Expected thanks in place of the feedback form, got: %s", out.String())
+	}
+	data.Feedback = nil
+
+	// Generated icons replace the default icon
+	data.SiteIcons = true
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	if !strings.Contains(out.String(), `<link rel="manifest" href="/site.webmanifest">`) || strings.Contains(out.String(), "design-system.alpha.canada.ca/favicon.ico") {
+		t.Errorf("Expected links to the generated icons, got: %s", out.String())
+	}
+	data.SiteIcons = false
+
+	data.Tagline = "Services & information"
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	if !strings.Contains(out.String(), `<meta name="description" content="Services &amp; information">`) {
+		t.Errorf("Expected the tagline as description, got: %s", out.String())
+	}
+	data.Tagline = ""
+
+	// The SEO title and description replace the page title and tagline
+	data.SEOTitle = "About us - Example Site"
+	data.Description = "Who we are & what we do"
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	for _, expected := range []string{
+		`<title>About us - Example Site</title>`,
+		`<meta name="description" content="Who we are &amp; what we do">`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected SEO metadata %q, got: %s", expected, out.String())
+		}
+	}
+	data.SEOTitle, data.Description = "", ""
+
+	data.NoIndex = true
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	if !strings.Contains(out.String(), `<meta name="robots" content="noindex">`) {
+		t.Errorf("Expected a robots meta tag, got: %s", out.String())
+	}
+	data.NoIndex = false
+
+	// Structured data is rendered as a JSON-LD script escaped for HTML
+	canonical, title := data.Canonical, data.Title
+	data.Canonical = "https://example.com/about"
+	data.Title = "Fish </script> chips"
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	for _, expected := range []string{
+		`<script type="application/ld+json">`,
+		`"@context":"https://schema.org"`,
+		`"name":"Fish \u003c/script\u003e chips"`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected structured data to contain %q, got: %s", expected, out.String())
+		}
+	}
+	data.Canonical, data.Title = canonical, title
+
+	// Configured assets load after the theme's, scripts with the nonce and
+	// analytics ID
+	data.Nonce = "abc123"
+	data.Assets = models.Assets{
+		Stylesheets: []string{"https://cdn.example.com/extra.css"},
+		Scripts:     []string{"/static/js/analytics.js"},
+		AnalyticsID: "G-TEST123",
+	}
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	for _, expected := range []string{
+		`<link rel="stylesheet" href="https://cdn.example.com/extra.css">`,
+		`<script defer nonce="abc123" src="/static/js/analytics.js" data-analytics-id="G-TEST123"></script>`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected configured asset %q, got: %s", expected, out.String())
+		}
+	}
+
+	// Analytics loads the provider's script with the nonce
+	data.Analytics = &models.Analytics{Provider: "google", ID: "G-TEST123"}
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	for _, expected := range []string{
+		`<script async nonce="abc123" src="https://www.googletagmanager.com/gtag/js?id=G-TEST123"></script>`,
+		`gtag("config", "G-TEST123");`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected analytics to contain %q, got: %s", expected, out.String())
+		}
+	}
+	data.Analytics = &models.Analytics{Provider: "adobe", ID: "abc123/def456/launch-789abc"}
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "layout.html", data.Lang, data); err != nil {
+		t.Fatalf("Error executing template: %v", err)
+	}
+	if !strings.Contains(out.String(), `<script async nonce="abc123" src="https://assets.adobedtm.com/abc123/def456/launch-789abc.min.js"></script>`) {
+		t.Errorf("Expected the Adobe launch script, got: %s", out.String())
+	}
+	data.Nonce = ""
+	data.Assets = models.Assets{}
+	data.Analytics = nil
+
+	// Posts render their comments and the comment form
+	post := models.PostData{
+		Lang:        "fr",
+		Comments:    []models.CommentData{{ID: 7, AuthorName: "Ada", Content: "<p>Bravo</p>", Replies: []models.CommentData{{ID: 8, AuthorName: "Grace"}}}},
+		CommentForm: &models.CommentFormData{Lang: "fr", Action: "/comments", PostID: 1, Path: "/fr/events/lancement", Status: models.CommentLimited},
+	}
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "post.html", post.Lang, post); err != nil {
+		t.Fatalf("Error executing post template: %v", err)
+	}
+	for _, expected := range []string{
+		`<section id="comments"`, `<li id="comment-8"`, "<p>Bravo</p>", `name="website"`,
+		`<input type="hidden" name="path" value="/fr/events/lancement">`, "trop de commentaires",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected post to contain %q, got: %s", expected, out.String())
+		}
+	}
+
+	// Form fields render their errors
+	form := models.FormData{Lang: "fr", Values: map[string]string{"your-email": "ada"}, Errors: map[string]string{"your-email": models.FieldEmail}}
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "form-contact.html", form.Lang, form); err != nil {
+		t.Fatalf("Error executing form template: %v", err)
+	}
+	if !strings.Contains(out.String(), `value="ada"`) || !strings.Contains(out.String(), `error-message="Entrez une adresse courriel valide."`) {
+		t.Errorf("Expected email error, got: %s", out.String())
+	}
+
+	out.Reset()
+	if err := executeTemplate(context.Background(), tmpl, &out, "error.html", "fr", models.ErrorPageData{Lang: "fr", Home: "/fr/", Status: 500}); err != nil {
+		t.Fatalf("Error executing error template: %v", err)
+	}
+	if !strings.Contains(out.String(), "Une erreur s&#39;est produite") || !strings.Contains(out.String(), `href="/fr/"`) {
+		t.Errorf("Expected French error page, got: %s", out.String())
+	}
+}
+
 // setupTestServer creates a test HTTP server that mimics WordPress API responses
 func setupTestServer(t *testing.T, responses map[string]interface{}) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -102,8 +420,8 @@ func TestNewPageHandler(t *testing.T) {
 		server.URL,
 		"testuser",
 		"testpass",
-		"menu-en",
-		"menu-fr",
+		map[string]string{"en": "menu-en", "fr": "menu-fr"},
+		api.DefaultTimeouts,
 	)
 
 	// Create site names
@@ -138,15 +456,17 @@ func TestServeHTTP(t *testing.T) {
 	// Setup the test responses
 	testResponses := map[string]interface{}{
 		"defaultPage": []models.WordPressPage{{
-			ID:   1,
-			Slug: "test-page",
-			Lang: "en",
+			ID:     1,
+			Status: "publish",
+			Slug:   "test-page",
+			Lang:   "en",
 			Title: struct {
 				Rendered string `json:"rendered"`
 			}{Rendered: "Test Page"},
 			Content: struct {
-				Rendered string `json:"rendered"`
-				Raw      string `json:"raw,omitempty"`
+				Rendered  string `json:"rendered"`
+				Raw       string `json:"raw,omitempty"`
+				Protected bool   `json:"protected,omitempty"`
 			}{Rendered: "<p>Test content</p>"},
 		}},
 	}
@@ -160,8 +480,8 @@ func TestServeHTTP(t *testing.T) {
 		server.URL,
 		"testuser",
 		"testpass",
-		"menu-en",
-		"menu-fr",
+		map[string]string{"en": "menu-en", "fr": "menu-fr"},
+		api.DefaultTimeouts,
 	)
 
 	// Create handler with the real client and mocked templates
@@ -225,6 +545,55 @@ func TestServeHTTP(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectError:    true,
 		},
+		{
+			name:           "Path with non-ASCII slug",
+			method:         "GET",
+			path:           "/%C3%A9t%C3%A9",
+			expectedStatus: http.StatusOK,
+			expectError:    false,
+		},
+		{
+			name:           "Path encoded twice",
+			method:         "GET",
+			path:           "/%25c3%25a9t%25c3%25a9",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "Path with a literal percent",
+			method:         "GET",
+			path:           "/100%25",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "Path with encoded invalid characters",
+			method:         "GET",
+			path:           "/about%253Cus%253E",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "Path with upper case letters",
+			method:         "GET",
+			path:           "/About-Us",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "Path with a segment too long for a slug",
+			method:         "GET",
+			path:           "/" + strings.Repeat("a", models.MaxSlugLength+1),
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "Path with invalid UTF-8",
+			method:         "GET",
+			path:           "/caf%E9",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
 		{
 			name:           "Path too long",
 			method:         "GET",
@@ -286,15 +655,17 @@ func TestHandlePage(t *testing.T) {
 			path: "/about-us",
 			testResponses: map[string]interface{}{
 				"pages/about-us": []models.WordPressPage{{
-					ID:   1,
-					Slug: "about-us",
-					Lang: "en",
+					ID:     1,
+					Status: "publish",
+					Slug:   "about-us",
+					Lang:   "en",
 					Title: struct {
 						Rendered string `json:"rendered"`
 					}{Rendered: "About Us"},
 					Content: struct {
-						Rendered string `json:"rendered"`
-						Raw      string `json:"raw,omitempty"`
+						Rendered  string `json:"rendered"`
+						Raw       string `json:"raw,omitempty"`
+						Protected bool   `json:"protected,omitempty"`
 					}{Rendered: "<p>About us content</p>"},
 				}},
 			},
@@ -306,15 +677,17 @@ func TestHandlePage(t *testing.T) {
 			path: "/fr/a-propos",
 			testResponses: map[string]interface{}{
 				"pages/a-propos": []models.WordPressPage{{
-					ID:   2,
-					Slug: "a-propos",
-					Lang: "fr",
+					ID:     2,
+					Status: "publish",
+					Slug:   "a-propos",
+					Lang:   "fr",
 					Title: struct {
 						Rendered string `json:"rendered"`
 					}{Rendered: "À propos"},
 					Content: struct {
-						Rendered string `json:"rendered"`
-						Raw      string `json:"raw,omitempty"`
+						Rendered  string `json:"rendered"`
+						Raw       string `json:"raw,omitempty"`
+						Protected bool   `json:"protected,omitempty"`
 					}{Rendered: "<p>Contenu à propos</p>"},
 				}},
 			},
@@ -327,7 +700,7 @@ func TestHandlePage(t *testing.T) {
 			testResponses: map[string]interface{}{
 				"pages/not-found": []models.WordPressPage{},
 			},
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusNotFound,
 		},
 	}
 
@@ -342,8 +715,8 @@ func TestHandlePage(t *testing.T) {
 				server.URL,
 				"testuser",
 				"testpass",
-				"menu-en",
-				"menu-fr",
+				map[string]string{"en": "menu-en", "fr": "menu-fr"},
+				api.DefaultTimeouts,
 			)
 
 			// Create handler
@@ -388,15 +761,17 @@ func TestTemplateRenderingError(t *testing.T) {
 	// Setup test server with a valid page response
 	testResponses := map[string]interface{}{
 		"pages/test-page": []models.WordPressPage{{
-			ID:   1,
-			Slug: "test-page",
-			Lang: "en",
+			ID:     1,
+			Status: "publish",
+			Slug:   "test-page",
+			Lang:   "en",
 			Title: struct {
 				Rendered string `json:"rendered"`
 			}{Rendered: "Test Page"},
 			Content: struct {
-				Rendered string `json:"rendered"`
-				Raw      string `json:"raw,omitempty"`
+				Rendered  string `json:"rendered"`
+				Raw       string `json:"raw,omitempty"`
+				Protected bool   `json:"protected,omitempty"`
 			}{Rendered: "<p>Test content</p>"},
 		}},
 	}
@@ -409,8 +784,8 @@ func TestTemplateRenderingError(t *testing.T) {
 		server.URL,
 		"testuser",
 		"testpass",
-		"menu-en",
-		"menu-fr",
+		map[string]string{"en": "menu-en", "fr": "menu-fr"},
+		api.DefaultTimeouts,
 	)
 
 	// Create handler with the error-generating template
@@ -442,3 +817,732 @@ func TestTemplateRenderingError(t *testing.T) {
 		t.Errorf("Expected error message containing %q, got: %s", expectedError, string(body))
 	}
 }
+
+// TestHandlePageRewritesImages tests that content images are pointed at the resize endpoint
+func TestHandlePageRewritesImages(t *testing.T) {
+	server := setupTestServer(t, map[string]interface{}{
+		"pages/gallery": []models.WordPressPage{{
+			ID:     1,
+			Status: "publish",
+			Slug:   "gallery",
+			Lang:   "en",
+			Content: struct {
+				Rendered  string `json:"rendered"`
+				Raw       string `json:"raw,omitempty"`
+				Protected bool   `json:"protected,omitempty"`
+			}{Rendered: `<img src="/wp-content/uploads/photo.jpg">`},
+		}},
+	})
+	defer server.Close()
+
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       setupTestTemplates(),
+		ImageWidths:     []int{400},
+	}
+
+	req := httptest.NewRequest("GET", "/gallery", nil)
+	w := httptest.NewRecorder()
+
+	handler.handlePage(w, req, "/gallery")
+
+	body := w.Body.String()
+	expected := `src="/img?src=%2Fwp-content%2Fuploads%2Fphoto.jpg&amp;w=400"`
+	if !strings.Contains(body, expected) {
+		t.Errorf("Expected body to contain %s, got: %s", expected, body)
+	}
+}
+
+func TestHandlePageCanonicalLinks(t *testing.T) {
+	server := setupTestServer(t, map[string]interface{}{
+		"pages/about": []models.WordPressPage{{
+			ID:     1,
+			Status: "publish",
+			Slug:   "about",
+			SlugEn: "about",
+			SlugFr: "a-propos",
+			Lang:   "en",
+		}},
+	})
+	defer server.Close()
+
+	tmpl := template.Must(template.New("layout.html").Parse(
+		`<link rel="canonical" href="{{.Canonical}}">{{range .Alternates}}<link rel="alternate" hreflang="{{.Lang}}" href="{{.Href}}">{{end}}`))
+
+	testCases := []struct {
+		name     string
+		siteURL  string
+		expected []string
+	}{
+		{
+			name:    "Configured site URL",
+			siteURL: "https://www.example.ca",
+			expected: []string{
+				`<link rel="canonical" href="https://www.example.ca/about">`,
+				`<link rel="alternate" hreflang="fr" href="https://www.example.ca/fr/a-propos">`,
+			},
+		},
+		{
+			name:    "Request URL",
+			siteURL: "",
+			expected: []string{
+				`<link rel="canonical" href="http://proxy.example.com/about">`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := &PageHandler{
+				SiteNames:       map[string]string{"en": "English Site"},
+				WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+				Templates:       tmpl,
+				SiteURL:         tc.siteURL,
+			}
+
+			req := httptest.NewRequest("GET", "http://proxy.example.com/about", nil)
+			w := httptest.NewRecorder()
+
+			handler.handlePage(w, req, "/about")
+
+			body := w.Body.String()
+			for _, expected := range tc.expected {
+				if !strings.Contains(body, expected) {
+					t.Errorf("Expected body to contain %s, got: %s", expected, body)
+				}
+			}
+		})
+	}
+}
+
+// TestHandlePageLanguageRedirect tests that pages requested under the
+// prefix of another language are redirected to their own
+func TestHandlePageLanguageRedirect(t *testing.T) {
+	server := setupTestServer(t, map[string]interface{}{
+		"pages/contact": []models.WordPressPage{{ID: 1, Status: "publish", Slug: "contact", Lang: "fr"}},
+		"pages/about":   []models.WordPressPage{{ID: 2, Status: "publish", Slug: "about", Lang: "en"}},
+	})
+	defer server.Close()
+
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"en": "English Site", "fr": "French Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       setupTestTemplates(),
+	}
+
+	testCases := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{path: "/contact?ref=menu", status: http.StatusMovedPermanently, location: "/fr/contact?ref=menu"},
+		{path: "/fr/about", status: http.StatusMovedPermanently, location: "/about"},
+		{path: "/fr/contact", status: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", tc.path, nil)
+		w := httptest.NewRecorder()
+
+		handler.handlePage(w, req, req.URL.Path)
+
+		if w.Code != tc.status || w.Header().Get("Location") != tc.location {
+			t.Errorf("%s: expected %d to %q, got %d to %q", tc.path, tc.status, tc.location, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+// TestHandlePagePrefetchTranslations tests that the translations of a page
+// are added to the page cache in the background
+func TestHandlePagePrefetchTranslations(t *testing.T) {
+	fetched := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch q := r.URL.Query(); q.Get("slug") {
+		case "about":
+			json.NewEncoder(w).Encode([]models.WordPressPage{{ID: 1, Status: "publish", Slug: "about", SlugFr: "a-propos", Lang: "en"}})
+		case "a-propos":
+			json.NewEncoder(w).Encode([]models.WordPressPage{{ID: 2, Status: "publish", Slug: "a-propos", SlugEn: "about", Lang: "fr"}})
+			fetched <- q.Get("lang") + "/" + q.Get("slug")
+		default:
+			json.NewEncoder(w).Encode([]models.WordPressPage{})
+		}
+	}))
+	defer server.Close()
+
+	client := &api.WordPressClient{
+		BaseURL:   server.URL,
+		PageCache: cache.New[*models.WordPressPage](cache.Policy{TTL: time.Minute}),
+	}
+	handler := &PageHandler{
+		SiteNames:            map[string]string{"en": "English Site", "fr": "French Site"},
+		WordPressClient:      client,
+		Templates:            setupTestTemplates(),
+		PrefetchTranslations: true,
+	}
+
+	req := httptest.NewRequest("GET", "/about", nil)
+	handler.handlePage(httptest.NewRecorder(), req, "/about")
+
+	select {
+	case translation := <-fetched:
+		if translation != "fr/a-propos" {
+			t.Errorf("Expected French translation prefetched, got %s", translation)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected translation to be prefetched")
+	}
+
+	// The translation is then served from the cache
+	for range 100 {
+		if _, ok := client.PageCache.Fresh("fr/a-propos"); ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	req = httptest.NewRequest("GET", "/fr/a-propos", nil)
+	w := httptest.NewRecorder()
+	handler.handlePage(w, req, "/fr/a-propos")
+	if w.Code != http.StatusOK || len(fetched) != 0 {
+		t.Errorf("Expected cached translation, got status %d and %d fetches", w.Code, len(fetched))
+	}
+}
+
+func TestHandlePageBreadcrumbs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/wp-json/wp/v2/pages":
+			if r.URL.Query().Get("parent") != "" {
+				json.NewEncoder(w).Encode([]models.WordPressPage{})
+				return
+			}
+			json.NewEncoder(w).Encode([]models.WordPressPage{{ID: 2, Status: "publish", Parent: 1, Slug: "benefits", Lang: "en"}})
+		case "/wp-json/wp/v2/pages/1":
+			parent := models.WordPressPage{ID: 1, Status: "publish", Slug: "services", Lang: "en"}
+			parent.Title.Rendered = "Services"
+			json.NewEncoder(w).Encode(parent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates: template.Must(template.New("layout.html").Parse(
+			`{{range .Breadcrumbs}}<a href="{{.Url}}">{{.Title}}</a>{{end}}`)),
+	}
+
+	req := httptest.NewRequest("GET", "/services/benefits", nil)
+	w := httptest.NewRecorder()
+
+	handler.handlePage(w, req, "/services/benefits")
+
+	expected := `<a href="/services">Services</a>`
+	if body := w.Body.String(); body != expected {
+		t.Errorf("Expected body %s, got: %s", expected, body)
+	}
+}
+
+func TestHandlePageSectionNav(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("parent") == "5" {
+			child := models.WordPressPage{ID: 6, Status: "publish", Parent: 5, Slug: "impots", Lang: "fr"}
+			child.Title.Rendered = "Impôts"
+			json.NewEncoder(w).Encode([]models.WordPressPage{child})
+			return
+		}
+		json.NewEncoder(w).Encode([]models.WordPressPage{{ID: 5, Status: "publish", Slug: "services", Lang: "fr"}})
+	}))
+	defer server.Close()
+
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"fr": "French Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates: template.Must(template.New("layout.html").Parse(
+			`{{range .SectionNav}}<a href="{{.Url}}">{{.Title}}</a>{{end}}`)),
+	}
+
+	req := httptest.NewRequest("GET", "/fr/services", nil)
+	w := httptest.NewRecorder()
+
+	handler.handlePage(w, req, "/fr/services")
+
+	expected := `<a href="/fr/impots">Impôts</a>`
+	if body := w.Body.String(); body != expected {
+		t.Errorf("Expected body %s, got: %s", expected, body)
+	}
+}
+
+func TestHandlePageSanitizesContent(t *testing.T) {
+	server := setupTestServer(t, map[string]interface{}{
+		"pages/about": []models.WordPressPage{{
+			ID:     1,
+			Status: "publish",
+			Slug:   "about",
+			Lang:   "en",
+			Content: struct {
+				Rendered  string `json:"rendered"`
+				Raw       string `json:"raw,omitempty"`
+				Protected bool   `json:"protected,omitempty"`
+			}{Rendered: `<p onclick="steal()">About</p><script>steal()</script>`},
+		}},
+	})
+	defer server.Close()
+
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       setupTestTemplates(),
+		Sanitizer:       sanitize.New(nil),
+	}
+
+	req := httptest.NewRequest("GET", "/about", nil)
+	w := httptest.NewRecorder()
+
+	handler.handlePage(w, req, "/about")
+
+	body := w.Body.String()
+	if !strings.Contains(body, "<p>About</p>") {
+		t.Errorf("Expected body to contain sanitized paragraph, got: %s", body)
+	}
+	if strings.Contains(body, "steal") {
+		t.Errorf("Expected scripts and handlers to be removed, got: %s", body)
+	}
+}
+
+// TestHandlePageEmbeds verifies embeds allowed by the sanitizer are
+// replaced with facades
+func TestHandlePageEmbeds(t *testing.T) {
+	server := setupTestServer(t, map[string]interface{}{
+		"pages/about": []models.WordPressPage{{
+			ID:     1,
+			Status: "publish",
+			Slug:   "about",
+			Lang:   "fr",
+			Content: struct {
+				Rendered  string `json:"rendered"`
+				Raw       string `json:"raw,omitempty"`
+				Protected bool   `json:"protected,omitempty"`
+			}{Rendered: `<iframe src="https://www.youtube.com/embed/abc" title="Budget"></iframe>`},
+		}},
+	})
+	defer server.Close()
+
+	rewriter := embeds.New(nil, embeds.ModeFacade)
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"fr": "French Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       setupTestTemplates(),
+		Sanitizer:       sanitize.New(rewriter.Hosts()),
+		Embeds:          rewriter,
+	}
+
+	req := httptest.NewRequest("GET", "/fr/about", nil)
+	w := httptest.NewRecorder()
+
+	handler.handlePage(w, req, "/fr/about")
+
+	body := w.Body.String()
+	if !strings.Contains(body, `data-embed-src="https://www.youtube-nocookie.com/embed/abc?autoplay=1"`) {
+		t.Errorf("Expected body to contain a facade, got: %s", body)
+	}
+	if !strings.Contains(body, "Charger Budget") {
+		t.Errorf("Expected facade text in the page language, got: %s", body)
+	}
+	if strings.Contains(body, "<iframe") {
+		t.Errorf("Expected iframe to be replaced, got: %s", body)
+	}
+}
+
+func TestHandlePageNonce(t *testing.T) {
+	server := setupTestServer(t, map[string]interface{}{
+		"pages/about": []models.WordPressPage{{ID: 1, Status: "publish", Slug: "about", Lang: "en"}},
+	})
+	defer server.Close()
+
+	handler := middleware.NewSecurityHeaders(middleware.CSP{Policy: "script-src 'nonce-{nonce}'"})(&PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       template.Must(template.New("layout.html").Parse(`<script nonce="{{.Nonce}}"></script>`)),
+	})
+
+	req := httptest.NewRequest("GET", "/about", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	policy := w.Header().Get("Content-Security-Policy")
+	nonce := strings.TrimSuffix(strings.TrimPrefix(policy, "script-src 'nonce-"), "'")
+	if nonce == "" || nonce == policy {
+		t.Fatalf("Expected a nonce in the policy, got %q", policy)
+	}
+	expected := `<script nonce="` + nonce + `"></script>`
+	if body := w.Body.String(); body != expected {
+		t.Errorf("Expected body %s, got: %s", expected, body)
+	}
+}
+
+// TestHandlePagePasswordProtected verifies protected pages prompt for their
+// password and are unlocked by posting it
+func TestHandlePagePasswordProtected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := models.WordPressPage{ID: 1, Status: "publish", Slug: "secret", Lang: "en"}
+		page.Content.Protected = true
+		if r.URL.Query().Get("password") == "letmein" {
+			page.Content.Rendered = "<p>Secret content</p>"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPage{page})
+	}))
+	defer server.Close()
+
+	tmpl := setupTestTemplates()
+	template.Must(tmpl.New("password.html").Parse(`<form action="{{.Action}}">{{if .Incorrect}}Incorrect{{end}}</form>`))
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       tmpl,
+	}
+
+	testCases := []struct {
+		name           string
+		method         string
+		password       string
+		expectedStatus int
+		expected       string
+		unexpected     string
+	}{
+		{
+			name:           "Prompt for the password",
+			method:         "GET",
+			expectedStatus: http.StatusOK,
+			expected:       `<form action="/secret"></form>`,
+			unexpected:     "Secret content",
+		},
+		{
+			name:           "Incorrect password",
+			method:         "POST",
+			password:       "guess",
+			expectedStatus: http.StatusForbidden,
+			expected:       `<form action="/secret">Incorrect</form>`,
+			unexpected:     "Secret content",
+		},
+		{
+			name:           "Correct password",
+			method:         "POST",
+			password:       "letmein",
+			expectedStatus: http.StatusOK,
+			expected:       "<p>Secret content</p>",
+			unexpected:     "<form",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body io.Reader
+			if tc.password != "" {
+				body = strings.NewReader("post_password=" + tc.password)
+			}
+			req := httptest.NewRequest(tc.method, "/secret", body)
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "private, no-store" {
+				t.Errorf("Expected protected page not to be cached, got %q", got)
+			}
+			if !strings.Contains(w.Body.String(), tc.expected) {
+				t.Errorf("Expected body to contain %q, got: %s", tc.expected, w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), tc.unexpected) {
+				t.Errorf("Expected body not to contain %q, got: %s", tc.unexpected, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestHandlePagePasswordRateLimit verifies a burst of passwords from one
+// client address is turned away before they are tried against WordPress
+func TestHandlePagePasswordRateLimit(t *testing.T) {
+	tries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("password") != "" {
+			tries++
+		}
+		slug := r.URL.Query().Get("slug")
+		page := models.WordPressPage{ID: 1, Status: "publish", Slug: slug, Lang: "en"}
+		page.Content.Protected = slug == "secret"
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPage{page})
+	}))
+	defer server.Close()
+
+	tmpl := setupTestTemplates()
+	template.Must(tmpl.New("password.html").Parse(`<form action="{{.Action}}"></form>`))
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       tmpl,
+		PasswordLimiter: ratelimit.New(3, time.Minute),
+	}
+
+	post := func(addr string) int {
+		return postPassword(handler, "/secret", addr, "application/x-www-form-urlencoded")
+	}
+	for i := 0; i < 3; i++ {
+		if code := post("192.0.2.1:1234"); code != http.StatusForbidden {
+			t.Fatalf("Expected password %d tried, got status %d", i+1, code)
+		}
+	}
+	if code := post("192.0.2.1:1234"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d, got %d", http.StatusTooManyRequests, code)
+	}
+	if tries != 3 {
+		t.Errorf("Expected 3 passwords tried, got %d", tries)
+	}
+	if code := post("192.0.2.2:1234"); code != http.StatusForbidden {
+		t.Errorf("Expected another address let through, got status %d", code)
+	}
+
+	// Only the passwords of protected pages count against the limit, and
+	// only urlencoded forms are read
+	if code := postPassword(handler, "/open", "192.0.2.1:1234", "application/x-www-form-urlencoded"); code == http.StatusTooManyRequests {
+		t.Errorf("Expected a password posted to an open page not to be limited, got status %d", code)
+	}
+	if code := postPassword(handler, "/secret", "192.0.2.3:1234", "multipart/form-data; boundary=x"); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, code)
+	}
+
+	// Visitors reading the page are not limited
+	req := httptest.NewRequest("GET", "/secret", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+// postPassword posts a password for a page to handler and returns the
+// status of the response
+func postPassword(handler http.Handler, path string, addr string, contentType string) int {
+	req := httptest.NewRequest("POST", path, strings.NewReader("post_password=guess"))
+	req.Header.Set("Content-Type", contentType)
+	req.RemoteAddr = addr
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+// TestHandlePagePrivate verifies private pages are only served to signed in
+// visitors, and that unpublished pages are never served
+func TestHandlePagePrivate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := models.WordPressPage{ID: 1, Slug: r.URL.Query().Get("slug"), Lang: "en", Status: r.URL.Query().Get("slug")}
+		page.Content.Rendered = "<p>Staff only</p>"
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPage{page})
+	}))
+	defer server.Close()
+
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL, AuthenticateContent: true},
+		Templates:       setupTestTemplates(),
+	}
+	users, err := auth.ParseHtpasswd("editor:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	signedIn := middleware.BasicAuth(users, "Intranet", nil)(handler)
+
+	testCases := []struct {
+		name           string
+		path           string
+		handler        http.Handler
+		expectedStatus int
+	}{
+		{"Private page for anonymous visitor", "/private", handler, http.StatusNotFound},
+		{"Private page for signed in visitor", "/private", signedIn, http.StatusOK},
+		{"Published page", "/publish", handler, http.StatusOK},
+		{"Draft for signed in visitor", "/draft", signedIn, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.path, nil)
+			req.SetBasicAuth("editor", "secret")
+			w := httptest.NewRecorder()
+
+			tc.handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if w.Code == http.StatusNotFound && strings.Contains(w.Body.String(), "Staff only") {
+				t.Errorf("Expected content withheld, got: %s", w.Body.String())
+			}
+			if tc.path == "/private" && w.Code == http.StatusOK && w.Header().Get("Cache-Control") != "private, no-store" {
+				t.Errorf("Expected private page not to be cached, got %q", w.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+// TestHandlePageAccessRules tests that sections restricted by access rules
+// cannot be reached with a path encoded twice, which the rules would not
+// match once decoded
+func TestHandlePageAccessRules(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := models.WordPressPage{ID: 1, Status: "publish", Slug: r.URL.Query().Get("slug"), Lang: "en"}
+		page.Content.Rendered = "<p>Staff only</p>"
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]models.WordPressPage{page})
+	}))
+	defer server.Close()
+
+	rules, err := access.ParseRules([]string{"/intranet/=10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	handler := middleware.Access(rules, nil, 0)(&PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       setupTestTemplates(),
+	})
+
+	testCases := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"Restricted path", "/intranet/secret", http.StatusForbidden},
+		{"Restricted path encoded twice", "/%2569ntranet/secret", http.StatusBadRequest},
+		{"Restricted slug encoded twice", "/intranet%252Fsecret", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.path, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if strings.Contains(w.Body.String(), "Staff only") {
+				t.Errorf("Expected content withheld, got: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestHandlePagePagination tests that content split with <!--nextpage-->
+// is served one page at a time under /page/<n>
+func TestHandlePagePagination(t *testing.T) {
+	page := models.WordPressPage{ID: 1, Status: "publish", Slug: "guide", Lang: "en"}
+	page.Content.Rendered = "<p>Part one</p><!--nextpage--><p>Part two</p>"
+	server := setupTestServer(t, map[string]interface{}{
+		"pages/guide": []models.WordPressPage{page},
+	})
+	defer server.Close()
+
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates: template.Must(template.New("layout.html").Parse(
+			`{{.Content}}|{{.PrevUrl}}|{{.NextUrl}}`)),
+	}
+
+	testCases := []struct {
+		path     string
+		status   int
+		location string
+		body     string
+	}{
+		{path: "/guide", status: http.StatusOK, body: "<p>Part one</p>||/guide/page/2"},
+		{path: "/guide/page/2", status: http.StatusOK, body: "<p>Part two</p>|/guide|"},
+		{path: "/guide/page/1?ref=toc", status: http.StatusMovedPermanently, location: "/guide?ref=toc"},
+		{path: "/guide/page/3", status: http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", tc.path, nil)
+		w := httptest.NewRecorder()
+
+		handler.handlePage(w, req, req.URL.Path)
+
+		if w.Code != tc.status || w.Header().Get("Location") != tc.location {
+			t.Errorf("%s: expected %d to %q, got %d to %q", tc.path, tc.status, tc.location, w.Code, w.Header().Get("Location"))
+		}
+		if tc.body != "" && !strings.Contains(w.Body.String(), tc.body) {
+			t.Errorf("%s: expected body to contain %q, got %q", tc.path, tc.body, w.Body.String())
+		}
+	}
+}
+
+// TestHandlePageFeedback tests that pages carry the feedback widget with
+// the outcome of the visitor's last answer
+func TestHandlePageFeedback(t *testing.T) {
+	server := setupTestServer(t, map[string]interface{}{
+		"pages/guide": []models.WordPressPage{{ID: 7, Status: "publish", Slug: "guide", Lang: "en"}},
+	})
+	defer server.Close()
+
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates: template.Must(template.New("layout.html").Parse(
+			`{{with .Feedback}}{{.Action}}|{{.PageID}}|{{.Path}}|{{.Status}}{{end}}`)),
+		Feedback: true,
+	}
+
+	for path, expected := range map[string]string{
+		"/guide":                   "/api/feedback|7|/guide|",
+		"/guide?feedback=thanks":   "/api/feedback|7|/guide|thanks",
+		"/guide?feedback=<script>": "/api/feedback|7|/guide|",
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		handler.handlePage(w, req, req.URL.Path)
+		if w.Code != http.StatusOK || w.Body.String() != expected {
+			t.Errorf("%s: expected %q, got %d: %q", path, expected, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestHandlePageSurrogateKeys tests that pages are tagged with their own
+// key and those of the pages linked from their section navigation
+func TestHandlePageSurrogateKeys(t *testing.T) {
+	// Child pages are requested without a slug
+	server := setupTestServer(t, map[string]interface{}{
+		"pages/guide": []models.WordPressPage{{ID: 7, Status: "publish", Slug: "guide", Lang: "fr"}},
+		"pages/":      []models.WordPressPage{{ID: 8, Status: "publish", Slug: "step-one", Lang: "fr", Parent: 7}},
+	})
+	defer server.Close()
+
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"fr": "French Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       setupTestTemplates(),
+	}
+
+	req := httptest.NewRequest("GET", "/fr/guide", nil)
+	w := httptest.NewRecorder()
+	handler.handlePage(w, req, req.URL.Path)
+
+	if got := w.Header().Get(SurrogateKeyHeader); got != "page-7 type-page lang-fr page-8" {
+		t.Errorf("Expected surrogate keys, got %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "X-Forwarded-Proto" {
+		t.Errorf("Expected response to vary on the forwarded scheme, got %q", got)
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// PageAPIPath is the path prefix of the JSON page API.  A page served at
+// /fr/a-propos is served as JSON at /api/pages/fr/a-propos.
+const PageAPIPath = "/api/pages/"
+
+// PageResponse is a page as served by the JSON page API, with the same
+// sanitized and rewritten content as its HTML page.  Links are paths of
+// the HTML pages, except Canonical and the alternates, which are absolute
+// URLs.
+type PageResponse struct {
+	ID          int             `json:"id"`
+	Lang        string          `json:"lang"`
+	Title       string          `json:"title"`
+	Content     string          `json:"content"`
+	Modified    time.Time       `json:"modified,omitzero"`
+	Canonical   string          `json:"canonical"`
+	Translation string          `json:"translation,omitempty"`
+	Alternates  []PageLink      `json:"alternates"`
+	Breadcrumbs []PageLink      `json:"breadcrumbs"`
+	SectionNav  []PageLink      `json:"section_nav"`
+	Fields      models.Fields   `json:"fields,omitempty"`
+	Protected   bool            `json:"protected"`
+	Pagination  *PagePagination `json:"pagination,omitempty"`
+}
+
+// PageLink is a link from a page to another.
+type PageLink struct {
+	Lang  string `json:"lang,omitempty"`
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+}
+
+// PagePagination is the position of a page of content split with
+// <!--nextpage-->.
+type PagePagination struct {
+	Page  int    `json:"page"`
+	Pages int    `json:"pages"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// PageAPIHandler serves pages as JSON for single page and mobile apps.
+// Pages are built by a PageHandler, so they are sanitized and have their
+// links rewritten as they are for the HTML pages.  Locked pages are served
+// without their content, since the API does not take passwords.
+type PageAPIHandler struct {
+	Pages *PageHandler
+}
+
+// NewPageAPIHandler creates a new JSON page API handler serving the pages
+// of the given page handler.
+func NewPageAPIHandler(pages *PageHandler) *PageAPIHandler {
+	return &PageAPIHandler{Pages: pages}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *PageAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Page API request: %s", r.URL.Path)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := "/" + strings.TrimPrefix(r.URL.Path, PageAPIPath)
+	if !validPath(w, r, path) {
+		return
+	}
+
+	h.Pages.servePage(pageAPIWriter{w}, r, path, respondJSON)
+}
+
+// respondJSON sends a page as JSON.
+func respondJSON(w http.ResponseWriter, r *http.Request, page *models.WordPressPage, data models.PageData, incorrect bool) {
+	resp := PageResponse{
+		ID:          page.ID,
+		Lang:        data.Lang,
+		Title:       string(data.Title),
+		Modified:    data.Modified,
+		Canonical:   data.Canonical,
+		Alternates:  make([]PageLink, 0, len(data.Alternates)),
+		Breadcrumbs: make([]PageLink, 0, len(data.Breadcrumbs)),
+		SectionNav:  make([]PageLink, 0, len(data.SectionNav)),
+		Fields:      data.Fields,
+		Protected:   page.Content.Protected,
+	}
+	if !page.Locked() {
+		resp.Content = string(data.Content)
+	}
+	if data.HasTranslation {
+		resp.Translation = data.LangSwapHref()
+	}
+	for _, alternate := range data.Alternates {
+		resp.Alternates = append(resp.Alternates, PageLink{Lang: alternate.Lang, URL: alternate.Href})
+	}
+	for _, crumb := range data.Breadcrumbs {
+		resp.Breadcrumbs = append(resp.Breadcrumbs, PageLink{Title: crumb.Title, URL: crumb.Url})
+	}
+	for _, link := range data.SectionNav {
+		resp.SectionNav = append(resp.SectionNav, PageLink{Title: link.Title, URL: link.Url})
+	}
+	if data.PageCount > 0 {
+		resp.Pagination = &PagePagination{Page: data.PageNumber, Pages: data.PageCount, Prev: data.PrevUrl, Next: data.NextUrl}
+	}
+
+	// The content is public, so apps on other origins may read it
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding page response: %v", err)
+	}
+}
+
+// pageAPIWriter redirects to the API paths of the pages that pages are
+// redirected to, such as the first page of paginated content.
+type pageAPIWriter struct {
+	http.ResponseWriter
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (w pageAPIWriter) WriteHeader(status int) {
+	location := w.Header().Get("Location")
+	if status >= 300 && status < 400 && strings.HasPrefix(location, "/") && !strings.HasPrefix(location, "//") {
+		w.Header().Set("Location", strings.TrimSuffix(PageAPIPath, "/")+location)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// prefersJSON reports whether an Accept header ranks application/json
+// above text/html.  At equal quality the type named more specifically
+// wins, and remaining ties go to HTML so that browsers and clients
+// accepting anything are sent the HTML page.
+func prefersJSON(accept string) bool {
+	jsonQuality, jsonSpecificity := acceptQuality(accept, "application/json")
+	htmlQuality, htmlSpecificity := acceptQuality(accept, "text/html")
+	if jsonQuality != htmlQuality {
+		return jsonQuality > htmlQuality
+	}
+	return jsonQuality > 0 && jsonSpecificity > htmlSpecificity
+}
+
+// acceptQuality returns the quality an Accept header gives a media type,
+// taken from the most specific range matching it, along with the
+// specificity of the range: 2 for the type itself, 1 for type/* and 0 for
+// */*.
+func acceptQuality(accept string, mediaType string) (float64, int) {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		accepted, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		var s int
+		switch accepted {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil || q < 0 || q > 1 {
+				q = 0
+			}
+		}
+		quality, specificity = q, s
+	}
+	return quality, specificity
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/sanitize"
+	"wordpress-go-proxy/pkg/models"
+)
+
+func TestPageAPIHandler(t *testing.T) {
+	page := func(id int, slug string, content string, protected bool) []models.WordPressPage {
+		p := models.WordPressPage{ID: id, Status: "publish", Slug: slug, SlugEn: slug, Lang: "en"}
+		p.Title.Rendered = "Title of " + slug
+		p.Content.Rendered = content
+		p.Content.Protected = protected
+		return []models.WordPressPage{p}
+	}
+	responses := map[string]interface{}{
+		"pages/guide":  page(2, "guide", "<p>One</p><!--nextpage--><p>Two</p>", false),
+		"pages/secret": page(3, "secret", "", true),
+	}
+	server := setupTestServer(t, responses)
+	defer server.Close()
+	about := page(1, "about", `<p><a href="`+server.URL+`/contact">Contact</a></p><script>alert(1)</script>`, false)
+	about[0].SlugFr = "a-propos"
+	responses["pages/about"] = about
+
+	pages := &PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       setupTestTemplates(),
+		SiteURL:         "https://www.example.ca",
+		Sanitizer:       sanitize.New(nil),
+	}
+	handler := NewPageAPIHandler(pages)
+
+	get := func(method string, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) PageResponse {
+		t.Helper()
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("Expected a JSON page, got %d %q: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+		var resp PageResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Error decoding page: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("Page", func(t *testing.T) {
+		w := get("GET", "/api/pages/about")
+		resp := decode(w)
+		if resp.ID != 1 || resp.Lang != "en" || resp.Title != "Title of about" {
+			t.Errorf("Unexpected page %+v", resp)
+		}
+		if resp.Content != `<p><a href="/contact">Contact</a></p>` {
+			t.Errorf("Expected sanitized content with rewritten links, got %q", resp.Content)
+		}
+		if resp.Canonical != "https://www.example.ca/about" || resp.Translation != "/fr/a-propos" {
+			t.Errorf("Expected links to the HTML pages, got %q and %q", resp.Canonical, resp.Translation)
+		}
+		if len(resp.Alternates) != 3 || resp.Alternates[1] != (PageLink{Lang: "fr", URL: "https://www.example.ca/fr/a-propos"}) {
+			t.Errorf("Unexpected alternates %+v", resp.Alternates)
+		}
+		if resp.Protected || resp.Pagination != nil {
+			t.Errorf("Expected an unprotected single page, got %+v", resp)
+		}
+		if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get(SurrogateKeyHeader) == "" {
+			t.Errorf("Unexpected headers %v", w.Header())
+		}
+	})
+
+	t.Run("Paginated", func(t *testing.T) {
+		resp := decode(get("GET", "/api/pages/guide/page/2"))
+		expected := PagePagination{Page: 2, Pages: 2, Prev: "/guide"}
+		if resp.Content != "<p>Two</p>" || resp.Pagination == nil || *resp.Pagination != expected {
+			t.Errorf("Expected the second page, got %q %+v", resp.Content, resp.Pagination)
+		}
+
+		w := get("GET", "/api/pages/guide/page/1")
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/api/pages/guide" {
+			t.Errorf("Expected a redirect to the API path of the first page, got %d %q", w.Code, w.Header().Get("Location"))
+		}
+	})
+
+	t.Run("Locked", func(t *testing.T) {
+		w := get("GET", "/api/pages/secret")
+		resp := decode(w)
+		if !resp.Protected || resp.Content != "" || resp.Title != "Title of secret" {
+			t.Errorf("Expected a protected page without content, got %+v", resp)
+		}
+		if w.Header().Get("Cache-Control") != "private, no-store" {
+			t.Errorf("Expected protected pages not to be cached, got %q", w.Header().Get("Cache-Control"))
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		if w := get("GET", "/api/pages/missing"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+		if w := get("GET", "/api/pages/about.json"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for a file extension, got %d", w.Code)
+		}
+		if w := get("POST", "/api/pages/about"); w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected 405, got %d", w.Code)
+		}
+		w := get("HEAD", "/api/pages/about")
+		if w.Code != http.StatusOK || w.Body.Len() != 0 {
+			t.Errorf("Expected an empty HEAD response, got %d %q", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestPageHandlerNegotiateJSON(t *testing.T) {
+	page := models.WordPressPage{ID: 1, Status: "publish", Slug: "about", SlugEn: "about", Lang: "en"}
+	page.Title.Rendered = "About"
+	page.Content.Rendered = "<p>About us</p>"
+	server := setupTestServer(t, map[string]interface{}{"pages/about": []models.WordPressPage{page}})
+	defer server.Close()
+
+	enabled := true
+	handler := &PageHandler{
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       setupTestTemplates(),
+		SiteURL:         "https://www.example.ca",
+		NegotiateJSON:   func() bool { return enabled },
+	}
+
+	testCases := []struct {
+		name        string
+		accept      string
+		enabled     bool
+		contentType string
+		vary        string
+	}{
+		{name: "JSON", accept: "application/json", enabled: true, contentType: "application/json", vary: "Accept"},
+		{name: "Browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", enabled: true, contentType: "text/html; charset=utf-8", vary: "Accept"},
+		{name: "Anything", accept: "*/*", enabled: true, contentType: "text/html; charset=utf-8", vary: "Accept"},
+		{name: "Disabled", accept: "application/json", enabled: false, contentType: "text/html; charset=utf-8"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			enabled = tc.enabled
+			req := httptest.NewRequest("GET", "/about", nil)
+			req.Header.Set("Accept", tc.accept)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK || w.Header().Get("Content-Type") != tc.contentType {
+				t.Errorf("Expected %q, got %d %q", tc.contentType, w.Code, w.Header().Get("Content-Type"))
+			}
+			if w.Header().Get("Vary") != tc.vary {
+				t.Errorf("Expected Vary %q, got %q", tc.vary, w.Header().Get("Vary"))
+			}
+			if tc.contentType == "application/json" {
+				var resp PageResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.ID != 1 || resp.Content != "<p>About us</p>" {
+					t.Errorf("Expected the page as JSON, got %v %s", err, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestPrefersJSON(t *testing.T) {
+	testCases := []struct {
+		accept   string
+		expected bool
+	}{
+		{accept: "", expected: false},
+		{accept: "application/json", expected: true},
+		{accept: "application/json, text/plain, */*", expected: true},
+		{accept: "text/html, application/json", expected: false},
+		{accept: "text/html;q=0.5, application/json", expected: true},
+		{accept: "application/*", expected: true},
+		{accept: "application/json;q=0, */*", expected: false},
+		{accept: "text/*, application/json;q=0.9", expected: false},
+		{accept: "application/json;q=2, text/html;q=0.1", expected: false},
+		{accept: "*/*", expected: false},
+		{accept: "application/*, text/*", expected: false},
+	}
+
+	for _, tc := range testCases {
+		if actual := prefersJSON(tc.accept); actual != tc.expected {
+			t.Errorf("prefersJSON(%q): expected %v, got %v", tc.accept, tc.expected, actual)
+		}
+	}
+}
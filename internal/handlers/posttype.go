@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/embeds"
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/internal/router"
+	"wordpress-go-proxy/internal/sanitize"
+	"wordpress-go-proxy/internal/transform"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// maxArchivePage is the highest archive page that can be requested.
+const maxArchivePage = 100
+
+// PostTypeHandler handles requests for the posts and archive of a custom
+// post type.  Posts are rendered with the <name>.html template of the
+// theme and the archive with <name>-archive.html, falling back to
+// post.html and archive.html.
+type PostTypeHandler struct {
+	PostType        models.PostType
+	SiteNames       map[string]string
+	WordPressClient *api.WordPressClient
+	Templates       *template.Template
+
+	// SiteURL is the public URL of the proxy used for canonical and
+	// hreflang links.  If empty, the URL of the request is used.
+	SiteURL string
+
+	// Sanitizer removes unsafe markup from post content.  If nil, content
+	// is rendered as returned by WordPress.
+	Sanitizer *sanitize.Sanitizer
+
+	// Embeds replaces embedded iframes with facades or links.  If nil,
+	// iframes are rendered as they are.
+	Embeds *embeds.Rewriter
+
+	// Transforms are applied to the content before it is rendered.  If
+	// nil, only links to the WordPress site are rewritten.
+	Transforms transform.Pipeline
+
+	// ImageWidths are the widths offered in the responsive srcset of
+	// content images.  If empty, images are not rewritten.
+	ImageWidths []int
+
+	// MissingTranslation is how the language toggle of untranslated
+	// content is rendered, one of models.MissingTranslationHide or
+	// models.MissingTranslationHome.  The toggle is hidden if empty.
+	MissingTranslation string
+
+	// Comments renders the approved comments below posts, with a form
+	// submitted to CommentPath on posts open to comments.
+	Comments bool
+
+	// Feedback renders the widget asking visitors whether a post was
+	// helpful, submitted to FeedbackPath.
+	Feedback bool
+}
+
+// NewPostTypeHandler creates a new handler for the posts and archive of a
+// custom post type.
+func NewPostTypeHandler(postType models.PostType, siteNames map[string]string, wordPressClient *api.WordPressClient) *PostTypeHandler {
+	// Load templates, including those dedicated to the post type
+	names := []string{"layout.html"}
+	for _, kind := range []contentKind{postContent, archiveContent} {
+		files, err := templateFiles(kind, postType.Name)
+		if err != nil {
+			log.Fatal("Error finding templates:", err)
+		}
+		names = append(names, files...)
+	}
+	tmpl, err := parseTheme(names...)
+	if err != nil {
+		log.Fatal("Error parsing template:", err)
+	}
+
+	return &PostTypeHandler{
+		PostType:        postType,
+		SiteNames:       siteNames,
+		WordPressClient: wordPressClient,
+		Templates:       tmpl,
+		Transforms:      transform.Default(wordPressClient.BaseURL),
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.  The archive is served
+// from the root of the post type's path and posts from the slug below it.
+func (h *PostTypeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	log.Printf("%s request: %s", h.PostType.Name, path)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !validPath(w, r, path) {
+		return
+	}
+
+	if params, ok := router.MustParse("/" + h.PostType.Name).MatchLocalized(path); ok {
+		h.handleArchive(w, r, params[router.LangParam])
+		return
+	}
+	contentPath, pageNumber, ok := splitPagePath(w, r, path)
+	if !ok {
+		return
+	}
+	if params, ok := router.MustParse("/" + h.PostType.Name + "/{slug}").MatchLocalized(contentPath); ok {
+		h.handlePost(w, r, params[router.LangParam], params["slug"], pageNumber)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// handlePost renders a page of a post of the post type inside the site
+// layout.
+func (h *PostTypeHandler) handlePost(w http.ResponseWriter, r *http.Request, lang string, slug string, pageNumber int) {
+	post, err := h.WordPressClient.FetchPost(r.Context(), h.PostType, slug, lang)
+	if err != nil {
+		upstreamError(w, r, "Error fetching post content", err)
+		log.Printf("Error fetching %s post: %v", h.PostType.Name, err)
+		return
+	}
+	if !post.Viewable(middleware.Authenticated(r.Context())) {
+		log.Printf("%s post not viewable with status %q: %s", h.PostType.Name, post.Status, slug)
+		http.NotFound(w, r)
+		return
+	}
+	if languageRedirect(w, r, r.URL.Path, post.Lang) {
+		return
+	}
+	if post.Private() {
+		w.Header().Set("Cache-Control", "private, no-store")
+		w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	}
+
+	menu, _ := h.WordPressClient.Menu(lang)
+	data := models.NewPageData(post, menu, h.SiteNames)
+	data.Published = post.PublishedTime()
+	addSurrogateKeys(w, models.PageSurrogateKey(post.ID), models.TypeSurrogateKey(h.PostType.Name), models.LangSurrogateKey(data.Lang))
+	data.ShowBreadcrumb = true
+	data.Breadcrumbs = []models.Crumb{{Title: h.PostType.Title(), Url: h.PostType.Path(data.Lang)}}
+	if swap, ok := models.Languages.Swap(data.Lang); ok {
+		data.LangSwapPath = h.PostType.Path(swap.Code)
+	}
+	data.SetPostLinks(h.PostType, post, siteURL(w, h.SiteURL, r))
+	data.SetMissingTranslation(h.MissingTranslation)
+	if !data.Paginate(h.PostType.PostPath(data.Lang, post.Slug), pageNumber) {
+		log.Printf("Page %d of %s post not found: %s", pageNumber, h.PostType.Name, slug)
+		http.NotFound(w, r)
+		return
+	}
+	data.Nonce = middleware.Nonce(r.Context())
+	if h.Feedback {
+		data.Feedback = newFeedbackData(r, data.Lang, post.ID)
+	}
+	if h.Sanitizer != nil {
+		data.Content = template.HTML(h.Sanitizer.Sanitize(string(data.Content)))
+	}
+	transforms := contentTransforms(h.Transforms, h.WordPressClient.BaseURL, h.Embeds, h.ImageWidths)
+	data.Content = transformContent(transforms, h.WordPressClient.BaseURL, data.Content, transform.Page{Lang: data.Lang, Path: h.PostType.PostPath(data.Lang, post.Slug)})
+
+	name := templateName(h.Templates, postContent, h.PostType.Name)
+	postData := models.NewPostData(h.PostType, post, data.Content)
+	if h.Comments {
+		h.addComments(r, &postData, post)
+	}
+	var body bytes.Buffer
+	err = executeTemplate(r.Context(), h.Templates, &body, name, postData.Lang, postData)
+	if err != nil {
+		log.Printf("Error rendering %s template: %v", name, err)
+		renderError(w, r, h.Templates, data.Lang)
+		return
+	}
+	data.Content = template.HTML(body.String())
+
+	renderPage(w, r, h.Templates, http.StatusOK, data)
+}
+
+// addComments adds the approved comments on a post to its data, along with
+// the comment form if the post is open to comments.  The post is rendered
+// without comments if they cannot be fetched.
+func (h *PostTypeHandler) addComments(r *http.Request, data *models.PostData, post *models.WordPressPage) {
+	comments, err := h.WordPressClient.FetchComments(r.Context(), post.ID)
+	if err != nil {
+		log.Printf("Error fetching comments on %s post %d: %v", h.PostType.Name, post.ID, err)
+	}
+	if h.Sanitizer != nil {
+		for i := range comments {
+			comments[i].Content.Rendered = h.Sanitizer.Sanitize(comments[i].Content.Rendered)
+		}
+	}
+	data.Comments = models.NewCommentThreads(comments)
+
+	if post.CommentStatus == "open" {
+		status := r.URL.Query().Get("comment")
+		if !slices.Contains(models.CommentStatuses, status) {
+			status = ""
+		}
+		data.CommentForm = &models.CommentFormData{
+			Lang:      data.Lang,
+			Action:    CommentPath,
+			PostID:    post.ID,
+			Path:      h.PostType.PostPath(data.Lang, post.Slug),
+			Status:    status,
+			CSRFToken: middleware.CSRFToken(r.Context()),
+		}
+	}
+}
+
+// handleArchive renders a page of the post type's archive inside the site
+// layout.  The page number is read from the page query parameter.
+func (h *PostTypeHandler) handleArchive(w http.ResponseWriter, r *http.Request, lang string) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 || page > maxArchivePage {
+		page = 1
+	}
+
+	archive, err := h.WordPressClient.FetchArchive(r.Context(), h.PostType, lang, page)
+	if err != nil {
+		upstreamError(w, r, "Error fetching archive", err)
+		log.Printf("Error fetching %s archive: %v", h.PostType.Name, err)
+		return
+	}
+
+	name := templateName(h.Templates, archiveContent, h.PostType.Name)
+	var content bytes.Buffer
+	archiveData := models.NewArchiveData(lang, h.PostType, archive, page)
+	for i, post := range archiveData.Posts {
+		excerpt := post.Excerpt
+		if h.Sanitizer != nil {
+			excerpt = template.HTML(h.Sanitizer.Sanitize(string(excerpt)))
+		}
+		archiveData.Posts[i].Excerpt = transformContent(h.Transforms, h.WordPressClient.BaseURL, excerpt, transform.Page{Lang: archiveData.Lang, Path: post.Url})
+	}
+	err = executeTemplate(r.Context(), h.Templates, &content, name, archiveData.Lang, archiveData)
+	if err != nil {
+		log.Printf("Error rendering %s template: %v", name, err)
+		renderError(w, r, h.Templates, lang)
+		return
+	}
+
+	menu, _ := h.WordPressClient.Menu(lang)
+	data := models.NewArchivePageData(lang, h.PostType, template.HTML(content.String()), menu, h.SiteNames)
+	data.Canonical = siteURL(w, h.SiteURL, r) + h.PostType.ArchiveUrl(data.Lang, page)
+	addSurrogateKeys(w, models.TypeSurrogateKey(h.PostType.Name), models.LangSurrogateKey(data.Lang))
+	data.Nonce = middleware.Nonce(r.Context())
+
+	renderPage(w, r, h.Templates, http.StatusOK, data)
+}
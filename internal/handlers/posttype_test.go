@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/auth"
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/internal/sanitize"
+	"wordpress-go-proxy/internal/theme"
+	"wordpress-go-proxy/pkg/models"
+)
+
+var eventsType = models.PostType{Name: "events", Route: "wp/v2/events"}
+
+// setupPostTypeTemplates creates mock layout, post and archive templates
+// for testing
+func setupPostTypeTemplates() *template.Template {
+	tmpl := template.Must(template.New("layout.html").Parse(
+		`<html lang="{{.Lang}}"><title>{{.Title}}</title><link rel="canonical" href="{{.Canonical}}">` +
+			`{{range .Breadcrumbs}}<a class="crumb" href="{{.Url}}">{{.Title}}</a>{{end}}` +
+			`<a class="swap" href="{{.LangSwapPath}}{{.LangSwapSlug}}"></a>{{.Content}}</html>`))
+	template.Must(tmpl.New("post.html").Parse(`<article>{{.Content}}</article>`))
+	template.Must(tmpl.New("archive.html").Parse(
+		`{{.Total}} posts{{range .Posts}}<a href="{{.Url}}">{{.Title}}</a>{{end}}{{.NextUrl}}`))
+	return tmpl
+}
+
+// postTypeServer creates a test server for the events post type
+func postTypeServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/events" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if slug := r.URL.Query().Get("slug"); slug != "" {
+			if slug != "launch" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"id":1,"slug":"launch","status":"publish","slug_en":"launch","slug_fr":"lancement","lang":"en",` +
+				`"title":{"rendered":"Launch"},"content":{"rendered":"<p>Launch day</p>"},"meta":{"venue":"Ottawa"}}]`))
+			return
+		}
+		w.Header().Set("X-WP-Total", "12")
+		w.Header().Set("X-WP-TotalPages", "2")
+		w.Write([]byte(`[{"id":1,"slug":"launch","title":{"rendered":"Launch"}}]`))
+	}))
+}
+
+func TestPostTypeHandlerServeHTTP(t *testing.T) {
+	server := postTypeServer(t)
+	defer server.Close()
+
+	handler := &PostTypeHandler{
+		PostType:        eventsType,
+		SiteNames:       map[string]string{"en": "English Site", "fr": "French Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       setupPostTypeTemplates(),
+		SiteURL:         "https://www.example.ca",
+	}
+
+	testCases := []struct {
+		name           string
+		method         string
+		url            string
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "Post",
+			method:         "GET",
+			url:            "/events/launch",
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				"<title>Launch</title>",
+				"<article><p>Launch day</p></article>",
+				`<link rel="canonical" href="https://www.example.ca/events/launch">`,
+				`<a class="crumb" href="/events/">Events</a>`,
+				`<a class="swap" href="/fr/events/lancement">`,
+			},
+		},
+		{
+			name:           "Post with trailing slash",
+			method:         "GET",
+			url:            "/events/launch/",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"<article><p>Launch day</p></article>"},
+		},
+		{
+			name:           "Archive",
+			method:         "GET",
+			url:            "/events/",
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				"<title>Events</title>",
+				`12 posts<a href="/events/launch">Launch</a>/events/?page=2`,
+				`<link rel="canonical" href="https://www.example.ca/events/">`,
+				`<a class="swap" href="/fr/events/">`,
+			},
+		},
+		{
+			name:           "French archive",
+			method:         "GET",
+			url:            "/fr/events/?page=2",
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				`<html lang="fr">`,
+				`<a href="/fr/events/launch">Launch</a>`,
+				`<link rel="canonical" href="https://www.example.ca/fr/events/?page=2">`,
+			},
+		},
+		{
+			name:           "Nested path",
+			method:         "GET",
+			url:            "/events/launch/extra",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Missing post",
+			method:         "GET",
+			url:            "/events/missing",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid method",
+			method:         "POST",
+			url:            "/events/launch",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "File extension",
+			method:         "GET",
+			url:            "/events/launch.php",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			body := w.Body.String()
+			for _, expected := range tc.expectedBody {
+				if !strings.Contains(body, expected) {
+					t.Errorf("Expected body to contain %q, got: %s", expected, body)
+				}
+			}
+		})
+	}
+}
+
+// TestPostTypeHandlerArchiveSanitized verifies the excerpts of archives
+// are sanitized like the content of posts
+func TestPostTypeHandlerArchiveSanitized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":1,"slug":"launch","title":{"rendered":"Launch"},` +
+			`"excerpt":{"rendered":"<p>Launch day<script>alert(1)</script></p>"}}]`))
+	}))
+	defer server.Close()
+
+	tmpl := setupPostTypeTemplates()
+	template.Must(tmpl.New("archive.html").Parse(`{{range .Posts}}{{.Excerpt}}{{end}}`))
+	handler := &PostTypeHandler{
+		PostType:        eventsType,
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       tmpl,
+		Sanitizer:       sanitize.New(nil),
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/events/", nil))
+
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, "<p>Launch day</p>") || strings.Contains(body, "<script") {
+		t.Errorf("Expected a sanitized excerpt, got %d: %s", w.Code, body)
+	}
+}
+
+// TestPostTypeHandlerPrivate verifies private posts are only served to
+// signed in visitors and never cached
+func TestPostTypeHandlerPrivate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":1,"slug":"briefing","lang":"en","status":"private",` +
+			`"title":{"rendered":"Briefing"},"content":{"rendered":"<p>Staff only</p>"}}]`))
+	}))
+	defer server.Close()
+
+	handler := &PostTypeHandler{
+		PostType:        eventsType,
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL, AuthenticateContent: true},
+		Templates:       setupPostTypeTemplates(),
+	}
+	users, err := auth.ParseHtpasswd("editor:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/events/briefing", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound || strings.Contains(w.Body.String(), "Staff only") {
+		t.Errorf("Expected the private post not found for an anonymous visitor, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req.SetBasicAuth("editor", "secret")
+	w = httptest.NewRecorder()
+	middleware.BasicAuth(users, "Intranet", nil)(handler).ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "private, no-store" {
+		t.Errorf("Expected the private post served uncached when signed in, got %d, %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+}
+
+// TestPostTypeHandlerDedicatedTemplates verifies a theme's templates for a
+// post type are used in place of the generic ones
+func TestPostTypeHandlerDedicatedTemplates(t *testing.T) {
+	server := postTypeServer(t)
+	defer server.Close()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"default/layout.html":      `<title>{{.Title}}</title>{{.Content}}`,
+		"default/post.html":        `{{.Content}}`,
+		"default/archive.html":     `{{range .Posts}}<a href="{{.Url}}">{{.Title}}</a>{{end}}`,
+		"events-theme/events.html": `<p class="venue">{{index .Meta "venue"}}</p>{{.Content}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	originalTheme := Theme
+	Theme = theme.New(dir, "events-theme")
+	defer func() { Theme = originalTheme }()
+
+	client := &api.WordPressClient{BaseURL: server.URL}
+	client.SetMenus(map[string]*models.MenuData{"en": {}})
+	handler := NewPostTypeHandler(eventsType, map[string]string{"en": "English Site"}, client)
+
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{"/events/launch", `<p class="venue">Ottawa</p><p>Launch day</p>`},
+		{"/events/", `<a href="/events/launch">Launch</a>`},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", tc.url, nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for %s, got %d: %s", tc.url, w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), tc.expected) {
+			t.Errorf("Expected %s to contain %q, got: %s", tc.url, tc.expected, w.Body.String())
+		}
+	}
+}
+
+// TestPostTypeHandlerComments tests that approved comments are rendered
+// below posts along with the comment form of posts open to comments
+func TestPostTypeHandlerComments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/wp-json/wp/v2/events":
+			w.Write([]byte(`[{"id":1,"slug":"launch","lang":"en","status":"publish","comment_status":"open",` +
+				`"title":{"rendered":"Launch"},"content":{"rendered":"<p>Launch day</p>"}}]`))
+		case "/wp-json/wp/v2/comments":
+			if r.URL.Query().Get("post") != "1" {
+				t.Errorf("Expected comments on post 1, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"id":7,"parent":0,"author_name":"Ada","content":{"rendered":"<p>Congratulations</p>"}},` +
+				`{"id":8,"parent":7,"author_name":"Grace","content":{"rendered":"<p>Thanks</p>"}}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tmpl := setupPostTypeTemplates()
+	template.Must(tmpl.New("post.html").Parse(
+		`{{range .Comments}}{{.AuthorName}}: {{.Content}} ({{len .Replies}} replies){{end}}` +
+			`{{with .CommentForm}}<form action="{{.Action}}" data-post="{{.PostID}}" data-path="{{.Path}}" data-status="{{.Status}}">{{end}}`))
+	handler := &PostTypeHandler{
+		PostType:        eventsType,
+		SiteNames:       map[string]string{"en": "English Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       tmpl,
+		Comments:        true,
+	}
+
+	req := httptest.NewRequest("GET", "/events/launch?comment=pending", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	for _, expected := range []string{
+		"Ada: <p>Congratulations</p> (1 replies)",
+		`<form action="/comments" data-post="1" data-path="/events/launch" data-status="pending">`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected body to contain %s, got: %s", expected, body)
+		}
+	}
+}
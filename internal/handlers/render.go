@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/internal/minify"
+	"wordpress-go-proxy/internal/sanitize"
+	"wordpress-go-proxy/internal/tracing"
+	"wordpress-go-proxy/pkg/models"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxRenderSize caps the size of a page rendered into memory before it is
+// sent.  Pages that grow past it fail to render rather than using memory
+// without bound.
+const maxRenderSize = 8 << 20
+
+// MinifyHTML reports whether rendered pages are minified before they are
+// sent.  It is set at startup from config.
+var MinifyHTML bool
+
+// NoIndex keeps every page out of search engines, such as on staging.  It
+// is set at startup from config.
+var NoIndex bool
+
+// Assets are the configured stylesheets and scripts pages load after the
+// theme's.  It is set at startup from config.
+var Assets models.Assets
+
+// Analytics is the analytics property pages load for visitors who
+// consented to analytics, if its ID is set.  It is set at startup from
+// config.
+var Analytics models.Analytics
+
+// Organization is the organization publishing the site, described in the
+// structured data of pages in the languages it has a name in.  It is set
+// at startup from config.
+var Organization models.Organization
+
+// FetchAlerts retrieves the site-wide alerts shown at the top of pages in a
+// language, or is nil if the site has none.  It is set at startup from
+// config.
+var FetchAlerts func(ctx context.Context, lang string) ([]models.Alert, error)
+
+// alertSanitizer removes unsafe markup from the messages of alerts, which
+// are shown on every page.
+var alertSanitizer = sanitize.New(nil)
+
+// errRenderTooLarge is returned when a page grows past maxRenderSize.
+var errRenderTooLarge = errors.New("rendered page too large")
+
+// limitedBuffer is a buffer that fails writes past its limit.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+// Write appends p to the buffer, or fails if it would pass the limit.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errRenderTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+// executeTemplate renders a named template to w with its functions bound
+// to a language, traced with a template-render span.
+func executeTemplate(ctx context.Context, t *template.Template, w io.Writer, name string, lang string, data any) error {
+	_, span := tracing.Start(ctx, "template-render", attribute.String("template.name", name))
+	t, err := localize(t, lang)
+	if err == nil {
+		err = t.ExecuteTemplate(w, name, data)
+	}
+	tracing.End(span, err)
+	return err
+}
+
+// renderPage renders a page inside the site layout and sends it with the
+// given status.  The page is rendered into a buffer first, so that a
+// template error is answered with the error page rather than a partial
+// page with a 200 status, and so that it can be minified.
+func renderPage(w http.ResponseWriter, r *http.Request, t *template.Template, status int, data models.PageData) {
+	if data.Path == "" {
+		data.Path = r.URL.Path
+	}
+	data.Experiments = middleware.Variants(r.Context())
+	data.SiteIcons = SiteIcons
+	data.Assets = Assets
+	if Analytics.ID != "" && middleware.Consented(r.Context()) {
+		analytics := Analytics
+		data.Analytics = &analytics
+	}
+	data.Tagline = models.Languages.Resolve(data.Lang).Tagline
+	if name := models.Languages.Resolve(data.Lang).Organization; name != "" {
+		organization := Organization
+		organization.Name = name
+		data.Organization = &organization
+	}
+	if ColorSchemes {
+		data.ColorScheme = newColorSchemeData(r)
+	}
+	// Pages are still served if alerts cannot be fetched
+	if FetchAlerts != nil {
+		alerts, err := FetchAlerts(r.Context(), data.Lang)
+		if err != nil {
+			log.Printf("Error fetching alerts: %v", err)
+		}
+		data.Alerts = sanitizeAlerts(alerts)
+	}
+	data.NoIndex = data.NoIndex || NoIndex
+	buf := &limitedBuffer{limit: maxRenderSize}
+	if err := executeTemplate(r.Context(), t, buf, layoutTemplate(t, data.Template, data.Experiments), data.Lang, data); err != nil {
+		log.Printf("Error rendering template: %v", err)
+		renderError(w, r, t, data.Lang)
+		return
+	}
+
+	page := buf.Bytes()
+	if MinifyHTML {
+		page = minify.HTML(page)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if data.NoIndex && w.Header().Get("X-Robots-Tag") == "" {
+		w.Header().Set("X-Robots-Tag", "noindex")
+	}
+	w.WriteHeader(status)
+	if _, err := w.Write(page); err != nil {
+		log.Printf("Error writing page: %v", err)
+	}
+}
+
+// layoutTemplate returns the name of the layout a page is rendered with.
+// A page whose editors chose a page template in WordPress, such as
+// "full-width", is rendered with the theme's page-full-width.html layout if
+// it has one.  Otherwise themes may define an alternate layout for a
+// variant of an experiment, named layout-<experiment>-<variant>, which is
+// used in place of layout.html for visitors assigned to it.  If several
+// apply, the first experiment by name wins.
+func layoutTemplate(t *template.Template, pageTemplate string, variants map[string]string) string {
+	if layout := templateName(t, pageContent, pageTemplate); layout != templateRules[pageContent].fallback {
+		return layout
+	}
+	names := make([]string, 0, len(variants))
+	for name := range variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		layout := "layout-" + name + "-" + variants[name]
+		if t.Lookup(layout) != nil {
+			return layout
+		}
+	}
+	return templateRules[pageContent].fallback
+}
+
+// renderError responds with a 500 and the theme's error page.  Themes
+// without an error page, or whose error page also fails to render, get a
+// plain text error.
+func renderError(w http.ResponseWriter, r *http.Request, t *template.Template, lang string) {
+	name := templateName(t, errorContent, "")
+	if t.Lookup(name) == nil {
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	data := models.ErrorPageData{
+		Lang:      lang,
+		Home:      models.Languages.Prefix(lang),
+		Status:    http.StatusInternalServerError,
+		Nonce:     middleware.Nonce(r.Context()),
+		SiteIcons: SiteIcons,
+	}
+	if err := executeTemplate(r.Context(), t, &buf, name, lang, data); err != nil {
+		log.Printf("Error rendering error template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusInternalServerError)
+	buf.WriteTo(w)
+}
+
+// sanitizeAlerts returns alerts with unsafe markup removed from their
+// messages.  The alerts are copied, since they may be shared with a cache.
+func sanitizeAlerts(alerts []models.Alert) []models.Alert {
+	if len(alerts) == 0 {
+		return alerts
+	}
+	sanitized := make([]models.Alert, len(alerts))
+	for i, alert := range alerts {
+		alert.Message = template.HTML(alertSanitizer.Sanitize(string(alert.Message)))
+		sanitized[i] = alert
+	}
+	return sanitized
+}
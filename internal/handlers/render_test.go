@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// setupRenderTemplates creates a layout that fails partway through when
+// the page has no title, and an error page
+func setupRenderTemplates() *template.Template {
+	return template.Must(template.New("layout.html").Parse(
+		`<main>{{.Content}}{{if not .Title}}{{index .Alternates 5}}{{end}}</main>` +
+			`{{define "error.html"}}<h1>Error {{.Status}}</h1><a href="{{.Home}}">Home</a>{{end}}`))
+}
+
+func TestRenderPage(t *testing.T) {
+	t.Run("Rendered page", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		data := models.PageData{Lang: "en", Title: "About", Content: "<p>About us</p>"}
+		renderPage(w, httptest.NewRequest("GET", "/about", nil), setupRenderTemplates(), http.StatusForbidden, data)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+		if w.Body.String() != "<main><p>About us</p></main>" {
+			t.Errorf("Unexpected body %q", w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+			t.Errorf("Expected HTML content type, got %q", w.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("Error after partial render", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		data := models.PageData{Lang: "fr", Content: "<p>Partial</p>"}
+		renderPage(w, httptest.NewRequest("GET", "/fr/about", nil), setupRenderTemplates(), http.StatusOK, data)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+		if strings.Contains(w.Body.String(), "Partial") {
+			t.Errorf("Expected partial page to be discarded, got %q", w.Body.String())
+		}
+		if w.Body.String() != `<h1>Error 500</h1><a href="/fr/">Home</a>` {
+			t.Errorf("Expected error page, got %q", w.Body.String())
+		}
+		if w.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("Expected error page not to be cached, got %q", w.Header().Get("Cache-Control"))
+		}
+	})
+
+	t.Run("Theme without an error page", func(t *testing.T) {
+		tmpl := template.Must(template.New("layout.html").Parse(`<main>{{index .Alternates 5}}</main>`))
+		w := httptest.NewRecorder()
+		renderPage(w, httptest.NewRequest("GET", "/about", nil), tmpl, http.StatusOK, models.PageData{Lang: "en"})
+
+		if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "Error rendering template") {
+			t.Errorf("Expected plain text error, got %d %q", w.Code, w.Body.String())
+		}
+	})
+}
+
+// TestRenderPageMinified tests that pages are minified when MinifyHTML is
+// set
+func TestRenderPageMinified(t *testing.T) {
+	MinifyHTML = true
+	defer func() { MinifyHTML = false }()
+
+	tmpl := template.Must(template.New("layout.html").Parse("<main>\n  <!-- content -->\n  {{.Content}}\n</main>\n"))
+	w := httptest.NewRecorder()
+	data := models.PageData{Lang: "en", Title: "About", Content: "<p>About   <em>us</em></p>"}
+	renderPage(w, httptest.NewRequest("GET", "/about", nil), tmpl, http.StatusOK, data)
+
+	if w.Body.String() != "<main><p>About <em>us</em></p></main>" {
+		t.Errorf("Expected minified page, got %q", w.Body.String())
+	}
+}
+
+// TestRenderPageAnalytics tests that analytics is only given to pages of
+// visitors who consented
+func TestRenderPageAnalytics(t *testing.T) {
+	Analytics = models.Analytics{Provider: "google", ID: "G-TEST123"}
+	defer func() { Analytics = models.Analytics{} }()
+
+	tmpl := template.Must(template.New("layout.html").Parse(`{{with .Analytics}}{{.Provider}} {{.ID}}{{end}}`))
+	handler := middleware.Consent("analytics_consent")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renderPage(w, r, tmpl, http.StatusOK, models.PageData{Lang: "en"})
+	}))
+
+	for cookie, expected := range map[string]string{
+		"analytics_consent=true": "google G-TEST123",
+		"analytics_consent=no":   "",
+		"":                       "",
+	} {
+		req := httptest.NewRequest("GET", "/about", nil)
+		if cookie != "" {
+			req.Header.Set("Cookie", cookie)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Body.String() != expected {
+			t.Errorf("Expected %q with cookie %q, got %q", expected, cookie, w.Body.String())
+		}
+	}
+}
+
+// TestRenderPageAlerts tests that pages show the sanitized site-wide alerts
+// of their language, and are still served when alerts cannot be fetched
+func TestRenderPageAlerts(t *testing.T) {
+	var fetchErr error
+	FetchAlerts = func(ctx context.Context, lang string) ([]models.Alert, error) {
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return []models.Alert{{Type: "danger", Title: "Outage " + lang, Message: `<p onclick="alert(1)">Down</p><script>alert(1)</script>`}}, nil
+	}
+	defer func() { FetchAlerts = nil }()
+
+	tmpl := template.Must(template.New("layout.html").Parse(`{{range .Alerts}}{{.Type}}: {{.Title}} {{.Message}}{{end}}`))
+	w := httptest.NewRecorder()
+	renderPage(w, httptest.NewRequest("GET", "/fr/a-propos", nil), tmpl, http.StatusOK, models.PageData{Lang: "fr"})
+	if w.Body.String() != "danger: Outage fr <p>Down</p>" {
+		t.Errorf("Expected the French alert, got %q", w.Body.String())
+	}
+
+	fetchErr = errors.New("down")
+	w = httptest.NewRecorder()
+	renderPage(w, httptest.NewRequest("GET", "/about", nil), tmpl, http.StatusOK, models.PageData{Lang: "en"})
+	if w.Code != http.StatusOK || w.Body.String() != "" {
+		t.Errorf("Expected the page without alerts, got %d and %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRenderPageNoIndex(t *testing.T) {
+	tmpl := template.Must(template.New("layout.html").Parse(`{{if .NoIndex}}noindex{{end}}`))
+
+	w := httptest.NewRecorder()
+	renderPage(w, httptest.NewRequest("GET", "/about", nil), tmpl, http.StatusOK, models.PageData{Lang: "en"})
+	if w.Header().Get("X-Robots-Tag") != "" || w.Body.String() != "" {
+		t.Errorf("Expected the page indexed, got %q and %q", w.Header().Get("X-Robots-Tag"), w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	renderPage(w, httptest.NewRequest("GET", "/about", nil), tmpl, http.StatusOK, models.PageData{Lang: "en", NoIndex: true})
+	if w.Header().Get("X-Robots-Tag") != "noindex" || w.Body.String() != "noindex" {
+		t.Errorf("Expected the page not indexed, got %q and %q", w.Header().Get("X-Robots-Tag"), w.Body.String())
+	}
+
+	NoIndex = true
+	defer func() { NoIndex = false }()
+	w = httptest.NewRecorder()
+	renderPage(w, httptest.NewRequest("GET", "/about", nil), tmpl, http.StatusOK, models.PageData{Lang: "en"})
+	if w.Body.String() != "noindex" {
+		t.Errorf("Expected every page not indexed, got %q", w.Body.String())
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	buf := &limitedBuffer{limit: 8}
+	if _, err := buf.Write([]byte("12345")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := buf.Write([]byte("6789")); !errors.Is(err, errRenderTooLarge) {
+		t.Errorf("Expected errRenderTooLarge, got %v", err)
+	}
+	if buf.String() != "12345" {
+		t.Errorf("Expected rejected write to be dropped, got %q", buf.String())
+	}
+}
+
+// TestRenderPageExperiments tests that pages render the layout and
+// treatments of the visitor's variants
+func TestRenderPageExperiments(t *testing.T) {
+	tmpl := template.Must(template.New("layout.html").Parse(
+		`<main>{{if eq (.Variant "hero") "short"}}short{{else}}control{{end}}</main>` +
+			`{{define "layout-nav-b"}}<nav>b</nav>{{end}}`))
+	experiments := middleware.Experiments([]middleware.Experiment{
+		{Name: "hero", Variants: []string{"control", "short"}},
+		{Name: "nav", Variants: []string{"a", "b"}},
+	})
+	handler := experiments(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renderPage(w, r, tmpl, http.StatusOK, models.PageData{Lang: "en"})
+	}))
+
+	for header, expected := range map[string]string{
+		"hero=short, nav=a":   "<main>short</main>",
+		"hero=control, nav=a": "<main>control</main>",
+		"hero=short, nav=b":   "<nav>b</nav>",
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(middleware.ExperimentsHeader, header)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Body.String() != expected {
+			t.Errorf("%s: expected %q, got %q", header, expected, w.Body.String())
+		}
+	}
+}
+
+// TestRenderPageTemplate tests that pages render the layout of the page
+// template chosen for them, falling back to layout.html
+func TestRenderPageTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("layout.html").Parse(`<main>default</main>` +
+		`{{define "page-full-width.html"}}<main class="full">{{.Title}}</main>{{end}}`))
+
+	for pageTemplate, expected := range map[string]string{
+		"":           "<main>default</main>",
+		"full-width": `<main class="full">About</main>`,
+		"landing":    "<main>default</main>",
+	} {
+		w := httptest.NewRecorder()
+		renderPage(w, httptest.NewRequest("GET", "/about", nil), tmpl, http.StatusOK, models.PageData{Lang: "en", Title: "About", Template: pageTemplate})
+		if w.Body.String() != expected {
+			t.Errorf("Template %q: expected %q, got %q", pageTemplate, expected, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/middleware"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// maxQueryLength is the maximum number of characters kept from a query.
+const maxQueryLength = 100
+
+// maxSearchPage is the highest results page that can be requested.
+const maxSearchPage = 100
+
+// SearchHandler handles site search requests.  It queries the WordPress
+// search endpoint and renders the results inside the site layout.
+type SearchHandler struct {
+	SiteNames       map[string]string
+	WordPressClient *api.WordPressClient
+	Templates       *template.Template
+}
+
+// NewSearchHandler creates a new search handler that will be used to
+// search WordPress content and render the results.
+func NewSearchHandler(siteNames map[string]string, wordPressClient *api.WordPressClient) *SearchHandler {
+	// Load templates
+	tmpl, err := parseTheme("layout.html", "search.html")
+	if err != nil {
+		log.Fatal("Error parsing template:", err)
+	}
+
+	return &SearchHandler{
+		SiteNames:       siteNames,
+		WordPressClient: wordPressClient,
+		Templates:       tmpl,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.  The language of the
+// results is determined by the search path.
+func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Search request: %s", r.URL.RequestURI())
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	lang := models.Languages.Default().Code
+	if language, ok := models.Languages.BySearchPath(r.URL.Path); ok {
+		lang = language.Code
+	}
+
+	query := sanitizeQuery(r.URL.Query().Get("q"))
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 || page > maxSearchPage {
+		page = 1
+	}
+
+	var results *models.SearchResults
+	if query != "" {
+		results, err = h.WordPressClient.Search(r.Context(), query, lang, page)
+		if err != nil {
+			upstreamError(w, r, "Error fetching search results", err)
+			log.Printf("Error fetching search results: %v", err)
+			return
+		}
+	}
+
+	var content bytes.Buffer
+	search := models.NewSearchData(lang, query, results, page, h.WordPressClient.BaseURL)
+	err = executeTemplate(r.Context(), h.Templates, &content, templateName(h.Templates, searchContent, ""), lang, search)
+	if err != nil {
+		log.Printf("Error rendering search template: %v", err)
+		renderError(w, r, h.Templates, lang)
+		return
+	}
+
+	menu, _ := h.WordPressClient.Menu(lang)
+	data := models.NewSearchPageData(lang, query, template.HTML(content.String()), menu, h.SiteNames)
+	data.Nonce = middleware.Nonce(r.Context())
+	addSurrogateKeys(w, models.LangSurrogateKey(lang))
+
+	renderPage(w, r, h.Templates, http.StatusOK, data)
+}
+
+// sanitizeQuery removes control characters and redundant whitespace from a
+// search query and limits its length.
+func sanitizeQuery(query string) string {
+	query = strings.ToValidUTF8(query, "")
+	query = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, query)
+	query = strings.Join(strings.Fields(query), " ")
+
+	if runes := []rune(query); len(runes) > maxQueryLength {
+		query = strings.TrimSpace(string(runes[:maxQueryLength]))
+	}
+	return query
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// setupSearchTemplates creates mock layout and search templates for testing
+func setupSearchTemplates() *template.Template {
+	tmpl := setupTestTemplates()
+	template.Must(tmpl.New("search.html").Parse(
+		`{{.Total}} results for {{.Query}}{{range .Results}}<a href="{{.Url}}">{{.Title}}</a>{{end}}{{.NextUrl}}`))
+	return tmpl
+}
+
+func TestSearchHandlerServeHTTP(t *testing.T) {
+	var lastQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-WP-Total", "11")
+		w.Header().Set("X-WP-TotalPages", "2")
+		json.NewEncoder(w).Encode([]models.WordPressSearchResult{
+			{ID: 1, Title: "Tax credits", Url: "/tax-credits"},
+		})
+	}))
+	defer server.Close()
+
+	handler := &SearchHandler{
+		SiteNames:       map[string]string{"en": "English Site", "fr": "French Site"},
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       setupSearchTemplates(),
+	}
+
+	testCases := []struct {
+		name           string
+		method         string
+		url            string
+		expectedStatus int
+		expectedBody   []string
+		expectedQuery  string
+	}{
+		{
+			name:           "English search",
+			method:         "GET",
+			url:            "/search?q=tax",
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				`<html lang="en">`,
+				"<title>Search results</title>",
+				"11 results for tax",
+				"<mark>Tax</mark> credits",
+				"/search?page=2&amp;q=tax",
+			},
+			expectedQuery: "_fields=id%2Ctitle%2Curl%2Ctype%2Csubtype&lang=en&page=1&per_page=10&search=tax",
+		},
+		{
+			name:           "French search with page",
+			method:         "GET",
+			url:            "/fr/recherche?q=tax&page=2",
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				`<html lang="fr">`,
+				"<title>Résultats de recherche</title>",
+			},
+			expectedQuery: "_fields=id%2Ctitle%2Curl%2Ctype%2Csubtype&lang=fr&page=2&per_page=10&search=tax",
+		},
+		{
+			name:           "Query is sanitized",
+			method:         "GET",
+			url:            "/search?q=%20tax%0A%0Dcredits%20&page=-4",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"11 results for tax credits"},
+			expectedQuery:  "_fields=id%2Ctitle%2Curl%2Ctype%2Csubtype&lang=en&page=1&per_page=10&search=tax+credits",
+		},
+		{
+			name:           "Empty query does not search",
+			method:         "GET",
+			url:            "/search?q=++",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"0 results for"},
+		},
+		{
+			name:           "Invalid method",
+			method:         "POST",
+			url:            "/search?q=tax",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lastQuery = ""
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, resp.StatusCode)
+			}
+			if lastQuery != tc.expectedQuery {
+				t.Errorf("Expected upstream query %q, got %q", tc.expectedQuery, lastQuery)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			for _, expected := range tc.expectedBody {
+				if !strings.Contains(string(body), expected) {
+					t.Errorf("Expected body to contain %q, got: %s", expected, string(body))
+				}
+			}
+		})
+	}
+}
+
+func TestSearchHandlerUpstreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	handler := &SearchHandler{
+		WordPressClient: &api.WordPressClient{BaseURL: server.URL},
+		Templates:       setupSearchTemplates(),
+	}
+
+	req := httptest.NewRequest("GET", "/search?q=tax", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestSanitizeQuery(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{"  tax   credits ", "tax credits"},
+		{"tax\x00\ncredits", "tax credits"},
+		{"impôts\xff", "impôts"},
+		{strings.Repeat("a", 150), strings.Repeat("a", 100)},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := sanitizeQuery(tc.query); got != tc.expected {
+			t.Errorf("sanitizeQuery(%q): expected %q, got %q", tc.query, tc.expected, got)
+		}
+	}
+}
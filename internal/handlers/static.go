@@ -1,16 +1,41 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
 	"log"
 	"mime"
 	"net/http"
+	"path"
 	"path/filepath"
+	"sync"
+	"time"
 )
 
-// StaticHandler handles static file requests
+// StaticHandler handles static file requests.  Files are served by
+// http.FileServer, which answers byte-range, HEAD and If-Modified-Since
+// requests, with an ETag of their contents so that If-None-Match and
+// If-Range also work where the modification times of deployed files are
+// not meaningful, such as in Lambda packages.
 type StaticHandler struct {
 	fileServer http.Handler
 	staticDir  string
+
+	// DirectoryListings serves listings of directories without an
+	// index.html.  They are not found otherwise.
+	DirectoryListings bool
+
+	// etags caches the ETag of each file by path, along with the size and
+	// modification time it was computed for.
+	etags sync.Map
+}
+
+// staticETag is the ETag of a version of a static file.
+type staticETag struct {
+	size    int64
+	modTime time.Time
+	etag    string
 }
 
 // NewStaticHandler creates a new static file handler
@@ -38,5 +63,70 @@ func (h *StaticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Set cache control headers for static assets
 	w.Header().Set("Cache-Control", "public, max-age=604800") // 7 days
 
+	if !h.DirectoryListings && h.listing(r.URL.Path) {
+		log.Printf("Directory listing disabled: %s", r.URL.Path)
+		http.NotFound(w, r)
+		return
+	}
+
+	// The file server checks conditional requests against the ETag
+	if etag, ok := h.etag(r.URL.Path); ok {
+		w.Header().Set("ETag", etag)
+	}
+
 	h.fileServer.ServeHTTP(w, r)
 }
+
+// listing reports whether the file server would list the directory at a
+// request path, which it does for directories without an index.html.
+func (h *StaticHandler) listing(urlPath string) bool {
+	name := path.Clean("/" + urlPath)
+	dir := http.Dir(h.staticDir)
+	f, err := dir.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil || !info.IsDir() {
+		return false
+	}
+
+	index, err := dir.Open(path.Join(name, "index.html"))
+	if err != nil {
+		return true
+	}
+	index.Close()
+	return false
+}
+
+// etag returns the ETag of the file at a request path, computed from its
+// contents the first time it is served and again when it changes.  False
+// is returned for directories and files that cannot be read.
+func (h *StaticHandler) etag(urlPath string) (string, bool) {
+	name := path.Clean("/" + urlPath)
+	f, err := http.Dir(h.staticDir).Open(name)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+
+	if cached, ok := h.etags.Load(name); ok {
+		cached := cached.(staticETag)
+		if cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+			return cached.etag, true
+		}
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		log.Printf("Error hashing static file %s: %v", name, err)
+		return "", false
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	h.etags.Store(name, staticETag{size: info.Size(), modTime: info.ModTime(), etag: etag})
+	return etag, true
+}
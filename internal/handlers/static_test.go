@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"context"
+	"encoding/base64"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
 )
 
 func TestNewStaticHandler(t *testing.T) {
@@ -75,7 +82,7 @@ func TestStaticHandlerServeHTTP(t *testing.T) {
 			name:           "JavaScript file",
 			path:           "/test.js",
 			expectedStatus: http.StatusOK,
-			expectedType:   "application/javascript",
+			expectedType:   "text/javascript; charset=utf-8",
 			checkBody:      true,
 		},
 		{
@@ -109,8 +116,8 @@ func TestStaticHandlerServeHTTP(t *testing.T) {
 		{
 			name:           "Directory listing",
 			path:           "/",
-			expectedStatus: http.StatusOK,
-			expectedType:   "text/html; charset=utf-8",
+			expectedStatus: http.StatusNotFound,
+			expectedType:   "text/plain; charset=utf-8",
 			checkBody:      false,
 		},
 	}
@@ -286,3 +293,162 @@ func TestStaticHandlerWithNestedPaths(t *testing.T) {
 		t.Errorf("Expected status 404 for directory traversal attempt, got %d", resp.StatusCode)
 	}
 }
+
+// setupStaticPDF creates a static directory holding a binary document
+// modified at a fixed time
+func setupStaticPDF(t *testing.T) (string, []byte, time.Time) {
+	dir := t.TempDir()
+	content := []byte("%PDF-1.7\n\xff\xfe binary body")
+	modTime := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	file := filepath.Join(dir, "guide.pdf")
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		t.Fatalf("Could not create test file: %v", err)
+	}
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatalf("Could not set modification time: %v", err)
+	}
+	return dir, content, modTime
+}
+
+func TestStaticHandlerConditional(t *testing.T) {
+	dir, content, modTime := setupStaticPDF(t)
+	handler := NewStaticHandler(dir)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/guide.pdf", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || len(etag) != 34 || w.Header().Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("Expected ETag and ranges, got %d with %v", w.Code, w.Header())
+	}
+	if w.Header().Get("Last-Modified") != modTime.Format(http.TimeFormat) {
+		t.Errorf("Expected Last-Modified, got %q", w.Header().Get("Last-Modified"))
+	}
+
+	testCases := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		status  int
+		body    string
+		length  string
+	}{
+		{name: "Range", method: "GET", headers: map[string]string{"Range": "bytes=0-3"}, status: http.StatusPartialContent, body: "%PDF", length: "4"},
+		{name: "Open range", method: "GET", headers: map[string]string{"Range": "bytes=-4"}, status: http.StatusPartialContent, body: string(content[len(content)-4:])},
+		{name: "Unsatisfiable range", method: "GET", headers: map[string]string{"Range": "bytes=1000-"}, status: http.StatusRequestedRangeNotSatisfiable},
+		{name: "Not modified since", method: "GET", headers: map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)}, status: http.StatusNotModified},
+		{name: "Modified since", method: "GET", headers: map[string]string{"If-Modified-Since": modTime.Add(-time.Hour).Format(http.TimeFormat)}, status: http.StatusOK, body: string(content)},
+		{name: "Matching ETag", method: "GET", headers: map[string]string{"If-None-Match": etag}, status: http.StatusNotModified},
+		{name: "Other ETag", method: "GET", headers: map[string]string{"If-None-Match": `"other"`}, status: http.StatusOK, body: string(content)},
+		{name: "If-Range matching", method: "GET", headers: map[string]string{"Range": "bytes=0-3", "If-Range": etag}, status: http.StatusPartialContent, body: "%PDF"},
+		{name: "If-Range changed", method: "GET", headers: map[string]string{"Range": "bytes=0-3", "If-Range": `"other"`}, status: http.StatusOK, body: string(content)},
+		{name: "HEAD", method: "HEAD", status: http.StatusOK, length: strconv.Itoa(len(content))},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/guide.pdf", nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			// Unsatisfiable ranges are answered with an error message
+			if w.Code != tc.status || (w.Code != http.StatusRequestedRangeNotSatisfiable && w.Body.String() != tc.body) {
+				t.Errorf("Expected %d %q, got %d %q", tc.status, tc.body, w.Code, w.Body.String())
+			}
+			if tc.length != "" && w.Header().Get("Content-Length") != tc.length {
+				t.Errorf("Expected Content-Length %s, got %q", tc.length, w.Header().Get("Content-Length"))
+			}
+			if tc.status != http.StatusRequestedRangeNotSatisfiable && w.Header().Get("ETag") != etag {
+				t.Errorf("Expected ETag %s, got %q", etag, w.Header().Get("ETag"))
+			}
+		})
+	}
+
+	// Changed files get a new ETag
+	if err := os.WriteFile(filepath.Join(dir, "guide.pdf"), []byte("%PDF-1.7 revised"), 0644); err != nil {
+		t.Fatalf("Could not update test file: %v", err)
+	}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/guide.pdf", nil))
+	if w.Header().Get("ETag") == etag || w.Body.String() != "%PDF-1.7 revised" {
+		t.Errorf("Expected new ETag for changed file, got %q", w.Header().Get("ETag"))
+	}
+}
+
+// TestStaticHandlerLambda tests that ranges, conditional requests and
+// binary bodies survive the Lambda adapter
+func TestStaticHandlerLambda(t *testing.T) {
+	dir, content, _ := setupStaticPDF(t)
+	proxy := httpadapter.NewV2(http.StripPrefix("/static/", NewStaticHandler(dir))).ProxyWithContext
+
+	request := func(method string, headers map[string]string) events.APIGatewayV2HTTPResponse {
+		event := events.APIGatewayV2HTTPRequest{
+			RawPath: "/static/guide.pdf",
+			Headers: headers,
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: method, Path: "/static/guide.pdf"},
+			},
+		}
+		resp, err := proxy(context.Background(), event)
+		if err != nil {
+			t.Fatalf("Error proxying %s request: %v", method, err)
+		}
+		return resp
+	}
+
+	resp := request("GET", map[string]string{"range": "bytes=9-12"})
+	body, _ := base64.StdEncoding.DecodeString(resp.Body)
+	if resp.StatusCode != http.StatusPartialContent || !resp.IsBase64Encoded || string(body) != string(content[9:13]) {
+		t.Errorf("Expected binary range, got %d %q (base64 %v)", resp.StatusCode, resp.Body, resp.IsBase64Encoded)
+	}
+	etag := resp.Headers["Etag"]
+
+	resp = request("GET", map[string]string{"if-none-match": etag})
+	if resp.StatusCode != http.StatusNotModified || resp.Body != "" {
+		t.Errorf("Expected 304 for matching ETag %s, got %d", etag, resp.StatusCode)
+	}
+
+	resp = request("HEAD", nil)
+	if resp.StatusCode != http.StatusOK || resp.Body != "" || resp.Headers["Content-Length"] != strconv.Itoa(len(content)) {
+		t.Errorf("Expected HEAD with length and no body, got %d %q %v", resp.StatusCode, resp.Body, resp.Headers)
+	}
+}
+
+func TestStaticHandlerDirectoryListings(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"css", "docs"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatalf("Could not create directory: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "css", "styles.css"), []byte("body {}"), 0644); err != nil {
+		t.Fatalf("Could not create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "docs", "index.html"), []byte("<h1>Docs</h1>"), 0644); err != nil {
+		t.Fatalf("Could not create test file: %v", err)
+	}
+
+	testCases := []struct {
+		path     string
+		listings bool
+		status   int
+		body     string
+	}{
+		{path: "/", status: http.StatusNotFound},
+		{path: "/css/", status: http.StatusNotFound},
+		{path: "/css/styles.css", status: http.StatusOK, body: "body {}"},
+		{path: "/docs/", status: http.StatusOK, body: "<h1>Docs</h1>"},
+		{path: "/css/", listings: true, status: http.StatusOK, body: `<a href="styles.css">styles.css</a>`},
+	}
+	for _, tc := range testCases {
+		handler := NewStaticHandler(dir)
+		handler.DirectoryListings = tc.listings
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if w.Code != tc.status || !strings.Contains(w.Body.String(), tc.body) {
+			t.Errorf("%s (listings %v): expected %d with %q, got %d %q", tc.path, tc.listings, tc.status, tc.body, w.Code, w.Body.String())
+		}
+	}
+}
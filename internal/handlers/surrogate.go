@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// SurrogateKeyHeader lists the cache tags of a response, separated by
+// spaces, so that a CDN such as Fastly, or CloudFront through a function,
+// can purge every response tagged with a key when content changes.
+const SurrogateKeyHeader = "Surrogate-Key"
+
+// addSurrogateKeys adds keys to the Surrogate-Key header of a response,
+// skipping those it already lists.
+func addSurrogateKeys(w http.ResponseWriter, keys ...string) {
+	listed := strings.Fields(w.Header().Get(SurrogateKeyHeader))
+	for _, key := range keys {
+		if key != "" && !slices.Contains(listed, key) {
+			listed = append(listed, key)
+		}
+	}
+	if len(listed) > 0 {
+		w.Header().Set(SurrogateKeyHeader, strings.Join(listed, " "))
+	}
+}
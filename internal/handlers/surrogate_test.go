@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAddSurrogateKeys(t *testing.T) {
+	w := httptest.NewRecorder()
+	addSurrogateKeys(w)
+	if _, ok := w.Header()[SurrogateKeyHeader]; ok {
+		t.Errorf("Expected no header without keys, got %q", w.Header().Get(SurrogateKeyHeader))
+	}
+
+	addSurrogateKeys(w, "page-1", "type-page")
+	addSurrogateKeys(w, "page-2", "", "page-1")
+	if got := w.Header().Get(SurrogateKeyHeader); got != "page-1 type-page page-2" {
+		t.Errorf("Expected keys without duplicates, got %q", got)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"html/template"
+)
+
+// contentKind is a kind of content rendered with its own template of the
+// theme, and with its own data: models.PageData for pages,
+// models.PostData for posts, models.ArchiveData for archives,
+// models.SearchData for search results and models.ErrorPageData for the
+// error page.
+type contentKind string
+
+// The kinds of content with their own templates.
+const (
+	pageContent    contentKind = "page"
+	postContent    contentKind = "post"
+	archiveContent contentKind = "archive"
+	searchContent  contentKind = "search"
+	errorContent   contentKind = "error"
+)
+
+// templateRule names the templates of a kind of content: the template
+// dedicated to some of it, such as the posts of one post type, and the
+// template of the kind the rest falls back to.
+type templateRule struct {
+	dedicated func(name string) string
+	fallback  string
+}
+
+// templateRules are the templates of each kind of content.  Pages are
+// rendered with the page-<template>.html layout of the page template
+// editors chose, the posts of a post type with <type>.html and its archive
+// with <type>-archive.html.
+var templateRules = map[contentKind]templateRule{
+	pageContent:    {dedicated: func(name string) string { return "page-" + name + ".html" }, fallback: "layout.html"},
+	postContent:    {dedicated: func(name string) string { return name + ".html" }, fallback: "post.html"},
+	archiveContent: {dedicated: func(name string) string { return name + "-archive.html" }, fallback: "archive.html"},
+	searchContent:  {fallback: "search.html"},
+	errorContent:   {fallback: "error.html"},
+}
+
+// templateFiles returns the templates to parse for content of a kind: the
+// template of the kind, and the templates of the theme dedicated to names
+// matching a pattern, such as a post type, or "*" for every page template.
+func templateFiles(kind contentKind, pattern string) ([]string, error) {
+	rule := templateRules[kind]
+	names := []string{rule.fallback}
+	if rule.dedicated == nil || pattern == "" {
+		return names, nil
+	}
+	dedicated, err := Theme.Glob(rule.dedicated(pattern))
+	if err != nil {
+		return nil, err
+	}
+	return append(names, dedicated...), nil
+}
+
+// templateName returns the name of the template content of a kind is
+// rendered with: the template dedicated to name if t has it, or the
+// template of the kind.
+func templateName(t *template.Template, kind contentKind, name string) string {
+	rule := templateRules[kind]
+	if rule.dedicated != nil && name != "" {
+		if dedicated := rule.dedicated(name); t.Lookup(dedicated) != nil {
+			return dedicated
+		}
+	}
+	return rule.fallback
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"wordpress-go-proxy/internal/theme"
+)
+
+func TestTemplateName(t *testing.T) {
+	tmpl := template.Must(template.New("layout.html").Parse(`layout`))
+	template.Must(tmpl.New("events.html").Parse(`events`))
+	template.Must(tmpl.New("page-landing.html").Parse(`landing`))
+
+	testCases := []struct {
+		kind     contentKind
+		name     string
+		expected string
+	}{
+		{pageContent, "", "layout.html"},
+		{pageContent, "landing", "page-landing.html"},
+		{pageContent, "full-width", "layout.html"},
+		{postContent, "events", "events.html"},
+		{postContent, "news", "post.html"},
+		{archiveContent, "events", "archive.html"},
+		{searchContent, "events", "search.html"},
+		{errorContent, "", "error.html"},
+	}
+
+	for _, tc := range testCases {
+		if got := templateName(tmpl, tc.kind, tc.name); got != tc.expected {
+			t.Errorf("templateName(%s, %q): expected %q, got %q", tc.kind, tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestTemplateFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"default/layout.html", "default/page-full-width.html", "events-theme/page-landing.html", "events-theme/events-archive.html"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	originalTheme := Theme
+	Theme = theme.New(dir, "events-theme")
+	defer func() { Theme = originalTheme }()
+
+	testCases := []struct {
+		kind     contentKind
+		pattern  string
+		expected []string
+	}{
+		{pageContent, "*", []string{"layout.html", "page-full-width.html", "page-landing.html"}},
+		{postContent, "events", []string{"post.html"}},
+		{archiveContent, "events", []string{"archive.html", "events-archive.html"}},
+		{searchContent, "*", []string{"search.html"}},
+	}
+
+	for _, tc := range testCases {
+		files, err := templateFiles(tc.kind, tc.pattern)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(files, tc.expected) {
+			t.Errorf("templateFiles(%s, %q): expected %v, got %v", tc.kind, tc.pattern, tc.expected, files)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"wordpress-go-proxy/internal/api"
+	"wordpress-go-proxy/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// upstreamError responds to a request whose content could not be fetched
+// from WordPress with a status for the class of the error: a 404 if
+// WordPress has no such content, a 503 with Retry-After if it is rate
+// limiting the proxy, a 504 if it timed out, and a 500 otherwise.  The
+// class is recorded on the request's span so errors can be counted by
+// class.
+func upstreamError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	tracing.Annotate(r.Context(), attribute.String("wordpress.error_class", api.ErrorClass(err)))
+
+	switch {
+	case errors.Is(err, api.ErrNotFound):
+		http.NotFound(w, r)
+	case errors.Is(err, api.ErrRateLimited):
+		if retryAfter := api.RetryAfter(err); retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		}
+		http.Error(w, message, http.StatusServiceUnavailable)
+	case errors.Is(err, api.ErrUpstreamTimeout):
+		http.Error(w, message, http.StatusGatewayTimeout)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/api"
+)
+
+func TestUpstreamError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		expected   int
+		retryAfter string
+	}{
+		{"not found", &api.StatusError{StatusCode: http.StatusGone}, http.StatusNotFound, ""},
+		{"rate limited", &api.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 1500 * time.Millisecond}, http.StatusServiceUnavailable, "2"},
+		{"timeout", fmt.Errorf("%w: context deadline exceeded", api.ErrUpstreamTimeout), http.StatusGatewayTimeout, ""},
+		{"unauthorized", &api.StatusError{StatusCode: http.StatusUnauthorized}, http.StatusInternalServerError, ""},
+		{"other", errors.New("connection refused"), http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			upstreamError(w, httptest.NewRequest("GET", "/about", nil), "Error fetching page content", tt.err)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("Expected Retry-After %q, got %q", tt.retryAfter, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"wordpress-go-proxy/internal/middleware"
+)
+
+// TrustForwardedHost is whether the X-Forwarded-Host header of requests is
+// set by a trusted proxy, such as CloudFront in front of API Gateway, so
+// that it gives the public host of the proxy rather than its internal one.
+// It is set at startup from config.
+var TrustForwardedHost bool
+
+// requestOrigin returns the scheme and host the client used to reach the
+// proxy.  The scheme is taken from X-Forwarded-Proto when the request has
+// been forwarded by a load balancer or the Lambda function URL, and the host
+// from X-Forwarded-Host when it is trusted.
+func requestOrigin(r *http.Request) string {
+	scheme := r.Header.Get("X-Forwarded-Proto")
+	if scheme != "http" && scheme != "https" {
+		scheme = "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+	}
+	host := r.Host
+	if forwarded, ok := forwardedHost(r); ok {
+		host = forwarded
+	}
+	return scheme + "://" + host
+}
+
+// forwardedHost returns the host of a request's X-Forwarded-Host header if
+// it is trusted and valid.
+func forwardedHost(r *http.Request) (string, bool) {
+	if !TrustForwardedHost {
+		return "", false
+	}
+	return middleware.ForwardedHost(r)
+}
+
+// siteURL returns the configured public URL of the proxy, falling back to
+// the origin of the request if it is not set, in which case the response
+// varies on the forwarded scheme, and host if it is trusted.
+func siteURL(w http.ResponseWriter, configured string, r *http.Request) string {
+	if configured != "" {
+		return configured
+	}
+	middleware.AddVary(w.Header(), "X-Forwarded-Proto")
+	if TrustForwardedHost {
+		middleware.AddVary(w.Header(), "X-Forwarded-Host")
+	}
+	return requestOrigin(r)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestOrigin(t *testing.T) {
+	testCases := []struct {
+		name     string
+		proto    string
+		tls      bool
+		expected string
+	}{
+		{"Plain HTTP", "", false, "http://proxy.example.com"},
+		{"TLS", "", true, "https://proxy.example.com"},
+		{"Forwarded HTTPS", "https", false, "https://proxy.example.com"},
+		{"Invalid forwarded scheme", "javascript", false, "http://proxy.example.com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://proxy.example.com/feed.xml", nil)
+			if tc.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tc.proto)
+			}
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			} else {
+				req.TLS = nil
+			}
+
+			if got := requestOrigin(req); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRequestOriginForwardedHost(t *testing.T) {
+	defer func() { TrustForwardedHost = false }()
+
+	testCases := []struct {
+		name     string
+		host     string
+		trusted  bool
+		expected string
+	}{
+		{"Untrusted", "www.example.ca", false, "https://abc123.execute-api.ca-central-1.amazonaws.com"},
+		{"Trusted", "www.example.ca", true, "https://www.example.ca"},
+		{"Port", "www.example.ca:8443", true, "https://www.example.ca:8443"},
+		{"Several proxies", "www.example.ca, cdn.example.net", true, "https://www.example.ca"},
+		{"Missing", "", true, "https://abc123.execute-api.ca-central-1.amazonaws.com"},
+		{"With a path", "evil.example/path", true, "https://abc123.execute-api.ca-central-1.amazonaws.com"},
+		{"With user info", "user@evil.example", true, "https://abc123.execute-api.ca-central-1.amazonaws.com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TrustForwardedHost = tc.trusted
+			req := httptest.NewRequest("GET", "http://abc123.execute-api.ca-central-1.amazonaws.com/about", nil)
+			req.Header.Set("X-Forwarded-Proto", "https")
+			if tc.host != "" {
+				req.Header.Set("X-Forwarded-Host", tc.host)
+			}
+
+			if got := requestOrigin(req); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+
+	TrustForwardedHost = true
+	w := httptest.NewRecorder()
+	siteURL(w, "", httptest.NewRequest("GET", "/about", nil))
+	if vary := w.Header().Values("Vary"); len(vary) != 2 || vary[1] != "X-Forwarded-Host" {
+		t.Errorf("Expected response to vary on the forwarded host, got %q", vary)
+	}
+}
+
+func TestSiteURL(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://proxy.example.com/about", nil)
+
+	w := httptest.NewRecorder()
+	if got := siteURL(w, "https://www.example.ca", req); got != "https://www.example.ca" {
+		t.Errorf("Expected configured URL, got %q", got)
+	}
+	if vary := w.Header().Get("Vary"); vary != "" {
+		t.Errorf("Expected no Vary with a configured URL, got %q", vary)
+	}
+	if got := siteURL(w, "", req); got != "http://proxy.example.com" {
+		t.Errorf("Expected request origin, got %q", got)
+	}
+	if vary := w.Header().Get("Vary"); vary != "X-Forwarded-Proto" {
+		t.Errorf("Expected response to vary on the forwarded scheme, got %q", vary)
+	}
+}
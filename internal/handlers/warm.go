@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"wordpress-go-proxy/internal/auth"
+	"wordpress-go-proxy/internal/warm"
+)
+
+// WarmHandler warms the caches on request, such as from a scheduled
+// EventBridge rule after a deploy.  Requests must carry a warm token,
+// signed with the secret shared with the WordPress plugin, as a bearer
+// credential.  The response is sent once the warm is done, with the number
+// of paths warmed.
+type WarmHandler struct {
+	Warmer *warm.Warmer
+	Signer *auth.Signer
+}
+
+// NewWarmHandler creates a new warm handler.
+func NewWarmHandler(warmer *warm.Warmer, signer *auth.Signer) *WarmHandler {
+	return &WarmHandler{
+		Warmer: warmer,
+		Signer: signer,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *WarmHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	job[warm.Result]{
+		name:    "Warm",
+		failure: "Error warming cache",
+		purpose: auth.PurposeWarm,
+		signer:  h.Signer,
+		running: warm.ErrWarming,
+		run:     h.Warmer.Warm,
+	}.ServeHTTP(w, r)
+}
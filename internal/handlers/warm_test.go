@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/internal/auth"
+	"wordpress-go-proxy/internal/warm"
+	"wordpress-go-proxy/pkg/models"
+)
+
+// fakeWarmMenus returns fixed menus to warm
+type fakeWarmMenus map[string]*models.MenuData
+
+func (f fakeWarmMenus) Menus() map[string]*models.MenuData {
+	return f
+}
+
+func TestWarmHandlerServeHTTP(t *testing.T) {
+	secret := "0123456789abcdef0123456789abcdef"
+	signer := auth.NewSigner([]byte(secret), 5*time.Minute, 30*time.Second)
+	bearer := func(purpose string) string {
+		token, _, _ := signer.Issue(purpose, "")
+		return "Bearer " + token
+	}
+
+	var requested []string
+	pages := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+	})
+	menus := fakeWarmMenus{"en": {Items: []*models.MenuItemData{{Title: "About", Url: "/about"}}}}
+	handler := NewWarmHandler(warm.New(pages, menus, nil), signer)
+
+	testCases := []struct {
+		name           string
+		method         string
+		authorization  string
+		expectedStatus int
+	}{
+		{"Invalid method", "GET", bearer(auth.PurposeWarm), http.StatusMethodNotAllowed},
+		{"Missing credential", "POST", "", http.StatusUnauthorized},
+		{"Wrong credential", "POST", "Bearer wrong", http.StatusUnauthorized},
+		{"Shared secret", "POST", "Bearer " + secret, http.StatusUnauthorized},
+		{"Token for another purpose", "POST", bearer(auth.PurposeExport), http.StatusUnauthorized},
+		{"Warm", "POST", bearer(auth.PurposeWarm), http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			requested = nil
+			req := httptest.NewRequest(tc.method, "/internal/warm", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "no-store" {
+				t.Errorf("Expected Cache-Control no-store, got %q", cacheControl)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				if len(requested) != 0 {
+					t.Errorf("Expected no paths warmed, got %v", requested)
+				}
+				return
+			}
+
+			var result warm.Result
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Error decoding response: %v", err)
+			}
+			if result.Warmed != len(requested) || result.Failed != 0 {
+				t.Errorf("Expected %d paths warmed, got %+v", len(requested), result)
+			}
+			if len(requested) == 0 || requested[len(requested)-1] != "/about" {
+				t.Errorf("Expected menu link warmed, got %v", requested)
+			}
+		})
+	}
+}
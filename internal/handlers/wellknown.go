@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WellKnownPath is the path prefix of well-known documents (RFC 8615).
+const WellKnownPath = "/.well-known/"
+
+// securityTxtExpiry is how long a generated security.txt is valid for when
+// no expiry is configured, keeping it under the year RFC 9116 recommends.
+const securityTxtExpiry = 180 * 24 * time.Hour
+
+// SecurityTxt holds the fields of a security.txt file (RFC 9116).
+type SecurityTxt struct {
+	// Contacts are the mailto:, tel: or https: URIs security issues are
+	// reported to, in order of preference.
+	Contacts []string
+
+	// Expires is when the file should no longer be trusted.  If zero, the
+	// file expires securityTxtExpiry after it is served.
+	Expires time.Time
+
+	// Policy is the URL of the vulnerability disclosure policy, if any.
+	Policy string
+
+	// Languages are the languages reports may be written in.
+	Languages []string
+
+	// Canonical is the URL the file is served at, if known.
+	Canonical string
+}
+
+// String returns the file's contents, with an expiry relative to now if
+// none is set.
+func (s SecurityTxt) String(now time.Time) string {
+	var b strings.Builder
+	for _, contact := range s.Contacts {
+		fmt.Fprintf(&b, "Contact: %s\n", contact)
+	}
+	expires := s.Expires
+	if expires.IsZero() {
+		expires = now.Add(securityTxtExpiry).Truncate(24 * time.Hour)
+	}
+	fmt.Fprintf(&b, "Expires: %s\n", expires.UTC().Format(time.RFC3339))
+	if s.Policy != "" {
+		fmt.Fprintf(&b, "Policy: %s\n", s.Policy)
+	}
+	if len(s.Languages) > 0 {
+		fmt.Fprintf(&b, "Preferred-Languages: %s\n", strings.Join(s.Languages, ", "))
+	}
+	if s.Canonical != "" {
+		fmt.Fprintf(&b, "Canonical: %s\n", s.Canonical)
+	}
+	return b.String()
+}
+
+// WellKnownHandler serves the documents under /.well-known/.  Documents
+// found in Dir are served as static files, such as assetlinks.json or a
+// signed security.txt.  Otherwise security.txt is generated from
+// SecurityTxt and change-password redirects to ChangePasswordURL (per the
+// W3C well-known URL for changing passwords), if they are set.
+type WellKnownHandler struct {
+	SecurityTxt       *SecurityTxt
+	ChangePasswordURL string
+	Dir               string
+
+	static *StaticHandler
+	now    func() time.Time
+}
+
+// NewWellKnownHandler creates a new handler for well-known documents.  dir
+// may be empty if no documents are served from files.
+func NewWellKnownHandler(securityTxt *SecurityTxt, changePasswordURL string, dir string) *WellKnownHandler {
+	h := &WellKnownHandler{
+		SecurityTxt:       securityTxt,
+		ChangePasswordURL: changePasswordURL,
+		Dir:               dir,
+		now:               time.Now,
+	}
+	if dir != "" {
+		h.static = NewStaticHandler(dir)
+	}
+	return h
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *WellKnownHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutPrefix(r.URL.Path, WellKnownPath)
+	log.Printf("Well-known request: %s", r.URL.Path)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		log.Printf("Invalid HTTP method: %s", r.Method)
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !ok || name == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if h.fileExists(name) {
+		http.StripPrefix(strings.TrimSuffix(WellKnownPath, "/"), h.static).ServeHTTP(w, r)
+		return
+	}
+
+	switch {
+	case name == "security.txt" && h.SecurityTxt != nil:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=86400") // 1 day
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.Write([]byte(h.SecurityTxt.String(h.now())))
+	case name == "change-password" && h.ChangePasswordURL != "":
+		http.Redirect(w, r, h.ChangePasswordURL, http.StatusFound)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// fileExists reports whether Dir holds a regular file with a name.
+func (h *WellKnownHandler) fileExists(name string) bool {
+	if h.static == nil || strings.Contains(name, "..") {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(h.Dir, filepath.FromSlash(name)))
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSecurityTxtString(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	securityTxt := SecurityTxt{
+		Contacts:  []string{"mailto:security@example.com", "https://example.com/report"},
+		Policy:    "https://example.com/disclosure",
+		Languages: []string{"en", "fr"},
+		Canonical: "https://example.com/.well-known/security.txt",
+	}
+
+	expected := "Contact: mailto:security@example.com\n" +
+		"Contact: https://example.com/report\n" +
+		"Expires: 2025-08-28T00:00:00Z\n" +
+		"Policy: https://example.com/disclosure\n" +
+		"Preferred-Languages: en, fr\n" +
+		"Canonical: https://example.com/.well-known/security.txt\n"
+	if got := securityTxt.String(now); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	securityTxt = SecurityTxt{
+		Contacts: []string{"mailto:security@example.com"},
+		Expires:  time.Date(2025, 12, 31, 0, 0, 0, 0, time.FixedZone("EST", -5*3600)),
+	}
+	expected = "Contact: mailto:security@example.com\nExpires: 2025-12-31T05:00:00Z\n"
+	if got := securityTxt.String(now); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestWellKnownHandler(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "assetlinks.json"), []byte(`[]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	securityTxt := &SecurityTxt{Contacts: []string{"mailto:security@example.com"}}
+	handler := NewWellKnownHandler(securityTxt, "https://example.com/wp-admin/profile.php", dir)
+	handler.now = func() time.Time { return time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	testCases := []struct {
+		name        string
+		method      string
+		path        string
+		status      int
+		contentType string
+		location    string
+		body        string
+	}{
+		{
+			name:        "Security.txt",
+			method:      "GET",
+			path:        "/.well-known/security.txt",
+			status:      http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+			body:        "Contact: mailto:security@example.com\nExpires: 2025-08-28T00:00:00Z\n",
+		},
+		{
+			name:        "Security.txt HEAD",
+			method:      "HEAD",
+			path:        "/.well-known/security.txt",
+			status:      http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:     "Change password",
+			method:   "GET",
+			path:     "/.well-known/change-password",
+			status:   http.StatusFound,
+			location: "https://example.com/wp-admin/profile.php",
+		},
+		{
+			name:        "File",
+			method:      "GET",
+			path:        "/.well-known/assetlinks.json",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        "[]",
+		},
+		{name: "Directory", method: "GET", path: "/.well-known/nested/", status: http.StatusNotFound},
+		{name: "Unknown", method: "GET", path: "/.well-known/openid-configuration", status: http.StatusNotFound},
+		{name: "Prefix", method: "GET", path: "/.well-known/", status: http.StatusNotFound},
+		{name: "Method", method: "POST", path: "/.well-known/security.txt", status: http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.status {
+				t.Errorf("Expected status %d, got %d", tc.status, w.Code)
+			}
+			if tc.contentType != "" && w.Header().Get("Content-Type") != tc.contentType {
+				t.Errorf("Expected Content-Type %q, got %q", tc.contentType, w.Header().Get("Content-Type"))
+			}
+			if w.Header().Get("Location") != tc.location {
+				t.Errorf("Expected Location %q, got %q", tc.location, w.Header().Get("Location"))
+			}
+			if tc.body != "" && w.Body.String() != tc.body {
+				t.Errorf("Expected body %q, got %q", tc.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWellKnownHandlerFiles(t *testing.T) {
+	dir := t.TempDir()
+	signed := "-----BEGIN PGP SIGNED MESSAGE-----\nContact: mailto:psirt@example.com\n"
+	if err := os.WriteFile(filepath.Join(dir, "security.txt"), []byte(signed), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files take precedence over generated documents
+	securityTxt := &SecurityTxt{Contacts: []string{"mailto:security@example.com"}}
+	handler := NewWellKnownHandler(securityTxt, "", dir)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/.well-known/security.txt", nil))
+	if w.Code != http.StatusOK || w.Body.String() != signed {
+		t.Errorf("Expected the file, got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("ETag") == "" {
+		t.Error("Expected an ETag on files")
+	}
+
+	// Nothing is served without configuration
+	handler = NewWellKnownHandler(nil, "", "")
+	for _, path := range []string{"/.well-known/security.txt", "/.well-known/change-password"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+)
+
+// maxICOSize is the largest icon an ICO file can hold.
+const maxICOSize = 256
+
+// Icon crops an image to a centered square and scales it to size pixels on
+// each side.  Unlike Resize, small images are scaled up, since icons must
+// have their exact size.
+func Icon(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	square := image.Rect(x, y, x+side, y+side)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, square, draw.Src, nil)
+	return dst
+}
+
+// PNG encodes an icon as a PNG image.
+func PNG(img image.Image) (*Result, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("error encoding image: %w", err)
+	}
+	return &Result{ContentType: "image/png", Data: buf.Bytes()}, nil
+}
+
+// EncodeICO writes icons to w as an ICO file, with each icon stored as a
+// PNG image.  Icons must be square and no larger than 256 pixels.
+func EncodeICO(w io.Writer, icons []image.Image) error {
+	entries := make([][]byte, len(icons))
+	for i, icon := range icons {
+		size := icon.Bounds().Dx()
+		if size != icon.Bounds().Dy() || size < 1 || size > maxICOSize {
+			return fmt.Errorf("invalid icon size: %v", icon.Bounds().Size())
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, icon); err != nil {
+			return fmt.Errorf("error encoding icon: %w", err)
+		}
+		entries[i] = buf.Bytes()
+	}
+
+	// ICONDIR header, then an ICONDIRENTRY for each icon pointing at its
+	// data, which follows the entries
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, [3]uint16{0, 1, uint16(len(icons))})
+	offset := 6 + 16*len(icons)
+	for i, icon := range icons {
+		// Sizes of 256 are written as 0
+		size := uint8(icon.Bounds().Dx())
+		binary.Write(&buf, binary.LittleEndian, struct {
+			Width, Height, Colors, Reserved uint8
+			Planes, BitCount                uint16
+			Size, Offset                    uint32
+		}{size, size, 0, 0, 1, 32, uint32(len(entries[i])), uint32(offset)})
+		offset += len(entries[i])
+	}
+	for _, entry := range entries {
+		buf.Write(entry)
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,79 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestIcon(t *testing.T) {
+	// A wide image with a red center and blue sides
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	for x := 0; x < 300; x++ {
+		for y := 0; y < 100; y++ {
+			c := color.NRGBA{B: 255, A: 255}
+			if x >= 100 && x < 200 {
+				c = color.NRGBA{R: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+
+	for _, size := range []int{16, 180, 512} {
+		icon := Icon(src, size)
+		if icon.Bounds().Dx() != size || icon.Bounds().Dy() != size {
+			t.Errorf("Expected %dx%d, got %v", size, size, icon.Bounds().Size())
+		}
+		r, _, b, _ := icon.At(0, 0).RGBA()
+		if r == 0 || b != 0 {
+			t.Errorf("Expected the center of the image at size %d, got %v", size, icon.At(0, 0))
+		}
+	}
+}
+
+func TestEncodeICO(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	sizes := []int{16, 32, 256}
+	icons := make([]image.Image, len(sizes))
+	for i, size := range sizes {
+		icons[i] = Icon(src, size)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeICO(&buf, icons); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data := buf.Bytes()
+
+	var header [3]uint16
+	binary.Read(bytes.NewReader(data), binary.LittleEndian, &header)
+	if header != [3]uint16{0, 1, 3} {
+		t.Fatalf("Unexpected header %v", header)
+	}
+	for i, size := range sizes {
+		entry := data[6+16*i:]
+		width := int(entry[0])
+		if width == 0 {
+			width = 256
+		}
+		if width != size || entry[1] != entry[0] {
+			t.Errorf("Expected entry %d of size %d, got %dx%d", i, size, entry[0], entry[1])
+		}
+		length := binary.LittleEndian.Uint32(entry[8:])
+		offset := binary.LittleEndian.Uint32(entry[12:])
+		img, err := png.Decode(bytes.NewReader(data[offset : offset+length]))
+		if err != nil {
+			t.Fatalf("Expected entry %d to be a PNG, got %v", i, err)
+		}
+		if img.Bounds().Dx() != size {
+			t.Errorf("Expected entry %d to be %d wide, got %d", i, size, img.Bounds().Dx())
+		}
+	}
+
+	if err := EncodeICO(&buf, []image.Image{image.NewNRGBA(image.Rect(0, 0, 512, 512))}); err == nil {
+		t.Error("Expected an error for icons larger than 256")
+	}
+}
@@ -0,0 +1,112 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxSourceBytes is the largest source image that will be read.
+const MaxSourceBytes = 20 << 20
+
+// MaxSourcePixels is the largest source image, in pixels, that will be
+// decoded.  This guards against decompression bombs.
+const MaxSourcePixels = 40_000_000
+
+// JPEGQuality is the quality used when encoding resized JPEG images.
+const JPEGQuality = 80
+
+// ErrImageTooLarge is returned when a source image exceeds the size limits.
+var ErrImageTooLarge = errors.New("image too large")
+
+// Result holds an encoded, resized image.
+type Result struct {
+	ContentType string
+	Data        []byte
+}
+
+// Process decodes the source image, resizes it to the given width and
+// encodes the result.  Images are never upscaled.  Opaque images are
+// encoded as JPEG and images with transparency as PNG.
+func Process(r io.Reader, width int) (*Result, error) {
+	img, err := Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	img = Resize(img, width)
+
+	var buf bytes.Buffer
+	result := &Result{}
+	if isOpaque(img) {
+		result.ContentType = "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: JPEGQuality})
+	} else {
+		result.ContentType = "image/png"
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error encoding image: %w", err)
+	}
+	result.Data = buf.Bytes()
+
+	return result, nil
+}
+
+// Decode reads and decodes a source image, enforcing the size limits.
+func Decode(r io.Reader) (image.Image, error) {
+	src, err := io.ReadAll(io.LimitReader(r, MaxSourceBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(src) > MaxSourceBytes {
+		return nil, ErrImageTooLarge
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image config: %w", err)
+	}
+	if cfg.Width*cfg.Height > MaxSourcePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %w", err)
+	}
+	return img, nil
+}
+
+// Resize scales an image to the given width, preserving its aspect ratio.
+// Images that are already narrower than width are returned unchanged.
+func Resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if width <= 0 || bounds.Dx() <= width {
+		return img
+	}
+
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// isOpaque reports whether an image has no transparent pixels.
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
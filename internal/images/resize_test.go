@@ -0,0 +1,93 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// encodePNG creates a PNG image of the given size for testing.
+func encodePNG(t *testing.T, width int, height int, c color.Color) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Error encoding test image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	testCases := []struct {
+		name           string
+		source         []byte
+		width          int
+		expectedType   string
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{
+			name:           "Opaque image is resized to JPEG",
+			source:         encodePNG(t, 400, 200, color.NRGBA{R: 255, A: 255}),
+			width:          100,
+			expectedType:   "image/jpeg",
+			expectedWidth:  100,
+			expectedHeight: 50,
+		},
+		{
+			name:           "Transparent image is resized to PNG",
+			source:         encodePNG(t, 400, 200, color.NRGBA{R: 255, A: 128}),
+			width:          200,
+			expectedType:   "image/png",
+			expectedWidth:  200,
+			expectedHeight: 100,
+		},
+		{
+			name:           "Small image is not upscaled",
+			source:         encodePNG(t, 50, 50, color.NRGBA{B: 255, A: 255}),
+			width:          800,
+			expectedType:   "image/jpeg",
+			expectedWidth:  50,
+			expectedHeight: 50,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Process(bytes.NewReader(tc.source), tc.width)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result.ContentType != tc.expectedType {
+				t.Errorf("Expected content type %q, got %q", tc.expectedType, result.ContentType)
+			}
+
+			var cfg image.Config
+			if tc.expectedType == "image/jpeg" {
+				cfg, err = jpeg.DecodeConfig(bytes.NewReader(result.Data))
+			} else {
+				cfg, err = png.DecodeConfig(bytes.NewReader(result.Data))
+			}
+			if err != nil {
+				t.Fatalf("Error decoding result: %v", err)
+			}
+			if cfg.Width != tc.expectedWidth || cfg.Height != tc.expectedHeight {
+				t.Errorf("Expected %dx%d, got %dx%d", tc.expectedWidth, tc.expectedHeight, cfg.Width, cfg.Height)
+			}
+		})
+	}
+}
+
+func TestProcessInvalidImage(t *testing.T) {
+	if _, err := Process(strings.NewReader("not an image"), 100); err == nil {
+		t.Error("Expected error for invalid image, got nil")
+	}
+}
@@ -0,0 +1,113 @@
+package images
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"wordpress-go-proxy/internal/transform"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Endpoint is the path of the image resize endpoint.
+const Endpoint = "/img"
+
+// uploadPrefixes are the paths of images that can be resized.
+var uploadPrefixes = []string{"/wp-content/uploads/", "/media/"}
+
+// resizableExtensions are the image types the resize endpoint can decode.
+// Vector and animated images are left untouched.
+var resizableExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+}
+
+// IsResizable reports whether src is a WordPress upload that the resize
+// endpoint can serve.
+func IsResizable(src string) bool {
+	if strings.Contains(src, "?") || strings.Contains(src, "#") {
+		return false
+	}
+	for _, prefix := range uploadPrefixes {
+		if strings.HasPrefix(src, prefix) {
+			return resizableExtensions[strings.ToLower(path.Ext(src))]
+		}
+	}
+	return false
+}
+
+// URL returns the resize endpoint URL for an image at the given width.
+func URL(src string, width int) string {
+	return fmt.Sprintf("%s?src=%s&w=%d", Endpoint, url.QueryEscape(src), width)
+}
+
+// Rewriter points the <img> tags of content that reference WordPress
+// uploads at the resize endpoint, so they load resized copies with a
+// responsive srcset.  Only widths up to the image's width attribute are
+// offered, and images narrower than every width use the smallest one since
+// the resize endpoint never upscales.  It runs last in the content
+// pipeline, once links to the WordPress site are relative.
+type Rewriter struct {
+	Widths []int
+}
+
+// Transform implements transform.Transformer.
+func (r Rewriter) Transform(doc *html.Node, page transform.Page) {
+	if len(r.Widths) == 0 {
+		return
+	}
+	sorted := slices.Clone(r.Widths)
+	slices.Sort(sorted)
+	for _, img := range transform.Elements(doc, atom.Img) {
+		rewriteImage(img, sorted)
+	}
+}
+
+// rewriteImage points an <img> element at the resize endpoint.
+func rewriteImage(n *html.Node, widths []int) {
+	src := transform.Attr(n, "src")
+	if !IsResizable(src) {
+		return
+	}
+
+	// Don't offer widths larger than the image is displayed at.  Widths
+	// that aren't a whole number of pixels, like "100%", are ignored.
+	width, err := strconv.Atoi(transform.Attr(n, "width"))
+	if err != nil || width <= 0 {
+		width = 0
+	}
+	candidates := widths
+	if width > 0 {
+		candidates = nil
+		for _, w := range widths {
+			if w <= width {
+				candidates = append(candidates, w)
+			}
+		}
+		if len(candidates) == 0 {
+			candidates = widths[:1]
+		}
+	}
+
+	srcset := make([]string, len(candidates))
+	for i, w := range candidates {
+		srcset[i] = fmt.Sprintf("%s %dw", URL(src, w), w)
+	}
+
+	transform.SetAttr(n, "src", URL(src, candidates[len(candidates)-1]))
+	transform.SetAttr(n, "srcset", strings.Join(srcset, ", "))
+	if transform.Attr(n, "sizes") == "" {
+		sizes := "100vw"
+		if width > 0 {
+			sizes = fmt.Sprintf("(max-width: %dpx) 100vw, %dpx", width, width)
+		}
+		transform.SetAttr(n, "sizes", sizes)
+	}
+}
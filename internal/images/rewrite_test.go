@@ -0,0 +1,76 @@
+package images
+
+import (
+	"testing"
+
+	"wordpress-go-proxy/internal/transform"
+)
+
+func TestRewriter(t *testing.T) {
+	widths := []int{800, 400}
+
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Upload image gets srcset",
+			content:  `<p><img src="/wp-content/uploads/2024/05/photo.jpg" alt="Photo"></p>`,
+			expected: `<p><img src="/img?src=%2Fwp-content%2Fuploads%2F2024%2F05%2Fphoto.jpg&amp;w=800" alt="Photo" srcset="/img?src=%2Fwp-content%2Fuploads%2F2024%2F05%2Fphoto.jpg&amp;w=400 400w, /img?src=%2Fwp-content%2Fuploads%2F2024%2F05%2Fphoto.jpg&amp;w=800 800w" sizes="100vw"/></p>`,
+		},
+		{
+			name:     "Widths are limited by the width attribute",
+			content:  `<img src="/media/logo.png" width="500" srcset="/media/logo-300x300.png 300w">`,
+			expected: `<img src="/img?src=%2Fmedia%2Flogo.png&amp;w=400" width="500" srcset="/img?src=%2Fmedia%2Flogo.png&amp;w=400 400w" sizes="(max-width: 500px) 100vw, 500px"/>`,
+		},
+		{
+			name:     "Images narrower than every width use the smallest width",
+			content:  `<img src="/media/icon.png" width="64" sizes="64px">`,
+			expected: `<img src="/img?src=%2Fmedia%2Ficon.png&amp;w=400" width="64" sizes="64px" srcset="/img?src=%2Fmedia%2Ficon.png&amp;w=400 400w"/>`,
+		},
+		{
+			name:     "Invalid width attributes are ignored",
+			content:  `<img src="/media/logo.png" width="100px), 1px">`,
+			expected: `<img src="/img?src=%2Fmedia%2Flogo.png&amp;w=800" width="100px), 1px" srcset="/img?src=%2Fmedia%2Flogo.png&amp;w=400 400w, /img?src=%2Fmedia%2Flogo.png&amp;w=800 800w" sizes="100vw"/>`,
+		},
+		{
+			name:     "SVG images are untouched",
+			content:  `<img src="/wp-content/uploads/logo.svg">`,
+			expected: `<img src="/wp-content/uploads/logo.svg"/>`,
+		},
+		{
+			name:     "External images are untouched",
+			content:  `<img src="https://cdn.example.com/photo.jpg">`,
+			expected: `<img src="https://cdn.example.com/photo.jpg"/>`,
+		},
+		{
+			name:     "Content without images is returned as is",
+			content:  `<p>No images here</p>`,
+			expected: `<p>No images here</p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := transform.Pipeline{Rewriter{Widths: widths}}.Apply(tc.content, transform.Page{})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRewriterDisabled(t *testing.T) {
+	content := `<img src="/wp-content/uploads/photo.jpg"/>`
+	got, err := transform.Pipeline{Rewriter{}}.Apply(content, transform.Page{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got != content {
+		t.Errorf("Expected content to be unchanged, got %s", got)
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"wordpress-go-proxy/internal/access"
+	"wordpress-go-proxy/internal/filter"
+)
+
+// Access returns a middleware that restricts the sections of the site
+// matched by rules.  A visitor whose address, behind trustedProxies
+// proxies, is in a rule's CIDRs is let in; otherwise rules allowing signed
+// in visitors challenge with authenticate, and other rules respond with a
+// 403.  Restricted responses are marked private and noindex, so that
+// shared caches and search engines do not pass them on.
+func Access(rules []access.Rule, authenticate Authenticator, trustedProxies int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rule, ok := access.Match(rules, r.URL.Path)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+			w = &privateWriter{ResponseWriter: w}
+			w.Header().Set("Cache-Control", "private, no-store")
+			if addr, ok := filter.ClientIP(r, trustedProxies); !ok || !rule.AllowsAddr(addr) {
+				if !rule.Auth || authenticate == nil {
+					log.Printf("Refused access to %s from %v", r.URL.Path, addr)
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+				if !authenticate(w, r) {
+					return
+				}
+				r = withAuthenticated(r)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// privateWriter keeps a response private, even when the handler sets its
+// own caching headers.
+type privateWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (w *privateWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (w *privateWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *privateWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
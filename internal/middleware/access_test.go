@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wordpress-go-proxy/internal/access"
+	"wordpress-go-proxy/internal/auth"
+)
+
+func TestAccess(t *testing.T) {
+	rules, err := access.ParseRules([]string{"/intranet/=auth|10.0.0.0/8", "/staff/=192.168.0.0/16"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	users, err := auth.ParseHtpasswd("editor:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	handler := Access(rules, BasicAuthenticator(users, "Intranet"), 1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Write([]byte("OK"))
+	}))
+
+	tests := []struct {
+		name     string
+		path     string
+		client   string
+		user     string
+		expected int
+	}{
+		{"public page", "/about/", "203.0.113.7", "", http.StatusOK},
+		{"anonymous", "/intranet/news/", "203.0.113.7", "", http.StatusUnauthorized},
+		{"signed in", "/intranet/news/", "203.0.113.7", "editor", http.StatusOK},
+		{"allowlisted network", "/intranet/news/", "10.1.2.3", "", http.StatusOK},
+		{"network only section", "/staff/", "203.0.113.7", "editor", http.StatusForbidden},
+		{"network only section from network", "/staff/", "192.168.4.5", "", http.StatusOK},
+		{"unknown client", "/staff/", "", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.client != "" {
+				req.Header.Set("X-Forwarded-For", tt.client)
+			}
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, "secret")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, rec.Code)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected a Basic challenge")
+			}
+			restricted := tt.path != "/about/"
+			if restricted && rec.Header().Get("Cache-Control") != "private, no-store" {
+				t.Errorf("Expected a private response, got %q", rec.Header().Get("Cache-Control"))
+			}
+			if !restricted && rec.Header().Get("Cache-Control") != "public, max-age=300" {
+				t.Errorf("Expected the handler's caching, got %q", rec.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestAccessWithoutAuthenticator(t *testing.T) {
+	rules, err := access.ParseRules([]string{"/intranet/=auth"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	handler := Access(rules, nil, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/intranet/", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, rec.Code)
+	}
+}
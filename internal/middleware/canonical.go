@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Trailing slash policies for canonical paths.
+const (
+	TrailingSlashIgnore = "ignore"
+	TrailingSlashStrip  = "strip"
+	TrailingSlashAdd    = "add"
+)
+
+// CanonicalPaths configures how request paths are canonicalized.
+type CanonicalPaths struct {
+	// TrailingSlash is one of TrailingSlashIgnore, TrailingSlashStrip or
+	// TrailingSlashAdd.
+	TrailingSlash string
+
+	// Lowercase redirects paths with upper case letters.
+	Lowercase bool
+
+	// Roots are paths whose trailing slash is left as requested, such as
+	// the home page of each language.
+	Roots []string
+}
+
+// Canonicalize returns a middleware that permanently redirects GET and HEAD
+// requests for non-canonical paths so each page has a single URL.  The
+// query string is kept.
+func Canonicalize(policy CanonicalPaths) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			canonical := policy.canonical(r.URL.Path)
+			if canonical == r.URL.Path {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			target := url.URL{Path: canonical, RawQuery: r.URL.RawQuery}
+			http.Redirect(w, r, target.String(), http.StatusMovedPermanently)
+		})
+	}
+}
+
+// canonical returns the canonical form of a path.
+func (p CanonicalPaths) canonical(requestPath string) string {
+	canonical := requestPath
+	if p.Lowercase {
+		canonical = strings.ToLower(canonical)
+	}
+	if canonical == "/" || p.isRoot(canonical) {
+		return canonical
+	}
+
+	switch p.TrailingSlash {
+	case TrailingSlashStrip:
+		canonical = strings.TrimRight(canonical, "/")
+		if canonical == "" {
+			canonical = "/"
+		}
+	case TrailingSlashAdd:
+		if !strings.HasSuffix(canonical, "/") && path.Ext(canonical) == "" {
+			canonical += "/"
+		}
+	}
+	return canonical
+}
+
+// isRoot reports whether a path is a root, with or without its slash.
+func (p CanonicalPaths) isRoot(requestPath string) bool {
+	for _, root := range p.Roots {
+		if strings.TrimSuffix(root, "/") == strings.TrimSuffix(requestPath, "/") {
+			return true
+		}
+	}
+	return false
+}
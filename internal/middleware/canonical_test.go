@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	})
+
+	testCases := []struct {
+		name             string
+		policy           CanonicalPaths
+		method           string
+		url              string
+		expectedLocation string
+	}{
+		{"Strip and lowercase", CanonicalPaths{TrailingSlash: TrailingSlashStrip, Lowercase: true}, "GET", "/About-Us/", "/about-us"},
+		{"Query string kept", CanonicalPaths{TrailingSlash: TrailingSlashStrip}, "GET", "/about/?lang=fr", "/about?lang=fr"},
+		{"Canonical path served", CanonicalPaths{TrailingSlash: TrailingSlashStrip, Lowercase: true}, "GET", "/about-us", ""},
+		{"Site root served", CanonicalPaths{TrailingSlash: TrailingSlashStrip}, "GET", "/", ""},
+		{"Language root kept with slash", CanonicalPaths{TrailingSlash: TrailingSlashStrip, Roots: []string{"/fr/"}}, "GET", "/fr/", ""},
+		{"Language root kept without slash", CanonicalPaths{TrailingSlash: TrailingSlashAdd, Roots: []string{"/fr/"}}, "GET", "/fr", ""},
+		{"Add slash", CanonicalPaths{TrailingSlash: TrailingSlashAdd}, "GET", "/about", "/about/"},
+		{"Add slash skips files", CanonicalPaths{TrailingSlash: TrailingSlashAdd}, "GET", "/feed.xml", ""},
+		{"Ignore slash", CanonicalPaths{TrailingSlash: TrailingSlashIgnore}, "GET", "/about/", ""},
+		{"Lowercase accented path", CanonicalPaths{Lowercase: true}, "GET", "/fr/%C3%89t%C3%A9", "/fr/%C3%A9t%C3%A9"},
+		{"Other methods served", CanonicalPaths{Lowercase: true}, "POST", "/About", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Canonicalize(tc.policy)(next).ServeHTTP(w, httptest.NewRequest(tc.method, tc.url, nil))
+
+			if tc.expectedLocation == "" {
+				if w.Code != http.StatusOK || w.Body.String() != "page" {
+					t.Errorf("Expected the next handler to respond, got %d", w.Code)
+				}
+				return
+			}
+			if w.Code != http.StatusMovedPermanently {
+				t.Errorf("Expected status %d, got %d", http.StatusMovedPermanently, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Errorf("Expected Location %q, got %q", tc.expectedLocation, location)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// colorSchemeHint is the client hint browsers send the color scheme their
+// visitor prefers in, once asked for it with Accept-CH.
+const colorSchemeHint = "Sec-CH-Prefers-Color-Scheme"
+
+type colorSchemeKey struct{}
+
+// colorScheme is the color scheme of a request.
+type colorScheme struct {
+	name   string
+	chosen bool
+}
+
+// PreferredColorScheme returns the color scheme of a request, one of
+// models.ColorSchemes, and whether the visitor chose it rather than their
+// browser.  It is empty if neither is known, or if the request was not
+// handled by the color scheme middleware.
+func PreferredColorScheme(ctx context.Context) (string, bool) {
+	scheme, _ := ctx.Value(colorSchemeKey{}).(colorScheme)
+	return scheme.name, scheme.chosen
+}
+
+// ColorScheme returns a middleware that finds the color scheme visitors
+// chose with the named cookie, or otherwise the light or dark scheme their
+// browser prefers from its Sec-CH-Prefers-Color-Scheme client hint, so
+// that pages are rendered in it without a flash of the wrong scheme.
+// Browsers are asked to send the hint with Accept-CH, and only do so from
+// their next request, and responses vary on both.
+func ColorScheme(cookie string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Accept-CH", colorSchemeHint)
+			AddVary(w.Header(), "Cookie", colorSchemeHint)
+
+			var scheme colorScheme
+			if c, err := r.Cookie(cookie); err == nil && slices.Contains(models.ColorSchemes, c.Value) {
+				scheme = colorScheme{name: c.Value, chosen: true}
+			} else {
+				switch hint := strings.Trim(r.Header.Get(colorSchemeHint), `" `); hint {
+				case models.ColorSchemeLight, models.ColorSchemeDark:
+					scheme.name = hint
+				}
+			}
+			ctx := context.WithValue(r.Context(), colorSchemeKey{}, scheme)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestColorScheme(t *testing.T) {
+	var scheme string
+	var chosen bool
+	handler := ColorScheme("color_scheme")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, chosen = PreferredColorScheme(r.Context())
+	}))
+
+	tests := []struct {
+		cookie         string
+		hint           string
+		expected       string
+		expectedChosen bool
+	}{
+		{"", "", "", false},
+		{"color_scheme=dark", "", "dark", true},
+		{"color_scheme=high-contrast", `"dark"`, "high-contrast", true},
+		{"color_scheme=light", `"dark"`, "light", true},
+		{"", `"dark"`, "dark", false},
+		{"", "light", "light", false},
+		{"", `"high-contrast"`, "", false},
+		{"color_scheme=sepia", `"dark"`, "dark", false},
+		{"other=dark", "", "", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tt.cookie != "" {
+			req.Header.Set("Cookie", tt.cookie)
+		}
+		if tt.hint != "" {
+			req.Header.Set("Sec-CH-Prefers-Color-Scheme", tt.hint)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if scheme != tt.expected || chosen != tt.expectedChosen {
+			t.Errorf("Expected %q (chosen %v) for cookie %q and hint %q, got %q (%v)", tt.expected, tt.expectedChosen, tt.cookie, tt.hint, scheme, chosen)
+		}
+		if vary := rec.Header().Values("Vary"); len(vary) != 2 || vary[0] != "Cookie" || vary[1] != "Sec-CH-Prefers-Color-Scheme" {
+			t.Errorf("Expected to vary on the cookie and hint, got %q", vary)
+		}
+		if rec.Header().Get("Accept-CH") != "Sec-CH-Prefers-Color-Scheme" {
+			t.Errorf("Expected the hint to be asked for, got %q", rec.Header().Get("Accept-CH"))
+		}
+	}
+
+	if scheme, chosen := PreferredColorScheme(httptest.NewRequest("GET", "/", nil).Context()); scheme != "" || chosen {
+		t.Errorf("Expected no color scheme without the middleware, got %q", scheme)
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// consentValues are the values of a consent cookie that grant consent.
+var consentValues = []string{"1", "true", "yes", "granted"}
+
+type consentKey struct{}
+
+// Consented reports whether the visitor consented to analytics, which is
+// false if the request was not handled by the consent middleware.
+func Consented(ctx context.Context) bool {
+	consented, _ := ctx.Value(consentKey{}).(bool)
+	return consented
+}
+
+// Consent returns a middleware that checks whether visitors consented to
+// analytics with the named cookie, set by the site's consent banner to 1,
+// true, yes or granted.  Responses vary on cookies, since pages only load
+// analytics for visitors who consented.
+func Consent(cookie string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			AddVary(w.Header(), "Cookie")
+
+			consented := false
+			if c, err := r.Cookie(cookie); err == nil {
+				for _, value := range consentValues {
+					if strings.EqualFold(c.Value, value) {
+						consented = true
+					}
+				}
+			}
+			ctx := context.WithValue(r.Context(), consentKey{}, consented)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConsent(t *testing.T) {
+	var consented bool
+	handler := Consent("analytics_consent")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		consented = Consented(r.Context())
+	}))
+
+	tests := []struct {
+		cookie   string
+		expected bool
+	}{
+		{"", false},
+		{"analytics_consent=true", true},
+		{"analytics_consent=Granted", true},
+		{"analytics_consent=0", false},
+		{"analytics_consent=denied", false},
+		{"other=true", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tt.cookie != "" {
+			req.Header.Set("Cookie", tt.cookie)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if consented != tt.expected {
+			t.Errorf("Expected consent %v for cookie %q, got %v", tt.expected, tt.cookie, consented)
+		}
+		if rec.Header().Get("Vary") != "Cookie" {
+			t.Errorf("Expected Vary: Cookie, got %q", rec.Header().Get("Vary"))
+		}
+	}
+
+	if Consented(httptest.NewRequest("GET", "/", nil).Context()) {
+		t.Error("Expected no consent without the middleware")
+	}
+}
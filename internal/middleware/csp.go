@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// NoncePlaceholder is replaced with the request's nonce in a CSP policy.
+const NoncePlaceholder = "{nonce}"
+
+// DefaultCSP is a strict policy that only allows scripts from the site and
+// the GC Design System CDN, and inline scripts carrying the request nonce.
+const DefaultCSP = "default-src 'self'; " +
+	"script-src 'self' 'nonce-{nonce}' 'strict-dynamic' https://cdn.design-system.alpha.canada.ca; " +
+	"style-src 'self' 'unsafe-inline' https://cdn.design-system.alpha.canada.ca https://fonts.googleapis.com; " +
+	"font-src 'self' https://cdn.design-system.alpha.canada.ca https://fonts.gstatic.com; " +
+	"img-src 'self' data: https://design-system.alpha.canada.ca; " +
+	"frame-src https:; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'self'"
+
+// reportToGroup is the reporting endpoint name used by report-to.
+const reportToGroup = "csp-endpoint"
+
+// CSP configures the Content-Security-Policy header.
+type CSP struct {
+	// Policy is the policy to send.  NoncePlaceholder is replaced with a
+	// per-request nonce.  No header is sent if empty.
+	Policy string
+
+	// ReportOnly sends the policy as Content-Security-Policy-Report-Only
+	// so violations are reported without being blocked.
+	ReportOnly bool
+
+	// ReportURI and ReportTo are the violation report endpoints for the
+	// report-uri and report-to directives.
+	ReportURI string
+	ReportTo  string
+}
+
+type nonceKey struct{}
+
+// Nonce returns the CSP nonce of the request, or an empty string if the
+// request was not handled by the security headers middleware.
+func Nonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(nonceKey{}).(string)
+	return nonce
+}
+
+// header returns the CSP header name and value for a nonce.
+func (c CSP) header(nonce string) (string, string) {
+	policy := strings.ReplaceAll(c.Policy, NoncePlaceholder, nonce)
+	if c.ReportURI != "" {
+		policy += "; report-uri " + c.ReportURI
+	}
+	if c.ReportTo != "" {
+		policy += "; report-to " + reportToGroup
+	}
+
+	name := "Content-Security-Policy"
+	if c.ReportOnly {
+		name = "Content-Security-Policy-Report-Only"
+	}
+	return name, policy
+}
+
+// newNonce returns a random URL-safe base64 nonce, which needs no escaping
+// in HTML attributes.
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// withCSP sets the CSP headers and adds a nonce to the request context.
+func withCSP(csp CSP, w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if csp.Policy == "" {
+		return r, true
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		log.Printf("Error generating CSP nonce: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return r, false
+	}
+
+	name, value := csp.header(nonce)
+	w.Header().Set(name, value)
+	if csp.ReportTo != "" {
+		w.Header().Set("Reporting-Endpoints", reportToGroup+`="`+csp.ReportTo+`"`)
+	}
+	return r.WithContext(context.WithValue(r.Context(), nonceKey{}, nonce)), true
+}
+
+// AddSources returns a policy with sources added to a directive, skipping
+// those it already lists.  A directive the policy lacks is added with the
+// sources of default-src, which it would otherwise fall back to.  An empty
+// policy, which sends no header, is left empty.
+func AddSources(policy string, directive string, sources ...string) string {
+	policy = strings.TrimRight(strings.TrimSpace(policy), "; ")
+	if policy == "" {
+		return ""
+	}
+	directives := strings.Split(policy, ";")
+	index, fallback := -1, []string(nil)
+	for i, d := range directives {
+		fields := strings.Fields(d)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToLower(fields[0]) {
+		case directive:
+			index = i
+		case "default-src":
+			fallback = fields[1:]
+		}
+	}
+	if index < 0 {
+		directives = append(directives, " "+strings.Join(append([]string{directive}, fallback...), " "))
+		index = len(directives) - 1
+	}
+
+	fields := strings.Fields(directives[index])
+	for _, source := range sources {
+		if !containsFold(fields[1:], source) {
+			fields = append(fields, source)
+		}
+	}
+	directives[index] = " " + strings.Join(fields, " ")
+	directives[0] = strings.TrimPrefix(directives[0], " ")
+	return strings.Join(directives, ";")
+}
+
+// containsFold reports whether a list holds a value, ignoring case.
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewSecurityHeadersCSP(t *testing.T) {
+	testCases := []struct {
+		name                string
+		csp                 CSP
+		expectedHeader      string
+		expectedContains    []string
+		expectNonceInPolicy bool
+		expectedEndpoint    string
+	}{
+		{
+			name:                "Enforced policy with nonce",
+			csp:                 CSP{Policy: "script-src 'nonce-{nonce}'"},
+			expectedHeader:      "Content-Security-Policy",
+			expectedContains:    []string{"script-src 'nonce-"},
+			expectNonceInPolicy: true,
+		},
+		{
+			name:             "Report only with report endpoints",
+			csp:              CSP{Policy: "default-src 'self'", ReportOnly: true, ReportURI: "https://reports.example.com/csp", ReportTo: "https://reports.example.com/v2"},
+			expectedHeader:   "Content-Security-Policy-Report-Only",
+			expectedContains: []string{"default-src 'self'; report-uri https://reports.example.com/csp; report-to csp-endpoint"},
+			expectedEndpoint: `csp-endpoint="https://reports.example.com/v2"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var nonce string
+			handler := NewSecurityHeaders(tc.csp)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nonce = Nonce(r.Context())
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+			policy := w.Header().Get(tc.expectedHeader)
+			for _, expected := range tc.expectedContains {
+				if !strings.Contains(policy, expected) {
+					t.Errorf("Expected %s to contain %q, got %q", tc.expectedHeader, expected, policy)
+				}
+			}
+			if nonce == "" {
+				t.Fatal("Expected a nonce in the request context")
+			}
+			if tc.expectNonceInPolicy && !strings.Contains(policy, "'nonce-"+nonce+"'") {
+				t.Errorf("Expected policy to contain the request nonce %q, got %q", nonce, policy)
+			}
+			if endpoint := w.Header().Get("Reporting-Endpoints"); endpoint != tc.expectedEndpoint {
+				t.Errorf("Expected Reporting-Endpoints %q, got %q", tc.expectedEndpoint, endpoint)
+			}
+			if w.Header().Get("X-Frame-Options") != "SAMEORIGIN" {
+				t.Error("Expected the standard security headers to be set")
+			}
+		})
+	}
+}
+
+func TestNewSecurityHeadersNonceUnique(t *testing.T) {
+	var nonces []string
+	handler := NewSecurityHeaders(CSP{Policy: DefaultCSP})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonces = append(nonces, Nonce(r.Context()))
+	}))
+
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+	if nonces[0] == nonces[1] {
+		t.Errorf("Expected a new nonce per request, got %q twice", nonces[0])
+	}
+}
+
+func TestSecurityHeadersWithoutCSP(t *testing.T) {
+	var nonce string
+	handler := SecurityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce = Nonce(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if csp := w.Header().Get("Content-Security-Policy"); csp != "" || nonce != "" {
+		t.Errorf("Expected no CSP or nonce, got %q and %q", csp, nonce)
+	}
+}
+
+func TestAddSources(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		expected string
+	}{
+		{"existing directive", "default-src 'self'; img-src 'self' data:", "default-src 'self'; img-src 'self' data: https://a.example.com"},
+		{"listed source", "img-src https://A.example.com;", "img-src https://A.example.com"},
+		{"missing directive", "default-src 'self' https://cdn.example.com", "default-src 'self' https://cdn.example.com; img-src 'self' https://cdn.example.com https://a.example.com"},
+		{"empty policy", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddSources(tt.policy, "img-src", "https://a.example.com"); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
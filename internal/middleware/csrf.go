@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// CSRFField is the form field, and CSRFHeader the header, that carry the
+// CSRF token of state-changing requests.
+const (
+	CSRFField  = "_csrf"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// csrfCookie is the cookie holding the visitor's CSRF secret.
+const csrfCookie = "csrf"
+
+// csrfBytes is the length of the random secret in the CSRF cookie.
+const csrfBytes = 32
+
+// maxCSRFFormBytes limits the size of a form body read to find its token.
+const maxCSRFFormBytes = 1 << 20
+
+type csrfKey struct{}
+
+// CSRFToken returns the CSRF token to embed in forms rendered for the
+// request, or an empty string if the request was not handled by the CSRF
+// middleware.
+func CSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(csrfKey{}).(string)
+	return token
+}
+
+// CSRF returns a middleware protecting state-changing requests with signed
+// double-submit cookies.  Each visitor gets a random secret in a cookie,
+// and forms rendered for them embed a token derived from it, see
+// CSRFToken.  POST, PUT, PATCH and DELETE requests are refused unless
+// they carry the token matching their cookie in the CSRFField form field
+// or CSRFHeader header, which other sites cannot read or forge.  Tokens
+// are the HMAC of the cookie with secret, so that a cookie planted by a
+// sibling domain is useless without the matching token; if secret is
+// empty the token is the cookie itself.
+func CSRF(secret []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cookie := csrfSecret(r)
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				// Responses embed a token derived from the cookie
+				AddVary(w.Header(), "Cookie")
+				if cookie == "" {
+					var err error
+					if cookie, err = newCSRFSecret(); err != nil {
+						log.Printf("Error generating CSRF secret: %v", err)
+						http.Error(w, "Internal server error", http.StatusInternalServerError)
+						return
+					}
+					http.SetCookie(w, &http.Cookie{
+						Name:     csrfCookie,
+						Value:    cookie,
+						Path:     "/",
+						HttpOnly: true,
+						Secure:   secureRequest(r),
+						SameSite: http.SameSiteLaxMode,
+					})
+				}
+			default:
+				if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+					log.Printf("Refusing cross-site %s request: %s", r.Method, r.URL.Path)
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+				token := r.Header.Get(CSRFHeader)
+				if token == "" {
+					r.Body = http.MaxBytesReader(w, r.Body, maxCSRFFormBytes)
+					var maxErr *http.MaxBytesError
+					if err := r.ParseForm(); errors.As(err, &maxErr) {
+						log.Printf("Form too large for %s request: %s", r.Method, r.URL.Path)
+						http.Error(w, "Request entity too large", http.StatusRequestEntityTooLarge)
+						return
+					}
+					token = r.PostFormValue(CSRFField)
+				}
+				if cookie == "" || subtle.ConstantTimeCompare([]byte(token), []byte(csrfToken(secret, cookie))) != 1 {
+					log.Printf("Invalid CSRF token for %s request: %s", r.Method, r.URL.Path)
+					http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+					return
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), csrfKey{}, csrfToken(secret, cookie))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// csrfSecret returns the visitor's CSRF secret from its cookie, or an
+// empty string if the cookie is missing or malformed.
+func csrfSecret(r *http.Request) string {
+	cookie, err := r.Cookie(csrfCookie)
+	if err != nil {
+		return ""
+	}
+	if b, err := base64.RawURLEncoding.DecodeString(cookie.Value); err != nil || len(b) != csrfBytes {
+		return ""
+	}
+	return cookie.Value
+}
+
+// newCSRFSecret returns a random URL-safe base64 CSRF secret.
+func newCSRFSecret() (string, error) {
+	b := make([]byte, csrfBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// csrfToken returns the token embedded in forms for a CSRF secret.
+func csrfToken(secret []byte, cookie string) string {
+	if len(secret) == 0 {
+		return cookie
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("csrf:" + cookie))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// secureRequest reports whether a request was made over HTTPS, directly
+// or through a proxy that terminated TLS.
+func secureRequest(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// csrfHandler returns a handler protected by the CSRF middleware that
+// writes the request's token
+func csrfHandler(secret string) http.Handler {
+	return CSRF([]byte(secret))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(CSRFToken(r.Context())))
+	}))
+}
+
+func TestCSRF(t *testing.T) {
+	handler := csrfHandler("test-secret")
+
+	// Visitors without a cookie are given one along with a token
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/events/launch", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	handler.ServeHTTP(w, req)
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != csrfCookie || !cookies[0].HttpOnly || !cookies[0].Secure || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("Expected a secure CSRF cookie, got %+v", cookies)
+	}
+	cookie := cookies[0]
+	token := w.Body.String()
+	if token == "" || token == cookie.Value {
+		t.Fatalf("Expected a token derived from the cookie, got %q", token)
+	}
+
+	// Visitors with a cookie keep it
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/events/launch", nil)
+	req.AddCookie(cookie)
+	handler.ServeHTTP(w, req)
+	if len(w.Result().Cookies()) != 0 || w.Body.String() != token {
+		t.Errorf("Expected the same token without a new cookie, got %q, %v", w.Body.String(), w.Result().Cookies())
+	}
+
+	testCases := []struct {
+		name    string
+		cookie  bool
+		field   string
+		header  string
+		site    string
+		allowed bool
+	}{
+		{name: "Form field", cookie: true, field: token, allowed: true},
+		{name: "Header", cookie: true, header: token, allowed: true},
+		{name: "Missing token", cookie: true},
+		{name: "Wrong token", cookie: true, field: token[1:]},
+		{name: "Cookie as token", cookie: true, field: cookie.Value},
+		{name: "Missing cookie", field: token},
+		{name: "Cross-site", cookie: true, field: token, site: "cross-site"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			form := url.Values{"content": {"Thanks"}}
+			if tc.field != "" {
+				form.Set(CSRFField, tc.field)
+			}
+			req := httptest.NewRequest("POST", "/comments", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.cookie {
+				req.AddCookie(cookie)
+			}
+			if tc.header != "" {
+				req.Header.Set(CSRFHeader, tc.header)
+			}
+			if tc.site != "" {
+				req.Header.Set("Sec-Fetch-Site", tc.site)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if allowed := w.Code == http.StatusOK; allowed != tc.allowed {
+				t.Errorf("Expected allowed %v, got status %d", tc.allowed, w.Code)
+			}
+			if tc.allowed && req.PostFormValue("content") != "Thanks" {
+				t.Errorf("Expected the form to still be readable")
+			}
+		})
+	}
+}
+
+// TestCSRFWithoutSecret tests that the cookie is the token when no secret
+// is configured
+func TestCSRFWithoutSecret(t *testing.T) {
+	handler := csrfHandler("")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/forms/contact", nil))
+	cookie := w.Result().Cookies()[0]
+	if w.Body.String() != cookie.Value || cookie.Secure {
+		t.Errorf("Expected the cookie as token over HTTP, got %q for %+v", w.Body.String(), cookie)
+	}
+
+	form := url.Values{CSRFField: {cookie.Value}}
+	req := httptest.NewRequest("POST", "/forms/contact", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected token to be accepted, got %d", w.Code)
+	}
+}
+
+// TestCSRFBodyLimit tests that forms over the limit of their route are
+// refused before their token is checked
+func TestCSRFBodyLimit(t *testing.T) {
+	handler := LimitBody(1 << 10)(csrfHandler(""))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/comments", nil))
+	cookie := w.Result().Cookies()[0]
+
+	testCases := []struct {
+		name     string
+		content  string
+		expected int
+	}{
+		{name: "Within the limit", content: "Thanks", expected: http.StatusOK},
+		{name: "Over the limit", content: strings.Repeat("a", 1<<10), expected: http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			form := url.Values{CSRFField: {cookie.Value}, "content": {tc.content}}
+			req := httptest.NewRequest("POST", "/comments", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.AddCookie(cookie)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expected {
+				t.Errorf("Expected status %d, got %d", tc.expected, w.Code)
+			}
+		})
+	}
+}
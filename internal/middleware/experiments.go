@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ExperimentsHeader carries variants assigned upstream, such as by a CDN
+// function, as comma-separated experiment=variant pairs.  Variants in the
+// header take precedence over the visitor's cookie and are not stored.
+const ExperimentsHeader = "X-Experiments"
+
+// experimentsCookie is the cookie holding the visitor's variants, encoded
+// as a query string.
+const experimentsCookie = "experiments"
+
+// experimentsMaxAge is how long visitors keep their variants.
+const experimentsMaxAge = 90 * 24 * time.Hour
+
+// Experiment is a rendering experiment whose visitors are split evenly
+// between its variants.
+type Experiment struct {
+	Name     string
+	Variants []string
+}
+
+type experimentsKey struct{}
+
+// Variants returns the variant of each experiment assigned to the request,
+// keyed by experiment name, or nil if the request was not handled by the
+// experiments middleware.
+func Variants(ctx context.Context) map[string]string {
+	variants, _ := ctx.Value(experimentsKey{}).(map[string]string)
+	return variants
+}
+
+// Experiments returns a middleware that assigns visitors a variant of each
+// experiment, kept in a cookie so they see the same variant on every page.
+// Visitors without a valid variant are assigned one at random, which is
+// logged for analysis, and variants may be assigned upstream in the
+// ExperimentsHeader.  Responses vary on both, since they may render
+// differently for each variant.
+func Experiments(experiments []Experiment) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			AddVary(w.Header(), "Cookie", ExperimentsHeader)
+
+			stored := map[string]string{}
+			if cookie, err := r.Cookie(experimentsCookie); err == nil {
+				if values, err := url.ParseQuery(cookie.Value); err == nil {
+					for name := range values {
+						stored[name] = values.Get(name)
+					}
+				}
+			}
+			assigned := parseExperimentsHeader(r.Header.Get(ExperimentsHeader))
+
+			variants := make(map[string]string, len(experiments))
+			cookie := url.Values{}
+			changed := false
+			for _, experiment := range experiments {
+				if variant, ok := assigned[experiment.Name]; ok && slices.Contains(experiment.Variants, variant) {
+					variants[experiment.Name] = variant
+					if variant, ok := stored[experiment.Name]; ok {
+						cookie.Set(experiment.Name, variant)
+					}
+					continue
+				}
+				variant, ok := stored[experiment.Name]
+				if !ok || !slices.Contains(experiment.Variants, variant) {
+					variant = experiment.Variants[rand.IntN(len(experiment.Variants))]
+					changed = true
+					log.Printf("Experiment assignment: experiment=%s variant=%s path=%s", experiment.Name, variant, r.URL.Path)
+				}
+				variants[experiment.Name] = variant
+				cookie.Set(experiment.Name, variant)
+			}
+
+			// Variants of experiments that have ended are dropped
+			if changed || len(stored) != len(cookie) {
+				http.SetCookie(w, &http.Cookie{
+					Name:     experimentsCookie,
+					Value:    cookie.Encode(),
+					Path:     "/",
+					MaxAge:   int(experimentsMaxAge.Seconds()),
+					HttpOnly: true,
+					Secure:   secureRequest(r),
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			ctx := context.WithValue(r.Context(), experimentsKey{}, variants)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// parseExperimentsHeader returns the variants assigned in the
+// ExperimentsHeader, keyed by experiment name.
+func parseExperimentsHeader(header string) map[string]string {
+	variants := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		name, variant, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok {
+			variants[strings.TrimSpace(name)] = strings.TrimSpace(variant)
+		}
+	}
+	return variants
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+var testExperiments = []Experiment{
+	{Name: "hero", Variants: []string{"control", "short"}},
+	{Name: "nav", Variants: []string{"a", "b", "c"}},
+}
+
+// variantsHandler returns a handler protected by the experiments
+// middleware that records the variants of the last request
+func variantsHandler(variants *map[string]string) http.Handler {
+	return Experiments(testExperiments)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*variants = Variants(r.Context())
+	}))
+}
+
+func TestExperiments(t *testing.T) {
+	var variants map[string]string
+	handler := variantsHandler(&variants)
+
+	// New visitors are assigned a variant of each experiment
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != experimentsCookie || cookies[0].MaxAge <= 0 {
+		t.Fatalf("Expected experiments cookie, got %+v", cookies)
+	}
+	stored, err := url.ParseQuery(cookies[0].Value)
+	if err != nil {
+		t.Fatalf("Invalid cookie %q: %v", cookies[0].Value, err)
+	}
+	for _, experiment := range testExperiments {
+		variant := variants[experiment.Name]
+		if stored.Get(experiment.Name) != variant || !slices.Contains(experiment.Variants, variant) {
+			t.Errorf("Expected %s variant in cookie, got %q and %q", experiment.Name, variant, stored.Get(experiment.Name))
+		}
+	}
+	if vary := w.Header().Values("Vary"); len(vary) != 2 || vary[0] != "Cookie" || vary[1] != ExperimentsHeader {
+		t.Errorf("Expected responses to vary on cookie and header, got %v", vary)
+	}
+
+	testCases := []struct {
+		name     string
+		cookie   string
+		header   string
+		expected map[string]string
+		stored   string
+	}{
+		{name: "Returning visitor", cookie: "hero=short&nav=c", expected: map[string]string{"hero": "short", "nav": "c"}},
+		{name: "Ended experiment", cookie: "hero=short&nav=c&old=x", expected: map[string]string{"hero": "short", "nav": "c"}, stored: "hero=short&nav=c"},
+		{name: "Header", cookie: "hero=short&nav=c", header: "hero=control, nav=a", expected: map[string]string{"hero": "control", "nav": "a"}},
+		{name: "Header without cookie", header: "hero=control,nav=b", expected: map[string]string{"hero": "control", "nav": "b"}},
+		{name: "Invalid header", cookie: "hero=short&nav=c", header: "hero=long", expected: map[string]string{"hero": "short", "nav": "c"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: experimentsCookie, Value: tc.cookie})
+			}
+			if tc.header != "" {
+				req.Header.Set(ExperimentsHeader, tc.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			for name, variant := range tc.expected {
+				if variants[name] != variant {
+					t.Errorf("Expected %s variant %q, got %q", name, variant, variants[name])
+				}
+			}
+			cookies := w.Result().Cookies()
+			if tc.stored == "" && len(cookies) != 0 {
+				t.Errorf("Expected no cookie, got %+v", cookies)
+			}
+			if tc.stored != "" && (len(cookies) != 1 || cookies[0].Value != tc.stored) {
+				t.Errorf("Expected cookie %q, got %+v", tc.stored, cookies)
+			}
+		})
+	}
+
+	// Invalid variants are reassigned
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: experimentsCookie, Value: "hero=long&nav=a"})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if !slices.Contains(testExperiments[0].Variants, variants["hero"]) || variants["nav"] != "a" || len(w.Result().Cookies()) != 1 {
+		t.Errorf("Expected hero to be reassigned, got %v", variants)
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+)
+
+// CircuitBreaker reports whether requests to the origin are being stopped
+// because it is failing.
+type CircuitBreaker interface {
+	Open() bool
+}
+
+// Snapshot serves a static copy of the site.
+type Snapshot interface {
+	// Serve responds with the copy of the requested path, reporting false
+	// without responding if there is none.
+	Serve(w http.ResponseWriter, r *http.Request) bool
+}
+
+// Failover returns a middleware that serves pages from a static copy of
+// the site while the circuit breaker of the origin is open, rather than
+// the error pages the next handler would respond with.  Paths without a
+// copy, and requests other than GET and HEAD, are passed to the next
+// handler.
+func Failover(breaker CircuitBreaker, snapshot Snapshot) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (r.Method == http.MethodGet || r.Method == http.MethodHead) && breaker.Open() {
+				if snapshot.Serve(w, r) {
+					return
+				}
+				log.Printf("No exported copy of %s to fail over to", r.URL.Path)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fixedBreaker is open or closed
+type fixedBreaker bool
+
+func (b fixedBreaker) Open() bool {
+	return bool(b)
+}
+
+// staticSnapshot has a copy of the home page only
+type staticSnapshot struct{}
+
+func (staticSnapshot) Serve(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Path != "/" {
+		return false
+	}
+	w.Write([]byte("copy"))
+	return true
+}
+
+func TestFailover(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	})
+
+	testCases := []struct {
+		name     string
+		open     bool
+		method   string
+		target   string
+		expected string
+	}{
+		{name: "Closed", open: false, method: "GET", target: "/", expected: "page"},
+		{name: "Open", open: true, method: "GET", target: "/", expected: "copy"},
+		{name: "No copy", open: true, method: "GET", target: "/about", expected: "page"},
+		{name: "Form post", open: true, method: "POST", target: "/", expected: "page"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Failover(fixedBreaker(tc.open), staticSnapshot{})(next).ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, nil))
+			if w.Body.String() != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, w.Body.String())
+			}
+		})
+	}
+}
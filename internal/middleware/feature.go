@@ -0,0 +1,23 @@
+package middleware
+
+import "net/http"
+
+// FeatureFlags reports whether features are enabled.
+type FeatureFlags interface {
+	Enabled(name string) bool
+}
+
+// Feature returns a middleware that responds with a 404 while the named
+// feature is disabled, so routes can be rolled out separately in each
+// environment and toggled without a deploy.
+func Feature(flags FeatureFlags, name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !flags.Enabled(name) {
+				http.NotFound(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
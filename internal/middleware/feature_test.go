@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// staticFlags enables the features set to true
+type staticFlags map[string]bool
+
+func (f staticFlags) Enabled(name string) bool {
+	return f[name]
+}
+
+func TestFeature(t *testing.T) {
+	flags := staticFlags{"search": true}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	})
+
+	t.Run("Enabled feature", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Feature(flags, "search")(next).ServeHTTP(w, httptest.NewRequest("GET", "/search", nil))
+
+		if w.Code != http.StatusOK || w.Body.String() != "page" {
+			t.Errorf("Expected the next handler to respond, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Disabled feature", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Feature(flags, "graphql")(next).ServeHTTP(w, httptest.NewRequest("GET", "/graphql", nil))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("Toggled at runtime", func(t *testing.T) {
+		handler := Feature(flags, "search")(next)
+		flags["search"] = false
+		defer func() { flags["search"] = true }()
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/search", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d after disabling, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
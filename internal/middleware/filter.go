@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+)
+
+// RequestFilter decides whether a request is blocked.
+type RequestFilter interface {
+	Block(r *http.Request) (reason string, blocked bool)
+}
+
+// Filter returns a middleware that responds with a 403 to requests blocked
+// by the filter and passes all other requests to the next handler.
+func Filter(filter RequestFilter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if reason, blocked := filter.Block(r); blocked {
+				log.Printf("Blocked request %s: %s", r.URL.Path, reason)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
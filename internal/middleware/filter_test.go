@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pathFilter blocks a single path
+type pathFilter struct{}
+
+func (pathFilter) Block(r *http.Request) (string, bool) {
+	return "blocked path", r.URL.Path == "/wp-login.php"
+}
+
+func TestFilter(t *testing.T) {
+	handler := Filter(pathFilter{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	}))
+
+	t.Run("Blocked request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/wp-login.php", nil))
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("Allowed request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/about", nil))
+
+		if w.Code != http.StatusOK || w.Body.String() != "page" {
+			t.Errorf("Expected the next handler to respond, got %d %q", w.Code, w.Body.String())
+		}
+	})
+}
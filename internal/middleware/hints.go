@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ResourceHints returns a middleware that adds Link headers with the given
+// resource hints, such as preloads of critical stylesheets and fonts, to
+// responses to page requests, so that browsers fetch them before parsing
+// the page.  Page requests are GET or HEAD requests accepting HTML.
+//
+// With earlyHints, the hints are first sent in a 103 Early Hints response,
+// letting browsers fetch them while the page is being rendered.  It must
+// be the outermost middleware of a server, since the handlers it wraps
+// would see the 103 status as the response's, and Lambda responses cannot
+// have informational responses.
+func ResourceHints(links []string, earlyHints bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(links) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (r.Method == http.MethodGet || r.Method == http.MethodHead) && acceptsHTML(r) {
+				for _, link := range links {
+					w.Header().Add("Link", link)
+				}
+				if earlyHints && r.Method == http.MethodGet {
+					w.WriteHeader(http.StatusEarlyHints)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acceptsHTML reports whether a request accepts an HTML response, as the
+// navigation requests of browsers do.
+func acceptsHTML(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept") {
+		if strings.Contains(value, "text/html") {
+			return true
+		}
+	}
+	return false
+}
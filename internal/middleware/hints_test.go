@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"reflect"
+	"testing"
+)
+
+func TestResourceHints(t *testing.T) {
+	links := []string{"</static/css/styles.css>; rel=preload; as=style", "<https://cdn.example.com>; rel=preconnect"}
+	handler := ResourceHints(links, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+
+	tests := []struct {
+		name     string
+		method   string
+		accept   string
+		expected []string
+	}{
+		{"page", http.MethodGet, "text/html,application/xhtml+xml,*/*;q=0.8", links},
+		{"head", http.MethodHead, "text/html", links},
+		{"image", http.MethodGet, "image/avif,image/webp,*/*", nil},
+		{"post", http.MethodPost, "text/html", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", rec.Code)
+			}
+			if got := rec.Header().Values("Link"); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected Link %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResourceHintsEarlyHints(t *testing.T) {
+	links := []string{"</static/css/styles.css>; rel=preload; as=style"}
+	server := httptest.NewServer(ResourceHints(links, true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})))
+	defer server.Close()
+
+	var early []string
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				early = header.Values("Link")
+			}
+			return nil
+		},
+	}
+	req, _ := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, server.URL, nil)
+	req.Header.Set("Accept", "text/html")
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if !reflect.DeepEqual(early, links) {
+		t.Errorf("Expected early hints %v, got %v", links, early)
+	}
+	if got := resp.Header.Values("Link"); !reflect.DeepEqual(got, links) {
+		t.Errorf("Expected Link %v, got %v", links, got)
+	}
+}
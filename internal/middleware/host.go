@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ForwardedHost returns the host of a request's X-Forwarded-Host header if
+// it is valid.  Of several hosts, the first is the one the client used,
+// added by the proxy furthest from the proxy.  The header must only be
+// trusted when a proxy in front of the proxy sets it.
+func ForwardedHost(r *http.Request) (string, bool) {
+	host, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Host"), ",")
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return "", false
+	}
+	u, err := url.Parse("//" + host)
+	if err != nil || u.Host != host || u.User != nil {
+		return "", false
+	}
+	return host, true
+}
+
+// CanonicalHost returns a middleware that permanently redirects GET and
+// HEAD requests for other origins of the site to its canonical origin,
+// such as "https://www.example.ca": requests over HTTP, when the origin is
+// HTTPS, and requests for the www or apex alias of its host, such as when
+// several CloudFront aliases point at the proxy.  Requests for other hosts,
+// such as the internal host of API Gateway or health checks by IP address,
+// are served.  The host of X-Forwarded-Host is used if trustForwardedHost
+// is set.
+func CanonicalHost(origin string, trustForwardedHost bool) func(http.Handler) http.Handler {
+	canonical, _ := url.Parse(origin)
+	canonicalHost := strings.ToLower(canonical.Host)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			host := r.Host
+			if forwarded, ok := ForwardedHost(r); ok && trustForwardedHost {
+				host = forwarded
+			}
+			host = strings.ToLower(host)
+			if strings.TrimPrefix(host, "www.") != strings.TrimPrefix(canonicalHost, "www.") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if host == canonicalHost && (canonical.Scheme != "https" || secureRequest(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			http.Redirect(w, r, canonical.Scheme+"://"+canonical.Host+r.URL.RequestURI(), http.StatusMovedPermanently)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalHost(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	})
+
+	testCases := []struct {
+		name             string
+		method           string
+		url              string
+		header           map[string]string
+		tls              bool
+		trusted          bool
+		expectedLocation string
+	}{
+		{name: "Canonical origin served", method: "GET", url: "https://www.example.ca/about", tls: true},
+		{name: "Forwarded HTTPS served", method: "GET", url: "http://www.example.ca/about", header: map[string]string{"X-Forwarded-Proto": "https"}},
+		{name: "HTTP", method: "GET", url: "http://www.example.ca/about?lang=fr", expectedLocation: "https://www.example.ca/about?lang=fr"},
+		{name: "Apex", method: "GET", url: "https://example.ca/fr/", tls: true, expectedLocation: "https://www.example.ca/fr/"},
+		{name: "Host case", method: "GET", url: "https://WWW.Example.ca/about", tls: true},
+		{name: "Other host served", method: "GET", url: "http://abc123.execute-api.ca-central-1.amazonaws.com/about"},
+		{name: "Health check by address served", method: "GET", url: "http://10.0.0.12/healthz"},
+		{name: "Other methods served", method: "POST", url: "http://example.ca/about"},
+		{name: "Forwarded apex", method: "GET", url: "https://abc123.lambda-url.ca-central-1.on.aws/about", tls: true, trusted: true,
+			header: map[string]string{"X-Forwarded-Host": "example.ca"}, expectedLocation: "https://www.example.ca/about"},
+		{name: "Untrusted forwarded apex served", method: "GET", url: "https://abc123.lambda-url.ca-central-1.on.aws/about", tls: true,
+			header: map[string]string{"X-Forwarded-Host": "example.ca"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			req.TLS = nil
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			for name, value := range tc.header {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			CanonicalHost("https://www.example.ca", tc.trusted)(next).ServeHTTP(w, req)
+
+			if tc.expectedLocation == "" {
+				if w.Code != http.StatusOK || w.Body.String() != "page" {
+					t.Errorf("Expected the next handler to respond, got %d", w.Code)
+				}
+				return
+			}
+			if w.Code != http.StatusMovedPermanently {
+				t.Errorf("Expected status %d, got %d", http.StatusMovedPermanently, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Errorf("Expected Location %q, got %q", tc.expectedLocation, location)
+			}
+		})
+	}
+}
+
+func TestForwardedHost(t *testing.T) {
+	for header, expected := range map[string]string{
+		"www.example.ca":                  "www.example.ca",
+		"www.example.ca:8443":             "www.example.ca:8443",
+		"www.example.ca, cdn.example.net": "www.example.ca",
+		"":                                "",
+		"evil.example/path":               "",
+		"user@evil.example":               "",
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Forwarded-Host", header)
+		if host, ok := ForwardedHost(req); host != expected || ok != (expected != "") {
+			t.Errorf("ForwardedHost(%q): expected %q, got %q, %v", header, expected, host, ok)
+		}
+	}
+}
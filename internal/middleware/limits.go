@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+)
+
+// RequestLimits bounds the requests the proxy handles.  A limit of zero is
+// not enforced.
+type RequestLimits struct {
+	// MaxHeaderCount is the number of header fields a request may have.
+	MaxHeaderCount int
+
+	// MaxHeaderBytes is the size of a request's header fields, counted as
+	// they are sent, with a colon, space and line break after each name and
+	// value.
+	MaxHeaderBytes int
+}
+
+// Limit returns a middleware rejecting requests no page or form of the
+// site takes before any handler runs, complementing the check of the
+// length of paths: requests with too many or too large headers are
+// rejected with 431, such as in Lambda mode where the server's own limit
+// does not apply, and GET and HEAD requests with a body with 400.
+func Limit(limits RequestLimits) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count, size := 0, 0
+			for name, values := range r.Header {
+				for _, value := range values {
+					count++
+					size += len(name) + len(value) + 4
+				}
+			}
+			if (limits.MaxHeaderCount > 0 && count > limits.MaxHeaderCount) ||
+				(limits.MaxHeaderBytes > 0 && size > limits.MaxHeaderBytes) {
+				log.Printf("Request headers too large: %d fields, %d bytes", count, size)
+				http.Error(w, "Request header fields too large", http.StatusRequestHeaderFieldsTooLarge)
+				return
+			}
+
+			if (r.Method == http.MethodGet || r.Method == http.MethodHead) && r.ContentLength != 0 {
+				log.Printf("%s request with a body: %s", r.Method, r.URL.Path)
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// LimitBody returns a middleware limiting request bodies to n bytes.  It
+// applies the limit of a handler's form to middleware reading the form
+// before it, such as CSRF, whose own limit is that of the largest form.
+func LimitBody(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
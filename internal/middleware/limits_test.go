@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLimit(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	})
+	limits := RequestLimits{MaxHeaderCount: 10, MaxHeaderBytes: 1024}
+
+	testCases := []struct {
+		name           string
+		method         string
+		body           string
+		headers        int
+		headerSize     int
+		expectedStatus int
+	}{
+		{name: "GET", method: "GET", expectedStatus: http.StatusOK},
+		{name: "POST with a body", method: "POST", body: "post_password=secret", expectedStatus: http.StatusOK},
+		{name: "GET with a body", method: "GET", body: "junk", expectedStatus: http.StatusBadRequest},
+		{name: "HEAD with a body", method: "HEAD", body: "junk", expectedStatus: http.StatusBadRequest},
+		{name: "Headers at the limit", method: "GET", headers: 10, expectedStatus: http.StatusOK},
+		{name: "Too many headers", method: "GET", headers: 11, expectedStatus: http.StatusRequestHeaderFieldsTooLarge},
+		{name: "Headers too large", method: "GET", headers: 1, headerSize: 1024, expectedStatus: http.StatusRequestHeaderFieldsTooLarge},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/about", strings.NewReader(tc.body))
+			for i := 0; i < tc.headers; i++ {
+				req.Header.Set("X-Test-"+strconv.Itoa(i), strings.Repeat("a", max(tc.headerSize, 1)))
+			}
+			w := httptest.NewRecorder()
+			Limit(limits)(next).ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	req := httptest.NewRequest("GET", "/about", nil)
+	for i := 0; i < 50; i++ {
+		req.Header.Set("X-Test-"+strconv.Itoa(i), "a")
+	}
+	w := httptest.NewRecorder()
+	Limit(RequestLimits{})(next).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected no limits when they are zero, got %d", w.Code)
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// PermalinkResolver finds the proxy path of the content at a WordPress
+// permalink.
+type PermalinkResolver interface {
+	Resolve(ctx context.Context, u *url.URL) (path string, ok bool)
+}
+
+// Permalinks returns a middleware that permanently redirects WordPress
+// permalinks, such as /?p=123, to the path the proxy serves their content
+// at, and passes all other requests to the next handler.
+func Permalinks(resolver PermalinkResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if target, ok := resolver.Resolve(r.Context(), r.URL); ok {
+				log.Printf("Redirecting permalink %s to %s", r.URL.RequestURI(), target)
+				http.Redirect(w, r, target, http.StatusMovedPermanently)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// staticPermalinks resolves a single post ID
+type staticPermalinks struct{}
+
+func (staticPermalinks) Resolve(ctx context.Context, u *url.URL) (string, bool) {
+	if u.Path == "/" && u.Query().Get("p") == "123" {
+		return "/events/launch", true
+	}
+	return "", false
+}
+
+func TestPermalinks(t *testing.T) {
+	handler := Permalinks(staticPermalinks{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	}))
+
+	testCases := []struct {
+		name     string
+		method   string
+		target   string
+		status   int
+		location string
+	}{
+		{name: "Permalink", method: "GET", target: "/?p=123", status: http.StatusMovedPermanently, location: "/events/launch"},
+		{name: "Other ID", method: "GET", target: "/?p=456", status: http.StatusOK},
+		{name: "Other path", method: "GET", target: "/about", status: http.StatusOK},
+		{name: "Form post", method: "POST", target: "/?p=123", status: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, nil))
+
+			if w.Code != tc.status || w.Header().Get("Location") != tc.location {
+				t.Errorf("Expected %d to %q, got %d to %q", tc.status, tc.location, w.Code, w.Header().Get("Location"))
+			}
+		})
+	}
+}
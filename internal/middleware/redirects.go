@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+)
+
+// Redirector finds the redirect for a request path.
+type Redirector interface {
+	Match(path string) (target string, code int, ok bool)
+}
+
+// Redirects returns a middleware that redirects requests matching a rule
+// and passes all other requests to the next handler.
+func Redirects(redirector Redirector) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if target, code, ok := redirector.Match(r.URL.Path); ok {
+				log.Printf("Redirecting %s to %s", r.URL.Path, target)
+				http.Redirect(w, r, target, code)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// staticRedirector redirects a single path
+type staticRedirector struct{}
+
+func (staticRedirector) Match(path string) (string, int, bool) {
+	if path == "/old" {
+		return "/new", http.StatusMovedPermanently, true
+	}
+	return "", 0, false
+}
+
+func TestRedirects(t *testing.T) {
+	handler := Redirects(staticRedirector{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	}))
+
+	t.Run("Matching path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/old", nil))
+
+		if w.Code != http.StatusMovedPermanently {
+			t.Errorf("Expected status %d, got %d", http.StatusMovedPermanently, w.Code)
+		}
+		if location := w.Header().Get("Location"); location != "/new" {
+			t.Errorf("Expected Location /new, got %q", location)
+		}
+	})
+
+	t.Run("Other path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/about", nil))
+
+		if w.Code != http.StatusOK || w.Body.String() != "page" {
+			t.Errorf("Expected the next handler to respond, got %d %q", w.Code, w.Body.String())
+		}
+	})
+}
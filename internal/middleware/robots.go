@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// NoIndex keeps every response out of search engines with an X-Robots-Tag
+// header, such as on a staging deployment that must stay open.  The
+// header also covers media, feeds and other responses that cannot carry
+// a robots meta tag.
+func NoIndex(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNoIndex(t *testing.T) {
+	handler := NoIndex(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+
+	for _, path := range []string{"/about", "/wp-content/uploads/report.pdf", "/feed"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if got := rec.Header().Get("X-Robots-Tag"); got != "noindex, nofollow" {
+			t.Errorf("Expected %s not indexed, got %q", path, got)
+		}
+	}
+}
@@ -6,11 +6,25 @@ import (
 
 // SecurityHeaders set security headers on the response.
 func SecurityHeaders(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains; preload")
-		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
-		w.Header().Set("X-Content-Type-Options", "nosniff")
-		w.Header().Set("Referrer-Policy", "no-referrer-when-downgrade")
-		next.ServeHTTP(w, r)
-	})
+	return NewSecurityHeaders(CSP{})(next)
+}
+
+// NewSecurityHeaders returns a middleware that sets security headers on the
+// response, including the given Content-Security-Policy.  The request's
+// CSP nonce is available to handlers with Nonce.
+func NewSecurityHeaders(csp CSP) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains; preload")
+			w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("Referrer-Policy", "no-referrer-when-downgrade")
+
+			r, ok := withCSP(csp, w, r)
+			if !ok {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"wordpress-go-proxy/internal/auth"
+)
+
+// Authenticator checks the credentials of a request.  When they are
+// missing or invalid it responds with a 401 and returns false.
+type Authenticator func(w http.ResponseWriter, r *http.Request) bool
+
+type authenticatedKey struct{}
+
+// Authenticated reports whether the visitor signed in through an
+// Authenticator, which is false if the request was not handled by
+// BasicAuth, ALBAuth or Access, or its path was exempt.
+func Authenticated(ctx context.Context) bool {
+	authenticated, _ := ctx.Value(authenticatedKey{}).(bool)
+	return authenticated
+}
+
+// withAuthenticated marks the visitor of a request as signed in.
+func withAuthenticated(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), authenticatedKey{}, true))
+}
+
+// BasicAuthenticator returns an Authenticator requiring HTTP Basic
+// authentication with the users of an htpasswd file.
+func BasicAuthenticator(users auth.Htpasswd, realm string) Authenticator {
+	return func(w http.ResponseWriter, r *http.Request) bool {
+		user, password, ok := r.BasicAuth()
+		if !ok || !users.Verify(user, password) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+strings.ReplaceAll(realm, `"`, "")+`", charset="UTF-8"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}
+}
+
+// ALBAuthenticator returns an Authenticator requiring the claims an
+// Application Load Balancer signs for the users it signed in with OIDC,
+// so that requests reaching the proxy without going through the load
+// balancer are refused.
+func ALBAuthenticator(verifier *auth.ALBVerifier) Authenticator {
+	return func(w http.ResponseWriter, r *http.Request) bool {
+		token := r.Header.Get(auth.ALBHeader)
+		if token == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return false
+		}
+		if _, err := verifier.Verify(r.Context(), token); err != nil {
+			log.Printf("Invalid ALB token: %v", err)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}
+}
+
+// BasicAuth returns a middleware that locks down a deployment, such as a
+// staging site, behind HTTP Basic authentication with the users of an
+// htpasswd file.  Paths in exempt, or under those ending in a slash, are
+// left open for health checks and endpoints with their own authentication.
+// Responses are marked noindex, so that a locked down site is not indexed
+// if it is ever reached.
+func BasicAuth(users auth.Htpasswd, realm string, exempt []string) func(http.Handler) http.Handler {
+	return siteAuth(BasicAuthenticator(users, realm), exempt)
+}
+
+// ALBAuth returns a middleware that locks down a deployment behind the
+// OIDC authentication of an Application Load Balancer, such as with a
+// Cognito user pool.  Exempt paths and noindex are as for BasicAuth.
+func ALBAuth(verifier *auth.ALBVerifier, exempt []string) func(http.Handler) http.Handler {
+	return siteAuth(ALBAuthenticator(verifier), exempt)
+}
+
+// siteAuth returns a middleware that requires every request to a path not
+// exempt to pass authenticate.
+func siteAuth(authenticate Authenticator, exempt []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+			if !exemptPath(r.URL.Path, exempt) {
+				if !authenticate(w, r) {
+					return
+				}
+				r = withAuthenticated(r)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// exemptPath reports whether a path is one of exempt, or under one of
+// those ending in a slash.
+func exemptPath(path string, exempt []string) bool {
+	for _, e := range exempt {
+		if path == e || (strings.HasSuffix(e, "/") && strings.HasPrefix(path, e)) {
+			return true
+		}
+	}
+	return false
+}
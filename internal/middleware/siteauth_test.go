@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wordpress-go-proxy/internal/auth"
+)
+
+func TestBasicAuth(t *testing.T) {
+	users, err := auth.ParseHtpasswd("editor:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	handler := BasicAuth(users, "Staging", []string{"/healthz", "/internal/"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+
+	tests := []struct {
+		name     string
+		path     string
+		user     string
+		password string
+		expected int
+	}{
+		{"signed in", "/about", "editor", "secret", http.StatusOK},
+		{"wrong password", "/about", "editor", "guess", http.StatusUnauthorized},
+		{"anonymous", "/about", "", "", http.StatusUnauthorized},
+		{"health check", "/healthz", "", "", http.StatusOK},
+		{"internal endpoint", "/internal/warm", "", "", http.StatusOK},
+		{"path like an exempt one", "/healthz/extra", "", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, rec.Code)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != `Basic realm="Staging", charset="UTF-8"` {
+				t.Errorf("Expected a Basic challenge, got %q", rec.Header().Get("WWW-Authenticate"))
+			}
+			if rec.Header().Get("X-Robots-Tag") != "noindex, nofollow" {
+				t.Errorf("Expected noindex, got %q", rec.Header().Get("X-Robots-Tag"))
+			}
+		})
+	}
+}
+
+func TestALBAuth(t *testing.T) {
+	verifier, err := auth.NewALBVerifier("arn:aws:elasticloadbalancing:ca-central-1:123456789012:loadbalancer/app/staging/1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	handler := ALBAuth(verifier, []string{"/healthz"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+
+	for path, token := range map[string]string{"/about": "", "/fr/": "forged.token.value"} {
+		req := httptest.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set(auth.ALBHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected %s refused, got %d", path, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected health check open, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// AddVary adds request headers to the Vary header of a response, skipping
+// those it already lists, so that caches key the response on them.
+func AddVary(header http.Header, names ...string) {
+	listed := make(map[string]bool)
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			listed[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+	for _, name := range names {
+		if key := http.CanonicalHeaderKey(name); !listed[key] {
+			listed[key] = true
+			header.Add("Vary", name)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAddVary(t *testing.T) {
+	header := http.Header{"Vary": {"Accept-Encoding, cookie"}}
+	AddVary(header, "Cookie", "X-Forwarded-Proto", "x-forwarded-proto")
+
+	expected := []string{"Accept-Encoding, cookie", "X-Forwarded-Proto"}
+	if !reflect.DeepEqual(header.Values("Vary"), expected) {
+		t.Errorf("Expected %v, got %v", expected, header.Values("Vary"))
+	}
+}
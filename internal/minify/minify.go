@@ -0,0 +1,144 @@
+// Package minify shrinks rendered HTML pages by removing the whitespace
+// and comments that do not change how they display.
+package minify
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// preservedElements are the elements whose text is kept as it is: raw text
+// elements, whose content is not HTML, and elements that display their
+// whitespace.
+var preservedElements = map[atom.Atom]bool{
+	atom.Pre: true, atom.Textarea: true, atom.Script: true, atom.Style: true,
+}
+
+// blockElements are the elements that are not laid out inline, so that
+// whitespace next to them is not displayed.  The elements of the head,
+// which are not displayed at all, are treated as block elements too.
+var blockElements = map[atom.Atom]bool{
+	atom.Html: true, atom.Head: true, atom.Body: true, atom.Address: true,
+	atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Details: true, atom.Dialog: true, atom.Dd: true,
+	atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Fieldset: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true,
+	atom.H6: true, atom.Header: true, atom.Hgroup: true, atom.Hr: true,
+	atom.Li: true, atom.Main: true, atom.Nav: true, atom.Ol: true, atom.P: true,
+	atom.Pre: true, atom.Section: true, atom.Summary: true, atom.Table: true,
+	atom.Caption: true, atom.Colgroup: true, atom.Col: true, atom.Thead: true,
+	atom.Tbody: true, atom.Tfoot: true, atom.Tr: true, atom.Td: true,
+	atom.Th: true, atom.Ul: true, atom.Option: true, atom.Optgroup: true,
+}
+
+// voidElements are the elements without content, whose tags need no
+// closing slash.
+var voidElements = map[atom.Atom]bool{
+	atom.Area: true, atom.Base: true, atom.Br: true, atom.Col: true,
+	atom.Embed: true, atom.Hr: true, atom.Img: true, atom.Input: true,
+	atom.Link: true, atom.Meta: true, atom.Source: true, atom.Track: true,
+	atom.Wbr: true,
+}
+
+// HTML returns a page with its comments removed, runs of whitespace
+// collapsed to a space, whitespace next to block elements removed, and
+// attributes written with double quotes.  The text of scripts, styles and
+// preformatted elements is kept as it is, as are conditional comments.
+func HTML(page []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(page))
+
+	preserved, inHead := 0, false
+	// space is whether whitespace was skipped since the last output, and
+	// block whether that output was a block element's tag, after which
+	// the whitespace is not displayed.
+	space, block := false, true
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return out.Bytes()
+		case html.TextToken:
+			if preserved > 0 {
+				out.Write(z.Raw())
+				continue
+			}
+			text := z.Raw()
+			fields := bytes.FieldsFunc(text, isSpace)
+			if len(fields) == 0 {
+				space = space || len(text) > 0
+				continue
+			}
+			if (space || isSpace(rune(text[0]))) && !block {
+				out.WriteByte(' ')
+			}
+			out.Write(bytes.Join(fields, []byte(" ")))
+			space, block = isSpace(rune(text[len(text)-1])), false
+		case html.CommentToken:
+			if raw := z.Raw(); bytes.HasPrefix(raw, []byte("<!--[if")) {
+				out.Write(raw)
+				space, block = false, false
+			}
+		case html.DoctypeToken:
+			out.Write(z.Raw())
+			space, block = false, true
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			token := z.Token()
+			if token.DataAtom == atom.Head || token.DataAtom == atom.Body {
+				inHead = token.DataAtom == atom.Head && tt == html.StartTagToken
+			}
+			isBlock := blockElements[token.DataAtom] || inHead
+			if space && !block && !isBlock {
+				out.WriteByte(' ')
+			}
+			writeTag(&out, token)
+			space, block = false, isBlock
+
+			if preservedElements[token.DataAtom] {
+				switch tt {
+				case html.StartTagToken:
+					preserved++
+				case html.EndTagToken:
+					preserved = max(preserved-1, 0)
+				}
+			}
+		}
+	}
+}
+
+// writeTag writes a tag with its attributes in double quotes, leaving out
+// empty values, which are the same as no value.
+func writeTag(out *bytes.Buffer, token html.Token) {
+	out.WriteByte('<')
+	if token.Type == html.EndTagToken {
+		out.WriteByte('/')
+	}
+	out.WriteString(token.Data)
+	for _, attr := range token.Attr {
+		out.WriteByte(' ')
+		out.WriteString(attr.Key)
+		if attr.Val != "" {
+			out.WriteString(`="`)
+			out.WriteString(attrEscaper.Replace(attr.Val))
+			out.WriteByte('"')
+		}
+	}
+	if token.Type == html.SelfClosingTagToken && !voidElements[token.DataAtom] {
+		out.WriteByte('/')
+	}
+	out.WriteByte('>')
+}
+
+// attrEscaper escapes the characters that cannot appear as they are in a
+// double-quoted attribute value.
+var attrEscaper = strings.NewReplacer(`&`, "&amp;", `"`, "&#34;")
+
+// isSpace reports whether r is HTML whitespace.
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r'
+}
@@ -0,0 +1,55 @@
+package minify
+
+import (
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		page     string
+		expected string
+	}{
+		{
+			name: "Head and blocks",
+			page: "<!DOCTYPE html>\n<html lang='en'>\n  <head>\n    <meta charset=utf-8>\n    <title>  About   us </title>\n  </head>\n" +
+				"  <body>\n    <main>\n      <p>\n        Hello,\n        <em>world</em> !\n      </p>\n    </main>\n  </body>\n</html>\n",
+			expected: `<!DOCTYPE html><html lang="en"><head><meta charset="utf-8"><title>About us</title></head>` +
+				`<body><main><p>Hello, <em>world</em> !</p></main></body></html>`,
+		},
+		{
+			name:     "Inline whitespace kept",
+			page:     "<p><a href=/a>One</a>\n<a href=/b>Two</a><span>  Three</span></p>",
+			expected: `<p><a href="/a">One</a> <a href="/b">Two</a><span> Three</span></p>`,
+		},
+		{
+			name:     "Comments removed",
+			page:     "<div>\n<!-- wp:paragraph -->\n<p>Text<!-- more --> here</p>\n<!-- /wp:paragraph -->\n</div><!--[if IE]><p>Old</p><![endif]-->",
+			expected: `<div><p>Text here</p></div><!--[if IE]><p>Old</p><![endif]-->`,
+		},
+		{
+			name:     "Preformatted and raw text kept",
+			page:     "<pre>\n  line  one\n  <b>line</b>  two\n</pre>\n<script nonce=\"n\">\n  if (a  <  b) {}\n</script>\n<textarea name=t>  a\n  b</textarea>",
+			expected: "<pre>\n  line  one\n  <b>line</b>  two\n</pre><script nonce=\"n\">\n  if (a  <  b) {}\n</script> <textarea name=\"t\">  a\n  b</textarea>",
+		},
+		{
+			name:     "Attributes normalized",
+			page:     `<input type='checkbox' checked="" value='say "hi" &amp; bye'><br/><svg><circle r='1'/></svg>`,
+			expected: `<input type="checkbox" checked value="say &#34;hi&#34; &amp; bye"><br><svg><circle r="1"/></svg>`,
+		},
+		{
+			name:     "Entities kept",
+			page:     "<p>Fish &amp;   chips&nbsp;&nbsp;today</p>",
+			expected: "<p>Fish &amp; chips&nbsp;&nbsp;today</p>",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := string(HTML([]byte(tc.page)))
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows each key, such as a client address, a number of events
+// per window of time.  Windows are fixed and start with the first event of
+// a key.  Counts are kept in memory, so each instance of the proxy limits
+// the clients it serves on its own.
+type Limiter struct {
+	Limit  int
+	Window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*window
+	pruned  time.Time
+	now     func() time.Time
+}
+
+// window counts the events of a key since start.
+type window struct {
+	start time.Time
+	count int
+}
+
+// New creates a limiter allowing limit events per key in each window of
+// the given length.
+func New(limit int, length time.Duration) *Limiter {
+	return &Limiter{
+		Limit:   limit,
+		Window:  length,
+		windows: make(map[string]*window),
+		now:     time.Now,
+	}
+}
+
+// Allow records an event for a key and reports whether it is within the
+// limit.  Events over the limit are not counted.
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.Window {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+	if w.count >= l.Limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// prune forgets the keys whose window has ended, at most once per window,
+// so that keys seen once do not accumulate.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < l.Window {
+		return
+	}
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.Window {
+			delete(l.windows, key)
+		}
+	}
+	l.pruned = now
+}
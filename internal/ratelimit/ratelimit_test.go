@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	for i, expected := range []bool{true, true, false, false} {
+		if allowed := l.Allow("192.0.2.1"); allowed != expected {
+			t.Errorf("Event %d: expected %v, got %v", i+1, expected, allowed)
+		}
+	}
+	if !l.Allow("192.0.2.2") {
+		t.Error("Expected other keys to have their own limit")
+	}
+
+	now = now.Add(time.Minute)
+	if !l.Allow("192.0.2.1") {
+		t.Error("Expected events to be allowed again in the next window")
+	}
+}
+
+// TestPrune tests that keys are forgotten once their window has ended
+func TestPrune(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	l := New(1, time.Minute)
+	l.now = func() time.Time { return now }
+
+	l.Allow("192.0.2.1")
+	now = now.Add(30 * time.Second)
+	l.Allow("192.0.2.2")
+	now = now.Add(45 * time.Second)
+	l.Allow("192.0.2.3")
+
+	if _, ok := l.windows["192.0.2.1"]; ok {
+		t.Error("Expected ended window to be pruned")
+	}
+	if len(l.windows) != 2 {
+		t.Errorf("Expected 2 windows, got %d", len(l.windows))
+	}
+}
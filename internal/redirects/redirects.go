@@ -0,0 +1,184 @@
+package redirects
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// Fetcher retrieves the redirects managed in WordPress.
+type Fetcher func(ctx context.Context) ([]models.WordPressRedirect, error)
+
+// Rule is a redirect from a source path to a target URL.  Regex rules
+// match the whole path and may reference capture groups in the target
+// with $1, $2 and so on.
+type Rule struct {
+	Source  string
+	Pattern *regexp.Regexp
+	Target  string
+	Code    int
+}
+
+// Store holds the redirect rules and refreshes them from WordPress once
+// they are older than the refresh interval.  Exact matches ignore case and
+// trailing slashes, like the Redirection plugin's defaults.
+type Store struct {
+	Fetch   Fetcher
+	BaseURL string
+
+	// RefreshInterval is how long rules are kept before they are
+	// refreshed in the background.  Zero disables refreshing.
+	RefreshInterval time.Duration
+
+	mu         sync.RWMutex
+	exact      map[string]Rule
+	patterns   []Rule
+	refreshed  time.Time
+	refreshing atomic.Bool
+}
+
+// NewStore creates a store that fetches redirects with fetch.  Target URLs
+// on the WordPress baseURL are rewritten to paths on the proxy.
+func NewStore(fetch Fetcher, baseURL string, refreshInterval time.Duration) *Store {
+	return &Store{
+		Fetch:           fetch,
+		BaseURL:         baseURL,
+		RefreshInterval: refreshInterval,
+		exact:           make(map[string]Rule),
+	}
+}
+
+// Refresh fetches the redirects and replaces the current rules.  If the
+// fetch fails the current rules are kept.
+func (s *Store) Refresh(ctx context.Context) error {
+	redirects, err := s.Fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshed = time.Now()
+	if err != nil {
+		return err
+	}
+
+	exact := make(map[string]Rule)
+	var patterns []Rule
+	for _, redirect := range redirects {
+		rule, ok := s.newRule(redirect)
+		if !ok {
+			continue
+		}
+		if rule.Pattern != nil {
+			patterns = append(patterns, rule)
+		} else if _, exists := exact[normalize(rule.Source)]; !exists {
+			exact[normalize(rule.Source)] = rule
+		}
+	}
+	s.exact = exact
+	s.patterns = patterns
+	log.Printf("Loaded %d redirects", len(exact)+len(patterns))
+	return nil
+}
+
+// newRule converts a WordPress redirect to a rule.  Disabled redirects and
+// those that do not redirect to a URL are skipped.
+func (s *Store) newRule(redirect models.WordPressRedirect) (Rule, bool) {
+	if !redirect.Enabled || redirect.ActionType != "url" || redirect.Url == "" || redirect.ActionData.Url == "" {
+		return Rule{}, false
+	}
+
+	rule := Rule{
+		Source: redirect.Url,
+		Target: redirect.ActionData.Url,
+		Code:   redirect.ActionCode,
+	}
+	rule.Target = models.RelativeUrl(rule.Target, s.BaseURL)
+	switch rule.Code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		rule.Code = http.StatusMovedPermanently
+	}
+
+	if redirect.Regex {
+		pattern, err := regexp.Compile("^(?:" + redirect.Url + ")$")
+		if err != nil {
+			log.Printf("Skipping redirect %d with invalid pattern: %v", redirect.ID, err)
+			return Rule{}, false
+		}
+		rule.Pattern = pattern
+	}
+	return rule, true
+}
+
+// Match returns the target and status code of the redirect for a path.
+// Regex rules whose expanded target would leave the site of their
+// literal target, such as a captured "/evil.com" making "/$1" a
+// protocol relative URL, are skipped.  If the rules are older than the refresh interval, a background refresh
+// is started and the current rules are used.
+func (s *Store) Match(path string) (string, int, bool) {
+	s.mu.RLock()
+	rule, ok := s.exact[normalize(path)]
+	target := rule.Target
+	if !ok {
+		for _, pattern := range s.patterns {
+			match := pattern.Pattern.FindStringSubmatchIndex(path)
+			if match == nil {
+				continue
+			}
+			expanded := string(pattern.Pattern.ExpandString(nil, pattern.Target, path, match))
+			if !sameOrigin(expanded, pattern.Target) {
+				log.Printf("Skipping redirect %s for %s to another site: %s", pattern.Source, path, expanded)
+				continue
+			}
+			rule, ok, target = pattern, true, expanded
+			break
+		}
+	}
+	stale := s.RefreshInterval > 0 && time.Since(s.refreshed) > s.RefreshInterval
+	s.mu.RUnlock()
+
+	if stale && s.refreshing.CompareAndSwap(false, true) {
+		go func() {
+			defer s.refreshing.Store(false)
+			if err := s.Refresh(context.Background()); err != nil {
+				log.Printf("Error refreshing redirects: %v", err)
+			}
+		}()
+	}
+
+	return target, rule.Code, ok
+}
+
+// sameOrigin reports whether an expanded target stays on the scheme and
+// host of the literal target it was expanded from.  Paths that browsers
+// read as protocol relative URLs never do.
+func sameOrigin(expanded string, literal string) bool {
+	if strings.HasPrefix(expanded, "//") || strings.HasPrefix(expanded, "/\\") || strings.HasPrefix(expanded, "\\") {
+		return false
+	}
+	target, err := url.Parse(expanded)
+	if err != nil {
+		return false
+	}
+	original, err := url.Parse(literal)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(target.Scheme, original.Scheme) && strings.EqualFold(target.Host, original.Host)
+}
+
+// normalize lowercases a path and removes its trailing slash.
+func normalize(path string) string {
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return strings.ToLower(path)
+}
@@ -0,0 +1,130 @@
+package redirects
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// newRedirect creates an enabled URL redirect for tests
+func newRedirect(source string, target string, code int, regex bool) models.WordPressRedirect {
+	redirect := models.WordPressRedirect{
+		Url:        source,
+		Regex:      regex,
+		Enabled:    true,
+		ActionType: "url",
+		ActionCode: code,
+	}
+	redirect.ActionData.Url = target
+	return redirect
+}
+
+func TestStoreMatch(t *testing.T) {
+	disabled := newRedirect("/disabled", "/target", 301, false)
+	disabled.Enabled = false
+	notURL := newRedirect("/error", "/target", 301, false)
+	notURL.ActionType = "error"
+
+	store := NewStore(func(ctx context.Context) ([]models.WordPressRedirect, error) {
+		return []models.WordPressRedirect{
+			newRedirect("/old-page", "/new-page", 301, false),
+			newRedirect("/moved/", "https://wp.example.com/fr/nouveau", 302, false),
+			newRedirect("/news/(\\d+)/(.*)", "/articles/$2?id=$1", 308, true),
+			newRedirect("/old/(.*)", "/$1", 301, true),
+			newRedirect("/site/(.*)", "https://wp.example.com/$1", 301, true),
+			newRedirect("/go/(.*)", "$1", 302, true),
+			newRedirect("/bad-code", "/target", 200, false),
+			newRedirect("/bad(", "/target", 301, true),
+			disabled,
+			notURL,
+		}, nil
+	}, "https://wp.example.com", 0)
+
+	if err := store.Refresh(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	testCases := []struct {
+		path           string
+		expectedTarget string
+		expectedCode   int
+		expectedMatch  bool
+	}{
+		{"/old-page", "/new-page", 301, true},
+		{"/Old-Page/", "/new-page", 301, true},
+		{"/moved", "/fr/nouveau", 302, true},
+		{"/news/42/budget", "/articles/budget?id=42", 308, true},
+		{"/news/latest", "", 0, false},
+		{"/old/about", "/about", 301, true},
+		{"/old//evil.com", "", 0, false},
+		{"/old/\\evil.com", "", 0, false},
+		{"/site/about", "/about", 301, true},
+		{"/go/contact", "contact", 302, true},
+		{"/go/https://evil.com", "", 0, false},
+		{"/go///evil.com", "", 0, false},
+		{"/bad-code", "/target", 301, true},
+		{"/disabled", "", 0, false},
+		{"/error", "", 0, false},
+		{"/about", "", 0, false},
+	}
+
+	for _, tc := range testCases {
+		target, code, ok := store.Match(tc.path)
+		if ok != tc.expectedMatch || target != tc.expectedTarget || code != tc.expectedCode {
+			t.Errorf("Match(%q): expected %q %d %v, got %q %d %v", tc.path, tc.expectedTarget, tc.expectedCode, tc.expectedMatch, target, code, ok)
+		}
+	}
+}
+
+func TestStoreRefreshErrorKeepsRules(t *testing.T) {
+	fail := false
+	store := NewStore(func(ctx context.Context) ([]models.WordPressRedirect, error) {
+		if fail {
+			return nil, errors.New("upstream error")
+		}
+		return []models.WordPressRedirect{newRedirect("/old", "/new", 301, false)}, nil
+	}, "", 0)
+
+	if err := store.Refresh(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	fail = true
+	if err := store.Refresh(context.Background()); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if _, _, ok := store.Match("/old"); !ok {
+		t.Error("Expected rules to be kept after a failed refresh")
+	}
+}
+
+func TestStoreBackgroundRefresh(t *testing.T) {
+	var fetches atomic.Int32
+	store := NewStore(func(ctx context.Context) ([]models.WordPressRedirect, error) {
+		fetches.Add(1)
+		return nil, nil
+	}, "", time.Minute)
+
+	store.Refresh(context.Background())
+	store.Match("/")
+	if fetches.Load() != 1 {
+		t.Errorf("Expected no refresh while rules are fresh, got %d fetches", fetches.Load())
+	}
+
+	store.mu.Lock()
+	store.refreshed = time.Now().Add(-2 * time.Minute)
+	store.mu.Unlock()
+	store.Match("/")
+
+	deadline := time.Now().Add(time.Second)
+	for fetches.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if fetches.Load() != 2 {
+		t.Errorf("Expected a background refresh, got %d fetches", fetches.Load())
+	}
+}
@@ -0,0 +1,265 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// LangParam is the parameter holding the language of a localized route.
+const LangParam = "lang"
+
+// segmentKind is the kind of a pattern segment.  Kinds are ordered from
+// most to least specific.
+type segmentKind int
+
+const (
+	literalSegment segmentKind = iota
+	paramSegment
+	wildcardSegment
+)
+
+// segment is one slash separated part of a pattern.
+type segment struct {
+	kind  segmentKind
+	value string
+}
+
+// Pattern is a parsed route pattern.  Patterns are made of slash separated
+// segments, each of which is either a literal, a {name} parameter matching
+// one path segment, or a final {name...} wildcard matching the rest of the
+// path, possibly empty.  Trailing slashes are not significant, so /events/
+// and /events match the same paths.
+type Pattern struct {
+	raw      string
+	segments []segment
+}
+
+// Parse parses a route pattern.
+func Parse(pattern string) (*Pattern, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, fmt.Errorf("pattern must start with a slash: %q", pattern)
+	}
+
+	p := &Pattern{raw: pattern}
+	seen := make(map[string]bool)
+	parts := splitPath(pattern)
+	for i, part := range parts {
+		name, isParam := strings.CutPrefix(part, "{")
+		if !isParam {
+			if strings.ContainsAny(part, "{}") {
+				return nil, fmt.Errorf("invalid segment %q in pattern %q", part, pattern)
+			}
+			p.segments = append(p.segments, segment{kind: literalSegment, value: part})
+			continue
+		}
+
+		name, ok := strings.CutSuffix(name, "}")
+		if !ok {
+			return nil, fmt.Errorf("invalid segment %q in pattern %q", part, pattern)
+		}
+		kind := paramSegment
+		if wildcard, ok := strings.CutSuffix(name, "..."); ok {
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("wildcard must be the last segment of pattern %q", pattern)
+			}
+			name, kind = wildcard, wildcardSegment
+		}
+		if name == "" || strings.ContainsAny(name, "{}.") {
+			return nil, fmt.Errorf("invalid parameter name %q in pattern %q", name, pattern)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate parameter %q in pattern %q", name, pattern)
+		}
+		seen[name] = true
+		p.segments = append(p.segments, segment{kind: kind, value: name})
+	}
+	return p, nil
+}
+
+// MustParse parses a route pattern, panicking if it is invalid.  It is
+// meant for patterns known when the program is written.
+func MustParse(pattern string) *Pattern {
+	p, err := Parse(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the pattern as it was written.
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// Params holds the parameters extracted from a path, keyed by name.
+type Params map[string]string
+
+// Match reports whether a path matches the pattern, returning the values
+// of its parameters.
+func (p *Pattern) Match(path string) (Params, bool) {
+	parts := splitPath(path)
+	params := make(Params)
+	for i, seg := range p.segments {
+		if seg.kind == wildcardSegment {
+			params[seg.value] = strings.Join(parts[i:], "/")
+			return params, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		switch seg.kind {
+		case literalSegment:
+			if parts[i] != seg.value {
+				return nil, false
+			}
+		case paramSegment:
+			if parts[i] == "" {
+				return nil, false
+			}
+			params[seg.value] = parts[i]
+		}
+	}
+	if len(parts) != len(p.segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+// MatchLocalized matches a path served in any language of the registry.
+// Paths starting with the code of a language other than the default are
+// matched without their language prefix, and the language is returned in
+// the LangParam parameter along with those of the pattern.
+func (p *Pattern) MatchLocalized(path string) (Params, bool) {
+	lang, rest := splitLang(path)
+	params, ok := p.Match(rest)
+	if !ok {
+		return nil, false
+	}
+	params[LangParam] = lang
+	return params, true
+}
+
+// splitLang returns the language a path is served in and the path without
+// its language prefix.
+func splitLang(path string) (string, string) {
+	lang := models.Languages.FromPath(path)
+	if lang.Code == models.Languages.Default().Code {
+		return lang.Code, path
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(path, "/"), lang.Code)
+	if rest == "" {
+		rest = "/"
+	}
+	return lang.Code, rest
+}
+
+// splitPath returns the segments of a path, ignoring its leading and
+// trailing slashes.
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// route is a handler registered with a router.
+type route struct {
+	pattern   *Pattern
+	localized bool
+	handler   http.Handler
+}
+
+// Router dispatches requests to the handler of the most specific pattern
+// matching their path.  At each segment, literals are more specific than
+// parameters, which are more specific than wildcards.  Parameters are made
+// available to handlers through http.Request.PathValue.
+type Router struct {
+	// NotFound handles requests that match no route.  If nil,
+	// http.NotFound is used.
+	NotFound http.Handler
+
+	routes []route
+}
+
+// New creates an empty router.
+func New() *Router {
+	return &Router{}
+}
+
+// Handle registers a handler for a pattern.  It panics if the pattern is
+// invalid, like http.ServeMux.
+func (rt *Router) Handle(pattern string, handler http.Handler) {
+	rt.add(route{pattern: MustParse(pattern), handler: handler})
+}
+
+// HandleFunc registers a handler function for a pattern.
+func (rt *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	rt.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// HandleLocalized registers a handler for a pattern served in every
+// language: at the pattern itself for the default language and under the
+// /<code> prefix of every other language.  The language is available to
+// the handler as the LangParam path value.
+func (rt *Router) HandleLocalized(pattern string, handler http.Handler) {
+	rt.add(route{pattern: MustParse(pattern), localized: true, handler: handler})
+}
+
+// add registers a route, keeping routes ordered from most to least
+// specific.  Routes that are equally specific keep their registration
+// order.
+func (rt *Router) add(r route) {
+	rt.routes = append(rt.routes, r)
+	sort.SliceStable(rt.routes, func(i, j int) bool {
+		return moreSpecific(rt.routes[i].pattern, rt.routes[j].pattern)
+	})
+}
+
+// moreSpecific reports whether pattern a should be tried before b.
+func moreSpecific(a *Pattern, b *Pattern) bool {
+	for i := 0; i < len(a.segments) && i < len(b.segments); i++ {
+		if a.segments[i].kind != b.segments[i].kind {
+			return a.segments[i].kind < b.segments[i].kind
+		}
+	}
+	return len(a.segments) > len(b.segments)
+}
+
+// Lookup returns the handler of the most specific route matching a path
+// along with the parameters extracted from the path.
+func (rt *Router) Lookup(path string) (http.Handler, Params, bool) {
+	for _, r := range rt.routes {
+		match := r.pattern.Match
+		if r.localized {
+			match = r.pattern.MatchLocalized
+		}
+		if params, ok := match(path); ok {
+			return r.handler, params, true
+		}
+	}
+	return nil, nil, false
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler, params, ok := rt.Lookup(r.URL.Path)
+	if !ok {
+		log.Printf("No route for path: %s", r.URL.Path)
+		if rt.NotFound != nil {
+			rt.NotFound.ServeHTTP(w, r)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+	for name, value := range params {
+		r.SetPathValue(name, value)
+	}
+	handler.ServeHTTP(w, r)
+}
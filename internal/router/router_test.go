@@ -0,0 +1,136 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, pattern := range []string{"/", "/search", "/events/{slug}", "/{slug...}", "/{a}/{b}/{rest...}"} {
+		if _, err := Parse(pattern); err != nil {
+			t.Errorf("Parse(%q): expected no error, got %v", pattern, err)
+		}
+	}
+
+	for _, pattern := range []string{"search", "/{slug", "/a{b}", "/{}", "/{rest...}/more", "/{a}/{a}", "/{a.b}"} {
+		if _, err := Parse(pattern); err == nil {
+			t.Errorf("Parse(%q): expected error", pattern)
+		}
+	}
+}
+
+func TestPatternMatch(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		path     string
+		expected Params
+		ok       bool
+	}{
+		{pattern: "/", path: "/", expected: Params{}, ok: true},
+		{pattern: "/", path: "/about", ok: false},
+		{pattern: "/search", path: "/search", expected: Params{}, ok: true},
+		{pattern: "/search", path: "/search/", expected: Params{}, ok: true},
+		{pattern: "/search", path: "/searching", ok: false},
+		{pattern: "/events/{slug}", path: "/events/launch", expected: Params{"slug": "launch"}, ok: true},
+		{pattern: "/events/{slug}", path: "/events/", ok: false},
+		{pattern: "/events/{slug}", path: "/events/launch/more", ok: false},
+		{pattern: "/events/{slug}", path: "/events//", ok: false},
+		{pattern: "/{slug...}", path: "/", expected: Params{"slug": ""}, ok: true},
+		{pattern: "/{slug...}", path: "/services/benefits/", expected: Params{"slug": "services/benefits"}, ok: true},
+		{pattern: "/events/{path...}", path: "/events", expected: Params{"path": ""}, ok: true},
+	}
+
+	for _, tc := range testCases {
+		params, ok := MustParse(tc.pattern).Match(tc.path)
+		if ok != tc.ok || !reflect.DeepEqual(params, tc.expected) {
+			t.Errorf("%s.Match(%q): expected %v, %v, got %v, %v", tc.pattern, tc.path, tc.expected, tc.ok, params, ok)
+		}
+	}
+}
+
+func TestPatternMatchLocalized(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		path     string
+		expected Params
+		ok       bool
+	}{
+		{pattern: "/{slug...}", path: "/", expected: Params{"lang": "en", "slug": ""}, ok: true},
+		{pattern: "/{slug...}", path: "/about", expected: Params{"lang": "en", "slug": "about"}, ok: true},
+		{pattern: "/{slug...}", path: "/fr", expected: Params{"lang": "fr", "slug": ""}, ok: true},
+		{pattern: "/{slug...}", path: "/fr/a-propos", expected: Params{"lang": "fr", "slug": "a-propos"}, ok: true},
+		{pattern: "/{slug...}", path: "/en/about", expected: Params{"lang": "en", "slug": "en/about"}, ok: true},
+		{pattern: "/{slug...}", path: "/french", expected: Params{"lang": "en", "slug": "french"}, ok: true},
+		{pattern: "/feed.xml", path: "/fr/feed.xml", expected: Params{"lang": "fr"}, ok: true},
+		{pattern: "/feed.xml", path: "/de/feed.xml", ok: false},
+	}
+
+	for _, tc := range testCases {
+		params, ok := MustParse(tc.pattern).MatchLocalized(tc.path)
+		if ok != tc.ok || !reflect.DeepEqual(params, tc.expected) {
+			t.Errorf("%s.MatchLocalized(%q): expected %v, %v, got %v, %v", tc.pattern, tc.path, tc.expected, tc.ok, params, ok)
+		}
+	}
+}
+
+// TestRouter tests that requests are dispatched to the most specific route
+// with their parameters
+func TestRouter(t *testing.T) {
+	named := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name+" "+r.PathValue(LangParam)+" "+r.PathValue("slug"))
+		})
+	}
+
+	rt := New()
+	rt.HandleLocalized("/{slug...}", named("page"))
+	rt.Handle("/search", named("search"))
+	rt.Handle("/fr/recherche", named("search"))
+	rt.HandleLocalized("/events/{slug}", named("event"))
+	rt.HandleLocalized("/events", named("archive"))
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"/", "page en "},
+		{"/about/team", "page en about/team"},
+		{"/fr/a-propos", "page fr a-propos"},
+		{"/search", "search  "},
+		{"/fr/recherche", "search  "},
+		{"/events/", "archive en "},
+		{"/fr/events/launch", "event fr launch"},
+		{"/events/launch/more", "page en events/launch/more"},
+	}
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if got := w.Body.String(); got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.path, tc.expected, got)
+		}
+	}
+}
+
+// TestRouterNotFound tests requests that match no route
+func TestRouterNotFound(t *testing.T) {
+	rt := New()
+	rt.Handle("/search", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/about", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	rt.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/about", nil))
+	if w.Code != http.StatusGone {
+		t.Errorf("Expected custom not found handler, got %d", w.Code)
+	}
+}
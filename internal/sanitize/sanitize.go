@@ -0,0 +1,52 @@
+package sanitize
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// Sanitizer removes unsafe markup from WordPress content using an allowlist
+// of elements and attributes.  Scripts, styles and iframes are stripped,
+// except for iframes embedding an allowed host.
+type Sanitizer struct {
+	policy *bluemonday.Policy
+}
+
+// New creates a sanitizer that allows the common content markup produced
+// by the WordPress block editor.  embedHosts are the hosts iframes may
+// embed over https, for example "www.youtube-nocookie.com".
+func New(embedHosts []string) *Sanitizer {
+	policy := bluemonday.UGCPolicy()
+
+	// Links point within the site so do not need rel="nofollow"
+	policy.RequireNoFollowOnLinks(false)
+
+	// Block editor layout and accessibility attributes
+	policy.AllowAttrs("class", "id", "lang", "dir", "title").Globally()
+	policy.AllowAttrs("aria-label", "aria-describedby", "aria-hidden", "role").Globally()
+	policy.AllowStyles("text-align", "flex-basis", "width", "height").Globally()
+	policy.AllowElements("figure", "figcaption", "picture", "source", "details", "summary", "mark")
+	policy.AllowAttrs("srcset", "sizes", "loading", "decoding").OnElements("img")
+	policy.AllowAttrs("srcset", "sizes", "type", "media").OnElements("source")
+
+	if len(embedHosts) > 0 {
+		hosts := make([]string, len(embedHosts))
+		for i, host := range embedHosts {
+			hosts[i] = regexp.QuoteMeta(strings.ToLower(host))
+		}
+		src := regexp.MustCompile(`^https://(` + strings.Join(hosts, "|") + `)/`)
+
+		policy.AllowElements("iframe")
+		policy.AllowAttrs("src").Matching(src).OnElements("iframe")
+		policy.AllowAttrs("width", "height", "title", "loading", "allow", "allowfullscreen").OnElements("iframe")
+	}
+
+	return &Sanitizer{policy: policy}
+}
+
+// Sanitize returns content with all markup outside the allowlist removed.
+func (s *Sanitizer) Sanitize(content string) string {
+	return s.policy.Sanitize(content)
+}
@@ -0,0 +1,84 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	sanitizer := New([]string{"www.youtube-nocookie.com"})
+
+	testCases := []struct {
+		name       string
+		content    string
+		contains   []string
+		notContain []string
+	}{
+		{
+			name:       "Script removed",
+			content:    `<p>Hello</p><script>alert(1)</script>`,
+			contains:   []string{"<p>Hello</p>"},
+			notContain: []string{"script", "alert"},
+		},
+		{
+			name:       "Style element removed",
+			content:    `<style>body{display:none}</style><p>Text</p>`,
+			contains:   []string{"<p>Text</p>"},
+			notContain: []string{"display:none"},
+		},
+		{
+			name:       "Event handlers removed",
+			content:    `<img src="/wp-content/uploads/a.jpg" alt="A" onerror="alert(1)">`,
+			contains:   []string{`src="/wp-content/uploads/a.jpg"`, `alt="A"`},
+			notContain: []string{"onerror"},
+		},
+		{
+			name:       "JavaScript links removed",
+			content:    `<a href="javascript:alert(1)">Click</a>`,
+			notContain: []string{"javascript"},
+		},
+		{
+			name:     "Content markup kept",
+			content:  `<figure class="wp-block-image"><img src="/a.jpg" srcset="/a.jpg 1x" width="300"><figcaption>Caption</figcaption></figure><p style="text-align:center" id="intro">Intro</p>`,
+			contains: []string{`<figure class="wp-block-image">`, `srcset="/a.jpg 1x"`, "<figcaption>Caption</figcaption>", `style="text-align: center"`, `id="intro"`},
+		},
+		{
+			name:     "Links are not nofollow",
+			content:  `<a href="/about">About</a>`,
+			contains: []string{`<a href="/about">About</a>`},
+		},
+		{
+			name:     "Allowed embed kept",
+			content:  `<iframe src="https://www.youtube-nocookie.com/embed/abc" title="Video" allowfullscreen></iframe>`,
+			contains: []string{`src="https://www.youtube-nocookie.com/embed/abc"`, `title="Video"`},
+		},
+		{
+			name:       "Other embeds lose their source",
+			content:    `<iframe src="https://evil.example.com/embed"></iframe><iframe src="http://www.youtube-nocookie.com/embed/abc"></iframe>`,
+			notContain: []string{"evil.example.com", "http://www.youtube-nocookie.com"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := sanitizer.Sanitize(tc.content)
+			for _, expected := range tc.contains {
+				if !strings.Contains(got, expected) {
+					t.Errorf("Expected %q to contain %q", got, expected)
+				}
+			}
+			for _, unexpected := range tc.notContain {
+				if strings.Contains(got, unexpected) {
+					t.Errorf("Expected %q not to contain %q", got, unexpected)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeWithoutEmbeds(t *testing.T) {
+	got := New(nil).Sanitize(`<iframe src="https://www.youtube-nocookie.com/embed/abc"></iframe><p>Text</p>`)
+	if strings.Contains(got, "iframe") {
+		t.Errorf("Expected iframe to be removed, got %q", got)
+	}
+}
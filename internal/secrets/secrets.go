@@ -0,0 +1,225 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Services that secrets can be referenced from, as named in their ARNs.
+const (
+	ServiceSecretsManager = "secretsmanager"
+	ServiceSSM            = "ssm"
+)
+
+// Reference is a secret stored in AWS Secrets Manager or SSM Parameter
+// Store, given by its ARN.  Secrets Manager ARNs may be followed by the
+// JSON key, version stage and version ID of the value to use, separated
+// by colons as in ECS task definitions:
+//
+//	arn:aws:secretsmanager:ca-central-1:123456789012:secret:wordpress-AbCdEf:password::
+type Reference struct {
+	ARN          string
+	Service      string
+	JSONKey      string
+	VersionStage string
+	VersionID    string
+}
+
+// secretARNFields is the number of colon separated fields of a Secrets
+// Manager secret ARN, before the optional JSON key and version.
+const secretARNFields = 7
+
+// ParseReference parses a secret reference, reporting whether the value
+// is the ARN of a Secrets Manager secret or SSM parameter.
+func ParseReference(value string) (Reference, bool) {
+	fields := strings.Split(value, ":")
+	if len(fields) < 6 || fields[0] != "arn" || fields[2] == "" || fields[5] == "" {
+		return Reference{}, false
+	}
+
+	switch fields[2] {
+	case ServiceSecretsManager:
+		if len(fields) < secretARNFields || fields[5] != "secret" || fields[6] == "" || len(fields) > secretARNFields+3 {
+			return Reference{}, false
+		}
+		ref := Reference{
+			ARN:     strings.Join(fields[:secretARNFields], ":"),
+			Service: ServiceSecretsManager,
+		}
+		extra := append(fields[secretARNFields:], "", "", "")
+		ref.JSONKey, ref.VersionStage, ref.VersionID = extra[0], extra[1], extra[2]
+		return ref, true
+	case ServiceSSM:
+		if len(fields) != 6 || !strings.HasPrefix(fields[5], "parameter/") {
+			return Reference{}, false
+		}
+		return Reference{ARN: value, Service: ServiceSSM}, true
+	}
+	return Reference{}, false
+}
+
+// IsReference reports whether a value is a secret reference rather than
+// the secret itself.
+func IsReference(value string) bool {
+	_, ok := ParseReference(value)
+	return ok
+}
+
+// Fetcher retrieves the current value of a secret.
+type Fetcher func(ctx context.Context, ref Reference) (string, error)
+
+// SecretsManagerAPI is the part of the Secrets Manager client used to
+// fetch secrets.
+type SecretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// SecretsManagerFetcher returns a fetcher that reads secrets from Secrets
+// Manager.  A JSON key selects one value of a secret holding a JSON object.
+func SecretsManagerFetcher(client SecretsManagerAPI) Fetcher {
+	return func(ctx context.Context, ref Reference) (string, error) {
+		input := &secretsmanager.GetSecretValueInput{SecretId: aws.String(ref.ARN)}
+		if ref.VersionStage != "" {
+			input.VersionStage = aws.String(ref.VersionStage)
+		}
+		if ref.VersionID != "" {
+			input.VersionId = aws.String(ref.VersionID)
+		}
+		out, err := client.GetSecretValue(ctx, input)
+		if err != nil {
+			return "", err
+		}
+		if out.SecretString == nil {
+			return "", fmt.Errorf("secret has no string value: %s", ref.ARN)
+		}
+		if ref.JSONKey == "" {
+			return *out.SecretString, nil
+		}
+
+		var values map[string]any
+		if err := json.Unmarshal([]byte(*out.SecretString), &values); err != nil {
+			return "", fmt.Errorf("secret is not a JSON object: %s", ref.ARN)
+		}
+		value, ok := values[ref.JSONKey].(string)
+		if !ok {
+			return "", fmt.Errorf("secret has no %q key: %s", ref.JSONKey, ref.ARN)
+		}
+		return value, nil
+	}
+}
+
+// SSMAPI is the part of the SSM client used to fetch parameters.
+type SSMAPI interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// SSMFetcher returns a fetcher that reads parameters from SSM Parameter
+// Store, decrypting SecureString parameters.
+func SSMFetcher(client SSMAPI) Fetcher {
+	return func(ctx context.Context, ref Reference) (string, error) {
+		out, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+			Name:           aws.String(ref.ARN),
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return "", err
+		}
+		if out.Parameter == nil || out.Parameter.Value == nil {
+			return "", fmt.Errorf("parameter has no value: %s", ref.ARN)
+		}
+		return *out.Parameter.Value, nil
+	}
+}
+
+// Store resolves secret references, caching their values so that each
+// secret is only fetched once.  Refresh fetches them again so that rotated
+// secrets are picked up.
+type Store struct {
+	Fetchers map[string]Fetcher
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// NewStore creates a store fetching secrets with a fetcher for each
+// service, keyed by ServiceSecretsManager or ServiceSSM.
+func NewStore(fetchers map[string]Fetcher) *Store {
+	return &Store{
+		Fetchers: fetchers,
+		values:   make(map[string]string),
+	}
+}
+
+// Resolve returns the value of a secret reference.  Values that are not
+// references are returned unchanged.
+func (s *Store) Resolve(ctx context.Context, value string) (string, error) {
+	ref, ok := ParseReference(value)
+	if !ok {
+		return value, nil
+	}
+
+	s.mu.Lock()
+	secret, found := s.values[value]
+	s.mu.Unlock()
+	if found {
+		return secret, nil
+	}
+
+	secret, err := s.fetch(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.values[value] = secret
+	s.mu.Unlock()
+	return secret, nil
+}
+
+// Refresh fetches every resolved secret again.  A secret that fails to
+// refresh keeps its cached value, and the first error is returned.
+func (s *Store) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	values := make([]string, 0, len(s.values))
+	for value := range s.values {
+		values = append(values, value)
+	}
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, value := range values {
+		ref, _ := ParseReference(value)
+		secret, err := s.fetch(ctx, ref)
+		if err != nil {
+			log.Printf("Error refreshing secret, keeping cached value: %v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		s.mu.Lock()
+		s.values[value] = secret
+		s.mu.Unlock()
+	}
+	return firstErr
+}
+
+// fetch retrieves a secret with the fetcher of its service.
+func (s *Store) fetch(ctx context.Context, ref Reference) (string, error) {
+	fetch, ok := s.Fetchers[ref.Service]
+	if !ok {
+		return "", fmt.Errorf("no fetcher for %s secret: %s", ref.Service, ref.ARN)
+	}
+	secret, err := fetch(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("error fetching secret %s: %w", ref.ARN, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,174 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+const secretARN = "arn:aws:secretsmanager:ca-central-1:123456789012:secret:wordpress-AbCdEf"
+
+// fakeSecretsManager returns a single secret string
+type fakeSecretsManager struct {
+	input  *secretsmanager.GetSecretValueInput
+	secret string
+	err    error
+}
+
+func (f *fakeSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	f.input = params
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(f.secret)}, nil
+}
+
+// fakeSSM returns a single parameter value
+type fakeSSM struct {
+	input *ssm.GetParameterInput
+	value string
+}
+
+func (f *fakeSSM) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	f.input = params
+	return &ssm.GetParameterOutput{Parameter: &types.Parameter{Value: aws.String(f.value)}}, nil
+}
+
+func TestParseReference(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected Reference
+		ok       bool
+	}{
+		{
+			value:    secretARN,
+			expected: Reference{ARN: secretARN, Service: ServiceSecretsManager},
+			ok:       true,
+		},
+		{
+			value:    secretARN + ":password::",
+			expected: Reference{ARN: secretARN, Service: ServiceSecretsManager, JSONKey: "password"},
+			ok:       true,
+		},
+		{
+			value:    secretARN + ":password:AWSPREVIOUS:",
+			expected: Reference{ARN: secretARN, Service: ServiceSecretsManager, JSONKey: "password", VersionStage: "AWSPREVIOUS"},
+			ok:       true,
+		},
+		{
+			value:    "arn:aws:ssm:ca-central-1:123456789012:parameter/wordpress/password",
+			expected: Reference{ARN: "arn:aws:ssm:ca-central-1:123456789012:parameter/wordpress/password", Service: ServiceSSM},
+			ok:       true,
+		},
+		{value: "hunter2"},
+		{value: ""},
+		{value: "arn:aws:s3:::bucket/key"},
+		{value: "arn:aws:secretsmanager:ca-central-1:123456789012:secret"},
+		{value: "arn:aws:ssm:ca-central-1:123456789012:document/name"},
+		{value: secretARN + ":a:b:c:d"},
+	}
+
+	for _, tc := range testCases {
+		ref, ok := ParseReference(tc.value)
+		if ok != tc.ok || ref != tc.expected {
+			t.Errorf("ParseReference(%q): expected %+v, %v, got %+v, %v", tc.value, tc.expected, tc.ok, ref, ok)
+		}
+	}
+}
+
+func TestSecretsManagerFetcher(t *testing.T) {
+	client := &fakeSecretsManager{secret: `{"username":"proxy","password":"s3cret"}`}
+	fetch := SecretsManagerFetcher(client)
+
+	ref, _ := ParseReference(secretARN + ":password:AWSCURRENT:")
+	value, err := fetch(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if value != "s3cret" {
+		t.Errorf("Expected password from JSON secret, got %q", value)
+	}
+	if aws.ToString(client.input.SecretId) != secretARN || aws.ToString(client.input.VersionStage) != "AWSCURRENT" || client.input.VersionId != nil {
+		t.Errorf("Unexpected request %+v", client.input)
+	}
+
+	ref, _ = ParseReference(secretARN)
+	if value, _ := fetch(context.Background(), ref); value != client.secret {
+		t.Errorf("Expected whole secret, got %q", value)
+	}
+
+	ref, _ = ParseReference(secretARN + ":token::")
+	if _, err := fetch(context.Background(), ref); err == nil {
+		t.Error("Expected error for missing JSON key")
+	}
+}
+
+func TestSSMFetcher(t *testing.T) {
+	client := &fakeSSM{value: "s3cret"}
+	ref, _ := ParseReference("arn:aws:ssm:ca-central-1:123456789012:parameter/wordpress/password")
+
+	value, err := SSMFetcher(client)(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if value != "s3cret" {
+		t.Errorf("Expected parameter value, got %q", value)
+	}
+	if aws.ToString(client.input.Name) != ref.ARN || !aws.ToBool(client.input.WithDecryption) {
+		t.Errorf("Expected decrypted parameter by ARN, got %+v", client.input)
+	}
+}
+
+func TestStore(t *testing.T) {
+	fetches := 0
+	secret := "first"
+	var fetchErr error
+	store := NewStore(map[string]Fetcher{
+		ServiceSecretsManager: func(ctx context.Context, ref Reference) (string, error) {
+			fetches++
+			return secret, fetchErr
+		},
+	})
+
+	if value, err := store.Resolve(context.Background(), "plain"); err != nil || value != "plain" {
+		t.Errorf("Expected plain value unchanged, got %q, %v", value, err)
+	}
+
+	for range 2 {
+		value, err := store.Resolve(context.Background(), secretARN)
+		if err != nil || value != "first" {
+			t.Errorf("Expected first secret, got %q, %v", value, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Expected secret to be cached, got %d fetches", fetches)
+	}
+
+	// Rotated secrets are picked up on refresh
+	secret = "second"
+	if err := store.Refresh(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if value, _ := store.Resolve(context.Background(), secretARN); value != "second" {
+		t.Errorf("Expected rotated secret, got %q", value)
+	}
+
+	// Failed refreshes keep the cached value
+	fetchErr = errors.New("throttled")
+	secret = ""
+	if err := store.Refresh(context.Background()); err == nil {
+		t.Error("Expected refresh error")
+	}
+	if value, _ := store.Resolve(context.Background(), secretARN); value != "second" {
+		t.Errorf("Expected cached secret after failed refresh, got %q", value)
+	}
+
+	if _, err := store.Resolve(context.Background(), "arn:aws:ssm:ca-central-1:123456789012:parameter/other"); err == nil {
+		t.Error("Expected error for a service without a fetcher")
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Options control how the server drains connections when it stops.
+type Options struct {
+	// ShutdownDelay is how long the server keeps serving after it is asked
+	// to stop, giving load balancers time to notice it is draining.
+	ShutdownDelay time.Duration
+
+	// ShutdownTimeout is how long requests in flight are given to
+	// complete before their connections are closed.
+	ShutdownTimeout time.Duration
+
+	// OnShutdown is called as soon as the server is asked to stop, before
+	// the shutdown delay.  It is typically used to fail readiness checks.
+	OnShutdown func()
+}
+
+// DefaultOptions stop the server without a delay and give requests in
+// flight ten seconds to complete.
+var DefaultOptions = Options{
+	ShutdownTimeout: 10 * time.Second,
+}
+
+// Serve runs an HTTP server until it fails or the context is cancelled.
+// On cancellation the server stops accepting connections and waits for
+// requests in flight to complete before returning.  Connections still
+// active after the shutdown timeout are closed and an error is returned.
+func Serve(ctx context.Context, srv *http.Server, opts Options) error {
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %s", srv.Addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down server")
+	srv.SetKeepAlivesEnabled(false)
+	if opts.OnShutdown != nil {
+		opts.OnShutdown()
+	}
+	if opts.ShutdownDelay > 0 {
+		log.Printf("Draining connections for %s", opts.ShutdownDelay)
+		time.Sleep(opts.ShutdownDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Requests still in flight after %s, closing connections", opts.ShutdownTimeout)
+		if closeErr := srv.Close(); closeErr != nil {
+			log.Printf("Error closing server: %v", closeErr)
+		}
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Printf("Server stopped")
+	return nil
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local address that is free to listen on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// waitForServer polls the server until it accepts connections
+func waitForServer(t *testing.T, addr string) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Server did not start on %s", addr)
+}
+
+// waitForError waits for Serve to return
+func waitForError(t *testing.T, done chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not shut down")
+		return nil
+	}
+}
+
+func TestServe(t *testing.T) {
+	addr := freeAddr(t)
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}),
+	}
+
+	var shutdownCalled atomic.Bool
+	opts := DefaultOptions
+	opts.OnShutdown = func() { shutdownCalled.Store(true) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, srv, opts) }()
+	waitForServer(t, addr)
+
+	resp, err := http.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatalf("Error making request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Errorf("Expected body ok, got %q", body)
+	}
+
+	cancel()
+	if err := waitForError(t, done); err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+	if !shutdownCalled.Load() {
+		t.Error("Expected OnShutdown to be called")
+	}
+	if _, err := http.Get("http://" + addr + "/"); err == nil {
+		t.Error("Expected server to stop accepting connections")
+	}
+}
+
+func TestServeCompletesInFlightRequests(t *testing.T) {
+	addr := freeAddr(t)
+	started := make(chan struct{})
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("done"))
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, srv, Options{ShutdownTimeout: 5 * time.Second}) }()
+	waitForServer(t, addr)
+
+	type result struct {
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/")
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		results <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	res := <-results
+	if res.err != nil || res.body != "done" {
+		t.Errorf("Expected in-flight request to complete, got %q, %v", res.body, res.err)
+	}
+	if err := waitForError(t, done); err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+}
+
+func TestServeShutdownTimeout(t *testing.T) {
+	addr := freeAddr(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, srv, Options{ShutdownTimeout: 50 * time.Millisecond}) }()
+	waitForServer(t, addr)
+
+	go func() {
+		resp, err := http.Get("http://" + addr + "/")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	<-started
+	cancel()
+	if err := waitForError(t, done); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestServeShutdownDelay(t *testing.T) {
+	addr := freeAddr(t)
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}),
+	}
+
+	shutdown := make(chan struct{})
+	opts := Options{
+		ShutdownDelay:   300 * time.Millisecond,
+		ShutdownTimeout: time.Second,
+		OnShutdown:      func() { close(shutdown) },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, srv, opts) }()
+	waitForServer(t, addr)
+
+	cancel()
+	<-shutdown
+
+	// Requests are still served during the delay
+	resp, err := http.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatalf("Expected request during the shutdown delay to succeed, got %v", err)
+	}
+	resp.Body.Close()
+	if !resp.Close {
+		t.Error("Expected keep-alives to be disabled while draining")
+	}
+
+	if err := waitForError(t, done); err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+}
+
+func TestServeListenError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	srv := &http.Server{Addr: l.Addr().String()}
+	if err := Serve(context.Background(), srv, DefaultOptions); err == nil {
+		t.Error("Expected error listening on an address in use")
+	}
+}
@@ -0,0 +1,93 @@
+package theme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hintsFile is the file of a theme listing its resource hints.
+const hintsFile = "hints.json"
+
+// Hint is a resource hint for a page of the theme, such as a stylesheet or
+// font to preload or an origin to preconnect to.  Rel is one of preload,
+// modulepreload, preconnect or dns-prefetch, and As is the destination of
+// preloaded resources, such as "style" or "font".
+type Hint struct {
+	URL         string `json:"url"`
+	Rel         string `json:"rel"`
+	As          string `json:"as,omitempty"`
+	Type        string `json:"type,omitempty"`
+	CrossOrigin bool   `json:"crossorigin,omitempty"`
+}
+
+// Link returns the hint as the value of a Link header.
+func (h Hint) Link() string {
+	link := "<" + h.URL + ">; rel=" + h.Rel
+	if h.As != "" {
+		link += "; as=" + h.As
+	}
+	if h.Type != "" {
+		link += `; type="` + h.Type + `"`
+	}
+	if h.CrossOrigin {
+		link += "; crossorigin"
+	}
+	return link
+}
+
+// validate reports whether a hint can be sent.
+func (h Hint) validate() error {
+	if h.URL == "" || strings.ContainsAny(h.URL, "<>\r\n") {
+		return fmt.Errorf("invalid hint URL: %q", h.URL)
+	}
+	switch h.Rel {
+	case "preconnect", "dns-prefetch", "modulepreload":
+	case "preload":
+		if h.As == "" {
+			return fmt.Errorf("preload hint without as: %q", h.URL)
+		}
+	default:
+		return fmt.Errorf("invalid hint rel: %q", h.Rel)
+	}
+	if strings.ContainsAny(h.As+h.Type, `";,`) {
+		return fmt.Errorf("invalid hint for %q", h.URL)
+	}
+	return nil
+}
+
+// Hints loads the resource hints of the theme from its hints.json, a list
+// of hints.  Themes without one inherit the hints of the default theme,
+// since hints depend on the resources the theme's layout loads.
+func (t Theme) Hints() ([]Hint, error) {
+	names := []string{t.Name}
+	if t.Name != Default {
+		names = append(names, Default)
+	}
+	for _, name := range names {
+		file := filepath.Join(t.Dir, name, hintsFile)
+		data, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var hints []Hint
+		if err := json.Unmarshal(data, &hints); err != nil {
+			return nil, fmt.Errorf("invalid hints in %s: %w", file, err)
+		}
+		for _, hint := range hints {
+			if err := hint.validate(); err != nil {
+				return nil, fmt.Errorf("invalid hints in %s: %w", file, err)
+			}
+		}
+		return hints, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,69 @@
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// messagesDir is the subdirectory of a theme holding its messages, one
+// <lang>.json file per language mapping keys to text.
+const messagesDir = "messages"
+
+// Messages holds the text of a theme's interface, such as its labels and
+// notices, keyed by language and then by message key.
+type Messages map[string]map[string]string
+
+// Lookup returns the text of a message in a language, reporting false if
+// the language has no such message.
+func (m Messages) Lookup(lang string, key string) (string, bool) {
+	text, ok := m[lang][key]
+	return text, ok
+}
+
+// Messages loads the messages of the theme.  Like templates, messages are
+// inherited from the default theme, so a theme's message files only need
+// the messages it changes.
+func (t Theme) Messages() (Messages, error) {
+	messages := make(Messages)
+	names := []string{Default}
+	if t.Name != Default {
+		names = append(names, t.Name)
+	}
+	for _, name := range names {
+		files, err := filepath.Glob(filepath.Join(t.Dir, name, messagesDir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if err := messages.load(file); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return messages, nil
+}
+
+// load adds the messages of a language file, replacing those of the same
+// key.
+func (m Messages) load(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var texts map[string]string
+	if err := json.Unmarshal(data, &texts); err != nil {
+		return fmt.Errorf("invalid messages in %s: %w", file, err)
+	}
+
+	lang := strings.TrimSuffix(filepath.Base(file), ".json")
+	if m[lang] == nil {
+		m[lang] = make(map[string]string, len(texts))
+	}
+	for key, text := range texts {
+		m[lang][key] = text
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package theme
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// Default is the name of the theme every other theme inherits from.
+const Default = "default"
+
+// partialsDir is the subdirectory of a theme holding its partials, such as
+// the header, nav and footer.
+const partialsDir = "partials"
+
+// Theme is a named set of templates stored in a subdirectory of Dir.  A
+// theme only needs to contain the templates and partials it changes: any
+// file it does not provide is inherited from the default theme.  The
+// header, nav, breadcrumb and footer are each a partial, and a partial may
+// also define a block of the default layout, "head" or "main", to change
+// that section of pages alone.
+type Theme struct {
+	Dir  string
+	Name string
+}
+
+// New creates a theme loaded from dir/name.  An empty name selects the
+// default theme.
+func New(dir string, name string) Theme {
+	if name == "" {
+		name = Default
+	}
+	return Theme{Dir: dir, Name: name}
+}
+
+// Files returns the template files to parse for the given templates, such
+// as "layout.html", along with every partial.  Default theme files come
+// first so that files of the same name, and the templates they define,
+// are overridden by the selected theme when parsed in order.  A template
+// only provided by the selected theme is not looked for in the default.
+func (t Theme) Files(names ...string) ([]string, error) {
+	if t.Name == Default {
+		return t.themeFiles(Default, names)
+	}
+
+	dir := filepath.Join(t.Dir, t.Name)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("theme not found: %s", dir)
+	}
+
+	var inherited []string
+	for _, n := range names {
+		if !exists(filepath.Join(t.Dir, Default, n)) && exists(filepath.Join(dir, n)) {
+			continue
+		}
+		inherited = append(inherited, n)
+	}
+	files, err := t.themeFiles(Default, inherited)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := t.themeFiles(t.Name, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range overrides {
+		if exists(file) {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// Exists reports whether the theme, or the default theme it inherits from,
+// provides a template.
+func (t Theme) Exists(name string) bool {
+	return exists(filepath.Join(t.Dir, t.Name, name)) || exists(filepath.Join(t.Dir, Default, name))
+}
+
+// Glob returns the names of the templates of the theme, or of the default
+// theme it inherits from, matching a pattern, such as "page-*.html" for
+// its page templates.
+func (t Theme) Glob(pattern string) ([]string, error) {
+	var names []string
+	for _, name := range []string{Default, t.Name} {
+		matches, err := filepath.Glob(filepath.Join(t.Dir, name, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if base := filepath.Base(match); !slices.Contains(names, base) {
+				names = append(names, base)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// exists reports whether a file exists.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// themeFiles returns the paths of the named templates and the partials of
+// a theme.
+func (t Theme) themeFiles(name string, names []string) ([]string, error) {
+	dir := filepath.Join(t.Dir, name)
+
+	files := make([]string, 0, len(names))
+	for _, n := range names {
+		files = append(files, filepath.Join(dir, n))
+	}
+
+	partials, err := filepath.Glob(filepath.Join(dir, partialsDir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(partials)
+	return append(files, partials...), nil
+}
@@ -0,0 +1,336 @@
+package theme
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles creates files with the given contents under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// setupThemes creates a default theme and a theme that overrides the nav
+func setupThemes(t *testing.T) string {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"default/layout.html":          `{{template "header" .}}|{{template "footer" .}}`,
+		"default/partials/header.html": `{{define "header"}}header {{template "nav" .}}{{end}}`,
+		"default/partials/nav.html":    `{{define "nav"}}default nav{{end}}`,
+		"default/partials/footer.html": `{{define "footer"}}default footer{{end}}`,
+		"dark/partials/nav.html":       `{{define "nav"}}dark nav{{end}}`,
+		"wide/layout.html":             `wide {{template "header" .}}`,
+	})
+	return dir
+}
+
+func TestNew(t *testing.T) {
+	if theme := New("templates", ""); theme.Name != Default {
+		t.Errorf("Expected empty name to select the default theme, got %q", theme.Name)
+	}
+	if theme := New("templates", "dark"); theme.Dir != "templates" || theme.Name != "dark" {
+		t.Errorf("Expected templates/dark, got %+v", theme)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := setupThemes(t)
+
+	testCases := []struct {
+		name     string
+		theme    string
+		expected []string
+	}{
+		{
+			name:  "Default theme",
+			theme: Default,
+			expected: []string{
+				"default/layout.html",
+				"default/partials/footer.html",
+				"default/partials/header.html",
+				"default/partials/nav.html",
+			},
+		},
+		{
+			name:  "Theme overriding a partial",
+			theme: "dark",
+			expected: []string{
+				"default/layout.html",
+				"default/partials/footer.html",
+				"default/partials/header.html",
+				"default/partials/nav.html",
+				"dark/partials/nav.html",
+			},
+		},
+		{
+			name:  "Theme overriding the layout",
+			theme: "wide",
+			expected: []string{
+				"default/layout.html",
+				"default/partials/footer.html",
+				"default/partials/header.html",
+				"default/partials/nav.html",
+				"wide/layout.html",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := New(dir, tc.theme).Files("layout.html")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			expected := make([]string, len(tc.expected))
+			for i, file := range tc.expected {
+				expected[i] = filepath.Join(dir, file)
+			}
+			if !reflect.DeepEqual(files, expected) {
+				t.Errorf("Expected %v, got %v", expected, files)
+			}
+		})
+	}
+}
+
+func TestFilesMissingTheme(t *testing.T) {
+	dir := setupThemes(t)
+
+	if _, err := New(dir, "missing").Files("layout.html"); err == nil {
+		t.Error("Expected error for a missing theme")
+	}
+}
+
+func TestFilesInheritance(t *testing.T) {
+	dir := setupThemes(t)
+
+	testCases := []struct {
+		theme    string
+		expected string
+	}{
+		{Default, "header default nav|default footer"},
+		{"dark", "header dark nav|default footer"},
+		{"wide", "wide header default nav"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.theme, func(t *testing.T) {
+			files, err := New(dir, tc.theme).Files("layout.html")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			tmpl, err := template.ParseFiles(files...)
+			if err != nil {
+				t.Fatalf("Error parsing templates: %v", err)
+			}
+
+			var out bytes.Buffer
+			if err := tmpl.ExecuteTemplate(&out, "layout.html", nil); err != nil {
+				t.Fatalf("Error executing template: %v", err)
+			}
+			if out.String() != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestFilesBlocks(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"default/layout.html":              `{{template "header" .}}|{{block "main" .}}default main{{end}}`,
+		"default/partials/header.html":     `{{define "header"}}header {{template "breadcrumb" .}}{{end}}`,
+		"default/partials/breadcrumb.html": `{{define "breadcrumb"}}default breadcrumb{{end}}`,
+		"landing/partials/breadcrumb.html": `{{define "breadcrumb"}}landing breadcrumb{{end}}`,
+		"landing/partials/main.html":       `{{define "main"}}landing main{{end}}`,
+	})
+
+	testCases := []struct {
+		theme    string
+		expected string
+	}{
+		{Default, "header default breadcrumb|default main"},
+		{"landing", "header landing breadcrumb|landing main"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.theme, func(t *testing.T) {
+			files, err := New(dir, tc.theme).Files("layout.html")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			tmpl, err := template.ParseFiles(files...)
+			if err != nil {
+				t.Fatalf("Error parsing templates: %v", err)
+			}
+
+			var out bytes.Buffer
+			if err := tmpl.ExecuteTemplate(&out, "layout.html", nil); err != nil {
+				t.Fatalf("Error executing template: %v", err)
+			}
+			if out.String() != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestFilesOnlyInTheme(t *testing.T) {
+	dir := setupThemes(t)
+	writeFiles(t, dir, map[string]string{"dark/events.html": `dark events`})
+
+	files, err := New(dir, "dark").Files("layout.html", "events.html")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, file := range files {
+		if file == filepath.Join(dir, "default/events.html") {
+			t.Errorf("Expected missing default template to be skipped, got %v", files)
+		}
+	}
+	if last := files[len(files)-2]; last != filepath.Join(dir, "dark/events.html") {
+		t.Errorf("Expected dark/events.html, got %v", files)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := setupThemes(t)
+	writeFiles(t, dir, map[string]string{
+		"default/page-full-width.html": `full width`,
+		"dark/page-full-width.html":    `dark full width`,
+		"dark/page-landing.html":       `dark landing`,
+	})
+
+	testCases := []struct {
+		theme    string
+		expected []string
+	}{
+		{Default, []string{"page-full-width.html"}},
+		{"dark", []string{"page-full-width.html", "page-landing.html"}},
+		{"wide", []string{"page-full-width.html"}},
+	}
+
+	for _, tc := range testCases {
+		names, err := New(dir, tc.theme).Glob("page-*.html")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.theme, tc.expected, names)
+		}
+	}
+}
+
+func TestExists(t *testing.T) {
+	dir := setupThemes(t)
+	writeFiles(t, dir, map[string]string{"dark/events.html": `dark events`})
+
+	testCases := []struct {
+		theme    string
+		name     string
+		expected bool
+	}{
+		{"dark", "layout.html", true},
+		{"dark", "events.html", true},
+		{Default, "events.html", false},
+		{"wide", "events.html", false},
+	}
+
+	for _, tc := range testCases {
+		if got := New(dir, tc.theme).Exists(tc.name); got != tc.expected {
+			t.Errorf("Expected %s in %s to exist %v, got %v", tc.name, tc.theme, tc.expected, got)
+		}
+	}
+}
+
+func TestMessages(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"default/messages/en.json": `{"search.empty": "No results found.", "page.next": "Next page"}`,
+		"default/messages/fr.json": `{"search.empty": "Aucun résultat trouvé."}`,
+		"dark/messages/en.json":    `{"search.empty": "Nothing here."}`,
+	})
+
+	messages, err := New(dir, "dark").Messages()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	testCases := []struct {
+		lang     string
+		key      string
+		expected string
+		ok       bool
+	}{
+		{"en", "search.empty", "Nothing here.", true},
+		{"en", "page.next", "Next page", true},
+		{"fr", "search.empty", "Aucun résultat trouvé.", true},
+		{"fr", "page.next", "", false},
+		{"de", "search.empty", "", false},
+	}
+	for _, tc := range testCases {
+		if text, ok := messages.Lookup(tc.lang, tc.key); text != tc.expected || ok != tc.ok {
+			t.Errorf("Lookup(%q, %q): expected %q %v, got %q %v", tc.lang, tc.key, tc.expected, tc.ok, text, ok)
+		}
+	}
+
+	writeFiles(t, dir, map[string]string{"broken/messages/en.json": `{"search.empty": 1}`})
+	if _, err := New(dir, "broken").Messages(); err == nil {
+		t.Error("Expected an error for invalid messages")
+	}
+}
+
+func TestHints(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"default/hints.json": `[{"url": "https://cdn.example.com", "rel": "preconnect", "crossorigin": true}, {"url": "/static/font.woff2", "rel": "preload", "as": "font", "type": "font/woff2", "crossorigin": true}]`,
+		"dark/hints.json":    `[{"url": "/static/dark.css", "rel": "preload", "as": "style"}]`,
+		"light/layout.html":  ``,
+	})
+
+	hints, err := New(dir, "light").Hints()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{
+		`<https://cdn.example.com>; rel=preconnect; crossorigin`,
+		`</static/font.woff2>; rel=preload; as=font; type="font/woff2"; crossorigin`,
+	}
+	if len(hints) != len(expected) {
+		t.Fatalf("Expected the default theme's hints, got %v", hints)
+	}
+	for i, hint := range hints {
+		if hint.Link() != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], hint.Link())
+		}
+	}
+
+	hints, err = New(dir, "dark").Hints()
+	if err != nil || len(hints) != 1 || hints[0].Link() != `</static/dark.css>; rel=preload; as=style` {
+		t.Errorf("Expected the theme's own hints, got %v, %v", hints, err)
+	}
+
+	for name, hints := range map[string]string{
+		"invalid":    `{"url": "/a.css"}`,
+		"rel":        `[{"url": "/a.css", "rel": "stylesheet"}]`,
+		"preload":    `[{"url": "/a.css", "rel": "preload"}]`,
+		"injection":  `[{"url": "/a.css>; rel=preload", "rel": "preload", "as": "style"}]`,
+		"parameters": `[{"url": "/a.css", "rel": "preload", "as": "style; x=1"}]`,
+	} {
+		writeFiles(t, dir, map[string]string{name + "/hints.json": hints})
+		if _, err := New(dir, name).Hints(); err == nil {
+			t.Errorf("%s: expected an error for invalid hints", name)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package tracing
+
+import (
+	"crypto/tls"
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ConnStats counts how upstream requests got their connections, to tell
+// requests slowed by new connections, such as after a Lambda execution
+// environment was frozen, from requests slowed by the origin.  Each
+// request's connection is also recorded on its span.
+type ConnStats struct {
+	requests atomic.Int64
+	reused   atomic.Int64
+	idle     atomic.Int64
+	http2    atomic.Int64
+
+	// The number of each phase of new connections and the total
+	// nanoseconds spent in them.
+	dnsLookups atomic.Int64
+	dnsTime    atomic.Int64
+	connects   atomic.Int64
+	connTime   atomic.Int64
+	handshakes atomic.Int64
+	tlsTime    atomic.Int64
+}
+
+// ConnSnapshot is a copy of the counts of ConnStats.  New is the number
+// of requests that opened a connection, and Idle the number that reused
+// one from the idle pool.  The durations are the averages of the phases of
+// new connections.
+type ConnSnapshot struct {
+	Requests int64
+	Reused   int64
+	New      int64
+	Idle     int64
+	HTTP2    int64
+	DNS      time.Duration
+	Connect  time.Duration
+	TLS      time.Duration
+}
+
+// Transport wraps an HTTP transport so that requests are counted.  It
+// should be wrapped by Transport so that connections are recorded on the
+// spans of requests.
+func (s *ConnStats) Transport(base http.RoundTripper) http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		var dnsStart, tlsStart time.Time
+		// Connections to several addresses may be attempted at once
+		var connectMu sync.Mutex
+		var connectStart time.Time
+		ctx := req.Context()
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				s.requests.Add(1)
+				attrs := []attribute.KeyValue{attribute.Bool("http.conn.reused", info.Reused)}
+				if info.Reused {
+					s.reused.Add(1)
+				}
+				if info.WasIdle {
+					s.idle.Add(1)
+					attrs = append(attrs, attribute.Int64("http.conn.idle_ms", info.IdleTime.Milliseconds()))
+				}
+				Annotate(ctx, attrs...)
+			},
+			DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+			DNSDone: func(httptrace.DNSDoneInfo) {
+				elapsed := time.Since(dnsStart)
+				s.dnsLookups.Add(1)
+				s.dnsTime.Add(int64(elapsed))
+				Annotate(ctx, attribute.Int64("http.conn.dns_ms", elapsed.Milliseconds()))
+			},
+			ConnectStart: func(string, string) {
+				connectMu.Lock()
+				defer connectMu.Unlock()
+				if connectStart.IsZero() {
+					connectStart = time.Now()
+				}
+			},
+			ConnectDone: func(_ string, _ string, err error) {
+				if err != nil {
+					return
+				}
+				connectMu.Lock()
+				elapsed := time.Since(connectStart)
+				connectMu.Unlock()
+				s.connects.Add(1)
+				s.connTime.Add(int64(elapsed))
+				Annotate(ctx, attribute.Int64("http.conn.connect_ms", elapsed.Milliseconds()))
+			},
+			TLSHandshakeStart: func() { tlsStart = time.Now() },
+			TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+				if err != nil {
+					return
+				}
+				elapsed := time.Since(tlsStart)
+				s.handshakes.Add(1)
+				s.tlsTime.Add(int64(elapsed))
+				Annotate(ctx, attribute.Int64("http.conn.tls_ms", elapsed.Milliseconds()))
+			},
+		}
+		resp, err := base.RoundTrip(req.WithContext(httptrace.WithClientTrace(ctx, trace)))
+		if err == nil && resp.ProtoMajor == 2 {
+			s.http2.Add(1)
+		}
+		return resp, err
+	})
+}
+
+// Snapshot returns the counts since the stats were created.
+func (s *ConnStats) Snapshot() ConnSnapshot {
+	requests, reused := s.requests.Load(), s.reused.Load()
+	return ConnSnapshot{
+		Requests: requests,
+		Reused:   reused,
+		New:      requests - reused,
+		Idle:     s.idle.Load(),
+		HTTP2:    s.http2.Load(),
+		DNS:      average(s.dnsTime.Load(), s.dnsLookups.Load()),
+		Connect:  average(s.connTime.Load(), s.connects.Load()),
+		TLS:      average(s.tlsTime.Load(), s.handshakes.Load()),
+	}
+}
+
+// Log logs the counts on an interval, such as to compare the connections
+// reused by a server with those of Lambda.  Nothing is logged for an
+// interval without requests.
+func (s *ConnStats) Log(interval time.Duration) {
+	var last int64
+	for range time.Tick(interval) {
+		snapshot := s.Snapshot()
+		if snapshot.Requests == last {
+			continue
+		}
+		last = snapshot.Requests
+		log.Printf("Upstream connections: %d requests, %d reused (%d idle), %d new, %d HTTP/2; average DNS %s, connect %s, TLS %s",
+			snapshot.Requests, snapshot.Reused, snapshot.Idle, snapshot.New, snapshot.HTTP2,
+			snapshot.DNS.Round(time.Millisecond), snapshot.Connect.Round(time.Millisecond), snapshot.TLS.Round(time.Millisecond))
+	}
+}
+
+// average returns the average of a total of nanoseconds.
+func average(total int64, count int64) time.Duration {
+	if count == 0 {
+		return 0
+	}
+	return time.Duration(total / count)
+}
+
+// roundTripper is a function implementing http.RoundTripper.
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestConnStats(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := Setup(Options{ServiceName: "proxy-test", SampleRatio: 1, Exporters: []sdktrace.SpanExporter{exporter}})
+	defer provider.Shutdown(context.Background())
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	stats := &ConnStats{}
+	base := &http.Transport{}
+	defer base.CloseIdleConnections()
+	client := &http.Client{Transport: Transport(stats.Transport(base))}
+	for range 2 {
+		resp, err := client.Get(upstream.URL)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	snapshot := stats.Snapshot()
+	if snapshot.Requests != 2 || snapshot.New != 1 || snapshot.Reused != 1 || snapshot.Idle != 1 {
+		t.Errorf("Expected one new connection reused from the idle pool, got %+v", snapshot)
+	}
+	if snapshot.Connect <= 0 || snapshot.TLS != 0 || snapshot.HTTP2 != 0 {
+		t.Errorf("Expected the connect time of a plain HTTP/1.1 connection, got %+v", snapshot)
+	}
+
+	// Each request's connection is recorded on its client span
+	provider.ForceFlush(context.Background())
+	var reused []bool
+	for _, span := range exporter.GetSpans() {
+		for _, attr := range span.Attributes {
+			if attr.Key == "http.conn.reused" {
+				reused = append(reused, attr.Value.AsBool())
+			}
+		}
+	}
+	if len(reused) != 2 || reused[0] || !reused[1] {
+		t.Errorf("Expected a new then a reused connection on the spans, got %v", reused)
+	}
+}
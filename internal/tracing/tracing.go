@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer of the proxy's own spans.
+const instrumentationName = "wordpress-go-proxy"
+
+// Options configures the tracer provider.
+type Options struct {
+	// ServiceName is reported as the service.name of every span.
+	ServiceName string
+
+	// SampleRatio is the fraction of new traces that are sampled.  Traces
+	// continued from an incoming request follow the caller's decision.
+	SampleRatio float64
+
+	// Exporters receive finished spans, such as an OTLP or X-Ray exporter.
+	Exporters []sdktrace.SpanExporter
+}
+
+// Setup installs a global tracer provider exporting spans in batches, and
+// the propagators of trace headers.  Trace IDs are compatible with X-Ray
+// so that traces can be followed from the load balancer or Lambda.
+func Setup(opts Options) *sdktrace.TracerProvider {
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithIDGenerator(xray.NewIDGenerator()),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", opts.ServiceName))),
+	}
+	for _, exporter := range opts.Exporters {
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(providerOpts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(Propagator())
+	return provider
+}
+
+// Propagator returns the propagator of W3C traceparent and X-Ray trace
+// headers.  It is installed by Setup, and should also be installed when
+// tracing is disabled so that incoming trace headers are passed on to
+// WordPress.
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}, xray.Propagator{})
+}
+
+// Start starts a span as a child of the span in ctx, if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Annotate sets attributes on the span in ctx, if any.  Attributes with an
+// X-Ray annotation name, such as the cache hit or upstream status, can be
+// searched for in X-Ray.
+func Annotate(ctx context.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+// End records err on a span, if not nil, and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// untracedPaths are the health checks, which are not traced since load
+// balancers call them every few seconds.
+var untracedPaths = map[string]bool{"/healthz": true, "/readyz": true}
+
+// Middleware returns a handler that starts a span for each request, named
+// by its method since paths would make too many span names.  The trace of
+// the Lambda invocation is continued, or otherwise that of the request's
+// traceparent or X-Amzn-Trace-Id header.
+func Middleware(next http.Handler) http.Handler {
+	handler := otelhttp.NewHandler(next, "request",
+		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+			return r.Method
+		}),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !untracedPaths[r.URL.Path]
+		}))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, withLambdaTraceHeader(r))
+	})
+}
+
+// Transport wraps an HTTP transport so that each upstream request has a
+// client span and carries the trace headers.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base)
+}
+
+// xrayHeader is the trace header of X-Ray.
+const xrayHeader = "X-Amzn-Trace-Id"
+
+// lambdaTraceIDKey is the context key the Lambda runtime stores the X-Ray
+// trace header of an invocation under.
+const lambdaTraceIDKey = "x-amzn-trace-id"
+
+// withLambdaTraceHeader returns a request carrying the X-Ray trace header
+// of the Lambda invocation, which replaces any trace header of the request.
+// Lambda has already continued the caller's trace, and its header names the
+// invocation's segment so request spans are nested under it in X-Ray.
+func withLambdaTraceHeader(r *http.Request) *http.Request {
+	traceID, _ := r.Context().Value(lambdaTraceIDKey).(string)
+	if traceID == "" {
+		return r
+	}
+	r = r.Clone(r.Context())
+	r.Header.Del("traceparent")
+	r.Header.Set(xrayHeader, traceID)
+	return r
+}
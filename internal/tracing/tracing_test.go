@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := Setup(Options{ServiceName: "proxy-test", SampleRatio: 1, Exporters: []sdktrace.SpanExporter{exporter}})
+	defer provider.Shutdown(context.Background())
+
+	// WordPress receives the trace headers of the request span
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+	client := &http.Client{Transport: Transport(http.DefaultTransport)}
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			return
+		}
+		ctx, span := Start(r.Context(), "fetch-page")
+		req, _ := http.NewRequestWithContext(ctx, "GET", upstream.URL, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		End(span, err)
+
+		_, span = Start(r.Context(), "template-render")
+		End(span, errors.New("missing template"))
+	}))
+
+	req := httptest.NewRequest("GET", "/about", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	provider.ForceFlush(context.Background())
+
+	spans := exporter.GetSpans()
+	names := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		names[span.Name] = span
+		if span.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("Expected span %s to continue the incoming trace, got %s", span.Name, span.SpanContext.TraceID())
+		}
+	}
+	if len(spans) != 4 {
+		t.Errorf("Expected request, fetch, client and render spans only, got %d", len(spans))
+	}
+	for _, name := range []string{"GET", "fetch-page", "template-render"} {
+		if _, ok := names[name]; !ok {
+			t.Errorf("Expected a %s span, got %v", name, names)
+		}
+	}
+	if names["template-render"].Status.Code != codes.Error {
+		t.Error("Expected the failed render to be recorded")
+	}
+	if !strings.Contains(traceparent, "4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Errorf("Expected trace to be propagated to WordPress, got %q", traceparent)
+	}
+}
+
+func TestWithLambdaTraceHeader(t *testing.T) {
+	const lambdaTrace = "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+	ctx := context.WithValue(context.Background(), lambdaTraceIDKey, lambdaTrace)
+
+	req := httptest.NewRequest("GET", "/about", nil).WithContext(ctx)
+	if got := withLambdaTraceHeader(req).Header.Get(xrayHeader); got != lambdaTrace {
+		t.Errorf("Expected the invocation's trace header, got %q", got)
+	}
+	if req.Header.Get(xrayHeader) != "" {
+		t.Error("Expected the original request to be unchanged")
+	}
+
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if got := withLambdaTraceHeader(req).Header; got.Get(xrayHeader) != lambdaTrace || got.Get("traceparent") != "" {
+		t.Errorf("Expected the invocation's trace header to replace the request's, got %v", got)
+	}
+
+	req = httptest.NewRequest("GET", "/about", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if got := withLambdaTraceHeader(req); got != req {
+		t.Error("Expected requests outside Lambda to be unchanged")
+	}
+}
@@ -0,0 +1,161 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// xrayAnnotations maps the span attributes recorded as X-Ray annotations,
+// which can be searched in X-Ray, to their annotation names.  Other
+// attributes are recorded as metadata.
+var xrayAnnotations = map[attribute.Key]string{
+	"wordpress.slug":   "slug",
+	"wordpress.lang":   "lang",
+	"wordpress.status": "upstream_status",
+	"cache.hit":        "cache_hit",
+}
+
+// xrayDaemonHeader precedes each segment document sent to the X-Ray daemon.
+const xrayDaemonHeader = `{"format":"json","version":1}` + "\n"
+
+// XRayExporter sends spans to the X-Ray daemon, which Lambda runs beside
+// functions with active tracing.  Spans are sent as subsegments of their
+// parent, so request spans appear under the segment Lambda records for the
+// invocation.
+type XRayExporter struct {
+	conn net.Conn
+}
+
+// NewXRayExporter creates an exporter sending to the X-Ray daemon's UDP
+// address, given in Lambda by AWS_XRAY_DAEMON_ADDRESS.
+func NewXRayExporter(address string) (*XRayExporter, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &XRayExporter{conn: conn}, nil
+}
+
+// ExportSpans implements sdktrace.SpanExporter.  Each span is sent in its
+// own packet, and the first error is returned.
+func (e *XRayExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	var firstErr error
+	for _, span := range spans {
+		doc, err := json.Marshal(newXRaySegment(span))
+		if err == nil {
+			_, err = e.conn.Write(append([]byte(xrayDaemonHeader), doc...))
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("error sending span %s to X-Ray: %w", span.Name(), err)
+		}
+	}
+	return firstErr
+}
+
+// Shutdown implements sdktrace.SpanExporter.
+func (e *XRayExporter) Shutdown(ctx context.Context) error {
+	return e.conn.Close()
+}
+
+// xraySegment is an X-Ray segment document.  Spans with a parent are sent
+// as independent subsegments naming their parent.
+type xraySegment struct {
+	Name        string                    `json:"name"`
+	ID          string                    `json:"id"`
+	TraceID     string                    `json:"trace_id"`
+	ParentID    string                    `json:"parent_id,omitempty"`
+	Type        string                    `json:"type,omitempty"`
+	Namespace   string                    `json:"namespace,omitempty"`
+	StartTime   float64                   `json:"start_time"`
+	EndTime     float64                   `json:"end_time"`
+	Error       bool                      `json:"error,omitempty"`
+	Fault       bool                      `json:"fault,omitempty"`
+	HTTP        *xrayHTTP                 `json:"http,omitempty"`
+	Annotations map[string]any            `json:"annotations,omitempty"`
+	Metadata    map[string]map[string]any `json:"metadata,omitempty"`
+}
+
+// xrayHTTP is the HTTP request and response of a segment.
+type xrayHTTP struct {
+	Request  map[string]any `json:"request,omitempty"`
+	Response map[string]any `json:"response,omitempty"`
+}
+
+// newXRaySegment converts a span to a segment document.  Upstream requests
+// are marked as remote, and 4xx and 5xx responses as errors and faults.
+func newXRaySegment(span sdktrace.ReadOnlySpan) xraySegment {
+	traceID := span.SpanContext().TraceID().String()
+	seg := xraySegment{
+		Name:      span.Name(),
+		ID:        span.SpanContext().SpanID().String(),
+		TraceID:   "1-" + traceID[:8] + "-" + traceID[8:],
+		StartTime: xrayTime(span.StartTime()),
+		EndTime:   xrayTime(span.EndTime()),
+	}
+	if span.Parent().IsValid() {
+		seg.ParentID = span.Parent().SpanID().String()
+		seg.Type = "subsegment"
+	}
+
+	var request, response map[string]any
+	for _, attr := range span.Attributes() {
+		value := attr.Value.AsInterface()
+		switch attr.Key {
+		case "http.request.method":
+			request = setValue(request, "method", value)
+		case "url.full", "url.path":
+			request = setValue(request, "url", value)
+		case "http.response.status_code":
+			response = setValue(response, "status", value)
+			if status := attr.Value.AsInt64(); status >= 500 {
+				seg.Fault = true
+			} else if status >= 400 {
+				seg.Error = true
+			}
+		}
+
+		if name, ok := xrayAnnotations[attr.Key]; ok {
+			if seg.Annotations == nil {
+				seg.Annotations = make(map[string]any)
+			}
+			seg.Annotations[name] = value
+		} else {
+			if seg.Metadata == nil {
+				seg.Metadata = map[string]map[string]any{"otel": {}}
+			}
+			seg.Metadata["otel"][string(attr.Key)] = value
+		}
+	}
+	if request != nil || response != nil {
+		seg.HTTP = &xrayHTTP{Request: request, Response: response}
+	}
+	if span.SpanKind() == trace.SpanKindClient {
+		seg.Namespace = "remote"
+	}
+	if span.Status().Code == codes.Error && !seg.Error {
+		seg.Fault = true
+	}
+	return seg
+}
+
+// setValue sets a key of a map, creating the map if it is nil.
+func setValue(m map[string]any, key string, value any) map[string]any {
+	if m == nil {
+		m = make(map[string]any)
+	}
+	m[key] = value
+	return m
+}
+
+// xrayTime returns a time in seconds since the epoch, as X-Ray expects.
+func xrayTime(t time.Time) float64 {
+	return float64(t.Unix()) + float64(t.Nanosecond())/float64(time.Second)
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestXRayExporter(t *testing.T) {
+	daemon, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer daemon.Close()
+
+	exporter, err := NewXRayExporter(daemon.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer exporter.Shutdown(context.Background())
+
+	traceID, _ := trace.TraceIDFromHex("5759e988bd862e3fe1be46a994272793")
+	parentID, _ := trace.SpanIDFromHex("53995c3f42cd8ad8")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	start := time.Unix(1700000000, 500000000)
+	spans := tracetest.SpanStubs{{
+		Name:        "fetch-page",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}),
+		Parent:      trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: parentID}),
+		SpanKind:    trace.SpanKindInternal,
+		StartTime:   start,
+		EndTime:     start.Add(250 * time.Millisecond),
+		Attributes: []attribute.KeyValue{
+			attribute.String("wordpress.slug", "about"),
+			attribute.String("wordpress.lang", "en"),
+			attribute.Int("wordpress.status", 503),
+			attribute.Bool("cache.hit", false),
+			attribute.String("wordpress.route", "wp/v2/pages"),
+			attribute.Int("http.response.status_code", 503),
+		},
+	}}.Snapshots()
+
+	if err := exporter.ExportSpans(context.Background(), spans); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	buf := make([]byte, 64*1024)
+	daemon.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := daemon.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Expected a packet, got %v", err)
+	}
+	header, body, _ := strings.Cut(string(buf[:n]), "\n")
+	if header != `{"format":"json","version":1}` {
+		t.Errorf("Expected daemon header, got %q", header)
+	}
+
+	var seg struct {
+		Name        string                    `json:"name"`
+		ID          string                    `json:"id"`
+		TraceID     string                    `json:"trace_id"`
+		ParentID    string                    `json:"parent_id"`
+		Type        string                    `json:"type"`
+		StartTime   float64                   `json:"start_time"`
+		EndTime     float64                   `json:"end_time"`
+		Fault       bool                      `json:"fault"`
+		HTTP        map[string]map[string]any `json:"http"`
+		Annotations map[string]any            `json:"annotations"`
+		Metadata    map[string]map[string]any `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(body), &seg); err != nil {
+		t.Fatalf("Expected a JSON segment, got %v", err)
+	}
+	if seg.Name != "fetch-page" || seg.ID != "00f067aa0ba902b7" || seg.ParentID != "53995c3f42cd8ad8" || seg.Type != "subsegment" {
+		t.Errorf("Unexpected subsegment %+v", seg)
+	}
+	if seg.TraceID != "1-5759e988-bd862e3fe1be46a994272793" {
+		t.Errorf("Expected X-Ray trace ID, got %q", seg.TraceID)
+	}
+	if seg.StartTime != 1700000000.5 || seg.EndTime != 1700000000.75 {
+		t.Errorf("Expected times in seconds, got %v, %v", seg.StartTime, seg.EndTime)
+	}
+	if !seg.Fault || seg.HTTP["response"]["status"] != float64(503) {
+		t.Errorf("Expected a fault with the response status, got %v, %v", seg.Fault, seg.HTTP)
+	}
+	expected := map[string]any{"slug": "about", "lang": "en", "upstream_status": float64(503), "cache_hit": false}
+	for name, value := range expected {
+		if seg.Annotations[name] != value {
+			t.Errorf("Expected annotation %s=%v, got %v", name, value, seg.Annotations)
+		}
+	}
+	if seg.Metadata["otel"]["wordpress.route"] != "wp/v2/pages" {
+		t.Errorf("Expected other attributes as metadata, got %v", seg.Metadata)
+	}
+}
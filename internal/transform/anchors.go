@@ -0,0 +1,147 @@
+package transform
+
+import (
+	"log"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// headings are the elements an anchor missing from the content may be
+// matched to by their text.
+var headings = []atom.Atom{atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6}
+
+// Anchors makes links to anchors on the same page work after links are
+// rewritten.  It runs after InternalLinks, so that links to the page on
+// the WordPress site are paths, and links to the page's own path with a
+// fragment are shortened to the fragment when the anchor is in the
+// content.
+//
+// Anchors that are not in the content are matched to an id differing only
+// in case, or to a heading whose text they match, such as #contact-us for
+// a "Contact us" heading, which is given the id.  Anchors that still match
+// nothing are logged as broken so editors can fix them.
+type Anchors struct{}
+
+// Transform implements Transformer.
+func (Anchors) Transform(doc *html.Node, page Page) {
+	ids := anchorTargets(doc)
+	for _, a := range Elements(doc, atom.A) {
+		href := strings.TrimSpace(Attr(a, "href"))
+		if fragment, ok := strings.CutPrefix(href, "#"); ok {
+			fragment = unescapeFragment(fragment)
+			if fragment == "" || fragment == "top" || ids[fragment] {
+				continue
+			}
+			if id, ok := matchAnchor(doc, ids, fragment); ok {
+				SetAttr(a, "href", "#"+id)
+				continue
+			}
+			log.Printf("Broken anchor #%s on %s", fragment, page.Path)
+			continue
+		}
+
+		u, err := url.Parse(href)
+		if err != nil || u.Scheme != "" || u.Host != "" || u.Fragment == "" || u.RawQuery != "" || !samePath(u.Path, page.Path) {
+			continue
+		}
+		if ids[u.Fragment] {
+			SetAttr(a, "href", "#"+u.EscapedFragment())
+		} else if id, ok := matchAnchor(doc, ids, u.Fragment); ok {
+			SetAttr(a, "href", "#"+id)
+		}
+	}
+}
+
+// anchorTargets returns the ids of the elements below n, along with the
+// names of its named anchors.
+func anchorTargets(n *html.Node) map[string]bool {
+	ids := make(map[string]bool)
+	walkElements(n, func(n *html.Node) {
+		if id := Attr(n, "id"); id != "" {
+			ids[id] = true
+		}
+		if name := Attr(n, "name"); name != "" && n.DataAtom == atom.A {
+			ids[name] = true
+		}
+	})
+	return ids
+}
+
+// matchAnchor returns the id an anchor missing from the content should
+// link to: an id that only differs in case, or the id given to the first
+// heading without one whose text matches the anchor.
+func matchAnchor(doc *html.Node, ids map[string]bool, fragment string) (string, bool) {
+	for id := range ids {
+		if strings.EqualFold(id, fragment) {
+			return id, true
+		}
+	}
+
+	slug := slugify(fragment)
+	if slug == "" {
+		return "", false
+	}
+	for _, heading := range headingElements(doc) {
+		if !HasAttr(heading, "id") && slugify(textContent(heading)) == slug {
+			SetAttr(heading, "id", fragment)
+			ids[fragment] = true
+			return fragment, true
+		}
+	}
+	return "", false
+}
+
+// headingElements returns the headings below n in document order.
+func headingElements(n *html.Node) []*html.Node {
+	var found []*html.Node
+	walkElements(n, func(n *html.Node) {
+		for _, a := range headings {
+			if n.DataAtom == a {
+				found = append(found, n)
+			}
+		}
+	})
+	return found
+}
+
+// slugify returns text in lower case with runs of anything but letters
+// and digits replaced by a dash, so that "Contact us!" and "contact-us"
+// compare equal.
+func slugify(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// unescapeFragment returns a fragment with its percent escapes decoded, or
+// as it is if they are invalid.
+func unescapeFragment(fragment string) string {
+	if unescaped, err := url.PathUnescape(fragment); err == nil {
+		return unescaped
+	}
+	return fragment
+}
+
+// samePath reports whether two paths are the same page, ignoring a
+// trailing slash.
+func samePath(a string, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
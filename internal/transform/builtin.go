@@ -0,0 +1,229 @@
+package transform
+
+import (
+	"net/url"
+	"strings"
+
+	"wordpress-go-proxy/pkg/models"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// urlAttrs are the attributes holding a single URL that internal links are
+// rewritten in.
+var urlAttrs = []string{"href", "src", "poster"}
+
+// InternalLinks rewrites URLs pointing at the WordPress site to paths
+// served by the proxy.  URLs match the site whatever their scheme, www.
+// prefix or port, see models.InternalPath.
+type InternalLinks struct {
+	BaseURL string
+}
+
+// Transform implements Transformer.
+func (t InternalLinks) Transform(doc *html.Node, page Page) {
+	if t.BaseURL == "" {
+		return
+	}
+	walkElements(doc, func(n *html.Node) {
+		for i, attr := range n.Attr {
+			switch {
+			case attr.Key == "srcset":
+				n.Attr[i].Val = t.rewriteSrcset(attr.Val)
+			case containsString(urlAttrs, attr.Key):
+				n.Attr[i].Val = t.rewrite(attr.Val)
+			}
+		}
+	})
+}
+
+// rewrite returns the proxy path of a URL on the WordPress site, or the
+// URL unchanged if it points elsewhere.
+func (t InternalLinks) rewrite(value string) string {
+	return models.RelativeUrl(strings.TrimSpace(value), t.BaseURL)
+}
+
+// rewriteSrcset rewrites each image candidate URL of a srcset.
+func (t InternalLinks) rewriteSrcset(value string) string {
+	candidates := strings.Split(value, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = t.rewrite(fields[0])
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// How links that leave the site are opened: as they were authored, in a
+// new tab or window, or in the same one.
+const (
+	ExternalTargetKeep = "keep"
+	ExternalTargetNew  = "new"
+	ExternalTargetSame = "same"
+)
+
+// externalLabelClass is the class of the text announcing a link as
+// external.
+const externalLabelClass = "external-link-label"
+
+// ExternalLinks marks links that leave the site with rel="external
+// noopener noreferrer" and the external-link class, so they can be styled
+// and announced.  It runs after InternalLinks so that links to the
+// WordPress site are relative.  Absolute links to SiteHosts, the hosts the
+// site is served from, are not external.
+//
+// Target is one of the ExternalTarget constants, leaving the target of
+// links as authored if empty.  Labels holds the text appended to links to
+// announce them as external, such as "(external)", keyed by language.
+// Links are labelled in the language of their content, falling back to
+// the default language, and are not labelled without a label.
+type ExternalLinks struct {
+	SiteHosts []string
+	Target    string
+	Labels    map[string]string
+}
+
+// Transform implements Transformer.
+func (t ExternalLinks) Transform(doc *html.Node, page Page) {
+	for _, a := range Elements(doc, atom.A) {
+		u, err := url.Parse(strings.TrimSpace(Attr(a, "href")))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || t.siteHost(u.Hostname()) {
+			continue
+		}
+		addToken(a, "rel", "external")
+		addToken(a, "rel", "noopener")
+		addToken(a, "rel", "noreferrer")
+		addToken(a, "class", "external-link")
+
+		switch t.Target {
+		case ExternalTargetNew:
+			SetAttr(a, "target", "_blank")
+		case ExternalTargetSame:
+			RemoveAttr(a, "target")
+		}
+		if label := t.label(Lang(a)); label != "" && !hasLabel(a) {
+			span := &html.Node{Type: html.ElementNode, Data: "span", DataAtom: atom.Span}
+			SetAttr(span, "class", externalLabelClass)
+			span.AppendChild(&html.Node{Type: html.TextNode, Data: " " + label})
+			a.AppendChild(span)
+		}
+	}
+}
+
+// siteHost reports whether a host is one the site is served from.
+func (t ExternalLinks) siteHost(host string) bool {
+	for _, h := range t.SiteHosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// label returns the label of external links in a language.
+func (t ExternalLinks) label(lang string) string {
+	if label, ok := t.Labels[lang]; ok {
+		return label
+	}
+	return t.Labels[models.Languages.Default().Code]
+}
+
+// hasLabel reports whether a link was already labelled as external.
+func hasLabel(a *html.Node) bool {
+	for _, span := range Elements(a, atom.Span) {
+		if strings.Contains(Attr(span, "class"), externalLabelClass) {
+			return true
+		}
+	}
+	return false
+}
+
+// LazyImages defers loading and decoding of images until they are needed.
+// Images that set their own loading or decoding are left as they are.
+type LazyImages struct{}
+
+// Transform implements Transformer.
+func (LazyImages) Transform(doc *html.Node, page Page) {
+	for _, img := range Elements(doc, atom.Img) {
+		if !HasAttr(img, "loading") {
+			SetAttr(img, "loading", "lazy")
+		}
+		if !HasAttr(img, "decoding") {
+			SetAttr(img, "decoding", "async")
+		}
+	}
+}
+
+// ResponsiveTables wraps tables in a scrollable container so wide tables
+// do not overflow the page on small screens.  The container can be
+// focused to scroll it with the keyboard and is named by the caption.
+type ResponsiveTables struct{}
+
+// tableWrapperClass is the class of the scrollable table container.
+const tableWrapperClass = "table-responsive"
+
+// Transform implements Transformer.
+func (ResponsiveTables) Transform(doc *html.Node, page Page) {
+	for _, table := range Elements(doc, atom.Table) {
+		parent := table.Parent
+		if parent == nil || (parent.DataAtom == atom.Div && strings.Contains(Attr(parent, "class"), tableWrapperClass)) {
+			continue
+		}
+
+		wrapper := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+		SetAttr(wrapper, "class", tableWrapperClass)
+		SetAttr(wrapper, "tabindex", "0")
+		if caption := caption(table); caption != "" {
+			SetAttr(wrapper, "role", "region")
+			SetAttr(wrapper, "aria-label", caption)
+		}
+		parent.InsertBefore(wrapper, table)
+		parent.RemoveChild(table)
+		wrapper.AppendChild(table)
+	}
+}
+
+// caption returns the text of a table's caption.
+func caption(table *html.Node) string {
+	captions := Elements(table, atom.Caption)
+	if len(captions) == 0 {
+		return ""
+	}
+	return strings.Join(strings.Fields(textContent(captions[0])), " ")
+}
+
+// textContent returns the text of a node and its descendants.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// walkElements calls fn for every element below n.
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			fn(c)
+		}
+		walkElements(c, fn)
+	}
+}
+
+// containsString reports whether a string is in the list.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package transform
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultCruft are the selectors of the markup Cruft removes by default:
+// the emoji detection script and styles, and the block library and global
+// styles that WordPress adds for its own themes.  The proxy's theme loads
+// its own scripts and styles.
+var DefaultCruft = []string{
+	"script:contains(_wpemojiSettings)",
+	"script[src*=wp-emoji]",
+	"style#wp-emoji-styles-inline-css",
+	"link#wp-block-library-css",
+	"style#wp-block-library-inline-css",
+	"link#wp-block-library-theme-css",
+	"link[href*=/wp-includes/css/dist/block-library/]",
+	"style#global-styles-inline-css",
+	"style#classic-theme-styles-inline-css",
+	"style#core-block-supports-inline-css",
+}
+
+// emojiClasses are the classes of the images WordPress replaces emoji
+// and smilies in content with.
+var emojiClasses = []string{"wp-smiley", "emoji"}
+
+// Cruft removes the elements matching Selectors from content, such as the
+// scripts and styles WordPress and its plugins inject, so that the page
+// only loads the assets of the proxy's theme.  Emoji and smilies that
+// WordPress replaced with images from its CDN are turned back into the
+// text of the emoji.
+type Cruft struct {
+	Selectors []Selector
+}
+
+// NewCruft creates a Cruft transformer removing the elements matching the
+// given selectors, see ParseSelector.
+func NewCruft(selectors []string) (Cruft, error) {
+	var c Cruft
+	for _, s := range selectors {
+		sel, err := ParseSelector(s)
+		if err != nil {
+			return Cruft{}, err
+		}
+		c.Selectors = append(c.Selectors, sel)
+	}
+	return c, nil
+}
+
+// defaultCruftSelectors returns the parsed DefaultCruft selectors.
+func defaultCruftSelectors() []Selector {
+	selectors := make([]Selector, len(DefaultCruft))
+	for i, s := range DefaultCruft {
+		selectors[i] = MustParseSelector(s)
+	}
+	return selectors
+}
+
+// Transform implements Transformer.
+func (c Cruft) Transform(doc *html.Node, page Page) {
+	var removed []*html.Node
+	walkElements(doc, func(n *html.Node) {
+		for _, sel := range c.Selectors {
+			if sel.Match(n) {
+				removed = append(removed, n)
+				return
+			}
+		}
+	})
+	for _, n := range removed {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+
+	for _, img := range Elements(doc, atom.Img) {
+		if !isEmoji(img) || img.Parent == nil {
+			continue
+		}
+		img.Parent.InsertBefore(&html.Node{Type: html.TextNode, Data: Attr(img, "alt")}, img)
+		img.Parent.RemoveChild(img)
+	}
+}
+
+// isEmoji reports whether an image is an emoji WordPress replaced.
+func isEmoji(img *html.Node) bool {
+	if Attr(img, "alt") == "" {
+		return false
+	}
+	for _, class := range strings.Fields(Attr(img, "class")) {
+		if containsString(emojiClasses, class) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Selector matches elements by a simple CSS selector: an optional tag name
+// followed by any of #id, .class, [attr], [attr=value], [attr^=value],
+// [attr$=value], [attr*=value] and :contains(text), which matches elements
+// whose text contains text.  Combinators are not supported.
+type Selector struct {
+	source   string
+	tag      string
+	id       string
+	classes  []string
+	attrs    []attrMatch
+	contains string
+}
+
+// attrMatch is an attribute condition of a selector.  An empty op only
+// requires the attribute.
+type attrMatch struct {
+	key   string
+	op    string
+	value string
+}
+
+// ParseSelector parses a selector, see Selector.
+func ParseSelector(s string) (Selector, error) {
+	sel := Selector{source: s}
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return Selector{}, fmt.Errorf("empty selector")
+	}
+
+	sel.tag, rest = cutName(rest)
+	sel.tag = strings.ToLower(sel.tag)
+	if strings.HasPrefix(rest, "*") && sel.tag == "" {
+		rest = rest[1:]
+	}
+	for rest != "" {
+		var name string
+		switch {
+		case rest[0] == '#':
+			name, rest = cutName(rest[1:])
+			if name == "" || sel.id != "" {
+				return Selector{}, fmt.Errorf("invalid id in selector %q", s)
+			}
+			sel.id = name
+		case rest[0] == '.':
+			name, rest = cutName(rest[1:])
+			if name == "" {
+				return Selector{}, fmt.Errorf("invalid class in selector %q", s)
+			}
+			sel.classes = append(sel.classes, name)
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return Selector{}, fmt.Errorf("unclosed attribute in selector %q", s)
+			}
+			attr, ok := parseAttrMatch(rest[1:end])
+			if !ok {
+				return Selector{}, fmt.Errorf("invalid attribute in selector %q", s)
+			}
+			sel.attrs = append(sel.attrs, attr)
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, ":contains(") && strings.HasSuffix(rest, ")"):
+			sel.contains = unquote(rest[len(":contains(") : len(rest)-1])
+			if sel.contains == "" {
+				return Selector{}, fmt.Errorf("invalid :contains in selector %q", s)
+			}
+			rest = ""
+		default:
+			return Selector{}, fmt.Errorf("invalid selector %q", s)
+		}
+	}
+	if sel.tag == "" && sel.id == "" && sel.classes == nil && sel.attrs == nil && sel.contains == "" {
+		return Selector{}, fmt.Errorf("selector %q matches every element", s)
+	}
+	return sel, nil
+}
+
+// MustParseSelector is like ParseSelector but panics if the selector is
+// invalid.  It is meant for selectors built into the program.
+func MustParseSelector(s string) Selector {
+	sel, err := ParseSelector(s)
+	if err != nil {
+		panic(err)
+	}
+	return sel
+}
+
+// String returns the selector as it was parsed.
+func (s Selector) String() string {
+	return s.source
+}
+
+// Match reports whether an element matches the selector.
+func (s Selector) Match(n *html.Node) bool {
+	if n.Type != html.ElementNode || (s.tag != "" && n.Data != s.tag) {
+		return false
+	}
+	if s.id != "" && Attr(n, "id") != s.id {
+		return false
+	}
+	classes := strings.Fields(Attr(n, "class"))
+	for _, class := range s.classes {
+		if !containsString(classes, class) {
+			return false
+		}
+	}
+	for _, attr := range s.attrs {
+		if !attr.match(n) {
+			return false
+		}
+	}
+	return s.contains == "" || strings.Contains(textContent(n), s.contains)
+}
+
+// match reports whether an element meets the attribute condition.
+func (m attrMatch) match(n *html.Node) bool {
+	if !HasAttr(n, m.key) {
+		return false
+	}
+	value := Attr(n, m.key)
+	switch m.op {
+	case "=":
+		return value == m.value
+	case "^=":
+		return strings.HasPrefix(value, m.value)
+	case "$=":
+		return strings.HasSuffix(value, m.value)
+	case "*=":
+		return strings.Contains(value, m.value)
+	}
+	return true
+}
+
+// parseAttrMatch parses the inside of an attribute condition.
+func parseAttrMatch(s string) (attrMatch, bool) {
+	key, rest := cutName(strings.TrimSpace(s))
+	if key == "" {
+		return attrMatch{}, false
+	}
+	m := attrMatch{key: strings.ToLower(key)}
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return m, true
+	}
+	for _, op := range []string{"^=", "$=", "*=", "="} {
+		if value, ok := strings.CutPrefix(rest, op); ok {
+			m.op, m.value = op, unquote(strings.TrimSpace(value))
+			return m, m.value != ""
+		}
+	}
+	return attrMatch{}, false
+}
+
+// cutName splits a leading tag, attribute, id or class name off s.
+func cutName(s string) (string, string) {
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+// unquote removes the quotes around a selector value, if it has them.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+package transform
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Page is the page content is transformed for.  Path is the path the
+// content is served at, without the /page/<n> suffix of paginated content.
+type Page struct {
+	Lang string
+	Path string
+}
+
+// Transformer changes the parsed content of a page before it is rendered.
+// doc is the body element the content was parsed into.
+type Transformer interface {
+	Transform(doc *html.Node, page Page)
+}
+
+// Func adapts a function to a Transformer.
+type Func func(doc *html.Node, page Page)
+
+// Transform calls f(doc, page).
+func (f Func) Transform(doc *html.Node, page Page) {
+	f(doc, page)
+}
+
+// Pipeline is a list of transformers applied in order to the same parsed
+// content, so the content is only parsed and rendered once.
+type Pipeline []Transformer
+
+// Default returns the pipeline of built-in transformers for content from
+// the WordPress site at baseURL.
+func Default(baseURL string) Pipeline {
+	return Pipeline{
+		Cruft{Selectors: defaultCruftSelectors()},
+		InternalLinks{BaseURL: baseURL},
+		Anchors{},
+		ExternalLinks{},
+		LazyImages{},
+		ResponsiveTables{},
+	}
+}
+
+// With returns a copy of the pipeline with t in place of the transformer
+// of the same type, or with t appended if there is none.
+func (p Pipeline) With(t Transformer) Pipeline {
+	with := slices.Clone(p)
+	for i, existing := range with {
+		if reflect.TypeOf(existing) == reflect.TypeOf(t) {
+			with[i] = t
+			return with
+		}
+	}
+	return append(with, t)
+}
+
+// Apply parses the content of a page, runs every transformer on it and
+// returns the rendered result.  The language of the page is set on the
+// body element the content is parsed into, see Lang.
+func (p Pipeline) Apply(content string, page Page) (string, error) {
+	if len(p) == 0 || content == "" {
+		return content, nil
+	}
+
+	doc := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	if page.Lang != "" {
+		SetAttr(doc, "lang", page.Lang)
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(content), doc)
+	if err != nil {
+		return "", err
+	}
+	for _, n := range nodes {
+		doc.AppendChild(n)
+	}
+
+	for _, t := range p {
+		t.Transform(doc, page)
+	}
+
+	var b strings.Builder
+	for n := doc.FirstChild; n != nil; n = n.NextSibling {
+		if err := html.Render(&b, n); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// Elements returns the elements below n with the given tag, in document
+// order.  Collecting them first lets transformers change the tree while
+// iterating.
+func Elements(n *html.Node, a atom.Atom) []*html.Node {
+	var found []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == a {
+				found = append(found, c)
+			}
+			walk(c)
+		}
+	}
+	walk(n)
+	return found
+}
+
+// Lang returns the language of a node, from the lang attribute of the
+// node or its closest ancestor that has one.
+func Lang(n *html.Node) string {
+	for ; n != nil; n = n.Parent {
+		if n.Type == html.ElementNode && HasAttr(n, "lang") {
+			return Attr(n, "lang")
+		}
+	}
+	return ""
+}
+
+// Attr returns the value of an attribute, or an empty string.
+func Attr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// HasAttr reports whether an element has an attribute.
+func HasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAttr sets the value of an attribute, adding it if necessary.
+func SetAttr(n *html.Node, key string, val string) {
+	for i, attr := range n.Attr {
+		if attr.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// RemoveAttr removes an attribute if it is present.
+func RemoveAttr(n *html.Node, key string) {
+	n.Attr = slices.DeleteFunc(n.Attr, func(attr html.Attribute) bool {
+		return attr.Key == key
+	})
+}
+
+// addToken adds a token to a space separated attribute such as class or
+// rel, unless it is already present.
+func addToken(n *html.Node, key string, token string) {
+	tokens := strings.Fields(Attr(n, key))
+	for _, t := range tokens {
+		if t == token {
+			return
+		}
+	}
+	SetAttr(n, key, strings.Join(append(tokens, token), " "))
+}
@@ -0,0 +1,405 @@
+package transform
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestPipelineApply(t *testing.T) {
+	var calls []string
+	pipeline := Pipeline{
+		Func(func(doc *html.Node, page Page) { calls = append(calls, "first") }),
+		Func(func(doc *html.Node, page Page) {
+			calls = append(calls, "second")
+			p := &html.Node{Type: html.ElementNode, Data: "p", DataAtom: atom.P}
+			p.AppendChild(&html.Node{Type: html.TextNode, Data: "Added"})
+			doc.AppendChild(p)
+		}),
+	}
+
+	got, err := pipeline.Apply(`<p>Text</p>`, Page{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got != `<p>Text</p><p>Added</p>` {
+		t.Errorf("Expected appended paragraph, got %q", got)
+	}
+	if strings.Join(calls, ",") != "first,second" {
+		t.Errorf("Expected transformers to run in order, got %v", calls)
+	}
+}
+
+func TestPipelineApplyEmpty(t *testing.T) {
+	content := `<p>Unparsed &amp; unchanged`
+	if got, _ := (Pipeline{}).Apply(content, Page{}); got != content {
+		t.Errorf("Expected content unchanged without transformers, got %q", got)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	content := `<p><a href="https://example.com/about">About</a> and <a href="https://www.canada.ca/">Canada</a></p>` +
+		`<img src="https://example.com/wp-content/uploads/a.jpg"><table><tr><td>1</td></tr></table>`
+
+	got, err := Default("https://example.com").Apply(content, Page{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		`<a href="/about">About</a>`,
+		`<a href="https://www.canada.ca/" rel="external noopener noreferrer" class="external-link">Canada</a>`,
+		`<img src="/wp-content/uploads/a.jpg" loading="lazy" decoding="async"/>`,
+		`<div class="table-responsive" tabindex="0"><table>`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("Expected %q to contain %q", got, expected)
+		}
+	}
+}
+
+func TestInternalLinks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Link",
+			content:  `<a href="https://example.com/about">About</a>`,
+			expected: `<a href="/about">About</a>`,
+		},
+		{
+			name:     "Home page",
+			content:  `<a href="https://example.com">Home</a>`,
+			expected: `<a href="/">Home</a>`,
+		},
+		{
+			name:     "Query and fragment",
+			content:  `<a href="https://example.com?p=1">A</a><a href="https://example.com#top">B</a>`,
+			expected: `<a href="/?p=1">A</a><a href="/#top">B</a>`,
+		},
+		{
+			name:     "Image source and srcset",
+			content:  `<img src="https://example.com/a.jpg" srcset="https://example.com/a-300.jpg 300w, https://cdn.example.net/a-600.jpg 600w"/>`,
+			expected: `<img src="/a.jpg" srcset="/a-300.jpg 300w, https://cdn.example.net/a-600.jpg 600w"/>`,
+		},
+		{
+			name:     "Scheme, www and port variants",
+			content:  `<a href="http://www.example.com/a">A</a><a href="https://example.com:443/b">B</a><img src="//example.com/c.jpg"/>`,
+			expected: `<a href="/a">A</a><a href="/b">B</a><img src="/c.jpg"/>`,
+		},
+		{
+			name:     "Other hosts with the same prefix",
+			content:  `<a href="https://example.com.evil.net/about">About</a>`,
+			expected: `<a href="https://example.com.evil.net/about">About</a>`,
+		},
+		{
+			name:     "Text is not rewritten",
+			content:  `<p>Visit https://example.com/about</p>`,
+			expected: `<p>Visit https://example.com/about</p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Pipeline{InternalLinks{BaseURL: "https://example.com"}}.Apply(tc.content, Page{})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestAnchors(t *testing.T) {
+	page := Page{Lang: "en", Path: "/about"}
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+		broken   bool
+	}{
+		{
+			name:     "Anchor in the content",
+			content:  `<a href="#faq">FAQ</a><h2 id="faq">FAQ</h2>`,
+			expected: `<a href="#faq">FAQ</a><h2 id="faq">FAQ</h2>`,
+		},
+		{
+			name:     "Link to the page",
+			content:  `<a href="/about/#faq">FAQ</a><a name="faq"></a>`,
+			expected: `<a href="#faq">FAQ</a><a name="faq"></a>`,
+		},
+		{
+			name:     "Link to another page",
+			content:  `<a href="/contact#faq">FAQ</a><h2 id="faq">FAQ</h2>`,
+			expected: `<a href="/contact#faq">FAQ</a><h2 id="faq">FAQ</h2>`,
+		},
+		{
+			name:     "Link to an anchor on another page of the content",
+			content:  `<a href="/about#faq">FAQ</a>`,
+			expected: `<a href="/about#faq">FAQ</a>`,
+		},
+		{
+			name:     "Id in another case",
+			content:  `<a href="#FAQ">FAQ</a><h2 id="faq">FAQ</h2>`,
+			expected: `<a href="#faq">FAQ</a><h2 id="faq">FAQ</h2>`,
+		},
+		{
+			name:     "Heading without an id",
+			content:  `<a href="/about#contact-us">Contact</a><h2>Contact <em>us</em>!</h2>`,
+			expected: `<a href="#contact-us">Contact</a><h2 id="contact-us">Contact <em>us</em>!</h2>`,
+		},
+		{
+			name:     "Top of the page",
+			content:  `<a href="#top">Top</a><a href="#">Nothing</a>`,
+			expected: `<a href="#top">Top</a><a href="#">Nothing</a>`,
+		},
+		{
+			name:     "Broken anchor",
+			content:  `<a href="#missing">Missing</a><h2>Other</h2>`,
+			expected: `<a href="#missing">Missing</a><h2>Other</h2>`,
+			broken:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			got, err := Pipeline{Anchors{}}.Apply(tc.content, page)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+			if broken := strings.Contains(logs.String(), "Broken anchor #missing on /about"); broken != tc.broken {
+				t.Errorf("Expected broken anchor logged %v, got %q", tc.broken, logs.String())
+			}
+		})
+	}
+}
+
+func TestExternalLinks(t *testing.T) {
+	labels := map[string]string{"en": "(external)", "fr": "(externe)"}
+	testCases := []struct {
+		name     string
+		links    ExternalLinks
+		lang     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Internal links",
+			content:  `<a href="/about">About</a><a href="mailto:info@example.com">Email</a>`,
+			expected: `<a href="/about">About</a><a href="mailto:info@example.com">Email</a>`,
+		},
+		{
+			name:     "External link",
+			content:  `<a href="https://www.canada.ca/" rel="noopener" class="button">Canada</a>`,
+			expected: `<a href="https://www.canada.ca/" rel="noopener external noreferrer" class="button external-link">Canada</a>`,
+		},
+		{
+			name:     "Site hosts",
+			links:    ExternalLinks{SiteHosts: []string{"www.example.com"}},
+			content:  `<a href="https://WWW.example.com:443/about">About</a>`,
+			expected: `<a href="https://WWW.example.com:443/about">About</a>`,
+		},
+		{
+			name:     "New window",
+			links:    ExternalLinks{Target: ExternalTargetNew},
+			content:  `<a href="https://www.canada.ca/">Canada</a>`,
+			expected: `<a href="https://www.canada.ca/" rel="external noopener noreferrer" class="external-link" target="_blank">Canada</a>`,
+		},
+		{
+			name:     "Same window",
+			links:    ExternalLinks{Target: ExternalTargetSame},
+			content:  `<a href="https://www.canada.ca/" target="_blank">Canada</a>`,
+			expected: `<a href="https://www.canada.ca/" rel="external noopener noreferrer" class="external-link">Canada</a>`,
+		},
+		{
+			name:     "Target kept",
+			links:    ExternalLinks{Target: ExternalTargetKeep},
+			content:  `<a href="https://www.canada.ca/" target="_blank">Canada</a>`,
+			expected: `<a href="https://www.canada.ca/" target="_blank" rel="external noopener noreferrer" class="external-link">Canada</a>`,
+		},
+		{
+			name:     "Label in the content language",
+			links:    ExternalLinks{Labels: labels},
+			lang:     "fr",
+			content:  `<a href="https://www.canada.ca/">Canada</a><p lang="en"><a href="https://www.canada.ca/">Canada</a></p>`,
+			expected: `<a href="https://www.canada.ca/" rel="external noopener noreferrer" class="external-link">Canada<span class="external-link-label"> (externe)</span></a><p lang="en"><a href="https://www.canada.ca/" rel="external noopener noreferrer" class="external-link">Canada<span class="external-link-label"> (external)</span></a></p>`,
+		},
+		{
+			name:     "Label in the default language",
+			links:    ExternalLinks{Labels: labels},
+			lang:     "es",
+			content:  `<a href="https://www.canada.ca/">Canada</a>`,
+			expected: `<a href="https://www.canada.ca/" rel="external noopener noreferrer" class="external-link">Canada<span class="external-link-label"> (external)</span></a>`,
+		},
+		{
+			name:     "Already labelled",
+			links:    ExternalLinks{Labels: labels},
+			lang:     "en",
+			content:  `<a href="https://www.canada.ca/">Canada<span class="external-link-label"> (external)</span></a>`,
+			expected: `<a href="https://www.canada.ca/" rel="external noopener noreferrer" class="external-link">Canada<span class="external-link-label"> (external)</span></a>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Pipeline{tc.links}.Apply(tc.content, Page{Lang: tc.lang})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestPipelineWith(t *testing.T) {
+	links := ExternalLinks{Target: ExternalTargetNew}
+	pipeline := Default("https://example.com")
+	with := pipeline.With(links)
+
+	if len(with) != len(pipeline) || !reflect.DeepEqual(with[3], links) {
+		t.Errorf("Expected the external links transformer replaced, got %#v", with)
+	}
+	if reflect.DeepEqual(pipeline[3], links) {
+		t.Error("Expected the original pipeline unchanged")
+	}
+	if appended := (Pipeline{LazyImages{}}).With(links); len(appended) != 2 || !reflect.DeepEqual(appended[1], links) {
+		t.Errorf("Expected the transformer appended, got %#v", appended)
+	}
+}
+
+func TestCruft(t *testing.T) {
+	content := `<script>window._wpemojiSettings = {};</script><style id="wp-emoji-styles-inline-css">img.emoji {}</style>` +
+		`<link rel="stylesheet" id="wp-block-library-css" href="https://example.com/wp-includes/css/dist/block-library/style.min.css"/>` +
+		`<p>Hi <img class="wp-smiley" alt="🙂" src="https://s.w.org/images/core/emoji/15.0.3/svg/1f642.svg"/> there</p>` +
+		`<div class="ad-slot"><p>Ad</p></div><img class="emoji" src="/a.svg"/><script src="/app.js"></script>`
+
+	got, err := Default("https://example.com").Apply(content, Page{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := `<p>Hi 🙂 there</p><div class="ad-slot"><p>Ad</p></div>` +
+		`<img class="emoji" src="/a.svg" loading="lazy" decoding="async"/><script src="/app.js"></script>`
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	cruft, err := NewCruft([]string{"div.ad-slot", "script[src$=app.js]"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got, err = Pipeline{cruft}.Apply(content, Page{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Contains(got, "ad-slot") || strings.Contains(got, "app.js") || !strings.Contains(got, "_wpemojiSettings") {
+		t.Errorf("Expected only the configured selectors removed, got %q", got)
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	div := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div, Attr: []html.Attribute{
+		{Key: "id", Val: "main"}, {Key: "class", Val: "wp-block notice"}, {Key: "data-src", Val: "https://example.com/a.js"},
+	}}
+	div.AppendChild(&html.Node{Type: html.TextNode, Data: "Sponsored content"})
+
+	matches := map[string]bool{
+		"div":                           true,
+		"DIV#main":                      true,
+		"*.notice.wp-block":             true,
+		"[data-src]":                    true,
+		`div[data-src^="https://"]`:     true,
+		"[data-src$=.js]":               true,
+		"[data-src*=example.com]":       true,
+		"div:contains(Sponsored)":       true,
+		"span":                          false,
+		"#other":                        false,
+		".notice.other":                 false,
+		"[data-src=a.js]":               false,
+		"div:contains('Not sponsored')": false,
+		"div.notice[title]":             false,
+	}
+	for s, expected := range matches {
+		sel, err := ParseSelector(s)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %v", s, err)
+			continue
+		}
+		if sel.Match(div) != expected {
+			t.Errorf("Expected %q to match %v", s, expected)
+		}
+	}
+
+	for _, s := range []string{"", "*", "div >p", "#", "div[src", "[src~=a]", "[=a]", "div:hover"} {
+		if _, err := ParseSelector(s); err == nil {
+			t.Errorf("Expected %q to be invalid", s)
+		}
+	}
+}
+
+func TestLazyImages(t *testing.T) {
+	content := `<img src="/a.jpg"/><img src="/b.jpg" loading="eager" decoding="sync"/>`
+
+	got, err := Pipeline{LazyImages{}}.Apply(content, Page{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := `<img src="/a.jpg" loading="lazy" decoding="async"/><img src="/b.jpg" loading="eager" decoding="sync"/>`
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestResponsiveTables(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Table in a figure",
+			content:  `<figure class="wp-block-table"><table><tbody><tr><td>1</td></tr></tbody></table></figure>`,
+			expected: `<figure class="wp-block-table"><div class="table-responsive" tabindex="0"><table><tbody><tr><td>1</td></tr></tbody></table></div></figure>`,
+		},
+		{
+			name:     "Table with a caption",
+			content:  `<table><caption> Fees  by year </caption><tbody><tr><td>1</td></tr></tbody></table>`,
+			expected: `<div class="table-responsive" tabindex="0" role="region" aria-label="Fees by year"><table><caption> Fees  by year </caption><tbody><tr><td>1</td></tr></tbody></table></div>`,
+		},
+		{
+			name:     "Table already wrapped",
+			content:  `<div class="table-responsive"><table><tbody><tr><td>1</td></tr></tbody></table></div>`,
+			expected: `<div class="table-responsive"><table><tbody><tr><td>1</td></tr></tbody></table></div>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Pipeline{ResponsiveTables{}}.Apply(tc.content, Page{})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package warm
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// UserAgent identifies warming requests in the logs.
+const UserAgent = "wordpress-go-proxy-warmer"
+
+// ErrWarming is returned when a warm is requested while one is running.
+var ErrWarming = errors.New("cache warm already running")
+
+// MenuSource provides the menus whose links are warmed.
+type MenuSource interface {
+	Menus() map[string]*models.MenuData
+}
+
+// Result counts the paths requested by a warm.
+type Result struct {
+	Warmed int `json:"warmed"`
+	Failed int `json:"failed"`
+}
+
+// Warmer primes the caches by requesting the home page of each language
+// and every page linked from the menus, so that the first visitors after a
+// deploy are served from the cache.  Requests are served in process by
+// Handler, the same handler visitors reach, so pages are cached under the
+// keys those visitors look up.
+type Warmer struct {
+	Handler http.Handler
+	Menus   MenuSource
+
+	// Hosts are the hosts of absolute menu URLs that are the site's own,
+	// such as the WordPress and proxy hosts.  Links to other hosts are
+	// not warmed.
+	Hosts []string
+
+	// Concurrency is the number of pages requested at once.
+	Concurrency int
+
+	// Prefetch, if set, is called with the paths before they are
+	// requested, so that their pages can be fetched in batches.
+	Prefetch func(ctx context.Context, paths []string)
+
+	running sync.Mutex
+}
+
+// New creates a warmer for the pages handler serves, linked from the menus
+// of menus.
+func New(handler http.Handler, menus MenuSource, hosts []string) *Warmer {
+	return &Warmer{
+		Handler:     handler,
+		Menus:       menus,
+		Hosts:       hosts,
+		Concurrency: 1,
+	}
+}
+
+// Paths returns the paths to warm: the home page of each language followed
+// by the menu links of each language, without duplicates.
+func (w *Warmer) Paths() []string {
+	menus := w.Menus.Menus()
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, lang := range models.Languages {
+		add(models.Languages.Prefix(lang.Code))
+	}
+	for _, lang := range models.Languages {
+		if menu := menus[lang.Code]; menu != nil {
+			w.addItems(menu.Items, add)
+		}
+	}
+	return paths
+}
+
+// addItems adds the paths of menu items and their children.
+func (w *Warmer) addItems(items []*models.MenuItemData, add func(path string)) {
+	for _, item := range items {
+		if path, ok := w.localPath(item.Url); ok {
+			add(path)
+		}
+		w.addItems(item.Children, add)
+	}
+}
+
+// localPath returns the path of a menu URL that links to the site.
+func (w *Warmer) localPath(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	if u.Host != "" && !w.isLocal(u.Hostname()) {
+		return "", false
+	}
+	if u.Host == "" && !strings.HasPrefix(u.Path, "/") {
+		return "", false
+	}
+	if u.Path == "" {
+		return "/", true
+	}
+	return u.Path, true
+}
+
+// isLocal reports whether a host is one of the site's own.
+func (w *Warmer) isLocal(host string) bool {
+	for _, h := range w.Hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Warm requests every path to warm, Concurrency at a time, and returns how
+// many were served successfully.  Only one warm runs at a time, and
+// ErrWarming is returned if one is already running.  Paths not yet
+// requested when ctx is done are skipped.
+func (w *Warmer) Warm(ctx context.Context) (Result, error) {
+	if !w.running.TryLock() {
+		return Result{}, ErrWarming
+	}
+	defer w.running.Unlock()
+
+	start := time.Now()
+	paths := w.Paths()
+	if w.Prefetch != nil {
+		w.Prefetch(ctx, paths)
+	}
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var result Result
+	var wg sync.WaitGroup
+	for range max(w.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				ok := w.warm(ctx, path)
+				mu.Lock()
+				if ok {
+					result.Warmed++
+				} else {
+					result.Failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+
+	log.Printf("Warmed %d of %d paths in %v, %d failed", result.Warmed, len(paths), time.Since(start).Round(time.Millisecond), result.Failed)
+	return result, ctx.Err()
+}
+
+// warm requests a path, reporting whether it was served without error.
+// Redirects count as served, since the redirect itself is what visitors
+// of the path get.
+func (w *Warmer) warm(ctx context.Context, path string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		log.Printf("Error warming %s: %v", path, err)
+		return false
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	rw := &discardWriter{header: make(http.Header), status: http.StatusOK}
+	w.Handler.ServeHTTP(rw, req)
+	if rw.status >= http.StatusBadRequest {
+		log.Printf("Error warming %s: status %d", path, rw.status)
+		return false
+	}
+	return true
+}
+
+// discardWriter is a response writer that keeps the status of a response
+// and discards its body.
+type discardWriter struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+}
+
+func (d *discardWriter) Header() http.Header {
+	return d.header
+}
+
+func (d *discardWriter) WriteHeader(status int) {
+	if !d.wroteHeader {
+		d.status = status
+		d.wroteHeader = true
+	}
+}
+
+func (d *discardWriter) Write(b []byte) (int, error) {
+	d.wroteHeader = true
+	return len(b), nil
+}
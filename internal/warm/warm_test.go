@@ -0,0 +1,130 @@
+package warm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+
+	"wordpress-go-proxy/pkg/models"
+)
+
+// fakeMenus returns fixed menus
+type fakeMenus map[string]*models.MenuData
+
+func (f fakeMenus) Menus() map[string]*models.MenuData {
+	return f
+}
+
+var testMenus = fakeMenus{
+	"en": {Items: []*models.MenuItemData{
+		{Title: "About", Url: "https://wordpress.example.com/about"},
+		{Title: "Services", Url: "/services", Children: []*models.MenuItemData{
+			{Title: "Benefits", Url: "https://WWW.example.com/services/benefits/"},
+			{Title: "Partner", Url: "https://partner.example.org/"},
+		}},
+		{Title: "Contact", Url: "mailto:info@example.com"},
+		{Title: "About again", Url: "/about"},
+	}},
+	"fr": {Items: []*models.MenuItemData{
+		{Title: "À propos", Url: "https://www.example.com/fr/a-propos"},
+		{Title: "Accueil", Url: "https://www.example.com"},
+		{Title: "Relative", Url: "a-propos"},
+	}},
+}
+
+func TestPaths(t *testing.T) {
+	w := New(http.NotFoundHandler(), testMenus, []string{"wordpress.example.com", "www.example.com"})
+
+	expected := []string{"/", "/fr/", "/about", "/services", "/services/benefits/", "/fr/a-propos"}
+	if paths := w.Paths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected paths %v, got %v", expected, paths)
+	}
+}
+
+func TestWarm(t *testing.T) {
+	var mu sync.Mutex
+	requested := make(map[string]string)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = r.UserAgent()
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/services/benefits/":
+			http.Redirect(w, r, "/services/benefits", http.StatusMovedPermanently)
+		case "/fr/a-propos":
+			http.Error(w, "Error", http.StatusInternalServerError)
+		default:
+			w.Write([]byte("<html></html>"))
+		}
+	})
+
+	w := New(handler, testMenus, []string{"wordpress.example.com", "www.example.com"})
+	w.Concurrency = 3
+	var prefetched []string
+	w.Prefetch = func(ctx context.Context, paths []string) {
+		prefetched = paths
+	}
+	result, err := w.Warm(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result != (Result{Warmed: 5, Failed: 1}) {
+		t.Errorf("Expected 5 warmed and 1 failed, got %+v", result)
+	}
+	if len(requested) != 6 {
+		t.Errorf("Expected 6 paths requested, got %v", requested)
+	}
+	if len(prefetched) != 6 {
+		t.Errorf("Expected paths prefetched before they are requested, got %v", prefetched)
+	}
+	for path, userAgent := range requested {
+		if userAgent != UserAgent {
+			t.Errorf("%s: expected user agent %q, got %q", path, UserAgent, userAgent)
+		}
+	}
+}
+
+// TestWarmRunning tests that warms do not overlap
+func TestWarmRunning(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			close(started)
+			<-release
+		}
+	})
+	w := New(handler, fakeMenus{}, nil)
+
+	done := make(chan Result)
+	go func() {
+		result, _ := w.Warm(context.Background())
+		done <- result
+	}()
+	<-started
+
+	if _, err := w.Warm(context.Background()); !errors.Is(err, ErrWarming) {
+		t.Errorf("Expected ErrWarming, got %v", err)
+	}
+	close(release)
+	if result := <-done; result.Warmed != 2 {
+		t.Errorf("Expected 2 paths warmed, got %+v", result)
+	}
+}
+
+func TestWarmCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := New(http.NotFoundHandler(), testMenus, nil)
+
+	result, err := w.Warm(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if result.Warmed+result.Failed != 0 {
+		t.Errorf("Expected canceled warm to stop requesting, got %+v", result)
+	}
+}
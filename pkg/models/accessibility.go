@@ -0,0 +1,67 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+)
+
+// IDs of the landmarks of the layout that skip links jump to.
+const (
+	MainContentID = "main-content"
+	SectionNavID  = "section-nav"
+)
+
+// SkipLink is a link letting keyboard and screen reader users skip past
+// the header to a landmark of the page.  Target is the ID of the landmark
+// and Label the key of its message.
+type SkipLink struct {
+	Target string
+	Label  string
+}
+
+// SkipLinks returns the skip links of the page, to the main content and to
+// each other landmark the page has.
+func (d PageData) SkipLinks() []SkipLink {
+	links := []SkipLink{{Target: MainContentID, Label: "skip.main"}}
+	if len(d.SectionNav) > 0 {
+		links = append(links, SkipLink{Target: SectionNavID, Label: "skip.section"})
+	}
+	return links
+}
+
+// AriaCurrent returns the aria-current attribute of the menu item, "page"
+// if it links to the page being rendered and empty otherwise.
+func (n NavItem) AriaCurrent() string {
+	if n.Current() {
+		return "page"
+	}
+	return ""
+}
+
+// Lang returns the language of the page the menu item links to if it is
+// not the language of the page being rendered, such as a link to the
+// translated site, so that its title is marked as a part in another
+// language.  It is empty for links to other sites.
+func (n NavItem) Lang() string {
+	path, ok := navPath(n.Url)
+	if !ok || n.PageLang == "" {
+		return ""
+	}
+	if lang := Languages.FromPath(path); lang.Code != n.PageLang {
+		return lang.Code
+	}
+	return ""
+}
+
+// navPath returns the decoded path of a menu item's link without its
+// trailing slash, reporting false if it links to another site.
+func navPath(link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return "", false
+	}
+	if u.Path == "/" {
+		return u.Path, true
+	}
+	return strings.TrimSuffix(u.Path, "/"), true
+}
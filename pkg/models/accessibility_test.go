@@ -0,0 +1,74 @@
+package models
+
+import "testing"
+
+// TestSkipLinks tests that pages skip to the section menu when they have
+// one
+func TestSkipLinks(t *testing.T) {
+	links := PageData{}.SkipLinks()
+	if len(links) != 1 || links[0].Target != MainContentID {
+		t.Errorf("Expected a skip link to the main content, got %+v", links)
+	}
+
+	links = PageData{SectionNav: []NavLink{{Title: "Renew", Url: "/renew"}}}.SkipLinks()
+	if len(links) != 2 || links[1].Target != SectionNavID {
+		t.Errorf("Expected a skip link to the section menu, got %+v", links)
+	}
+}
+
+// TestNavItemsCurrentPath tests that menu items are current by the path
+// of the page, ignoring trailing slashes and encoding
+func TestNavItemsCurrentPath(t *testing.T) {
+	tests := []struct {
+		url      string
+		path     string
+		expected bool
+	}{
+		{"/renew/", "/renew", true},
+		{"/renew", "/renew/", true},
+		{"/fr/%C3%A9t%C3%A9/", "/fr/été", true},
+		{"/renew?step=2", "/renew", true},
+		{"/", "/", true},
+		{"/renew", "/services/renew", false},
+		{"https://example.com/renew", "/renew", false},
+		{"#", "/renew", false},
+	}
+	for _, tt := range tests {
+		page := PageData{Title: "Other", Path: tt.path, Menu: &MenuData{Items: []*MenuItemData{{Title: "Renew", Url: tt.url}}}}
+		item := page.NavItems()[0]
+		if item.Current() != tt.expected {
+			t.Errorf("Expected current %v for %q on %q, got %v", tt.expected, tt.url, tt.path, item.Current())
+		}
+		expected := ""
+		if tt.expected {
+			expected = "page"
+		}
+		if item.AriaCurrent() != expected {
+			t.Errorf("Expected aria-current %q for %q on %q, got %q", expected, tt.url, tt.path, item.AriaCurrent())
+		}
+	}
+}
+
+// TestNavItemLang tests that links to pages in another language are marked
+// with it at every depth
+func TestNavItemLang(t *testing.T) {
+	page := PageData{Lang: "en", Path: "/about", Menu: &MenuData{Items: []*MenuItemData{
+		{Title: "Français", Url: "/fr/"},
+		{Title: "More", Url: "#", Children: []*MenuItemData{
+			{Title: "À propos", Url: "/fr/a-propos"},
+			{Title: "Contact", Url: "/contact"},
+			{Title: "Partner", Url: "https://example.com/fr/"},
+		}},
+	}}}
+
+	items := page.NavItems()
+	if items[0].Lang() != "fr" || items[1].Lang() != "" {
+		t.Errorf("Expected only the French link marked, got %q and %q", items[0].Lang(), items[1].Lang())
+	}
+	children := items[1].NavChildren()
+	for i, expected := range []string{"fr", "", ""} {
+		if children[i].Lang() != expected {
+			t.Errorf("Expected %q marked %q, got %q", children[i].Title, expected, children[i].Lang())
+		}
+	}
+}
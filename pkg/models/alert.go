@@ -0,0 +1,37 @@
+package models
+
+import (
+	"html"
+	"html/template"
+	"slices"
+)
+
+// AlertTypes are the types of notice an alert can be shown as, the first
+// being the default.
+var AlertTypes = []string{"warning", "danger", "info", "success"}
+
+// Alert is a site-wide notification banner, such as of a service
+// disruption, shown at the top of every page.  Title is plain text, and
+// Message the rendered content of the alert in WordPress.
+type Alert struct {
+	ID      int
+	Type    string
+	Title   string
+	Message template.HTML
+}
+
+// NewAlert creates an alert from a post of the alerts route.  Its type is
+// read from its alert_type field, and is a warning if the field is missing
+// or not one of AlertTypes.
+func NewAlert(post *WordPressPage) Alert {
+	alertType := post.Fields().Text("alert_type")
+	if !slices.Contains(AlertTypes, alertType) {
+		alertType = AlertTypes[0]
+	}
+	return Alert{
+		ID:      post.ID,
+		Type:    alertType,
+		Title:   html.UnescapeString(post.Title.Rendered),
+		Message: template.HTML(post.Content.Rendered),
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+// Color schemes pages can be rendered in, chosen by visitors or preferred
+// by their browsers.
+const (
+	ColorSchemeLight    = "light"
+	ColorSchemeDark     = "dark"
+	ColorSchemeContrast = "high-contrast"
+)
+
+// ColorSchemes are the color schemes visitors can choose.
+var ColorSchemes = []string{ColorSchemeLight, ColorSchemeDark, ColorSchemeContrast}
+
+// ColorSchemeData holds the color scheme a page is rendered in, and the
+// data needed to render the form switching it.  Name is the scheme the
+// visitor chose or their browser prefers, or empty if neither is known, in
+// which case the scheme's stylesheets apply by media query.  Chosen reports
+// whether the visitor chose it, and Path is the path of the page visitors
+// are sent back to after switching.
+type ColorSchemeData struct {
+	Name        string
+	Chosen      bool
+	Action      string
+	Path        string
+	Stylesheets []StylesheetLink
+}
+
+// StylesheetLink is a stylesheet of a color scheme.  Media is the media
+// query it applies to, or empty if it always applies.
+type StylesheetLink struct {
+	Href  string
+	Media string
+}
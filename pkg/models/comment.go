@@ -0,0 +1,93 @@
+package models
+
+import (
+	"html/template"
+	"time"
+)
+
+// Outcomes of a comment submission, passed back to the post in the comment
+// query parameter so the form can report them.
+const (
+	CommentPending  = "pending"
+	CommentInvalid  = "invalid"
+	CommentRejected = "rejected"
+	CommentLimited  = "limited"
+	CommentError    = "error"
+)
+
+// CommentStatuses are the outcomes of a comment submission.
+var CommentStatuses = []string{CommentPending, CommentInvalid, CommentRejected, CommentLimited, CommentError}
+
+// WordPressComment represents an approved comment on a post as returned by
+// the WordPress REST API.  Parent is the ID of the comment it replies to,
+// or 0.
+type WordPressComment struct {
+	ID         int    `json:"id"`
+	Post       int    `json:"post"`
+	Parent     int    `json:"parent"`
+	AuthorName string `json:"author_name"`
+	Date       string `json:"date"`
+	DateGMT    string `json:"date_gmt"`
+	Content    struct {
+		Rendered string `json:"rendered"`
+	} `json:"content"`
+}
+
+// CommentData holds the data needed to render a comment and its replies.
+type CommentData struct {
+	ID         int
+	AuthorName string
+	Date       time.Time
+	Content    template.HTML
+	Replies    []CommentData
+}
+
+// CommentFormData holds the data needed to render the form that submits a
+// comment on a post.  Path is the path of the post visitors are sent back
+// to, and Status the outcome of their last submission, if any.  CSRFToken
+// is the visitor's CSRF token, submitted with the form.
+type CommentFormData struct {
+	Lang      string
+	Action    string
+	PostID    int
+	Path      string
+	Status    string
+	CSRFToken string
+}
+
+// NewCommentThreads nests comments under the comments they reply to, in
+// the order they are given.  Replies to comments that are not listed, such
+// as ones awaiting moderation, are shown at the top level.
+func NewCommentThreads(comments []WordPressComment) []CommentData {
+	children := make(map[int][]WordPressComment)
+	listed := make(map[int]bool, len(comments))
+	for _, comment := range comments {
+		listed[comment.ID] = true
+	}
+	var roots []WordPressComment
+	for _, comment := range comments {
+		if comment.Parent != 0 && listed[comment.Parent] && comment.Parent != comment.ID {
+			children[comment.Parent] = append(children[comment.Parent], comment)
+		} else {
+			roots = append(roots, comment)
+		}
+	}
+
+	var thread func(comments []WordPressComment) []CommentData
+	thread = func(comments []WordPressComment) []CommentData {
+		var data []CommentData
+		for _, comment := range comments {
+			replies := children[comment.ID]
+			delete(children, comment.ID)
+			data = append(data, CommentData{
+				ID:         comment.ID,
+				AuthorName: comment.AuthorName,
+				Date:       parseSiteTime(comment.Date, comment.DateGMT),
+				Content:    template.HTML(comment.Content.Rendered),
+				Replies:    thread(replies),
+			})
+		}
+		return data
+	}
+	return thread(roots)
+}
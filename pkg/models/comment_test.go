@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewCommentThreads(t *testing.T) {
+	comment := func(id int, parent int) WordPressComment {
+		c := WordPressComment{ID: id, Parent: parent, AuthorName: "Ada", Date: "2024-05-01T10:00:00"}
+		c.Content.Rendered = "<p>Hello</p>"
+		return c
+	}
+	// Comment 5 replies to a comment awaiting moderation
+	threads := NewCommentThreads([]WordPressComment{comment(1, 0), comment(2, 1), comment(3, 0), comment(4, 2), comment(5, 9)})
+
+	if len(threads) != 3 || threads[0].ID != 1 || threads[1].ID != 3 || threads[2].ID != 5 {
+		t.Fatalf("Expected top level comments 1, 3 and 5, got %+v", threads)
+	}
+	if len(threads[0].Replies) != 1 || threads[0].Replies[0].ID != 2 {
+		t.Errorf("Expected comment 2 to reply to comment 1, got %+v", threads[0].Replies)
+	}
+	if replies := threads[0].Replies[0].Replies; len(replies) != 1 || replies[0].ID != 4 {
+		t.Errorf("Expected comment 4 to reply to comment 2, got %+v", replies)
+	}
+	if !threads[0].Date.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) || threads[0].Content != "<p>Hello</p>" {
+		t.Errorf("Unexpected comment data: %+v", threads[0])
+	}
+	if NewCommentThreads(nil) != nil {
+		t.Error("Expected no threads without comments")
+	}
+}
@@ -0,0 +1,219 @@
+package models
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// WordPressPost represents a WordPress post JSON response.
+type WordPressPost struct {
+	ID          int    `json:"id"`
+	Slug        string `json:"slug"`
+	Lang        string `json:"lang"`
+	Link        string `json:"link"`
+	DateGmt     string `json:"date_gmt"`
+	ModifiedGmt string `json:"modified_gmt"`
+	Title       struct {
+		Rendered string `json:"rendered"`
+	} `json:"title"`
+	Excerpt struct {
+		Rendered string `json:"rendered"`
+	} `json:"excerpt"`
+}
+
+// FeedInfo describes the site a feed is generated for.  Origin is the
+// scheme and host of the proxy and BaseUrl is the WordPress origin.  The
+// Description defaults to the Title if empty.
+type FeedInfo struct {
+	Title       string
+	Description string
+	Lang        string
+	Origin      string
+	Home        string
+	FeedPath    string
+	BaseUrl     string
+}
+
+// RSSFeed is an RSS 2.0 document.
+type RSSFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+// RSSChannel is the channel element of an RSS 2.0 document.
+type RSSChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	AtomLink      AtomLink  `xml:"atom:link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []RSSItem `xml:"item"`
+}
+
+// RSSItem is an item element of an RSS 2.0 document.
+type RSSItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Guid        RSSGuid `xml:"guid"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+}
+
+// RSSGuid is the unique identifier of an RSS item.
+type RSSGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// AtomFeed is an Atom 1.0 document.
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang    string      `xml:"xml:lang,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+// AtomLink is a link element of an Atom document.
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// AtomEntry is an entry element of an Atom document.
+type AtomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Link      AtomLink    `xml:"link"`
+	Published string      `xml:"published,omitempty"`
+	Updated   string      `xml:"updated"`
+	Summary   AtomSummary `xml:"summary"`
+}
+
+// AtomSummary is the summary of an Atom entry.
+type AtomSummary struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// NewRSSFeed creates an RSS 2.0 feed from a list of posts.  Post links
+// are rewritten from the WordPress origin to the site URL.
+func NewRSSFeed(info FeedInfo, posts []WordPressPost) RSSFeed {
+	description := info.Description
+	if description == "" {
+		description = info.Title
+	}
+	channel := RSSChannel{
+		Title:       info.Title,
+		Link:        info.Origin + info.Home,
+		AtomLink:    AtomLink{Href: info.Origin + info.FeedPath, Rel: "self", Type: "application/rss+xml"},
+		Description: description,
+		Language:    info.Lang,
+		Items:       make([]RSSItem, 0, len(posts)),
+	}
+
+	var lastBuild time.Time
+	for _, post := range posts {
+		link := feedLink(post.Link, info)
+		item := RSSItem{
+			Title:       post.Title.Rendered,
+			Link:        link,
+			Guid:        RSSGuid{IsPermaLink: true, Value: link},
+			Description: post.Excerpt.Rendered,
+		}
+		if published, ok := parseGmt(post.DateGmt); ok {
+			item.PubDate = siteTime(published).Format(time.RFC1123Z)
+		}
+		if modified, ok := parseGmt(post.ModifiedGmt); ok && modified.After(lastBuild) {
+			lastBuild = modified
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	if !lastBuild.IsZero() {
+		channel.LastBuildDate = siteTime(lastBuild).Format(time.RFC1123Z)
+	}
+
+	return RSSFeed{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: channel,
+	}
+}
+
+// NewAtomFeed creates an Atom 1.0 feed from a list of posts.  Post links
+// are rewritten from the WordPress origin to the site URL.
+func NewAtomFeed(info FeedInfo, posts []WordPressPost) AtomFeed {
+	feed := AtomFeed{
+		Lang:  info.Lang,
+		ID:    info.Origin + info.FeedPath,
+		Title: info.Title,
+		Links: []AtomLink{
+			{Href: info.Origin + info.Home, Rel: "alternate", Type: "text/html"},
+			{Href: info.Origin + info.FeedPath, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: make([]AtomEntry, 0, len(posts)),
+	}
+
+	var updated time.Time
+	for _, post := range posts {
+		link := feedLink(post.Link, info)
+		entry := AtomEntry{
+			ID:      link,
+			Title:   post.Title.Rendered,
+			Link:    AtomLink{Href: link, Rel: "alternate", Type: "text/html"},
+			Summary: AtomSummary{Type: "html", Value: post.Excerpt.Rendered},
+		}
+		published, hasPublished := parseGmt(post.DateGmt)
+		if hasPublished {
+			entry.Published = siteTime(published).Format(time.RFC3339)
+		}
+		modified, hasModified := parseGmt(post.ModifiedGmt)
+		if !hasModified {
+			modified = published
+		}
+		entry.Updated = siteTime(modified).Format(time.RFC3339)
+		if modified.After(updated) {
+			updated = modified
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	feed.Updated = siteTime(updated).Format(time.RFC3339)
+
+	return feed
+}
+
+// feedLink rewrites a WordPress permalink to an absolute URL on the site.
+func feedLink(link string, info FeedInfo) string {
+	if path, ok := InternalPath(link, info.BaseUrl); ok {
+		return info.Origin + path
+	}
+	return link
+}
+
+// wordPressTime is the layout of WordPress timestamps, which have no time
+// zone.
+const wordPressTime = "2006-01-02T15:04:05"
+
+// parseGmt parses a WordPress GMT timestamp, which has no time zone.
+func parseGmt(value string) (time.Time, bool) {
+	t, err := time.Parse(wordPressTime, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.UTC(), true
+}
+
+// siteTime returns a time in the site's Timezone.  The zero time, of feeds
+// without dated posts, is left in UTC.
+func siteTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.In(Timezone)
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPosts creates posts for feed tests
+func testPosts() []WordPressPost {
+	post := WordPressPost{
+		ID:          1,
+		Slug:        "news",
+		Link:        "https://wp.example.com/2024/05/news/",
+		DateGmt:     "2024-05-15T10:30:45",
+		ModifiedGmt: "2024-05-16T08:00:00",
+	}
+	post.Title.Rendered = "News &amp; updates"
+	post.Excerpt.Rendered = "<p>Latest news</p>"
+
+	external := WordPressPost{
+		ID:      2,
+		Link:    "https://other.example.com/post",
+		DateGmt: "not a date",
+	}
+	return []WordPressPost{post, external}
+}
+
+var testFeedInfo = FeedInfo{
+	Title:    "Example Site",
+	Lang:     "en",
+	Origin:   "https://proxy.example.com",
+	Home:     "/",
+	FeedPath: "/feed.xml",
+	BaseUrl:  "https://wp.example.com",
+}
+
+func TestNewRSSFeed(t *testing.T) {
+	feed := NewRSSFeed(testFeedInfo, testPosts())
+
+	if feed.Version != "2.0" {
+		t.Errorf("Expected version 2.0, got %q", feed.Version)
+	}
+	if feed.Channel.Link != "https://proxy.example.com/" {
+		t.Errorf("Expected channel link to the proxy home, got %q", feed.Channel.Link)
+	}
+	if feed.Channel.AtomLink.Href != "https://proxy.example.com/feed.xml" {
+		t.Errorf("Expected self link to the feed, got %q", feed.Channel.AtomLink.Href)
+	}
+	if feed.Channel.LastBuildDate != "Thu, 16 May 2024 08:00:00 +0000" {
+		t.Errorf("Expected last build date from the latest modified post, got %q", feed.Channel.LastBuildDate)
+	}
+	if len(feed.Channel.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(feed.Channel.Items))
+	}
+
+	item := feed.Channel.Items[0]
+	if item.Link != "https://proxy.example.com/2024/05/news/" {
+		t.Errorf("Expected link rewritten to the proxy, got %q", item.Link)
+	}
+	if item.Guid.Value != item.Link || !item.Guid.IsPermaLink {
+		t.Errorf("Expected permalink guid, got %+v", item.Guid)
+	}
+	if item.PubDate != "Wed, 15 May 2024 10:30:45 +0000" {
+		t.Errorf("Expected RFC 1123 publication date, got %q", item.PubDate)
+	}
+	if feed.Channel.Items[1].Link != "https://other.example.com/post" {
+		t.Errorf("Expected external link to be unchanged, got %q", feed.Channel.Items[1].Link)
+	}
+	if feed.Channel.Items[1].PubDate != "" {
+		t.Errorf("Expected invalid date to be omitted, got %q", feed.Channel.Items[1].PubDate)
+	}
+
+	out, err := xml.Marshal(feed)
+	if err != nil {
+		t.Fatalf("Error marshalling feed: %v", err)
+	}
+	if !strings.Contains(string(out), `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">`) {
+		t.Errorf("Expected RSS root element, got %s", out)
+	}
+	if !strings.Contains(string(out), "<description>&lt;p&gt;Latest news&lt;/p&gt;</description>") {
+		t.Errorf("Expected escaped excerpt, got %s", out)
+	}
+}
+
+func TestNewAtomFeed(t *testing.T) {
+	info := testFeedInfo
+	info.Lang = "fr"
+	info.Home = "/fr/"
+	info.FeedPath = "/fr/atom.xml"
+
+	feed := NewAtomFeed(info, testPosts())
+
+	if feed.ID != "https://proxy.example.com/fr/atom.xml" {
+		t.Errorf("Expected feed ID to be the feed URL, got %q", feed.ID)
+	}
+	if feed.Links[0].Href != "https://proxy.example.com/fr/" {
+		t.Errorf("Expected alternate link to the French home, got %q", feed.Links[0].Href)
+	}
+	if feed.Updated != "2024-05-16T08:00:00Z" {
+		t.Errorf("Expected updated from the latest modified post, got %q", feed.Updated)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(feed.Entries))
+	}
+
+	entry := feed.Entries[0]
+	if entry.Link.Href != "https://proxy.example.com/2024/05/news/" {
+		t.Errorf("Expected link rewritten to the proxy, got %q", entry.Link.Href)
+	}
+	if entry.Published != "2024-05-15T10:30:45Z" || entry.Updated != "2024-05-16T08:00:00Z" {
+		t.Errorf("Expected RFC 3339 dates, got published %q updated %q", entry.Published, entry.Updated)
+	}
+	if entry.Summary.Type != "html" {
+		t.Errorf("Expected HTML summary, got %q", entry.Summary.Type)
+	}
+
+	out, err := xml.Marshal(feed)
+	if err != nil {
+		t.Fatalf("Error marshalling feed: %v", err)
+	}
+	if !strings.HasPrefix(string(out), `<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="fr">`) {
+		t.Errorf("Expected Atom root element, got %s", out)
+	}
+}
+
+func TestFeedSiteSettings(t *testing.T) {
+	originalTimezone := Timezone
+	Timezone = time.FixedZone("EDT", -4*3600)
+	defer func() { Timezone = originalTimezone }()
+
+	info := testFeedInfo
+	info.Description = "A tagline"
+	rss := NewRSSFeed(info, testPosts())
+	if rss.Channel.Description != "A tagline" {
+		t.Errorf("Expected the tagline as description, got %q", rss.Channel.Description)
+	}
+	if rss.Channel.Items[0].PubDate != "Wed, 15 May 2024 06:30:45 -0400" {
+		t.Errorf("Expected the publication date in the site's timezone, got %q", rss.Channel.Items[0].PubDate)
+	}
+
+	atom := NewAtomFeed(info, testPosts())
+	if atom.Updated != "2024-05-16T04:00:00-04:00" {
+		t.Errorf("Expected updated in the site's timezone, got %q", atom.Updated)
+	}
+	if empty := NewAtomFeed(info, nil); empty.Updated != "0001-01-01T00:00:00Z" {
+		t.Errorf("Expected the zero time in UTC, got %q", empty.Updated)
+	}
+}
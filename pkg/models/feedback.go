@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Outcomes of a feedback submission, passed back to the page in the
+// feedback query parameter so the widget can report them.
+const (
+	FeedbackThanks = "thanks"
+	FeedbackError  = "error"
+)
+
+// FeedbackStatuses are the outcomes of a feedback submission.
+var FeedbackStatuses = []string{FeedbackThanks, FeedbackError}
+
+// PageFeedback is a visitor's answer to whether a page was helpful.
+type PageFeedback struct {
+	PageID  int       `json:"page_id"`
+	Lang    string    `json:"lang"`
+	Helpful bool      `json:"helpful"`
+	Time    time.Time `json:"timestamp"`
+}
+
+// FeedbackData holds the data needed to render the widget asking visitors
+// whether a page was helpful.  Path is the path of the page visitors are
+// sent back to, and Status the outcome of their last answer, if any.
+type FeedbackData struct {
+	Lang   string
+	Action string
+	PageID int
+	Path   string
+	Status string
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Fields are the custom fields of a page or post, such as its Advanced
+// Custom Fields, for templates to render structured content like banners,
+// alerts and calls to action.  Their methods look up a field by a path of
+// names separated by dots, with numbers indexing into lists, such as
+// "banner.links.0.url".  A missing field is never an error, so templates
+// can use fields that only some pages have.
+type Fields map[string]any
+
+// Get returns the value of a field, or nil if there is none.
+func (f Fields) Get(path string) any {
+	var value any = map[string]any(f)
+	for _, name := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[name]
+		case Fields:
+			value = v[name]
+		case []any:
+			i, err := strconv.Atoi(name)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// Text returns a field as text.  Numbers and booleans are formatted, and
+// lists, objects and missing fields are empty.
+func (f Fields) Text(path string) string {
+	switch v := f.Get(path).(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool, int:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// Has reports whether a field is set.  ACF sends false or an empty value
+// for fields that were left blank, which are not set.
+func (f Fields) Has(path string) bool {
+	switch v := f.Get(path).(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	case Fields:
+		return len(v) > 0
+	}
+	return true
+}
+
+// List returns the items of a list field, such as an ACF repeater, with
+// each object item as Fields.  It is empty if the field is not a list.
+func (f Fields) List(path string) []any {
+	v, ok := f.Get(path).([]any)
+	if !ok {
+		return nil
+	}
+	items := make([]any, len(v))
+	for i, item := range v {
+		if object, ok := item.(map[string]any); ok {
+			item = Fields(object)
+		}
+		items[i] = item
+	}
+	return items
+}
+
+// Sub returns an object field, such as an ACF group, as Fields so it can
+// be passed to a partial.  It is nil if the field is not an object.
+func (f Fields) Sub(path string) Fields {
+	switch v := f.Get(path).(type) {
+	case map[string]any:
+		return v
+	case Fields:
+		return v
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func testFields(t *testing.T) Fields {
+	t.Helper()
+	var fields Fields
+	err := json.Unmarshal([]byte(`{
+		"banner": {"title": "Apply now", "links": [{"url": "/apply", "label": "Apply"}]},
+		"count": 3,
+		"ratio": 0.5,
+		"featured": true,
+		"hidden": false,
+		"subtitle": "",
+		"tags": []
+	}`), &fields)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return fields
+}
+
+func TestFieldsText(t *testing.T) {
+	fields := testFields(t)
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"banner.title", "Apply now"},
+		{"banner.links.0.url", "/apply"},
+		{"count", "3"},
+		{"ratio", "0.5"},
+		{"featured", "true"},
+		{"banner", ""},
+		{"banner.links.1.url", ""},
+		{"banner.links.url", ""},
+		{"banner.title.text", ""},
+		{"missing", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := fields.Text(tc.path); got != tc.expected {
+			t.Errorf("Text(%q): expected %q, got %q", tc.path, tc.expected, got)
+		}
+	}
+
+	var empty Fields
+	if got := empty.Text("banner.title"); got != "" {
+		t.Errorf("Expected nil fields to be empty, got %q", got)
+	}
+}
+
+func TestFieldsHas(t *testing.T) {
+	fields := testFields(t)
+
+	for path, expected := range map[string]bool{
+		"banner":       true,
+		"banner.title": true,
+		"count":        true,
+		"featured":     true,
+		"hidden":       false,
+		"subtitle":     false,
+		"tags":         false,
+		"missing":      false,
+	} {
+		if got := fields.Has(path); got != expected {
+			t.Errorf("Has(%q): expected %v, got %v", path, expected, got)
+		}
+	}
+}
+
+func TestFieldsListAndSub(t *testing.T) {
+	fields := testFields(t)
+
+	links := fields.List("banner.links")
+	if len(links) != 1 {
+		t.Fatalf("Expected 1 link, got %v", links)
+	}
+	link, ok := links[0].(Fields)
+	if !ok || link.Text("label") != "Apply" {
+		t.Errorf("Expected list items as fields, got %#v", links[0])
+	}
+	if fields.List("banner.title") != nil {
+		t.Error("Expected non-list field to have no items")
+	}
+
+	banner := fields.Sub("banner")
+	if banner.Text("title") != "Apply now" {
+		t.Errorf("Expected banner group, got %v", banner)
+	}
+	if fields.Sub("count") != nil || fields.Sub("missing") != nil {
+		t.Error("Expected non-object fields to have no sub fields")
+	}
+}
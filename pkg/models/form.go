@@ -0,0 +1,107 @@
+package models
+
+import "html/template"
+
+// Form is a form of the WordPress forms plugin that the proxy renders and
+// forwards submissions of.  The form is served from /forms/<Name> under
+// each language prefix and its fields are rendered with the
+// form-<Name>.html template of the theme.  ID is the form's ID in the
+// plugin.
+type Form struct {
+	Name string
+	ID   string
+}
+
+// Path returns the path of the form in a language.
+func (f Form) Path(lang string) string {
+	return Languages.Prefix(lang) + "forms/" + f.Name
+}
+
+// ThanksPath returns the path of the page shown once the form is sent.
+func (f Form) ThanksPath(lang string) string {
+	return f.Path(lang) + "/thanks"
+}
+
+// Title returns the title of the form's page, its name with the first
+// letter capitalized and dashes replaced by spaces.
+func (f Form) Title() string {
+	return humanize(f.Name)
+}
+
+// Form field errors, rendered by the form-error partial.
+const (
+	FieldRequired = "required"
+	FieldEmail    = "email"
+	FieldLength   = "length"
+)
+
+// FormData holds the data needed to render a form.  Values are those the
+// visitor submitted, and Errors the problem with each invalid field.
+// Fields is the rendered form-<name>.html template, and Token the signed
+// token submitted with the form to show it was served by the proxy.
+// CSRFToken is the visitor's CSRF token, also submitted with the form.
+// Expired is set if the token of a submission was invalid and Failed if
+// the form could not be forwarded.
+type FormData struct {
+	Lang      string
+	Name      string
+	Action    string
+	Token     string
+	CSRFToken string
+	Fields    template.HTML
+	Values    map[string]string
+	Errors    map[string]string
+	Expired   bool
+	Failed    bool
+}
+
+// Value returns the submitted value of a field.
+func (d FormData) Value(name string) string {
+	return d.Values[name]
+}
+
+// Error returns the error of a field, or nil if the field is valid.
+func (d FormData) Error(name string) *FieldErrorData {
+	code, ok := d.Errors[name]
+	if !ok {
+		return nil
+	}
+	return &FieldErrorData{Lang: d.Lang, Code: code}
+}
+
+// FieldErrorData holds the data needed to render the error of a form
+// field.  Code is one of FieldRequired, FieldEmail or FieldLength.
+type FieldErrorData struct {
+	Lang string
+	Code string
+}
+
+// FormThanksData holds the data needed to render the page shown once a
+// form is sent.
+type FormThanksData struct {
+	Lang string
+	Name string
+	Home string
+}
+
+// NewFormPageData creates the data used to render a form, or the page
+// shown once it is sent, inside the site layout.  path returns the path of
+// the page in a language.
+func NewFormPageData(lang string, form Form, path func(lang string) string, content template.HTML, menu *MenuData, siteNames map[string]string) PageData {
+	language := Languages.Resolve(lang)
+	data := PageData{
+		Lang:           language.Code,
+		Home:           Languages.Prefix(language.Code),
+		SearchPath:     language.SearchPath,
+		Title:          template.HTML(template.HTMLEscapeString(form.Title())),
+		Content:        content,
+		ShowBreadcrumb: true,
+		SiteName:       siteNames[language.Code],
+		Menu:           menu,
+	}
+	if swap, ok := Languages.Swap(language.Code); ok {
+		data.LangSwapSlug = path(swap.Code)
+		data.HasTranslation = true
+	}
+	return data
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"strings"
+)
+
+// Language describes a language the site is served in.  The first language
+// in the registry is the default and is served from the root of the site.
+// Every other language is served under a /<code>/ prefix.
+type Language struct {
+	Code       string
+	SiteName   string
+	Tagline    string
+	MenuID     string
+	HomeSlug   string
+	SearchPath string
+
+	// Organization is the name of the organization publishing the site in
+	// the language, described in the structured data of pages.
+	Organization string
+
+	// HomePageID is the ID of the WordPress page to serve as the home
+	// page, such as the front page set in the WordPress reading settings.
+	// Its slug replaces HomeSlug at startup.  Zero uses HomeSlug.
+	HomePageID int
+}
+
+// LanguageRegistry is the ordered set of languages the site is served in.
+type LanguageRegistry []Language
+
+// Languages is the language registry used to build paths and links.  It
+// defaults to English and French and is replaced at startup from config.
+var Languages = LanguageRegistry{
+	{Code: "en", HomeSlug: "home", SearchPath: "/search"},
+	{Code: "fr", HomeSlug: "home-fr", SearchPath: "/fr/recherche"},
+}
+
+// NewLanguage creates a language with the default home slug and search
+// path for its position in the registry.
+func NewLanguage(code string, isDefault bool) Language {
+	lang := Language{
+		Code:       code,
+		HomeSlug:   "home-" + code,
+		SearchPath: "/" + code + "/search",
+	}
+	if isDefault {
+		lang.HomeSlug = "home"
+		lang.SearchPath = "/search"
+	}
+	if code == "fr" {
+		lang.SearchPath = "/fr/recherche"
+	}
+	return lang
+}
+
+// Default returns the default language of the site.
+func (l LanguageRegistry) Default() Language {
+	if len(l) == 0 {
+		return Language{Code: "en", HomeSlug: "home", SearchPath: "/search"}
+	}
+	return l[0]
+}
+
+// Get returns the language with the given code.
+func (l LanguageRegistry) Get(code string) (Language, bool) {
+	for _, lang := range l {
+		if lang.Code == code {
+			return lang, true
+		}
+	}
+	return Language{}, false
+}
+
+// Resolve returns the language with the given code, falling back to the
+// default language if it is not registered.
+func (l LanguageRegistry) Resolve(code string) Language {
+	if lang, ok := l.Get(code); ok {
+		return lang
+	}
+	return l.Default()
+}
+
+// Codes returns the codes of all languages in registry order.
+func (l LanguageRegistry) Codes() []string {
+	codes := make([]string, len(l))
+	for i, lang := range l {
+		codes[i] = lang.Code
+	}
+	return codes
+}
+
+// SiteNames returns the site name of each language keyed by code.
+func (l LanguageRegistry) SiteNames() map[string]string {
+	names := make(map[string]string, len(l))
+	for _, lang := range l {
+		names[lang.Code] = lang.SiteName
+	}
+	return names
+}
+
+// FromPath returns the language a path is served in based on its first
+// segment.
+func (l LanguageRegistry) FromPath(path string) Language {
+	segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	for _, lang := range l[min(1, len(l)):] {
+		if lang.Code == segment {
+			return lang
+		}
+	}
+	return l.Default()
+}
+
+// BySearchPath returns the language whose search page is at the given path.
+func (l LanguageRegistry) BySearchPath(path string) (Language, bool) {
+	for _, lang := range l {
+		if lang.SearchPath == path {
+			return lang, true
+		}
+	}
+	return Language{}, false
+}
+
+// IsHomeSlug reports whether a slug is the home page of any language.
+func (l LanguageRegistry) IsHomeSlug(slug string) bool {
+	for _, lang := range l {
+		if SameSlug(lang.HomeSlug, slug) {
+			return true
+		}
+	}
+	return false
+}
+
+// Prefix returns the path prefix of the language, "/" for the default
+// language and "/<code>/" otherwise.
+func (l LanguageRegistry) Prefix(code string) string {
+	if code == l.Default().Code {
+		return "/"
+	}
+	return "/" + code + "/"
+}
+
+// Swap returns the language offered by the language toggle.  The default
+// language toggles to the second language and every other language
+// toggles back to the default.
+func (l LanguageRegistry) Swap(code string) (Language, bool) {
+	if code != l.Default().Code {
+		return l.Default(), true
+	}
+	if len(l) < 2 {
+		return Language{}, false
+	}
+	return l[1], true
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"testing"
+)
+
+// setLanguages replaces the language registry for the duration of a test
+func setLanguages(t *testing.T, languages LanguageRegistry) {
+	original := Languages
+	Languages = languages
+	t.Cleanup(func() { Languages = original })
+}
+
+var testLanguages = LanguageRegistry{
+	NewLanguage("en", true),
+	NewLanguage("fr", false),
+	NewLanguage("es", false),
+}
+
+func TestNewLanguage(t *testing.T) {
+	testCases := []struct {
+		code      string
+		isDefault bool
+		expected  Language
+	}{
+		{"en", true, Language{Code: "en", HomeSlug: "home", SearchPath: "/search"}},
+		{"fr", false, Language{Code: "fr", HomeSlug: "home-fr", SearchPath: "/fr/recherche"}},
+		{"es", false, Language{Code: "es", HomeSlug: "home-es", SearchPath: "/es/search"}},
+	}
+
+	for _, tc := range testCases {
+		if got := NewLanguage(tc.code, tc.isDefault); got != tc.expected {
+			t.Errorf("NewLanguage(%q): expected %+v, got %+v", tc.code, tc.expected, got)
+		}
+	}
+}
+
+func TestLanguageRegistryFromPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"/", "en"},
+		{"/about", "en"},
+		{"/fr/a-propos", "fr"},
+		{"/es", "es"},
+		{"/en/about", "en"},
+		{"/esprit", "en"},
+	}
+
+	for _, tc := range testCases {
+		if got := testLanguages.FromPath(tc.path).Code; got != tc.expected {
+			t.Errorf("FromPath(%q): expected %q, got %q", tc.path, tc.expected, got)
+		}
+	}
+}
+
+func TestLanguageRegistrySwap(t *testing.T) {
+	testCases := []struct {
+		code     string
+		expected string
+	}{
+		{"en", "fr"},
+		{"fr", "en"},
+		{"es", "en"},
+	}
+
+	for _, tc := range testCases {
+		swap, ok := testLanguages.Swap(tc.code)
+		if !ok || swap.Code != tc.expected {
+			t.Errorf("Swap(%q): expected %q, got %q", tc.code, tc.expected, swap.Code)
+		}
+	}
+
+	if _, ok := testLanguages[:1].Swap("en"); ok {
+		t.Error("Expected no swap language for a single language site")
+	}
+}
+
+func TestLanguageRegistryLookups(t *testing.T) {
+	if lang := testLanguages.Resolve("de"); lang.Code != "en" {
+		t.Errorf("Expected unknown language to resolve to the default, got %q", lang.Code)
+	}
+	if lang, ok := testLanguages.BySearchPath("/es/search"); !ok || lang.Code != "es" {
+		t.Errorf("Expected /es/search to be the Spanish search page, got %q", lang.Code)
+	}
+	if !testLanguages.IsHomeSlug("home-es") || testLanguages.IsHomeSlug("about") {
+		t.Error("Expected only home slugs to be reported as home pages")
+	}
+	if prefix := testLanguages.Prefix("es"); prefix != "/es/" {
+		t.Errorf("Expected prefix /es/, got %q", prefix)
+	}
+}
+
+// TestNewPageDataLanguages tests page data for a language beyond English and French
+func TestNewPageDataLanguages(t *testing.T) {
+	setLanguages(t, testLanguages)
+
+	page := WordPressPage{Slug: "acerca", Lang: "es", SlugEn: "about", Slugs: map[string]string{"fr": "a-propos", "es": "acerca"}}
+	data := NewPageData(&page, nil, map[string]string{"es": "Sitio"})
+
+	if data.Home != "/es/" || data.SearchPath != "/es/search" || data.SiteName != "Sitio" {
+		t.Errorf("Expected Spanish home, search path and site name, got %q, %q, %q", data.Home, data.SearchPath, data.SiteName)
+	}
+	if data.LangSwapPath != "/" || data.LangSwapSlug != "about" {
+		t.Errorf("Expected language toggle to /about, got %q%q", data.LangSwapPath, data.LangSwapSlug)
+	}
+
+	data.SetLinks(&page, "https://www.example.ca")
+	if len(data.Alternates) != 4 {
+		t.Fatalf("Expected 3 alternates and x-default, got %v", data.Alternates)
+	}
+	if data.Alternates[2] != (AlternateLink{Lang: "es", Href: "https://www.example.ca/es/acerca"}) {
+		t.Errorf("Expected Spanish alternate, got %v", data.Alternates[2])
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+)
+
+// InternalPath returns the path, query and fragment of a link to the
+// WordPress site at baseUrl, and whether the link points at the site.
+// Links match whether they use http or https, a www. prefix on the host or
+// another port, and protocol-relative links match as well.
+func InternalPath(link string, baseUrl string) (string, bool) {
+	base, err := url.Parse(baseUrl)
+	if err != nil || base.Host == "" {
+		return link, false
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+		return link, false
+	}
+	if siteHost(u.Hostname()) != siteHost(base.Hostname()) {
+		return link, false
+	}
+
+	path := u.EscapedPath()
+	if basePath := strings.TrimSuffix(base.EscapedPath(), "/"); basePath != "" {
+		rest, ok := strings.CutPrefix(path, basePath)
+		if !ok || (rest != "" && rest[0] != '/') {
+			return link, false
+		}
+		path = rest
+	}
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" || u.ForceQuery {
+		path += "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		path += "#" + u.EscapedFragment()
+	}
+	return path, true
+}
+
+// RelativeUrl returns the path of a link to the WordPress site at baseUrl,
+// or the link unchanged if it points elsewhere.
+func RelativeUrl(link string, baseUrl string) string {
+	path, _ := InternalPath(link, baseUrl)
+	return path
+}
+
+// siteHost returns a host name without its www. prefix, so that both
+// forms of a site's host compare equal.
+func siteHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
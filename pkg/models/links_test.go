@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func TestInternalPath(t *testing.T) {
+	testCases := []struct {
+		name     string
+		link     string
+		baseUrl  string
+		expected string
+		internal bool
+	}{
+		{name: "Same origin", link: "https://example.com/about", baseUrl: "https://example.com", expected: "/about", internal: true},
+		{name: "Home page", link: "https://example.com", baseUrl: "https://example.com", expected: "/", internal: true},
+		{name: "Query and fragment", link: "https://example.com?p=1#top", baseUrl: "https://example.com", expected: "/?p=1#top", internal: true},
+		{name: "Other scheme", link: "http://example.com/about", baseUrl: "https://example.com", expected: "/about", internal: true},
+		{name: "www prefix", link: "https://www.example.com/about", baseUrl: "https://example.com", expected: "/about", internal: true},
+		{name: "Base with www prefix", link: "https://example.com/about", baseUrl: "https://www.example.com", expected: "/about", internal: true},
+		{name: "Port", link: "https://example.com:8443/about", baseUrl: "https://example.com", expected: "/about", internal: true},
+		{name: "Protocol-relative", link: "//example.com/wp-content/uploads/a.jpg", baseUrl: "https://example.com", expected: "/wp-content/uploads/a.jpg", internal: true},
+		{name: "Host case", link: "https://EXAMPLE.com/about", baseUrl: "https://example.com", expected: "/about", internal: true},
+		{name: "Base path", link: "https://example.com/wp/about", baseUrl: "https://example.com/wp", expected: "/about", internal: true},
+		{name: "Outside base path", link: "https://example.com/wpx/about", baseUrl: "https://example.com/wp", expected: "https://example.com/wpx/about"},
+		{name: "Other host", link: "https://example.com.evil.net/about", baseUrl: "https://example.com", expected: "https://example.com.evil.net/about"},
+		{name: "Subdomain", link: "https://blog.example.com/about", baseUrl: "https://example.com", expected: "https://blog.example.com/about"},
+		{name: "Other scheme type", link: "ftp://example.com/file", baseUrl: "https://example.com", expected: "ftp://example.com/file"},
+		{name: "Relative link", link: "/about", baseUrl: "https://example.com", expected: "/about"},
+		{name: "No base", link: "https://example.com/about", baseUrl: "", expected: "https://example.com/about"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, internal := InternalPath(tc.link, tc.baseUrl)
+			if got != tc.expected || internal != tc.internal {
+				t.Errorf("Expected %q, %v, got %q, %v", tc.expected, tc.internal, got, internal)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pageBreak matches the page breaks WordPress leaves in rendered content
+// for the <!--nextpage--> tag, along with the comments the block editor
+// wraps them in.
+var pageBreak = regexp.MustCompile(`(?:<!-- wp:nextpage -->\s*)?<!--nextpage-->(?:\s*<!-- /wp:nextpage -->)?`)
+
+// SplitPages splits rendered content into the pages separated by its page
+// breaks.  Content without page breaks is a single page.
+func SplitPages(content string) []string {
+	return pageBreak.Split(content, -1)
+}
+
+// anchorID matches the id attributes of elements that anchors link to.
+var anchorID = regexp.MustCompile(`\sid\s*=\s*(?:"([^"]+)"|'([^']+)')`)
+
+// anchorLink matches links to an anchor on the same page.
+var anchorLink = regexp.MustCompile(`(\shref\s*=\s*["'])#([^"']+)(["'])`)
+
+// linkAnchors points the links of the nth page of paginated content to
+// anchors that are on other pages at the page the anchor is on, so that
+// in-page links keep working once the content is split.  path is the path
+// of the content.
+func linkAnchors(pages []string, n int, path string) string {
+	pageOf := make(map[string]int)
+	for i := len(pages) - 1; i >= 0; i-- {
+		for _, match := range anchorID.FindAllStringSubmatch(pages[i], -1) {
+			pageOf[html.UnescapeString(match[1]+match[2])] = i + 1
+		}
+	}
+	for _, match := range anchorID.FindAllStringSubmatch(pages[n-1], -1) {
+		pageOf[html.UnescapeString(match[1]+match[2])] = n
+	}
+
+	return anchorLink.ReplaceAllStringFunc(pages[n-1], func(link string) string {
+		match := anchorLink.FindStringSubmatch(link)
+		page, ok := pageOf[html.UnescapeString(match[2])]
+		if !ok || page == n {
+			return link
+		}
+		return match[1] + html.EscapeString(ContentPagePath(path, page)) + "#" + match[2] + match[3]
+	})
+}
+
+// ContentPagePath returns the path of a page of paginated content: the
+// content's own path for the first page and /page/<n> below it for the
+// following pages.
+func ContentPagePath(path string, n int) string {
+	if n <= 1 {
+		return path
+	}
+	return strings.TrimSuffix(path, "/") + "/page/" + strconv.Itoa(n)
+}
+
+// SplitPagePath returns the path of the content a paginated path is a page
+// of, and the page number.  Paths without a /page/<n> suffix are returned
+// as they are, as the first page of their content.
+func SplitPagePath(path string) (string, int) {
+	trimmed := strings.TrimSuffix(path, "/")
+	i := strings.LastIndex(trimmed, "/page/")
+	if i < 0 {
+		return path, 1
+	}
+	number := trimmed[i+len("/page/"):]
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || strconv.Itoa(n) != number {
+		return path, 1
+	}
+	if i == 0 {
+		return "/", n
+	}
+	return trimmed[:i], n
+}
+
+// Paginate replaces the content with its nth page, where path is the path
+// of the content, and sets the links to the previous and next pages.  The
+// canonical link of the pages after the first points to the page, and
+// their alternates are dropped since translations may be paginated
+// differently.  Links to anchors on other pages are pointed at those
+// pages.  It reports false if the content has no such page.
+func (d *PageData) Paginate(path string, n int) bool {
+	pages := SplitPages(string(d.Content))
+	if n < 1 || n > len(pages) {
+		return false
+	}
+	if len(pages) == 1 {
+		return true
+	}
+
+	d.Content = template.HTML(linkAnchors(pages, n, path))
+	d.PageNumber = n
+	d.PageCount = len(pages)
+	if n > 1 {
+		d.PrevUrl = ContentPagePath(path, n-1)
+		d.Canonical = ContentPagePath(d.Canonical, n)
+		d.Alternates = nil
+	}
+	if n < len(pages) {
+		d.NextUrl = ContentPagePath(path, n+1)
+	}
+	return true
+}
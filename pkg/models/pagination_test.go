@@ -0,0 +1,118 @@
+package models
+
+import (
+	"html/template"
+	"reflect"
+	"testing"
+)
+
+func TestSplitPages(t *testing.T) {
+	content := "<p>One</p>\n<!--nextpage-->\n<p>Two</p>\n<!-- wp:nextpage -->\n<!--nextpage-->\n<!-- /wp:nextpage -->\n<p>Three</p>"
+	expected := []string{"<p>One</p>\n", "\n<p>Two</p>\n", "\n<p>Three</p>"}
+	if pages := SplitPages(content); !reflect.DeepEqual(pages, expected) {
+		t.Errorf("Expected %q, got %q", expected, pages)
+	}
+	if pages := SplitPages("<p>One</p>"); len(pages) != 1 {
+		t.Errorf("Expected a single page, got %q", pages)
+	}
+}
+
+func TestPaginateAnchors(t *testing.T) {
+	content := `<p><a href="#faq">FAQ</a> <a href="#intro">Intro</a> <a href='#nowhere'>?</a></p><h2 id="intro">Intro</h2>` +
+		`<!--nextpage--><h2 id='faq'>FAQ</h2><p><a href="#intro">Back</a> <a href="#faq">Top</a></p>`
+
+	data := &PageData{Content: template.HTML(content)}
+	if !data.Paginate("/about", 1) {
+		t.Fatal("Expected page 1 to exist")
+	}
+	expected := `<p><a href="/about/page/2#faq">FAQ</a> <a href="#intro">Intro</a> <a href='#nowhere'>?</a></p><h2 id="intro">Intro</h2>`
+	if string(data.Content) != expected {
+		t.Errorf("Expected %s, got %s", expected, data.Content)
+	}
+
+	data = &PageData{Content: template.HTML(content)}
+	if !data.Paginate("/about", 2) {
+		t.Fatal("Expected page 2 to exist")
+	}
+	expected = `<h2 id='faq'>FAQ</h2><p><a href="/about#intro">Back</a> <a href="#faq">Top</a></p>`
+	if string(data.Content) != expected {
+		t.Errorf("Expected %s, got %s", expected, data.Content)
+	}
+}
+
+func TestSplitPagePath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+		n        int
+	}{
+		{path: "/about", expected: "/about", n: 1},
+		{path: "/about/page/2", expected: "/about", n: 2},
+		{path: "/about/page/2/", expected: "/about", n: 2},
+		{path: "/fr/a-propos/page/10", expected: "/fr/a-propos", n: 10},
+		{path: "/about/page/1", expected: "/about", n: 1},
+		{path: "/page/3", expected: "/", n: 3},
+		{path: "/about/page/0", expected: "/about/page/0", n: 1},
+		{path: "/about/page/02", expected: "/about/page/02", n: 1},
+		{path: "/about/page/two", expected: "/about/page/two", n: 1},
+		{path: "/about/page", expected: "/about/page", n: 1},
+	}
+
+	for _, tc := range testCases {
+		path, n := SplitPagePath(tc.path)
+		if path != tc.expected || n != tc.n {
+			t.Errorf("SplitPagePath(%q): expected %q, %d, got %q, %d", tc.path, tc.expected, tc.n, path, n)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	newData := func() *PageData {
+		return &PageData{
+			Content:    template.HTML("<p>One</p><!--nextpage--><p>Two</p><!--nextpage--><p>Three</p>"),
+			Canonical:  "https://www.example.com/about",
+			Alternates: []AlternateLink{{Lang: "fr", Href: "https://www.example.com/fr/a-propos"}},
+		}
+	}
+
+	data := newData()
+	if !data.Paginate("/about", 1) {
+		t.Fatal("Expected page 1 to exist")
+	}
+	if data.Content != "<p>One</p>" || data.PrevUrl != "" || data.NextUrl != "/about/page/2" {
+		t.Errorf("Unexpected first page: %+v", data)
+	}
+	if data.Canonical != "https://www.example.com/about" || len(data.Alternates) != 1 {
+		t.Errorf("Expected first page to keep its links, got %q, %v", data.Canonical, data.Alternates)
+	}
+
+	data = newData()
+	if !data.Paginate("/about", 2) {
+		t.Fatal("Expected page 2 to exist")
+	}
+	if data.Content != "<p>Two</p>" || data.PrevUrl != "/about" || data.NextUrl != "/about/page/3" {
+		t.Errorf("Unexpected second page: %+v", data)
+	}
+	if data.PageNumber != 2 || data.PageCount != 3 {
+		t.Errorf("Expected page 2 of 3, got %d of %d", data.PageNumber, data.PageCount)
+	}
+	if data.Canonical != "https://www.example.com/about/page/2" || data.Alternates != nil {
+		t.Errorf("Expected canonical link to the page without alternates, got %q, %v", data.Canonical, data.Alternates)
+	}
+
+	data = newData()
+	if !data.Paginate("/about", 3) || data.PrevUrl != "/about/page/2" || data.NextUrl != "" {
+		t.Errorf("Unexpected last page: %+v", data)
+	}
+
+	if newData().Paginate("/about", 4) {
+		t.Error("Expected page 4 not to exist")
+	}
+	single := &PageData{Content: "<p>One</p>"}
+	if !single.Paginate("/about", 1) || single.PageCount != 0 || single.NextUrl != "" {
+		t.Errorf("Expected single page content to be left as is, got %+v", single)
+	}
+	if single.Paginate("/about", 2) {
+		t.Error("Expected page 2 of single page content not to exist")
+	}
+}
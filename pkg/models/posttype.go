@@ -0,0 +1,184 @@
+package models
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PostType is a WordPress custom post type served by the proxy.  Posts are
+// served from /<Name>/<slug> under each language prefix and the archive of
+// the type from /<Name>/.  Route is the REST route of the type relative to
+// /wp-json/, for example "wp/v2/events".
+type PostType struct {
+	Name  string
+	Route string
+}
+
+// Path returns the archive path of the post type in a language.
+func (t PostType) Path(lang string) string {
+	return Languages.Prefix(lang) + t.Name + "/"
+}
+
+// PostPath returns the path of a post of the type given its language and
+// slug.
+func (t PostType) PostPath(lang string, slug string) string {
+	return t.Path(lang) + slug
+}
+
+// Title returns the title of the post type's archive, its name with the
+// first letter capitalized and dashes replaced by spaces.
+func (t PostType) Title() string {
+	return humanize(t.Name)
+}
+
+// humanize returns a name with the first letter capitalized and dashes and
+// underscores replaced by spaces.
+func humanize(name string) string {
+	name = strings.NewReplacer("-", " ", "_", " ").Replace(name)
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// ArchiveUrl returns the path of a page of the post type's archive.
+func (t PostType) ArchiveUrl(lang string, page int) string {
+	path := t.Path(lang)
+	if page > 1 {
+		path += "?" + url.Values{"page": {strconv.Itoa(page)}}.Encode()
+	}
+	return path
+}
+
+// Archive holds a page of posts of a post type along with the totals
+// reported by the WordPress API.
+type Archive struct {
+	Posts      []WordPressPage
+	Total      int
+	TotalPages int
+}
+
+// ArchiveItemData holds the data needed to render a post in an archive.
+type ArchiveItemData struct {
+	Title     string
+	Url       string
+	Excerpt   template.HTML
+	Modified  time.Time
+	Published time.Time
+}
+
+// ArchiveData holds the data needed to render the archive content of a
+// post type.
+type ArchiveData struct {
+	Lang        string
+	Type        string
+	Posts       []ArchiveItemData
+	Total       int
+	CurrentPage int
+	TotalPages  int
+	PrevUrl     string
+	NextUrl     string
+}
+
+// PostData holds the data needed to render the content of a post.  Meta
+// holds the registered meta fields of the post and Fields those along with
+// its custom fields, for templates dedicated to a post type.  WordCount
+// and ReadingTime are counted over all the pages of the post.  Comments
+// are the approved comments on the post, and CommentForm is set if
+// visitors may comment on it.
+type PostData struct {
+	Lang        string
+	Type        string
+	Slug        string
+	Title       template.HTML
+	Content     template.HTML
+	Excerpt     template.HTML
+	Modified    time.Time
+	Published   time.Time
+	WordCount   int
+	ReadingTime int
+	Meta        map[string]any
+	Fields      Fields
+	Comments    []CommentData
+	CommentForm *CommentFormData
+}
+
+// NewPostData creates the data used to render the content of a post.
+// Content is expected to already be rewritten for the proxy.
+func NewPostData(postType PostType, post *WordPressPage, content template.HTML) PostData {
+	words := CountWords(post.Content.Rendered)
+	return PostData{
+		Lang:        Languages.Resolve(post.Lang).Code,
+		Type:        postType.Name,
+		Slug:        post.Slug,
+		Title:       template.HTML(post.Title.Rendered),
+		Content:     content,
+		Excerpt:     template.HTML(post.Excerpt.Rendered),
+		Modified:    post.ModifiedTime(),
+		Published:   post.PublishedTime(),
+		WordCount:   words,
+		ReadingTime: ReadingTime(words),
+		Meta:        post.Meta,
+		Fields:      post.Fields(),
+	}
+}
+
+// NewArchiveData creates the data used to render a page of a post type's
+// archive.  Excerpts are used as returned by WordPress, so they must be
+// sanitized and their links rewritten before they are rendered.
+func NewArchiveData(lang string, postType PostType, archive *Archive, currentPage int) ArchiveData {
+	data := ArchiveData{
+		Lang:        lang,
+		Type:        postType.Name,
+		Posts:       make([]ArchiveItemData, 0),
+		CurrentPage: currentPage,
+	}
+	if archive == nil {
+		return data
+	}
+
+	data.Total = archive.Total
+	data.TotalPages = archive.TotalPages
+	for _, post := range archive.Posts {
+		data.Posts = append(data.Posts, ArchiveItemData{
+			Title:     html.UnescapeString(post.Title.Rendered),
+			Url:       postType.PostPath(lang, post.Slug),
+			Excerpt:   template.HTML(post.Excerpt.Rendered),
+			Modified:  post.ModifiedTime(),
+			Published: post.PublishedTime(),
+		})
+	}
+
+	if currentPage > 1 {
+		data.PrevUrl = postType.ArchiveUrl(lang, currentPage-1)
+	}
+	if currentPage < archive.TotalPages {
+		data.NextUrl = postType.ArchiveUrl(lang, currentPage+1)
+	}
+	return data
+}
+
+// NewArchivePageData creates the PageData used to render a post type's
+// archive content inside the site layout.
+func NewArchivePageData(lang string, postType PostType, content template.HTML, menu *MenuData, siteNames map[string]string) PageData {
+	language := Languages.Resolve(lang)
+	data := PageData{
+		Lang:           language.Code,
+		Home:           Languages.Prefix(language.Code),
+		SearchPath:     language.SearchPath,
+		Title:          template.HTML(template.HTMLEscapeString(postType.Title())),
+		Content:        content,
+		ShowBreadcrumb: true,
+		SiteName:       siteNames[language.Code],
+		Menu:           menu,
+	}
+	if swap, ok := Languages.Swap(language.Code); ok {
+		data.LangSwapSlug = postType.Path(swap.Code)
+		data.HasTranslation = true
+	}
+	return data
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"html/template"
+	"testing"
+	"time"
+)
+
+var events = PostType{Name: "events", Route: "wp/v2/events"}
+
+func TestPostTypePaths(t *testing.T) {
+	testCases := []struct {
+		got      string
+		expected string
+	}{
+		{events.Path("en"), "/events/"},
+		{events.Path("fr"), "/fr/events/"},
+		{events.PostPath("fr", "lancement"), "/fr/events/lancement"},
+		{events.ArchiveUrl("en", 1), "/events/"},
+		{events.ArchiveUrl("fr", 3), "/fr/events/?page=3"},
+	}
+
+	for _, tc := range testCases {
+		if tc.got != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, tc.got)
+		}
+	}
+}
+
+func TestPostTypeTitle(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{"events", "Events"},
+		{"job-postings", "Job postings"},
+		{"faq_items", "Faq items"},
+	}
+
+	for _, tc := range testCases {
+		if got := (PostType{Name: tc.name}).Title(); got != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, got)
+		}
+	}
+}
+
+func TestNewArchiveData(t *testing.T) {
+	archive := &Archive{Total: 25, TotalPages: 3}
+	post := WordPressPage{Slug: "launch", Modified: "2025-01-02T03:04:05", Date: "2024-12-20T09:00:00"}
+	post.Title.Rendered = "Launch &amp; demo"
+	post.Excerpt.Rendered = `<p>See <a href="https://example.com/fr/plan">the plan</a></p>`
+	archive.Posts = []WordPressPage{post}
+
+	data := NewArchiveData("fr", events, archive, 2)
+
+	if data.Total != 25 || data.TotalPages != 3 || data.CurrentPage != 2 || data.Type != "events" {
+		t.Errorf("Expected totals 25/3 on page 2, got %+v", data)
+	}
+	if len(data.Posts) != 1 {
+		t.Fatalf("Expected 1 post, got %d", len(data.Posts))
+	}
+	item := data.Posts[0]
+	if item.Title != "Launch & demo" || item.Url != "/fr/events/launch" ||
+		!item.Modified.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)) || !item.Published.Equal(time.Date(2024, 12, 20, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected archive item %+v", item)
+	}
+	if item.Excerpt != template.HTML(post.Excerpt.Rendered) {
+		t.Errorf("Expected excerpt unchanged, got %q", item.Excerpt)
+	}
+	if data.PrevUrl != "/fr/events/" || data.NextUrl != "/fr/events/?page=3" {
+		t.Errorf("Expected pagination links, got prev %q next %q", data.PrevUrl, data.NextUrl)
+	}
+
+	empty := NewArchiveData("en", events, nil, 1)
+	if empty.Posts == nil || empty.PrevUrl != "" || empty.NextUrl != "" {
+		t.Errorf("Expected empty archive without pagination, got %+v", empty)
+	}
+}
+
+func TestNewArchivePageData(t *testing.T) {
+	data := NewArchivePageData("fr", events, "<ul></ul>", &MenuData{}, map[string]string{"fr": "Site"})
+
+	if data.Lang != "fr" || data.Title != "Events" || data.Home != "/fr/" || data.SiteName != "Site" {
+		t.Errorf("Unexpected page data %+v", data)
+	}
+	if data.LangSwapPath+data.LangSwapSlug != "/events/" {
+		t.Errorf("Expected language toggle to /events/, got %q", data.LangSwapPath+data.LangSwapSlug)
+	}
+}
+
+func TestSetPostLinks(t *testing.T) {
+	post := WordPressPage{Slug: "launch", SlugEn: "launch", SlugFr: "lancement"}
+	data := PageData{Lang: "en"}
+	data.SetPostLinks(events, &post, "https://www.example.ca")
+
+	if data.Canonical != "https://www.example.ca/events/launch" {
+		t.Errorf("Expected canonical post URL, got %q", data.Canonical)
+	}
+	expected := []AlternateLink{
+		{Lang: "en", Href: "https://www.example.ca/events/launch"},
+		{Lang: "fr", Href: "https://www.example.ca/fr/events/lancement"},
+		{Lang: "x-default", Href: "https://www.example.ca/events/launch"},
+	}
+	if len(data.Alternates) != len(expected) {
+		t.Fatalf("Expected alternates %v, got %v", expected, data.Alternates)
+	}
+	for i := range expected {
+		if data.Alternates[i] != expected[i] {
+			t.Errorf("Expected alternate %v, got %v", expected[i], data.Alternates[i])
+		}
+	}
+}
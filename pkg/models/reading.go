@@ -0,0 +1,83 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// WordsPerMinute is the reading speed reading times are estimated with.
+const WordsPerMinute = 200
+
+// skippedElements are the elements whose text is not read.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Template: true, atom.Noscript: true,
+}
+
+// inlineElements are the elements that do not separate words, so that
+// "un<em>believable</em>" is one word.
+var inlineElements = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.B: true, atom.Bdi: true, atom.Bdo: true,
+	atom.Cite: true, atom.Code: true, atom.Dfn: true, atom.Em: true, atom.I: true,
+	atom.Kbd: true, atom.Mark: true, atom.Q: true, atom.S: true, atom.Samp: true,
+	atom.Small: true, atom.Span: true, atom.Strong: true, atom.Sub: true,
+	atom.Sup: true, atom.Time: true, atom.U: true, atom.Var: true,
+}
+
+// CountWords returns the number of words in the text of rendered content,
+// leaving out scripts and styles.  Words are runs of non-space characters
+// with at least one letter or digit, so dashes and bullets are not counted.
+func CountWords(content string) int {
+	return countTextWords(plainText(content))
+}
+
+// plainText returns the text of rendered content, leaving out scripts and
+// styles.  Elements other than inline ones are separated by a space.
+func plainText(content string) string {
+	var text strings.Builder
+	skipped := 0
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return text.String()
+		case html.TextToken:
+			if skipped == 0 {
+				text.Write(z.Text())
+			}
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if skippedElements[token.DataAtom] {
+				switch token.Type {
+				case html.StartTagToken:
+					skipped++
+				case html.EndTagToken:
+					skipped = max(skipped-1, 0)
+				}
+			}
+			if !inlineElements[token.DataAtom] {
+				text.WriteByte(' ')
+			}
+		}
+	}
+}
+
+// countTextWords returns the number of words in plain text.
+func countTextWords(text string) int {
+	words := 0
+	for _, field := range strings.Fields(text) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			words++
+		}
+	}
+	return words
+}
+
+// ReadingTime returns the estimated number of minutes it takes to read a
+// number of words, rounded up so that short content takes a minute.
+// Content without words takes no time.
+func ReadingTime(words int) int {
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
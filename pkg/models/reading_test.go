@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountWords(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected int
+	}{
+		{name: "Empty", content: "", expected: 0},
+		{name: "Paragraphs", content: "<p>One two</p><p>three</p>", expected: 3},
+		{name: "Inline elements", content: "<p>un<em>believable</em> <a href=\"/x\">link</a></p>", expected: 2},
+		{name: "Entities", content: "<p>Tom &amp; Jerry&nbsp;show</p>", expected: 3},
+		{name: "Punctuation", content: "<p>Before — after • 2025</p>", expected: 3},
+		{name: "Scripts and styles", content: "<style>p { color: red }</style><p>Text</p><script>var a = 1;</script>", expected: 1},
+		{name: "Accents", content: "<h2>Été</h2><p>déjà vu</p>", expected: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if words := CountWords(tc.content); words != tc.expected {
+				t.Errorf("Expected %d words, got %d", tc.expected, words)
+			}
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	testCases := []struct {
+		words    int
+		expected int
+	}{
+		{0, 0},
+		{1, 1},
+		{WordsPerMinute, 1},
+		{WordsPerMinute + 1, 2},
+		{5 * WordsPerMinute, 5},
+	}
+	for _, tc := range testCases {
+		if minutes := ReadingTime(tc.words); minutes != tc.expected {
+			t.Errorf("ReadingTime(%d): expected %d, got %d", tc.words, tc.expected, minutes)
+		}
+	}
+
+	page := WordPressPage{Lang: "en"}
+	page.Content.Rendered = "<p>" + strings.Repeat("word ", 450) + "</p>"
+	data := NewPageData(&page, nil, nil)
+	if data.WordCount != 450 || data.ReadingTime != 3 {
+		t.Errorf("Expected 450 words read in 3 minutes, got %d in %d", data.WordCount, data.ReadingTime)
+	}
+	post := NewPostData(events, &page, "<p>First page</p>")
+	if post.WordCount != 450 || post.ReadingTime != 3 {
+		t.Errorf("Expected the post counted over all of its pages, got %d in %d", post.WordCount, post.ReadingTime)
+	}
+}
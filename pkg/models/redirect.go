@@ -0,0 +1,23 @@
+package models
+
+// WordPressRedirect represents a redirect from the Redirection plugin's
+// REST API.
+type WordPressRedirect struct {
+	ID         int    `json:"id"`
+	Url        string `json:"url"`
+	MatchUrl   string `json:"match_url"`
+	Regex      bool   `json:"regex"`
+	Enabled    bool   `json:"enabled"`
+	ActionType string `json:"action_type"`
+	ActionCode int    `json:"action_code"`
+	ActionData struct {
+		Url string `json:"url"`
+	} `json:"action_data"`
+}
+
+// WordPressRedirects represents a page of redirects from the Redirection
+// plugin's REST API.
+type WordPressRedirects struct {
+	Items []WordPressRedirect `json:"items"`
+	Total int                 `json:"total"`
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// WordPressSearchResult represents a WordPress search result JSON response.
+type WordPressSearchResult struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	Url     string `json:"url"`
+	Type    string `json:"type"`
+	Subtype string `json:"subtype"`
+}
+
+// SearchResults holds a page of search results along with the totals
+// reported by the WordPress API.
+type SearchResults struct {
+	Results    []WordPressSearchResult
+	Total      int
+	TotalPages int
+}
+
+// SearchResultData holds the data needed to render a search result.
+type SearchResultData struct {
+	Title template.HTML
+	Url   string
+}
+
+// SearchData holds the data needed to render the search results content.
+type SearchData struct {
+	Lang        string
+	Query       string
+	Results     []SearchResultData
+	Total       int
+	CurrentPage int
+	TotalPages  int
+	PrevUrl     string
+	NextUrl     string
+}
+
+// searchTitles are the search page titles for each language.  Languages
+// without a title use the English one.
+var searchTitles = map[string]string{
+	"en": "Search results",
+	"fr": "Résultats de recherche",
+}
+
+// NewSearchData creates a new SearchData object that can then be used to
+// render search results.  Result titles have the query terms highlighted.
+func NewSearchData(lang string, query string, results *SearchResults, currentPage int, baseUrl string) SearchData {
+	data := SearchData{
+		Lang:        lang,
+		Query:       query,
+		Results:     make([]SearchResultData, 0),
+		CurrentPage: currentPage,
+	}
+	if results == nil {
+		return data
+	}
+
+	data.Total = results.Total
+	data.TotalPages = results.TotalPages
+	for _, result := range results.Results {
+		data.Results = append(data.Results, SearchResultData{
+			Title: Highlight(html.UnescapeString(result.Title), query),
+			Url:   RelativeUrl(result.Url, baseUrl),
+		})
+	}
+
+	if currentPage > 1 {
+		data.PrevUrl = SearchUrl(lang, query, currentPage-1)
+	}
+	if currentPage < results.TotalPages {
+		data.NextUrl = SearchUrl(lang, query, currentPage+1)
+	}
+
+	return data
+}
+
+// NewSearchPageData creates the PageData used to render the search results
+// content inside the site layout.
+func NewSearchPageData(lang string, query string, content template.HTML, menu *MenuData, siteNames map[string]string) PageData {
+	language := Languages.Resolve(lang)
+	title, ok := searchTitles[language.Code]
+	if !ok {
+		title = searchTitles["en"]
+	}
+
+	data := PageData{
+		Lang:           language.Code,
+		Home:           Languages.Prefix(language.Code),
+		SearchPath:     language.SearchPath,
+		Title:          template.HTML(title),
+		Content:        content,
+		ShowBreadcrumb: true,
+		SiteName:       siteNames[language.Code],
+		Menu:           menu,
+	}
+	if swap, ok := Languages.Swap(language.Code); ok {
+		data.LangSwapSlug = SearchUrl(swap.Code, query, 1)
+		data.HasTranslation = true
+	}
+	return data
+}
+
+// SearchUrl returns the path of a search results page.
+func SearchUrl(lang string, query string, page int) string {
+	path := Languages.Resolve(lang).SearchPath
+	values := url.Values{"q": {query}}
+	if page > 1 {
+		values.Set("page", fmt.Sprint(page))
+	}
+	return path + "?" + values.Encode()
+}
+
+// Highlight escapes text and wraps each case-insensitive occurrence of the
+// query's terms in a <mark> element.
+func Highlight(text string, query string) template.HTML {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	patterns := make([]string, len(terms))
+	for i, term := range terms {
+		patterns[i] = regexp.QuoteMeta(term)
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(patterns, "|"))
+
+	var b strings.Builder
+	last := 0
+	for _, match := range re.FindAllStringIndex(text, -1) {
+		b.WriteString(template.HTMLEscapeString(text[last:match[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(template.HTMLEscapeString(text[match[0]:match[1]]))
+		b.WriteString("</mark>")
+		last = match[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+
+	return template.HTML(b.String())
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"html/template"
+	"testing"
+)
+
+// TestNewSearchData tests the NewSearchData function which creates search result rendering data
+func TestNewSearchData(t *testing.T) {
+	results := &SearchResults{
+		Results: []WordPressSearchResult{
+			{ID: 1, Title: "Benefits &amp; services", Url: "https://example.com/benefits"},
+			{ID: 2, Title: "Apply for benefits", Url: "https://example.com/apply"},
+		},
+		Total:      25,
+		TotalPages: 3,
+	}
+
+	data := NewSearchData("en", "benefits", results, 2, "https://example.com")
+
+	if data.Total != 25 || data.TotalPages != 3 || data.CurrentPage != 2 {
+		t.Errorf("Expected totals 25/3 on page 2, got %d/%d on page %d", data.Total, data.TotalPages, data.CurrentPage)
+	}
+	if len(data.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(data.Results))
+	}
+
+	expectedTitle := template.HTML("<mark>Benefits</mark> &amp; services")
+	if data.Results[0].Title != expectedTitle {
+		t.Errorf("Expected title %q, got %q", expectedTitle, data.Results[0].Title)
+	}
+	if data.Results[0].Url != "/benefits" {
+		t.Errorf("Expected URL /benefits, got %q", data.Results[0].Url)
+	}
+	if data.PrevUrl != "/search?q=benefits" {
+		t.Errorf("Expected previous URL /search?q=benefits, got %q", data.PrevUrl)
+	}
+	if data.NextUrl != "/search?page=3&q=benefits" {
+		t.Errorf("Expected next URL /search?page=3&q=benefits, got %q", data.NextUrl)
+	}
+}
+
+func TestNewSearchDataFirstAndLastPage(t *testing.T) {
+	results := &SearchResults{Total: 1, TotalPages: 1}
+
+	data := NewSearchData("fr", "impôts", results, 1, "https://example.com")
+
+	if data.PrevUrl != "" || data.NextUrl != "" {
+		t.Errorf("Expected no pagination links, got prev %q next %q", data.PrevUrl, data.NextUrl)
+	}
+	if data.Results == nil {
+		t.Error("Expected empty results slice, got nil")
+	}
+}
+
+func TestSearchUrl(t *testing.T) {
+	testCases := []struct {
+		lang     string
+		query    string
+		page     int
+		expected string
+	}{
+		{"en", "tax credits", 1, "/search?q=tax+credits"},
+		{"fr", "crédits", 2, "/fr/recherche?page=2&q=cr%C3%A9dits"},
+		{"es", "query", 1, "/search?q=query"},
+	}
+
+	for _, tc := range testCases {
+		if got := SearchUrl(tc.lang, tc.query, tc.page); got != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, got)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		query    string
+		expected template.HTML
+	}{
+		{"Single term", "Tax credits", "tax", "<mark>Tax</mark> credits"},
+		{"Multiple terms", "Apply for tax credits", "credits apply", "<mark>Apply</mark> for tax <mark>credits</mark>"},
+		{"Markup is escaped", "<b>Tax</b>", "tax", "&lt;b&gt;<mark>Tax</mark>&lt;/b&gt;"},
+		{"Regex characters in query", "C++ (advanced)", "c++", "<mark>C++</mark> (advanced)"},
+		{"Empty query", "Tax credits", " ", "Tax credits"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Highlight(tc.text, tc.query); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewSearchPageData(t *testing.T) {
+	siteNames := map[string]string{"en": "English Site", "fr": "French Site"}
+	menu := &MenuData{}
+
+	data := NewSearchPageData("fr", "impôts", "<p>Results</p>", menu, siteNames)
+
+	if data.Lang != "fr" || data.Home != "/fr/" || data.SiteName != "French Site" {
+		t.Errorf("Expected French page data, got %+v", data)
+	}
+	if data.Title != "Résultats de recherche" {
+		t.Errorf("Expected French title, got %q", data.Title)
+	}
+	if data.LangSwapPath+data.LangSwapSlug != "/search?q=imp%C3%B4ts" {
+		t.Errorf("Expected language swap to English search, got %q", data.LangSwapPath+data.LangSwapSlug)
+	}
+	if data.Content != "<p>Results</p>" || data.Menu != menu || !data.ShowBreadcrumb {
+		t.Errorf("Expected content, menu and breadcrumb to be set, got %+v", data)
+	}
+}
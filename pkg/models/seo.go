@@ -0,0 +1,93 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDescriptionLength is the number of characters meta descriptions
+// taken from excerpts are shortened to, about as many as search engines
+// show.
+const maxDescriptionLength = 160
+
+// NoIndexField is the path of the custom field that keeps a page out of
+// search engines when it is set, see Fields.  It is replaced at startup
+// from config.
+var NoIndexField = "noindex"
+
+// falseValues are the values of a custom field that leave it unset.
+var falseValues = []string{"0", "false", "no"}
+
+// YoastHead holds the fields of the yoast_head_json object Yoast SEO adds
+// to REST responses that the proxy uses.
+type YoastHead struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Robots holds the robots directives of the page, such as "noindex"
+	// keyed by "index".
+	Robots map[string]string `json:"robots,omitempty"`
+}
+
+// SEOTitle returns the title set for search engines with Yoast SEO or
+// Rank Math, or an empty string if there is none.  Rank Math titles are
+// skipped when they hold %variables%, which only Rank Math can replace.
+func (p *WordPressPage) SEOTitle() string {
+	if p.YoastHead != nil && p.YoastHead.Title != "" {
+		return p.YoastHead.Title
+	}
+	return rankMathField(p.Meta, "rank_math_title")
+}
+
+// MetaDescription returns the description of the page for search engines:
+// the one set with Yoast SEO or Rank Math, or otherwise its excerpt as
+// plain text, shortened at a word to maxDescriptionLength characters.
+// The excerpt of a locked page is not used.
+func (p *WordPressPage) MetaDescription() string {
+	if p.YoastHead != nil && p.YoastHead.Description != "" {
+		return p.YoastHead.Description
+	}
+	if description := rankMathField(p.Meta, "rank_math_description"); description != "" {
+		return description
+	}
+	if p.Locked() {
+		return ""
+	}
+
+	text := strings.Join(strings.Fields(plainText(p.Excerpt.Rendered)), " ")
+	text = strings.TrimSpace(strings.TrimSuffix(text, "[…]"))
+	if utf8.RuneCountInString(text) <= maxDescriptionLength {
+		return text
+	}
+	runes := []rune(text)[:maxDescriptionLength]
+	if i := strings.LastIndexByte(string(runes), ' '); i > 0 {
+		return strings.TrimRight(string(runes)[:i], ",;:.") + "…"
+	}
+	return string(runes) + "…"
+}
+
+// rankMathField returns a Rank Math meta field, or an empty string if it
+// is not set or holds variables.
+func rankMathField(meta map[string]any, name string) string {
+	value, _ := meta[name].(string)
+	if strings.Contains(value, "%") {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+// NoIndex reports whether the page asks search engines not to index it,
+// with its NoIndexField custom field or its robots settings in Yoast SEO
+// or Rank Math.
+func (p *WordPressPage) NoIndex() bool {
+	fields := p.Fields()
+	if fields.Has(NoIndexField) && !slices.Contains(falseValues, strings.ToLower(fields.Text(NoIndexField))) {
+		return true
+	}
+	if p.YoastHead != nil && p.YoastHead.Robots["index"] == "noindex" {
+		return true
+	}
+	robots, _ := p.Meta["rank_math_robots"].([]any)
+	return slices.Contains(robots, any("noindex"))
+}
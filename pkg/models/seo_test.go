@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWordPressPageSEO(t *testing.T) {
+	var page WordPressPage
+	page.Excerpt.Rendered = "<p>Apply for a fishing licence &amp; renew it online [&hellip;]</p>\n"
+	if got := page.MetaDescription(); got != "Apply for a fishing licence & renew it online" {
+		t.Errorf("Expected the excerpt as plain text, got %q", got)
+	}
+	if got := page.SEOTitle(); got != "" {
+		t.Errorf("Expected no SEO title, got %q", got)
+	}
+
+	page.Excerpt.Rendered = "<p>" + strings.Repeat("Fishing licences, ", 20) + "</p>"
+	got := page.MetaDescription()
+	if utf8.RuneCountInString(got) > maxDescriptionLength+1 || !strings.HasSuffix(got, "licences, Fishing…") {
+		t.Errorf("Expected the excerpt shortened at a word, got %q", got)
+	}
+
+	page.Meta = map[string]any{"rank_math_title": "%title% %sep% %sitename%", "rank_math_description": "Fishing licences from Rank Math"}
+	if page.SEOTitle() != "" || page.MetaDescription() != "Fishing licences from Rank Math" {
+		t.Errorf("Expected Rank Math fields without variables, got %q and %q", page.SEOTitle(), page.MetaDescription())
+	}
+
+	page.YoastHead = &YoastHead{Title: "Fishing - Example Site", Description: "Fishing licences from Yoast"}
+	if page.SEOTitle() != "Fishing - Example Site" || page.MetaDescription() != "Fishing licences from Yoast" {
+		t.Errorf("Expected Yoast fields, got %q and %q", page.SEOTitle(), page.MetaDescription())
+	}
+
+	locked := WordPressPage{}
+	locked.Content.Protected = true
+	locked.Excerpt.Rendered = "<p>Secret</p>"
+	if got := locked.MetaDescription(); got != "" {
+		t.Errorf("Expected no description for a locked page, got %q", got)
+	}
+}
+
+func TestWordPressPageYoastHeadJSON(t *testing.T) {
+	var page WordPressPage
+	if err := page.UnmarshalJSON([]byte(`{"id":1,"yoast_head_json":{"title":"About - Site","description":"About us","og_type":"article"}}`)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if page.YoastHead == nil || page.YoastHead.Title != "About - Site" || page.YoastHead.Description != "About us" {
+		t.Errorf("Unexpected Yoast head %+v", page.YoastHead)
+	}
+}
+
+func TestWordPressPageNoIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     WordPressPage
+		expected bool
+	}{
+		{"no settings", WordPressPage{}, false},
+		{"field set", WordPressPage{Meta: map[string]any{"noindex": true}}, true},
+		{"ACF field set", WordPressPage{ACF: map[string]any{"noindex": "1"}}, true},
+		{"field unset", WordPressPage{ACF: map[string]any{"noindex": "0"}}, false},
+		{"Yoast", WordPressPage{YoastHead: &YoastHead{Robots: map[string]string{"index": "noindex", "follow": "follow"}}}, true},
+		{"Yoast indexed", WordPressPage{YoastHead: &YoastHead{Robots: map[string]string{"index": "index"}}}, false},
+		{"Rank Math", WordPressPage{Meta: map[string]any{"rank_math_robots": []any{"noindex", "nofollow"}}}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.page.NoIndex(); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
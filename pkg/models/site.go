@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timezone is the timezone of the site, which dates are shown in.  It
+// defaults to UTC and is replaced at startup from config or the WordPress
+// site settings.
+var Timezone = time.UTC
+
+// parseSiteTime returns a WordPress timestamp in the site's Timezone.  The
+// timestamp in UTC is used if it is set, since the local timestamp has no
+// time zone, and the zero time is returned if neither can be parsed.
+func parseSiteTime(local string, gmt string) time.Time {
+	if t, ok := parseGmt(gmt); ok {
+		return siteTime(t)
+	}
+	t, err := time.ParseInLocation(wordPressTime, local, Timezone)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// SiteSettings are the general settings of a WordPress site in a
+// language, from the index of its REST API.
+type SiteSettings struct {
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	TimezoneString string    `json:"timezone_string"`
+	GMTOffset      gmtOffset `json:"gmt_offset"`
+}
+
+// Location returns the site's timezone.  Sites set to a UTC offset rather
+// than a named timezone get a fixed zone, and nil is returned if the
+// named timezone is unknown.
+func (s SiteSettings) Location() *time.Location {
+	if s.TimezoneString != "" {
+		loc, err := time.LoadLocation(s.TimezoneString)
+		if err != nil {
+			return nil
+		}
+		return loc
+	}
+	offset := int(float64(s.GMTOffset) * 3600)
+	if offset == 0 {
+		return time.UTC
+	}
+	return time.FixedZone(fmt.Sprintf("UTC%+g", float64(s.GMTOffset)), offset)
+}
+
+// gmtOffset is a UTC offset in hours, which WordPress encodes as either a
+// number or a string.
+type gmtOffset float64
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (o *gmtOffset) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case float64:
+		*o = gmtOffset(v)
+	case string:
+		if strings.TrimSpace(v) == "" {
+			*o = 0
+			return nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return fmt.Errorf("invalid gmt_offset: %q", v)
+		}
+		*o = gmtOffset(f)
+	case nil:
+		*o = 0
+	default:
+		return fmt.Errorf("invalid gmt_offset: %s", data)
+	}
+	return nil
+}
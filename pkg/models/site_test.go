@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSiteSettingsLocation(t *testing.T) {
+	testCases := []struct {
+		name     string
+		json     string
+		expected string
+		offset   int
+	}{
+		{name: "Named", json: `{"timezone_string":"America/Toronto","gmt_offset":-5}`, expected: "America/Toronto", offset: -5 * 3600},
+		{name: "Offset", json: `{"timezone_string":"","gmt_offset":5.5}`, expected: "UTC+5.5", offset: 5*3600 + 1800},
+		{name: "String offset", json: `{"timezone_string":"","gmt_offset":"-3.5"}`, expected: "UTC-3.5", offset: -3*3600 - 1800},
+		{name: "UTC", json: `{"timezone_string":"","gmt_offset":"0"}`, expected: "UTC"},
+		{name: "Missing", json: `{}`, expected: "UTC"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var settings SiteSettings
+			if err := json.Unmarshal([]byte(tc.json), &settings); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			loc := settings.Location()
+			if loc == nil || loc.String() != tc.expected {
+				t.Fatalf("Expected %s, got %v", tc.expected, loc)
+			}
+			// Toronto is on standard time in January
+			if _, offset := time.Date(2025, 1, 15, 12, 0, 0, 0, loc).Zone(); offset != tc.offset {
+				t.Errorf("Expected offset %d, got %d", tc.offset, offset)
+			}
+		})
+	}
+
+	settings := SiteSettings{TimezoneString: "Mars/Olympus_Mons"}
+	if settings.Location() != nil {
+		t.Error("Expected no location for an unknown timezone")
+	}
+	var invalid SiteSettings
+	if err := json.Unmarshal([]byte(`{"gmt_offset":"east"}`), &invalid); err == nil {
+		t.Error("Expected an error for an invalid offset")
+	}
+}
+
+func TestPageTimes(t *testing.T) {
+	originalTimezone := Timezone
+	Timezone = time.FixedZone("EDT", -4*3600)
+	defer func() { Timezone = originalTimezone }()
+
+	page := WordPressPage{Modified: "2024-05-15T06:30:45", ModifiedGMT: "2024-05-15T10:30:45", Date: "2024-05-01T09:00:00"}
+	if modified := page.ModifiedTime(); modified.Format(time.RFC3339) != "2024-05-15T06:30:45-04:00" {
+		t.Errorf("Expected the modified time in the site's timezone, got %v", modified)
+	}
+	if published := page.PublishedTime(); published.Format(time.RFC3339) != "2024-05-01T09:00:00-04:00" {
+		t.Errorf("Expected the local publication time in the site's timezone, got %v", published)
+	}
+	if missing := (&WordPressPage{Modified: "yesterday"}).ModifiedTime(); !missing.IsZero() {
+		t.Errorf("Expected the zero time for an invalid timestamp, got %v", missing)
+	}
+}
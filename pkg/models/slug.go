@@ -0,0 +1,62 @@
+package models
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DecodeSlug returns a slug as visitors read it.  WordPress stores the
+// non-ASCII characters of slugs, common in French titles, percent-encoded,
+// so "été" is stored as "%c3%a9t%c3%a9".  A slug that is not validly
+// encoded is returned unchanged.
+func DecodeSlug(slug string) string {
+	if !strings.Contains(slug, "%") {
+		return slug
+	}
+	decoded, err := url.PathUnescape(slug)
+	if err != nil || !utf8.ValidString(decoded) {
+		return slug
+	}
+	return decoded
+}
+
+// EncodeSlug returns a slug as WordPress stores it: its non-ASCII bytes
+// percent-encoded in lowercase, and the rest unchanged.
+func EncodeSlug(slug string) string {
+	const hex = "0123456789abcdef"
+	var b strings.Builder
+	for i := 0; i < len(slug); i++ {
+		if c := slug[i]; c >= utf8.RuneSelf {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// SameSlug reports whether two slugs are the same, whether or not either is
+// percent-encoded.
+func SameSlug(a string, b string) bool {
+	return DecodeSlug(a) == DecodeSlug(b)
+}
+
+// MaxSlugLength is the length of the longest slug WordPress stores, once
+// percent-encoded.
+const MaxSlugLength = 200
+
+// slugPattern matches the slugs WordPress generates: lowercase letters,
+// including accented and other letters without case, their combining
+// marks, digits, hyphens and underscores.
+var slugPattern = regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{Mn}0-9_-]+$`)
+
+// ValidSlug reports whether a decoded slug can name a page or post, so that
+// paths that cannot, such as those of scanners, are rejected without
+// querying WordPress.
+func ValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug) && len(EncodeSlug(slug)) <= MaxSlugLength
+}
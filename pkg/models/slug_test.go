@@ -0,0 +1,65 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugEncoding(t *testing.T) {
+	testCases := []struct {
+		name    string
+		slug    string
+		decoded string
+		encoded string
+	}{
+		{name: "ASCII", slug: "a-propos", decoded: "a-propos", encoded: "a-propos"},
+		{name: "Encoded", slug: "%c3%a9t%c3%a9", decoded: "été", encoded: "%c3%a9t%c3%a9"},
+		{name: "Uppercase escapes", slug: "%C3%A9t%C3%A9", decoded: "été", encoded: "%c3%a9t%c3%a9"},
+		{name: "Decoded", slug: "été", decoded: "été", encoded: "%c3%a9t%c3%a9"},
+		{name: "Invalid escape", slug: "100%", decoded: "100%", encoded: "100%"},
+		{name: "Invalid UTF-8", slug: "caf%e9", decoded: "caf%e9", encoded: "caf%e9"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DecodeSlug(tc.slug); got != tc.decoded {
+				t.Errorf("DecodeSlug(%q): expected %q, got %q", tc.slug, tc.decoded, got)
+			}
+			if got := EncodeSlug(DecodeSlug(tc.slug)); got != tc.encoded {
+				t.Errorf("EncodeSlug(%q): expected %q, got %q", tc.decoded, tc.encoded, got)
+			}
+		})
+	}
+
+	if !SameSlug("%c3%a9t%c3%a9", "été") || SameSlug("ete", "été") {
+		t.Error("Expected slugs to compare decoded")
+	}
+}
+
+func TestValidSlug(t *testing.T) {
+	for slug, expected := range map[string]bool{
+		"a-propos":                             true,
+		"été":                                  true,
+		"2":                                    true,
+		"":                                     false,
+		"a/b":                                  false,
+		"100%":                                 false,
+		"<script>":                             false,
+		"tab\tslug":                            false,
+		"caf\xe9":                              false,
+		"question?":                            false,
+		"l'été":                                false,
+		"under_score":                          true,
+		"About-Us":                             false,
+		"a+b":                                  false,
+		"日本":                                   true,
+		strings.Repeat("a", MaxSlugLength):     true,
+		strings.Repeat("a", MaxSlugLength+1):   false,
+		strings.Repeat("é", MaxSlugLength/6):   true,
+		strings.Repeat("é", MaxSlugLength/6+1): false,
+	} {
+		if got := ValidSlug(slug); got != expected {
+			t.Errorf("ValidSlug(%q): expected %v, got %v", slug, expected, got)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"html"
+	"net/url"
+	"time"
+)
+
+// schemaContext is the JSON-LD context of schema.org types.
+const schemaContext = "https://schema.org"
+
+// Organization is the organization publishing the site, described in the
+// structured data of pages as a schema.org GovernmentOrganization.
+type Organization struct {
+	Name string
+	URL  string
+	Logo string
+
+	// SameAs are the URLs of the organization's other profiles, such as
+	// its social media accounts.
+	SameAs []string
+}
+
+// StructuredData returns the schema.org JSON-LD graph of the page: a
+// WebPage, or an Article for published posts, with its BreadcrumbList and
+// the Organization publishing it, if set.  Nodes refer to each other by
+// @id, so the graph is rendered into a single ld+json script.  It returns
+// nil if the page has no canonical URL, since nodes are identified by it.
+func (d PageData) StructuredData() map[string]any {
+	if d.Canonical == "" {
+		return nil
+	}
+	base, err := url.Parse(d.Canonical)
+	if err != nil {
+		return nil
+	}
+	home := base.ResolveReference(&url.URL{Path: d.Home})
+
+	title := html.UnescapeString(string(d.Title))
+	page := map[string]any{
+		"@type":      "WebPage",
+		"@id":        d.Canonical + "#webpage",
+		"url":        d.Canonical,
+		"name":       title,
+		"inLanguage": d.Lang,
+		"isPartOf": map[string]any{
+			"@type": "WebSite",
+			"@id":   home.String() + "#website",
+			"url":   home.String(),
+			"name":  d.SiteName,
+		},
+	}
+	if !d.Published.IsZero() {
+		page["@type"] = "Article"
+		page["headline"] = title
+		page["datePublished"] = d.Published.Format(time.RFC3339)
+	}
+	if !d.Modified.IsZero() {
+		page["dateModified"] = d.Modified.Format(time.RFC3339)
+	}
+	if description := d.MetaDescription(); description != "" {
+		page["description"] = description
+	}
+	graph := []any{page}
+
+	if len(d.Breadcrumbs) > 0 {
+		items := []any{listItem(1, d.SiteName, home.String())}
+		for _, crumb := range d.Breadcrumbs {
+			ref, err := url.Parse(crumb.Url)
+			if err != nil {
+				continue
+			}
+			items = append(items, listItem(len(items)+1, crumb.Title, base.ResolveReference(ref).String()))
+		}
+		items = append(items, listItem(len(items)+1, title, d.Canonical))
+		page["breadcrumb"] = map[string]any{"@id": d.Canonical + "#breadcrumb"}
+		graph = append(graph, map[string]any{
+			"@type":           "BreadcrumbList",
+			"@id":             d.Canonical + "#breadcrumb",
+			"itemListElement": items,
+		})
+	}
+
+	if org := d.Organization; org != nil {
+		id := home.String() + "#organization"
+		node := map[string]any{
+			"@type": "GovernmentOrganization",
+			"@id":   id,
+			"name":  org.Name,
+		}
+		if org.URL != "" {
+			node["url"] = org.URL
+		}
+		if org.Logo != "" {
+			node["logo"] = org.Logo
+		}
+		if len(org.SameAs) > 0 {
+			node["sameAs"] = org.SameAs
+		}
+		page["publisher"] = map[string]any{"@id": id}
+		graph = append(graph, node)
+	}
+
+	return map[string]any{
+		"@context": schemaContext,
+		"@graph":   graph,
+	}
+}
+
+// listItem returns a schema.org ListItem of a BreadcrumbList.
+func listItem(position int, name string, item string) map[string]any {
+	return map[string]any{
+		"@type":    "ListItem",
+		"position": position,
+		"name":     name,
+		"item":     item,
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStructuredData(t *testing.T) {
+	data := PageData{
+		Lang:        "en",
+		Home:        "/",
+		Title:       "Fish &amp; chips",
+		SiteName:    "Example Site",
+		Canonical:   "https://example.com/services/fish",
+		Breadcrumbs: []Crumb{{Title: "Services", Url: "/services"}},
+		Modified:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Organization: &Organization{
+			Name:   "Example Agency",
+			URL:    "https://www.canada.ca/en/example.html",
+			SameAs: []string{"https://x.com/example"},
+		},
+	}
+
+	out, err := json.Marshal(data.StructuredData())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, expected := range []string{
+		`"@context":"https://schema.org"`,
+		`"@type":"WebPage"`,
+		`"name":"Fish \u0026 chips"`,
+		`"dateModified":"2024-05-01T12:00:00Z"`,
+		`"isPartOf":{"@id":"https://example.com/#website","@type":"WebSite","name":"Example Site","url":"https://example.com/"}`,
+		`"@type":"BreadcrumbList"`,
+		`{"@type":"ListItem","item":"https://example.com/services","name":"Services","position":2}`,
+		`{"@type":"ListItem","item":"https://example.com/services/fish","name":"Fish \u0026 chips","position":3}`,
+		`"@type":"GovernmentOrganization"`,
+		`"publisher":{"@id":"https://example.com/#organization"}`,
+		`"sameAs":["https://x.com/example"]`,
+	} {
+		if !strings.Contains(string(out), expected) {
+			t.Errorf("Expected structured data to contain %s, got: %s", expected, out)
+		}
+	}
+
+	// Posts are articles, and pages without breadcrumbs or organization
+	// leave them out
+	data.Published = time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	data.Breadcrumbs = nil
+	data.Organization = nil
+	out, err = json.Marshal(data.StructuredData())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(string(out), `"@type":"Article"`) || !strings.Contains(string(out), `"datePublished":"2024-04-01T09:00:00Z"`) {
+		t.Errorf("Expected an article, got: %s", out)
+	}
+	if strings.Contains(string(out), "BreadcrumbList") || strings.Contains(string(out), "publisher") {
+		t.Errorf("Expected no breadcrumbs or publisher, got: %s", out)
+	}
+
+	data.Canonical = ""
+	if data.StructuredData() != nil {
+		t.Error("Expected no structured data without a canonical URL")
+	}
+}
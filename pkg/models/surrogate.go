@@ -0,0 +1,28 @@
+package models
+
+import "strconv"
+
+// SurrogateKeyPageType is the type surrogate key of WordPress pages, and
+// SurrogateKeyPostType that of blog posts.
+const (
+	SurrogateKeyPageType = "page"
+	SurrogateKeyPostType = "post"
+)
+
+// PageSurrogateKey returns the surrogate key of responses rendering a page
+// or post, by its WordPress ID, which is purged when it changes.
+func PageSurrogateKey(id int) string {
+	return "page-" + strconv.Itoa(id)
+}
+
+// TypeSurrogateKey returns the surrogate key of responses rendering
+// content of a post type, such as its posts, archive and feed.
+func TypeSurrogateKey(postType string) string {
+	return "type-" + postType
+}
+
+// LangSurrogateKey returns the surrogate key of responses in a language,
+// which all render its menu.
+func LangSurrogateKey(lang string) string {
+	return "lang-" + lang
+}
@@ -1,22 +1,52 @@
 package models
 
 import (
+	"encoding/json"
+	"html"
 	"html/template"
 	"log"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 )
 
 // WordPressPage represents a WordPress page JSON response.
 type WordPressPage struct {
 	ID       int    `json:"id"`
+	Parent   int    `json:"parent"`
 	Slug     string `json:"slug"`
 	SlugEn   string `json:"slug_en"`
 	SlugFr   string `json:"slug_fr"`
 	Lang     string `json:"lang"`
 	Modified string `json:"modified"`
-	Content  struct {
-		Rendered string `json:"rendered"`
-		Raw      string `json:"raw,omitempty"`
+
+	// ModifiedGMT is the modified time in UTC, used to revalidate cached
+	// pages.  Modified is in the site's timezone.
+	ModifiedGMT string `json:"modified_gmt,omitempty"`
+
+	// Date and DateGMT are the time the page was published, in the site's
+	// timezone and in UTC.
+	Date    string `json:"date,omitempty"`
+	DateGMT string `json:"date_gmt,omitempty"`
+
+	// Status is the publication status of the page, such as "publish",
+	// or "private" for pages only signed in users may read.
+	Status string `json:"status,omitempty"`
+
+	// CommentStatus is "open" if visitors may comment on the page.
+	CommentStatus string `json:"comment_status,omitempty"`
+
+	// Template is the page template editors chose for the page, such as
+	// "full-width" or "page-templates/landing.php", or empty for the
+	// default template.
+	Template string `json:"template,omitempty"`
+
+	Content struct {
+		Rendered  string `json:"rendered"`
+		Raw       string `json:"raw,omitempty"`
+		Protected bool   `json:"protected,omitempty"`
 	} `json:"content"`
 	Title struct {
 		Rendered string `json:"rendered"`
@@ -26,6 +56,184 @@ type WordPressPage struct {
 	} `json:"excerpt,omitempty"`
 	FeaturedMedia int   `json:"featured_media,omitempty"`
 	Categories    []int `json:"categories,omitempty"`
+
+	// YoastHead holds the SEO title and description of the page when Yoast
+	// SEO is installed.
+	YoastHead *YoastHead `json:"yoast_head_json,omitempty"`
+
+	// Slugs holds the slug of each translation keyed by language code,
+	// read from the slug_<code> fields of the response.
+	Slugs map[string]string `json:"-"`
+
+	// Meta holds the registered meta fields of the page.  WordPress sends
+	// an empty array when there are none, so it is decoded by hand.
+	Meta map[string]any `json:"-"`
+
+	// ACF holds the Advanced Custom Fields of the page, sent when the
+	// field group is shown in the REST API.  ACF sends an empty array or
+	// false when there are none, so it is decoded by hand like Meta.
+	ACF map[string]any `json:"-"`
+}
+
+// UnmarshalJSON decodes a page, collecting the slug_<code> translation
+// fields into Slugs and the meta and acf objects into Meta and ACF.
+func (p *WordPressPage) UnmarshalJSON(data []byte) error {
+	type page WordPressPage
+	if err := json.Unmarshal(data, (*page)(p)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	p.Slugs = nil
+	p.Meta = decodeObject(fields["meta"])
+	p.ACF = decodeObject(fields["acf"])
+	for name, value := range fields {
+		code, ok := strings.CutPrefix(name, "slug_")
+		if !ok {
+			continue
+		}
+		var slug string
+		if err := json.Unmarshal(value, &slug); err != nil || slug == "" {
+			continue
+		}
+		if p.Slugs == nil {
+			p.Slugs = make(map[string]string)
+		}
+		p.Slugs[code] = slug
+	}
+	return nil
+}
+
+// ModifiedTime returns the time the page was last modified in the site's
+// Timezone, or the zero time if it is unknown.
+func (p *WordPressPage) ModifiedTime() time.Time {
+	return parseSiteTime(p.Modified, p.ModifiedGMT)
+}
+
+// PublishedTime returns the time the page was published in the site's
+// Timezone, or the zero time if it is unknown.
+func (p *WordPressPage) PublishedTime() time.Time {
+	return parseSiteTime(p.Date, p.DateGMT)
+}
+
+// templateName matches the names of page templates.
+var templateName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// TemplateName returns the name of the page's template without its
+// directory and extension, such as "landing" for
+// "page-templates/landing.php", or an empty string for the default
+// template or a name that cannot name a template file.
+func (p *WordPressPage) TemplateName() string {
+	if p.Template == "" {
+		return ""
+	}
+	name := strings.ToLower(strings.TrimSuffix(path.Base(p.Template), path.Ext(p.Template)))
+	if name == "default" || !templateName.MatchString(name) {
+		return ""
+	}
+	return name
+}
+
+// decodeObject decodes a JSON object, returning nil for anything else or
+// an empty object.
+func decodeObject(value json.RawMessage) map[string]any {
+	var object map[string]any
+	if err := json.Unmarshal(value, &object); err != nil || len(object) == 0 {
+		return nil
+	}
+	return object
+}
+
+// MarshalJSON encodes a page with its translation slugs as slug_<code>
+// fields and its meta and custom fields, so that it decodes back to the
+// same page.
+func (p WordPressPage) MarshalJSON() ([]byte, error) {
+	type page WordPressPage
+	data, err := json.Marshal(page(p))
+	if err != nil || (len(p.Slugs) == 0 && len(p.Meta) == 0 && len(p.ACF) == 0) {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, object := range map[string]map[string]any{"meta": p.Meta, "acf": p.ACF} {
+		if len(object) == 0 {
+			continue
+		}
+		value, err := json.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+	for code, slug := range p.Slugs {
+		value, err := json.Marshal(slug)
+		if err != nil {
+			return nil, err
+		}
+		fields["slug_"+code] = value
+	}
+	return json.Marshal(fields)
+}
+
+// Locked reports whether the page is password protected and WordPress
+// withheld its content because the password was not given.
+func (p *WordPressPage) Locked() bool {
+	return p.Content.Protected && p.Content.Rendered == ""
+}
+
+// Private reports whether the page is private, so that only signed in
+// visitors may read it.
+func (p *WordPressPage) Private() bool {
+	return p.Status == "private"
+}
+
+// Viewable reports whether a visitor may read the page: published pages
+// are public, private pages need the visitor signed in, and drafts, other
+// unpublished pages and pages without a status are never shown.
+func (p *WordPressPage) Viewable(signedIn bool) bool {
+	switch p.Status {
+	case "publish":
+		return true
+	case "private":
+		return signedIn
+	}
+	return false
+}
+
+// Fields returns the custom fields of the page: its meta fields, overridden
+// by its Advanced Custom Fields of the same name.
+func (p *WordPressPage) Fields() Fields {
+	if len(p.Meta) == 0 && len(p.ACF) == 0 {
+		return nil
+	}
+	fields := make(Fields, len(p.Meta)+len(p.ACF))
+	for name, value := range p.Meta {
+		fields[name] = value
+	}
+	for name, value := range p.ACF {
+		fields[name] = value
+	}
+	return fields
+}
+
+// TranslationSlug returns the slug of the page's translation in a language.
+func (p *WordPressPage) TranslationSlug(lang string) string {
+	if slug, ok := p.Slugs[lang]; ok {
+		return slug
+	}
+	switch lang {
+	case "en":
+		return p.SlugEn
+	case "fr":
+		return p.SlugFr
+	}
+	return ""
 }
 
 // WordPressMenuItem represents a WordPress menu item JSON response.
@@ -34,30 +242,186 @@ type WordPressMenuItem struct {
 	Title struct {
 		Rendered string `json:"rendered"`
 	} `json:"title"`
-	Parent int    `json:"parent"`
-	Url    string `json:"url"`
+	Parent      int      `json:"parent"`
+	Url         string   `json:"url"`
+	MenuOrder   int      `json:"menu_order"`
+	AttrTitle   string   `json:"attr_title"`
+	Description string   `json:"description"`
+	Target      string   `json:"target"`
+	Classes     []string `json:"classes"`
 }
 
+// How the language toggle of an untranslated page is rendered: hidden, or
+// linked to the home page of the other language.
+const (
+	MissingTranslationHide = "hide"
+	MissingTranslationHome = "home"
+)
+
 // PageData holds the data needed to render a page.
 type PageData struct {
-	Lang           string
-	LangSwapPath   string
-	LangSwapSlug   string
+	Lang         string
+	LangSwapPath string
+	LangSwapSlug string
+
+	// HasTranslation reports whether the language toggle links to a
+	// translation of the page.  Without one, the toggle links to
+	// LangSwapFallback, or is hidden if it is empty.
+	HasTranslation   bool
+	LangSwapFallback string
+
+	// Path is the path the page is served at, which menu items linking
+	// to it are marked current by.
+	Path string
+
 	Home           string
-	Modified       string
+	SearchPath     string
 	Title          template.HTML
 	Content        template.HTML
 	ShowBreadcrumb bool
 	SiteName       string
 	Menu           *MenuData
+	Canonical      string
+	Alternates     []AlternateLink
+	Breadcrumbs    []Crumb
+	SectionNav     []NavLink
+	Fields         Fields
+	Nonce          string
+
+	// Modified is the time the page was last modified, and Published the
+	// time a post was published.  Either is the zero time if unknown or
+	// not shown.
+	Modified  time.Time
+	Published time.Time
+
+	// WordCount is the number of words in the content and ReadingTime the
+	// estimated minutes it takes to read, over all of its pages.
+	WordCount   int
+	ReadingTime int
+
+	// Tagline is the site's tagline in the page language, used as its
+	// description when it has none.
+	Tagline string
+
+	// SEOTitle and Description are the title and description of the page
+	// for search engines, see WordPressPage.SEOTitle and MetaDescription.
+	// The page title and Tagline are used when they are empty.
+	SEOTitle    string
+	Description string
+
+	// NoIndex keeps the page out of search engines, see
+	// WordPressPage.NoIndex.
+	NoIndex bool
+
+	// SiteIcons links the site's generated icons and web app manifest in
+	// place of the theme's default icon.
+	SiteIcons bool
+
+	// Assets are the configured stylesheets and scripts loaded after the
+	// theme's.
+	Assets Assets
+
+	// Organization is the organization publishing the site, described in
+	// the page's structured data, or nil if it is not configured.
+	Organization *Organization
+
+	// Analytics is the analytics the page loads, or nil if it is not
+	// configured or the visitor did not consent to it.
+	Analytics *Analytics
+
+	// Feedback is the widget asking whether the page was helpful, or nil
+	// if it is not shown.
+	Feedback *FeedbackData
+
+	// Alerts are the site-wide alerts shown at the top of the page.
+	Alerts []Alert
+
+	// ColorScheme is the color scheme the page is rendered in, or nil if
+	// visitors cannot choose one.
+	ColorScheme *ColorSchemeData
+
+	// Template is the name of the page template editors chose for the
+	// page, which selects the page-<name>.html layout of the theme if it
+	// has one.
+	Template string
+
+	// Experiments holds the variant of each running experiment the
+	// visitor is assigned to, keyed by experiment name.
+	Experiments map[string]string
+
+	// Pagination of content split with <!--nextpage-->.  PageCount is
+	// zero for content that is not paginated.
+	PageNumber int
+	PageCount  int
+	PrevUrl    string
+	NextUrl    string
+}
+
+// PasswordFormData holds the data needed to render the password prompt of
+// a protected page.  Action is the path the form is posted to.
+type PasswordFormData struct {
+	Lang      string
+	Action    string
+	Incorrect bool
 }
 
-// MenuItemData holds the data needed to render a menu item.
+// ErrorPageData holds the data needed to render the error page shown when
+// a page fails to render.
+type ErrorPageData struct {
+	Lang      string
+	Home      string
+	Status    int
+	Nonce     string
+	SiteIcons bool
+}
+
+// Assets are the stylesheets and scripts loaded on every page in addition
+// to the theme's, such as analytics.  AnalyticsID is the ID of the
+// environment's analytics property, which scripts are given in their
+// data-analytics-id attribute.  DarkStylesheets and ContrastStylesheets are
+// loaded after Stylesheets for visitors in the dark and high contrast color
+// schemes.
+type Assets struct {
+	Stylesheets         []string
+	Scripts             []string
+	AnalyticsID         string
+	DarkStylesheets     []string
+	ContrastStylesheets []string
+}
+
+// Analytics is the analytics property pages load the script of.  Provider
+// is the name of one of analytics.Providers, such as "google".
+type Analytics struct {
+	Provider string
+	ID       string
+}
+
+// Crumb is a link to an ancestor page in the breadcrumb trail.
+type Crumb struct {
+	Title string
+	Url   string
+}
+
+// AlternateLink is a translation of a page, rendered as an hreflang link.
+type AlternateLink struct {
+	Lang string
+	Href string
+}
+
+// MenuItemData holds the data needed to render a menu item.  Depth is 0
+// for top level items and increases by one for each level of nesting.
+// AttrTitle, Description, Target and Class are set in the WordPress menu
+// editor for richer navigation such as mega-menus.
 type MenuItemData struct {
-	ID       int
-	Title    string
-	Url      string
-	Children []*MenuItemData
+	ID          int
+	Title       string
+	Url         string
+	AttrTitle   string
+	Description string
+	Target      string
+	Class       string
+	Depth       int
+	Children    []*MenuItemData
 }
 
 // MenuData holds the data needed to render a menu.
@@ -65,64 +429,268 @@ type MenuData struct {
 	Items []*MenuItemData
 }
 
+// NavItem is a menu item rendered on a page.  It carries the title, path
+// and language of the page so that nested menu templates can mark the
+// current page, and links in other languages, at any depth.
+type NavItem struct {
+	*MenuItemData
+	PageTitle template.HTML
+	PagePath  string
+	PageLang  string
+}
+
+// NavChildren returns the children of a menu item rendered on the same page.
+func (n NavItem) NavChildren() []NavItem {
+	return n.page().navItems(n.Children)
+}
+
+// Current reports whether the menu item links to the page being rendered,
+// by path if the page's is known and by title otherwise.
+func (n NavItem) Current() bool {
+	if n.PagePath == "" {
+		return n.Title == string(n.PageTitle)
+	}
+	path, ok := navPath(n.Url)
+	current, _ := navPath(n.PagePath)
+	return ok && path == current
+}
+
+// page returns the page a menu item is rendered on.
+func (n NavItem) page() PageData {
+	return PageData{Title: n.PageTitle, Path: n.PagePath, Lang: n.PageLang}
+}
+
+// NavItems returns the top level items of the page's menu.
+func (p PageData) NavItems() []NavItem {
+	if p.Menu == nil {
+		return nil
+	}
+	return p.navItems(p.Menu.Items)
+}
+
+// navItems wraps menu items with the page they are rendered on.
+func (p PageData) navItems(items []*MenuItemData) []NavItem {
+	nav := make([]NavItem, len(items))
+	for i, item := range items {
+		nav[i] = NavItem{MenuItemData: item, PageTitle: p.Title, PagePath: p.Path, PageLang: p.Lang}
+	}
+	return nav
+}
+
 // NewPageData creates a new PageData object that can then be used to render a page.
-func NewPageData(page *WordPressPage, menu *MenuData, siteNames map[string]string, baseUrl string) PageData {
-	lang := page.Lang
-	if lang != "en" && lang != "fr" {
-		lang = "en"
-		log.Printf("Warning: Invalid language '%s', defaulting to 'en'", page.Lang)
-	}
-
-	langPaths := map[string]struct {
-		swap string
-		slug string
-		home string
-	}{
-		"en": {"/fr/", page.SlugFr, "/"},
-		"fr": {"/", page.SlugEn, "/fr/"},
-	}
-
-	return PageData{
-		Lang:           lang,
-		LangSwapPath:   langPaths[lang].swap,
-		LangSwapSlug:   langPaths[lang].slug,
-		Home:           langPaths[lang].home,
-		Modified:       strings.Split(page.Modified, "T")[0],
+// Content is the rendered content of the page as returned by WordPress.
+func NewPageData(page *WordPressPage, menu *MenuData, siteNames map[string]string) PageData {
+	lang, ok := Languages.Get(page.Lang)
+	if !ok {
+		lang = Languages.Default()
+		log.Printf("Warning: Invalid language '%s', defaulting to '%s'", page.Lang, lang.Code)
+	}
+
+	data := PageData{
+		Lang:           lang.Code,
+		Home:           Languages.Prefix(lang.Code),
+		SearchPath:     lang.SearchPath,
+		Modified:       page.ModifiedTime(),
 		Title:          template.HTML(page.Title.Rendered),
-		Content:        template.HTML(strings.ReplaceAll(page.Content.Rendered, baseUrl, "")),
-		ShowBreadcrumb: !strings.Contains(page.Slug, "home"),
-		SiteName:       siteNames[lang],
+		Content:        template.HTML(page.Content.Rendered),
+		ShowBreadcrumb: !Languages.IsHomeSlug(page.Slug),
+		SiteName:       siteNames[lang.Code],
 		Menu:           menu,
+		Fields:         page.Fields(),
+		SEOTitle:       page.SEOTitle(),
+		Description:    page.MetaDescription(),
+		NoIndex:        page.NoIndex(),
+		Template:       page.TemplateName(),
 	}
+	data.WordCount = CountWords(page.Content.Rendered)
+	data.ReadingTime = ReadingTime(data.WordCount)
+	if swap, ok := Languages.Swap(lang.Code); ok {
+		data.LangSwapPath = Languages.Prefix(swap.Code)
+		data.LangSwapSlug = page.TranslationSlug(swap.Code)
+		data.HasTranslation = data.LangSwapSlug != ""
+	}
+	return data
+}
+
+// LangSwapHref returns the link of the language toggle, which is empty if
+// the toggle is hidden.
+func (d PageData) LangSwapHref() string {
+	if d.HasTranslation {
+		return d.LangSwapPath + d.LangSwapSlug
+	}
+	return d.LangSwapFallback
+}
+
+// Variant returns the visitor's variant of an experiment, or an empty
+// string if the experiment is not running, so templates can render
+// treatments with {{if eq (.Variant "hero") "short"}}.
+func (d PageData) Variant(experiment string) string {
+	return d.Experiments[experiment]
+}
+
+// MetaDescription returns the description of the page for search engines,
+// or the site's tagline if it has none.
+func (d PageData) MetaDescription() string {
+	if d.Description != "" {
+		return d.Description
+	}
+	return d.Tagline
+}
+
+// SetMissingTranslation sets how the language toggle is rendered when the
+// page has no translation, one of MissingTranslationHide or
+// MissingTranslationHome.
+func (d *PageData) SetMissingTranslation(mode string) {
+	d.LangSwapFallback = ""
+	if d.HasTranslation || mode != MissingTranslationHome {
+		return
+	}
+	if swap, ok := Languages.Swap(d.Lang); ok {
+		d.LangSwapFallback = Languages.Prefix(swap.Code)
+	}
+}
+
+// SetLinks sets the canonical URL and hreflang alternates of a page.
+// siteUrl is the public URL of the proxy, without a trailing slash.
+// Translations without a slug are not linked.
+func (d *PageData) SetLinks(page *WordPressPage, siteUrl string) {
+	d.setLinks(page, siteUrl, PagePath)
+}
+
+// SetPostLinks sets the canonical URL and hreflang alternates of a post of
+// a custom post type.
+func (d *PageData) SetPostLinks(postType PostType, post *WordPressPage, siteUrl string) {
+	d.setLinks(post, siteUrl, postType.PostPath)
+}
+
+// setLinks sets the canonical URL and alternates of a page using path to
+// build the path of each translation.
+func (d *PageData) setLinks(page *WordPressPage, siteUrl string, path func(lang string, slug string) string) {
+	d.Canonical = siteUrl + path(d.Lang, page.Slug)
+	d.Alternates = nil
+
+	for _, lang := range Languages {
+		slug := page.TranslationSlug(lang.Code)
+		if slug == "" {
+			continue
+		}
+		d.Alternates = append(d.Alternates, AlternateLink{
+			Lang: lang.Code,
+			Href: siteUrl + path(lang.Code, slug),
+		})
+	}
+	if len(d.Alternates) > 0 && d.Alternates[0].Lang == Languages.Default().Code {
+		d.Alternates = append(d.Alternates, AlternateLink{Lang: "x-default", Href: d.Alternates[0].Href})
+	}
+}
+
+// NewBreadcrumbs creates the breadcrumb trail from a page's ancestors,
+// ordered from the top of the hierarchy down.  The home page is skipped
+// since the layout always links to it first, as are ancestors that are not
+// published, whose titles are not public.
+func NewBreadcrumbs(lang string, ancestors []WordPressPage) []Crumb {
+	crumbs := make([]Crumb, 0, len(ancestors))
+	for _, ancestor := range ancestors {
+		if Languages.IsHomeSlug(ancestor.Slug) || !ancestor.Viewable(false) {
+			continue
+		}
+		crumbs = append(crumbs, Crumb{
+			Title: html.UnescapeString(ancestor.Title.Rendered),
+			Url:   PagePath(lang, ancestor.Slug),
+		})
+	}
+	return crumbs
+}
+
+// NavLink is a link to a child page in the section navigation.
+type NavLink struct {
+	Title string
+	Url   string
+}
+
+// NewSectionNav creates the section navigation from a page's children.
+func NewSectionNav(lang string, children []WordPressPage) []NavLink {
+	links := make([]NavLink, 0, len(children))
+	for _, child := range children {
+		links = append(links, NavLink{
+			Title: html.UnescapeString(child.Title.Rendered),
+			Url:   PagePath(lang, child.Slug),
+		})
+	}
+	return links
+}
+
+// PagePath returns the proxy path of a page given its language and slug.
+// The home pages are served from the root of each language.
+func PagePath(lang string, slug string) string {
+	prefix := Languages.Prefix(lang)
+	if Languages.IsHomeSlug(slug) {
+		return prefix
+	}
+	return prefix + slug
 }
 
 // NewMenuData creates a new MenuData object that can then be used to render a menu.
 // The menu items are expected to be in a flat list with parent/child relationships
-// represented by the Parent field.
+// represented by the Parent field.  Items are nested to any depth and sorted
+// by their menu order at each level.  Items whose parent is missing, or that
+// are their own ancestor, are placed at the top level so they are not lost.
 func NewMenuData(menuItems *[]WordPressMenuItem, baseUrl string) *MenuData {
+	items := make([]WordPressMenuItem, len(*menuItems))
+	copy(items, *menuItems)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].MenuOrder < items[j].MenuOrder
+	})
+
 	menuMap := make(map[int]*MenuItemData)
-	for _, item := range *menuItems {
+	parents := make(map[int]int)
+	for _, item := range items {
 		menuMap[item.ID] = &MenuItemData{
-			ID:       item.ID,
-			Title:    item.Title.Rendered,
-			Url:      strings.Replace(item.Url, baseUrl, "", 1),
-			Children: make([]*MenuItemData, 0),
+			ID:          item.ID,
+			Title:       item.Title.Rendered,
+			Url:         RelativeUrl(item.Url, baseUrl),
+			AttrTitle:   item.AttrTitle,
+			Description: item.Description,
+			Target:      item.Target,
+			Class:       strings.Join(strings.Fields(strings.Join(item.Classes, " ")), " "),
+			Children:    make([]*MenuItemData, 0),
 		}
+		parents[item.ID] = item.Parent
 	}
 
 	// Build up the menu tree of parent/child relationships
 	menuTree := make([]*MenuItemData, 0)
-	for _, item := range *menuItems {
-		if item.Parent != 0 {
-			if parent, ok := menuMap[item.Parent]; ok {
-				parent.Children = append(parent.Children, menuMap[item.ID])
-			}
-		} else {
+	for _, item := range items {
+		parent, ok := menuMap[item.Parent]
+		if item.Parent == 0 || !ok || menuCycle(parents, item.ID) {
 			menuTree = append(menuTree, menuMap[item.ID])
+			continue
 		}
+		parent.Children = append(parent.Children, menuMap[item.ID])
 	}
+	setMenuDepth(menuTree, 0)
 
 	return &MenuData{
 		Items: menuTree,
 	}
 }
+
+// menuCycle reports whether a menu item is its own ancestor.
+func menuCycle(parents map[int]int, id int) bool {
+	seen := map[int]bool{id: true}
+	for parent := parents[id]; parent != 0; parent = parents[parent] {
+		if seen[parent] {
+			return parent == id
+		}
+		seen[parent] = true
+	}
+	return false
+}
+
+// setMenuDepth sets the depth of menu items and their descendants.
+func setMenuDepth(items []*MenuItemData, depth int) {
+	for _, item := range items {
+		item.Depth = depth
+		setMenuDepth(item.Children, depth+1)
+	}
+}
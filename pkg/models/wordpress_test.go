@@ -1,8 +1,10 @@
 package models
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestNewPageData tests the NewPageData function which creates page rendering data
@@ -12,7 +14,6 @@ func TestNewPageData(t *testing.T) {
 		page         WordPressPage
 		menu         *MenuData
 		siteNames    map[string]string
-		baseUrl      string
 		expectedData PageData
 	}{
 		{
@@ -28,8 +29,9 @@ func TestNewPageData(t *testing.T) {
 					Rendered string `json:"rendered"`
 				}{Rendered: "About Us"},
 				Content: struct {
-					Rendered string `json:"rendered"`
-					Raw      string `json:"raw,omitempty"`
+					Rendered  string `json:"rendered"`
+					Raw       string `json:"raw,omitempty"`
+					Protected bool   `json:"protected,omitempty"`
 				}{Rendered: "<p>This is content with https://example.com/image.jpg</p>"},
 			},
 			menu: &MenuData{
@@ -39,15 +41,14 @@ func TestNewPageData(t *testing.T) {
 				"en": "English Site Name",
 				"fr": "French Site Name",
 			},
-			baseUrl: "https://example.com",
 			expectedData: PageData{
 				Lang:           "en",
 				LangSwapPath:   "/fr/",
 				LangSwapSlug:   "a-propos",
 				Home:           "/",
-				Modified:       "2023-05-15",
+				Modified:       time.Date(2023, 5, 15, 10, 30, 45, 0, time.UTC),
 				Title:          "About Us",
-				Content:        "<p>This is content with /image.jpg</p>",
+				Content:        "<p>This is content with https://example.com/image.jpg</p>",
 				ShowBreadcrumb: true,
 				SiteName:       "English Site Name",
 			},
@@ -65,8 +66,9 @@ func TestNewPageData(t *testing.T) {
 					Rendered string `json:"rendered"`
 				}{Rendered: "À propos"},
 				Content: struct {
-					Rendered string `json:"rendered"`
-					Raw      string `json:"raw,omitempty"`
+					Rendered  string `json:"rendered"`
+					Raw       string `json:"raw,omitempty"`
+					Protected bool   `json:"protected,omitempty"`
 				}{Rendered: "<p>C'est du contenu avec https://example.com/image.jpg</p>"},
 			},
 			menu: &MenuData{
@@ -76,15 +78,14 @@ func TestNewPageData(t *testing.T) {
 				"en": "English Site Name",
 				"fr": "French Site Name",
 			},
-			baseUrl: "https://example.com",
 			expectedData: PageData{
 				Lang:           "fr",
 				LangSwapPath:   "/",
 				LangSwapSlug:   "about",
 				Home:           "/fr/",
-				Modified:       "2023-05-15",
+				Modified:       time.Date(2023, 5, 15, 10, 30, 45, 0, time.UTC),
 				Title:          "À propos",
-				Content:        "<p>C'est du contenu avec /image.jpg</p>",
+				Content:        "<p>C'est du contenu avec https://example.com/image.jpg</p>",
 				ShowBreadcrumb: true,
 				SiteName:       "French Site Name",
 			},
@@ -102,8 +103,9 @@ func TestNewPageData(t *testing.T) {
 					Rendered string `json:"rendered"`
 				}{Rendered: "About Us"},
 				Content: struct {
-					Rendered string `json:"rendered"`
-					Raw      string `json:"raw,omitempty"`
+					Rendered  string `json:"rendered"`
+					Raw       string `json:"raw,omitempty"`
+					Protected bool   `json:"protected,omitempty"`
 				}{Rendered: "<p>Content</p>"},
 			},
 			menu: &MenuData{
@@ -113,13 +115,12 @@ func TestNewPageData(t *testing.T) {
 				"en": "English Site Name",
 				"fr": "French Site Name",
 			},
-			baseUrl: "https://example.com",
 			expectedData: PageData{
 				Lang:           "en",
 				LangSwapPath:   "/fr/",
 				LangSwapSlug:   "a-propos",
 				Home:           "/",
-				Modified:       "2023-05-15",
+				Modified:       time.Date(2023, 5, 15, 10, 30, 45, 0, time.UTC),
 				Title:          "About Us",
 				Content:        "<p>Content</p>",
 				ShowBreadcrumb: true,
@@ -139,8 +140,9 @@ func TestNewPageData(t *testing.T) {
 					Rendered string `json:"rendered"`
 				}{Rendered: "Home Page"},
 				Content: struct {
-					Rendered string `json:"rendered"`
-					Raw      string `json:"raw,omitempty"`
+					Rendered  string `json:"rendered"`
+					Raw       string `json:"raw,omitempty"`
+					Protected bool   `json:"protected,omitempty"`
 				}{Rendered: "<p>Welcome home</p>"},
 			},
 			menu: &MenuData{
@@ -150,13 +152,12 @@ func TestNewPageData(t *testing.T) {
 				"en": "English Site Name",
 				"fr": "French Site Name",
 			},
-			baseUrl: "https://example.com",
 			expectedData: PageData{
 				Lang:           "en",
 				LangSwapPath:   "/fr/",
 				LangSwapSlug:   "accueil",
 				Home:           "/",
-				Modified:       "2023-05-15",
+				Modified:       time.Date(2023, 5, 15, 10, 30, 45, 0, time.UTC),
 				Title:          "Home Page",
 				Content:        "<p>Welcome home</p>",
 				ShowBreadcrumb: false, // Home page, no breadcrumb
@@ -171,7 +172,7 @@ func TestNewPageData(t *testing.T) {
 			page := tc.page
 
 			// Call the function being tested
-			result := NewPageData(&page, tc.menu, tc.siteNames, tc.baseUrl)
+			result := NewPageData(&page, tc.menu, tc.siteNames)
 
 			// Verify results
 			if result.Lang != tc.expectedData.Lang {
@@ -190,8 +191,8 @@ func TestNewPageData(t *testing.T) {
 				t.Errorf("Expected Home %q, got %q", tc.expectedData.Home, result.Home)
 			}
 
-			if result.Modified != tc.expectedData.Modified {
-				t.Errorf("Expected Modified %q, got %q", tc.expectedData.Modified, result.Modified)
+			if !result.Modified.Equal(tc.expectedData.Modified) {
+				t.Errorf("Expected Modified %v, got %v", tc.expectedData.Modified, result.Modified)
 			}
 
 			if string(result.Title) != string(tc.expectedData.Title) {
@@ -218,6 +219,21 @@ func TestNewPageData(t *testing.T) {
 	}
 }
 
+// TestNewPageDataHomeSlug tests that breadcrumbs are hidden on the
+// configured home pages only
+func TestNewPageDataHomeSlug(t *testing.T) {
+	setLanguages(t, LanguageRegistry{
+		{Code: "fr", HomeSlug: "accueil", SearchPath: "/recherche"},
+	})
+
+	for slug, expected := range map[string]bool{"accueil": false, "homeowners": true, "home": true} {
+		page := WordPressPage{Slug: slug, Lang: "fr"}
+		if got := NewPageData(&page, &MenuData{}, nil).ShowBreadcrumb; got != expected {
+			t.Errorf("Expected ShowBreadcrumb %v for %q, got %v", expected, slug, got)
+		}
+	}
+}
+
 // TestNewMenuData tests the NewMenuData function which creates hierarchical menu data
 func TestNewMenuData(t *testing.T) {
 	testCases := []struct {
@@ -333,6 +349,49 @@ func TestNewMenuData(t *testing.T) {
 				"About": 0,
 			},
 		},
+		{
+			name: "Menu with multiple levels of nesting",
+			menuItems: []WordPressMenuItem{
+				{
+					ID: 4,
+					Title: struct {
+						Rendered string `json:"rendered"`
+					}{Rendered: "Product A1"},
+					Parent: 3,
+					Url:    "https://example.com/products/a/1",
+				},
+				{
+					ID: 1,
+					Title: struct {
+						Rendered string `json:"rendered"`
+					}{Rendered: "Home"},
+					Parent: 0,
+					Url:    "https://example.com/",
+				},
+				{
+					ID: 3,
+					Title: struct {
+						Rendered string `json:"rendered"`
+					}{Rendered: "Category A"},
+					Parent: 2,
+					Url:    "https://example.com/products/a",
+				},
+				{
+					ID: 2,
+					Title: struct {
+						Rendered string `json:"rendered"`
+					}{Rendered: "Products"},
+					Parent: 0,
+					Url:    "https://example.com/products",
+				},
+			},
+			baseUrl:          "https://example.com",
+			expectedTopItems: 2,
+			expectedChildren: map[string]int{
+				"Home":     0,
+				"Products": 1,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -404,3 +463,388 @@ func TestNewMenuData(t *testing.T) {
 		})
 	}
 }
+
+// TestNewMenuDataOrder tests that menu items are sorted by menu order at
+// every level and keep their menu editor attributes
+func TestNewMenuDataOrder(t *testing.T) {
+	var menuItems []WordPressMenuItem
+	err := json.Unmarshal([]byte(`[
+		{"id": 1, "title": {"rendered": "About"}, "parent": 0, "menu_order": 3, "url": "https://example.com/about"},
+		{"id": 2, "title": {"rendered": "Services"}, "parent": 0, "menu_order": 1, "url": "https://example.com/services",
+			"classes": ["mega", " ", "wide"], "description": "What we do"},
+		{"id": 3, "title": {"rendered": "Apply"}, "parent": 2, "menu_order": 5, "url": "https://example.com/services/apply"},
+		{"id": 4, "title": {"rendered": "Renew"}, "parent": 2, "menu_order": 4, "url": "https://example.com/services/renew"},
+		{"id": 5, "title": {"rendered": "Online"}, "parent": 4, "menu_order": 6, "url": "https://forms.example.net/",
+			"target": "_blank", "attr_title": "Opens in a new tab"},
+		{"id": 6, "title": {"rendered": "Orphan"}, "parent": 99, "menu_order": 2, "url": "https://example.com/orphan"},
+		{"id": 7, "title": {"rendered": "Loop A"}, "parent": 8, "menu_order": 7, "url": "https://example.com/a"},
+		{"id": 8, "title": {"rendered": "Loop B"}, "parent": 7, "menu_order": 8, "url": "https://example.com/b"}
+	]`), &menuItems)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	menu := NewMenuData(&menuItems, "https://example.com")
+
+	titles := func(items []*MenuItemData) string {
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Title)
+		}
+		return strings.Join(names, ",")
+	}
+	if got := titles(menu.Items); got != "Services,Orphan,About,Loop A,Loop B" {
+		t.Errorf("Expected top level items in menu order, got %s", got)
+	}
+
+	services := menu.Items[0]
+	if got := titles(services.Children); got != "Renew,Apply" {
+		t.Errorf("Expected children in menu order, got %s", got)
+	}
+	if services.Class != "mega wide" || services.Description != "What we do" {
+		t.Errorf("Expected class and description, got %q, %q", services.Class, services.Description)
+	}
+
+	online := services.Children[0].Children[0]
+	if online.Title != "Online" || online.Depth != 2 || services.Depth != 0 {
+		t.Errorf("Expected third level item at depth 2, got %+v", online)
+	}
+	if online.Target != "_blank" || online.AttrTitle != "Opens in a new tab" || online.Url != "https://forms.example.net/" {
+		t.Errorf("Expected link attributes, got %+v", online)
+	}
+
+	// The original list is not reordered
+	if menuItems[0].Title.Rendered != "About" {
+		t.Errorf("Expected menu items to be left in order, got %q first", menuItems[0].Title.Rendered)
+	}
+}
+
+// TestNavItems tests that nav items mark the current page at every depth
+func TestNavItems(t *testing.T) {
+	child := &MenuItemData{Title: "Renew", Children: []*MenuItemData{}}
+	page := PageData{
+		Title: "Renew",
+		Menu: &MenuData{Items: []*MenuItemData{
+			{Title: "Home", Children: []*MenuItemData{}},
+			{Title: "Services", Children: []*MenuItemData{child}},
+		}},
+	}
+
+	items := page.NavItems()
+	if len(items) != 2 || items[0].Current() {
+		t.Fatalf("Expected 2 top level items without a current item, got %+v", items)
+	}
+	children := items[1].NavChildren()
+	if len(children) != 1 || children[0].MenuItemData != child || !children[0].Current() {
+		t.Errorf("Expected current nested item, got %+v", children)
+	}
+
+	if items := (PageData{}).NavItems(); items != nil {
+		t.Errorf("Expected no nav items without a menu, got %+v", items)
+	}
+}
+
+// TestSetLinks tests the canonical and hreflang links of a page
+// TestLangSwapHref tests the language toggle of translated and
+// untranslated pages
+func TestLangSwapHref(t *testing.T) {
+	translated := NewPageData(&WordPressPage{Slug: "about", SlugFr: "a-propos", Lang: "en"}, nil, nil)
+	untranslated := NewPageData(&WordPressPage{Slug: "news", Lang: "en"}, nil, nil)
+	untranslatedFr := NewPageData(&WordPressPage{Slug: "nouvelles", Lang: "fr"}, nil, nil)
+
+	testCases := []struct {
+		name     string
+		data     PageData
+		mode     string
+		expected string
+	}{
+		{"Translated", translated, MissingTranslationHide, "/fr/a-propos"},
+		{"Translated with home fallback", translated, MissingTranslationHome, "/fr/a-propos"},
+		{"Untranslated hidden", untranslated, MissingTranslationHide, ""},
+		{"Untranslated without mode", untranslated, "", ""},
+		{"Untranslated to home", untranslated, MissingTranslationHome, "/fr/"},
+		{"Untranslated French to home", untranslatedFr, MissingTranslationHome, "/"},
+	}
+	for _, tc := range testCases {
+		tc.data.SetMissingTranslation(tc.mode)
+		if href := tc.data.LangSwapHref(); href != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, href)
+		}
+	}
+	if !translated.HasTranslation || untranslated.HasTranslation {
+		t.Errorf("Expected HasTranslation only for the translated page, got %v and %v", translated.HasTranslation, untranslated.HasTranslation)
+	}
+}
+
+func TestSetLinks(t *testing.T) {
+	testCases := []struct {
+		name               string
+		lang               string
+		page               WordPressPage
+		expectedCanonical  string
+		expectedAlternates []AlternateLink
+	}{
+		{
+			name:              "English page",
+			lang:              "en",
+			page:              WordPressPage{Slug: "about", SlugEn: "about", SlugFr: "a-propos"},
+			expectedCanonical: "https://www.example.ca/about",
+			expectedAlternates: []AlternateLink{
+				{Lang: "en", Href: "https://www.example.ca/about"},
+				{Lang: "fr", Href: "https://www.example.ca/fr/a-propos"},
+				{Lang: "x-default", Href: "https://www.example.ca/about"},
+			},
+		},
+		{
+			name:              "French home page",
+			lang:              "fr",
+			page:              WordPressPage{Slug: "home-fr", SlugEn: "home", SlugFr: "home-fr"},
+			expectedCanonical: "https://www.example.ca/fr/",
+			expectedAlternates: []AlternateLink{
+				{Lang: "en", Href: "https://www.example.ca/"},
+				{Lang: "fr", Href: "https://www.example.ca/fr/"},
+				{Lang: "x-default", Href: "https://www.example.ca/"},
+			},
+		},
+		{
+			name:              "Untranslated French page",
+			lang:              "fr",
+			page:              WordPressPage{Slug: "nouvelles", SlugFr: "nouvelles"},
+			expectedCanonical: "https://www.example.ca/fr/nouvelles",
+			expectedAlternates: []AlternateLink{
+				{Lang: "fr", Href: "https://www.example.ca/fr/nouvelles"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := PageData{Lang: tc.lang}
+			data.SetLinks(&tc.page, "https://www.example.ca")
+
+			if data.Canonical != tc.expectedCanonical {
+				t.Errorf("Expected canonical %q, got %q", tc.expectedCanonical, data.Canonical)
+			}
+			if len(data.Alternates) != len(tc.expectedAlternates) {
+				t.Fatalf("Expected alternates %v, got %v", tc.expectedAlternates, data.Alternates)
+			}
+			for i, expected := range tc.expectedAlternates {
+				if data.Alternates[i] != expected {
+					t.Errorf("Expected alternate %v, got %v", expected, data.Alternates[i])
+				}
+			}
+		})
+	}
+}
+
+// TestNewBreadcrumbs tests the breadcrumb trail built from page ancestors
+func TestNewBreadcrumbs(t *testing.T) {
+	ancestors := []WordPressPage{
+		{Slug: "home-fr", Status: "publish"},
+		{Slug: "services", Status: "publish"},
+		{Slug: "brouillon", Status: "draft"},
+		{Slug: "impots", Status: "publish"},
+	}
+	ancestors[1].Title.Rendered = "Services &amp; information"
+	ancestors[2].Title.Rendered = "Brouillon"
+	ancestors[3].Title.Rendered = "Impôts"
+
+	crumbs := NewBreadcrumbs("fr", ancestors)
+
+	expected := []Crumb{
+		{Title: "Services & information", Url: "/fr/services"},
+		{Title: "Impôts", Url: "/fr/impots"},
+	}
+	if len(crumbs) != len(expected) {
+		t.Fatalf("Expected crumbs %v, got %v", expected, crumbs)
+	}
+	for i, crumb := range expected {
+		if crumbs[i] != crumb {
+			t.Errorf("Expected crumb %v, got %v", crumb, crumbs[i])
+		}
+	}
+}
+
+// TestNewSectionNav tests the section navigation built from child pages
+func TestNewSectionNav(t *testing.T) {
+	children := []WordPressPage{{Slug: "benefits"}, {Slug: "taxes"}}
+	children[0].Title.Rendered = "Benefits &amp; credits"
+	children[1].Title.Rendered = "Taxes"
+
+	links := NewSectionNav("en", children)
+
+	expected := []NavLink{
+		{Title: "Benefits & credits", Url: "/benefits"},
+		{Title: "Taxes", Url: "/taxes"},
+	}
+	if len(links) != len(expected) {
+		t.Fatalf("Expected links %v, got %v", expected, links)
+	}
+	for i, link := range expected {
+		if links[i] != link {
+			t.Errorf("Expected link %v, got %v", link, links[i])
+		}
+	}
+}
+
+// TestWordPressPageUnmarshalJSON tests that translation slugs are collected
+func TestWordPressPageUnmarshalJSON(t *testing.T) {
+	var page WordPressPage
+	err := json.Unmarshal([]byte(`{"id":1,"slug":"about","slug_en":"about","slug_fr":"a-propos","slug_es":"acerca","slug_de":""}`), &page)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if page.ID != 1 || page.SlugFr != "a-propos" {
+		t.Errorf("Expected page fields to be decoded, got %+v", page)
+	}
+	expected := map[string]string{"en": "about", "fr": "a-propos", "es": "acerca"}
+	if len(page.Slugs) != len(expected) {
+		t.Errorf("Expected slugs %v, got %v", expected, page.Slugs)
+	}
+	for lang, slug := range expected {
+		if got := page.TranslationSlug(lang); got != slug {
+			t.Errorf("Expected %s slug %q, got %q", lang, slug, got)
+		}
+	}
+	if got := page.TranslationSlug("de"); got != "" {
+		t.Errorf("Expected no German slug, got %q", got)
+	}
+}
+
+// TestWordPressPageMarshalJSON tests that a page survives a JSON round trip
+func TestWordPressPageMarshalJSON(t *testing.T) {
+	page := WordPressPage{ID: 1, Slug: "about", SlugEn: "about", Slugs: map[string]string{"es": "acerca"}}
+	page.Content.Rendered = "<p>About</p>"
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(string(data), `"slug_es":"acerca"`) {
+		t.Errorf("Expected translation slug field, got %s", data)
+	}
+
+	var decoded WordPressPage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if decoded.ID != 1 || decoded.Content.Rendered != "<p>About</p>" || decoded.TranslationSlug("es") != "acerca" {
+		t.Errorf("Expected page to round trip, got %+v", decoded)
+	}
+}
+
+// TestWordPressPageMeta tests that meta objects are decoded and the empty
+// array WordPress sends without meta is ignored
+func TestWordPressPageMeta(t *testing.T) {
+	var page WordPressPage
+	if err := json.Unmarshal([]byte(`{"id":1,"meta":{"venue":"Ottawa"}}`), &page); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if page.Meta["venue"] != "Ottawa" {
+		t.Errorf("Expected venue meta, got %v", page.Meta)
+	}
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var decoded WordPressPage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if decoded.Meta["venue"] != "Ottawa" {
+		t.Errorf("Expected meta to round trip, got %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"id":2,"meta":[]}`), &page); err != nil {
+		t.Fatalf("Expected no error for empty meta, got %v", err)
+	}
+	if page.Meta != nil {
+		t.Errorf("Expected no meta, got %v", page.Meta)
+	}
+}
+
+// TestWordPressPageFields tests that ACF fields are decoded, cached and
+// merged over meta fields, and that the false ACF sends without fields is
+// ignored
+func TestWordPressPageFields(t *testing.T) {
+	var page WordPressPage
+	err := json.Unmarshal([]byte(`{"id":1,"meta":{"venue":"Ottawa","room":"A"},"acf":{"room":"B","alert":{"message":"Closed"}}}`), &page)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	fields := page.Fields()
+	if fields.Text("venue") != "Ottawa" || fields.Text("room") != "B" || fields.Text("alert.message") != "Closed" {
+		t.Errorf("Expected meta and ACF fields, got %v", fields)
+	}
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var decoded WordPressPage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if decoded.Fields().Text("alert.message") != "Closed" {
+		t.Errorf("Expected ACF fields to round trip, got %s", data)
+	}
+
+	pageData := NewPageData(&decoded, &MenuData{}, nil)
+	if pageData.Fields.Text("room") != "B" {
+		t.Errorf("Expected page data fields, got %v", pageData.Fields)
+	}
+
+	if err := json.Unmarshal([]byte(`{"id":2,"acf":false}`), &page); err != nil {
+		t.Fatalf("Expected no error for empty ACF, got %v", err)
+	}
+	if page.ACF != nil || page.Fields() != nil {
+		t.Errorf("Expected no fields, got %v", page.Fields())
+	}
+}
+
+func TestWordPressPageViewable(t *testing.T) {
+	tests := []struct {
+		status   string
+		signedIn bool
+		expected bool
+	}{
+		{"publish", false, true},
+		{"", false, false},
+		{"", true, false},
+		{"private", false, false},
+		{"private", true, true},
+		{"draft", true, false},
+		{"future", true, false},
+	}
+	for _, tt := range tests {
+		page := WordPressPage{Status: tt.status}
+		if got := page.Viewable(tt.signedIn); got != tt.expected {
+			t.Errorf("Expected %q viewable %v when signed in is %v, got %v", tt.status, tt.expected, tt.signedIn, got)
+		}
+	}
+}
+
+func TestWordPressPageTemplateName(t *testing.T) {
+	for template, expected := range map[string]string{
+		"":                                "",
+		"default":                         "",
+		"full-width":                      "full-width",
+		"page-templates/landing.php":      "landing",
+		"templates/Full_Width.php":        "full_width",
+		"../../etc/passwd":                "passwd",
+		"page-templates/landing page.php": "",
+	} {
+		page := WordPressPage{Template: template}
+		if got := page.TemplateName(); got != expected {
+			t.Errorf("TemplateName() of %q: expected %q, got %q", template, expected, got)
+		}
+	}
+
+	page := WordPressPage{Lang: "en", Template: "full-width"}
+	if got := NewPageData(&page, &MenuData{}, nil).Template; got != "full-width" {
+		t.Errorf("Expected page data with the full-width template, got %q", got)
+	}
+}
@@ -0,0 +1,26 @@
+// Load embedded content when the button of its placeholder is clicked.
+// Placeholders are rendered by the proxy in place of third party iframes
+// so that no request reaches the provider until the visitor asks for it.
+document.addEventListener('click', function (event) {
+  var button = event.target.closest('.embed-facade__load');
+  if (!button) {
+    return;
+  }
+
+  var facade = button.closest('.embed-facade');
+  var iframe = document.createElement('iframe');
+  iframe.src = facade.dataset.embedSrc;
+  iframe.title = facade.dataset.embedTitle || button.textContent;
+  if (facade.dataset.embedWidth) {
+    iframe.width = facade.dataset.embedWidth;
+  }
+  if (facade.dataset.embedHeight) {
+    iframe.height = facade.dataset.embedHeight;
+  }
+  if (facade.dataset.embedAllow) {
+    iframe.allow = facade.dataset.embedAllow;
+  }
+  iframe.allowFullscreen = true;
+  facade.replaceWith(iframe);
+  iframe.focus();
+});